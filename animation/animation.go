@@ -5,6 +5,10 @@
 // Package animation
 package animation
 
+import (
+	"github.com/thommil/tge-g3n/core"
+)
+
 // Animation is a keyframe animation, containing channels.
 // Each channel animates a specific property of an object.
 // Animations can span multiple objects and properties.
@@ -99,15 +103,37 @@ func (anim *Animation) SetStart(v float32) {
 // returns true if the input value is inside the key frames ranges or false otherwise.
 func (anim *Animation) Update(delta float32) {
 
+	if !anim.advance(delta) {
+		return
+	}
+
+	// Update all channels
+	for i := range anim.channels {
+		ch := anim.channels[i]
+		ch.Update(anim.time)
+	}
+}
+
+// UpdateClock updates the animation with the time the specified clock advanced at its last tick,
+// so that the animation is paused and scaled with the clock.
+func (anim *Animation) UpdateClock(clock *core.Clock) {
+
+	anim.Update(clock.Delta())
+}
+
+// advance advances the animation time by the specified delta multiplied by the speed.
+// Returns false if the animation is paused or the time is before the key frames ranges.
+func (anim *Animation) advance(delta float32) bool {
+
 	// Check if paused
 	if anim.paused {
-		return
+		return false
 	}
 
 	// Check if input is less than minimum
 	anim.time = anim.time + delta*anim.speed
 	if anim.time < anim.minTime {
-		return
+		return false
 	}
 
 	// Check if input is greater than maximum
@@ -119,12 +145,25 @@ func (anim *Animation) Update(delta float32) {
 			anim.SetPaused(true)
 		}
 	}
+	return true
+}
 
-	// Update all channels
-	for i := range anim.channels {
-		ch := anim.channels[i]
-		ch.Update(anim.time)
-	}
+// Time returns the current animation time.
+func (anim *Animation) Time() float32 {
+
+	return anim.time
+}
+
+// Duration returns the maximum time value across all channels.
+func (anim *Animation) Duration() float32 {
+
+	return anim.maxTime
+}
+
+// Channels returns the channels of the animation.
+func (anim *Animation) Channels() []IChannel {
+
+	return anim.channels
 }
 
 // AddChannel adds a channel to the animation.
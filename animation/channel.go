@@ -7,6 +7,7 @@ package animation
 import (
 	"github.com/thommil/tge-g3n/core"
 	"github.com/thommil/tge-g3n/geometry"
+	"github.com/thommil/tge-g3n/material"
 	"github.com/thommil/tge-g3n/math32"
 )
 
@@ -96,6 +97,38 @@ func (c *Channel) Update(time float32) {
 	c.interpAction(idx, relativeDelta)
 }
 
+// keyframeAt returns the index of the keyframe interval containing the specified time,
+// clamped to the keyframes range, and the relative position of the time in the interval.
+func (c *Channel) keyframeAt(time float32) (int, float32) {
+
+	last := len(c.keyframes) - 1
+	if last < 1 || time <= c.keyframes[0] {
+		return 0, 0
+	}
+	if time >= c.keyframes[last] {
+		return last - 1, 1
+	}
+	idx := 0
+	for idx < last-1 && time >= c.keyframes[idx+1] {
+		idx++
+	}
+	return idx, (time - c.keyframes[idx]) / (c.keyframes[idx+1] - c.keyframes[idx])
+}
+
+// sampleKeys returns the indices of the two keyframes and the interpolation factor
+// used to sample the channel at the specified time.
+func (c *Channel) sampleKeys(time float32) (int, int, float32) {
+
+	idx, k := c.keyframeAt(time)
+	if len(c.keyframes) < 2 {
+		return 0, 0, 0
+	}
+	if c.interpType == STEP {
+		return idx, idx, 0
+	}
+	return idx, idx + 1, k
+}
+
 // IChannel is the interface for all channel types.
 type IChannel interface {
 	Update(time float32)
@@ -151,6 +184,22 @@ func NewPositionChannel(node core.INode) *PositionChannel {
 	return pc
 }
 
+// Target returns the node animated by this channel.
+func (pc *PositionChannel) Target() core.INode {
+
+	return pc.target
+}
+
+// Sample returns the position of this channel at the specified time without updating the target.
+func (pc *PositionChannel) Sample(time float32) math32.Vector3 {
+
+	var v1, v2 math32.Vector3
+	i1, i2, k := pc.sampleKeys(time)
+	pc.values.GetVector3(i1*3, &v1)
+	pc.values.GetVector3(i2*3, &v2)
+	return *v1.Lerp(&v2, k)
+}
+
 // RotationChannel is the animation channel for a node's rotation.
 type RotationChannel NodeChannel
 
@@ -195,6 +244,24 @@ func NewRotationChannel(node core.INode) *RotationChannel {
 	return rc
 }
 
+// Target returns the node animated by this channel.
+func (rc *RotationChannel) Target() core.INode {
+
+	return rc.target
+}
+
+// Sample returns the rotation of this channel at the specified time without updating the target.
+func (rc *RotationChannel) Sample(time float32) math32.Quaternion {
+
+	var q1, q2 math32.Vector4
+	i1, i2, k := rc.sampleKeys(time)
+	rc.values.GetVector4(i1*4, &q1)
+	rc.values.GetVector4(i2*4, &q2)
+	quat1 := math32.NewQuaternion(q1.X, q1.Y, q1.Z, q1.W)
+	quat2 := math32.NewQuaternion(q2.X, q2.Y, q2.Z, q2.W)
+	return *quat1.Slerp(quat2, k)
+}
+
 // ScaleChannel is the animation channel for a node's scale.
 type ScaleChannel NodeChannel
 
@@ -235,6 +302,22 @@ func NewScaleChannel(node core.INode) *ScaleChannel {
 	return sc
 }
 
+// Target returns the node animated by this channel.
+func (sc *ScaleChannel) Target() core.INode {
+
+	return sc.target
+}
+
+// Sample returns the scale of this channel at the specified time without updating the target.
+func (sc *ScaleChannel) Sample(time float32) math32.Vector3 {
+
+	var v1, v2 math32.Vector3
+	i1, i2, k := sc.sampleKeys(time)
+	sc.values.GetVector3(i1*3, &v1)
+	sc.values.GetVector3(i2*3, &v2)
+	return *v1.Lerp(&v2, k)
+}
+
 // MorphChannel is the IChannel for morph geometries.
 type MorphChannel struct {
 	Channel
@@ -285,6 +368,101 @@ func NewMorphChannel(mg *geometry.MorphGeometry) *MorphChannel {
 	return mc
 }
 
+// Target returns the morph geometry animated by this channel.
+func (mc *MorphChannel) Target() *geometry.MorphGeometry {
+
+	return mc.target
+}
+
+// Sample stores the weights of this channel at the specified time in the specified
+// slice, which must have the number of weights of the target, without updating the target.
+func (mc *MorphChannel) Sample(time float32, weights []float32) {
+
+	n := len(weights)
+	i1, i2, k := mc.sampleKeys(time)
+	weights1 := mc.values[i1*n : i1*n+n]
+	weights2 := mc.values[i2*n : i2*n+n]
+	for i := range weights {
+		weights[i] = weights1[i] + (weights2[i]-weights1[i])*k
+	}
+}
+
+// MaterialChannel is the IChannel for an animatable material property.
+type MaterialChannel struct {
+	Channel
+	target   material.IAnimatable
+	property string
+	size     int
+}
+
+// NewMaterialTrack creates and returns a pointer to a new channel animating the specified
+// property of the specified material, such as material.PropColor, with the specified key
+// frame times and values, which contain the property values of each key frame in sequence.
+func NewMaterialTrack(mat material.IAnimatable, property string, times, values []float32) *MaterialChannel {
+
+	current := mat.Property(property)
+	if current == nil {
+		panic("animation.NewMaterialTrack: invalid property " + property)
+	}
+	size := len(current)
+	if len(values) != len(times)*size {
+		panic("animation.NewMaterialTrack: invalid number of values")
+	}
+	mc := new(MaterialChannel)
+	mc.target = mat
+	mc.property = property
+	mc.size = size
+	mc.SetBuffers(times, values)
+	mc.updateInterpAction = func() {
+		// Update interpolation function
+		switch mc.interpType {
+		case STEP:
+			mc.interpAction = func(idx int, k float32) {
+				start := idx * size
+				mat.SetProperty(property, mc.values[start:start+size])
+			}
+		default:
+			mc.interpAction = func(idx int, k float32) {
+				values := make([]float32, size)
+				mc.lerp(idx, idx+1, k, values)
+				mat.SetProperty(property, values)
+			}
+		}
+	}
+	mc.SetInterpolationType(LINEAR)
+	return mc
+}
+
+// Target returns the material animated by this channel.
+func (mc *MaterialChannel) Target() material.IAnimatable {
+
+	return mc.target
+}
+
+// Property returns the name of the material property animated by this channel.
+func (mc *MaterialChannel) Property() string {
+
+	return mc.property
+}
+
+// Sample stores the property values of this channel at the specified time in the
+// specified slice, which must have the size of the property, without updating the target.
+func (mc *MaterialChannel) Sample(time float32, values []float32) {
+
+	i1, i2, k := mc.sampleKeys(time)
+	mc.lerp(i1, i2, k, values)
+}
+
+// lerp stores the values interpolated between the specified key frames in the specified slice.
+func (mc *MaterialChannel) lerp(i1, i2 int, k float32, values []float32) {
+
+	values1 := mc.values[i1*mc.size : i1*mc.size+mc.size]
+	values2 := mc.values[i2*mc.size : i2*mc.size+mc.size]
+	for i := range values {
+		values[i] = values1[i] + (values2[i]-values1[i])*k
+	}
+}
+
 // InterpolationType specifies the interpolation type.
 type InterpolationType string
 
@@ -0,0 +1,151 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package animation
+
+import (
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// SolveTwoBoneIK rotates the root and mid nodes of a two bones chain, such as
+// a leg or an arm, so that the effector node reaches the specified world target
+// position, or points to it if out of reach. The chain bends toward the specified
+// world pole position, such as a point in front of the knee or behind the elbow.
+// The mid node must be a descendant of the root node and the effector a descendant
+// of the mid node. Only the nodes orientations are changed, so the solver can be
+// applied after the animations of the frame, such as after a Mixer update.
+// The world matrices of the ancestors of the root node must be up to date.
+func SolveTwoBoneIK(root, mid, effector *core.Node, target, pole math32.Vector3) {
+
+	const eps = 1e-5
+	var a, b, c math32.Vector3
+	root.UpdateMatrixWorld()
+	root.WorldPosition(&a)
+	mid.WorldPosition(&b)
+	effector.WorldPosition(&c)
+
+	// Lengths of the bones and clamped distance to the target
+	var ab, cb, ca, ta math32.Vector3
+	ab.SubVectors(&b, &a)
+	cb.SubVectors(&c, &b)
+	ca.SubVectors(&c, &a)
+	ta.SubVectors(&target, &a)
+	lab := ab.Length()
+	lcb := cb.Length()
+	if lab < eps || lcb < eps {
+		return
+	}
+	lat := math32.Clamp(ta.Length(), eps, lab+lcb-eps)
+
+	// Bends the chain in the plane of the bones to reach the target distance
+	var axis math32.Vector3
+	axis.CrossVectors(&ca, &ab)
+	if axis.Length() < eps {
+		var pa math32.Vector3
+		pa.SubVectors(&pole, &a)
+		axis.CrossVectors(&ca, &pa)
+		if axis.Length() < eps {
+			return
+		}
+	}
+	axis.Normalize()
+	angleA0 := vectorsAngle(&ca, &ab)
+	ba := *ab.Clone().Negate()
+	angleB0 := vectorsAngle(&ba, &cb)
+	angleA1 := math32.Acos(math32.Clamp((lcb*lcb-lab*lab-lat*lat)/(-2*lab*lat), -1, 1))
+	angleB1 := math32.Acos(math32.Clamp((lat*lat-lab*lab-lcb*lcb)/(-2*lab*lcb), -1, 1))
+	var rot math32.Quaternion
+	rotateWorld(root, rot.SetFromAxisAngle(&axis, angleA1-angleA0))
+	rotateWorld(mid, rot.SetFromAxisAngle(&axis, angleB1-angleB0))
+
+	// Points the chain to the target
+	effector.WorldPosition(&c)
+	ca.SubVectors(&c, &a).Normalize()
+	if ta.Length() < eps {
+		return
+	}
+	dir := *ta.Clone().Normalize()
+	rotateWorld(root, rot.SetFromUnitVectors(&ca, &dir))
+
+	// Rotates the chain around the target direction to bend it toward the pole
+	var bp, pp math32.Vector3
+	mid.WorldPosition(&b)
+	bp.SubVectors(&b, &a)
+	bp.Sub(dir.Clone().MultiplyScalar(bp.Dot(&dir)))
+	pp.SubVectors(&pole, &a)
+	pp.Sub(dir.Clone().MultiplyScalar(pp.Dot(&dir)))
+	if bp.Length() < eps || pp.Length() < eps {
+		return
+	}
+	bp.Normalize()
+	pp.Normalize()
+	angle := vectorsAngle(&bp, &pp)
+	if axis.CrossVectors(&bp, &pp).Dot(&dir) < 0 {
+		angle = -angle
+	}
+	rotateWorld(root, rot.SetFromAxisAngle(&dir, angle))
+}
+
+// SolveCCDIK rotates the nodes of the specified chain, ordered from its root to its
+// effector, using cyclic coordinate descent so that the effector, which is the last
+// node, reaches the specified world target position. Each iteration rotates each node
+// from the one before the effector to the root so that the effector points to the target.
+// Returns true if the effector is within the specified distance of the target.
+// The world matrices of the ancestors of the chain root must be up to date.
+func SolveCCDIK(chain []*core.Node, target math32.Vector3, iterations int, tolerance float32) bool {
+
+	if len(chain) < 2 {
+		return false
+	}
+	effector := chain[len(chain)-1]
+	chain[0].UpdateMatrixWorld()
+	var e, j, toEffector, toTarget math32.Vector3
+	var rot math32.Quaternion
+	for it := 0; it < iterations; it++ {
+		for i := len(chain) - 2; i >= 0; i-- {
+			effector.WorldPosition(&e)
+			if e.DistanceTo(&target) <= tolerance {
+				return true
+			}
+			chain[i].WorldPosition(&j)
+			toEffector.SubVectors(&e, &j)
+			toTarget.SubVectors(&target, &j)
+			if toEffector.Length() < 1e-6 || toTarget.Length() < 1e-6 {
+				continue
+			}
+			toEffector.Normalize()
+			toTarget.Normalize()
+			rotateWorld(chain[i], rot.SetFromUnitVectors(&toEffector, &toTarget))
+		}
+	}
+	effector.WorldPosition(&e)
+	return e.DistanceTo(&target) <= tolerance
+}
+
+// rotateWorld applies the specified world space rotation to the orientation
+// of the node and updates the world matrices of the node and its descendants.
+func rotateWorld(node *core.Node, rot *math32.Quaternion) {
+
+	var world, parent math32.Quaternion
+	node.WorldQuaternion(&world)
+	parent.SetIdentity()
+	if iparent := node.Parent(); iparent != nil {
+		iparent.GetNode().WorldQuaternion(&parent)
+		parent.Inverse()
+	}
+	local := parent.Multiply(rot).Multiply(&world).Normalize()
+	node.SetQuaternionQuat(local)
+	node.UpdateMatrixWorld()
+}
+
+// vectorsAngle returns the angle in radians between the two specified vectors.
+func vectorsAngle(v1, v2 *math32.Vector3) float32 {
+
+	l := v1.Length() * v2.Length()
+	if l == 0 {
+		return 0
+	}
+	return math32.Acos(math32.Clamp(v1.Dot(v2)/l, -1, 1))
+}
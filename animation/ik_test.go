@@ -0,0 +1,67 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package animation
+
+import (
+	"testing"
+
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// newChain creates a chain of nodes along the Y axis with the specified bone lengths.
+func newChain(lengths ...float32) []*core.Node {
+
+	chain := []*core.Node{core.NewNode()}
+	chain[0].SetPosition(1, 2, 3)
+	chain[0].SetRotation(0.2, 0, 0.1)
+	for _, l := range lengths {
+		node := core.NewNode()
+		node.SetPosition(0, l, 0)
+		chain[len(chain)-1].Add(node)
+		chain = append(chain, node)
+	}
+	return chain
+}
+
+// Test that the effector reaches reachable targets and bends toward the pole
+func TestSolveTwoBoneIK(t *testing.T) {
+
+	for _, target := range []math32.Vector3{{X: 2, Y: 3, Z: 3}, {X: 1, Y: 0.5, Z: 4}, {X: -0.2, Y: 2, Z: 2.5}} {
+		chain := newChain(1, 1)
+		pole := math32.Vector3{X: 1, Y: 2, Z: 10}
+		SolveTwoBoneIK(chain[0], chain[1], chain[2], target, pole)
+		var e, b math32.Vector3
+		chain[2].WorldPosition(&e)
+		if d := e.DistanceTo(&target); d > 1e-3 {
+			t.Errorf("target %v: effector at %v is %v away", target, e, d)
+		}
+
+		// The mid node is on the pole side of the line from the root to the target
+		var a, dir, side math32.Vector3
+		chain[0].WorldPosition(&a)
+		chain[1].WorldPosition(&b)
+		dir.SubVectors(&target, &a).Normalize()
+		side.SubVectors(&b, &a)
+		side.Sub(dir.Clone().MultiplyScalar(side.Dot(&dir)))
+		pole.Sub(&a)
+		pole.Sub(dir.Clone().MultiplyScalar(pole.Dot(&dir)))
+		if side.Dot(&pole) <= 0 {
+			t.Errorf("target %v: chain not bent toward the pole", target)
+		}
+	}
+}
+
+// Test that the cyclic coordinate descent solver reaches a reachable target
+func TestSolveCCDIK(t *testing.T) {
+
+	chain := newChain(1, 1, 1, 1)
+	target := math32.Vector3{X: 3, Y: 3, Z: 2}
+	if !SolveCCDIK(chain, target, 50, 1e-3) {
+		var e math32.Vector3
+		chain[len(chain)-1].WorldPosition(&e)
+		t.Errorf("effector at %v did not reach target %v", e, target)
+	}
+}
@@ -0,0 +1,546 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package animation
+
+import (
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/geometry"
+	"github.com/thommil/tge-g3n/material"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// Mixer plays several animations on a node hierarchy at the same time,
+// blending the values of their channels according to their weights before
+// updating the targets. Where the total weight of the animations animating
+// a value is less than one, the value is blended with its rest pose, which is
+// the pose of the hierarchy when the mixer was created.
+// Additive animations are applied on top of the blended pose. They add the
+// difference between their current values and their first key frame values,
+// so that an animation such as aiming can be layered over walking.
+// The material properties animated by material tracks are blended the same
+// way, their rest values being their values when first animated.
+type Mixer struct {
+	root    core.INode                                // Root of the animated hierarchy
+	actions []*mixerAction                            // Animations being played
+	rest    map[*core.Node]*nodePose                  // Rest pose of the animated nodes
+	morphs  map[*geometry.MorphGeometry][]float32     // Rest weights of the animated morph geometries
+	poses   map[*core.Node]*nodePose                  // Blended node poses of the current update
+	weights map[*geometry.MorphGeometry]*morphWeights // Blended morph weights of the current update
+	props   map[materialProperty][]float32            // Rest values of the animated material properties
+	values  map[materialProperty]*morphWeights        // Blended material property values of the current update
+}
+
+// mixerAction describes an animation played by a mixer.
+type mixerAction struct {
+	anim        *Animation // Animation
+	weight      float32    // Current weight
+	additive    bool       // Whether the animation is added to the blended pose
+	fadeFrom    float32    // Weight at the start of the current fade
+	fadeTo      float32    // Weight at the end of the current fade
+	fadeTime    float32    // Duration of the current fade or 0 if not fading
+	fadeElapsed float32    // Elapsed time of the current fade
+	stopOnFade  bool       // Whether the animation is stopped at the end of the fade
+}
+
+// nodePose contains the accumulated transform values of a node.
+type nodePose struct {
+	pos      math32.Vector3
+	rot      math32.Quaternion
+	scale    math32.Vector3
+	posW     float32
+	rotW     float32
+	scaleW   float32
+	modified bool
+}
+
+// morphWeights contains the accumulated weights of a morph geometry
+// or the accumulated values of a material property.
+type morphWeights struct {
+	values []float32
+	total  float32
+}
+
+// materialProperty identifies an animated material property.
+type materialProperty struct {
+	target material.IAnimatable
+	name   string
+}
+
+// NewMixer creates and returns a pointer to a new animation Mixer
+// for the hierarchy of the specified node, whose current pose is the rest pose.
+func NewMixer(root core.INode) *Mixer {
+
+	m := new(Mixer)
+	m.root = root
+	m.rest = make(map[*core.Node]*nodePose)
+	m.morphs = make(map[*geometry.MorphGeometry][]float32)
+	m.poses = make(map[*core.Node]*nodePose)
+	m.weights = make(map[*geometry.MorphGeometry]*morphWeights)
+	m.props = make(map[materialProperty][]float32)
+	m.values = make(map[materialProperty]*morphWeights)
+	m.captureRest(root)
+	return m
+}
+
+// Root returns the root of the hierarchy animated by this mixer.
+func (m *Mixer) Root() core.INode {
+
+	return m.root
+}
+
+// Play starts playing the specified animation from its beginning with the specified weight.
+// If the animation is already being played, only its weight is changed.
+func (m *Mixer) Play(anim *Animation, weight float32) {
+
+	action := m.action(anim)
+	if action == nil {
+		action = &mixerAction{anim: anim}
+		m.actions = append(m.actions, action)
+		anim.time = anim.start
+		anim.paused = false
+	}
+	action.weight = weight
+	action.fadeTime = 0
+}
+
+// Stop stops playing the specified animation.
+func (m *Mixer) Stop(anim *Animation) {
+
+	for i, action := range m.actions {
+		if action.anim == anim {
+			copy(m.actions[i:], m.actions[i+1:])
+			m.actions[len(m.actions)-1] = nil
+			m.actions = m.actions[:len(m.actions)-1]
+			return
+		}
+	}
+}
+
+// StopAll stops playing all the animations.
+func (m *Mixer) StopAll() {
+
+	m.actions = nil
+}
+
+// Playing returns whether the specified animation is being played.
+func (m *Mixer) Playing(anim *Animation) bool {
+
+	return m.action(anim) != nil
+}
+
+// SetWeight sets the weight of the specified animation being played,
+// cancelling any fade in progress.
+func (m *Mixer) SetWeight(anim *Animation, weight float32) {
+
+	if action := m.action(anim); action != nil {
+		action.weight = weight
+		action.fadeTime = 0
+	}
+}
+
+// Weight returns the current weight of the specified animation or 0 if not being played.
+func (m *Mixer) Weight(anim *Animation) float32 {
+
+	if action := m.action(anim); action != nil {
+		return action.weight
+	}
+	return 0
+}
+
+// SetAdditive sets whether the specified animation being played is added to the blended pose.
+func (m *Mixer) SetAdditive(anim *Animation, additive bool) {
+
+	if action := m.action(anim); action != nil {
+		action.additive = additive
+	}
+}
+
+// CrossFade fades out the specified animation being played while fading in the
+// other animation, started from its beginning if not already being played, over
+// the specified duration in seconds. The weight of the faded out animation is
+// transferred to the faded in one and the faded out animation is stopped at the end.
+func (m *Mixer) CrossFade(from, to *Animation, duration float32) {
+
+	weight := float32(1)
+	if action := m.action(from); action != nil {
+		weight = action.weight
+		m.fade(action, 0, duration, true)
+	}
+	action := m.action(to)
+	if action == nil {
+		m.Play(to, 0)
+		action = m.action(to)
+	}
+	m.fade(action, weight, duration, false)
+}
+
+// FadeIn starts playing the specified animation if necessary and fades its weight
+// to the specified weight over the specified duration in seconds.
+func (m *Mixer) FadeIn(anim *Animation, weight, duration float32) {
+
+	if !m.Playing(anim) {
+		m.Play(anim, 0)
+	}
+	m.fade(m.action(anim), weight, duration, false)
+}
+
+// FadeOut fades the weight of the specified animation being played to zero
+// over the specified duration in seconds and then stops it.
+func (m *Mixer) FadeOut(anim *Animation, duration float32) {
+
+	if action := m.action(anim); action != nil {
+		m.fade(action, 0, duration, true)
+	}
+}
+
+// Update advances the animations being played by the specified time in seconds
+// and updates their targets with the blended values.
+func (m *Mixer) Update(delta float32) {
+
+	// Updates the fading weights and the animations times
+	for i := 0; i < len(m.actions); i++ {
+		action := m.actions[i]
+		if action.fadeTime > 0 {
+			action.fadeElapsed += delta
+			t := math32.Min(action.fadeElapsed/action.fadeTime, 1)
+			action.weight = action.fadeFrom + (action.fadeTo-action.fadeFrom)*t
+			if t == 1 {
+				action.fadeTime = 0
+				if action.stopOnFade {
+					m.Stop(action.anim)
+					i--
+					continue
+				}
+			}
+		}
+		action.anim.advance(delta)
+	}
+
+	// Accumulates the weighted values of the animations
+	for _, pose := range m.poses {
+		*pose = nodePose{}
+	}
+	for _, weights := range m.weights {
+		for i := range weights.values {
+			weights.values[i] = 0
+		}
+		weights.total = 0
+	}
+	for _, values := range m.values {
+		for i := range values.values {
+			values.values[i] = 0
+		}
+		values.total = 0
+	}
+	for _, action := range m.actions {
+		if !action.additive && action.weight > 0 {
+			m.accumulate(action)
+		}
+	}
+
+	// Normalizes the blended values, completing them with the rest pose
+	for node, pose := range m.poses {
+		if !pose.modified {
+			continue
+		}
+		rest := m.restPose(node)
+		if pose.posW < 1 {
+			pose.pos.Add(rest.pos.Clone().MultiplyScalar(1 - pose.posW))
+		} else {
+			pose.pos.MultiplyScalar(1 / pose.posW)
+		}
+		if pose.scaleW < 1 {
+			pose.scale.Add(rest.scale.Clone().MultiplyScalar(1 - pose.scaleW))
+		} else {
+			pose.scale.MultiplyScalar(1 / pose.scaleW)
+		}
+		if pose.rotW < 1 {
+			addQuaternion(&pose.rot, &rest.rot, 1-pose.rotW)
+		}
+		pose.rot.Normalize()
+	}
+	for mg, weights := range m.weights {
+		if weights.total == 0 {
+			continue
+		}
+		normalize(weights, m.morphs[mg])
+	}
+	for prop, values := range m.values {
+		if values.total != 0 {
+			normalize(values, m.props[prop])
+		}
+	}
+
+	// Adds the additive animations
+	for _, action := range m.actions {
+		if action.additive && action.weight != 0 {
+			m.add(action)
+		}
+	}
+
+	// Updates the targets
+	for node, pose := range m.poses {
+		if pose.modified {
+			node.SetPositionVec(&pose.pos)
+			node.SetQuaternionQuat(&pose.rot)
+			node.SetScaleVec(&pose.scale)
+		}
+	}
+	for mg, weights := range m.weights {
+		if weights.total != 0 {
+			mg.SetWeights(append([]float32(nil), weights.values...))
+		}
+	}
+	for prop, values := range m.values {
+		if values.total != 0 {
+			prop.target.SetProperty(prop.name, values.values)
+		}
+	}
+}
+
+// UpdateClock updates the mixer with the time the specified clock advanced at its last tick,
+// so that the animations are paused and scaled with the clock.
+func (m *Mixer) UpdateClock(clock *core.Clock) {
+
+	m.Update(clock.Delta())
+}
+
+// action returns the action of the specified animation or nil if not being played.
+func (m *Mixer) action(anim *Animation) *mixerAction {
+
+	for _, action := range m.actions {
+		if action.anim == anim {
+			return action
+		}
+	}
+	return nil
+}
+
+// fade starts fading the weight of the specified action.
+func (m *Mixer) fade(action *mixerAction, weight, duration float32, stop bool) {
+
+	if duration <= 0 {
+		action.weight = weight
+		action.fadeTime = 0
+		if stop {
+			m.Stop(action.anim)
+		}
+		return
+	}
+	action.fadeFrom = action.weight
+	action.fadeTo = weight
+	action.fadeTime = duration
+	action.fadeElapsed = 0
+	action.stopOnFade = stop
+}
+
+// accumulate adds the weighted values of the channels of the specified action to the blended pose.
+func (m *Mixer) accumulate(action *mixerAction) {
+
+	w := action.weight
+	time := action.anim.time
+	for _, ich := range action.anim.channels {
+		switch ch := ich.(type) {
+		case *PositionChannel:
+			pose := m.pose(ch.target.GetNode())
+			v := ch.Sample(time)
+			pose.pos.Add(v.MultiplyScalar(w))
+			pose.posW += w
+		case *RotationChannel:
+			pose := m.pose(ch.target.GetNode())
+			q := ch.Sample(time)
+			addQuaternion(&pose.rot, &q, w)
+			pose.rotW += w
+		case *ScaleChannel:
+			pose := m.pose(ch.target.GetNode())
+			v := ch.Sample(time)
+			pose.scale.Add(v.MultiplyScalar(w))
+			pose.scaleW += w
+		case *MorphChannel:
+			weights := m.morphWeights(ch.target)
+			values := make([]float32, len(weights.values))
+			ch.Sample(time, values)
+			for i := range values {
+				weights.values[i] += values[i] * w
+			}
+			weights.total += w
+		case *MaterialChannel:
+			acc := m.propertyValues(ch)
+			values := make([]float32, ch.size)
+			ch.Sample(time, values)
+			for i := range values {
+				acc.values[i] += values[i] * w
+			}
+			acc.total += w
+		}
+	}
+}
+
+// add adds the weighted differences between the current values of the channels of the specified
+// action and their first key frame values to the blended pose.
+func (m *Mixer) add(action *mixerAction) {
+
+	w := action.weight
+	time := action.anim.time
+	for _, ich := range action.anim.channels {
+		switch ch := ich.(type) {
+		case *PositionChannel:
+			pose := m.blendedPose(ch.target.GetNode())
+			ref := ch.Sample(ch.keyframes[0])
+			v := ch.Sample(time)
+			pose.pos.Add(v.Sub(&ref).MultiplyScalar(w))
+		case *RotationChannel:
+			pose := m.blendedPose(ch.target.GetNode())
+			ref := ch.Sample(ch.keyframes[0])
+			q := ch.Sample(time)
+			var delta math32.Quaternion
+			delta.MultiplyQuaternions(ref.Inverse(), &q)
+			identity := math32.NewQuaternion(0, 0, 0, 1)
+			pose.rot.Multiply(identity.Slerp(&delta, w)).Normalize()
+		case *ScaleChannel:
+			pose := m.blendedPose(ch.target.GetNode())
+			ref := ch.Sample(ch.keyframes[0])
+			v := ch.Sample(time)
+			pose.scale.X *= additiveScale(v.X, ref.X, w)
+			pose.scale.Y *= additiveScale(v.Y, ref.Y, w)
+			pose.scale.Z *= additiveScale(v.Z, ref.Z, w)
+		case *MorphChannel:
+			weights := m.morphWeights(ch.target)
+			if weights.total == 0 {
+				copy(weights.values, m.morphs[ch.target])
+				weights.total = 1
+			}
+			ref := make([]float32, len(weights.values))
+			values := make([]float32, len(weights.values))
+			ch.Sample(ch.keyframes[0], ref)
+			ch.Sample(time, values)
+			for i := range values {
+				weights.values[i] += (values[i] - ref[i]) * w
+			}
+		case *MaterialChannel:
+			acc := m.propertyValues(ch)
+			if acc.total == 0 {
+				copy(acc.values, m.props[materialProperty{ch.target, ch.property}])
+				acc.total = 1
+			}
+			ref := make([]float32, ch.size)
+			values := make([]float32, ch.size)
+			ch.Sample(ch.keyframes[0], ref)
+			ch.Sample(time, values)
+			for i := range values {
+				acc.values[i] += (values[i] - ref[i]) * w
+			}
+		}
+	}
+}
+
+// pose returns the accumulated pose of the specified node for the current update.
+func (m *Mixer) pose(node *core.Node) *nodePose {
+
+	pose := m.poses[node]
+	if pose == nil {
+		pose = new(nodePose)
+		m.poses[node] = pose
+	}
+	pose.modified = true
+	return pose
+}
+
+// blendedPose returns the blended pose of the specified node for the current
+// update, initialized with its rest pose if not animated by any blended animation.
+func (m *Mixer) blendedPose(node *core.Node) *nodePose {
+
+	pose := m.poses[node]
+	if pose == nil || !pose.modified {
+		pose = m.pose(node)
+		*pose = *m.restPose(node)
+		pose.modified = true
+	}
+	return pose
+}
+
+// morphWeights returns the accumulated weights of the specified morph geometry for the current update.
+func (m *Mixer) morphWeights(mg *geometry.MorphGeometry) *morphWeights {
+
+	weights := m.weights[mg]
+	if weights == nil {
+		weights = &morphWeights{values: make([]float32, len(mg.Weights()))}
+		m.weights[mg] = weights
+	}
+	if _, ok := m.morphs[mg]; !ok {
+		m.morphs[mg] = append([]float32(nil), mg.Weights()...)
+	}
+	return weights
+}
+
+// propertyValues returns the accumulated values of the material property of the
+// specified channel for the current update, capturing its rest values at its first use.
+func (m *Mixer) propertyValues(ch *MaterialChannel) *morphWeights {
+
+	prop := materialProperty{ch.target, ch.property}
+	values := m.values[prop]
+	if values == nil {
+		values = &morphWeights{values: make([]float32, ch.size)}
+		m.values[prop] = values
+	}
+	if _, ok := m.props[prop]; !ok {
+		m.props[prop] = ch.target.Property(ch.property)
+	}
+	return values
+}
+
+// restPose returns the rest pose of the specified node, captured
+// now if the node was not in the hierarchy when the mixer was created.
+func (m *Mixer) restPose(node *core.Node) *nodePose {
+
+	rest := m.rest[node]
+	if rest == nil {
+		rest = &nodePose{pos: node.Position(), rot: node.Quaternion(), scale: node.Scale()}
+		m.rest[node] = rest
+	}
+	return rest
+}
+
+// captureRest captures the rest pose of the specified node and of its descendants.
+func (m *Mixer) captureRest(inode core.INode) {
+
+	node := inode.GetNode()
+	m.restPose(node)
+	for _, child := range node.Children() {
+		m.captureRest(child)
+	}
+}
+
+// normalize divides the accumulated values by their total weight,
+// completing them with the specified rest values if it is less than one.
+func normalize(acc *morphWeights, rest []float32) {
+
+	for i := range acc.values {
+		if acc.total < 1 {
+			acc.values[i] += rest[i] * (1 - acc.total)
+		} else {
+			acc.values[i] /= acc.total
+		}
+	}
+}
+
+// addQuaternion adds the weighted quaternion to the accumulated quaternion,
+// negating it if necessary so that both are in the same hemisphere.
+func addQuaternion(acc, q *math32.Quaternion, w float32) {
+
+	if acc.Dot(q) < 0 {
+		w = -w
+	}
+	acc.Set(acc.X+q.X*w, acc.Y+q.Y*w, acc.Z+q.Z*w, acc.W+q.W*w)
+}
+
+// additiveScale returns the scale factor of the weighted ratio between the value and the reference value.
+func additiveScale(v, ref, w float32) float32 {
+
+	if ref == 0 {
+		return 1
+	}
+	return 1 + (v/ref-1)*w
+}
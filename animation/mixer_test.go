@@ -0,0 +1,33 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package animation
+
+import (
+	"testing"
+
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/material"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// Test a material color track played alone and blended with the rest color by a mixer
+func TestMaterialTrack(t *testing.T) {
+
+	mat := material.NewStandard(&math32.Color{0, 0, 0})
+	anim := NewAnimation()
+	anim.AddChannel(NewMaterialTrack(mat, material.PropColor, []float32{0, 2}, []float32{0, 0, 0, 1, 0.5, 0}))
+	anim.Update(1)
+	if c := mat.Color(); c != (math32.Color{0.5, 0.25, 0}) {
+		t.Errorf("animated color: got %v", c)
+	}
+
+	mat.SetColor(&math32.Color{0, 0, 1})
+	m := NewMixer(core.NewNode())
+	m.Play(anim, 0.5)
+	m.Update(2)
+	if c := mat.Color(); c != (math32.Color{0.5, 0.25, 0.5}) {
+		t.Errorf("blended color: got %v", c)
+	}
+}
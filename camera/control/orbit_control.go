@@ -120,6 +120,53 @@ func (oc *OrbitControl) Zoom(delta float32) {
 	oc.updateZoom()
 }
 
+// FrameAll moves the camera along its current direction from the target, or along +Z
+// if at the target, so that the specified world bounding box, such as the one returned by
+// graphic.SceneBounds, is entirely visible and centered, and fits the camera near and far
+// planes to it. The box center becomes the new target. Orthographic cameras are zoomed
+// instead of being moved closer. Empty boxes are ignored.
+func (oc *OrbitControl) FrameAll(bounds math32.Box3) {
+
+	if bounds.Empty() {
+		return
+	}
+	var sphere math32.Sphere
+	bounds.GetBoundingSphere(&sphere)
+	radius := math32.Max(sphere.Radius, 1e-3)
+
+	// Direction from the target to the camera
+	vdir := oc.cam.Position()
+	target := oc.cam.Target()
+	vdir.Sub(&target)
+	if vdir.Length() < 1e-6 {
+		vdir.Set(0, 0, 1)
+	}
+	vdir.Normalize()
+
+	var dist float32
+	if oc.camPersp != nil {
+		// Distance at which the bounding sphere fits the smallest field of view
+		vfov := math32.DegToRad(oc.camPersp.Fov())
+		hfov := 2 * math32.Atan(math32.Tan(vfov/2)*oc.camPersp.Aspect())
+		dist = radius / math32.Sin(math32.Min(vfov, hfov)/2)
+		dist = math32.Max(oc.MinDistance, math32.Min(oc.MaxDistance, dist))
+	} else {
+		left, right, top, bottom, _, _ := oc.camOrtho.Planes()
+		oc.camOrtho.SetZoom(math32.Min(math32.Abs(top-bottom), math32.Abs(right-left)) / (2 * radius))
+		dist = 2 * radius
+	}
+
+	position := sphere.Center
+	position.Add(vdir.MultiplyScalar(dist))
+	oc.cam.SetPositionVec(&position)
+	oc.cam.LookAt(&sphere.Center)
+	if oc.camPersp != nil {
+		oc.camPersp.FitToScene(bounds)
+	} else {
+		oc.camOrtho.FitToScene(bounds)
+	}
+}
+
 // RotateLeft rotates the camera left by specified angle
 func (oc *OrbitControl) RotateLeft(angle float32) {
 
@@ -0,0 +1,77 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// fitMargin is the relative margin added around the depth range of fitted scene bounds.
+const fitMargin = 0.01
+
+// fitNearRatio is the minimum ratio between the near and far planes of fitted
+// perspective cameras, which limits the loss of depth precision near the far plane.
+const fitNearRatio = 1e-4
+
+// FitToScene sets the near and far planes to tightly enclose the specified world
+// bounding box, such as the one returned by graphic.SceneBounds, from the current camera
+// position and orientation, which maximizes the depth buffer precision. The near plane
+// is limited to a ten thousandth of the far plane when the camera is inside the bounds.
+// The planes are not changed if the bounds are empty or behind the camera.
+func (cam *Perspective) FitToScene(bounds math32.Box3) {
+
+	near, far, ok := cam.Camera.depthRange(&bounds)
+	if !ok || far <= 0 {
+		return
+	}
+	far *= 1 + fitMargin
+	cam.near = math32.Max(near*(1-fitMargin), far*fitNearRatio)
+	cam.far = far
+	cam.projChanged = true
+}
+
+// FitToScene sets the near and far planes to tightly enclose the specified world
+// bounding box, such as the one returned by graphic.SceneBounds, from the current
+// camera position and orientation, which maximizes the depth buffer precision.
+// The planes are not changed if the bounds are empty.
+func (cam *Orthographic) FitToScene(bounds math32.Box3) {
+
+	near, far, ok := cam.Camera.depthRange(&bounds)
+	if !ok {
+		return
+	}
+	margin := math32.Max(far-near, 1e-3) * fitMargin
+	cam.near = near - margin
+	cam.far = far + margin
+	cam.projChanged = true
+}
+
+// depthRange returns the minimum and maximum distances along the view direction
+// of the camera of the corners of the specified world bounding box.
+func (cam *Camera) depthRange(bounds *math32.Box3) (near, far float32, ok bool) {
+
+	if bounds.Empty() {
+		return 0, 0, false
+	}
+	var view math32.Matrix4
+	cam.ViewMatrix(&view)
+	near, far = math32.Infinity, -math32.Infinity
+	for i := 0; i < 8; i++ {
+		corner := bounds.Min
+		if i&1 != 0 {
+			corner.X = bounds.Max.X
+		}
+		if i&2 != 0 {
+			corner.Y = bounds.Max.Y
+		}
+		if i&4 != 0 {
+			corner.Z = bounds.Max.Z
+		}
+		corner.ApplyMatrix4(&view)
+		near = math32.Min(near, -corner.Z)
+		far = math32.Max(far, -corner.Z)
+	}
+	return near, far, true
+}
@@ -0,0 +1,29 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"testing"
+
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// Test fitting the planes of a perspective camera outside and inside the scene bounds
+func TestFitToScene(t *testing.T) {
+
+	cam := NewPerspective(60, 1, 0.1, 1000)
+	cam.SetPosition(0, 0, 10)
+	bounds := math32.Box3{Min: math32.Vector3{X: -1, Y: -1, Z: -1}, Max: math32.Vector3{X: 1, Y: 1, Z: 1}}
+	cam.FitToScene(bounds)
+	if math32.Abs(cam.Near()-9*(1-fitMargin)) > 1e-4 || math32.Abs(cam.Far()-11*(1+fitMargin)) > 1e-4 {
+		t.Errorf("outside planes: got %v and %v", cam.Near(), cam.Far())
+	}
+
+	cam.SetPosition(0, 0, 0)
+	cam.FitToScene(bounds)
+	if cam.Near() != cam.Far()*fitNearRatio || math32.Abs(cam.Far()-1*(1+fitMargin)) > 1e-4 {
+		t.Errorf("inside planes: got %v and %v", cam.Near(), cam.Far())
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"math"
+
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/math32"
+	"github.com/thommil/tge-g3n/math32/noise"
+)
+
+// Pose is the pose of a camera updated by the behaviors of a rig.
+type Pose struct {
+	Position   math32.Vector3    // Base position
+	Quaternion math32.Quaternion // Base orientation
+	Offset     math32.Vector3    // Offset added to the base position in camera coordinates, cleared every update
+}
+
+// Behavior is a camera behavior, such as following a target or shaking, updated by a rig.
+type Behavior interface {
+	// Update updates the specified pose for the specified elapsed time in seconds.
+	// Returns false when the behavior is finished and should be removed.
+	Update(pose *Pose, delta float32) bool
+}
+
+// Rig updates a camera with a list of behaviors every frame. The behaviors move the base
+// pose of the camera, which is kept between the updates, or add offsets to it, which are
+// only applied to the camera, so that transient behaviors such as shakes do not drift the
+// camera. The base pose is read again from the camera if it was moved by the application.
+type Rig struct {
+	icam      ICamera           // Camera updated by the rig
+	behaviors []Behavior        // Behaviors in update order
+	pose      Pose              // Base pose
+	applied   math32.Vector3    // Camera position set by the last update
+	appliedQ  math32.Quaternion // Camera orientation set by the last update
+	started   bool              // Whether the rig has updated the camera
+}
+
+// NewRig creates and returns a pointer to a new rig for the specified camera without behaviors.
+func NewRig(icam ICamera) *Rig {
+
+	rig := new(Rig)
+	rig.icam = icam
+	return rig
+}
+
+// Add adds the specified behavior, which is updated after the behaviors already added.
+func (rig *Rig) Add(b Behavior) {
+
+	rig.behaviors = append(rig.behaviors, b)
+}
+
+// Remove removes the specified behavior.
+func (rig *Rig) Remove(b Behavior) {
+
+	for i, rb := range rig.behaviors {
+		if rb == b {
+			copy(rig.behaviors[i:], rig.behaviors[i+1:])
+			rig.behaviors[len(rig.behaviors)-1] = nil
+			rig.behaviors = rig.behaviors[:len(rig.behaviors)-1]
+			return
+		}
+	}
+}
+
+// Behaviors returns the current behaviors of the rig.
+func (rig *Rig) Behaviors() []Behavior {
+
+	return rig.behaviors
+}
+
+// Update updates the behaviors for the specified elapsed time in seconds
+// and sets the resulting pose to the camera.
+func (rig *Rig) Update(delta float32) {
+
+	cam := rig.icam.GetCamera()
+	pos, quat := cam.Position(), cam.Quaternion()
+	if !rig.started || pos != rig.applied || quat != rig.appliedQ {
+		rig.pose.Position = pos
+		rig.pose.Quaternion = quat
+		rig.started = true
+	}
+	rig.pose.Offset.Zero()
+	for i := 0; i < len(rig.behaviors); i++ {
+		if !rig.behaviors[i].Update(&rig.pose, delta) {
+			rig.Remove(rig.behaviors[i])
+			i--
+		}
+	}
+
+	offset := rig.pose.Offset
+	offset.ApplyQuaternion(&rig.pose.Quaternion)
+	rig.applied = rig.pose.Position
+	rig.applied.Add(&offset)
+	rig.appliedQ = rig.pose.Quaternion
+	cam.SetPositionVec(&rig.applied)
+	cam.SetQuaternionQuat(&rig.appliedQ)
+}
+
+// UpdateClock updates the rig with the time the specified clock advanced at its last tick,
+// so that the behaviors are paused and scaled with the clock.
+func (rig *Rig) UpdateClock(clock *core.Clock) {
+
+	rig.Update(clock.Delta())
+}
+
+// Follow is a behavior moving the camera smoothly toward a target node,
+// keeping an offset from it, and looking at it.
+type Follow struct {
+	target  core.INode     // Followed node
+	offset  math32.Vector3 // Offset from the target in world coordinates
+	damping float32        // Damping rate
+}
+
+// SmoothFollow creates and returns a pointer to a new behavior following the specified
+// target node at the specified offset in world coordinates. The camera covers the
+// fraction 1-exp(-damping*delta) of its distance to its position each update, which
+// does not depend on the frame rate. A higher damping follows the target more tightly.
+func SmoothFollow(target core.INode, offset math32.Vector3, damping float32) *Follow {
+
+	if damping < 0 {
+		panic("camera.SmoothFollow: invalid damping")
+	}
+	return &Follow{target: target, offset: offset, damping: damping}
+}
+
+// SetOffset sets the offset from the target in world coordinates.
+func (f *Follow) SetOffset(offset math32.Vector3) {
+
+	f.offset = offset
+}
+
+// Update moves the pose toward the target. It satisfies the Behavior interface.
+func (f *Follow) Update(pose *Pose, delta float32) bool {
+
+	var target math32.Vector3
+	f.target.GetNode().WorldPosition(&target)
+	desired := target
+	desired.Add(&f.offset)
+	pose.Position.Lerp(&desired, 1-float32(math.Exp(float64(-f.damping*delta))))
+
+	var rot math32.Matrix4
+	up := math32.Vector3{0, 1, 0}
+	rot.LookAt(&pose.Position, &target, &up)
+	pose.Quaternion.SetFromRotationMatrix(&rot)
+	return true
+}
+
+// Shaker is a behavior shaking the camera with noise, decreasing until its end.
+type Shaker struct {
+	amplitude float32 // Maximum offset in world units
+	frequency float32 // Noise frequency in hertz
+	duration  float32 // Duration in seconds
+	elapsed   float32 // Elapsed time in seconds
+}
+
+// Shake creates and returns a pointer to a new behavior moving the camera in its view plane
+// by up to the specified amplitude in world units, with a noise of the specified frequency in
+// hertz, for the specified duration in seconds. The amplitude decreases quadratically to zero.
+func Shake(amplitude, frequency, duration float32) *Shaker {
+
+	if duration <= 0 {
+		panic("camera.Shake: invalid duration")
+	}
+	return &Shaker{amplitude: amplitude, frequency: frequency, duration: duration}
+}
+
+// Update adds the shake offset to the pose. It satisfies the Behavior interface.
+func (s *Shaker) Update(pose *Pose, delta float32) bool {
+
+	s.elapsed += delta
+	if s.elapsed >= s.duration {
+		return false
+	}
+	decay := 1 - s.elapsed/s.duration
+	amplitude := s.amplitude * decay * decay
+	t := s.elapsed * s.frequency
+	pose.Offset.X += noise.Noise2D(t, 0.31) * amplitude
+	pose.Offset.Y += noise.Noise2D(t, 7.73) * amplitude
+	return true
+}
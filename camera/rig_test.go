@@ -0,0 +1,45 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"testing"
+
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// Test that following does not depend on the frame rate and is not drifted by a shake
+func TestRig(t *testing.T) {
+
+	target := core.NewNode()
+	target.SetPosition(10, 0, 0)
+	offset := math32.Vector3{0, 2, 5}
+	var cams [3]*Perspective
+	var rigs [3]*Rig
+	for i := range rigs {
+		cams[i] = NewPerspective(60, 1, 0.1, 100)
+		rigs[i] = NewRig(cams[i])
+		rigs[i].Add(SmoothFollow(target, offset, 2))
+	}
+	shake := Shake(1, 10, 0.5)
+	rigs[2].Add(shake)
+
+	rigs[0].Update(1)
+	for i := 0; i < 10; i++ {
+		rigs[1].Update(0.1)
+		rigs[2].Update(0.1)
+		if i == 2 && cams[2].Position() == cams[1].Position() {
+			t.Errorf("expected a shaken position")
+		}
+	}
+	p0, p1, p2 := cams[0].Position(), cams[1].Position(), cams[2].Position()
+	if p0.DistanceTo(&p1) > 1e-4 || p1.DistanceTo(&p2) > 1e-4 {
+		t.Errorf("positions differ: %v, %v and %v", p0, p1, p2)
+	}
+	if len(rigs[2].Behaviors()) != 1 {
+		t.Errorf("expected the finished shake removed")
+	}
+}
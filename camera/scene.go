@@ -0,0 +1,94 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"encoding/json"
+
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+func init() {
+	core.RegisterSceneType("PerspectiveCamera", func() core.INode { return NewPerspective(60, 1, 0.1, 100) })
+	core.RegisterSceneType("OrthographicCamera", func() core.INode { return NewOrthographic(-1, 1, 1, -1, 0.1, 100) })
+}
+
+// sceneCamera describes the target and up vector of a camera in a scene document
+type sceneCamera struct {
+	Target math32.Vector3 `json:"target"`
+	Up     math32.Vector3 `json:"up"`
+}
+
+// scenePerspective describes a perspective camera
+type scenePerspective struct {
+	sceneCamera
+	Fov    float32 `json:"fov"`
+	Aspect float32 `json:"aspect"`
+	Near   float32 `json:"near"`
+	Far    float32 `json:"far"`
+}
+
+// sceneOrthographic describes an orthographic camera
+type sceneOrthographic struct {
+	sceneCamera
+	Left   float32 `json:"left"`
+	Right  float32 `json:"right"`
+	Top    float32 `json:"top"`
+	Bottom float32 `json:"bottom"`
+	Near   float32 `json:"near"`
+	Far    float32 `json:"far"`
+	Zoom   float32 `json:"zoom"`
+}
+
+// SceneData satisfies the core.ISceneNode interface and returns the projection of this camera.
+func (cam *Perspective) SceneData() (interface{}, error) {
+
+	return &scenePerspective{sceneCamera{cam.target, cam.up}, cam.fov, cam.aspect, cam.near, cam.far}, nil
+}
+
+// SetSceneData satisfies the core.ISceneNode interface and sets the projection of this camera.
+// The orientation is restored by the node quaternion.
+func (cam *Perspective) SetSceneData(data json.RawMessage) error {
+
+	var sp scenePerspective
+	err := json.Unmarshal(data, &sp)
+	if err != nil {
+		return err
+	}
+	cam.target = sp.Target
+	cam.up = sp.Up
+	cam.fov = sp.Fov
+	cam.aspect = sp.Aspect
+	cam.near = sp.Near
+	cam.far = sp.Far
+	cam.projChanged = true
+	return nil
+}
+
+// SceneData satisfies the core.ISceneNode interface and returns the projection of this camera.
+func (cam *Orthographic) SceneData() (interface{}, error) {
+
+	return &sceneOrthographic{sceneCamera{cam.target, cam.up}, cam.left, cam.right, cam.top, cam.bottom, cam.near, cam.far, cam.zoom}, nil
+}
+
+// SetSceneData satisfies the core.ISceneNode interface and sets the projection of this camera.
+// The orientation is restored by the node quaternion.
+func (cam *Orthographic) SetSceneData(data json.RawMessage) error {
+
+	var so sceneOrthographic
+	err := json.Unmarshal(data, &so)
+	if err != nil {
+		return err
+	}
+	cam.target = so.Target
+	cam.up = so.Up
+	cam.left, cam.right, cam.top, cam.bottom = so.Left, so.Right, so.Top, so.Bottom
+	cam.near = so.Near
+	cam.far = so.Far
+	cam.zoom = so.Zoom
+	cam.projChanged = true
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"time"
+)
+
+// Clock is the time source of the render loop. It is advanced once per frame
+// by calling Tick and supplies the time elapsed since the previous frame to the
+// systems updated during the frame, so that they all advance consistently.
+// A paused clock does not advance. The clock time can be scaled, for slow motion
+// for example, and can advance by a fixed delta per tick for deterministic playback.
+type Clock struct {
+	now        func() time.Time // Time source
+	last       time.Time        // Time of the last tick
+	started    bool             // Whether the clock was started
+	paused     bool             // Whether the clock is paused
+	delta      float32          // Time elapsed between the last two ticks in seconds
+	elapsed    float32          // Total clock time in seconds
+	scale      float32          // Time scale
+	fixedDelta float32          // Fixed delta per tick in seconds or 0 to use the real time
+}
+
+// NewClock creates and returns a pointer to a new stopped Clock.
+func NewClock() *Clock {
+
+	c := new(Clock)
+	c.now = time.Now
+	c.scale = 1
+	return c
+}
+
+// Start starts or restarts the clock from zero.
+func (c *Clock) Start() {
+
+	c.last = c.now()
+	c.started = true
+	c.paused = false
+	c.delta = 0
+	c.elapsed = 0
+}
+
+// Started returns whether the clock was started.
+func (c *Clock) Started() bool {
+
+	return c.started
+}
+
+// Pause pauses the clock. The delta of the following ticks is zero until the clock is resumed.
+func (c *Clock) Pause() {
+
+	c.paused = true
+}
+
+// Resume resumes the paused clock. The time elapsed while paused is not counted.
+func (c *Clock) Resume() {
+
+	if c.paused {
+		c.paused = false
+		c.last = c.now()
+	}
+}
+
+// Paused returns whether the clock is paused.
+func (c *Clock) Paused() bool {
+
+	return c.paused
+}
+
+// SetScale sets the factor applied to the time elapsed between ticks.
+// The default value is 1.
+func (c *Clock) SetScale(scale float32) {
+
+	c.scale = scale
+}
+
+// Scale returns the factor applied to the time elapsed between ticks.
+func (c *Clock) Scale() float32 {
+
+	return c.scale
+}
+
+// SetFixedDelta sets the time in seconds the clock advances at each tick
+// regardless of the real time elapsed, for deterministic playback.
+// The default value of 0 uses the real time.
+func (c *Clock) SetFixedDelta(delta float32) {
+
+	c.fixedDelta = delta
+}
+
+// FixedDelta returns the time the clock advances at each tick or 0 if it uses the real time.
+func (c *Clock) FixedDelta() float32 {
+
+	return c.fixedDelta
+}
+
+// Tick advances the clock and must be called once per frame.
+// It starts the clock if not yet started.
+func (c *Clock) Tick() {
+
+	if !c.started {
+		c.Start()
+		return
+	}
+	now := c.now()
+	if c.paused {
+		c.delta = 0
+	} else if c.fixedDelta > 0 {
+		c.delta = c.fixedDelta * c.scale
+	} else {
+		c.delta = float32(now.Sub(c.last).Seconds()) * c.scale
+	}
+	c.last = now
+	c.elapsed += c.delta
+}
+
+// Delta returns the time in seconds the clock advanced at the last tick.
+func (c *Clock) Delta() float32 {
+
+	return c.delta
+}
+
+// Elapsed returns the total time in seconds the clock advanced since started.
+func (c *Clock) Elapsed() float32 {
+
+	return c.elapsed
+}
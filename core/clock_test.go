@@ -0,0 +1,40 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// Test the clock deltas when running, paused, scaled and with a fixed delta
+func TestClock(t *testing.T) {
+
+	now := time.Unix(0, 0)
+	c := NewClock()
+	c.now = func() time.Time { return now }
+
+	// checkTick advances the time source and ticks the clock
+	checkTick := func(step time.Duration, delta, elapsed float32) {
+		now = now.Add(step)
+		c.Tick()
+		if c.Delta() != delta || c.Elapsed() != elapsed {
+			t.Fatalf("expected delta %v and elapsed %v got %v and %v", delta, elapsed, c.Delta(), c.Elapsed())
+		}
+	}
+	checkTick(time.Second, 0, 0)
+	checkTick(time.Second/2, 0.5, 0.5)
+	c.Pause()
+	checkTick(time.Second, 0, 0.5)
+	now = now.Add(time.Second)
+	c.Resume()
+	checkTick(time.Second/4, 0.25, 0.75)
+	c.SetScale(2)
+	checkTick(time.Second/4, 0.5, 1.25)
+	c.SetFixedDelta(0.125)
+	checkTick(time.Second, 0.25, 1.5)
+	c.Start()
+	checkTick(time.Second, 0.25, 0.25)
+}
@@ -85,6 +85,15 @@ func (d *Dispatcher) UnsubscribeID(evname string, id interface{}) int {
 	return found
 }
 
+// Unsubscribe unsubscribes all the subscriptions to the specified event.
+// Returns the number of subscriptions found.
+func (d *Dispatcher) Unsubscribe(evname string) int {
+
+	found := len(d.evmap[evname])
+	delete(d.evmap, evname)
+	return found
+}
+
 // UnsubscribeAllID unsubscribes from all events with the specified subscription id.
 // Returns the number of subscriptions found.
 func (d *Dispatcher) UnsubscribeAllID(id interface{}) int {
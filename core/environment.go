@@ -0,0 +1,120 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"encoding/json"
+
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// FogMode is the way the fog density increases with the distance from the camera.
+type FogMode int
+
+// The fog modes
+const (
+	FogNone   = FogMode(iota) // No fog
+	FogLinear                 // Fog increasing linearly between the near and far distances
+	FogExp2                   // Fog increasing exponentially with the squared distance
+)
+
+// Fog contains the parameters of the fog of a scene.
+type Fog struct {
+	Mode    FogMode      `json:"mode"`    // Fog mode
+	Color   math32.Color `json:"color"`   // Fog color
+	Near    float32      `json:"near"`    // Distance where the linear fog starts
+	Far     float32      `json:"far"`     // Distance where the linear fog is opaque
+	Density float32      `json:"density"` // Density of the exponential fog
+}
+
+// Environment contains the world settings of a scene which are not
+// carried by its nodes, read by the renderer at each render of the scene.
+type Environment struct {
+	AmbientColor     math32.Color  `json:"ambientColor"`     // Color of the ambient light added to the scene lights
+	AmbientIntensity float32       `json:"ambientIntensity"` // Intensity of the ambient light or 0 for none
+	Fog              Fog           `json:"fog"`              // Fog of the scene
+	Background       math32.Color4 `json:"background"`       // Color the framebuffer is cleared with
+	ClearColor       bool          `json:"clearColor"`       // Whether the color buffer is cleared before rendering
+	ForceClear       bool          `json:"forceClear"`       // Whether the color buffer is cleared behind an opaque sky
+}
+
+// IScene is the interface for root nodes which carry an Environment.
+// Scenes set as the renderer scene without an environment use the current
+// clear color and no fog or additional ambient light.
+type IScene interface {
+	INode
+	Environment() *Environment
+}
+
+// ISky is the interface for sky nodes which can cover the whole background.
+// The color buffer is not cleared behind a visible sky covering the background
+// unless the ForceClear environment setting is set, as clearing is faster than
+// overwriting the previous contents on tiled mobile GPUs.
+type ISky interface {
+	INode
+	CoversBackground() bool
+}
+
+// Scene is a root node with the environment of the world it contains
+// and an optional sky, such as a skybox or a sky dome.
+type Scene struct {
+	Node             // Embedded node
+	env  Environment // Environment settings
+	sky  INode       // Current sky node
+}
+
+func init() {
+	RegisterSceneType("Scene", func() INode { return NewScene() })
+}
+
+// NewScene creates and returns a pointer to a new Scene with a black
+// background, which is cleared before rendering, and no fog or ambient light.
+func NewScene() *Scene {
+
+	s := new(Scene)
+	s.Node.Init()
+	s.env.AmbientColor = math32.Color{1, 1, 1}
+	s.env.Fog = Fog{Mode: FogNone, Color: math32.Color{1, 1, 1}, Near: 1, Far: 100, Density: 0.05}
+	s.env.Background = math32.Color4{0, 0, 0, 1}
+	s.env.ClearColor = true
+	return s
+}
+
+// Environment returns a pointer to the environment settings of this scene.
+func (s *Scene) Environment() *Environment {
+
+	return &s.env
+}
+
+// SetSky sets the node drawn as the background of this scene, replacing the previous one.
+// It is added to the children of the scene, or nil to remove the current sky.
+func (s *Scene) SetSky(sky INode) {
+
+	if s.sky != nil {
+		s.Remove(s.sky)
+	}
+	s.sky = sky
+	if sky != nil {
+		s.Add(sky)
+	}
+}
+
+// Sky returns the current sky node of this scene or nil.
+func (s *Scene) Sky() INode {
+
+	return s.sky
+}
+
+// SceneData satisfies the ISceneNode interface and returns the environment of this scene.
+func (s *Scene) SceneData() (interface{}, error) {
+
+	return &s.env, nil
+}
+
+// SetSceneData satisfies the ISceneNode interface and sets the environment of this scene.
+func (s *Scene) SetSceneData(data json.RawMessage) error {
+
+	return json.Unmarshal(data, &s.env)
+}
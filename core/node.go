@@ -45,6 +45,12 @@ type Node struct {
 	matrixWorld math32.Matrix4    // World transform matrix. Contains all absolute position/rotation/scale information (i.e. relative to very top parent, generally the scene)
 }
 
+// Node events dispatched to the node and then to its ancestors
+const (
+	OnChildAdded   = "core.OnChildAdded"   // A child was added, the event data is the child INode
+	OnChildRemoved = "core.OnChildRemoved" // A child was removed, the event data is the child INode
+)
+
 // NewNode returns a pointer to a new Node.
 func NewNode() *Node {
 
@@ -286,6 +292,7 @@ func (n *Node) Add(ichild INode) *Node {
 
 	n.setParentOf(ichild)
 	n.children = append(n.children, ichild)
+	n.Dispatch(OnChildAdded, ichild)
 	return n
 }
 
@@ -304,6 +311,38 @@ func (n *Node) AddAt(idx int, ichild INode) {
 	n.children = append(n.children, nil)
 	copy(n.children[idx+1:], n.children[idx:])
 	n.children[idx] = ichild
+	n.Dispatch(OnChildAdded, ichild)
+}
+
+// AttachPreservingTransform adds the specified node to the list of children like Add, and
+// changes its local transform so that its world transform is unchanged by the reparenting,
+// such as when picking up an object in a hand. The world transforms are computed from the
+// local transforms of the ancestors. The shear of non uniformly scaled ancestors is lost.
+func (n *Node) AttachPreservingTransform(ichild INode) *Node {
+
+	child := ichild.GetNode()
+	world := child.computeMatrixWorld()
+	parent := n.computeMatrixWorld()
+	var inverse, local math32.Matrix4
+	inverse.GetInverse(&parent)
+	local.MultiplyMatrices(&inverse, &world)
+	n.Add(ichild)
+	child.SetMatrix(&local)
+	return n
+}
+
+// computeMatrixWorld returns the world matrix of this node computed from
+// its local matrix and the ones of its ancestors.
+func (n *Node) computeMatrixWorld() math32.Matrix4 {
+
+	n.UpdateMatrix()
+	m := n.matrix
+	for p := n.parent; p != nil; p = p.GetNode().parent {
+		parent := p.GetNode()
+		parent.UpdateMatrix()
+		m.MultiplyMatrices(&parent.matrix, &m)
+	}
+	return m
 }
 
 // setParentOf is used by Add and AddAt.
@@ -324,6 +363,20 @@ func (n *Node) setParentOf(ichild INode) {
 	child.parent = n
 }
 
+// Dispatch dispatches the specified event and data to the subscribers of this node
+// and then to the ones of its ancestors, until a subscriber cancels the propagation.
+// Returns true if the propagation was cancelled.
+func (n *Node) Dispatch(evname string, ev interface{}) bool {
+
+	if n.Dispatcher.Dispatch(evname, ev) {
+		return true
+	}
+	if n.parent != nil {
+		return n.parent.Dispatch(evname, ev)
+	}
+	return false
+}
+
 // ChildAt returns the child at the specified index.
 func (n *Node) ChildAt(idx int) INode {
 
@@ -354,6 +407,7 @@ func (n *Node) Remove(ichild INode) bool {
 			n.children[len(n.children)-1] = nil
 			n.children = n.children[:len(n.children)-1]
 			ichild.GetNode().parent = nil
+			n.Dispatch(OnChildRemoved, ichild)
 			return true
 		}
 	}
@@ -374,6 +428,7 @@ func (n *Node) RemoveAt(idx int) INode {
 	copy(n.children[idx:], n.children[idx+1:])
 	n.children[len(n.children)-1] = nil
 	n.children = n.children[:len(n.children)-1]
+	n.Dispatch(OnChildRemoved, child)
 
 	return child
 }
@@ -726,6 +781,22 @@ func (n *Node) WorldDirection(result *math32.Vector3) {
 	result.ApplyQuaternion(&quaternion)
 }
 
+// LocalToWorld returns the world coordinates of the specified point in the local
+// coordinates of this node, using the world matrix of the last UpdateMatrixWorld.
+func (n *Node) LocalToWorld(p math32.Vector3) math32.Vector3 {
+
+	return *p.ApplyMatrix4(&n.matrixWorld)
+}
+
+// WorldToLocal returns the local coordinates of this node of the specified point in world
+// coordinates, using the world matrix of the last UpdateMatrixWorld, which must be invertible.
+func (n *Node) WorldToLocal(p math32.Vector3) math32.Vector3 {
+
+	var inverse math32.Matrix4
+	inverse.GetInverse(&n.matrixWorld)
+	return *p.ApplyMatrix4(&inverse)
+}
+
 // MatrixWorld returns a copy of the matrix world of this node.
 func (n *Node) MatrixWorld() math32.Matrix4 {
 
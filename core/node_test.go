@@ -0,0 +1,117 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// Test that node events bubble up to the ancestors until cancelled
+func TestNodeDispatch(t *testing.T) {
+
+	root := NewNode()
+	parent := NewNode()
+	child := NewNode()
+	root.Add(parent)
+	parent.Add(child)
+
+	var received []string
+	root.Subscribe("hit", func(evname string, ev interface{}) { received = append(received, "root") })
+	parent.Subscribe("hit", func(evname string, ev interface{}) { received = append(received, "parent") })
+	child.Subscribe("hit", func(evname string, ev interface{}) { received = append(received, "child") })
+	if child.Dispatch("hit", nil) {
+		t.Fatalf("unexpected cancelled dispatch")
+	}
+	if len(received) != 3 || received[0] != "child" || received[2] != "root" {
+		t.Fatalf("expected child, parent and root got %v", received)
+	}
+
+	// Cancelling at the parent stops the propagation
+	received = received[:0]
+	parent.Unsubscribe("hit")
+	parent.Subscribe("hit", func(evname string, ev interface{}) { parent.CancelDispatch() })
+	if !child.Dispatch("hit", nil) || len(received) != 1 {
+		t.Fatalf("expected cancelled dispatch after child got %v", received)
+	}
+
+	// Lifecycle events reach the root
+	var added INode
+	root.Subscribe(OnChildAdded, func(evname string, ev interface{}) { added = ev.(INode) })
+	other := NewNode()
+	child.Add(other)
+	if added != other {
+		t.Fatalf("expected child added event at the root")
+	}
+}
+
+// Test the conversions between the world and local coordinates of a nested, rotated and scaled hierarchy
+func TestNodeWorldToLocal(t *testing.T) {
+
+	root := NewNode()
+	root.SetPosition(1, 2, 3)
+	root.SetRotation(0, math32.Pi/2, 0)
+	root.SetScale(2, 2, 2)
+	child := NewNode()
+	child.SetPosition(0, 1, 0)
+	child.SetRotation(math32.Pi/4, 0, 0.3)
+	child.SetScale(0.5, 1, 3)
+	root.Add(child)
+	root.UpdateMatrixWorld()
+
+	// The local X axis of the root is the world -Z axis scaled by 2
+	world := root.LocalToWorld(math32.Vector3{1, 0, 0})
+	if expected := (math32.Vector3{1, 2, 1}); !world.AlmostEquals(&expected, 1e-5) {
+		t.Errorf("expected %v got %v", expected, world)
+	}
+	origin := child.LocalToWorld(math32.Vector3{})
+	var pos math32.Vector3
+	child.WorldPosition(&pos)
+	if !origin.AlmostEquals(&pos, 1e-5) || !origin.AlmostEquals(&math32.Vector3{1, 4, 3}, 1e-5) {
+		t.Errorf("expected the child origin at its world position %v got %v", pos, origin)
+	}
+
+	for _, p := range []math32.Vector3{{0, 0, 0}, {1, -2, 3}, {-5, 0.5, 7}} {
+		local := child.WorldToLocal(child.LocalToWorld(p))
+		if !local.AlmostEquals(&p, 1e-4) {
+			t.Errorf("expected the round trip of %v got %v", p, local)
+		}
+		local = child.WorldToLocal(p)
+		if world := child.LocalToWorld(local); !world.AlmostEquals(&p, 1e-4) {
+			t.Errorf("expected the round trip of %v got %v", p, world)
+		}
+	}
+}
+
+// Test that attaching a node to another rotated and scaled parent preserves its world transform
+func TestAttachPreservingTransform(t *testing.T) {
+
+	root := NewNode()
+	hand := NewNode()
+	hand.SetPosition(3, 1, -2)
+	hand.SetRotation(0.4, 1.2, -0.3)
+	hand.SetScale(2, 2, 2)
+	root.Add(hand)
+	item := NewNode()
+	item.SetPosition(-1, 4, 5)
+	item.SetRotation(0, 0.5, 1)
+	item.SetScale(1, 1.5, 1)
+	root.Add(item)
+	root.UpdateMatrixWorld()
+	before := item.MatrixWorld()
+
+	hand.AttachPreservingTransform(item)
+	if item.Parent() != hand || len(root.Children()) != 1 {
+		t.Fatalf("expected the item to be moved to the hand")
+	}
+	root.UpdateMatrixWorld()
+	after := item.MatrixWorld()
+	for i := range before {
+		if math32.Abs(before[i]-after[i]) > 1e-4 {
+			t.Fatalf("expected the world matrix %v got %v", before, after)
+		}
+	}
+}
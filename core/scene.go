@@ -0,0 +1,166 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// ISceneNode is the interface for node types which save and restore
+// their type specific data in scene documents.
+type ISceneNode interface {
+	INode
+	SceneData() (interface{}, error)
+	SetSceneData(data json.RawMessage) error
+}
+
+// Version of the scene documents written by SaveScene
+const sceneVersion = 1
+
+// Maps the registered node type names to their constructors and back
+var sceneTypes = map[string]func() INode{}
+var sceneTypeNames = map[reflect.Type]string{}
+
+// sceneDocument is the root of a scene document
+type sceneDocument struct {
+	Version int        `json:"version"`
+	Root    *sceneNode `json:"root"`
+}
+
+// sceneNode describes one node of a scene document
+type sceneNode struct {
+	Type       string          `json:"type"`
+	Name       string          `json:"name,omitempty"`
+	Visible    bool            `json:"visible"`
+	Position   math32.Vector3  `json:"position"`
+	Quaternion [4]float32      `json:"quaternion"`
+	Scale      math32.Vector3  `json:"scale"`
+	Data       json.RawMessage `json:"data,omitempty"`
+	Children   []*sceneNode    `json:"children,omitempty"`
+}
+
+func init() {
+	RegisterSceneType("Node", func() INode { return NewNode() })
+}
+
+// RegisterSceneType registers the constructor of a node type with the name
+// used to identify it in scene documents. Node types which are not registered
+// cannot be saved or loaded. Types implementing ISceneNode also save and restore
+// their specific data. Panics if the name is already registered.
+func RegisterSceneType(name string, create func() INode) {
+
+	if _, ok := sceneTypes[name]; ok {
+		panic(fmt.Sprintf("scene type %q already registered", name))
+	}
+	sceneTypes[name] = create
+	sceneTypeNames[reflect.TypeOf(create())] = name
+}
+
+// SaveScene writes the hierarchy, transforms and type specific data of
+// the specified node and all its descendants as a JSON scene document.
+func SaveScene(w io.Writer, root INode) error {
+
+	sroot, err := saveSceneNode(root)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&sceneDocument{Version: sceneVersion, Root: sroot})
+}
+
+// LoadScene reads a JSON scene document written by SaveScene and
+// returns its root node with all its descendants.
+func LoadScene(r io.Reader) (INode, error) {
+
+	var doc sceneDocument
+	err := json.NewDecoder(r).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Version != sceneVersion {
+		return nil, fmt.Errorf("unsupported scene version: %d", doc.Version)
+	}
+	if doc.Root == nil {
+		return nil, fmt.Errorf("scene has no root node")
+	}
+	return loadSceneNode(doc.Root)
+}
+
+// saveSceneNode returns the description of the specified node and its children.
+func saveSceneNode(inode INode) (*sceneNode, error) {
+
+	name, ok := sceneTypeNames[reflect.TypeOf(inode)]
+	if !ok {
+		return nil, fmt.Errorf("scene type not registered: %T", inode)
+	}
+	n := inode.GetNode()
+	q := n.Quaternion()
+	sn := &sceneNode{
+		Type:       name,
+		Name:       n.Name(),
+		Visible:    n.Visible(),
+		Position:   n.Position(),
+		Quaternion: [4]float32{q.X, q.Y, q.Z, q.W},
+		Scale:      n.Scale(),
+	}
+	if isn, ok := inode.(ISceneNode); ok {
+		data, err := isn.SceneData()
+		if err != nil {
+			return nil, err
+		}
+		sn.Data, err = json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, ichild := range n.Children() {
+		schild, err := saveSceneNode(ichild)
+		if err != nil {
+			return nil, err
+		}
+		sn.Children = append(sn.Children, schild)
+	}
+	return sn, nil
+}
+
+// loadSceneNode creates the node from the specified description and its children.
+func loadSceneNode(sn *sceneNode) (INode, error) {
+
+	create, ok := sceneTypes[sn.Type]
+	if !ok {
+		return nil, fmt.Errorf("scene type not registered: %q", sn.Type)
+	}
+	inode := create()
+	if isn, ok := inode.(ISceneNode); ok {
+		// The nodes with specific data, such as the geometry of a mesh, are not usable without it
+		if len(sn.Data) == 0 {
+			return nil, fmt.Errorf("scene node %q: missing %s data", sn.Name, sn.Type)
+		}
+		err := isn.SetSceneData(sn.Data)
+		if err != nil {
+			return nil, fmt.Errorf("scene node %q: %v", sn.Name, err)
+		}
+	}
+	n := inode.GetNode()
+	n.SetName(sn.Name)
+	n.SetVisible(sn.Visible)
+	n.SetPositionVec(&sn.Position)
+	n.SetQuaternion(sn.Quaternion[0], sn.Quaternion[1], sn.Quaternion[2], sn.Quaternion[3])
+	n.SetScaleVec(&sn.Scale)
+	for _, schild := range sn.Children {
+		ichild, err := loadSceneNode(schild)
+		if err != nil {
+			return nil, err
+		}
+		n.Add(ichild)
+	}
+	return inode, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package core
+
+// FixedStepper accumulates the variable time elapsed between frames and calls
+// an update function a whole number of times per frame with a fixed time step,
+// so that physics and animations are integrated deterministically regardless of
+// the frame rate. The remaining accumulated time is available as an interpolation
+// factor between the last two updated states for rendering.
+type FixedStepper struct {
+	step     float32          // Fixed time step in seconds
+	update   func(dt float32) // Update function
+	acc      float32          // Accumulated time not yet updated
+	maxSteps int              // Maximum number of steps per advance
+}
+
+// NewFixedStepper creates and returns a pointer to a new FixedStepper
+// which calls the specified update function with the specified time step in seconds.
+func NewFixedStepper(step float32, update func(dt float32)) *FixedStepper {
+
+	if step <= 0 {
+		panic("NewFixedStepper: step must be greater than 0")
+	}
+	fs := new(FixedStepper)
+	fs.step = step
+	fs.update = update
+	fs.maxSteps = 8
+	return fs
+}
+
+// Step returns the fixed time step in seconds.
+func (fs *FixedStepper) Step() float32 {
+
+	return fs.step
+}
+
+// SetMaxSteps sets the maximum number of updates per advance. The accumulated
+// time exceeding this number of steps is dropped, so that the application slows
+// down instead of falling further behind when updates take longer than the step.
+// The default value is 8.
+func (fs *FixedStepper) SetMaxSteps(max int) {
+
+	fs.maxSteps = max
+}
+
+// Advance accumulates the specified time in seconds, calls the update function
+// for each whole step of accumulated time and returns the number of updates.
+func (fs *FixedStepper) Advance(delta float32) int {
+
+	fs.acc += delta
+	steps := 0
+	for fs.acc >= fs.step {
+		if fs.maxSteps > 0 && steps == fs.maxSteps {
+			fs.acc = 0
+			break
+		}
+		fs.update(fs.step)
+		fs.acc -= fs.step
+		steps++
+	}
+	return steps
+}
+
+// AdvanceClock advances the stepper by the time the specified clock advanced at its last tick.
+func (fs *FixedStepper) AdvanceClock(clock *Clock) int {
+
+	return fs.Advance(clock.Delta())
+}
+
+// Alpha returns the fraction of a step of accumulated time not yet updated, in the [0,1)
+// range, used to interpolate between the previous and current updated states for rendering.
+func (fs *FixedStepper) Alpha() float32 {
+
+	return fs.acc / fs.step
+}
+
+// Reset discards the accumulated time.
+func (fs *FixedStepper) Reset() {
+
+	fs.acc = 0
+}
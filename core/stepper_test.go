@@ -0,0 +1,37 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"testing"
+)
+
+// Test the number of fixed steps and the interpolation factor
+func TestFixedStepper(t *testing.T) {
+
+	var total float32
+	fs := NewFixedStepper(0.25, func(dt float32) { total += dt })
+	for _, c := range []struct {
+		delta float32
+		steps int
+		alpha float32
+	}{{0.1, 0, 0.4}, {0.2, 1, 0.2}, {0.55, 2, 0.4}, {0, 0, 0.4}} {
+		if n := fs.Advance(c.delta); n != c.steps {
+			t.Errorf("delta %v: expected %d steps got %d", c.delta, c.steps, n)
+		}
+		if a := fs.Alpha(); a < c.alpha-1e-4 || a > c.alpha+1e-4 {
+			t.Errorf("delta %v: expected alpha %v got %v", c.delta, c.alpha, a)
+		}
+	}
+	if total != 0.75 {
+		t.Errorf("expected total time 0.75 got %v", total)
+	}
+
+	// Steps exceeding the maximum are dropped
+	fs.SetMaxSteps(2)
+	if n := fs.Advance(10); n != 2 || fs.Alpha() != 0 {
+		t.Errorf("expected 2 steps and no remaining time got %d and %v", n, fs.Alpha())
+	}
+}
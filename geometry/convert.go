@@ -0,0 +1,198 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"bytes"
+	"math"
+
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// ToNonIndexed returns a new non indexed geometry with one vertex for each index
+// of this geometry, so that no vertex is shared between triangles.
+// All the vertex attributes and the geometry groups are preserved and
+// instanced attributes are copied unchanged.
+// A non indexed geometry is returned as a copy.
+func (g *Geometry) ToNonIndexed() *Geometry {
+
+	order := g.vertexOrder()
+	ng := NewGeometry()
+	for _, vbo := range g.vbos {
+		ng.AddVBO(copyVBO(vbo, order))
+	}
+	ng.AddGroupList(g.groups)
+	return ng
+}
+
+// ToIndexed returns a new indexed geometry in which the vertices of this geometry
+// with all their float attribute components within the specified tolerance are welded
+// into a single vertex. Attributes with non float elements must be equal to be welded.
+// A zero tolerance only welds identical vertices.
+// All the vertex attributes and the geometry groups are preserved and
+// instanced attributes are copied unchanged.
+func (g *Geometry) ToIndexed(weldTolerance float32) *Geometry {
+
+	if weldTolerance < 0 {
+		panic("Geometry.ToIndexed: weld tolerance must not be negative")
+	}
+	order := g.vertexOrder()
+
+	// Locates the vertex positions used to hash the vertices
+	var posData math32.ArrayF32
+	posOffset, posStride := 0, 0
+	if vbo := g.VBO(gls.VertexPosition); vbo != nil && vbo.Bytes() == nil {
+		posData = *vbo.Buffer()
+		posOffset = int(vbo.Attrib(gls.VertexPosition).ByteOffset) / 4
+		posStride = vbo.StrideSize() / 4
+	}
+	cellKey := func(v uint32, dx, dy, dz int64) [3]int64 {
+		if posData == nil {
+			return [3]int64{}
+		}
+		p := posData[int(v)*posStride+posOffset:]
+		if weldTolerance == 0 {
+			return [3]int64{int64(math.Float32bits(p[0])), int64(math.Float32bits(p[1])), int64(math.Float32bits(p[2]))}
+		}
+		return [3]int64{
+			int64(math.Floor(float64(p[0]/weldTolerance))) + dx,
+			int64(math.Floor(float64(p[1]/weldTolerance))) + dy,
+			int64(math.Floor(float64(p[2]/weldTolerance))) + dz,
+		}
+	}
+
+	// Welds each vertex to an equivalent vertex of the neighbouring cells if any
+	vertexVBOs := make([]*gls.VBO, 0, len(g.vbos))
+	for _, vbo := range g.vbos {
+		if !instancedVBO(vbo) {
+			vertexVBOs = append(vertexVBOs, vbo)
+		}
+	}
+	r := int64(1)
+	if weldTolerance == 0 || posData == nil {
+		r = 0
+	}
+	cells := make(map[[3]int64][]uint32)
+	unique := make([]uint32, 0)
+	indices := math32.NewArrayU32(0, len(order))
+	for _, v := range order {
+		idx := -1
+	search:
+		for dx := -r; dx <= r; dx++ {
+			for dy := -r; dy <= r; dy++ {
+				for dz := -r; dz <= r; dz++ {
+					for _, u := range cells[cellKey(v, dx, dy, dz)] {
+						if sameVertex(vertexVBOs, unique[u], v, weldTolerance) {
+							idx = int(u)
+							break search
+						}
+					}
+				}
+			}
+		}
+		if idx < 0 {
+			idx = len(unique)
+			key := cellKey(v, 0, 0, 0)
+			cells[key] = append(cells[key], uint32(idx))
+			unique = append(unique, v)
+		}
+		indices.Append(uint32(idx))
+	}
+
+	ng := NewGeometry()
+	for _, vbo := range g.vbos {
+		ng.AddVBO(copyVBO(vbo, unique))
+	}
+	ng.SetIndices(indices)
+	ng.AddGroupList(g.groups)
+	return ng
+}
+
+// vertexOrder returns the vertices of the geometry in drawing order.
+func (g *Geometry) vertexOrder() []uint32 {
+
+	if g.Indexed() {
+		return append([]uint32(nil), g.indices...)
+	}
+	order := make([]uint32, g.Items())
+	for i := range order {
+		order[i] = uint32(i)
+	}
+	return order
+}
+
+// instancedVBO returns if the specified VBO has per instance attributes.
+func instancedVBO(vbo *gls.VBO) bool {
+
+	for _, attrib := range vbo.Attributes() {
+		if attrib.Divisor > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// copyVBO returns a new VBO with the same attributes as the specified VBO
+// and the data of the specified vertices. Instanced VBOs are copied unchanged.
+func copyVBO(vbo *gls.VBO, vertices []uint32) *gls.VBO {
+
+	var nvbo *gls.VBO
+	stride := vbo.StrideSize()
+	if src := vbo.Bytes(); src != nil {
+		var data []byte
+		if instancedVBO(vbo) {
+			data = append(data, src...)
+		} else {
+			data = make([]byte, 0, len(vertices)*stride)
+			for _, v := range vertices {
+				data = append(data, src[int(v)*stride:int(v+1)*stride]...)
+			}
+		}
+		nvbo = gls.NewVBOBytes(data)
+	} else {
+		src := *vbo.Buffer()
+		stride /= 4
+		var data math32.ArrayF32
+		if instancedVBO(vbo) {
+			data = append(data, src...)
+		} else {
+			data = math32.NewArrayF32(0, len(vertices)*stride)
+			for _, v := range vertices {
+				data.Append(src[int(v)*stride : int(v+1)*stride]...)
+			}
+		}
+		nvbo = gls.NewVBO(data)
+	}
+	for i, attrib := range vbo.Attributes() {
+		nvbo.AddCustomAttribOffset(attrib.Name, attrib.NumElements, attrib.ByteOffset)
+		*nvbo.AttribAt(i) = attrib
+	}
+	return nvbo
+}
+
+// sameVertex returns if the attributes of the two specified vertices are within the tolerance.
+func sameVertex(vbos []*gls.VBO, a, b uint32, tolerance float32) bool {
+
+	for _, vbo := range vbos {
+		stride := vbo.StrideSize()
+		if data := vbo.Bytes(); data != nil {
+			if !bytes.Equal(data[int(a)*stride:int(a+1)*stride], data[int(b)*stride:int(b+1)*stride]) {
+				return false
+			}
+			continue
+		}
+		data := *vbo.Buffer()
+		stride /= 4
+		va := data[int(a)*stride : int(a+1)*stride]
+		vb := data[int(b)*stride : int(b+1)*stride]
+		for i := range va {
+			if math32.Abs(va[i]-vb[i]) > tolerance {
+				return false
+			}
+		}
+	}
+	return true
+}
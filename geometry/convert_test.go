@@ -0,0 +1,56 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"testing"
+
+	"github.com/thommil/tge-g3n/gls"
+)
+
+// Test a cube round trip between indexed and non indexed geometries
+func TestIndexedConversion(t *testing.T) {
+
+	box := NewBox(1, 1, 1)
+	if box.Items() != 24 || len(box.Indices()) != 36 {
+		t.Fatalf("unexpected box with %d vertices and %d indices", box.Items(), len(box.Indices()))
+	}
+
+	flat := box.ToNonIndexed()
+	if flat.Indexed() {
+		t.Fatal("expected non indexed geometry")
+	}
+	if flat.Items() != 36 {
+		t.Errorf("expected 36 vertices got %d", flat.Items())
+	}
+	if flat.GroupCount() != box.GroupCount() {
+		t.Errorf("expected %d groups got %d", box.GroupCount(), flat.GroupCount())
+	}
+	for _, atype := range []gls.AttribType{gls.VertexPosition, gls.VertexNormal, gls.VertexTexcoord} {
+		if flat.VBO(atype) == nil {
+			t.Errorf("missing attribute %d", atype)
+		}
+	}
+	if flat.Area() != box.Area() {
+		t.Errorf("expected area %v got %v", box.Area(), flat.Area())
+	}
+
+	// Normals and texture coordinates differ between faces so only the face vertices are welded
+	indexed := flat.ToIndexed(1e-4)
+	if !indexed.Indexed() {
+		t.Fatal("expected indexed geometry")
+	}
+	if indexed.Items() != 24 || len(indexed.Indices()) != 36 {
+		t.Errorf("expected 24 vertices and 36 indices got %d and %d", indexed.Items(), len(indexed.Indices()))
+	}
+	if indexed.Area() != box.Area() {
+		t.Errorf("expected area %v got %v", box.Area(), indexed.Area())
+	}
+
+	// A large tolerance welds vertices with different normals
+	if n := flat.ToIndexed(2).Items(); n != 1 {
+		t.Errorf("expected 1 vertex got %d", n)
+	}
+}
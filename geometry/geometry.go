@@ -28,7 +28,13 @@ type Geometry struct {
 	indices       math32.ArrayU32   // Buffer with indices
 	handleIndices uint32            // Handle to OpenGL buffer for indices
 	updateIndices bool              // Flag to indicate that indices must be transferred
+	indicesSize   int               // Size in bytes of the OpenGL indices data store
+	usage         uint32            // Expected usage pattern of the buffers
+	indexType     uint32            // Type of the transferred indices (UNSIGNED_SHORT or UNSIGNED_INT)
+	indexBytes    []byte            // Conversion buffer of the 16 bits indices
+	restart       bool              // Indicates if the indices contain RestartIndex to restart the strips
 	ShaderDefines gls.ShaderDefines // Geometry-specific shader defines
+	path          string            // Optional path of the file this geometry was loaded from
 
 	// Geometric properties
 	boundingBox    math32.Box3    // Last calculated bounding box
@@ -43,8 +49,17 @@ type Geometry struct {
 	areaValid           bool // Indicates if last calculated area is valid
 	volumeValid         bool // Indicates if last calculated volume is valid
 	rotInertiaValid     bool // Indicates if last calculated rotational inertia matrix is valid
+
+	// Tracking of the vertex positions changes
+	posVersion    uint32 // Version of the positions VBO the geometric properties correspond to
+	posTracked    bool   // Indicates if the positions VBO version is tracked
+	normalsDirty  bool   // Indicates if the vertex normals are stale
+	tangentsDirty bool   // Indicates if the vertex tangents are stale
 }
 
+// RestartIndex is the index which separates the strips of a geometry with primitive restart.
+const RestartIndex = 0xFFFFFFFF
+
 // Group is a geometry group object.
 type Group struct {
 	Start    int    // Index of first element of the group
@@ -71,6 +86,9 @@ func (g *Geometry) Init() {
 	g.handleVAO = 0
 	g.handleIndices = 0
 	g.updateIndices = true
+	g.indicesSize = 0
+	g.usage = gls.STATIC_DRAW
+	g.indexType = gls.UNSIGNED_INT
 	g.ShaderDefines = *gls.NewShaderDefines()
 }
 
@@ -106,6 +124,19 @@ func (g *Geometry) Dispose() {
 	g.Init()
 }
 
+// SetPath sets the path of the file this geometry was loaded from,
+// used to reference it instead of saving its vertices in scene documents.
+func (g *Geometry) SetPath(path string) {
+
+	g.path = path
+}
+
+// Path returns the path of the file this geometry was loaded from or an empty string.
+func (g *Geometry) Path() string {
+
+	return g.path
+}
+
 // GetGeometry satisfies the IGeometry interface.
 func (g *Geometry) GetGeometry() *Geometry {
 
@@ -148,12 +179,65 @@ func (g *Geometry) SetIndices(indices math32.ArrayU32) {
 	g.boundingSphereValid = false
 }
 
+// SetUsage sets the expected usage pattern of the indices buffer and of the VBOs of this
+// geometry, including the ones added later: gls.STATIC_DRAW (the default) for buffers set once,
+// gls.DYNAMIC_DRAW for buffers modified repeatedly, such as deformable terrains,
+// or gls.STREAM_DRAW for buffers modified at each frame, such as particles.
+// It lets the driver place the buffers appropriately and should be called before
+// the geometry is first rendered, as it applies when the buffers are allocated.
+func (g *Geometry) SetUsage(usage uint32) {
+
+	if usage != gls.STATIC_DRAW && usage != gls.DYNAMIC_DRAW && usage != gls.STREAM_DRAW {
+		panic("Geometry.SetUsage: invalid usage")
+	}
+	g.usage = usage
+	for _, vbo := range g.vbos {
+		vbo.SetUsage(usage)
+	}
+}
+
+// Usage returns the expected usage pattern of the buffers of this geometry.
+func (g *Geometry) Usage() uint32 {
+
+	return g.usage
+}
+
 // Indices returns the indices array for this geometry.
 func (g *Geometry) Indices() math32.ArrayU32 {
 
 	return g.indices
 }
 
+// IndexType returns the type of the indices transferred to OpenGL by the last
+// RenderSetup: UNSIGNED_SHORT if all the indices fit in 16 bits, UNSIGNED_INT otherwise.
+// The largest 16 bits index is left for primitive restart.
+func (g *Geometry) IndexType() uint32 {
+
+	return g.indexType
+}
+
+// IndexSize returns the size in bytes of the indices transferred to OpenGL.
+func (g *Geometry) IndexSize() uint32 {
+
+	if g.indexType == gls.UNSIGNED_SHORT {
+		return 2
+	}
+	return 4
+}
+
+// SetPrimitiveRestart sets whether the indices of this geometry contain RestartIndex
+// to draw several triangle or line strips in a single draw call.
+func (g *Geometry) SetPrimitiveRestart(state bool) {
+
+	g.restart = state
+}
+
+// PrimitiveRestart returns whether the indices of this geometry contain RestartIndex.
+func (g *Geometry) PrimitiveRestart() bool {
+
+	return g.restart
+}
+
 // SetVAO sets the Vertex Array Object handle associated with this geometry.
 func (g *Geometry) SetVAO(handle uint32) {
 
@@ -167,6 +251,7 @@ func (g *Geometry) VAO() uint32 {
 }
 
 // AddVBO adds a Vertex Buffer Object for this geometry.
+// The VBO gets the usage pattern set by SetUsage if it is not the default gls.STATIC_DRAW.
 func (g *Geometry) AddVBO(vbo *gls.VBO) {
 
 	// Check that the provided VBO doesn't have conflicting attributes with existing VBOs
@@ -181,9 +266,31 @@ func (g *Geometry) AddVBO(vbo *gls.VBO) {
 		}
 	}
 
+	if g.usage != gls.STATIC_DRAW {
+		vbo.SetUsage(g.usage)
+	}
 	g.vbos = append(g.vbos, vbo)
 }
 
+// AddInstancedAttribute adds a VBO with a custom attribute with the specified name,
+// data and number of components per value, which advances once per divisor instances
+// when the geometry is rendered with instancing. It must be added after the VBO
+// with the vertex positions and returns the new VBO. The attribute is left
+// disabled if the OpenGL 3 functions are not supported (see GLS.GL3Supported).
+func (g *Geometry) AddInstancedAttribute(name string, data math32.ArrayF32, components int, divisor uint32) *gls.VBO {
+
+	if components < 1 || components > 4 {
+		panic("Geometry.AddInstancedAttribute: invalid number of components")
+	}
+	if divisor == 0 {
+		panic("Geometry.AddInstancedAttribute: divisor must be greater than 0")
+	}
+	vbo := gls.NewVBO(data).AddCustomAttrib(name, int32(components))
+	vbo.AttribAt(0).Divisor = divisor
+	g.AddVBO(vbo)
+	return vbo
+}
+
 // VBO returns a pointer to this geometry's VBO which contain the specified attribute.
 // Returns nil if the VBO is not found.
 func (g *Geometry) VBO(atype gls.AttribType) *gls.VBO {
@@ -226,7 +333,7 @@ func (g *Geometry) Items() int {
 	if vbo.AttribCount() == 0 {
 		return 0
 	}
-	return vbo.Buffer().Bytes() / vbo.StrideSize()
+	return vbo.BufferSize() / vbo.StrideSize()
 }
 
 // SetAttributeName sets the name of the VBO attribute associated with the provided attribute type.
@@ -256,14 +363,11 @@ func (g *Geometry) OperateOnVertices(cb func(vertex *math32.Vector3) bool) {
 	if vbo == nil {
 		return
 	}
+	g.checkPositions()
 	vbo.OperateOnVectors3(gls.VertexPosition, cb)
 
 	// Geometric properties may have changed
-	g.boundingBoxValid = false
-	g.boundingSphereValid = false
-	g.areaValid = false
-	g.volumeValid = false
-	g.rotInertiaValid = false
+	g.checkPositions()
 }
 
 // ReadVertices iterates over all the vertices and calls
@@ -291,7 +395,10 @@ func (g *Geometry) OperateOnVertexNormals(cb func(normal *math32.Vector3) bool)
 	if vbo == nil {
 		return
 	}
+	g.checkPositions()
 	vbo.OperateOnVectors3(gls.VertexNormal, cb)
+	g.normalsDirty = false
+	g.syncPositions()
 }
 
 // ReadVertexNormals iterates over all the vertex normals and calls
@@ -339,6 +446,247 @@ func (g *Geometry) ReadFaces(cb func(vA, vB, vC math32.Vector3) bool) {
 	}
 }
 
+// ComputeTangents calculates the per vertex tangents of this geometry from its
+// positions, normals and texture coordinates and stores them in the VertexTangent
+// attribute, adding a new VBO if necessary. The tangent w component contains the
+// handedness of the tangent space. Tangents are used by normal and height maps.
+func (g *Geometry) ComputeTangents() {
+
+	vboPos := g.VBO(gls.VertexPosition)
+	vboNormal := g.VBO(gls.VertexNormal)
+	vboUV := g.VBO(gls.VertexTexcoord)
+	if vboPos == nil || vboNormal == nil || vboUV == nil {
+		return
+	}
+	g.checkPositions()
+	g.tangentsDirty = false
+	items := g.Items()
+
+	// Returns the float element position of the attribute for the specified vertex
+	attribPos := func(vbo *gls.VBO, atype gls.AttribType, idx int) int {
+		return idx*vbo.Stride() + vbo.AttribOffset(atype)
+	}
+
+	// Accumulates the tangents and bitangents of the faces sharing each vertex
+	tan1 := make([]math32.Vector3, items)
+	tan2 := make([]math32.Vector3, items)
+	var v1, v2, v3, sdir, tdir math32.Vector3
+	var uv1, uv2, uv3 math32.Vector2
+	addFace := func(a, b, c int) {
+		vboPos.Buffer().GetVector3(attribPos(vboPos, gls.VertexPosition, a), &v1)
+		vboPos.Buffer().GetVector3(attribPos(vboPos, gls.VertexPosition, b), &v2)
+		vboPos.Buffer().GetVector3(attribPos(vboPos, gls.VertexPosition, c), &v3)
+		vboUV.Buffer().GetVector2(attribPos(vboUV, gls.VertexTexcoord, a), &uv1)
+		vboUV.Buffer().GetVector2(attribPos(vboUV, gls.VertexTexcoord, b), &uv2)
+		vboUV.Buffer().GetVector2(attribPos(vboUV, gls.VertexTexcoord, c), &uv3)
+		v2.Sub(&v1)
+		v3.Sub(&v1)
+		s1, s2 := uv2.X-uv1.X, uv3.X-uv1.X
+		t1, t2 := uv2.Y-uv1.Y, uv3.Y-uv1.Y
+		det := s1*t2 - s2*t1
+		if det == 0 {
+			return
+		}
+		r := 1 / det
+		sdir.Set((t2*v2.X-t1*v3.X)*r, (t2*v2.Y-t1*v3.Y)*r, (t2*v2.Z-t1*v3.Z)*r)
+		tdir.Set((s1*v3.X-s2*v2.X)*r, (s1*v3.Y-s2*v2.Y)*r, (s1*v3.Z-s2*v2.Z)*r)
+		for _, idx := range [3]int{a, b, c} {
+			tan1[idx].Add(&sdir)
+			tan2[idx].Add(&tdir)
+		}
+	}
+	if g.Indexed() {
+		for i := 0; i+2 < g.indices.Size(); i += 3 {
+			addFace(int(g.indices[i]), int(g.indices[i+1]), int(g.indices[i+2]))
+		}
+	} else {
+		for i := 0; i+2 < items; i += 3 {
+			addFace(i, i+1, i+2)
+		}
+	}
+
+	// Gets or creates the VBO for the tangents
+	vboTangent := g.VBO(gls.VertexTangent)
+	if vboTangent == nil {
+		vboTangent = gls.NewVBO(math32.NewArrayF32(4*items, 4*items)).AddAttrib(gls.VertexTangent)
+		g.AddVBO(vboTangent)
+	}
+
+	// Orthogonalizes each tangent against the vertex normal (Gram-Schmidt)
+	// and calculates the handedness of the tangent space
+	var n, t, cross math32.Vector3
+	tangents := vboTangent.Buffer()
+	for i := 0; i < items; i++ {
+		vboNormal.Buffer().GetVector3(attribPos(vboNormal, gls.VertexNormal, i), &n)
+		t.Copy(&n).MultiplyScalar(n.Dot(&tan1[i]))
+		t.SubVectors(&tan1[i], &t).Normalize()
+		w := float32(1)
+		if cross.CrossVectors(&n, &tan1[i]).Dot(&tan2[i]) < 0 {
+			w = -1
+		}
+		tangents.Set(attribPos(vboTangent, gls.VertexTangent, i), t.X, t.Y, t.Z, w)
+	}
+	vboTangent.Update()
+	g.syncPositions()
+}
+
+// ComputeNormals calculates the smooth per vertex normals of this geometry from its
+// positions and stores them in the VertexNormal attribute, adding a new VBO if necessary.
+// The normal of each vertex is the sum of the normals of the faces sharing it weighted by
+// their area, so the vertices of a non indexed geometry receive the normal of their face.
+// NOTE: This only works for triangle-based meshes.
+func (g *Geometry) ComputeNormals() {
+
+	vboPos := g.VBO(gls.VertexPosition)
+	if vboPos == nil {
+		return
+	}
+	g.checkPositions()
+	g.normalsDirty = false
+	items := g.Items()
+	posStride, posOffset := vboPos.Stride(), vboPos.AttribOffset(gls.VertexPosition)
+
+	// Accumulates the normals of the faces, whose length is twice their area
+	normals := make([]math32.Vector3, items)
+	var v1, v2, v3 math32.Vector3
+	addFace := func(a, b, c int) {
+		vboPos.Buffer().GetVector3(a*posStride+posOffset, &v1)
+		vboPos.Buffer().GetVector3(b*posStride+posOffset, &v2)
+		vboPos.Buffer().GetVector3(c*posStride+posOffset, &v3)
+		v2.Sub(&v1)
+		v3.Sub(&v1)
+		v2.Cross(&v3)
+		normals[a].Add(&v2)
+		normals[b].Add(&v2)
+		normals[c].Add(&v2)
+	}
+	if g.Indexed() {
+		for i := 0; i+2 < g.indices.Size(); i += 3 {
+			a, b, c := g.indices[i], g.indices[i+1], g.indices[i+2]
+			if a == RestartIndex || b == RestartIndex || c == RestartIndex {
+				continue
+			}
+			addFace(int(a), int(b), int(c))
+		}
+	} else {
+		for i := 0; i+2 < items; i += 3 {
+			addFace(i, i+1, i+2)
+		}
+	}
+
+	// Gets or creates the VBO for the normals
+	vboNormal := g.VBO(gls.VertexNormal)
+	if vboNormal == nil {
+		vboNormal = gls.NewVBO(math32.NewArrayF32(3*items, 3*items)).AddAttrib(gls.VertexNormal)
+		g.AddVBO(vboNormal)
+	}
+	buffer := vboNormal.Buffer()
+	stride, offset := vboNormal.Stride(), vboNormal.AttribOffset(gls.VertexNormal)
+	for i := range normals {
+		buffer.SetVector3(i*stride+offset, normals[i].Normalize())
+	}
+	vboNormal.Update()
+	g.syncPositions()
+}
+
+// Refresh recomputes the vertex normals and tangents of this geometry if its vertex
+// positions changed since they were computed or set, such as after a deformation.
+// Only the attributes the geometry already has are recomputed, with ComputeNormals and
+// ComputeTangents. The other geometric properties, such as the bounding box used by the
+// renderer to cull the geometry, are recomputed automatically when requested.
+// The positions changes are detected by the version of their VBO, so the VBO Update
+// method must be called after modifying its buffer directly.
+func (g *Geometry) Refresh() {
+
+	g.checkPositions()
+	if g.normalsDirty {
+		g.ComputeNormals()
+	}
+	if g.tangentsDirty {
+		g.ComputeTangents()
+	}
+}
+
+// NeedsRefresh returns whether the vertex normals or tangents of this geometry
+// must be recomputed by Refresh after a change of its vertex positions.
+func (g *Geometry) NeedsRefresh() bool {
+
+	g.checkPositions()
+	return g.normalsDirty || g.tangentsDirty
+}
+
+// checkPositions invalidates the geometric properties and marks the vertex normals and
+// tangents stale if the vertex positions changed since they were last checked.
+func (g *Geometry) checkPositions() {
+
+	vbo := g.VBO(gls.VertexPosition)
+	if vbo == nil {
+		return
+	}
+	if !g.posTracked {
+		g.posTracked = true
+		g.posVersion = vbo.Version()
+		return
+	}
+	if vbo.Version() == g.posVersion {
+		return
+	}
+	g.posVersion = vbo.Version()
+	g.boundingBoxValid = false
+	g.boundingSphereValid = false
+	g.areaValid = false
+	g.volumeValid = false
+	g.rotInertiaValid = false
+	g.normalsDirty = g.VBO(gls.VertexNormal) != nil
+	g.tangentsDirty = g.VBO(gls.VertexTangent) != nil
+}
+
+// syncPositions accepts the current version of the positions VBO without invalidating
+// the geometric properties, after changes which preserve them, such as vertex attributes
+// updates of a VBO interleaving the positions with other attributes.
+func (g *Geometry) syncPositions() {
+
+	if vbo := g.VBO(gls.VertexPosition); vbo != nil && g.posTracked {
+		g.posVersion = vbo.Version()
+	}
+}
+
+// ComputeLineDistances calculates the distance along the lines of each vertex of this
+// non indexed geometry and stores it in the VertexDistance attribute, adding a new VBO
+// if necessary. The distances are accumulated along the vertices for a line strip,
+// or restart from zero at the first vertex of each pair for independent lines (segments).
+// Distances are used by the LineDashed material to compute the dash pattern.
+func (g *Geometry) ComputeLineDistances(segments bool) {
+
+	vboPos := g.VBO(gls.VertexPosition)
+	if vboPos == nil || g.Indexed() {
+		return
+	}
+	items := g.Items()
+
+	// Gets or creates the VBO for the distances
+	vboDist := g.VBO(gls.VertexDistance)
+	if vboDist == nil {
+		vboDist = gls.NewVBO(math32.NewArrayF32(items, items)).AddAttrib(gls.VertexDistance)
+		g.AddVBO(vboDist)
+	}
+
+	var prev, pos math32.Vector3
+	dist := float32(0)
+	distances := vboDist.Buffer()
+	for i := 0; i < items; i++ {
+		vboPos.Buffer().GetVector3(i*vboPos.Stride()+vboPos.AttribOffset(gls.VertexPosition), &pos)
+		if segments && i%2 == 0 {
+			dist = 0
+		} else if i > 0 {
+			dist += pos.DistanceTo(&prev)
+		}
+		(*distances)[i*vboDist.Stride()+vboDist.AttribOffset(gls.VertexDistance)] = dist
+		prev = pos
+	}
+	vboDist.Update()
+}
+
 // TODO Read and Operate on Texcoords, Faces, Edges, FaceNormals, etc...
 
 // Indexed returns whether the geometry is indexed or not.
@@ -351,20 +699,25 @@ func (g *Geometry) Indexed() bool {
 // and returns is value.
 func (g *Geometry) BoundingBox() math32.Box3 {
 
+	g.checkPositions()
+
 	// If valid, return its value
 	if g.boundingBoxValid {
 		return g.boundingBox
 	}
 
 	// Reset bounding box
-	g.boundingBox.Min.Set(0, 0, 0)
-	g.boundingBox.Max.Set(0, 0, 0)
+	g.boundingBox.MakeEmpty()
 
 	// Expand bounding box by each vertex
 	g.ReadVertices(func(vertex math32.Vector3) bool {
 		g.boundingBox.ExpandByPoint(&vertex)
 		return false
 	})
+	if g.boundingBox.Empty() {
+		g.boundingBox.Min.Set(0, 0, 0)
+		g.boundingBox.Max.Set(0, 0, 0)
+	}
 	g.boundingBoxValid = true
 	return g.boundingBox
 }
@@ -373,6 +726,8 @@ func (g *Geometry) BoundingBox() math32.Box3 {
 // if necessary and returns its value.
 func (g *Geometry) BoundingSphere() math32.Sphere {
 
+	g.checkPositions()
+
 	// If valid, return its value
 	if g.boundingSphereValid {
 		return g.boundingSphere
@@ -398,6 +753,8 @@ func (g *Geometry) BoundingSphere() math32.Sphere {
 // NOTE: This only works for triangle-based meshes.
 func (g *Geometry) Area() float32 {
 
+	g.checkPositions()
+
 	// If valid, return its value
 	if g.areaValid {
 		return g.area
@@ -422,6 +779,8 @@ func (g *Geometry) Area() float32 {
 // NOTE: This only works for closed triangle-based meshes.
 func (g *Geometry) Volume() float32 {
 
+	g.checkPositions()
+
 	// If valid, return its value
 	if g.volumeValid {
 		return g.volume
@@ -446,6 +805,8 @@ func (g *Geometry) Volume() float32 {
 // To adjust for a different constant density simply scale the returning matrix by the density.
 func (g *Geometry) RotationalInertia(mass float32) math32.Matrix3 {
 
+	g.checkPositions()
+
 	// If valid, return its value
 	if g.rotInertiaValid {
 		return g.rotInertia
@@ -542,7 +903,46 @@ func (g *Geometry) RenderSetup(gs *gls.GLS) {
 	// Update Indices buffer if necessary
 	if g.indices.Size() > 0 && g.updateIndices {
 		gs.BindBuffer(gls.ELEMENT_ARRAY_BUFFER, g.handleIndices)
-		gs.BufferData(gls.ELEMENT_ARRAY_BUFFER, g.indices.Bytes(), g.indices, gls.STATIC_DRAW)
+		if g.shortIndices() {
+			// Converts the indices to 16 bits in little endian order
+			g.indexType = gls.UNSIGNED_SHORT
+			g.indexBytes = g.indexBytes[:0]
+			for _, idx := range g.indices {
+				if idx == RestartIndex {
+					idx = 0xFFFF
+				}
+				g.indexBytes = append(g.indexBytes, byte(idx), byte(idx>>8))
+			}
+			g.transferIndices(gs, len(g.indexBytes), &g.indexBytes[0])
+		} else {
+			g.indexType = gls.UNSIGNED_INT
+			g.indexBytes = nil
+			g.transferIndices(gs, g.indices.Bytes(), g.indices)
+		}
 		g.updateIndices = false
 	}
 }
+
+// transferIndices transfers the specified indices data to the bound indices buffer,
+// updating the existing data store if its size is unchanged.
+func (g *Geometry) transferIndices(gs *gls.GLS, size int, data interface{}) {
+
+	if g.indicesSize == size {
+		gs.BufferSubData(gls.ELEMENT_ARRAY_BUFFER, 0, size, data)
+	} else {
+		gs.BufferData(gls.ELEMENT_ARRAY_BUFFER, size, data, g.usage)
+		g.indicesSize = size
+	}
+}
+
+// shortIndices returns whether all the indices fit in 16 bits,
+// excluding the primitive restart index.
+func (g *Geometry) shortIndices() bool {
+
+	for _, idx := range g.indices {
+		if idx >= 0xFFFF && (idx != RestartIndex || !g.restart) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,217 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/math32"
+	gl "github.com/thommil/tge-gl"
+)
+
+// newTestGLS returns a GLS recording the OpenGL calls with a dummy program in use,
+// so that the geometries can be set up.
+func newTestGLS(tb testing.TB) (*gls.GLS, *gls.Recorder) {
+
+	tb.Helper()
+	rec := gls.NewRecorder()
+	gs, err := gls.NewWithBackend(rec)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	prog := gs.NewProgram()
+	prog.AddShader(gls.VERTEX_SHADER, "void main() {}")
+	prog.AddShader(gls.FRAGMENT_SHADER, "void main() {}")
+	if err := prog.Build(); err != nil {
+		tb.Fatal(err)
+	}
+	gs.UseProgram(prog)
+	return gs, rec
+}
+
+// Test the configuration of instanced attributes VBOs
+func TestAddInstancedAttribute(t *testing.T) {
+
+	g := NewBox(1, 1, 1)
+	items := g.Items()
+	colors := math32.NewArrayF32(0, 0)
+	colors.Append(1, 0, 0, 1, 0, 1, 0, 1)
+	vbo := g.AddInstancedAttribute("InstanceColor", colors, 4, 2)
+
+	if g.VBOName("InstanceColor") != vbo {
+		t.Fatal("instanced VBO not found")
+	}
+	attrib := vbo.AttribName("InstanceColor")
+	if attrib.Divisor != 2 {
+		t.Errorf("expected divisor 2 got %d", attrib.Divisor)
+	}
+	if attrib.NumElements != 4 || attrib.ByteOffset != 0 || attrib.ElementType != gls.FLOAT {
+		t.Errorf("invalid attribute: %+v", *attrib)
+	}
+	if vbo.Stride() != 4 || vbo.StrideSize() != 16 {
+		t.Errorf("expected stride 4/16 got %d/%d", vbo.Stride(), vbo.StrideSize())
+	}
+	if g.Items() != items {
+		t.Errorf("expected %d items got %d", items, g.Items())
+	}
+	if a := g.VBO(gls.VertexPosition).Attrib(gls.VertexPosition); a.Divisor != 0 {
+		t.Errorf("expected vertex attribute divisor 0 got %d", a.Divisor)
+	}
+}
+
+// Test the line distances of a line strip and of independent lines
+func TestComputeLineDistances(t *testing.T) {
+
+	positions := math32.NewArrayF32(0, 0)
+	positions.Append(0, 0, 0, 3, 4, 0, 3, 4, 2, 3, 6, 2)
+	expected := map[bool][]float32{false: {0, 5, 7, 9}, true: {0, 5, 0, 2}}
+	for _, segments := range []bool{false, true} {
+		g := NewGeometry()
+		g.AddVBO(gls.NewVBO(positions).AddAttrib(gls.VertexPosition))
+		g.ComputeLineDistances(segments)
+		dist := *g.VBO(gls.VertexDistance).Buffer()
+		for i, d := range expected[segments] {
+			if dist[i] != d {
+				t.Errorf("segments %v: got distances %v, expected %v", segments, dist, expected[segments])
+				break
+			}
+		}
+	}
+}
+
+// Test that 16 bits indices are used only if all the vertices can be indexed with them
+func TestIndexType(t *testing.T) {
+
+	gs, _ := newTestGLS(t)
+	for _, c := range []struct {
+		segments int
+		itype    uint32
+	}{{10, gls.UNSIGNED_SHORT}, {254, gls.UNSIGNED_SHORT}, {256, gls.UNSIGNED_INT}, {300, gls.UNSIGNED_INT}} {
+		plane := NewPlane(1, 1, c.segments, c.segments)
+		plane.RenderSetup(gs)
+		vertices := (c.segments + 1) * (c.segments + 1)
+		if plane.IndexType() != c.itype {
+			t.Fatalf("%d vertices: expected index type %d got %d", vertices, c.itype, plane.IndexType())
+		}
+		if plane.IndexType() == gls.UNSIGNED_SHORT && plane.IndexSize() != 2 || plane.IndexType() == gls.UNSIGNED_INT && plane.IndexSize() != 4 {
+			t.Fatalf("%d vertices: invalid index size %d", vertices, plane.IndexSize())
+		}
+	}
+}
+
+// Test that the buffers are allocated with the geometry usage and the indices updated in place
+func TestUsage(t *testing.T) {
+
+	gs, rec := newTestGLS(t)
+	plane := NewPlane(1, 1, 2, 2)
+	plane.SetUsage(gls.DYNAMIC_DRAW)
+	rec.Reset()
+	plane.RenderSetup(gs)
+	allocs := 0
+	for _, c := range rec.Calls() {
+		if c.Name == "BufferData" {
+			allocs++
+			if usage := c.Args[2].(gl.Enum); usage != gls.DYNAMIC_DRAW {
+				t.Errorf("expected usage %d got %d", gls.DYNAMIC_DRAW, usage)
+			}
+		}
+	}
+	if allocs != 4 {
+		t.Errorf("expected 4 buffers allocated got %d", allocs)
+	}
+
+	// Indices of the same size update the existing data store
+	plane.SetIndices(plane.Indices())
+	rec.Reset()
+	plane.RenderSetup(gs)
+	if names := strings.Join(rec.Names(), " "); names != "BindVertexArray BindBuffer BufferSubData" {
+		t.Errorf("unexpected calls %s", names)
+	}
+
+	// The VBOs added after SetUsage get the geometry usage
+	colors := math32.NewArrayF32(0, 0)
+	colors.Append(1, 0, 0, 1)
+	plane.AddInstancedAttribute("InstanceColor", colors, 4, 1)
+	rec.Reset()
+	plane.RenderSetup(gs)
+	allocs = 0
+	for _, c := range rec.Calls() {
+		if c.Name == "BufferData" {
+			allocs++
+			if usage := c.Args[2].(gl.Enum); usage != gls.DYNAMIC_DRAW {
+				t.Errorf("expected usage %d of the added VBO got %d", gls.DYNAMIC_DRAW, usage)
+			}
+		}
+	}
+	if allocs != 1 {
+		t.Errorf("expected the added VBO allocated got %d buffers", allocs)
+	}
+}
+
+// Test the invalidation of the geometric properties after a change of the vertex positions
+func TestRefresh(t *testing.T) {
+
+	g := NewPlane(2, 2, 1, 1)
+	g.ComputeTangents()
+	box := g.BoundingBox()
+	if box.Max.Z != 0 || g.NeedsRefresh() {
+		t.Fatalf("unexpected initial state %+v", box)
+	}
+
+	// Tilts the plane around the Y axis modifying the buffer directly
+	vbo := g.VBO(gls.VertexPosition)
+	positions := vbo.Buffer()
+	for i := 0; i < positions.Size(); i += 3 {
+		(*positions)[i+2] = (*positions)[i]
+	}
+	vbo.Update()
+	box = g.BoundingBox()
+	if box.Min.Z != -1 || box.Max.Z != 1 {
+		t.Errorf("expected the bounding box to follow the positions got %+v", box)
+	}
+	if !g.NeedsRefresh() {
+		t.Fatal("expected the normals and tangents to be stale")
+	}
+	g.Refresh()
+	if g.NeedsRefresh() {
+		t.Error("expected the normals and tangents to be recomputed")
+	}
+	expected := math32.Vector3{-math32.Sqrt(0.5), 0, math32.Sqrt(0.5)}
+	g.ReadVertexNormals(func(n math32.Vector3) bool {
+		if n.DistanceTo(&expected) > 1e-5 {
+			t.Errorf("expected normal %v got %v", expected, n)
+			return true
+		}
+		return false
+	})
+	var tangent math32.Vector4
+	g.VBO(gls.VertexTangent).Buffer().GetVector4(0, &tangent)
+	if math32.Abs(tangent.X-math32.Sqrt(0.5)) > 1e-5 || math32.Abs(tangent.Z-math32.Sqrt(0.5)) > 1e-5 {
+		t.Errorf("expected the tangent to follow the surface got %v", tangent)
+	}
+
+	// Normals set after the positions change are kept
+	g.OperateOnVertices(func(v *math32.Vector3) bool {
+		v.Z = 0
+		return false
+	})
+	g.OperateOnVertexNormals(func(n *math32.Vector3) bool {
+		n.Set(0, 0, 1)
+		return false
+	})
+	if !g.NeedsRefresh() {
+		t.Error("expected the tangents to be stale")
+	}
+	g.Refresh()
+	g.ReadVertexNormals(func(n math32.Vector3) bool {
+		if n.Z != 1 {
+			t.Errorf("expected the set normal to be kept got %v", n)
+			return true
+		}
+		return false
+	})
+}
@@ -0,0 +1,144 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// Heightfield represents a terrain geometry built from a regular grid of heights
+type Heightfield struct {
+	Geometry
+	Width   int            // Number of height samples along the X axis
+	Depth   int            // Number of height samples along the Z axis
+	Scale   math32.Vector3 // Samples spacing in X and Z and heights multiplier in Y
+	heights []float32      // Copy of the source heights
+}
+
+// NewHeightfield creates and returns a pointer to a new Heightfield geometry.
+// The heights slice contains width*depth samples in row major order, each row
+// containing the width samples along the X axis for one Z coordinate.
+// The scale X and Z components are the distances between adjacent samples and the
+// Y component multiplies the heights. The terrain is generated centered in the XZ plane
+// with smooth normals and texture coordinates covering the whole grid.
+func NewHeightfield(heights []float32, width, depth int, scale math32.Vector3) *Heightfield {
+
+	if width < 2 || depth < 2 || len(heights) != width*depth {
+		panic("NewHeightfield: invalid heights grid size")
+	}
+	hf := new(Heightfield)
+	hf.Geometry.Init()
+
+	hf.Width = width
+	hf.Depth = depth
+	hf.Scale = scale
+	hf.heights = make([]float32, len(heights))
+	copy(hf.heights, heights)
+
+	widthHalf := float32(width-1) * scale.X / 2
+	depthHalf := float32(depth-1) * scale.Z / 2
+
+	// Returns the scaled height of the sample clamped to the grid
+	height := func(ix, iz int) float32 {
+		ix = clampIndex(ix, width)
+		iz = clampIndex(iz, depth)
+		return heights[iz*width+ix] * scale.Y
+	}
+
+	// Create buffers
+	positions := math32.NewArrayF32(0, 3*width*depth)
+	normals := math32.NewArrayF32(0, 3*width*depth)
+	uvs := math32.NewArrayF32(0, 2*width*depth)
+	indices := math32.NewArrayU32(0, 6*(width-1)*(depth-1))
+
+	// Generate vertices, smooth normals from the central differences of the heights and texture mappings.
+	var normal math32.Vector3
+	minY := height(0, 0)
+	maxY := minY
+	for iz := 0; iz < depth; iz++ {
+		z := float32(iz)*scale.Z - depthHalf
+		for ix := 0; ix < width; ix++ {
+			x := float32(ix)*scale.X - widthHalf
+			y := height(ix, iz)
+			positions.Append(x, y, z)
+			minY = math32.Min(minY, y)
+			maxY = math32.Max(maxY, y)
+			dx := float32(clampIndex(ix+1, width)-clampIndex(ix-1, width)) * scale.X
+			dz := float32(clampIndex(iz+1, depth)-clampIndex(iz-1, depth)) * scale.Z
+			normal.Set(-(height(ix+1, iz)-height(ix-1, iz))/dx, 1, -(height(ix, iz+1)-height(ix, iz-1))/dz).Normalize()
+			normals.AppendVector3(&normal)
+			uvs.Append(float32(ix)/float32(width-1), 1-float32(iz)/float32(depth-1))
+		}
+	}
+
+	// Generate vertices indices for the faces
+	for iz := 0; iz < depth-1; iz++ {
+		for ix := 0; ix < width-1; ix++ {
+			a := ix + width*iz
+			b := ix + width*(iz+1)
+			c := (ix + 1) + width*(iz+1)
+			d := (ix + 1) + width*iz
+			indices.Append(uint32(a), uint32(b), uint32(d))
+			indices.Append(uint32(b), uint32(c), uint32(d))
+		}
+	}
+
+	hf.SetIndices(indices)
+	hf.AddVBO(gls.NewVBO(positions).AddAttrib(gls.VertexPosition))
+	hf.AddVBO(gls.NewVBO(normals).AddAttrib(gls.VertexNormal))
+	hf.AddVBO(gls.NewVBO(uvs).AddAttrib(gls.VertexTexcoord))
+
+	// Update bounding box
+	hf.boundingBox = math32.Box3{
+		Min: math32.Vector3{X: -widthHalf, Y: minY, Z: -depthHalf},
+		Max: math32.Vector3{X: widthHalf, Y: maxY, Z: depthHalf},
+	}
+	hf.boundingBoxValid = true
+
+	return hf
+}
+
+// HeightAt returns the terrain height at the specified X and Z local coordinates,
+// interpolated over the same triangles used by the geometry.
+// Coordinates outside the terrain are clamped to its borders.
+func (hf *Heightfield) HeightAt(x, z float32) float32 {
+
+	// Converts coordinates to grid units
+	fx := (x + float32(hf.Width-1)*hf.Scale.X/2) / hf.Scale.X
+	fz := (z + float32(hf.Depth-1)*hf.Scale.Z/2) / hf.Scale.Z
+	fx = math32.Clamp(fx, 0, float32(hf.Width-1))
+	fz = math32.Clamp(fz, 0, float32(hf.Depth-1))
+
+	// Gets the grid cell and the position inside it
+	ix := int(math32.Min(math32.Floor(fx), float32(hf.Width-2)))
+	iz := int(math32.Min(math32.Floor(fz), float32(hf.Depth-2)))
+	tx := fx - float32(ix)
+	tz := fz - float32(iz)
+
+	ha := hf.heights[iz*hf.Width+ix]
+	hb := hf.heights[(iz+1)*hf.Width+ix]
+	hc := hf.heights[(iz+1)*hf.Width+ix+1]
+	hd := hf.heights[iz*hf.Width+ix+1]
+	var h float32
+	if tx+tz <= 1 {
+		h = ha + (hd-ha)*tx + (hb-ha)*tz
+	} else {
+		h = hc + (hb-hc)*(1-tx) + (hd-hc)*(1-tz)
+	}
+	return h * hf.Scale.Y
+}
+
+// clampIndex clamps the specified index to the range [0, size-1]
+func clampIndex(idx, size int) int {
+
+	if idx < 0 {
+		return 0
+	}
+	if idx >= size {
+		return size - 1
+	}
+	return idx
+}
@@ -0,0 +1,54 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"testing"
+
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// Test heightfield vertex count and sampled heights
+func TestHeightfield(t *testing.T) {
+
+	const width, depth = 4, 3
+	heights := []float32{
+		0, 1, 2, 3,
+		1, 2, 3, 4,
+		5, 0, 2, 1,
+	}
+	scale := math32.Vector3{X: 2, Y: 0.5, Z: 1}
+	hf := NewHeightfield(heights, width, depth, scale)
+
+	if hf.Items() != width*depth {
+		t.Errorf("Items: expected %d got %d", width*depth, hf.Items())
+	}
+	indices := hf.Indices()
+	if indices.Size() != 6*(width-1)*(depth-1) {
+		t.Errorf("Indices: expected %d got %d", 6*(width-1)*(depth-1), indices.Size())
+	}
+
+	// Heights sampled at the grid points must match the input grid
+	for iz := 0; iz < depth; iz++ {
+		for ix := 0; ix < width; ix++ {
+			x := float32(ix)*scale.X - float32(width-1)*scale.X/2
+			z := float32(iz)*scale.Z - float32(depth-1)*scale.Z/2
+			expected := heights[iz*width+ix] * scale.Y
+			if h := hf.HeightAt(x, z); math32.Abs(h-expected) > 1e-5 {
+				t.Errorf("HeightAt(%v, %v): expected %v got %v", x, z, expected, h)
+			}
+		}
+	}
+
+	// Height at the center of the first cell diagonal
+	if h := hf.HeightAt(-2, -0.5); math32.Abs(h-0.5) > 1e-5 {
+		t.Errorf("HeightAt(-2, -0.5): expected 0.5 got %v", h)
+	}
+
+	bbox := hf.BoundingBox()
+	if bbox.Min.Y != 0 || bbox.Max.Y != 2.5 || bbox.Min.X != -3 || bbox.Max.Z != 1 {
+		t.Errorf("BoundingBox: unexpected %v", bbox)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// Mergeable returns whether the specified geometries can be merged by Merge, which
+// requires float VBOs with the same attributes, without instanced attributes
+// or primitive restart.
+func Mergeable(a, b *Geometry) bool {
+
+	if a.restart || b.restart || len(a.vbos) != len(b.vbos) {
+		return false
+	}
+	for i := range a.vbos {
+		va, vb := a.vbos[i], b.vbos[i]
+		if va.Bytes() != nil || vb.Bytes() != nil || va.AttribCount() != vb.AttribCount() {
+			return false
+		}
+		for j, attrib := range va.Attributes() {
+			if attrib != *vb.AttribAt(j) || attrib.ElementType != gls.FLOAT || attrib.Divisor != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Merge creates and returns a pointer to a new indexed geometry with the vertices of
+// the specified mergeable geometries, each transformed by the matrix at the same index
+// if matrices is not nil. The triangles of the geometries transformed by a matrix with
+// a negative determinant are reversed so that their front faces stay the same.
+// The groups of the geometries are not kept.
+func Merge(geoms []*Geometry, matrices []*math32.Matrix4) *Geometry {
+
+	if len(geoms) == 0 || (matrices != nil && len(matrices) != len(geoms)) {
+		panic("geometry.Merge: invalid number of geometries or matrices")
+	}
+	for _, g := range geoms {
+		if !Mergeable(geoms[0], g) {
+			panic("geometry.Merge: geometries not mergeable")
+		}
+	}
+
+	// Concatenates the buffers of each VBO
+	merged := NewGeometry()
+	for i, vbo := range geoms[0].vbos {
+		var buffer math32.ArrayF32
+		for _, g := range geoms {
+			buffer = append(buffer, *g.vbos[i].Buffer()...)
+		}
+		mvbo := gls.NewVBO(buffer)
+		for j, attrib := range vbo.Attributes() {
+			mvbo.AddCustomAttribOffset(attrib.Name, attrib.NumElements, attrib.ByteOffset)
+			*mvbo.AttribAt(j) = attrib
+		}
+		merged.AddVBO(mvbo)
+	}
+
+	// Offsets the indices of each geometry, indexing the vertices in order if it is not indexed
+	var indices math32.ArrayU32
+	bases := make([]int, len(geoms)+1)
+	for i, g := range geoms {
+		items := g.Items()
+		bases[i+1] = bases[i] + items
+		gindices := g.indices
+		if len(gindices) == 0 {
+			gindices = make(math32.ArrayU32, items)
+			for j := range gindices {
+				gindices[j] = uint32(j)
+			}
+		}
+		base := uint32(bases[i])
+		mirrored := matrices != nil && matrices[i].Determinant() < 0
+		for j := 0; j+2 < len(gindices); j += 3 {
+			if mirrored {
+				indices = append(indices, gindices[j]+base, gindices[j+2]+base, gindices[j+1]+base)
+			} else {
+				indices = append(indices, gindices[j]+base, gindices[j+1]+base, gindices[j+2]+base)
+			}
+		}
+	}
+	merged.SetIndices(indices)
+	if matrices == nil {
+		return merged
+	}
+
+	// Transforms the vertices and the normals of each geometry
+	item, index := 0, 0
+	merged.OperateOnVertices(func(vertex *math32.Vector3) bool {
+		for item >= bases[index+1] {
+			index++
+		}
+		vertex.ApplyMatrix4(matrices[index])
+		item++
+		return false
+	})
+	normalMatrices := make([]math32.Matrix3, len(matrices))
+	for i, m := range matrices {
+		normalMatrices[i].GetNormalMatrix(m)
+	}
+	item, index = 0, 0
+	merged.OperateOnVertexNormals(func(normal *math32.Vector3) bool {
+		for item >= bases[index+1] {
+			index++
+		}
+		normal.ApplyMatrix3(&normalMatrices[index]).Normalize()
+		item++
+		return false
+	})
+	return merged
+}
@@ -0,0 +1,42 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"testing"
+
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// Test merging transformed and mirrored boxes with indexed and non indexed boxes
+func TestMerge(t *testing.T) {
+
+	var moved, mirrored math32.Matrix4
+	moved.MakeTranslation(10, 0, 0)
+	mirrored.MakeScale(-1, 1, 1)
+	geoms := []*Geometry{&NewBox(2, 2, 2).Geometry, NewBox(2, 2, 2).ToNonIndexed(), &NewBox(2, 2, 2).Geometry}
+	merged := Merge(geoms, []*math32.Matrix4{&moved, &moved, &mirrored})
+
+	if items := merged.Items(); items != 24+36+24 {
+		t.Errorf("expected %d vertices got %d", 24+36+24, items)
+	}
+	if n := len(merged.Indices()); n != 3*36 {
+		t.Errorf("expected %d indices got %d", 3*36, n)
+	}
+	box := merged.BoundingBox()
+	if box.Min != (math32.Vector3{-1, -1, -1}) || box.Max != (math32.Vector3{11, 1, 1}) {
+		t.Errorf("unexpected bounding box %v", box)
+	}
+	// The mirrored box is not inside out
+	if v := merged.ComputeSignedVolume(); math32.Abs(v-24) > 1e-3 {
+		t.Errorf("expected volume 24 got %v", v)
+	}
+
+	instanced := &NewBox(1, 1, 1).Geometry
+	instanced.AddInstancedAttribute("Offset", math32.ArrayF32{0, 0, 0}, 3, 1)
+	if Mergeable(geoms[0], instanced) || Mergeable(instanced, instanced) {
+		t.Error("expected geometries with instanced attributes not mergeable")
+	}
+}
@@ -0,0 +1,474 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"math"
+	"sort"
+
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// Simplify returns a new geometry with approximately the specified fraction of
+// the triangles of the specified geometry, using quadric error metric edge collapses.
+// All the vertex attributes are preserved and interpolated when vertices are merged.
+// Vertices on boundary edges, including texture seams where vertices are split,
+// are never moved, so borders and seams keep their shape.
+// The geometry groups are kept. The source geometry is not modified.
+func Simplify(g *Geometry, targetRatio float32) *Geometry {
+
+	if targetRatio <= 0 || targetRatio > 1 {
+		panic("Simplify: target ratio must be in the range (0, 1]")
+	}
+	s := newSimplifier(g)
+	s.run(int(float32(len(s.tris)) * targetRatio))
+	return s.build()
+}
+
+// quadric is a symmetric 4x4 error matrix stored as its upper triangle
+type quadric [10]float64
+
+// addPlane adds the quadric of the plane ax+by+cz+d=0 with the specified weight.
+func (q *quadric) addPlane(a, b, c, d, w float64) {
+
+	q[0] += w * a * a
+	q[1] += w * a * b
+	q[2] += w * a * c
+	q[3] += w * a * d
+	q[4] += w * b * b
+	q[5] += w * b * c
+	q[6] += w * b * d
+	q[7] += w * c * c
+	q[8] += w * c * d
+	q[9] += w * d * d
+}
+
+// add adds the other quadric to this one.
+func (q *quadric) add(other *quadric) {
+
+	for i := range q {
+		q[i] += other[i]
+	}
+}
+
+// eval returns the error of the specified point.
+func (q *quadric) eval(p *math32.Vector3) float64 {
+
+	x, y, z := float64(p.X), float64(p.Y), float64(p.Z)
+	return q[0]*x*x + 2*q[1]*x*y + 2*q[2]*x*z + 2*q[3]*x +
+		q[4]*y*y + 2*q[5]*y*z + 2*q[6]*y +
+		q[7]*z*z + 2*q[8]*z + q[9]
+}
+
+// Collapse target positions
+const (
+	collapseToA = iota
+	collapseToB
+	collapseToMid
+)
+
+// collapse is a candidate edge collapse
+type collapse struct {
+	cost   float64
+	a, b   uint32
+	sa, sb uint32 // vertices stamps when the collapse was evaluated
+	target int
+}
+
+// collapseQueue is a min heap of edge collapses ordered by cost
+type collapseQueue []collapse
+
+func (cq collapseQueue) Len() int            { return len(cq) }
+func (cq collapseQueue) Less(i, j int) bool  { return cq[i].cost < cq[j].cost }
+func (cq collapseQueue) Swap(i, j int)       { cq[i], cq[j] = cq[j], cq[i] }
+func (cq *collapseQueue) Push(x interface{}) { *cq = append(*cq, x.(collapse)) }
+func (cq *collapseQueue) Pop() interface{} {
+
+	old := *cq
+	c := old[len(old)-1]
+	*cq = old[:len(old)-1]
+	return c
+}
+
+// simplifier contains the state of a geometry simplification
+type simplifier struct {
+	src        *Geometry
+	vbos       []*gls.VBO  // Source VBOs
+	strides    []int       // Number of floats per vertex of each VBO
+	data       [][]float32 // Copy of the per vertex data of each VBO
+	posVBO     int         // Index of the VBO with the positions
+	posOffset  int         // Offset of the position in the VBO vertex data
+	normVBO    int         // Index of the VBO with the normals or -1
+	normOffset int         // Offset of the normal in the VBO vertex data
+	tris       [][3]uint32 // Triangles vertices
+	triGroup   []int       // Triangles geometry group
+	triRemoved []bool      // Triangles removed by collapses
+	vtris      [][]int     // Triangles using each vertex
+	quadrics   []quadric   // Vertices error quadrics
+	locked     []bool      // Vertices which cannot be moved
+	removed    []bool      // Vertices removed by collapses
+	stamps     []uint32    // Vertices modification counters
+	live       int         // Number of remaining triangles
+	queue      collapseQueue
+}
+
+// newSimplifier creates the simplifier state for the specified geometry.
+func newSimplifier(g *Geometry) *simplifier {
+
+	s := new(simplifier)
+	s.src = g
+	s.posVBO = -1
+	s.normVBO = -1
+	s.vbos = g.VBOs()
+	nverts := 0
+	for i, vbo := range s.vbos {
+		stride := vbo.StrideSize() / 4
+		s.strides = append(s.strides, stride)
+		s.data = append(s.data, append([]float32(nil), (*vbo.Buffer())...))
+		nverts = vbo.Buffer().Size() / stride
+		if attrib := vbo.Attrib(gls.VertexPosition); attrib != nil {
+			s.posVBO = i
+			s.posOffset = int(attrib.ByteOffset / 4)
+		}
+		if attrib := vbo.Attrib(gls.VertexNormal); attrib != nil {
+			s.normVBO = i
+			s.normOffset = int(attrib.ByteOffset / 4)
+		}
+	}
+	if s.posVBO < 0 {
+		panic("Simplify: geometry has no VertexPosition attribute")
+	}
+
+	// Non indexed geometries are indexed by merging identical vertices
+	var indices []uint32
+	if g.Indexed() {
+		indices = g.Indices()
+	} else {
+		indices = s.weld(nverts)
+		nverts = len(s.data[0]) / s.strides[0]
+	}
+
+	// Triangles and their groups
+	ntris := len(indices) / 3
+	s.tris = make([][3]uint32, ntris)
+	s.triGroup = make([]int, ntris)
+	s.triRemoved = make([]bool, ntris)
+	s.vtris = make([][]int, nverts)
+	for t := 0; t < ntris; t++ {
+		tri := [3]uint32{indices[3*t], indices[3*t+1], indices[3*t+2]}
+		s.tris[t] = tri
+		for _, v := range tri {
+			s.vtris[v] = append(s.vtris[v], t)
+		}
+		for gi := 0; gi < g.GroupCount(); gi++ {
+			group := g.GroupAt(gi)
+			if 3*t >= group.Start && 3*t < group.Start+group.Count {
+				s.triGroup[t] = gi
+				break
+			}
+		}
+	}
+	s.live = ntris
+
+	// Vertices quadrics from their triangles planes weighted by area
+	s.quadrics = make([]quadric, nverts)
+	for _, tri := range s.tris {
+		p0, p1, p2 := s.pos(tri[0]), s.pos(tri[1]), s.pos(tri[2])
+		var e1, e2, n math32.Vector3
+		n.CrossVectors(e1.SubVectors(&p1, &p0), e2.SubVectors(&p2, &p0))
+		area := float64(n.Length()) / 2
+		if area == 0 {
+			continue
+		}
+		n.Normalize()
+		d := -float64(n.Dot(&p0))
+		for _, v := range tri {
+			s.quadrics[v].addPlane(float64(n.X), float64(n.Y), float64(n.Z), d, area)
+		}
+	}
+
+	// Vertices of edges used by only one triangle are on a boundary
+	s.locked = make([]bool, nverts)
+	s.removed = make([]bool, nverts)
+	s.stamps = make([]uint32, nverts)
+	edges := make(map[uint64]int)
+	for _, tri := range s.tris {
+		for i := 0; i < 3; i++ {
+			edges[edgeKey(tri[i], tri[(i+1)%3])]++
+		}
+	}
+	for key, count := range edges {
+		if count == 1 {
+			s.locked[key>>32] = true
+			s.locked[uint32(key)] = true
+		}
+	}
+
+	// Initial collapse candidates in a deterministic order
+	keys := make([]uint64, 0, len(edges))
+	for key := range edges {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for _, key := range keys {
+		s.push(uint32(key>>32), uint32(key))
+	}
+	return s
+}
+
+// edgeKey returns the key of the edge between the specified vertices.
+func edgeKey(a, b uint32) uint64 {
+
+	if a > b {
+		a, b = b, a
+	}
+	return uint64(a)<<32 | uint64(b)
+}
+
+// weld merges the identical vertices of a non indexed geometry
+// and returns the indices of the triangles vertices.
+func (s *simplifier) weld(nverts int) []uint32 {
+
+	indices := make([]uint32, nverts)
+	unique := make(map[string]uint32)
+	welded := make([][]float32, len(s.data))
+	var key []byte
+	var bits [4]byte
+	for v := 0; v < nverts; v++ {
+		key = key[:0]
+		for i, data := range s.data {
+			for _, f := range data[v*s.strides[i] : (v+1)*s.strides[i]] {
+				binary.LittleEndian.PutUint32(bits[:], math.Float32bits(f))
+				key = append(key, bits[:]...)
+			}
+		}
+		idx, ok := unique[string(key)]
+		if !ok {
+			idx = uint32(len(unique))
+			unique[string(key)] = idx
+			for i, data := range s.data {
+				welded[i] = append(welded[i], data[v*s.strides[i]:(v+1)*s.strides[i]]...)
+			}
+		}
+		indices[v] = idx
+	}
+	s.data = welded
+	return indices
+}
+
+// pos returns the position of the specified vertex.
+func (s *simplifier) pos(v uint32) math32.Vector3 {
+
+	d := s.data[s.posVBO][int(v)*s.strides[s.posVBO]+s.posOffset:]
+	return math32.Vector3{X: d[0], Y: d[1], Z: d[2]}
+}
+
+// push evaluates the collapse of the specified edge and adds it to the queue.
+func (s *simplifier) push(a, b uint32) {
+
+	if s.locked[a] && s.locked[b] {
+		return
+	}
+	q := s.quadrics[a]
+	q.add(&s.quadrics[b])
+	pa, pb := s.pos(a), s.pos(b)
+	c := collapse{a: a, b: b, sa: s.stamps[a], sb: s.stamps[b]}
+	switch {
+	case s.locked[a]:
+		c.cost, c.target = q.eval(&pa), collapseToA
+	case s.locked[b]:
+		c.cost, c.target = q.eval(&pb), collapseToB
+	default:
+		var mid math32.Vector3
+		mid.AddVectors(&pa, &pb).MultiplyScalar(0.5)
+		c.cost, c.target = q.eval(&pa), collapseToA
+		if cost := q.eval(&pb); cost < c.cost {
+			c.cost, c.target = cost, collapseToB
+		}
+		if cost := q.eval(&mid); cost < c.cost {
+			c.cost, c.target = cost, collapseToMid
+		}
+	}
+	heap.Push(&s.queue, c)
+}
+
+// run collapses edges until the number of triangles reaches the target.
+func (s *simplifier) run(target int) {
+
+	for s.live > target && s.queue.Len() > 0 {
+		c := heap.Pop(&s.queue).(collapse)
+		if s.removed[c.a] || s.removed[c.b] || s.stamps[c.a] != c.sa || s.stamps[c.b] != c.sb {
+			continue
+		}
+		s.collapse(&c)
+	}
+}
+
+// collapse merges the vertices of the specified edge if it does not flip any triangle.
+func (s *simplifier) collapse(c *collapse) {
+
+	keep, drop := c.b, c.a
+	if c.target == collapseToA {
+		keep, drop = c.a, c.b
+	}
+	pkeep, pdrop := s.pos(keep), s.pos(drop)
+	newPos := pkeep
+	if c.target == collapseToMid {
+		newPos.AddVectors(&pkeep, &pdrop).MultiplyScalar(0.5)
+	}
+
+	// Rejects the collapse if a remaining triangle would flip
+	if s.flips(drop, keep, drop, &newPos) || (c.target == collapseToMid && s.flips(keep, drop, keep, &newPos)) {
+		return
+	}
+
+	// Interpolates the attributes of the merged vertex
+	if c.target == collapseToMid {
+		for i, data := range s.data {
+			stride := s.strides[i]
+			k := data[int(keep)*stride : int(keep+1)*stride]
+			d := data[int(drop)*stride : int(drop+1)*stride]
+			for j := range k {
+				k[j] = (k[j] + d[j]) / 2
+			}
+		}
+		if s.normVBO >= 0 {
+			n := s.data[s.normVBO][int(keep)*s.strides[s.normVBO]+s.normOffset:]
+			v := math32.Vector3{X: n[0], Y: n[1], Z: n[2]}
+			v.Normalize()
+			n[0], n[1], n[2] = v.X, v.Y, v.Z
+		}
+	}
+	s.quadrics[keep].add(&s.quadrics[drop])
+
+	// Moves the triangles of the removed vertex to the kept vertex
+	for _, t := range s.vtris[drop] {
+		if s.triRemoved[t] {
+			continue
+		}
+		tri := &s.tris[t]
+		if tri[0] == keep || tri[1] == keep || tri[2] == keep {
+			s.triRemoved[t] = true
+			s.live--
+			continue
+		}
+		for i := range tri {
+			if tri[i] == drop {
+				tri[i] = keep
+			}
+		}
+		s.vtris[keep] = append(s.vtris[keep], t)
+	}
+	s.removed[drop] = true
+	s.vtris[drop] = nil
+	s.stamps[keep]++
+
+	// Re-evaluates the edges of the kept vertex
+	tris := s.vtris[keep][:0]
+	for _, t := range s.vtris[keep] {
+		if s.triRemoved[t] {
+			continue
+		}
+		tris = append(tris, t)
+		for _, v := range s.tris[t] {
+			if v != keep {
+				s.push(keep, v)
+			}
+		}
+	}
+	s.vtris[keep] = tris
+}
+
+// flips returns if moving vertex v to the specified position would flip
+// any of its triangles which do not contain the other vertex.
+func (s *simplifier) flips(v, other, moved uint32, newPos *math32.Vector3) bool {
+
+	for _, t := range s.vtris[v] {
+		tri := s.tris[t]
+		if s.triRemoved[t] || tri[0] == other || tri[1] == other || tri[2] == other {
+			continue
+		}
+		var p, q [3]math32.Vector3
+		for i, tv := range tri {
+			p[i] = s.pos(tv)
+			q[i] = p[i]
+			if tv == moved {
+				q[i] = *newPos
+			}
+		}
+		var e1, e2, n0, n1 math32.Vector3
+		n0.CrossVectors(e1.SubVectors(&p[1], &p[0]), e2.SubVectors(&p[2], &p[0]))
+		n1.CrossVectors(e1.SubVectors(&q[1], &q[0]), e2.SubVectors(&q[2], &q[0]))
+		if n0.Dot(&n1) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// build creates the simplified geometry from the remaining triangles.
+func (s *simplifier) build() *Geometry {
+
+	// Remaining triangles ordered by group
+	tris := make([]int, 0, s.live)
+	for t := range s.tris {
+		if !s.triRemoved[t] {
+			tris = append(tris, t)
+		}
+	}
+	sort.SliceStable(tris, func(i, j int) bool { return s.triGroup[tris[i]] < s.triGroup[tris[j]] })
+
+	// Remaps the used vertices
+	remap := make(map[uint32]uint32)
+	indices := math32.NewArrayU32(0, 3*len(tris))
+	data := make([]math32.ArrayF32, len(s.data))
+	for _, t := range tris {
+		for _, v := range s.tris[t] {
+			idx, ok := remap[v]
+			if !ok {
+				idx = uint32(len(remap))
+				remap[v] = idx
+				for i := range data {
+					stride := s.strides[i]
+					data[i].Append(s.data[i][int(v)*stride : int(v+1)*stride]...)
+				}
+			}
+			indices.Append(idx)
+		}
+	}
+
+	g := NewGeometry()
+	for i, vbo := range s.vbos {
+		nvbo := gls.NewVBO(data[i])
+		for _, attrib := range vbo.Attributes() {
+			if attrib.Type == gls.Undefined {
+				nvbo.AddCustomAttribOffset(attrib.Name, attrib.NumElements, attrib.ByteOffset)
+			} else {
+				nvbo.AddAttribOffset(attrib.Type, attrib.ByteOffset)
+			}
+		}
+		g.AddVBO(nvbo)
+	}
+	g.SetIndices(indices)
+
+	// Recreates the groups with their new ranges
+	for gi := 0; gi < s.src.GroupCount(); gi++ {
+		group := *s.src.GroupAt(gi)
+		group.Start, group.Count = 0, 0
+		for i, t := range tris {
+			if s.triGroup[t] == gi {
+				if group.Count == 0 {
+					group.Start = 3 * i
+				}
+				group.Count += 3
+			}
+		}
+		g.AddGroupList([]Group{group})
+	}
+	return g
+}
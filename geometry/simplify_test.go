@@ -0,0 +1,53 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"testing"
+
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// Test simplified triangle count and index buffer validity
+func TestSimplify(t *testing.T) {
+
+	const size = 33
+	heights := make([]float32, size*size)
+	for iz := 0; iz < size; iz++ {
+		for ix := 0; ix < size; ix++ {
+			heights[iz*size+ix] = math32.Sin(float32(ix)/4) * math32.Cos(float32(iz)/5)
+		}
+	}
+	hf := NewHeightfield(heights, size, size, math32.Vector3{X: 1, Y: 2, Z: 1})
+	srcIndices := hf.Indices()
+	srcTris := srcIndices.Size() / 3
+
+	for _, ratio := range []float32{0.5, 0.25, 0.1} {
+		g := Simplify(&hf.Geometry, ratio)
+		indices := g.Indices()
+		if indices.Size()%3 != 0 {
+			t.Fatalf("ratio %v: index count %d not a multiple of 3", ratio, indices.Size())
+		}
+		tris := indices.Size() / 3
+		target := float32(srcTris) * ratio
+		if float32(tris) > target*1.1 || float32(tris) < target*0.9 {
+			t.Errorf("ratio %v: expected about %v triangles got %d", ratio, target, tris)
+		}
+		items := g.Items()
+		for i := 0; i < indices.Size(); i += 3 {
+			a, b, c := indices[i], indices[i+1], indices[i+2]
+			if int(a) >= items || int(b) >= items || int(c) >= items {
+				t.Fatalf("ratio %v: index out of range at %d", ratio, i)
+			}
+			if a == b || b == c || a == c {
+				t.Fatalf("ratio %v: degenerate triangle at %d", ratio, i)
+			}
+		}
+		if g.VBO(gls.VertexNormal) == nil || g.VBO(gls.VertexTexcoord) == nil {
+			t.Errorf("ratio %v: missing vertex attributes", ratio)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// ApplyPlanarUV sets the texture coordinates of this geometry by projecting
+// its vertex positions along the specified axis (0 for X, 1 for Y and 2 for Z)
+// onto the opposite face of its bounding box, which is mapped to the [0,1] range.
+// The VertexTexcoord attribute is added if necessary.
+func (g *Geometry) ApplyPlanarUV(axis int) {
+
+	if axis < 0 || axis > 2 {
+		panic("Geometry.ApplyPlanarUV: invalid axis")
+	}
+	bbox := g.BoundingBox()
+	var size math32.Vector3
+	size.SubVectors(&bbox.Max, &bbox.Min)
+	g.applyUV(func(pos, normal *math32.Vector3) (float32, float32) {
+		pos.Sub(&bbox.Min)
+		switch axis {
+		case 0:
+			return uvRatio(pos.Z, size.Z), uvRatio(pos.Y, size.Y)
+		case 1:
+			return uvRatio(pos.X, size.X), uvRatio(pos.Z, size.Z)
+		default:
+			return uvRatio(pos.X, size.X), uvRatio(pos.Y, size.Y)
+		}
+	})
+}
+
+// ApplyBoxUV sets the texture coordinates of this geometry by projecting each vertex
+// position onto the face of a box selected by the dominant axis of the vertex normal.
+// The scale sets the number of texture repetitions per unit length along each axis,
+// so that tiling textures have the same density on all the faces.
+// Without vertex normals, the direction from the bounding box center is used.
+// The VertexTexcoord attribute is added if necessary.
+func (g *Geometry) ApplyBoxUV(scale math32.Vector3) {
+
+	bbox := g.BoundingBox()
+	g.applyUV(func(pos, normal *math32.Vector3) (float32, float32) {
+		ax, ay, az := math32.Abs(normal.X), math32.Abs(normal.Y), math32.Abs(normal.Z)
+		pos.Sub(&bbox.Min).Multiply(&scale)
+		if ax >= ay && ax >= az {
+			return pos.Z, pos.Y
+		}
+		if ay >= az {
+			return pos.X, pos.Z
+		}
+		return pos.X, pos.Y
+	})
+}
+
+// ApplySphericalUV sets the texture coordinates of this geometry by projecting
+// its vertex positions onto a sphere centered on its bounding box center.
+// The longitude is mapped to U and the latitude to V, both in the [0,1] range.
+// The VertexTexcoord attribute is added if necessary.
+func (g *Geometry) ApplySphericalUV() {
+
+	bbox := g.BoundingBox()
+	var center math32.Vector3
+	bbox.Center(&center)
+	g.applyUV(func(pos, normal *math32.Vector3) (float32, float32) {
+		if pos.Sub(&center).LengthSq() == 0 {
+			return 0.5, 0.5
+		}
+		pos.Normalize()
+		u := 0.5 + math32.Atan2(pos.X, pos.Z)/(2*math32.Pi)
+		v := 0.5 + math32.Asin(math32.Clamp(pos.Y, -1, 1))/math32.Pi
+		return u, v
+	})
+}
+
+// applyUV sets the texture coordinates of all the vertices from the values returned by
+// the specified function for the vertex position and normal, which may be modified.
+// Without vertex normals, the direction from the bounding box center is used.
+func (g *Geometry) applyUV(uvFunc func(pos, normal *math32.Vector3) (float32, float32)) {
+
+	vboPos := g.VBO(gls.VertexPosition)
+	if vboPos == nil {
+		return
+	}
+	vboNormal := g.VBO(gls.VertexNormal)
+	items := g.Items()
+	var center math32.Vector3
+	bbox := g.BoundingBox()
+	bbox.Center(&center)
+
+	// Gets or creates the VBO for the texture coordinates
+	vboUV := g.VBO(gls.VertexTexcoord)
+	if vboUV == nil {
+		vboUV = gls.NewVBO(math32.NewArrayF32(2*items, 2*items)).AddAttrib(gls.VertexTexcoord)
+		g.AddVBO(vboUV)
+	}
+
+	var pos, normal math32.Vector3
+	uvs := vboUV.Buffer()
+	for i := 0; i < items; i++ {
+		vboPos.Buffer().GetVector3(i*vboPos.Stride()+vboPos.AttribOffset(gls.VertexPosition), &pos)
+		if vboNormal != nil {
+			vboNormal.Buffer().GetVector3(i*vboNormal.Stride()+vboNormal.AttribOffset(gls.VertexNormal), &normal)
+		} else {
+			normal.SubVectors(&pos, &center)
+		}
+		u, v := uvFunc(&pos, &normal)
+		uvs.Set(i*vboUV.Stride()+vboUV.AttribOffset(gls.VertexTexcoord), u, v)
+	}
+	vboUV.Update()
+}
+
+// uvRatio returns the ratio of the specified value to the specified size or 0 if the size is 0.
+func uvRatio(value, size float32) float32 {
+
+	if size == 0 {
+		return 0
+	}
+	return value / size
+}
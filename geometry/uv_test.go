@@ -0,0 +1,59 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"testing"
+
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// Test the ranges of the texture coordinates generated by the projections
+func TestApplyUV(t *testing.T) {
+
+	// checkRange checks that all the texture coordinates are within the specified range
+	checkRange := func(name string, g *Geometry, min, max float32) {
+		vbo := g.VBO(gls.VertexTexcoord)
+		if vbo == nil {
+			t.Fatalf("%s: missing texture coordinates", name)
+		}
+		uvs := *vbo.Buffer()
+		if len(uvs) != 2*g.Items() {
+			t.Fatalf("%s: expected %d coordinates got %d", name, 2*g.Items(), len(uvs))
+		}
+		lo, hi := uvs[0], uvs[0]
+		for _, c := range uvs {
+			lo, hi = math32.Min(lo, c), math32.Max(hi, c)
+		}
+		if lo < min-1e-5 || hi > max+1e-5 {
+			t.Errorf("%s: coordinates range [%v,%v] outside [%v,%v]", name, lo, hi, min, max)
+		}
+		if hi-lo < (max-min)/2 {
+			t.Errorf("%s: coordinates range [%v,%v] too small", name, lo, hi)
+		}
+	}
+
+	// Geometry without normals and texture coordinates
+	positions := math32.NewArrayF32(0, 0)
+	positions.Append(9, -2, 0, 13, -2, 1, 13, 2, 2, 9, 2, 3)
+	quad := NewGeometry()
+	quad.AddVBO(gls.NewVBO(positions).AddAttrib(gls.VertexPosition))
+	quad.SetIndices(math32.ArrayU32{0, 1, 2, 0, 2, 3})
+	for axis := 0; axis < 3; axis++ {
+		quad.ApplyPlanarUV(axis)
+		checkRange("planar", quad, 0, 1)
+	}
+
+	box := NewBox(2, 4, 8)
+	box.ApplyBoxUV(math32.Vector3{X: 0.5, Y: 0.5, Z: 0.5})
+	checkRange("box", &box.Geometry, 0, 4)
+
+	sphere := NewSphere(3, 16, 8, 0, 2*math32.Pi, 0, math32.Pi)
+	sphere.ApplySphericalUV()
+	checkRange("spherical", &sphere.Geometry, 0, 1)
+	quad.ApplySphericalUV()
+	checkRange("spherical quad", quad, 0, 1)
+}
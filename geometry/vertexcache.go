@@ -0,0 +1,234 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"math"
+
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// Parameters of the vertex cache optimization scores (Tom Forsyth, "Linear-Speed Vertex Cache Optimisation")
+const (
+	vcacheSize         = 32   // Size of the simulated vertex cache
+	vcacheDecayPower   = 1.5  // Decay of the score with the position in the cache
+	vcacheLastTriScore = 0.75 // Score of the vertices of the last triangle
+	vcacheValenceScale = 2.0  // Scale of the bonus of the vertices with few remaining triangles
+	vcacheValencePower = 0.5  // Power of the bonus of the vertices with few remaining triangles
+)
+
+// OptimizeVertexCache reorders the triangles of the specified indexed geometry to improve
+// the hit rate of the GPU post-transform vertex cache, using Tom Forsyth's algorithm.
+// The triangles keep their vertices and winding and stay inside their geometry group.
+// NOTE: This only works for triangle lists without primitive restart.
+func OptimizeVertexCache(g *Geometry) {
+
+	if !g.Indexed() || g.PrimitiveRestart() {
+		return
+	}
+	nverts := 0
+	for _, v := range g.indices {
+		if int(v) >= nverts {
+			nverts = int(v) + 1
+		}
+	}
+	if len(g.groups) == 0 {
+		optimizeTriangles(g.indices[:len(g.indices)/3*3], nverts)
+	}
+	for _, group := range g.groups {
+		end := group.Start + group.Count
+		if end > len(g.indices) {
+			end = len(g.indices)
+		}
+		optimizeTriangles(g.indices[group.Start:end-(end-group.Start)%3], nverts)
+	}
+	g.updateIndices = true
+}
+
+// OptimizeVertexFetch reorders the vertices of the specified indexed geometry in the order
+// they are first used by its indices, and remaps the indices, to improve the locality of the
+// vertex fetches. Unused vertices are moved after the used ones. It should be called after
+// OptimizeVertexCache. Morph targets of the geometry are not reordered.
+func OptimizeVertexFetch(g *Geometry) {
+
+	if !g.Indexed() {
+		return
+	}
+	g.checkPositions()
+	defer g.syncPositions()
+	items := g.Items()
+	remap := make([]uint32, items)
+	for i := range remap {
+		remap[i] = math.MaxUint32
+	}
+	order := make([]uint32, 0, items)
+	for i, v := range g.indices {
+		if v == RestartIndex && g.restart {
+			continue
+		}
+		if remap[v] == math.MaxUint32 {
+			remap[v] = uint32(len(order))
+			order = append(order, v)
+		}
+		g.indices[i] = remap[v]
+	}
+	for v := range remap {
+		if remap[v] == math.MaxUint32 {
+			order = append(order, uint32(v))
+		}
+	}
+
+	// Moves the vertices attributes to their new positions
+	for _, vbo := range g.vbos {
+		if instancedVBO(vbo) {
+			continue
+		}
+		stride := vbo.StrideSize()
+		if src := vbo.Bytes(); src != nil {
+			data := make([]byte, 0, len(src))
+			for _, v := range order {
+				data = append(data, src[int(v)*stride:int(v+1)*stride]...)
+			}
+			vbo.SetBytes(data)
+			continue
+		}
+		src := *vbo.Buffer()
+		stride /= 4
+		data := math32.NewArrayF32(0, len(src))
+		for _, v := range order {
+			data.Append(src[int(v)*stride : int(v+1)*stride]...)
+		}
+		vbo.SetBuffer(data)
+	}
+	g.updateIndices = true
+}
+
+// vertexCacheScore returns the score of a vertex at the specified position in the
+// simulated cache, or -1 if it is not cached, with the specified number of remaining triangles.
+func vertexCacheScore(pos, remaining int) float32 {
+
+	if remaining == 0 {
+		return -1
+	}
+	score := 0.0
+	if pos >= 0 {
+		if pos < 3 {
+			score = vcacheLastTriScore
+		} else {
+			score = math.Pow(1-float64(pos-3)/(vcacheSize-3), vcacheDecayPower)
+		}
+	}
+	score += vcacheValenceScale * math.Pow(float64(remaining), -vcacheValencePower)
+	return float32(score)
+}
+
+// optimizeTriangles reorders the triangles of the specified indices, whose vertices are below nverts,
+// choosing at each step the triangle with the best score from the vertices in the simulated cache.
+func optimizeTriangles(indices []uint32, nverts int) {
+
+	ntris := len(indices) / 3
+	if ntris < 2 {
+		return
+	}
+
+	// Triangles using each vertex, the first remaining[v] ones are not emitted yet
+	remaining := make([]int, nverts)
+	for _, v := range indices {
+		remaining[v]++
+	}
+	start := make([]int, nverts+1)
+	for v := 0; v < nverts; v++ {
+		start[v+1] = start[v] + remaining[v]
+	}
+	adj := make([]int, len(indices))
+	fill := append([]int(nil), start[:nverts]...)
+	for i, v := range indices {
+		adj[fill[v]] = i / 3
+		fill[v]++
+	}
+
+	// Initial scores
+	cachePos := make([]int, nverts)
+	vscore := make([]float32, nverts)
+	for v := range cachePos {
+		cachePos[v] = -1
+		vscore[v] = vertexCacheScore(-1, remaining[v])
+	}
+	tscore := make([]float32, ntris)
+	emitted := make([]bool, ntris)
+	best := 0
+	for t := range tscore {
+		tscore[t] = vscore[indices[3*t]] + vscore[indices[3*t+1]] + vscore[indices[3*t+2]]
+		if tscore[t] > tscore[best] {
+			best = t
+		}
+	}
+
+	out := make([]uint32, 0, len(indices))
+	cache := make([]uint32, 0, vcacheSize+3)
+	next := make([]uint32, 0, vcacheSize+3)
+	for n := 0; n < ntris; n++ {
+		// Falls back to the best remaining triangle when no cached vertex has one left
+		if best < 0 {
+			for t := range tscore {
+				if !emitted[t] && (best < 0 || tscore[t] > tscore[best]) {
+					best = t
+				}
+			}
+		}
+		t := best
+		emitted[t] = true
+		tri := indices[3*t : 3*t+3]
+		out = append(out, tri...)
+		for _, v := range tri {
+			s, e := start[v], start[v]+remaining[v]
+			for i := s; i < e; i++ {
+				if adj[i] == t {
+					adj[i], adj[e-1] = adj[e-1], adj[i]
+					break
+				}
+			}
+			remaining[v]--
+		}
+
+		// Moves the triangle vertices to the front of the cache
+		next = next[:0]
+		for _, v := range tri {
+			if cachePos[v] != -2 {
+				cachePos[v] = -2
+				next = append(next, v)
+			}
+		}
+		for _, v := range cache {
+			if cachePos[v] != -2 {
+				next = append(next, v)
+			}
+		}
+		for i, v := range next {
+			pos := i
+			if i >= vcacheSize {
+				pos = -1
+			}
+			cachePos[v] = pos
+			vscore[v] = vertexCacheScore(pos, remaining[v])
+		}
+
+		// Updates the scores of the triangles of the vertices whose score changed
+		best = -1
+		for _, v := range next {
+			for _, nt := range adj[start[v] : start[v]+remaining[v]] {
+				tscore[nt] = vscore[indices[3*nt]] + vscore[indices[3*nt+1]] + vscore[indices[3*nt+2]]
+				if best < 0 || tscore[nt] > tscore[best] {
+					best = nt
+				}
+			}
+		}
+		if len(next) > vcacheSize {
+			next = next[:vcacheSize]
+		}
+		cache, next = next, cache
+	}
+	copy(indices, out)
+}
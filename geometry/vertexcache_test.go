@@ -0,0 +1,120 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// Test that the vertex cache and fetch optimizations keep the triangles and improve the cache hits
+func TestOptimizeVertexCache(t *testing.T) {
+
+	g := &NewSphere(1, 32, 16, 0, 2*math.Pi, 0, math.Pi).Geometry
+
+	// Shuffles the triangles to simulate a badly ordered mesh
+	rnd := rand.New(rand.NewSource(1))
+	ntris := len(g.indices) / 3
+	for i := ntris - 1; i > 0; i-- {
+		j := rnd.Intn(i + 1)
+		for k := 0; k < 3; k++ {
+			g.indices[3*i+k], g.indices[3*j+k] = g.indices[3*j+k], g.indices[3*i+k]
+		}
+	}
+	before := positionTriangles(g)
+	shuffled := append([]uint32(nil), g.indices...)
+	acmr := cacheMissRatio(g.indices, 16)
+
+	OptimizeVertexCache(g)
+	if optimized := cacheMissRatio(g.indices, 16); optimized >= acmr*0.6 {
+		t.Errorf("expected a better cache miss ratio than %v got %v", acmr, optimized)
+	}
+	if !equalTriangles(before, positionTriangles(g)) {
+		t.Error("expected the same triangles after the vertex cache optimization")
+	}
+	same := true
+	for i := range shuffled {
+		same = same && shuffled[i] == g.indices[i]
+	}
+	if same {
+		t.Error("expected a different triangle order")
+	}
+
+	OptimizeVertexFetch(g)
+	if !equalTriangles(before, positionTriangles(g)) {
+		t.Error("expected the same triangles after the vertex fetch optimization")
+	}
+	next := uint32(0)
+	for _, v := range g.indices {
+		if v > next {
+			t.Fatalf("expected the vertices in the order of their first use got %d before %d", v, next)
+		}
+		if v == next {
+			next++
+		}
+	}
+}
+
+// cacheMissRatio returns the average number of vertex transforms per triangle
+// of the specified indices with a FIFO vertex cache of the specified size.
+func cacheMissRatio(indices []uint32, size int) float32 {
+
+	var fifo []uint32
+	misses := 0
+	for _, v := range indices {
+		hit := false
+		for _, c := range fifo {
+			hit = hit || c == v
+		}
+		if !hit {
+			misses++
+			fifo = append(fifo, v)
+			if len(fifo) > size {
+				fifo = fifo[1:]
+			}
+		}
+	}
+	return float32(misses) / float32(len(indices)/3)
+}
+
+// positionTriangles returns the positions of the triangles of the geometry
+// rotated to start with their smallest vertex, which keeps their winding.
+func positionTriangles(g *Geometry) []string {
+
+	var tris []string
+	for _, tri := range g.triangles() {
+		var s [3]string
+		for i, v := range tri {
+			p := g.position(v)
+			s[i] = fmt.Sprintf("%.4f,%.4f,%.4f", p.X, p.Y, p.Z)
+		}
+		first := 0
+		for i := 1; i < 3; i++ {
+			if s[i] < s[first] {
+				first = i
+			}
+		}
+		tris = append(tris, s[first]+" "+s[(first+1)%3]+" "+s[(first+2)%3])
+	}
+	sort.Strings(tris)
+	return tris
+}
+
+// equalTriangles returns if the two sorted lists of triangles are equal.
+func equalTriangles(a, b []string) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,276 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"math"
+
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// FixWinding makes the winding of the triangles of this geometry consistent.
+// Triangles sharing an edge, including through split vertices with the same position,
+// are oriented alike, and each connected part of the geometry keeps the winding of
+// the majority of its surface, so consistently wound geometries are not changed.
+// Vertex normals of the flipped triangles pointing against their new face are inverted.
+// Returns the number of flipped triangles.
+// NOTE: This only works for triangle-based meshes.
+func (g *Geometry) FixWinding() int {
+
+	// The flipped vertices keep the geometric properties
+	g.checkPositions()
+	defer g.syncPositions()
+	tris := g.triangles()
+	if len(tris) == 0 {
+		return 0
+	}
+
+	// Welds the vertices by position and maps each edge to its triangles
+	weld := make(map[[3]uint32]uint32)
+	welded := make([][3]uint32, len(tris))
+	for t, tri := range tris {
+		for i, v := range tri {
+			p := g.position(v)
+			key := [3]uint32{math.Float32bits(p.X), math.Float32bits(p.Y), math.Float32bits(p.Z)}
+			w, ok := weld[key]
+			if !ok {
+				w = uint32(len(weld))
+				weld[key] = w
+			}
+			welded[t][i] = w
+		}
+	}
+	edges := make(map[uint64][]int)
+	for t, tri := range welded {
+		for i := 0; i < 3; i++ {
+			a, b := tri[i], tri[(i+1)%3]
+			if a != b {
+				edges[edgeKey(a, b)] = append(edges[edgeKey(a, b)], t)
+			}
+		}
+	}
+
+	// hasEdge returns if the triangle has the specified directed edge
+	hasEdge := func(t int, a, b uint32) bool {
+		tri := welded[t]
+		for i := 0; i < 3; i++ {
+			if tri[i] == a && tri[(i+1)%3] == b {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Propagates the orientation through each connected part
+	flip := make([]bool, len(tris))
+	visited := make([]bool, len(tris))
+	count := 0
+	for seed := range tris {
+		if visited[seed] {
+			continue
+		}
+		visited[seed] = true
+		part := []int{seed}
+		for i := 0; i < len(part); i++ {
+			t := part[i]
+			for j := 0; j < 3; j++ {
+				a, b := welded[t][j], welded[t][(j+1)%3]
+				if flip[t] {
+					a, b = b, a
+				}
+				if a == b {
+					continue
+				}
+				for _, n := range edges[edgeKey(a, b)] {
+					if visited[n] {
+						continue
+					}
+					visited[n] = true
+					flip[n] = hasEdge(n, a, b)
+					part = append(part, n)
+				}
+			}
+		}
+
+		// Keeps the winding of the majority of the part surface
+		var flipped, kept float32
+		for _, t := range part {
+			a, b, c := g.position(tris[t][0]), g.position(tris[t][1]), g.position(tris[t][2])
+			b.Sub(&a)
+			c.Sub(&a)
+			area := b.Cross(&c).Length()
+			if flip[t] {
+				flipped += area
+			} else {
+				kept += area
+			}
+		}
+		for _, t := range part {
+			if flipped > kept {
+				flip[t] = !flip[t]
+			}
+			if flip[t] {
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+
+	// Flips the triangles
+	for t, tri := range tris {
+		if !flip[t] {
+			continue
+		}
+		if g.Indexed() {
+			g.indices[3*t+1], g.indices[3*t+2] = tri[2], tri[1]
+			tris[t][1], tris[t][2] = tri[2], tri[1]
+		} else {
+			g.swapVertices(tri[1], tri[2])
+		}
+	}
+	if g.Indexed() {
+		g.updateIndices = true
+	}
+
+	// Inverts the normals of the flipped triangles vertices facing away from their faces
+	if vbo := g.VBO(gls.VertexNormal); vbo != nil && vbo.Bytes() == nil {
+		faces := make(map[uint32]math32.Vector3)
+		for t, tri := range tris {
+			if !flip[t] {
+				continue
+			}
+			for _, v := range tri {
+				faces[v] = math32.Vector3{}
+			}
+		}
+		for _, tri := range tris {
+			a, b, c := g.position(tri[0]), g.position(tri[1]), g.position(tri[2])
+			b.Sub(&a)
+			c.Sub(&a)
+			b.Cross(&c)
+			for _, v := range tri {
+				if n, ok := faces[v]; ok {
+					faces[v] = *n.Add(&b)
+				}
+			}
+		}
+		data := *vbo.Buffer()
+		offset := int(vbo.Attrib(gls.VertexNormal).ByteOffset) / 4
+		stride := vbo.StrideSize() / 4
+		for v, face := range faces {
+			var normal math32.Vector3
+			data.GetVector3(int(v)*stride+offset, &normal)
+			if normal.Dot(&face) < 0 {
+				normal.Negate()
+				data.SetVector3(int(v)*stride+offset, &normal)
+			}
+		}
+		vbo.Update()
+	}
+	g.volumeValid = false
+	g.rotInertiaValid = false
+	return count
+}
+
+// ComputeSignedVolume computes and returns the signed volume enclosed by this geometry.
+// The volume is positive when the triangles are wound counter-clockwise as seen
+// from outside and negative when the geometry is inside-out.
+// NOTE: This only works for closed triangle-based meshes.
+func (g *Geometry) ComputeSignedVolume() float32 {
+
+	var volume float32
+	for _, tri := range g.triangles() {
+		a, b, c := g.position(tri[0]), g.position(tri[1]), g.position(tri[2])
+		volume += a.Dot(b.Cross(&c)) / 6
+	}
+	return volume
+}
+
+// IsConvex returns if all the vertices of this geometry lie on the same side
+// of the plane of each of its triangles, regardless of their winding.
+// Its cost is proportional to the number of triangles times the number of vertices.
+// NOTE: This only works for triangle-based meshes.
+func (g *Geometry) IsConvex() bool {
+
+	tris := g.triangles()
+	items := g.Items()
+	bbox := g.BoundingBox()
+	size := bbox.Max.DistanceTo(&bbox.Min)
+	tolerance := size * 1e-5
+	for _, tri := range tris {
+		a, b, c := g.position(tri[0]), g.position(tri[1]), g.position(tri[2])
+		b.Sub(&a)
+		c.Sub(&a)
+		normal := b.Cross(&c)
+		if normal.Length() <= tolerance*size {
+			continue
+		}
+		normal.Normalize()
+		above, below := false, false
+		for v := 0; v < items; v++ {
+			p := g.position(uint32(v))
+			d := normal.Dot(p.Sub(&a))
+			if d > tolerance {
+				above = true
+			} else if d < -tolerance {
+				below = true
+			}
+			if above && below {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// triangles returns the vertex indices of the triangles of the geometry.
+func (g *Geometry) triangles() [][3]uint32 {
+
+	if g.VBO(gls.VertexPosition) == nil || g.VBO(gls.VertexPosition).Bytes() != nil {
+		return nil
+	}
+	order := g.vertexOrder()
+	tris := make([][3]uint32, len(order)/3)
+	for t := range tris {
+		tris[t] = [3]uint32{order[3*t], order[3*t+1], order[3*t+2]}
+	}
+	return tris
+}
+
+// position returns the position of the specified vertex.
+func (g *Geometry) position(v uint32) math32.Vector3 {
+
+	vbo := g.VBO(gls.VertexPosition)
+	offset := int(vbo.Attrib(gls.VertexPosition).ByteOffset) / 4
+	var p math32.Vector3
+	vbo.Buffer().GetVector3(int(v)*vbo.StrideSize()/4+offset, &p)
+	return p
+}
+
+// swapVertices swaps the attributes of the specified vertices in all the per vertex VBOs.
+func (g *Geometry) swapVertices(a, b uint32) {
+
+	for _, vbo := range g.vbos {
+		if instancedVBO(vbo) {
+			continue
+		}
+		stride := vbo.StrideSize()
+		if data := vbo.Bytes(); data != nil {
+			for i := 0; i < stride; i++ {
+				data[int(a)*stride+i], data[int(b)*stride+i] = data[int(b)*stride+i], data[int(a)*stride+i]
+			}
+		} else {
+			data := *vbo.Buffer()
+			stride /= 4
+			for i := 0; i < stride; i++ {
+				data[int(a)*stride+i], data[int(b)*stride+i] = data[int(b)*stride+i], data[int(a)*stride+i]
+			}
+		}
+		vbo.Update()
+	}
+}
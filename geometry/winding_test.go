@@ -0,0 +1,59 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"testing"
+
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// Test fixing a cube with some of its faces wound the wrong way
+func TestFixWinding(t *testing.T) {
+
+	box := NewBox(2, 2, 2)
+	if v := box.ComputeSignedVolume(); math32.Abs(v-8) > 1e-4 {
+		t.Fatalf("expected volume 8 got %v", v)
+	}
+	if !box.IsConvex() {
+		t.Error("expected convex box")
+	}
+	if box.FixWinding() != 0 {
+		t.Error("expected no flipped triangles")
+	}
+
+	// Mis-winds the triangles of the first face and one triangle of the second face
+	indexed := &NewBox(2, 2, 2).Geometry
+	for _, tri := range []int{0, 1, 2} {
+		indexed.indices[3*tri+1], indexed.indices[3*tri+2] = indexed.indices[3*tri+2], indexed.indices[3*tri+1]
+	}
+	flat := indexed.ToNonIndexed()
+	for name, g := range map[string]*Geometry{"indexed": indexed, "non indexed": flat} {
+		if n := g.FixWinding(); n != 3 {
+			t.Errorf("%s: expected 3 flipped triangles got %d", name, n)
+		}
+		if v := g.ComputeSignedVolume(); math32.Abs(v-8) > 1e-4 {
+			t.Errorf("%s: expected volume 8 got %v", name, v)
+		}
+		if n := g.FixWinding(); n != 0 {
+			t.Errorf("%s: expected consistent winding got %d flipped triangles", name, n)
+		}
+	}
+
+	// An inside-out geometry is consistent
+	for i := 0; i < len(indexed.indices); i += 3 {
+		indexed.indices[i+1], indexed.indices[i+2] = indexed.indices[i+2], indexed.indices[i+1]
+	}
+	if n := indexed.FixWinding(); n != 0 {
+		t.Errorf("expected no flipped triangles got %d", n)
+	}
+	if v := indexed.ComputeSignedVolume(); math32.Abs(v+8) > 1e-4 {
+		t.Errorf("expected volume -8 got %v", v)
+	}
+
+	if NewTorus(1, 0.3, 8, 12, 2*math32.Pi).IsConvex() {
+		t.Error("expected concave torus")
+	}
+}
@@ -0,0 +1,460 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+import (
+	"unsafe"
+
+	gl "github.com/thommil/tge-gl"
+)
+
+// GL is the set of OpenGL functions called by GLS, with the signatures of tge-gl.
+// It allows to replace OpenGL, such as to record the calls in tests.
+type GL interface {
+	ActiveTexture(texture gl.Enum)
+	AttachShader(p gl.Program, s gl.Shader)
+	BeginTransformFeedback(mode gl.Enum)
+	BindBuffer(target gl.Enum, b gl.Buffer)
+	BindBufferBase(target gl.Enum, index int, b gl.Buffer)
+	BindFramebuffer(target gl.Enum, fb gl.Framebuffer)
+	BindRenderbuffer(target gl.Enum, rb gl.Renderbuffer)
+	BindTexture(target gl.Enum, t gl.Texture)
+	BindVertexArray(vao gl.VertexArray)
+	BlitFramebuffer(srcX0, srcY0, srcX1, srcY1, dstX0, dstY0, dstX1, dstY1 int, mask, filter gl.Enum)
+	BlendEquation(mode gl.Enum)
+	BlendEquationSeparate(modeRGB, modeAlpha gl.Enum)
+	BlendFunc(sfactor, dfactor gl.Enum)
+	BlendFuncSeparate(sfactorRGB, dfactorRGB, sfactorAlpha, dfactorAlpha gl.Enum)
+	BufferData(target gl.Enum, src []byte, usage gl.Enum)
+	BufferSubData(target gl.Enum, offset int, data []byte)
+	CheckFramebufferStatus(target gl.Enum) gl.Enum
+	Clear(mask gl.Enum)
+	ClearColor(red, green, blue, alpha float32)
+	ClearStencil(s int)
+	CompileShader(s gl.Shader)
+	CompressedTexImage2D(target gl.Enum, level int, iformat gl.Enum, width, height, border int, data []byte)
+	CreateBuffer() gl.Buffer
+	CreateFramebuffer() gl.Framebuffer
+	CreateProgram() gl.Program
+	CreateRenderbuffer() gl.Renderbuffer
+	CreateShader(ty gl.Enum) gl.Shader
+	CreateTexture() gl.Texture
+	CreateVertexArray() gl.VertexArray
+	CullFace(mode gl.Enum)
+	DeleteBuffer(v gl.Buffer)
+	DeleteFramebuffer(v gl.Framebuffer)
+	DeleteProgram(p gl.Program)
+	DeleteRenderbuffer(v gl.Renderbuffer)
+	DeleteShader(s gl.Shader)
+	DeleteTexture(v gl.Texture)
+	DeleteVertexArray(v gl.VertexArray)
+	DepthFunc(fn gl.Enum)
+	DepthMask(flag bool)
+	Disable(cap gl.Enum)
+	DrawArrays(mode gl.Enum, first, count int)
+	DrawArraysInstanced(m gl.Enum, first, count, inst int)
+	DrawBuffers(bufs []gl.Enum)
+	DrawElements(mode gl.Enum, count int, ty gl.Enum, offset int)
+	DrawElementsInstanced(m gl.Enum, count int, ty gl.Enum, off, inst int)
+	Enable(cap gl.Enum)
+	EnableVertexAttribArray(a gl.Attrib)
+	EndTransformFeedback()
+	FramebufferRenderbuffer(target, attachment, rbTarget gl.Enum, rb gl.Renderbuffer)
+	FramebufferTexture2D(target, attachment, texTarget gl.Enum, t gl.Texture, level int)
+	FramebufferTextureLayer(target, attachment gl.Enum, t gl.Texture, level, layer int)
+	FrontFace(mode gl.Enum)
+	GenerateMipmap(target gl.Enum)
+	GetAttribLocation(p gl.Program, name string) gl.Attrib
+	GetBufferSubData(target gl.Enum, offset int, data []byte)
+	GetFloat(pname gl.Enum) float32
+	GetInteger(pname gl.Enum) int
+	GetProgramInfoLog(p gl.Program) string
+	GetProgrami(p gl.Program, pname gl.Enum) int
+	GetShaderInfoLog(s gl.Shader) string
+	GetShaderi(s gl.Shader, pname gl.Enum) int
+	GetString(pname gl.Enum) string
+	GetUniformLocation(p gl.Program, name string) gl.Uniform
+	IsEnabled(cap gl.Enum) bool
+	LineWidth(width float32)
+	LinkProgram(p gl.Program)
+	MultiDrawElementsIndirect(m, ty gl.Enum, indirect, drawcount, stride int)
+	PolygonMode(face, mode gl.Enum)
+	PolygonOffset(factor, units float32)
+	PrimitiveRestartIndex(index uint32)
+	ReadBuffer(src gl.Enum)
+	RenderbufferStorage(target, internalFormat gl.Enum, width, height int)
+	RenderbufferStorageMultisample(target gl.Enum, samples int, internalFormat gl.Enum, width, height int)
+	Scissor(x, y, width, height int32)
+	ShaderSource(s gl.Shader, src string)
+	StencilFunc(fn gl.Enum, ref int, mask uint32)
+	StencilMask(mask uint32)
+	StencilOp(fail, zfail, zpass gl.Enum)
+	TexImage2D(target gl.Enum, level, width, height int, format, ty gl.Enum, data []byte)
+	TexImage3D(target gl.Enum, level int, iformat gl.Enum, width, height, depth int, format, ty gl.Enum, data []byte)
+	TexParameterf(target, pname gl.Enum, param float32)
+	TexParameteri(target, pname gl.Enum, param int)
+	TransformFeedbackVaryings(p gl.Program, varyings []string, mode gl.Enum)
+	Uniform1f(dst gl.Uniform, v float32)
+	Uniform1fv(dst gl.Uniform, src []float32)
+	Uniform1i(dst gl.Uniform, v int)
+	Uniform2f(dst gl.Uniform, v0, v1 float32)
+	Uniform2fvP(dst gl.Uniform, count int32, value *float32)
+	Uniform2fvUP(dst gl.Uniform, count int32, value unsafe.Pointer)
+	Uniform3f(dst gl.Uniform, v0, v1, v2 float32)
+	Uniform3fvP(dst gl.Uniform, count int32, value *float32)
+	Uniform3fvUP(dst gl.Uniform, count int32, value unsafe.Pointer)
+	Uniform4f(dst gl.Uniform, v0, v1, v2, v3 float32)
+	Uniform4fv(dst gl.Uniform, src []float32)
+	Uniform4fvUP(dst gl.Uniform, count int32, value unsafe.Pointer)
+	UniformMatrix3fvP(dst gl.Uniform, count int32, transpose bool, value *float32)
+	UniformMatrix4fvP(dst gl.Uniform, count int32, transpose bool, value *float32)
+	UseProgram(p gl.Program)
+	VertexAttribDivisor(a gl.Attrib, d int)
+	VertexAttribIPointer(a gl.Attrib, s int, t gl.Enum, st, o int)
+	VertexAttribPointer(dst gl.Attrib, size int, ty gl.Enum, normalized bool, stride, offset int)
+	Viewport(x, y, width, height int)
+}
+
+// TgeGL is the GL backend calling the OpenGL functions of tge-gl, used by New.
+// The OpenGL 3 functions not provided by tge-gl are called with the go-gl binding
+// initialized by tge-gl on desktop, see backend_desktop.go, and are not supported
+// on the other platforms.
+type TgeGL struct{}
+
+func (TgeGL) ActiveTexture(texture gl.Enum) {
+	gl.ActiveTexture(texture)
+}
+
+func (TgeGL) AttachShader(p gl.Program, s gl.Shader) {
+	gl.AttachShader(p, s)
+}
+
+func (TgeGL) BindBuffer(target gl.Enum, b gl.Buffer) {
+	gl.BindBuffer(target, b)
+}
+
+func (TgeGL) BindFramebuffer(target gl.Enum, fb gl.Framebuffer) {
+	gl.BindFramebuffer(target, fb)
+}
+
+func (TgeGL) BindRenderbuffer(target gl.Enum, rb gl.Renderbuffer) {
+	gl.BindRenderbuffer(target, rb)
+}
+
+func (TgeGL) BindTexture(target gl.Enum, t gl.Texture) {
+	gl.BindTexture(target, t)
+}
+
+func (TgeGL) BindVertexArray(vao gl.VertexArray) {
+	gl.BindVertexArray(vao)
+}
+
+func (TgeGL) BlendEquation(mode gl.Enum) {
+	gl.BlendEquation(mode)
+}
+
+func (TgeGL) BlendEquationSeparate(modeRGB, modeAlpha gl.Enum) {
+	gl.BlendEquationSeparate(modeRGB, modeAlpha)
+}
+
+func (TgeGL) BlendFunc(sfactor, dfactor gl.Enum) {
+	gl.BlendFunc(sfactor, dfactor)
+}
+
+func (TgeGL) BlendFuncSeparate(sfactorRGB, dfactorRGB, sfactorAlpha, dfactorAlpha gl.Enum) {
+	gl.BlendFuncSeparate(sfactorRGB, dfactorRGB, sfactorAlpha, dfactorAlpha)
+}
+
+func (TgeGL) BufferData(target gl.Enum, src []byte, usage gl.Enum) {
+	gl.BufferData(target, src, usage)
+}
+
+func (TgeGL) BufferSubData(target gl.Enum, offset int, data []byte) {
+	gl.BufferSubData(target, offset, data)
+}
+
+func (TgeGL) CheckFramebufferStatus(target gl.Enum) gl.Enum {
+	return gl.CheckFramebufferStatus(target)
+}
+
+func (TgeGL) Clear(mask gl.Enum) {
+	gl.Clear(mask)
+}
+
+func (TgeGL) ClearColor(red, green, blue, alpha float32) {
+	gl.ClearColor(red, green, blue, alpha)
+}
+
+func (TgeGL) ClearStencil(s int) {
+	gl.ClearStencil(s)
+}
+
+func (TgeGL) CompileShader(s gl.Shader) {
+	gl.CompileShader(s)
+}
+
+func (TgeGL) CompressedTexImage2D(target gl.Enum, level int, iformat gl.Enum, width, height, border int, data []byte) {
+	gl.CompressedTexImage2D(target, level, iformat, width, height, border, data)
+}
+
+func (TgeGL) CreateBuffer() gl.Buffer {
+	return gl.CreateBuffer()
+}
+
+func (TgeGL) CreateFramebuffer() gl.Framebuffer {
+	return gl.CreateFramebuffer()
+}
+
+func (TgeGL) CreateProgram() gl.Program {
+	return gl.CreateProgram()
+}
+
+func (TgeGL) CreateRenderbuffer() gl.Renderbuffer {
+	return gl.CreateRenderbuffer()
+}
+
+func (TgeGL) CreateShader(ty gl.Enum) gl.Shader {
+	return gl.CreateShader(ty)
+}
+
+func (TgeGL) CreateTexture() gl.Texture {
+	return gl.CreateTexture()
+}
+
+func (TgeGL) CreateVertexArray() gl.VertexArray {
+	return gl.CreateVertexArray()
+}
+
+func (TgeGL) CullFace(mode gl.Enum) {
+	gl.CullFace(mode)
+}
+
+func (TgeGL) DeleteBuffer(v gl.Buffer) {
+	gl.DeleteBuffer(v)
+}
+
+func (TgeGL) DeleteFramebuffer(v gl.Framebuffer) {
+	gl.DeleteFramebuffer(v)
+}
+
+func (TgeGL) DeleteProgram(p gl.Program) {
+	gl.DeleteProgram(p)
+}
+
+func (TgeGL) DeleteRenderbuffer(v gl.Renderbuffer) {
+	gl.DeleteRenderbuffer(v)
+}
+
+func (TgeGL) DeleteShader(s gl.Shader) {
+	gl.DeleteShader(s)
+}
+
+func (TgeGL) DeleteTexture(v gl.Texture) {
+	gl.DeleteTexture(v)
+}
+
+func (TgeGL) DeleteVertexArray(v gl.VertexArray) {
+	gl.DeleteVertexArray(v)
+}
+
+func (TgeGL) DepthFunc(fn gl.Enum) {
+	gl.DepthFunc(fn)
+}
+
+func (TgeGL) DepthMask(flag bool) {
+	gl.DepthMask(flag)
+}
+
+func (TgeGL) Disable(cap gl.Enum) {
+	gl.Disable(cap)
+}
+
+func (TgeGL) DrawArrays(mode gl.Enum, first, count int) {
+	gl.DrawArrays(mode, first, count)
+}
+
+func (TgeGL) DrawElements(mode gl.Enum, count int, ty gl.Enum, offset int) {
+	gl.DrawElements(mode, count, ty, offset)
+}
+
+func (TgeGL) Enable(cap gl.Enum) {
+	gl.Enable(cap)
+}
+
+func (TgeGL) EnableVertexAttribArray(a gl.Attrib) {
+	gl.EnableVertexAttribArray(a)
+}
+
+func (TgeGL) FramebufferRenderbuffer(target, attachment, rbTarget gl.Enum, rb gl.Renderbuffer) {
+	gl.FramebufferRenderbuffer(target, attachment, rbTarget, rb)
+}
+
+func (TgeGL) FramebufferTexture2D(target, attachment, texTarget gl.Enum, t gl.Texture, level int) {
+	gl.FramebufferTexture2D(target, attachment, texTarget, t, level)
+}
+
+func (TgeGL) FrontFace(mode gl.Enum) {
+	gl.FrontFace(mode)
+}
+
+func (TgeGL) GenerateMipmap(target gl.Enum) {
+	gl.GenerateMipmap(target)
+}
+
+func (TgeGL) GetAttribLocation(p gl.Program, name string) gl.Attrib {
+	return gl.GetAttribLocation(p, name)
+}
+
+func (TgeGL) GetInteger(pname gl.Enum) int {
+	return gl.GetInteger(pname)
+}
+
+func (TgeGL) GetProgramInfoLog(p gl.Program) string {
+	return gl.GetProgramInfoLog(p)
+}
+
+func (TgeGL) GetProgrami(p gl.Program, pname gl.Enum) int {
+	return gl.GetProgrami(p, pname)
+}
+
+func (TgeGL) GetShaderInfoLog(s gl.Shader) string {
+	return gl.GetShaderInfoLog(s)
+}
+
+func (TgeGL) GetShaderi(s gl.Shader, pname gl.Enum) int {
+	return gl.GetShaderi(s, pname)
+}
+
+func (TgeGL) GetString(pname gl.Enum) string {
+	return gl.GetString(pname)
+}
+
+func (TgeGL) GetUniformLocation(p gl.Program, name string) gl.Uniform {
+	return gl.GetUniformLocation(p, name)
+}
+
+func (TgeGL) IsEnabled(cap gl.Enum) bool {
+	return gl.IsEnabled(cap)
+}
+
+func (TgeGL) LineWidth(width float32) {
+	gl.LineWidth(width)
+}
+
+func (TgeGL) LinkProgram(p gl.Program) {
+	gl.LinkProgram(p)
+}
+
+func (TgeGL) PolygonMode(face, mode gl.Enum) {
+	gl.PolygonMode(face, mode)
+}
+
+func (TgeGL) PolygonOffset(factor, units float32) {
+	gl.PolygonOffset(factor, units)
+}
+
+func (TgeGL) RenderbufferStorage(target, internalFormat gl.Enum, width, height int) {
+	gl.RenderbufferStorage(target, internalFormat, width, height)
+}
+
+func (TgeGL) Scissor(x, y, width, height int32) {
+	gl.Scissor(x, y, width, height)
+}
+
+func (TgeGL) ShaderSource(s gl.Shader, src string) {
+	gl.ShaderSource(s, src)
+}
+
+func (TgeGL) StencilFunc(fn gl.Enum, ref int, mask uint32) {
+	gl.StencilFunc(fn, ref, mask)
+}
+
+func (TgeGL) StencilMask(mask uint32) {
+	gl.StencilMask(mask)
+}
+
+func (TgeGL) StencilOp(fail, zfail, zpass gl.Enum) {
+	gl.StencilOp(fail, zfail, zpass)
+}
+
+func (TgeGL) TexImage2D(target gl.Enum, level, width, height int, format, ty gl.Enum, data []byte) {
+	gl.TexImage2D(target, level, width, height, format, ty, data)
+}
+
+func (TgeGL) TexParameterf(target, pname gl.Enum, param float32) {
+	gl.TexParameterf(target, pname, param)
+}
+
+func (TgeGL) TexParameteri(target, pname gl.Enum, param int) {
+	gl.TexParameteri(target, pname, param)
+}
+
+func (TgeGL) Uniform1f(dst gl.Uniform, v float32) {
+	gl.Uniform1f(dst, v)
+}
+
+func (TgeGL) Uniform1fv(dst gl.Uniform, src []float32) {
+	gl.Uniform1fv(dst, src)
+}
+
+func (TgeGL) Uniform1i(dst gl.Uniform, v int) {
+	gl.Uniform1i(dst, v)
+}
+
+func (TgeGL) Uniform2f(dst gl.Uniform, v0, v1 float32) {
+	gl.Uniform2f(dst, v0, v1)
+}
+
+func (TgeGL) Uniform2fvP(dst gl.Uniform, count int32, value *float32) {
+	gl.Uniform2fvP(dst, count, value)
+}
+
+func (TgeGL) Uniform2fvUP(dst gl.Uniform, count int32, value unsafe.Pointer) {
+	gl.Uniform2fvUP(dst, count, value)
+}
+
+func (TgeGL) Uniform3f(dst gl.Uniform, v0, v1, v2 float32) {
+	gl.Uniform3f(dst, v0, v1, v2)
+}
+
+func (TgeGL) Uniform3fvP(dst gl.Uniform, count int32, value *float32) {
+	gl.Uniform3fvP(dst, count, value)
+}
+
+func (TgeGL) Uniform3fvUP(dst gl.Uniform, count int32, value unsafe.Pointer) {
+	gl.Uniform3fvUP(dst, count, value)
+}
+
+func (TgeGL) Uniform4f(dst gl.Uniform, v0, v1, v2, v3 float32) {
+	gl.Uniform4f(dst, v0, v1, v2, v3)
+}
+
+func (TgeGL) Uniform4fv(dst gl.Uniform, src []float32) {
+	gl.Uniform4fv(dst, src)
+}
+
+func (TgeGL) Uniform4fvUP(dst gl.Uniform, count int32, value unsafe.Pointer) {
+	gl.Uniform4fvUP(dst, count, value)
+}
+
+func (TgeGL) UniformMatrix3fvP(dst gl.Uniform, count int32, transpose bool, value *float32) {
+	gl.UniformMatrix3fvP(dst, count, transpose, value)
+}
+
+func (TgeGL) UniformMatrix4fvP(dst gl.Uniform, count int32, transpose bool, value *float32) {
+	gl.UniformMatrix4fvP(dst, count, transpose, value)
+}
+
+func (TgeGL) UseProgram(p gl.Program) {
+	gl.UseProgram(p)
+}
+
+func (TgeGL) VertexAttribPointer(dst gl.Attrib, size int, ty gl.Enum, normalized bool, stride, offset int) {
+	gl.VertexAttribPointer(dst, size, ty, normalized, stride, offset)
+}
+
+func (TgeGL) Viewport(x, y, width, height int) {
+	gl.Viewport(x, y, width, height)
+}
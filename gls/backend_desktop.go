@@ -0,0 +1,133 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build darwin freebsd linux windows
+// +build !android
+// +build !ios
+// +build !js
+
+package gls
+
+import (
+	"sync"
+	"unsafe"
+
+	gl33 "github.com/go-gl/gl/v3.3-core/gl"
+	gl43 "github.com/go-gl/gl/v4.3-core/gl"
+	gl "github.com/thommil/tge-gl"
+)
+
+// The OpenGL 3 functions below are not provided by tge-gl and are called with
+// the go-gl binding of OpenGL 3.3 core which tge-gl initializes on desktop.
+// MultiDrawElementsIndirect is called with the go-gl binding of OpenGL 4.3 core.
+
+// tgeGL3 is whether tge-gl provides the OpenGL 3 functions on this platform.
+const tgeGL3 = true
+
+var gl43Once sync.Once // loads the OpenGL 4.3 binding once
+var gl43Err error      // error loading the OpenGL 4.3 binding
+
+func (TgeGL) BeginTransformFeedback(mode gl.Enum) {
+	gl33.BeginTransformFeedback(uint32(mode))
+}
+
+func (TgeGL) BindBufferBase(target gl.Enum, index int, b gl.Buffer) {
+	gl33.BindBufferBase(uint32(target), uint32(index), uint32(b))
+}
+
+func (TgeGL) BlitFramebuffer(srcX0, srcY0, srcX1, srcY1, dstX0, dstY0, dstX1, dstY1 int, mask, filter gl.Enum) {
+	gl33.BlitFramebuffer(int32(srcX0), int32(srcY0), int32(srcX1), int32(srcY1), int32(dstX0), int32(dstY0), int32(dstX1), int32(dstY1), uint32(mask), uint32(filter))
+}
+
+func (TgeGL) DrawArraysInstanced(m gl.Enum, first, count, inst int) {
+	gl33.DrawArraysInstanced(uint32(m), int32(first), int32(count), int32(inst))
+}
+
+func (TgeGL) DrawBuffers(bufs []gl.Enum) {
+	if len(bufs) == 0 {
+		gl33.DrawBuffers(0, nil)
+		return
+	}
+	gl33.DrawBuffers(int32(len(bufs)), (*uint32)(unsafe.Pointer(&bufs[0])))
+}
+
+func (TgeGL) DrawElementsInstanced(m gl.Enum, count int, ty gl.Enum, off, inst int) {
+	gl33.DrawElementsInstanced(uint32(m), int32(count), uint32(ty), gl33.PtrOffset(off), int32(inst))
+}
+
+func (TgeGL) EndTransformFeedback() {
+	gl33.EndTransformFeedback()
+}
+
+func (TgeGL) FramebufferTextureLayer(target, attachment gl.Enum, t gl.Texture, level, layer int) {
+	gl33.FramebufferTextureLayer(uint32(target), uint32(attachment), uint32(t), int32(level), int32(layer))
+}
+
+func (TgeGL) GetBufferSubData(target gl.Enum, offset int, data []byte) {
+	gl33.GetBufferSubData(uint32(target), offset, len(data), bytesPtr(data))
+}
+
+func (TgeGL) GetFloat(pname gl.Enum) float32 {
+	var value float32
+	gl33.GetFloatv(uint32(pname), &value)
+	return value
+}
+
+func (TgeGL) MultiDrawElementsIndirect(m, ty gl.Enum, indirect, drawcount, stride int) {
+	// The OpenGL 4.3 binding is loaded at the first call, which
+	// GLS.MultiDrawIndirectSupported only allows with OpenGL 4.3 contexts
+	gl43Once.Do(func() { gl43Err = gl43.Init() })
+	if gl43Err != nil {
+		panic("TgeGL.MultiDrawElementsIndirect: " + gl43Err.Error())
+	}
+	gl43.MultiDrawElementsIndirect(uint32(m), uint32(ty), gl43.PtrOffset(indirect), int32(drawcount), int32(stride))
+}
+
+func (TgeGL) PrimitiveRestartIndex(index uint32) {
+	gl33.PrimitiveRestartIndex(index)
+}
+
+func (TgeGL) ReadBuffer(src gl.Enum) {
+	gl33.ReadBuffer(uint32(src))
+}
+
+func (TgeGL) RenderbufferStorageMultisample(target gl.Enum, samples int, internalFormat gl.Enum, width, height int) {
+	gl33.RenderbufferStorageMultisample(uint32(target), int32(samples), uint32(internalFormat), int32(width), int32(height))
+}
+
+func (TgeGL) TexImage3D(target gl.Enum, level int, iformat gl.Enum, width, height, depth int, format, ty gl.Enum, data []byte) {
+	gl33.TexImage3D(uint32(target), int32(level), int32(iformat), int32(width), int32(height), int32(depth), 0, uint32(format), uint32(ty), bytesPtr(data))
+}
+
+func (TgeGL) TransformFeedbackVaryings(p gl.Program, varyings []string, mode gl.Enum) {
+	if len(varyings) == 0 {
+		gl33.TransformFeedbackVaryings(uint32(p), 0, nil, uint32(mode))
+		return
+	}
+	// The C strings must be null terminated
+	cvaryings := make([]string, len(varyings))
+	for i, v := range varyings {
+		cvaryings[i] = v + "\x00"
+	}
+	cstrs, free := gl33.Strs(cvaryings...)
+	defer free()
+	gl33.TransformFeedbackVaryings(uint32(p), int32(len(varyings)), cstrs, uint32(mode))
+}
+
+func (TgeGL) VertexAttribDivisor(a gl.Attrib, d int) {
+	gl33.VertexAttribDivisor(uint32(a), uint32(d))
+}
+
+func (TgeGL) VertexAttribIPointer(a gl.Attrib, s int, t gl.Enum, st, o int) {
+	gl33.VertexAttribIPointer(uint32(a), int32(s), uint32(t), int32(st), gl33.PtrOffset(o))
+}
+
+// bytesPtr returns a pointer to the first byte of the specified data or nil if it is empty.
+func bytesPtr(data []byte) unsafe.Pointer {
+
+	if len(data) == 0 {
+		return nil
+	}
+	return gl33.Ptr(&data[0])
+}
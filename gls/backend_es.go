@@ -0,0 +1,91 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build android ios js
+
+package gls
+
+import (
+	gl "github.com/thommil/tge-gl"
+)
+
+// The OpenGL 3 functions below are not provided by tge-gl on OpenGL ES and WebGL.
+// GLS.GL3Supported returns false so that they are never called.
+
+// tgeGL3 is whether tge-gl provides the OpenGL 3 functions on this platform.
+const tgeGL3 = false
+
+func (TgeGL) BeginTransformFeedback(mode gl.Enum) {
+	panic("TgeGL.BeginTransformFeedback: not supported by tge-gl on this platform")
+}
+
+func (TgeGL) BindBufferBase(target gl.Enum, index int, b gl.Buffer) {
+	panic("TgeGL.BindBufferBase: not supported by tge-gl on this platform")
+}
+
+func (TgeGL) BlitFramebuffer(srcX0, srcY0, srcX1, srcY1, dstX0, dstY0, dstX1, dstY1 int, mask, filter gl.Enum) {
+	panic("TgeGL.BlitFramebuffer: not supported by tge-gl on this platform")
+}
+
+func (TgeGL) DrawArraysInstanced(m gl.Enum, first, count, inst int) {
+	panic("TgeGL.DrawArraysInstanced: not supported by tge-gl on this platform")
+}
+
+func (TgeGL) DrawBuffers(bufs []gl.Enum) {
+	panic("TgeGL.DrawBuffers: not supported by tge-gl on this platform")
+}
+
+func (TgeGL) DrawElementsInstanced(m gl.Enum, count int, ty gl.Enum, off, inst int) {
+	panic("TgeGL.DrawElementsInstanced: not supported by tge-gl on this platform")
+}
+
+func (TgeGL) EndTransformFeedback() {
+	panic("TgeGL.EndTransformFeedback: not supported by tge-gl on this platform")
+}
+
+func (TgeGL) FramebufferTextureLayer(target, attachment gl.Enum, t gl.Texture, level, layer int) {
+	panic("TgeGL.FramebufferTextureLayer: not supported by tge-gl on this platform")
+}
+
+func (TgeGL) GetBufferSubData(target gl.Enum, offset int, data []byte) {
+	panic("TgeGL.GetBufferSubData: not supported by tge-gl on this platform")
+}
+
+func (TgeGL) MultiDrawElementsIndirect(m, ty gl.Enum, indirect, drawcount, stride int) {
+	panic("TgeGL.MultiDrawElementsIndirect: not supported by tge-gl on this platform")
+}
+
+func (TgeGL) PrimitiveRestartIndex(index uint32) {
+	panic("TgeGL.PrimitiveRestartIndex: not supported by tge-gl on this platform")
+}
+
+func (TgeGL) ReadBuffer(src gl.Enum) {
+	panic("TgeGL.ReadBuffer: not supported by tge-gl on this platform")
+}
+
+func (TgeGL) RenderbufferStorageMultisample(target gl.Enum, samples int, internalFormat gl.Enum, width, height int) {
+	panic("TgeGL.RenderbufferStorageMultisample: not supported by tge-gl on this platform")
+}
+
+func (TgeGL) TexImage3D(target gl.Enum, level int, iformat gl.Enum, width, height, depth int, format, ty gl.Enum, data []byte) {
+	panic("TgeGL.TexImage3D: not supported by tge-gl on this platform")
+}
+
+func (TgeGL) TransformFeedbackVaryings(p gl.Program, varyings []string, mode gl.Enum) {
+	panic("TgeGL.TransformFeedbackVaryings: not supported by tge-gl on this platform")
+}
+
+func (TgeGL) VertexAttribDivisor(a gl.Attrib, d int) {
+	panic("TgeGL.VertexAttribDivisor: not supported by tge-gl on this platform")
+}
+
+func (TgeGL) VertexAttribIPointer(a gl.Attrib, s int, t gl.Enum, st, o int) {
+	panic("TgeGL.VertexAttribIPointer: not supported by tge-gl on this platform")
+}
+
+// GetFloat is not provided by tge-gl either: the float parameters are queried
+// as integers, which OpenGL ES and WebGL round to the nearest integer.
+func (TgeGL) GetFloat(pname gl.Enum) float32 {
+	return float32(gl.GetInteger(pname))
+}
@@ -0,0 +1,56 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+// BlendAlpha enables blending of the colors by their alpha over the destination colors.
+func (gs *GLS) BlendAlpha() {
+
+	gs.setBlend(SRC_ALPHA, ONE_MINUS_SRC_ALPHA)
+}
+
+// BlendAdditive enables blending adding the colors weighted by their alpha
+// to the destination colors, such as for glowing particles.
+func (gs *GLS) BlendAdditive() {
+
+	gs.setBlend(SRC_ALPHA, ONE)
+}
+
+// BlendPremultiplied enables blending of colors premultiplied by their alpha
+// over the destination colors, such as for textures with premultiplied alpha.
+func (gs *GLS) BlendPremultiplied() {
+
+	gs.setBlend(ONE, ONE_MINUS_SRC_ALPHA)
+}
+
+// BlendMultiply enables blending multiplying the destination colors by the colors.
+func (gs *GLS) BlendMultiply() {
+
+	gs.setBlend(ZERO, SRC_COLOR)
+}
+
+// BlendSubtractive enables blending multiplying the destination colors
+// by one minus the colors.
+func (gs *GLS) BlendSubtractive() {
+
+	gs.setBlend(ZERO, ONE_MINUS_SRC_COLOR)
+}
+
+// BlendAccumulate enables blending adding the colors to the destination colors and
+// multiplying the destination alpha by one minus the alpha, such as for the weighted
+// blended order independent transparency.
+func (gs *GLS) BlendAccumulate() {
+
+	gs.Enable(BLEND)
+	gs.BlendEquation(FUNC_ADD)
+	gs.BlendFuncSeparate(ONE, ONE, ZERO, ONE_MINUS_SRC_ALPHA)
+}
+
+// setBlend enables blending with the add equation and the specified factors.
+func (gs *GLS) setBlend(sfactor, dfactor uint32) {
+
+	gs.Enable(BLEND)
+	gs.BlendEquation(FUNC_ADD)
+	gs.BlendFunc(sfactor, dfactor)
+}
@@ -0,0 +1,200 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+import (
+	gl "github.com/thommil/tge-gl"
+)
+
+// capture is a GL backend which forwards the calls to another backend
+// and records the state changes and the draw calls.
+type capture struct {
+	GL           // Backend performing the calls
+	calls []Call // Recorded calls
+}
+
+// BeginCapture starts recording the state changes and the draw calls submitted to the
+// OpenGL backend, which still performs them, until EndCapture. The uniforms and the
+// buffers data are not recorded. It is a debugging aid to analyze a frame without an
+// external OpenGL debugger.
+func (gs *GLS) BeginCapture() {
+
+	if _, ok := gs.backend.(*capture); !ok {
+		gs.backend = &capture{GL: gs.backend}
+	}
+}
+
+// EndCapture stops the capture started by BeginCapture and returns the recorded calls
+// in submission order or nil if there was no capture. The calls can't be replayed.
+func (gs *GLS) EndCapture() []Call {
+
+	c, ok := gs.backend.(*capture)
+	if !ok {
+		return nil
+	}
+	gs.backend = c.GL
+	return c.calls
+}
+
+// record appends a call of the specified function.
+func (c *capture) record(name string, args ...interface{}) {
+
+	c.calls = append(c.calls, Call{Name: name, Args: args})
+}
+
+func (c *capture) BindFramebuffer(target gl.Enum, fb gl.Framebuffer) {
+	c.record("BindFramebuffer", target, fb)
+	c.GL.BindFramebuffer(target, fb)
+}
+
+func (c *capture) BindTexture(target gl.Enum, t gl.Texture) {
+	c.record("BindTexture", target, t)
+	c.GL.BindTexture(target, t)
+}
+
+func (c *capture) BindVertexArray(vao gl.VertexArray) {
+	c.record("BindVertexArray", vao)
+	c.GL.BindVertexArray(vao)
+}
+
+func (c *capture) BlendEquation(mode gl.Enum) {
+	c.record("BlendEquation", mode)
+	c.GL.BlendEquation(mode)
+}
+
+func (c *capture) BlendEquationSeparate(modeRGB, modeAlpha gl.Enum) {
+	c.record("BlendEquationSeparate", modeRGB, modeAlpha)
+	c.GL.BlendEquationSeparate(modeRGB, modeAlpha)
+}
+
+func (c *capture) BlendFunc(sfactor, dfactor gl.Enum) {
+	c.record("BlendFunc", sfactor, dfactor)
+	c.GL.BlendFunc(sfactor, dfactor)
+}
+
+func (c *capture) BlendFuncSeparate(sfactorRGB, dfactorRGB, sfactorAlpha, dfactorAlpha gl.Enum) {
+	c.record("BlendFuncSeparate", sfactorRGB, dfactorRGB, sfactorAlpha, dfactorAlpha)
+	c.GL.BlendFuncSeparate(sfactorRGB, dfactorRGB, sfactorAlpha, dfactorAlpha)
+}
+
+func (c *capture) BlitFramebuffer(srcX0, srcY0, srcX1, srcY1, dstX0, dstY0, dstX1, dstY1 int, mask, filter gl.Enum) {
+	c.record("BlitFramebuffer", srcX0, srcY0, srcX1, srcY1, dstX0, dstY0, dstX1, dstY1, mask, filter)
+	c.GL.BlitFramebuffer(srcX0, srcY0, srcX1, srcY1, dstX0, dstY0, dstX1, dstY1, mask, filter)
+}
+
+func (c *capture) Clear(mask gl.Enum) {
+	c.record("Clear", mask)
+	c.GL.Clear(mask)
+}
+
+func (c *capture) ClearColor(red, green, blue, alpha float32) {
+	c.record("ClearColor", red, green, blue, alpha)
+	c.GL.ClearColor(red, green, blue, alpha)
+}
+
+func (c *capture) CullFace(mode gl.Enum) {
+	c.record("CullFace", mode)
+	c.GL.CullFace(mode)
+}
+
+func (c *capture) DepthFunc(fn gl.Enum) {
+	c.record("DepthFunc", fn)
+	c.GL.DepthFunc(fn)
+}
+
+func (c *capture) DepthMask(flag bool) {
+	c.record("DepthMask", flag)
+	c.GL.DepthMask(flag)
+}
+
+func (c *capture) Disable(cap gl.Enum) {
+	c.record("Disable", cap)
+	c.GL.Disable(cap)
+}
+
+func (c *capture) DrawArrays(mode gl.Enum, first, count int) {
+	c.record("DrawArrays", mode, first, count)
+	c.GL.DrawArrays(mode, first, count)
+}
+
+func (c *capture) DrawArraysInstanced(m gl.Enum, first, count, inst int) {
+	c.record("DrawArraysInstanced", m, first, count, inst)
+	c.GL.DrawArraysInstanced(m, first, count, inst)
+}
+
+func (c *capture) DrawBuffers(bufs []gl.Enum) {
+	c.record("DrawBuffers", bufs)
+	c.GL.DrawBuffers(bufs)
+}
+
+func (c *capture) DrawElements(mode gl.Enum, count int, ty gl.Enum, offset int) {
+	c.record("DrawElements", mode, count, ty, offset)
+	c.GL.DrawElements(mode, count, ty, offset)
+}
+
+func (c *capture) DrawElementsInstanced(m gl.Enum, count int, ty gl.Enum, off, inst int) {
+	c.record("DrawElementsInstanced", m, count, ty, off, inst)
+	c.GL.DrawElementsInstanced(m, count, ty, off, inst)
+}
+
+func (c *capture) Enable(cap gl.Enum) {
+	c.record("Enable", cap)
+	c.GL.Enable(cap)
+}
+
+func (c *capture) FrontFace(mode gl.Enum) {
+	c.record("FrontFace", mode)
+	c.GL.FrontFace(mode)
+}
+
+func (c *capture) LineWidth(width float32) {
+	c.record("LineWidth", width)
+	c.GL.LineWidth(width)
+}
+
+func (c *capture) MultiDrawElementsIndirect(m, ty gl.Enum, indirect, drawcount, stride int) {
+	c.record("MultiDrawElementsIndirect", m, ty, indirect, drawcount, stride)
+	c.GL.MultiDrawElementsIndirect(m, ty, indirect, drawcount, stride)
+}
+
+func (c *capture) PolygonMode(face, mode gl.Enum) {
+	c.record("PolygonMode", face, mode)
+	c.GL.PolygonMode(face, mode)
+}
+
+func (c *capture) PolygonOffset(factor, units float32) {
+	c.record("PolygonOffset", factor, units)
+	c.GL.PolygonOffset(factor, units)
+}
+
+func (c *capture) Scissor(x, y, width, height int32) {
+	c.record("Scissor", x, y, width, height)
+	c.GL.Scissor(x, y, width, height)
+}
+
+func (c *capture) StencilFunc(fn gl.Enum, ref int, mask uint32) {
+	c.record("StencilFunc", fn, ref, mask)
+	c.GL.StencilFunc(fn, ref, mask)
+}
+
+func (c *capture) StencilMask(mask uint32) {
+	c.record("StencilMask", mask)
+	c.GL.StencilMask(mask)
+}
+
+func (c *capture) StencilOp(fail, zfail, zpass gl.Enum) {
+	c.record("StencilOp", fail, zfail, zpass)
+	c.GL.StencilOp(fail, zfail, zpass)
+}
+
+func (c *capture) UseProgram(p gl.Program) {
+	c.record("UseProgram", p)
+	c.GL.UseProgram(p)
+}
+
+func (c *capture) Viewport(x, y, width, height int) {
+	c.record("Viewport", x, y, width, height)
+	c.GL.Viewport(x, y, width, height)
+}
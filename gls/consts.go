@@ -401,6 +401,7 @@ const (
 	VERTEX_SHADER                                 = 0x8B31
 	MAX_FRAGMENT_UNIFORM_COMPONENTS               = 0x8B49
 	MAX_VERTEX_UNIFORM_COMPONENTS                 = 0x8B4A
+	MAX_VERTEX_UNIFORM_VECTORS                    = 0x8DFB
 	MAX_VARYING_FLOATS                            = 0x8B4B
 	MAX_VERTEX_TEXTURE_IMAGE_UNITS                = 0x8B4C
 	MAX_COMBINED_TEXTURE_IMAGE_UNITS              = 0x8B4D
@@ -720,6 +721,7 @@ const (
 	SIGNED_NORMALIZED                             = 0x8F9C
 	PRIMITIVE_RESTART                             = 0x8F9D
 	PRIMITIVE_RESTART_INDEX                       = 0x8F9E
+	PRIMITIVE_RESTART_FIXED_INDEX                 = 0x8D69
 	COPY_READ_BUFFER                              = 0x8F36
 	COPY_WRITE_BUFFER                             = 0x8F37
 	UNIFORM_BUFFER                                = 0x8A11
@@ -837,4 +839,11 @@ const (
 	TIME_ELAPSED                                  = 0x88BF
 	TIMESTAMP                                     = 0x8E28
 	INT_2_10_10_10_REV                            = 0x8D9F
+	DRAW_INDIRECT_BUFFER                          = 0x8F3F
+	DRAW_INDIRECT_BUFFER_BINDING                  = 0x8F43
+	COMPRESSED_RGB_S3TC_DXT1_EXT                  = 0x83F0
+	COMPRESSED_RGBA_S3TC_DXT1_EXT                 = 0x83F1
+	COMPRESSED_RGBA_S3TC_DXT5_EXT                 = 0x83F3
+	TEXTURE_MAX_ANISOTROPY_EXT                    = 0x84FE
+	MAX_TEXTURE_MAX_ANISOTROPY_EXT                = 0x84FF
 )
@@ -0,0 +1,10 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build debug
+
+package gls
+
+// debugBuild is true in debug builds, built with the debug tag.
+const debugBuild = true
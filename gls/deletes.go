@@ -0,0 +1,152 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+import (
+	"sync"
+)
+
+// pendingDeletes is the queue of the OpenGL objects to delete on the render thread.
+type pendingDeletes struct {
+	mu            sync.Mutex
+	enabled       bool     // whether the Delete methods queue the objects
+	buffers       []uint32 // queued buffer objects
+	framebuffers  []uint32 // queued framebuffer objects
+	renderbuffers []uint32 // queued renderbuffer objects
+	textures      []uint32 // queued textures
+	vertexArrays  []uint32 // queued vertex array objects
+	programs      []uint32 // queued shader programs
+	textureBytes  int      // released texture bytes not yet removed from the statistics
+}
+
+// SetDeferDeletes sets whether DeleteBuffers, DeleteFramebuffers, DeleteRenderbuffers,
+// DeleteTextures and DeleteVertexArrays queue the objects as the QueueDelete methods
+// instead of deleting them, so that the geometries, textures and render targets can be
+// disposed from any goroutine, such as background loaders, game logic or finalizers.
+// ProcessPendingDeletes must then be called on the render thread once per frame,
+// which Renderer.Render does before rendering.
+//
+// The threading contract of the GLS is otherwise unchanged: all its other methods,
+// and the first render setup of the objects, must be called on the render thread.
+// The streamed textures must be disposed on the render thread.
+func (gs *GLS) SetDeferDeletes(state bool) {
+
+	gs.pending.mu.Lock()
+	gs.pending.enabled = state
+	gs.pending.mu.Unlock()
+}
+
+// DeferDeletes returns whether the Delete methods queue the objects.
+func (gs *GLS) DeferDeletes() bool {
+
+	gs.pending.mu.Lock()
+	defer gs.pending.mu.Unlock()
+	return gs.pending.enabled
+}
+
+// QueueDeleteBuffers queues the specified buffer objects for deletion by ProcessPendingDeletes.
+// It can be called from any goroutine.
+func (gs *GLS) QueueDeleteBuffers(bufs ...uint32) {
+
+	gs.pending.mu.Lock()
+	gs.pending.buffers = append(gs.pending.buffers, bufs...)
+	gs.pending.mu.Unlock()
+}
+
+// QueueDeleteFramebuffers queues the specified framebuffer objects for deletion by
+// ProcessPendingDeletes. It can be called from any goroutine.
+func (gs *GLS) QueueDeleteFramebuffers(fbos ...uint32) {
+
+	gs.pending.mu.Lock()
+	gs.pending.framebuffers = append(gs.pending.framebuffers, fbos...)
+	gs.pending.mu.Unlock()
+}
+
+// QueueDeleteRenderbuffers queues the specified renderbuffer objects for deletion by
+// ProcessPendingDeletes. It can be called from any goroutine.
+func (gs *GLS) QueueDeleteRenderbuffers(rbos ...uint32) {
+
+	gs.pending.mu.Lock()
+	gs.pending.renderbuffers = append(gs.pending.renderbuffers, rbos...)
+	gs.pending.mu.Unlock()
+}
+
+// QueueDeleteTextures queues the specified textures for deletion by ProcessPendingDeletes.
+// It can be called from any goroutine.
+func (gs *GLS) QueueDeleteTextures(texs ...uint32) {
+
+	gs.pending.mu.Lock()
+	gs.pending.textures = append(gs.pending.textures, texs...)
+	gs.pending.mu.Unlock()
+}
+
+// QueueDeleteVertexArrays queues the specified vertex array objects for deletion by
+// ProcessPendingDeletes. It can be called from any goroutine.
+func (gs *GLS) QueueDeleteVertexArrays(vaos ...uint32) {
+
+	gs.pending.mu.Lock()
+	gs.pending.vertexArrays = append(gs.pending.vertexArrays, vaos...)
+	gs.pending.mu.Unlock()
+}
+
+// QueueDeletePrograms queues the specified shader programs for deletion by
+// ProcessPendingDeletes. It can be called from any goroutine.
+func (gs *GLS) QueueDeletePrograms(programs ...uint32) {
+
+	gs.pending.mu.Lock()
+	gs.pending.programs = append(gs.pending.programs, programs...)
+	gs.pending.mu.Unlock()
+}
+
+// PendingDeletes returns the number of queued objects not yet deleted.
+func (gs *GLS) PendingDeletes() int {
+
+	p := &gs.pending
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.buffers) + len(p.framebuffers) + len(p.renderbuffers) + len(p.textures) +
+		len(p.vertexArrays) + len(p.programs)
+}
+
+// ProcessPendingDeletes deletes the queued objects and returns their number.
+// It must be called on the render thread, at a point of the frame where the
+// objects are not used anymore, such as before rendering.
+func (gs *GLS) ProcessPendingDeletes() int {
+
+	p := &gs.pending
+	p.mu.Lock()
+	buffers, framebuffers, renderbuffers := p.buffers, p.framebuffers, p.renderbuffers
+	textures, vertexArrays, programs := p.textures, p.vertexArrays, p.programs
+	textureBytes := p.textureBytes
+	p.buffers, p.framebuffers, p.renderbuffers = nil, nil, nil
+	p.textures, p.vertexArrays, p.programs = nil, nil, nil
+	p.textureBytes = 0
+	p.mu.Unlock()
+
+	gs.stats.TextureBytes += textureBytes
+	gs.deleteBuffers(buffers)
+	gs.deleteFramebuffers(framebuffers)
+	gs.deleteRenderbuffers(renderbuffers)
+	gs.deleteTextures(textures)
+	gs.deleteVertexArrays(vertexArrays)
+	for _, program := range programs {
+		gs.DeleteProgram(program)
+	}
+	return len(buffers) + len(framebuffers) + len(renderbuffers) + len(textures) +
+		len(vertexArrays) + len(programs)
+}
+
+// deferDelete queues the specified objects in the specified queue if
+// the deletes are deferred and returns whether they were queued.
+func (gs *GLS) deferDelete(queue *[]uint32, objs []uint32) bool {
+
+	gs.pending.mu.Lock()
+	defer gs.pending.mu.Unlock()
+	if !gs.pending.enabled {
+		return false
+	}
+	*queue = append(*queue, objs...)
+	return true
+}
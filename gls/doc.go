@@ -10,4 +10,9 @@
 //
 // This package also contains abstractions for some OpenGL object such as Program,
 // Uniform, VBO and others.
+//
+// The OpenGL calls, and so all the GLS methods, must be made on the render thread,
+// except the QueueDelete methods, which queue objects for deletion on the render thread
+// by ProcessPendingDeletes. With SetDeferDeletes, the Delete methods also queue the objects,
+// so that the engine objects can be disposed from other goroutines.
 package gls
@@ -6,6 +6,7 @@ package gls
 
 import (
 	"math"
+	"strings"
 	"unsafe"
 
 	"github.com/thommil/tge-g3n/math32"
@@ -15,6 +16,8 @@ import (
 // GLS encapsulates the state of an OpenGL context and contains
 // methods to call OpenGL functions.
 type GLS struct {
+	backend             GL                // OpenGL functions backend
+	gl3                 bool              // backend provides the OpenGL 3 functions
 	stats               Stats             // statistics
 	prog                *Program          // current active shader program
 	programs            map[*Program]bool // shader programs cache
@@ -43,6 +46,24 @@ type GLS struct {
 	polygonModeMode     uint32            // cached last set polygon mode mode
 	polygonOffsetFactor float32           // cached last set polygon offset factor
 	polygonOffsetUnits  float32           // cached last set polygon offset units
+	framebuffer         uint32            // cached last bound framebuffer
+	multisample         int               // cached multisample state of the bound framebuffer
+	clearColor          [4]float32        // last set clear color
+	stencilFunc         uint32            // cached last set stencil function
+	stencilRef          int32             // cached last set stencil reference value
+	stencilFuncMask     uint32            // cached last set stencil function mask
+	stencilOps          [3]uint32         // cached last set stencil operations
+	stencilMask         uint32            // cached last set stencil write mask
+	caps                *Capabilities     // limits of the OpenGL implementation or nil if not queried
+	nextTextureUnit     int               // next free texture unit of the current draw
+	allocs              map[uint64]string // allocation stacks of the live resources if tracked
+	multiDrawIndirect   int               // multi draw indirect support (capUndef, capDisabled or capEnabled)
+	states              []stateBlock      // states saved by PushState
+	restartIndex        int64             // cached last set primitive restart index or -1
+	restartMode         int               // primitive restart mode or 0 if not queried
+	transformFeedback   int               // transform feedback support (capUndef, capDisabled or capEnabled)
+	s3tc                int               // S3TC compressed textures support (capUndef, capDisabled or capEnabled)
+	pending             pendingDeletes    // objects queued for deletion on the render thread
 	// gobuf               []byte            // conversion buffer with GO memory
 	// cbuf                []byte            // conversion buffer with C memory
 }
@@ -50,15 +71,30 @@ type GLS struct {
 // Stats contains counters of OpenGL resources being used as well
 // the cumulative numbers of some OpenGL calls for performance evaluation.
 type Stats struct {
-	Shaders    int    // Current number of shader programs
-	Vaos       int    // Number of Vertex Array Objects
-	Buffers    int    // Number of Buffer Objects
-	Textures   int    // Number of Textures
-	Caphits    uint64 // Cumulative number of hits for Enable/Disable
-	UnilocHits uint64 // Cumulative number of uniform location cache hits
-	UnilocMiss uint64 // Cumulative number of uniform location cache misses
-	Unisets    uint64 // Cumulative number of uniform sets
-	Drawcalls  uint64 // Cumulative number of draw calls
+	Shaders       int    // Current number of shader programs
+	Vaos          int    // Number of Vertex Array Objects
+	Buffers       int    // Number of Buffer Objects
+	Textures      int    // Number of Textures
+	TextureBytes  int    // Estimated memory of the 2D textures data in bytes
+	Fbos          int    // Number of Framebuffer Objects
+	Caphits       uint64 // Cumulative number of hits for Enable/Disable
+	UnilocHits    uint64 // Cumulative number of uniform location cache hits
+	UnilocMiss    uint64 // Cumulative number of uniform location cache misses
+	Unisets       uint64 // Cumulative number of uniform sets
+	Drawcalls     uint64 // Cumulative number of draw calls
+	IndirectDraws uint64 // Cumulative number of draws submitted by indirect draw calls
+	Triangles     uint64 // Cumulative number of triangles drawn, except by indirect draw calls
+	Vertices      uint64 // Cumulative number of vertices drawn, except by indirect draw calls
+}
+
+// Capabilities contains the limits of the OpenGL implementation. Features
+// requesting more than these limits should be clamped or fall back.
+type Capabilities struct {
+	MaxAnisotropy           float32 // Maximum texture filtering anisotropy or 0 if not supported
+	MaxTextureUnits         int     // Maximum number of texture image units of the fragment shader
+	MaxVertexUniformVectors int     // Maximum number of vec4 uniforms of the vertex shader
+	MaxSamples              int     // Maximum number of samples of the multisampled renderbuffers or 0 if not supported
+	MaxTextureSize          int     // Maximum width and height of the 2D textures in texels
 }
 
 // Polygon side view.
@@ -72,6 +108,10 @@ const (
 	FloatSize = int32(unsafe.Sizeof(float32(0)))
 )
 
+// ReservedTextureUnits is the number of the last texture units which are not allocated
+// to the materials by AllocTextureUnit but reserved for the renderer.
+const ReservedTextureUnits = 2
+
 const (
 	capUndef    = 0
 	capDisabled = 1
@@ -87,10 +127,22 @@ const (
 // is established, such as by creating a new window.
 func New() (*GLS, error) {
 
+	return NewWithBackend(TgeGL{})
+}
+
+// NewWithBackend creates and returns a new instance of a GLS object
+// calling the OpenGL functions of the specified backend, such as a
+// Recorder to test the OpenGL calls without an OpenGL context.
+func NewWithBackend(backend GL) (*GLS, error) {
+
 	gs := new(GLS)
+	gs.backend = backend
+	_, tge := backend.(TgeGL)
+	gs.gl3 = !tge || tgeGL3
 	gs.reset()
 	gs.setDefaultState()
 	gs.checkErrors = true
+	gs.SetTrackAllocations(debugBuild)
 	return gs, nil
 }
 
@@ -120,6 +172,7 @@ func (gs *GLS) reset() {
 	gs.prog = nil
 
 	gs.activeTexture = uintUndef
+	gs.nextTextureUnit = 0
 	gs.blendEquation = uintUndef
 	gs.blendSrc = uintUndef
 	gs.blendDst = uintUndef
@@ -133,6 +186,12 @@ func (gs *GLS) reset() {
 	gs.polygonModeMode = 0
 	gs.polygonOffsetFactor = -1
 	gs.polygonOffsetUnits = -1
+	gs.multisample = uintUndef
+	gs.stencilFunc = uintUndef
+	gs.stencilOps = [3]uint32{uintUndef, uintUndef, uintUndef}
+	gs.stencilMask = uintUndef
+	gs.restartIndex = -1
+	gs.caps = nil
 }
 
 // setDefaultState is used internally to set the initial state of OpenGL
@@ -142,17 +201,17 @@ func (gs *GLS) setDefaultState() {
 	gs.Enable(DEPTH_TEST)
 	gs.DepthFunc(LEQUAL)
 	gs.FrontFace(CCW)
-	gs.CullFace(BACK)
-	gs.Enable(CULL_FACE)
+	gs.SetSideView(FrontSide)
 	gs.Enable(BLEND)
 	gs.BlendEquation(FUNC_ADD)
 	gs.BlendFunc(SRC_ALPHA, ONE_MINUS_SRC_ALPHA)
 	gs.Enable(VERTEX_PROGRAM_POINT_SIZE)
 	gs.Enable(PROGRAM_POINT_SIZE)
 	gs.Enable(MULTISAMPLE)
-	gs.Enable(POLYGON_OFFSET_FILL)
-	gs.Enable(POLYGON_OFFSET_LINE)
-	gs.Enable(POLYGON_OFFSET_POINT)
+	gs.Disable(POLYGON_OFFSET_FILL) // Enabled only by the materials with a polygon offset
+	gs.Disable(POLYGON_OFFSET_LINE)
+	gs.Disable(POLYGON_OFFSET_POINT)
+	gs.framebuffer = uint32(gs.GetInteger(FRAMEBUFFER_BINDING))
 }
 
 // Stats copy the current values of the internal statistics structure
@@ -171,28 +230,166 @@ func (gs *GLS) ActiveTexture(texture uint32) {
 	if gs.activeTexture == texture {
 		return
 	}
-	gl.ActiveTexture(gl.Enum(texture))
+	gs.backend.ActiveTexture(gl.Enum(texture))
 	gs.activeTexture = texture
 }
 
+// Capabilities returns the limits of the OpenGL implementation, queried once.
+func (gs *GLS) Capabilities() Capabilities {
+
+	if gs.caps == nil {
+		caps := new(Capabilities)
+		if gs.anisotropySupported() {
+			caps.MaxAnisotropy = gs.GetFloat(MAX_TEXTURE_MAX_ANISOTROPY_EXT)
+		}
+		caps.MaxTextureUnits = int(gs.GetInteger(MAX_TEXTURE_IMAGE_UNITS))
+		caps.MaxVertexUniformVectors = int(gs.GetInteger(MAX_VERTEX_UNIFORM_VECTORS))
+		caps.MaxSamples = int(gs.GetInteger(MAX_SAMPLES))
+		caps.MaxTextureSize = int(gs.GetInteger(MAX_TEXTURE_SIZE))
+
+		// Unsupported features and failed queries
+		if caps.MaxAnisotropy < 0 {
+			caps.MaxAnisotropy = 0
+		}
+		// Multisampled renderbuffers need RenderbufferStorageMultisample and BlitFramebuffer
+		if caps.MaxSamples < 0 || !gs.gl3 {
+			caps.MaxSamples = 0
+		}
+		// MAX_VERTEX_UNIFORM_VECTORS is not available before OpenGL 4.1 core
+		if caps.MaxVertexUniformVectors <= 0 {
+			caps.MaxVertexUniformVectors = int(gs.GetInteger(MAX_VERTEX_UNIFORM_COMPONENTS)) / 4
+		}
+		// Minimum limits required by OpenGL ES 3
+		if caps.MaxTextureUnits <= 0 {
+			caps.MaxTextureUnits = 16
+		}
+		if caps.MaxVertexUniformVectors <= 0 {
+			caps.MaxVertexUniformVectors = 256
+		}
+		if caps.MaxTextureSize <= 0 {
+			caps.MaxTextureSize = 2048
+		}
+		gs.caps = caps
+	}
+	return *gs.caps
+}
+
+// MaxTextureUnits returns the maximum number of texture image units
+// which can be used by the fragment shader.
+func (gs *GLS) MaxTextureUnits() int {
+
+	return gs.Capabilities().MaxTextureUnits
+}
+
+// MaxSamples returns the maximum number of samples of the multisampled renderbuffers,
+// queried once, or 0 if multisampled renderbuffers are not supported.
+func (gs *GLS) MaxSamples() int {
+
+	return gs.Capabilities().MaxSamples
+}
+
+// MaxAnisotropy returns the maximum anisotropy of the texture filtering, queried once,
+// or 0 if anisotropic filtering (EXT_texture_filter_anisotropic) is not supported.
+func (gs *GLS) MaxAnisotropy() float32 {
+
+	return gs.Capabilities().MaxAnisotropy
+}
+
+// ResetTextureUnits frees all the texture units allocated by AllocTextureUnit.
+// It is called by the materials before setting up their textures for a draw.
+func (gs *GLS) ResetTextureUnits() {
+
+	gs.nextTextureUnit = 0
+}
+
+// AllocTextureUnit returns the next free texture unit for the current draw,
+// so that each sampler uniform gets its own unit, or -1 if all the texture units
+// but the ReservedTextureUnits are used. The units are allocated in order, so the
+// same sequence of allocations gets the same units at each draw.
+func (gs *GLS) AllocTextureUnit() int {
+
+	if gs.nextTextureUnit >= gs.MaxAllocTextureUnits() {
+		return -1
+	}
+	unit := gs.nextTextureUnit
+	gs.nextTextureUnit++
+	return unit
+}
+
+// MaxAllocTextureUnits returns the number of texture units which can be allocated
+// by AllocTextureUnit, which are all the texture units but the ReservedTextureUnits.
+func (gs *GLS) MaxAllocTextureUnits() int {
+
+	return gs.MaxTextureUnits() - ReservedTextureUnits
+}
+
+// ReservedTextureUnit returns the reserved texture unit of the specified slot,
+// from 0 to ReservedTextureUnits-1, which is never allocated by AllocTextureUnit.
+func (gs *GLS) ReservedTextureUnit(slot int) int {
+
+	if slot < 0 || slot >= ReservedTextureUnits {
+		panic("ReservedTextureUnit: invalid slot")
+	}
+	return gs.MaxAllocTextureUnits() + slot
+}
+
 // AttachShader attaches the specified shader object to the specified program object.
 func (gs *GLS) AttachShader(program, shader uint32) {
-	gl.AttachShader(gl.Program(program), gl.Shader(shader))
+	gs.backend.AttachShader(gl.Program(program), gl.Shader(shader))
+}
+
+// BeginTransformFeedback starts capturing the outputs of the vertex shader of the primitives
+// of the specified mode (POINTS, LINES or TRIANGLES) to the buffers bound to TRANSFORM_FEEDBACK_BUFFER.
+func (gs *GLS) BeginTransformFeedback(mode uint32) {
+	gs.backend.BeginTransformFeedback(gl.Enum(mode))
 }
 
 // BindBuffer binds a buffer object to the specified buffer binding point.
 func (gs *GLS) BindBuffer(target int, vbo uint32) {
-	gl.BindBuffer(gl.Enum(target), gl.Buffer(vbo))
+	gs.backend.BindBuffer(gl.Enum(target), gl.Buffer(vbo))
+}
+
+// BindBufferBase binds a buffer object to the specified index of an indexed binding point
+// such as TRANSFORM_FEEDBACK_BUFFER or UNIFORM_BUFFER.
+func (gs *GLS) BindBufferBase(target int, index uint32, buffer uint32) {
+	gs.backend.BindBufferBase(gl.Enum(target), int(index), gl.Buffer(buffer))
+}
+
+// BlitFramebuffer copies the specified rectangle of the buffers of the mask (COLOR_BUFFER_BIT | DEPTH_BUFFER_BIT ...)
+// from the src framebuffer to the specified rectangle of the dst framebuffer, resolving the samples
+// of multisampled buffers. The framebuffer bound with BindFramebuffer is bound again afterwards.
+func (gs *GLS) BlitFramebuffer(src, dst uint32, srcX0, srcY0, srcX1, srcY1, dstX0, dstY0, dstX1, dstY1 int32, mask uint, filter uint32) {
+
+	gs.backend.BindFramebuffer(gl.Enum(READ_FRAMEBUFFER), gl.Framebuffer(src))
+	gs.backend.BindFramebuffer(gl.Enum(DRAW_FRAMEBUFFER), gl.Framebuffer(dst))
+	gs.backend.BlitFramebuffer(int(srcX0), int(srcY0), int(srcX1), int(srcY1), int(dstX0), int(dstY0), int(dstX1), int(dstY1), gl.Enum(mask), gl.Enum(filter))
+	gs.backend.BindFramebuffer(gl.Enum(FRAMEBUFFER), gl.Framebuffer(gs.framebuffer))
+}
+
+// BindFramebuffer binds the specified framebuffer object to the FRAMEBUFFER target.
+func (gs *GLS) BindFramebuffer(fbo uint32) {
+
+	if gs.framebuffer == fbo {
+		return
+	}
+	gs.backend.BindFramebuffer(gl.Enum(FRAMEBUFFER), gl.Framebuffer(fbo))
+	gs.framebuffer = fbo
+	gs.multisample = uintUndef
+}
+
+// BindRenderbuffer binds the specified renderbuffer object to the RENDERBUFFER target.
+func (gs *GLS) BindRenderbuffer(rbo uint32) {
+	gs.backend.BindRenderbuffer(gl.Enum(RENDERBUFFER), gl.Renderbuffer(rbo))
 }
 
 // BindTexture lets you create or use a named texture.
 func (gs *GLS) BindTexture(target int, tex uint32) {
-	gl.BindTexture(gl.Enum(target), gl.Texture(tex))
+	gs.backend.BindTexture(gl.Enum(target), gl.Texture(tex))
 }
 
 // BindVertexArray binds the vertex array object.
 func (gs *GLS) BindVertexArray(vao uint32) {
-	gl.BindVertexArray(gl.VertexArray(vao))
+	gs.backend.BindVertexArray(gl.VertexArray(vao))
 }
 
 // BlendEquation sets the blend equations for all draw buffers.
@@ -201,8 +398,10 @@ func (gs *GLS) BlendEquation(mode uint32) {
 	if gs.blendEquation == mode {
 		return
 	}
-	gl.BlendEquation(gl.Enum(mode))
+	gs.backend.BlendEquation(gl.Enum(mode))
 	gs.blendEquation = mode
+	gs.blendEquationRGB = uintUndef
+	gs.blendEquationAlpha = uintUndef
 }
 
 // BlendEquationSeparate sets the blend equations for all draw buffers
@@ -211,9 +410,10 @@ func (gs *GLS) BlendEquationSeparate(modeRGB uint32, modeAlpha uint32) {
 	if gs.blendEquationRGB == modeRGB && gs.blendEquationAlpha == modeAlpha {
 		return
 	}
-	gl.BlendEquationSeparate(gl.Enum(modeRGB), gl.Enum(modeAlpha))
+	gs.backend.BlendEquationSeparate(gl.Enum(modeRGB), gl.Enum(modeAlpha))
 	gs.blendEquationRGB = modeRGB
 	gs.blendEquationAlpha = modeAlpha
+	gs.blendEquation = uintUndef
 }
 
 // BlendFunc defines the operation of blending for
@@ -223,9 +423,13 @@ func (gs *GLS) BlendFunc(sfactor, dfactor uint32) {
 	if gs.blendSrc == sfactor && gs.blendDst == dfactor {
 		return
 	}
-	gl.BlendFunc(gl.Enum(sfactor), gl.Enum(dfactor))
+	gs.backend.BlendFunc(gl.Enum(sfactor), gl.Enum(dfactor))
 	gs.blendSrc = sfactor
 	gs.blendDst = dfactor
+	gs.blendSrcRGB = uintUndef
+	gs.blendDstRGB = uintUndef
+	gs.blendSrcAlpha = uintUndef
+	gs.blendDstAlpha = uintUndef
 }
 
 // BlendFuncSeparate defines the operation of blending for all draw buffers when blending
@@ -236,11 +440,18 @@ func (gs *GLS) BlendFuncSeparate(srcRGB uint32, dstRGB uint32, srcAlpha uint32,
 		gs.blendSrcAlpha == srcAlpha && gs.blendDstAlpha == dstAlpha {
 		return
 	}
-	gl.BlendFuncSeparate(gl.Enum(srcRGB), gl.Enum(dstRGB), gl.Enum(srcAlpha), gl.Enum(dstAlpha))
+	gs.backend.BlendFuncSeparate(gl.Enum(srcRGB), gl.Enum(dstRGB), gl.Enum(srcAlpha), gl.Enum(dstAlpha))
 	gs.blendSrcRGB = srcRGB
 	gs.blendDstRGB = dstRGB
 	gs.blendSrcAlpha = srcAlpha
 	gs.blendDstAlpha = dstAlpha
+	gs.blendSrc = uintUndef
+	gs.blendDst = uintUndef
+}
+
+// CheckFramebufferStatus returns the completeness status of the bound framebuffer object.
+func (gs *GLS) CheckFramebufferStatus() uint32 {
+	return uint32(gs.backend.CheckFramebufferStatus(gl.Enum(FRAMEBUFFER)))
 }
 
 // BufferData creates a new data store for the buffer object currently
@@ -248,80 +459,177 @@ func (gs *GLS) BlendFuncSeparate(srcRGB uint32, dstRGB uint32, srcAlpha uint32,
 func (gs *GLS) BufferData(target uint32, size int, data interface{}, usage uint32) {
 	switch data.(type) {
 	case math32.ArrayU32:
-		gl.BufferData(gl.Enum(target), gl.PointerToBytes(&(data.(math32.ArrayU32)[0]), size), gl.Enum(usage))
+		gs.backend.BufferData(gl.Enum(target), gl.PointerToBytes(&(data.(math32.ArrayU32)[0]), size), gl.Enum(usage))
 	case math32.ArrayF32:
-		gl.BufferData(gl.Enum(target), gl.PointerToBytes(&(data.(math32.ArrayF32)[0]), size), gl.Enum(usage))
+		gs.backend.BufferData(gl.Enum(target), gl.PointerToBytes(&(data.(math32.ArrayF32)[0]), size), gl.Enum(usage))
 	default:
-		gl.BufferData(gl.Enum(target), gl.PointerToBytes(data, size), gl.Enum(usage))
+		gs.backend.BufferData(gl.Enum(target), gl.PointerToBytes(data, size), gl.Enum(usage))
+	}
+}
+
+// BufferSubData updates a subset of the data store of the buffer object currently
+// bound to target, starting at the specified byte offset.
+func (gs *GLS) BufferSubData(target uint32, offset int, size int, data interface{}) {
+	switch data.(type) {
+	case math32.ArrayU32:
+		gs.backend.BufferSubData(gl.Enum(target), offset, gl.PointerToBytes(&(data.(math32.ArrayU32)[0]), size))
+	case math32.ArrayF32:
+		gs.backend.BufferSubData(gl.Enum(target), offset, gl.PointerToBytes(&(data.(math32.ArrayF32)[0]), size))
+	default:
+		gs.backend.BufferSubData(gl.Enum(target), offset, gl.PointerToBytes(data, size))
 	}
 }
 
 // ClearColor specifies the red, green, blue, and alpha values
 // used by glClear to clear the color buffers.
 func (gs *GLS) ClearColor(r, g, b, a float32) {
-	gl.ClearColor(r, g, b, a)
+	gs.backend.ClearColor(r, g, b, a)
+	gs.clearColor = [4]float32{r, g, b, a}
+}
+
+// GetClearColor returns the last set clear color.
+func (gs *GLS) GetClearColor() (r, g, b, a float32) {
+	return gs.clearColor[0], gs.clearColor[1], gs.clearColor[2], gs.clearColor[3]
 }
 
 // Clear sets the bitplane area of the window to values previously
 // selected by ClearColor, ClearDepth, and ClearStencil.
 func (gs *GLS) Clear(mask uint) {
-	gl.Clear(gl.Enum(mask))
+	gs.backend.Clear(gl.Enum(mask))
 }
 
 // CompileShader compiles the source code strings that
 // have been stored in the specified shader object.
 func (gs *GLS) CompileShader(shader uint32) {
-	gl.CompileShader(gl.Shader(shader))
+	gs.backend.CompileShader(gl.Shader(shader))
 }
 
 // CreateProgram creates an empty program object and returns
 // a non-zero value by which it can be referenced.
 func (gs *GLS) CreateProgram() uint32 {
-	return uint32(gl.CreateProgram())
+	return uint32(gs.backend.CreateProgram())
 }
 
 // CreateShader creates an empty shader object and returns
 // a non-zero value by which it can be referenced.
 func (gs *GLS) CreateShader(stype uint32) uint32 {
-	return uint32(gl.CreateShader(gl.Enum(stype)))
+	return uint32(gs.backend.CreateShader(gl.Enum(stype)))
 }
 
 // DeleteBuffers deletes n​buffer objects named
 // by the elements of the provided array.
+// They are queued for ProcessPendingDeletes if the deletes are deferred.
 func (gs *GLS) DeleteBuffers(bufs ...uint32) {
+
+	if gs.deferDelete(&gs.pending.buffers, bufs) {
+		return
+	}
+	gs.deleteBuffers(bufs)
+}
+
+// deleteBuffers deletes the specified buffer objects.
+func (gs *GLS) deleteBuffers(bufs []uint32) {
+
 	for _, buf := range bufs {
-		gl.DeleteBuffer(gl.Buffer(buf))
+		gs.backend.DeleteBuffer(gl.Buffer(buf))
 		gs.stats.Buffers--
+		gs.untrack(allocBuffer, buf)
+	}
+}
+
+// DeleteFramebuffers deletes the specified framebuffer objects.
+// If a deleted framebuffer is bound, the default framebuffer is bound instead.
+// They are queued for ProcessPendingDeletes if the deletes are deferred.
+func (gs *GLS) DeleteFramebuffers(fbos ...uint32) {
+
+	if gs.deferDelete(&gs.pending.framebuffers, fbos) {
+		return
+	}
+	gs.deleteFramebuffers(fbos)
+}
+
+// deleteFramebuffers deletes the specified framebuffer objects.
+func (gs *GLS) deleteFramebuffers(fbos []uint32) {
+
+	for _, fbo := range fbos {
+		gs.backend.DeleteFramebuffer(gl.Framebuffer(fbo))
+		if gs.framebuffer == fbo {
+			gs.framebuffer = 0
+		}
+		gs.stats.Fbos--
+		gs.untrack(allocFramebuffer, fbo)
+	}
+}
+
+// DeleteRenderbuffers deletes the specified renderbuffer objects.
+// They are queued for ProcessPendingDeletes if the deletes are deferred.
+func (gs *GLS) DeleteRenderbuffers(rbos ...uint32) {
+
+	if gs.deferDelete(&gs.pending.renderbuffers, rbos) {
+		return
+	}
+	gs.deleteRenderbuffers(rbos)
+}
+
+// deleteRenderbuffers deletes the specified renderbuffer objects.
+func (gs *GLS) deleteRenderbuffers(rbos []uint32) {
+
+	for _, rbo := range rbos {
+		gs.backend.DeleteRenderbuffer(gl.Renderbuffer(rbo))
 	}
 }
 
 // DeleteShader frees the memory and invalidates the name
 // associated with the specified shader object.
 func (gs *GLS) DeleteShader(shader uint32) {
-	gl.DeleteShader(gl.Shader(shader))
+	gs.backend.DeleteShader(gl.Shader(shader))
 }
 
 // DeleteProgram frees the memory and invalidates the name
 // associated with the specified program object.
 func (gs *GLS) DeleteProgram(program uint32) {
-	gl.DeleteProgram(gl.Program(program))
+	gs.backend.DeleteProgram(gl.Program(program))
 }
 
 // DeleteTextures deletes n​textures named
 // by the elements of the provided array.
+// They are queued for ProcessPendingDeletes if the deletes are deferred.
 func (gs *GLS) DeleteTextures(texs ...uint32) {
+
+	if gs.deferDelete(&gs.pending.textures, texs) {
+		return
+	}
+	gs.deleteTextures(texs)
+}
+
+// deleteTextures deletes the specified textures.
+func (gs *GLS) deleteTextures(texs []uint32) {
+
 	for _, tex := range texs {
-		gl.DeleteTexture(gl.Texture(tex))
+		gs.backend.DeleteTexture(gl.Texture(tex))
 		gs.stats.Textures--
+		gs.untrack(allocTexture, tex)
 	}
 }
 
 // DeleteVertexArrays deletes n​vertex array objects named
 // by the elements of the provided array.
+// They are queued for ProcessPendingDeletes if the deletes are deferred.
 func (gs *GLS) DeleteVertexArrays(vaos ...uint32) {
+
+	if gs.deferDelete(&gs.pending.vertexArrays, vaos) {
+		return
+	}
+	gs.deleteVertexArrays(vaos)
+}
+
+// deleteVertexArrays deletes the specified vertex array objects.
+func (gs *GLS) deleteVertexArrays(vaos []uint32) {
+
 	for _, vao := range vaos {
-		gl.DeleteVertexArray(gl.VertexArray(vao))
+		gs.backend.DeleteVertexArray(gl.VertexArray(vao))
 		gs.stats.Vaos--
+		gs.untrack(allocVertexArray, vao)
 	}
 }
 
@@ -332,7 +640,7 @@ func (gs *GLS) DepthFunc(mode uint32) {
 	if gs.depthFunc == mode {
 		return
 	}
-	gl.DepthFunc(gl.Enum(mode))
+	gs.backend.DepthFunc(gl.Enum(mode))
 	gs.depthFunc = mode
 }
 
@@ -345,7 +653,7 @@ func (gs *GLS) DepthMask(flag bool) {
 	if gs.depthMask == intFalse && !flag {
 		return
 	}
-	gl.DepthMask(flag)
+	gs.backend.DepthMask(flag)
 	if flag {
 		gs.depthMask = intTrue
 	} else {
@@ -353,16 +661,252 @@ func (gs *GLS) DepthMask(flag bool) {
 	}
 }
 
+// ClearStencil specifies the value used by Clear for the stencil buffer.
+func (gs *GLS) ClearStencil(s int32) {
+
+	gs.backend.ClearStencil(int(s))
+}
+
+// StencilFunc sets the function, reference value and mask of the stencil test.
+func (gs *GLS) StencilFunc(fn uint32, ref int32, mask uint32) {
+
+	if gs.stencilFunc == fn && gs.stencilRef == ref && gs.stencilFuncMask == mask {
+		return
+	}
+	gs.backend.StencilFunc(gl.Enum(fn), int(ref), mask)
+	gs.stencilFunc = fn
+	gs.stencilRef = ref
+	gs.stencilFuncMask = mask
+}
+
+// StencilOp sets the actions when the stencil test fails, when it passes and
+// the depth test fails and when both tests pass.
+func (gs *GLS) StencilOp(fail, zfail, zpass uint32) {
+
+	if gs.stencilOps[0] == fail && gs.stencilOps[1] == zfail && gs.stencilOps[2] == zpass {
+		return
+	}
+	gs.backend.StencilOp(gl.Enum(fail), gl.Enum(zfail), gl.Enum(zpass))
+	gs.stencilOps = [3]uint32{fail, zfail, zpass}
+}
+
+// StencilMask sets which bits of the stencil buffer are written.
+func (gs *GLS) StencilMask(mask uint32) {
+
+	if gs.stencilMask == mask {
+		return
+	}
+	gs.backend.StencilMask(mask)
+	gs.stencilMask = mask
+}
+
 // DrawArrays renders primitives from array data.
 func (gs *GLS) DrawArrays(mode uint32, first int32, count int32) {
-	gl.DrawArrays(gl.Enum(mode), int(first), int(count))
+	gs.backend.DrawArrays(gl.Enum(mode), int(first), int(count))
 	gs.stats.Drawcalls++
+	gs.countPrimitives(mode, count, 1)
 }
 
 // DrawElements renders primitives from array data.
 func (gs *GLS) DrawElements(mode uint32, count int32, itype uint32, start uint32) {
-	gl.DrawElements(gl.Enum(mode), int(count), gl.Enum(itype), int(start))
+	gs.backend.DrawElements(gl.Enum(mode), int(count), gl.Enum(itype), int(start))
+	gs.stats.Drawcalls++
+	gs.countPrimitives(mode, count, 1)
+}
+
+// DrawArraysInstanced renders the specified number of instances of primitives from array data.
+// It must only be called if GL3Supported.
+func (gs *GLS) DrawArraysInstanced(mode uint32, first int32, count int32, instances int32) {
+	gs.backend.DrawArraysInstanced(gl.Enum(mode), int(first), int(count), int(instances))
 	gs.stats.Drawcalls++
+	gs.countPrimitives(mode, count, instances)
+}
+
+// DrawBuffers sets the color attachments of the bound framebuffer written by the
+// fragment shader outputs, such as COLOR_ATTACHMENT0 and COLOR_ATTACHMENT1 for
+// multiple render targets, or NONE for the outputs which are discarded.
+func (gs *GLS) DrawBuffers(bufs ...uint32) {
+	enums := make([]gl.Enum, len(bufs))
+	for i, buf := range bufs {
+		enums[i] = gl.Enum(buf)
+	}
+	gs.backend.DrawBuffers(enums)
+}
+
+// DrawElementsInstanced renders the specified number of instances of primitives from array data.
+// It must only be called if GL3Supported.
+func (gs *GLS) DrawElementsInstanced(mode uint32, count int32, itype uint32, start uint32, instances int32) {
+	gs.backend.DrawElementsInstanced(gl.Enum(mode), int(count), gl.Enum(itype), int(start), int(instances))
+	gs.stats.Drawcalls++
+	gs.countPrimitives(mode, count, instances)
+}
+
+// countPrimitives adds to the statistics the vertices and triangles of the specified
+// number of instances of the specified primitives and vertices count. The points and
+// lines have no triangles and the primitive restart indices are counted as vertices.
+func (gs *GLS) countPrimitives(mode uint32, count int32, instances int32) {
+
+	var triangles int32
+	switch mode {
+	case TRIANGLES:
+		triangles = count / 3
+	case TRIANGLE_STRIP, TRIANGLE_FAN:
+		if count > 2 {
+			triangles = count - 2
+		}
+	}
+	gs.stats.Triangles += uint64(triangles) * uint64(instances)
+	gs.stats.Vertices += uint64(count) * uint64(instances)
+}
+
+// GL3Supported returns whether the OpenGL 3 functions which tge-gl doesn't provide
+// on OpenGL ES and WebGL can be called: DrawBuffers, ReadBuffer, BlitFramebuffer,
+// RenderbufferStorageMultisample, TexImage3D, FramebufferTextureLayer, the instanced
+// draws, VertexAttribDivisor, VertexAttribIPointer, PrimitiveRestartIndex and the
+// transform feedback functions. The features using them are disabled otherwise.
+func (gs *GLS) GL3Supported() bool {
+
+	return gs.gl3
+}
+
+// MultiDrawIndirectSupported returns whether MultiDrawElementsIndirect is supported,
+// which requires OpenGL 4.3 and is not available with OpenGL ES and WebGL.
+func (gs *GLS) MultiDrawIndirectSupported() bool {
+
+	if gs.multiDrawIndirect == capUndef {
+		gs.multiDrawIndirect = capDisabled
+		version := gs.GetString(VERSION)
+		if !strings.Contains(version, "OpenGL ES") && !strings.Contains(version, "WebGL") {
+			major, minor := gs.GetInteger(MAJOR_VERSION), gs.GetInteger(MINOR_VERSION)
+			if major > 4 || major == 4 && minor >= 3 {
+				gs.multiDrawIndirect = capEnabled
+			}
+		}
+	}
+	return gs.multiDrawIndirect == capEnabled
+}
+
+// TransformFeedbackSupported returns whether transform feedback and reading its results back
+// with GetBufferSubData are supported, which requires OpenGL 3.0. OpenGL ES 3 has no
+// GetBufferSubData and tge-gl doesn't provide these functions on OpenGL ES and WebGL.
+func (gs *GLS) TransformFeedbackSupported() bool {
+
+	if gs.transformFeedback == capUndef {
+		gs.transformFeedback = capDisabled
+		version := gs.GetString(VERSION)
+		if gs.gl3 && !strings.Contains(version, "OpenGL ES") && !strings.Contains(version, "WebGL") {
+			if gs.GetInteger(MAJOR_VERSION) >= 3 {
+				gs.transformFeedback = capEnabled
+			}
+		}
+	}
+	return gs.transformFeedback == capEnabled
+}
+
+// S3TCSupported returns whether the S3TC (DXT) compressed texture formats are supported,
+// which desktop OpenGL drivers provide and OpenGL ES and WebGL only provide as extensions.
+func (gs *GLS) S3TCSupported() bool {
+
+	if gs.s3tc == capUndef {
+		gs.s3tc = capDisabled
+		version := gs.GetString(VERSION)
+		if !strings.Contains(version, "OpenGL ES") && !strings.Contains(version, "WebGL") {
+			gs.s3tc = capEnabled
+		} else if ext := gs.GetString(EXTENSIONS); strings.Contains(ext, "texture_compression_s3tc") || strings.Contains(ext, "compressed_texture_s3tc") {
+			gs.s3tc = capEnabled
+		}
+	}
+	return gs.s3tc == capEnabled
+}
+
+// anisotropySupported returns whether anisotropic filtering (EXT_texture_filter_anisotropic)
+// is supported, which desktop OpenGL drivers provide and OpenGL ES and WebGL only provide as
+// an extension, so that its limit is not queried without it.
+func (gs *GLS) anisotropySupported() bool {
+
+	version := gs.GetString(VERSION)
+	if !strings.Contains(version, "OpenGL ES") && !strings.Contains(version, "WebGL") {
+		return true
+	}
+	return strings.Contains(gs.GetString(EXTENSIONS), "texture_filter_anisotropic")
+}
+
+// MultiDrawElementsIndirect renders multiple sets of primitives with the specified number
+// of draw commands stored in the buffer bound to DRAW_INDIRECT_BUFFER from the specified
+// byte offset, each command following the previous one by the specified stride in bytes
+// or being tightly packed if zero. It counts as a single draw call.
+// It must only be called if MultiDrawIndirectSupported, see IndirectBuffer for a fallback.
+func (gs *GLS) MultiDrawElementsIndirect(mode uint32, itype uint32, indirect uint32, drawcount int32, stride int32) {
+	gs.backend.MultiDrawElementsIndirect(gl.Enum(mode), gl.Enum(itype), int(indirect), int(drawcount), int(stride))
+	gs.stats.Drawcalls++
+	gs.stats.IndirectDraws += uint64(drawcount)
+}
+
+// PrimitiveRestartIndex sets the index which restarts the primitive being drawn by
+// DrawElements when PRIMITIVE_RESTART is enabled, such as to draw several strips at once.
+// It is not available with OpenGL ES and WebGL, see SetPrimitiveRestart.
+func (gs *GLS) PrimitiveRestartIndex(index uint32) {
+
+	if gs.restartIndex == int64(index) {
+		return
+	}
+	gs.backend.PrimitiveRestartIndex(index)
+	gs.restartIndex = int64(index)
+}
+
+// Primitive restart modes
+const (
+	restartIndexed = iota + 1 // the restart index is set by PrimitiveRestartIndex
+	restartFixed              // the restart index is fixed and enabled by PRIMITIVE_RESTART_FIXED_INDEX
+	restartAlways             // the restart index is fixed and always enabled
+)
+
+// FixedPrimitiveRestart returns whether the primitive restart index is fixed to the
+// largest value of the indices type, as with OpenGL ES and WebGL, or PrimitiveRestartIndex
+// is not available (see GL3Supported).
+func (gs *GLS) FixedPrimitiveRestart() bool {
+
+	if gs.restartMode == 0 {
+		gs.restartMode = restartIndexed
+		version := gs.GetString(VERSION)
+		if strings.Contains(version, "WebGL") {
+			gs.restartMode = restartAlways
+		} else if strings.Contains(version, "OpenGL ES") || !gs.gl3 {
+			gs.restartMode = restartFixed
+		}
+	}
+	return gs.restartMode != restartIndexed
+}
+
+// SetPrimitiveRestart enables or disables the primitive restart of the following
+// DrawElements calls with indices of the specified type, whose largest value is
+// the restart index. With WebGL the primitive restart is always enabled.
+func (gs *GLS) SetPrimitiveRestart(state bool, itype uint32) {
+
+	if gs.FixedPrimitiveRestart() {
+		if gs.restartMode == restartAlways {
+			return
+		}
+		if state {
+			gs.Enable(PRIMITIVE_RESTART_FIXED_INDEX)
+		} else {
+			gs.Disable(PRIMITIVE_RESTART_FIXED_INDEX)
+		}
+		return
+	}
+	if !state {
+		gs.Disable(PRIMITIVE_RESTART)
+		return
+	}
+	gs.Enable(PRIMITIVE_RESTART)
+	switch itype {
+	case UNSIGNED_BYTE:
+		gs.PrimitiveRestartIndex(0xFF)
+	case UNSIGNED_SHORT:
+		gs.PrimitiveRestartIndex(0xFFFF)
+	default:
+		gs.PrimitiveRestartIndex(0xFFFFFFFF)
+	}
 }
 
 // Enable enables the specified capability.
@@ -372,7 +916,7 @@ func (gs *GLS) Enable(cap int) {
 		gs.stats.Caphits++
 		return
 	}
-	gl.Enable(gl.Enum(cap))
+	gs.backend.Enable(gl.Enum(cap))
 	gs.capabilities[cap] = capEnabled
 }
 
@@ -383,18 +927,85 @@ func (gs *GLS) Disable(cap int) {
 		gs.stats.Caphits++
 		return
 	}
-	gl.Disable(gl.Enum(cap))
+	gs.backend.Disable(gl.Enum(cap))
+	gs.capabilities[cap] = capDisabled
+}
+
+// IsEnabled returns whether the specified capability is enabled.
+// The state is cached and OpenGL is only queried if it is not known yet.
+func (gs *GLS) IsEnabled(cap int) bool {
+
+	switch gs.capabilities[cap] {
+	case capEnabled:
+		return true
+	case capDisabled:
+		return false
+	}
+	if gs.backend.IsEnabled(gl.Enum(cap)) {
+		gs.capabilities[cap] = capEnabled
+		return true
+	}
 	gs.capabilities[cap] = capDisabled
+	return false
 }
 
 // EnableVertexAttribArray enables a generic vertex attribute array.
 func (gs *GLS) EnableVertexAttribArray(index uint32) {
-	gl.EnableVertexAttribArray(gl.Attrib(int32(index)))
+	gs.backend.EnableVertexAttribArray(gl.Attrib(int32(index)))
+}
+
+// EndTransformFeedback stops capturing the outputs of the vertex shader.
+func (gs *GLS) EndTransformFeedback() {
+	gs.backend.EndTransformFeedback()
 }
 
 // CullFace specifies whether front- or back-facing facets can be culled.
 func (gs *GLS) CullFace(mode uint32) {
-	gl.CullFace(gl.Enum(mode))
+	gs.backend.CullFace(gl.Enum(mode))
+}
+
+// SetSideView sets the visible side(s) of the triangles (FrontSide, BackSide or DoubleSide)
+// enabling or disabling face culling and setting the culled face accordingly.
+// The front faces are always defined by the FrontFace winding. The mode is cached,
+// so CULL_FACE must not be enabled or disabled directly.
+func (gs *GLS) SetSideView(mode int) {
+
+	if gs.sideView == mode {
+		gs.stats.Caphits++
+		return
+	}
+	switch mode {
+	case FrontSide:
+		gs.Enable(CULL_FACE)
+		gs.CullFace(BACK)
+	case BackSide:
+		gs.Enable(CULL_FACE)
+		gs.CullFace(FRONT)
+	case DoubleSide:
+		gs.Disable(CULL_FACE)
+	default:
+		panic("SetSideView: invalid side view mode")
+	}
+	gs.sideView = mode
+}
+
+// SideView returns the current visible side(s) of the triangles.
+func (gs *GLS) SideView() int {
+
+	return gs.sideView
+}
+
+// Multisample returns whether the bound framebuffer has multisample buffers.
+// The state is queried once after each framebuffer change.
+func (gs *GLS) Multisample() bool {
+
+	if gs.multisample == uintUndef {
+		gs.multisample = intFalse
+		if gs.GetInteger(SAMPLE_BUFFERS) > 0 {
+			gs.multisample = intTrue
+		}
+	}
+	return gs.multisample == intTrue
 }
 
 // FrontFace defines front- and back-facing polygons.
@@ -403,65 +1014,138 @@ func (gs *GLS) FrontFace(mode uint32) {
 	if gs.frontFace == mode {
 		return
 	}
-	gl.FrontFace(gl.Enum(mode))
+	gs.backend.FrontFace(gl.Enum(mode))
 	gs.frontFace = mode
 }
 
+// Framebuffer returns the currently bound framebuffer object.
+func (gs *GLS) Framebuffer() uint32 {
+
+	return gs.framebuffer
+}
+
+// FramebufferRenderbuffer attaches the specified renderbuffer to the bound framebuffer.
+func (gs *GLS) FramebufferRenderbuffer(attachment uint32, rbo uint32) {
+	gs.backend.FramebufferRenderbuffer(gl.Enum(FRAMEBUFFER), gl.Enum(attachment), gl.Enum(RENDERBUFFER), gl.Renderbuffer(rbo))
+}
+
+// FramebufferTexture2D attaches the specified level of a texture image to the bound framebuffer.
+// The texture target is TEXTURE_2D or one of the TEXTURE_CUBE_MAP_POSITIVE_X... faces.
+func (gs *GLS) FramebufferTexture2D(attachment uint32, textarget uint32, tex uint32, level int32) {
+	gs.backend.FramebufferTexture2D(gl.Enum(FRAMEBUFFER), gl.Enum(attachment), gl.Enum(textarget), gl.Texture(tex), int(level))
+}
+
+// FramebufferTextureLayer attaches the specified level of a layer of a texture array or of a 3D texture
+// to the framebuffer bound to the specified target, which is FRAMEBUFFER, READ_FRAMEBUFFER or DRAW_FRAMEBUFFER.
+// The faces of cube maps are attached by FramebufferTexture2D. It must only be called if GL3Supported.
+func (gs *GLS) FramebufferTextureLayer(target, attachment uint32, tex uint32, level, layer int32) {
+	gs.backend.FramebufferTextureLayer(gl.Enum(target), gl.Enum(attachment), gl.Texture(tex), int(level), int(layer))
+}
+
 // GenBuffer generates a​buffer object name.
 func (gs *GLS) GenBuffer() uint32 {
-	buf := gl.CreateBuffer()
+	buf := gs.backend.CreateBuffer()
 	gs.stats.Buffers++
+	gs.track(allocBuffer, uint32(buf))
 	return uint32(buf)
 }
 
+// GenFramebuffer generates a framebuffer object name.
+func (gs *GLS) GenFramebuffer() uint32 {
+	fbo := gs.backend.CreateFramebuffer()
+	gs.stats.Fbos++
+	gs.track(allocFramebuffer, uint32(fbo))
+	return uint32(fbo)
+}
+
+// GenRenderbuffer generates a renderbuffer object name.
+func (gs *GLS) GenRenderbuffer() uint32 {
+	return uint32(gs.backend.CreateRenderbuffer())
+}
+
+// AddTextureBytes adds the specified number of bytes, which is negative when
+// texture data is released, to the texture memory statistics.
+func (gs *GLS) AddTextureBytes(bytes int) {
+
+	if bytes < 0 {
+		gs.pending.mu.Lock()
+		defer gs.pending.mu.Unlock()
+		if gs.pending.enabled {
+			gs.pending.textureBytes += bytes
+			return
+		}
+	}
+	gs.stats.TextureBytes += bytes
+}
+
 // GenerateMipmap generates mipmaps for the specified texture target.
 func (gs *GLS) GenerateMipmap(target uint32) {
-	gl.GenerateMipmap(gl.Enum(target))
+	gs.backend.GenerateMipmap(gl.Enum(target))
 }
 
 // GenTexture generates a texture object name.
 func (gs *GLS) GenTexture() uint32 {
-	tex := gl.CreateTexture()
+	tex := gs.backend.CreateTexture()
 	gs.stats.Textures++
+	gs.track(allocTexture, uint32(tex))
 	return uint32(tex)
 }
 
 // GenVertexArray generates a vertex array object name.
 func (gs *GLS) GenVertexArray() uint32 {
-	vao := gl.CreateVertexArray()
+	vao := gs.backend.CreateVertexArray()
 	gs.stats.Vaos++
+	gs.track(allocVertexArray, uint32(vao))
 	return uint32(vao)
 }
 
 // GetAttribLocation returns the location of the specified attribute variable.
 func (gs *GLS) GetAttribLocation(program uint32, name string) int32 {
-	loc := gl.GetAttribLocation(gl.Program(program), name)
+	loc := gs.backend.GetAttribLocation(gl.Program(program), name)
 	return int32(loc)
 }
 
+// GetBufferSubData reads the data of the buffer bound to the specified target from the
+// specified byte offset. It waits until the commands writing the buffer are completed.
+func (gs *GLS) GetBufferSubData(target uint32, offset int, data []byte) {
+	gs.backend.GetBufferSubData(gl.Enum(target), offset, data)
+}
+
+// GetFloat returns the value of the specified float parameter, such as
+// MAX_TEXTURE_MAX_ANISOTROPY_EXT or LINE_WIDTH.
+func (gs *GLS) GetFloat(pname uint32) float32 {
+	return gs.backend.GetFloat(gl.Enum(pname))
+}
+
+// GetInteger returns the value of the specified integer parameter, such as
+// MAX_VERTEX_UNIFORM_VECTORS or MAX_TEXTURE_IMAGE_UNITS.
+func (gs *GLS) GetInteger(pname uint32) int32 {
+	return int32(gs.backend.GetInteger(gl.Enum(pname)))
+}
+
 // GetProgramiv returns the specified parameter from the specified program object.
 func (gs *GLS) GetProgramiv(program, pname uint32, params *int32) {
-	*params = int32(gl.GetProgrami(gl.Program(program), gl.Enum(pname)))
+	*params = int32(gs.backend.GetProgrami(gl.Program(program), gl.Enum(pname)))
 }
 
 // GetProgramInfoLog returns the information log for the specified program object.
 func (gs *GLS) GetProgramInfoLog(program uint32) string {
-	return gl.GetProgramInfoLog(gl.Program(program))
+	return gs.backend.GetProgramInfoLog(gl.Program(program))
 }
 
 // GetShaderInfoLog returns the information log for the specified shader object.
 func (gs *GLS) GetShaderInfoLog(shader uint32) string {
-	return gl.GetShaderInfoLog(gl.Shader(shader))
+	return gs.backend.GetShaderInfoLog(gl.Shader(shader))
 }
 
 // GetString returns a string describing the specified aspect of the current GL connection.
 func (gs *GLS) GetString(name uint32) string {
-	return gl.GetString(gl.Enum(name))
+	return gs.backend.GetString(gl.Enum(name))
 }
 
 // GetUniformLocation returns the location of a uniform variable for the specified program.
 func (gs *GLS) GetUniformLocation(program uint32, name string) int32 {
-	loc := gl.GetUniformLocation(gl.Program(program), name)
+	loc := gs.backend.GetUniformLocation(gl.Program(program), name)
 	return int32(loc)
 }
 
@@ -475,38 +1159,63 @@ func (gs *GLS) LineWidth(width float32) {
 	if gs.lineWidth == width {
 		return
 	}
-	gl.LineWidth(width)
+	gs.backend.LineWidth(width)
 	gs.lineWidth = width
 }
 
 // LinkProgram links the specified program object.
 func (gs *GLS) LinkProgram(program uint32) {
-	gl.LinkProgram(gl.Program(program))
+	gs.backend.LinkProgram(gl.Program(program))
 }
 
 // GetShaderiv returns the specified parameter from the specified shader object.
 func (gs *GLS) GetShaderiv(shader, pname uint32, params *int32) {
-	*params = int32(gl.GetShaderi(gl.Shader(shader), gl.Enum(pname)))
+	*params = int32(gs.backend.GetShaderi(gl.Shader(shader), gl.Enum(pname)))
 }
 
 // Scissor defines the scissor box rectangle in window coordinates.
 func (gs *GLS) Scissor(x, y int32, width, height uint32) {
-	gl.Scissor(x, y, int32(width), int32(height))
+	gs.backend.Scissor(x, y, int32(width), int32(height))
 }
 
 // ShaderSource sets the source code for the specified shader object.
 func (gs *GLS) ShaderSource(shader uint32, src string) {
-	gl.ShaderSource(gl.Shader(shader), src)
+	gs.backend.ShaderSource(gl.Shader(shader), src)
+}
+
+// CompressedTexImage2D specifies a two-dimensional texture image in the specified compressed internal format.
+func (gs *GLS) CompressedTexImage2D(target uint32, level int32, iformat uint32, width int32, height int32, data []byte) {
+	gs.backend.CompressedTexImage2D(gl.Enum(target), int(level), gl.Enum(iformat), int(width), int(height), 0, data)
 }
 
 // TexImage2D specifies a two-dimensional texture image.
 func (gs *GLS) TexImage2D(target uint32, level int32, iformat int32, width int32, height int32, border int32, format uint32, itype uint32, data interface{}) {
-	gl.TexImage2D(gl.Enum(target), int(level), int(width), int(height), gl.Enum(format), gl.Enum(itype), data.([]byte))
+	pixels, _ := data.([]byte) // nil data only allocates the texture storage
+	gs.backend.TexImage2D(gl.Enum(target), int(level), int(width), int(height), gl.Enum(format), gl.Enum(itype), pixels)
+}
+
+// TexImage3D specifies a three-dimensional texture image or a two-dimensional texture array.
+// For texture arrays (TEXTURE_2D_ARRAY) the depth is the number of layers.
+func (gs *GLS) TexImage3D(target uint32, level int32, iformat int32, width int32, height int32, depth int32, border int32, format uint32, itype uint32, data interface{}) {
+	pixels, _ := data.([]byte) // nil data only allocates the texture storage
+	gs.backend.TexImage3D(gl.Enum(target), int(level), gl.Enum(iformat), int(width), int(height), int(depth), gl.Enum(format), gl.Enum(itype), pixels)
+}
+
+// TexParameterf sets the specified float texture parameter on the specified texture.
+func (gs *GLS) TexParameterf(target uint32, pname uint32, param float32) {
+	gs.backend.TexParameterf(gl.Enum(target), gl.Enum(pname), param)
 }
 
 // TexParameteri sets the specified texture parameter on the specified texture.
 func (gs *GLS) TexParameteri(target uint32, pname uint32, param int32) {
-	gl.TexParameteri(gl.Enum(target), gl.Enum(target), int(param))
+	gs.backend.TexParameteri(gl.Enum(target), gl.Enum(pname), int(param))
+}
+
+// TransformFeedbackVaryings specifies the outputs of the vertex shader of the specified program
+// captured by transform feedback, in a single buffer (INTERLEAVED_ATTRIBS) or in a buffer each
+// (SEPARATE_ATTRIBS). It must be called before the program is linked.
+func (gs *GLS) TransformFeedbackVaryings(program uint32, varyings []string, bufferMode uint32) {
+	gs.backend.TransformFeedbackVaryings(gl.Program(program), varyings, gl.Enum(bufferMode))
 }
 
 // PolygonMode controls the interpretation of polygons for rasterization.
@@ -515,7 +1224,7 @@ func (gs *GLS) PolygonMode(face, mode uint32) {
 	if gs.polygonModeFace == face && gs.polygonModeMode == mode {
 		return
 	}
-	gl.PolygonMode(gl.Enum(face), gl.Enum(mode))
+	gs.backend.PolygonMode(gl.Enum(face), gl.Enum(mode))
 	gs.polygonModeFace = face
 	gs.polygonModeMode = mode
 }
@@ -526,100 +1235,130 @@ func (gs *GLS) PolygonOffset(factor float32, units float32) {
 	if gs.polygonOffsetFactor == factor && gs.polygonOffsetUnits == units {
 		return
 	}
-	gl.PolygonOffset(factor, units)
+	gs.backend.PolygonOffset(factor, units)
 	gs.polygonOffsetFactor = factor
 	gs.polygonOffsetUnits = units
 }
 
+// ReadBuffer sets the color attachment of the bound framebuffer read by
+// the pixel reads and the blits, or NONE for framebuffers without colors.
+func (gs *GLS) ReadBuffer(src uint32) {
+	gs.backend.ReadBuffer(gl.Enum(src))
+}
+
+// RenderbufferStorage establishes the data storage, format and dimensions
+// of the bound renderbuffer object.
+func (gs *GLS) RenderbufferStorage(iformat uint32, width, height int32) {
+	gs.backend.RenderbufferStorage(gl.Enum(RENDERBUFFER), gl.Enum(iformat), int(width), int(height))
+}
+
+// RenderbufferStorageMultisample establishes the data storage, format, dimensions
+// and number of samples of the bound multisampled renderbuffer object.
+func (gs *GLS) RenderbufferStorageMultisample(samples int32, iformat uint32, width, height int32) {
+	gs.backend.RenderbufferStorageMultisample(gl.Enum(RENDERBUFFER), int(samples), gl.Enum(iformat), int(width), int(height))
+}
+
 // Uniform1i sets the value of an int uniform variable for the current program object.
 func (gs *GLS) Uniform1i(location int32, v0 int32) {
-	gl.Uniform1i(gl.Uniform(location), int(v0))
+	gs.backend.Uniform1i(gl.Uniform(location), int(v0))
 	gs.stats.Unisets++
 }
 
 // Uniform1f sets the value of a float uniform variable for the current program object.
 func (gs *GLS) Uniform1f(location int32, v0 float32) {
-	gl.Uniform1f(gl.Uniform(location), v0)
+	gs.backend.Uniform1f(gl.Uniform(location), v0)
 	gs.stats.Unisets++
 }
 
 // Uniform2f sets the value of a vec2 uniform variable for the current program object.
 func (gs *GLS) Uniform2f(location int32, v0, v1 float32) {
-	gl.Uniform2f(gl.Uniform(location), v0, v1)
+	gs.backend.Uniform2f(gl.Uniform(location), v0, v1)
 	gs.stats.Unisets++
 }
 
 // Uniform3f sets the value of a vec3 uniform variable for the current program object.
 func (gs *GLS) Uniform3f(location int32, v0, v1, v2 float32) {
-	gl.Uniform3f(gl.Uniform(location), v0, v1, v2)
+	gs.backend.Uniform3f(gl.Uniform(location), v0, v1, v2)
 	gs.stats.Unisets++
 }
 
 // Uniform4f sets the value of a vec4 uniform variable for the current program object.
 func (gs *GLS) Uniform4f(location int32, v0, v1, v2, v3 float32) {
-	gl.Uniform4f(gl.Uniform(location), v0, v1, v2, v3)
+	gs.backend.Uniform4f(gl.Uniform(location), v0, v1, v2, v3)
 	gs.stats.Unisets++
 }
 
 // UniformMatrix3fv sets the value of one or many 3x3 float matrices for the current program object.
 func (gs *GLS) UniformMatrix3fv(location int32, count int32, transpose bool, pm *float32) {
-	gl.UniformMatrix3fvP(gl.Uniform(location), count, transpose, pm)
+	gs.backend.UniformMatrix3fvP(gl.Uniform(location), count, transpose, pm)
 	gs.stats.Unisets++
 }
 
 // UniformMatrix4fv sets the value of one or many 4x4 float matrices for the current program object.
 func (gs *GLS) UniformMatrix4fv(location int32, count int32, transpose bool, pm *float32) {
-	gl.UniformMatrix4fvP(gl.Uniform(location), count, transpose, pm)
+	gs.backend.UniformMatrix4fvP(gl.Uniform(location), count, transpose, pm)
 	gs.stats.Unisets++
 }
 
 // Uniform1fv sets the value of one or many float uniform variables for the current program object.
 func (gs *GLS) Uniform1fv(location int32, count int32, v []float32) {
-	gl.Uniform1fv(gl.Uniform(location), v)
+	gs.backend.Uniform1fv(gl.Uniform(location), v)
 	gs.stats.Unisets++
 }
 
 // Uniform2fv sets the value of one or many vec2 uniform variables for the current program object.
 func (gs *GLS) Uniform2fv(location int32, count int32, v *float32) {
-	gl.Uniform2fvP(gl.Uniform(location), count, v)
+	gs.backend.Uniform2fvP(gl.Uniform(location), count, v)
 	gs.stats.Unisets++
 }
 
 func (gs *GLS) Uniform2fvUP(location int32, count int32, v unsafe.Pointer) {
-	gl.Uniform2fvUP(gl.Uniform(location), count, v)
+	gs.backend.Uniform2fvUP(gl.Uniform(location), count, v)
 	gs.stats.Unisets++
 }
 
 // Uniform3fv sets the value of one or many vec3 uniform variables for the current program object.
 func (gs *GLS) Uniform3fv(location int32, count int32, v *float32) {
-	gl.Uniform3fvP(gl.Uniform(location), count, v)
+	gs.backend.Uniform3fvP(gl.Uniform(location), count, v)
 	gs.stats.Unisets++
 }
 
 func (gs *GLS) Uniform3fvUP(location int32, count int32, v unsafe.Pointer) {
-	gl.Uniform3fvUP(gl.Uniform(location), count, v)
+	gs.backend.Uniform3fvUP(gl.Uniform(location), count, v)
 	gs.stats.Unisets++
 }
 
 // Uniform4fv sets the value of one or many vec4 uniform variables for the current program object.
 func (gs *GLS) Uniform4fv(location int32, count int32, v []float32) {
-	gl.Uniform4fv(gl.Uniform(location), v)
+	gs.backend.Uniform4fv(gl.Uniform(location), v)
 	gs.stats.Unisets++
 }
 
 func (gs *GLS) Uniform4fvUP(location int32, count int32, v unsafe.Pointer) {
-	gl.Uniform4fvUP(gl.Uniform(location), count, v)
+	gs.backend.Uniform4fvUP(gl.Uniform(location), count, v)
 	gs.stats.Unisets++
 }
 
 // VertexAttribPointer defines an array of generic vertex attribute data.
 func (gs *GLS) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset uint32) {
-	gl.VertexAttribPointer(gl.Attrib(int32(index)), int(size), gl.Enum(xtype), normalized, int(stride), int(offset))
+	gs.backend.VertexAttribPointer(gl.Attrib(int32(index)), int(size), gl.Enum(xtype), normalized, int(stride), int(offset))
+}
+
+// VertexAttribIPointer defines an array of generic vertex attribute data
+// with integer elements which are not converted to float.
+func (gs *GLS) VertexAttribIPointer(index uint32, size int32, xtype uint32, stride int32, offset uint32) {
+	gs.backend.VertexAttribIPointer(gl.Attrib(int32(index)), int(size), gl.Enum(xtype), int(stride), int(offset))
+}
+
+// VertexAttribDivisor sets the rate at which a generic vertex attribute advances
+// during instanced rendering. A divisor of 0 advances it once per vertex.
+func (gs *GLS) VertexAttribDivisor(index uint32, divisor uint32) {
+	gs.backend.VertexAttribDivisor(gl.Attrib(int32(index)), int(divisor))
 }
 
 // Viewport sets the viewport.
 func (gs *GLS) Viewport(x, y, width, height int32) {
-	gl.Viewport(int(x), int(y), int(width), int(height))
+	gs.backend.Viewport(int(x), int(y), int(width), int(height))
 	gs.viewportX = x
 	gs.viewportY = y
 	gs.viewportWidth = width
@@ -631,7 +1370,7 @@ func (gs *GLS) UseProgram(prog *Program) {
 	if prog.handle == 0 {
 		panic("Invalid program")
 	}
-	gl.UseProgram(gl.Program(prog.handle))
+	gs.backend.UseProgram(gl.Program(prog.handle))
 	gs.prog = prog
 
 	// Inserts program in cache if not already there.
@@ -639,3 +1378,9 @@ func (gs *GLS) UseProgram(prog *Program) {
 		gs.programs[prog] = true
 	}
 }
+
+// Prog returns the current active shader program or nil.
+func (gs *GLS) Prog() *Program {
+
+	return gs.prog
+}
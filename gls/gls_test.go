@@ -0,0 +1,380 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test that the cached setters call OpenGL only when the state changes
+func TestCachedSetters(t *testing.T) {
+
+	setters := []struct {
+		name string
+		set  func(gs *GLS)
+	}{
+		{"ActiveTexture", func(gs *GLS) { gs.ActiveTexture(TEXTURE3) }},
+		{"BlendEquation", func(gs *GLS) { gs.BlendEquation(FUNC_SUBTRACT) }},
+		{"BlendEquationSeparate", func(gs *GLS) { gs.BlendEquationSeparate(FUNC_SUBTRACT, FUNC_ADD) }},
+		{"BlendFunc", func(gs *GLS) { gs.BlendFunc(ONE, ONE) }},
+		{"BlendFuncSeparate", func(gs *GLS) { gs.BlendFuncSeparate(ONE, ZERO, ONE, ONE) }},
+		{"DepthFunc", func(gs *GLS) { gs.DepthFunc(GREATER) }},
+		{"DepthMask", func(gs *GLS) { gs.DepthMask(false) }},
+		{"Disable", func(gs *GLS) { gs.Disable(DEPTH_TEST) }},
+		{"FrontFace", func(gs *GLS) { gs.FrontFace(CW) }},
+		{"LineWidth", func(gs *GLS) { gs.LineWidth(2) }},
+		{"PolygonMode", func(gs *GLS) { gs.PolygonMode(FRONT_AND_BACK, LINE) }},
+		{"PolygonOffset", func(gs *GLS) { gs.PolygonOffset(1, 1) }},
+		{"PrimitiveRestartIndex", func(gs *GLS) { gs.PrimitiveRestartIndex(0xFFFF) }},
+		{"StencilMask", func(gs *GLS) { gs.StencilMask(0) }},
+	}
+	rec := NewRecorder()
+	gs, err := NewWithBackend(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range setters {
+		rec.Reset()
+		s.set(gs)
+		s.set(gs)
+		names := rec.Names()
+		if len(names) != 1 || names[0] != s.name {
+			t.Errorf("%s: got calls %v", s.name, names)
+		}
+	}
+}
+
+// Test that the indirect draws use MultiDrawElementsIndirect only with OpenGL 4.3
+// and fall back to a draw call per command otherwise
+func TestIndirectBuffer(t *testing.T) {
+
+	ib := NewIndirectBuffer()
+	ib.AddCommand(DrawElementsIndirectCommand{Count: 6, InstanceCount: 1})
+	ib.AddCommand(DrawElementsIndirectCommand{Count: 3})
+	ib.AddCommand(DrawElementsIndirectCommand{Count: 12, InstanceCount: 2, FirstIndex: 6})
+	for _, c := range []struct {
+		major, minor int
+		gl3          bool
+		expected     string
+	}{
+		{3, 3, true, "DrawElementsInstanced(4, 6, 5125, 0, 1)\nDrawElementsInstanced(4, 12, 5125, 24, 2)\n"},
+		{3, 3, false, "DrawElements(4, 6, 5125, 0)\nDrawElements(4, 12, 5125, 24)\n"},
+		{4, 3, true, "MultiDrawElementsIndirect(4, 5125, 0, 3, 20)\n"},
+	} {
+		rec := NewRecorder()
+		rec.SetInteger(MAJOR_VERSION, c.major)
+		rec.SetInteger(MINOR_VERSION, c.minor)
+		gs, err := NewWithBackend(rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gs.gl3 = c.gl3
+		gs.MultiDrawIndirectSupported()
+		rec.Reset()
+		ib.Draw(gs, TRIANGLES, UNSIGNED_INT)
+		var draws string
+		for _, call := range rec.Calls() {
+			if strings.HasPrefix(call.Name, "Draw") || strings.HasPrefix(call.Name, "MultiDraw") {
+				draws += call.String() + "\n"
+			}
+		}
+		if draws != c.expected {
+			t.Errorf("OpenGL %d.%d: expected draws:\n%sgot:\n%s", c.major, c.minor, c.expected, draws)
+		}
+		ib.Dispose()
+	}
+}
+
+// Test that IsEnabled returns the cached state and queries OpenGL only once
+func TestIsEnabled(t *testing.T) {
+
+	rec := NewRecorder()
+	gs, err := NewWithBackend(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs.Enable(SCISSOR_TEST)
+	gs.Disable(STENCIL_TEST)
+	rec.Reset()
+	if !gs.IsEnabled(SCISSOR_TEST) || gs.IsEnabled(STENCIL_TEST) {
+		t.Fatal("expected the cached states")
+	}
+	if len(rec.Calls()) != 0 {
+		t.Fatalf("expected no calls got %v", rec)
+	}
+	if gs.IsEnabled(DITHER) || gs.IsEnabled(DITHER) {
+		t.Fatal("expected DITHER disabled")
+	}
+	if len(rec.Calls()) != 1 {
+		t.Fatalf("expected 1 call got %v", rec)
+	}
+}
+
+// Test that PopState restores only the state changed since PushState
+func TestPushPopState(t *testing.T) {
+
+	rec := NewRecorder()
+	gs, err := NewWithBackend(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs.PushState(DEPTH_TEST, BLEND)
+	gs.Disable(DEPTH_TEST)
+	gs.DepthMask(false)
+	gs.SetSideView(DoubleSide)
+	gs.BlendAdditive()
+	gs.PushState(DEPTH_TEST)
+	gs.Enable(DEPTH_TEST)
+	gs.PopState()
+	if gs.IsEnabled(DEPTH_TEST) {
+		t.Fatal("expected DEPTH_TEST disabled by the nested PopState")
+	}
+	rec.Reset()
+	gs.PopState()
+	expected := "Enable CullFace BlendFunc Enable"
+	names := strings.Join(rec.Names(), " ")
+	if names != expected {
+		t.Fatalf("expected %s got %s", expected, names)
+	}
+	if !gs.IsEnabled(DEPTH_TEST) || !gs.IsEnabled(CULL_FACE) {
+		t.Fatal("expected the saved state restored")
+	}
+}
+
+// Test that the primitive restart index is set once for each indices type
+func TestPrimitiveRestart(t *testing.T) {
+
+	rec := NewRecorder()
+	gs, err := NewWithBackend(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+	for i := 0; i < 2; i++ {
+		gs.SetPrimitiveRestart(true, UNSIGNED_INT)
+		gs.SetPrimitiveRestart(false, UNSIGNED_INT)
+	}
+	gs.SetPrimitiveRestart(true, UNSIGNED_SHORT)
+	var calls []string
+	for _, c := range rec.Calls() {
+		calls = append(calls, c.String())
+	}
+	expected := "GetString(7938) Enable(36765) PrimitiveRestartIndex(4294967295) Disable(36765) Enable(36765) Disable(36765) Enable(36765) PrimitiveRestartIndex(65535)"
+	if strings.Join(calls, " ") != expected {
+		t.Fatalf("expected %s got %v", expected, calls)
+	}
+
+	// The fixed restart index is used without PrimitiveRestartIndex
+	rec = NewRecorder()
+	gs, _ = NewWithBackend(rec)
+	gs.gl3 = false
+	rec.Reset()
+	gs.SetPrimitiveRestart(true, UNSIGNED_SHORT)
+	calls = calls[:0]
+	for _, c := range rec.Calls() {
+		calls = append(calls, c.String())
+	}
+	expected = "GetString(7938) Enable(36201)"
+	if strings.Join(calls, " ") != expected {
+		t.Fatalf("expected %s got %v", expected, calls)
+	}
+}
+
+// Test that transform feedback is only reported supported when the backend can read back its results
+func TestTransformFeedbackSupported(t *testing.T) {
+
+	rec := NewRecorder()
+	rec.SetInteger(MAJOR_VERSION, 3)
+	gs, _ := NewWithBackend(rec)
+	if !gs.TransformFeedbackSupported() {
+		t.Error("expected transform feedback supported with OpenGL 3")
+	}
+	gs, _ = NewWithBackend(rec)
+	gs.gl3 = false
+	if gs.TransformFeedbackSupported() {
+		t.Error("expected transform feedback not supported without the OpenGL 3 functions")
+	}
+}
+
+// Test that multisampling is only reported supported when the backend provides the OpenGL 3 functions
+func TestMaxSamples(t *testing.T) {
+
+	rec := NewRecorder()
+	rec.SetInteger(MAX_SAMPLES, 4)
+	gs, _ := NewWithBackend(rec)
+	if samples := gs.MaxSamples(); samples != 4 {
+		t.Errorf("expected 4 samples got %d", samples)
+	}
+	gs, _ = NewWithBackend(rec)
+	gs.gl3 = false
+	if samples := gs.MaxSamples(); samples != 0 {
+		t.Errorf("expected no multisampling without the OpenGL 3 functions got %d samples", samples)
+	}
+}
+
+// Test that the capabilities are queried once with the minimum limits as fallbacks
+func TestCapabilities(t *testing.T) {
+
+	rec := NewRecorder()
+	rec.SetFloat(MAX_TEXTURE_MAX_ANISOTROPY_EXT, 16)
+	rec.SetInteger(MAX_SAMPLES, 8)
+	rec.SetInteger(MAX_TEXTURE_SIZE, 8192)
+	gs, err := NewWithBackend(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+	caps := gs.Capabilities()
+	expected := Capabilities{MaxAnisotropy: 16, MaxTextureUnits: 16, MaxVertexUniformVectors: 256, MaxSamples: 8, MaxTextureSize: 8192}
+	if caps != expected {
+		t.Errorf("expected %+v got %+v", expected, caps)
+	}
+	queries := len(rec.Calls())
+	if gs.MaxSamples() != 8 || gs.MaxAnisotropy() != 16 || gs.MaxTextureUnits() != 16 || len(rec.Calls()) != queries {
+		t.Errorf("expected the cached capabilities without new queries")
+	}
+
+	// OpenGL 3.3 core only reports the vertex uniform components
+	rec = NewRecorder()
+	rec.SetInteger(MAX_VERTEX_UNIFORM_COMPONENTS, 4096)
+	gs, _ = NewWithBackend(rec)
+	if vectors := gs.Capabilities().MaxVertexUniformVectors; vectors != 1024 {
+		t.Errorf("expected 1024 vertex uniform vectors got %d", vectors)
+	}
+
+	// OpenGL ES and WebGL only report the anisotropy with its extension
+	for _, ext := range []string{"", "GL_EXT_texture_filter_anisotropic"} {
+		rec = NewRecorder()
+		rec.SetString(VERSION, "OpenGL ES 3.0")
+		rec.SetString(EXTENSIONS, ext)
+		rec.SetFloat(MAX_TEXTURE_MAX_ANISOTROPY_EXT, 16)
+		gs, _ = NewWithBackend(rec)
+		rec.Reset()
+		anisotropy := gs.MaxAnisotropy()
+		queried := false
+		for _, c := range rec.Calls() {
+			queried = queried || c.Name == "GetFloat"
+		}
+		if ext == "" && (anisotropy != 0 || queried) {
+			t.Errorf("expected no anisotropy query without the extension got %v", anisotropy)
+		}
+		if ext != "" && anisotropy != 16 {
+			t.Errorf("expected the anisotropy of the extension got %v", anisotropy)
+		}
+	}
+}
+
+// Test that the blend functions and equations set for all the components
+// and separately for the RGB and alpha components don't hide each other
+func TestBlendSeparate(t *testing.T) {
+
+	rec := NewRecorder()
+	gs, err := NewWithBackend(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+	gs.BlendAlpha()
+	gs.BlendAccumulate()
+	gs.BlendAlpha()
+	gs.BlendEquationSeparate(FUNC_SUBTRACT, FUNC_ADD)
+	gs.BlendEquation(FUNC_ADD)
+	names := strings.Join(rec.Names(), " ")
+	expected := "BlendFuncSeparate BlendFunc BlendEquationSeparate BlendEquation"
+	if names != expected {
+		t.Errorf("expected calls %s got %s", expected, names)
+	}
+}
+
+// Test the triangles and vertices counts of the primitive modes
+func TestPrimitiveStats(t *testing.T) {
+
+	gs, err := NewWithBackend(NewRecorder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		mode      uint32
+		count     int32
+		instances int32
+		triangles uint64
+	}{{TRIANGLES, 36, 1, 12}, {TRIANGLES, 6, 10, 20}, {TRIANGLE_STRIP, 6, 1, 4}, {TRIANGLE_FAN, 2, 1, 0},
+		{LINES, 8, 1, 0}, {LINE_STRIP, 8, 1, 0}, {POINTS, 5, 3, 0}} {
+		var before, after Stats
+		gs.Stats(&before)
+		if c.instances > 1 {
+			gs.DrawArraysInstanced(c.mode, 0, c.count, c.instances)
+		} else {
+			gs.DrawElements(c.mode, c.count, UNSIGNED_INT, 0)
+		}
+		gs.Stats(&after)
+		if triangles := after.Triangles - before.Triangles; triangles != c.triangles {
+			t.Errorf("mode %d count %d: expected %d triangles got %d", c.mode, c.count, c.triangles, triangles)
+		}
+		if vertices := after.Vertices - before.Vertices; vertices != uint64(c.count*c.instances) {
+			t.Errorf("mode %d count %d: expected %d vertices got %d", c.mode, c.count, c.count*c.instances, vertices)
+		}
+	}
+}
+
+// Test the deletes queued from another goroutine and processed on the render thread
+func TestDeferDeletes(t *testing.T) {
+
+	rec := NewRecorder()
+	gs, err := NewWithBackend(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tex := gs.GenTexture()
+	buf := gs.GenBuffer()
+	gs.SetDeferDeletes(true)
+	rec.Reset()
+	done := make(chan struct{})
+	go func() {
+		gs.DeleteTextures(tex)
+		gs.QueueDeleteBuffers(buf)
+		close(done)
+	}()
+	<-done
+	if len(rec.Calls()) != 0 || gs.PendingDeletes() != 2 {
+		t.Fatalf("unexpected calls %v or pending deletes %d", rec.Names(), gs.PendingDeletes())
+	}
+	if n := gs.ProcessPendingDeletes(); n != 2 {
+		t.Errorf("processed deletes: got %d, expected 2", n)
+	}
+	if leaks := gs.ResourceLeaks(); leaks.Textures != 0 || leaks.Buffers != 0 || gs.PendingDeletes() != 0 {
+		t.Errorf("unexpected leaks %+v", leaks)
+	}
+	if len(rec.Calls()) != 2 {
+		t.Errorf("unexpected calls %v", rec.Names())
+	}
+}
+
+// Test that the materials texture units don't include the units reserved for the renderer
+func TestAllocTextureUnit(t *testing.T) {
+
+	rec := NewRecorder()
+	rec.SetInteger(MAX_TEXTURE_IMAGE_UNITS, 4)
+	gs, err := NewWithBackend(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs.ResetTextureUnits()
+	for i := 0; i < 2; i++ {
+		if unit := gs.AllocTextureUnit(); unit != i {
+			t.Errorf("expected texture unit %d got %d", i, unit)
+		}
+	}
+	if unit := gs.AllocTextureUnit(); unit != -1 {
+		t.Errorf("expected no free texture unit got %d", unit)
+	}
+	if unit := gs.ReservedTextureUnit(0); unit != 2 {
+		t.Errorf("expected reserved texture unit 2 got %d", unit)
+	}
+	if unit := gs.ReservedTextureUnit(ReservedTextureUnits - 1); unit != 3 {
+		t.Errorf("expected reserved texture unit 3 got %d", unit)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+import (
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// DrawElementsIndirectCommand is an indexed draw of an IndirectBuffer,
+// with the layout of the OpenGL DrawElementsIndirectCommand structure.
+type DrawElementsIndirectCommand struct {
+	Count         uint32 // Number of indices
+	InstanceCount uint32 // Number of instances
+	FirstIndex    uint32 // Position of the first index in the indices buffer
+	BaseVertex    int32  // Value added to the indices, ignored by the fallback
+	BaseInstance  uint32 // First instance of the instanced attributes, ignored by the fallback
+}
+
+// indirectCommandSize is the number of 32 bits words of a DrawElementsIndirectCommand.
+const indirectCommandSize = 5
+
+// IndirectBuffer is a list of indexed draw commands using the bound vertex array
+// and indices buffer, such as the parts of a geometry shared by many objects of a
+// large static scene. The commands are submitted with a single call of
+// MultiDrawElementsIndirect if supported, or with a draw call per command otherwise.
+type IndirectBuffer struct {
+	gs       *GLS                          // Reference to OpenGL state
+	handle   uint32                        // OpenGL handle of the DRAW_INDIRECT_BUFFER
+	commands []DrawElementsIndirectCommand // Draw commands
+	buffer   math32.ArrayU32               // Draw commands data
+	update   bool                          // Update flag
+}
+
+// NewIndirectBuffer creates and returns a pointer to a new IndirectBuffer without commands.
+func NewIndirectBuffer() *IndirectBuffer {
+
+	return new(IndirectBuffer)
+}
+
+// AddCommand adds the specified draw command.
+func (ib *IndirectBuffer) AddCommand(cmd DrawElementsIndirectCommand) {
+
+	ib.commands = append(ib.commands, cmd)
+	ib.update = true
+}
+
+// SetCommands sets the draw commands.
+func (ib *IndirectBuffer) SetCommands(cmds []DrawElementsIndirectCommand) {
+
+	ib.commands = cmds
+	ib.update = true
+}
+
+// Commands returns the draw commands. The buffer must be updated if they are modified.
+func (ib *IndirectBuffer) Commands() []DrawElementsIndirectCommand {
+
+	return ib.commands
+}
+
+// Update sets the update flag so that the commands are transferred at the next draw.
+func (ib *IndirectBuffer) Update() {
+
+	ib.update = true
+}
+
+// Draw draws the primitives of the specified mode of all the commands from the bound
+// indices buffer of the specified type. Without MultiDrawElementsIndirect support, each
+// command is drawn with an instanced draw call whose base vertex and instance are zero,
+// or only its first instance is drawn if the instanced draws are not supported (see GL3Supported).
+func (ib *IndirectBuffer) Draw(gs *GLS, mode, itype uint32) {
+
+	if len(ib.commands) == 0 {
+		return
+	}
+	if !gs.MultiDrawIndirectSupported() {
+		isize := uint32(4)
+		switch itype {
+		case UNSIGNED_SHORT:
+			isize = 2
+		case UNSIGNED_BYTE:
+			isize = 1
+		}
+		for _, cmd := range ib.commands {
+			if cmd.InstanceCount == 0 {
+				continue
+			}
+			if gs.gl3 {
+				gs.DrawElementsInstanced(mode, int32(cmd.Count), itype, cmd.FirstIndex*isize, int32(cmd.InstanceCount))
+			} else {
+				gs.DrawElements(mode, int32(cmd.Count), itype, cmd.FirstIndex*isize)
+			}
+		}
+		return
+	}
+
+	// First time initialization
+	if ib.gs == nil {
+		ib.handle = gs.GenBuffer()
+		ib.gs = gs
+	}
+	gs.BindBuffer(DRAW_INDIRECT_BUFFER, ib.handle)
+	if ib.update {
+		ib.buffer = ib.buffer[:0]
+		for _, cmd := range ib.commands {
+			ib.buffer.Append(cmd.Count, cmd.InstanceCount, cmd.FirstIndex, uint32(cmd.BaseVertex), cmd.BaseInstance)
+		}
+		gs.BufferData(DRAW_INDIRECT_BUFFER, ib.buffer.Bytes(), ib.buffer, STATIC_DRAW)
+		ib.update = false
+	}
+	gs.MultiDrawElementsIndirect(mode, itype, 0, int32(len(ib.commands)), indirectCommandSize*4)
+}
+
+// Dispose deletes the OpenGL buffer of this IndirectBuffer.
+func (ib *IndirectBuffer) Dispose() {
+
+	if ib.gs != nil {
+		ib.gs.DeleteBuffers(ib.handle)
+	}
+	ib.gs = nil
+	ib.update = true
+}
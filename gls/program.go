@@ -22,6 +22,9 @@ type Program struct {
 	handle     uint32           // OpenGL program handle
 	shaders    []shaderInfo     // List of shaders for this program
 	uniforms   map[string]int32 // List of uniforms
+	unihits    uint64           // Number of uniform location cache hits
+	unimiss    uint64           // Number of uniform location cache misses
+	varyings   []string         // Vertex shader outputs captured by transform feedback
 }
 
 // shaderInfo contains OpenGL-related shader information.
@@ -69,6 +72,16 @@ func (prog *Program) AddShader(stype uint32, source string) {
 	prog.shaders = append(prog.shaders, shaderInfo{stype, source, 0})
 }
 
+// SetFeedbackVaryings sets the outputs of the vertex shader captured by transform
+// feedback, interleaved in a single buffer. This must be done before the program is built.
+func (prog *Program) SetFeedbackVaryings(varyings ...string) {
+
+	if prog.handle != 0 {
+		panic(fmt.Errorf("Program already built"))
+	}
+	prog.varyings = varyings
+}
+
 // DeleteShaders deletes all of this program's shaders from OpenGL.
 func (prog *Program) DeleteShaders() {
 
@@ -119,6 +132,9 @@ func (prog *Program) Build() error {
 	}
 
 	// Link program and check for errors
+	if len(prog.varyings) > 0 {
+		prog.gs.TransformFeedbackVaryings(prog.handle, prog.varyings, INTERLEAVED_ATTRIBS)
+	}
 	prog.gs.LinkProgram(prog.handle)
 	var status int32
 	prog.gs.GetProgramiv(prog.handle, LINK_STATUS, &status)
@@ -142,26 +158,67 @@ func (prog *Program) GetAttribLocation(name string) int32 {
 // This location is internally cached.
 func (prog *Program) GetUniformLocation(name string) int32 {
 
+	return prog.uniformLocation(name, true)
+}
+
+// uniformLocation returns the cached location of the specified uniform in this program,
+// warning the first time it is not found if warn is set.
+func (prog *Program) uniformLocation(name string, warn bool) int32 {
+
 	// Try to get from the cache
 	loc, ok := prog.uniforms[name]
 	if ok {
+		prog.unihits++
 		prog.gs.stats.UnilocHits++
 		return loc
 	}
 
 	// Get location from OpenGL
 	loc = prog.gs.GetUniformLocation(prog.handle, name)
+	prog.unimiss++
 	prog.gs.stats.UnilocMiss++
 
 	// Cache result
 	prog.uniforms[name] = loc
-	if loc < 0 {
+	if loc < 0 && warn {
 		fmt.Printf("WARNING : Program.GetUniformLocation(%s): NOT FOUND\n", name)
 	}
 
 	return loc
 }
 
+// PrewarmUniforms gets from OpenGL and caches the locations of the specified uniforms
+// which are not already cached, to avoid the cache misses when the program is first used.
+// It must be called after the program is built. Names not found in the program are
+// cached without warning. Indexed uniforms must be specified with their index as
+// used by Uniform.LocationIdx, for example "PointLight[3]".
+// The uniforms used by meshes with the standard and phong materials are:
+// "ModelMatrix", "ModelViewMatrix", "MVP", "NormalMatrix", "CameraPosition" and "Material",
+// for each texture i: "MatTexture[i]" ("MatTexture" for the first) and "MatTexinfo[3*i]",
+// and for each light i:
+// "AmbientLightColor[i]", "DirLight[2*i]", "PointLight[3*i]" and "SpotLight[5*i]".
+func (prog *Program) PrewarmUniforms(names []string) {
+
+	if prog.handle == 0 {
+		panic(fmt.Errorf("Program not built"))
+	}
+	for _, name := range names {
+		if _, ok := prog.uniforms[name]; ok {
+			continue
+		}
+		prog.uniforms[name] = prog.gs.GetUniformLocation(prog.handle, name)
+		prog.unimiss++
+		prog.gs.stats.UnilocMiss++
+	}
+}
+
+// UniformCacheStats returns the number of uniform location cache hits
+// and misses of this program.
+func (prog *Program) UniformCacheStats() (hits, misses uint64) {
+
+	return prog.unihits, prog.unimiss
+}
+
 // CompileShader creates and compiles an OpenGL shader of the specified type, with
 // the specified source code, and returns a non-zero value by which it can be referenced.
 func (prog *Program) CompileShader(stype uint32, source string) (uint32, error) {
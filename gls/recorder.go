@@ -0,0 +1,803 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+
+	gl "github.com/thommil/tge-gl"
+)
+
+// Recorder is a GL backend which records the sequence of OpenGL calls
+// of a GLS instead of executing them, so that the calls of a frame can be
+// compared in tests without an OpenGL context or replayed into another GLS.
+// The GLS recording its calls is created with NewWithBackend.
+//
+// The created objects get sequential handles starting from 1, the shaders
+// compile and the programs link successfully, the framebuffers are complete,
+// the locations of the attributes and uniforms are sequential per name and
+// the integer, float and string parameters are zero or empty unless set with
+// SetInteger, SetFloat and SetString.
+type Recorder struct {
+	calls     []Call              // Recorded calls
+	handles   uint32              // Last created object handle
+	integers  map[gl.Enum]int     // Values of the integer parameters
+	floats    map[gl.Enum]float32 // Values of the float parameters
+	strs      map[gl.Enum]string  // Values of the string parameters
+	locations map[string]int      // Attributes and uniforms locations
+}
+
+// Call is an OpenGL call recorded by a Recorder.
+type Call struct {
+	Name   string        // Name of the OpenGL function without the gl prefix
+	Args   []interface{} // Arguments, with the data pointed by pointers copied into slices
+	replay func(to GL)   // Calls the function of the specified backend
+}
+
+// NewRecorder creates and returns a pointer to a new Recorder without calls.
+func NewRecorder() *Recorder {
+
+	rec := new(Recorder)
+	rec.integers = make(map[gl.Enum]int)
+	rec.floats = make(map[gl.Enum]float32)
+	rec.strs = make(map[gl.Enum]string)
+	rec.locations = make(map[string]int)
+	return rec
+}
+
+// SetInteger sets the value returned for the specified integer parameter.
+func (rec *Recorder) SetInteger(pname uint32, value int) {
+
+	rec.integers[gl.Enum(pname)] = value
+}
+
+// SetFloat sets the value returned for the specified float parameter.
+func (rec *Recorder) SetFloat(pname uint32, value float32) {
+
+	rec.floats[gl.Enum(pname)] = value
+}
+
+// SetString sets the value returned for the specified string parameter, such as EXTENSIONS.
+func (rec *Recorder) SetString(pname uint32, value string) {
+
+	rec.strs[gl.Enum(pname)] = value
+}
+
+// Calls returns the recorded calls.
+func (rec *Recorder) Calls() []Call {
+
+	return rec.calls
+}
+
+// Names returns the names of the recorded functions.
+func (rec *Recorder) Names() []string {
+
+	names := make([]string, len(rec.calls))
+	for i, c := range rec.calls {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// Reset clears the recorded calls, such as at the start of a frame.
+// The created objects handles and the locations are kept.
+func (rec *Recorder) Reset() {
+
+	rec.calls = rec.calls[:0]
+}
+
+// Replay performs the recorded calls with the OpenGL backend of the
+// specified GLS. The calls are replayed with the recorded object handles,
+// which are the handles of a GLS of a Recorder with the same history.
+func (rec *Recorder) Replay(gs *GLS) {
+
+	for _, c := range rec.calls {
+		c.replay(gs.backend)
+	}
+}
+
+// String returns the recorded calls, one per line.
+func (rec *Recorder) String() string {
+
+	var sb strings.Builder
+	for _, c := range rec.calls {
+		sb.WriteString(c.String())
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// String returns the function name and the arguments of this call.
+// Byte slices are summarized by their length.
+func (c Call) String() string {
+
+	args := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		if data, ok := arg.([]byte); ok {
+			args[i] = fmt.Sprintf("[%d bytes]", len(data))
+		} else {
+			args[i] = fmt.Sprint(arg)
+		}
+	}
+	return c.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// record appends a call of the specified function.
+func (rec *Recorder) record(name string, replay func(to GL), args ...interface{}) {
+
+	rec.calls = append(rec.calls, Call{Name: name, Args: args, replay: replay})
+}
+
+// location returns the location of the specified attribute or uniform name.
+func (rec *Recorder) location(name string) int {
+
+	loc, ok := rec.locations[name]
+	if !ok {
+		loc = len(rec.locations)
+		rec.locations[name] = loc
+	}
+	return loc
+}
+
+// floats returns a copy of the specified number of floats at the specified address.
+func floats(p *float32, count int) []float32 {
+
+	data := make([]float32, count)
+	copy(data, (*[1 << 28]float32)(unsafe.Pointer(p))[:count:count])
+	return data
+}
+
+// ActiveTexture records a call of glActiveTexture.
+func (rec *Recorder) ActiveTexture(texture gl.Enum) {
+
+	rec.record("ActiveTexture", func(to GL) { to.ActiveTexture(texture) }, texture)
+}
+
+// AttachShader records a call of glAttachShader.
+func (rec *Recorder) AttachShader(p gl.Program, s gl.Shader) {
+
+	rec.record("AttachShader", func(to GL) { to.AttachShader(p, s) }, p, s)
+}
+
+// BeginTransformFeedback records a call of glBeginTransformFeedback.
+func (rec *Recorder) BeginTransformFeedback(mode gl.Enum) {
+
+	rec.record("BeginTransformFeedback", func(to GL) { to.BeginTransformFeedback(mode) }, mode)
+}
+
+// BindBuffer records a call of glBindBuffer.
+func (rec *Recorder) BindBuffer(target gl.Enum, b gl.Buffer) {
+
+	rec.record("BindBuffer", func(to GL) { to.BindBuffer(target, b) }, target, b)
+}
+
+// BindBufferBase records a call of glBindBufferBase.
+func (rec *Recorder) BindBufferBase(target gl.Enum, index int, b gl.Buffer) {
+
+	rec.record("BindBufferBase", func(to GL) { to.BindBufferBase(target, index, b) }, target, index, b)
+}
+
+// BindFramebuffer records a call of glBindFramebuffer.
+func (rec *Recorder) BindFramebuffer(target gl.Enum, fb gl.Framebuffer) {
+
+	rec.record("BindFramebuffer", func(to GL) { to.BindFramebuffer(target, fb) }, target, fb)
+}
+
+// BindRenderbuffer records a call of glBindRenderbuffer.
+func (rec *Recorder) BindRenderbuffer(target gl.Enum, rb gl.Renderbuffer) {
+
+	rec.record("BindRenderbuffer", func(to GL) { to.BindRenderbuffer(target, rb) }, target, rb)
+}
+
+// BindTexture records a call of glBindTexture.
+func (rec *Recorder) BindTexture(target gl.Enum, t gl.Texture) {
+
+	rec.record("BindTexture", func(to GL) { to.BindTexture(target, t) }, target, t)
+}
+
+// BindVertexArray records a call of glBindVertexArray.
+func (rec *Recorder) BindVertexArray(vao gl.VertexArray) {
+
+	rec.record("BindVertexArray", func(to GL) { to.BindVertexArray(vao) }, vao)
+}
+
+// BlitFramebuffer records a call of glBlitFramebuffer.
+func (rec *Recorder) BlitFramebuffer(srcX0, srcY0, srcX1, srcY1, dstX0, dstY0, dstX1, dstY1 int, mask, filter gl.Enum) {
+
+	rec.record("BlitFramebuffer", func(to GL) { to.BlitFramebuffer(srcX0, srcY0, srcX1, srcY1, dstX0, dstY0, dstX1, dstY1, mask, filter) }, srcX0, srcY0, srcX1, srcY1, dstX0, dstY0, dstX1, dstY1, mask, filter)
+}
+
+// BlendEquation records a call of glBlendEquation.
+func (rec *Recorder) BlendEquation(mode gl.Enum) {
+
+	rec.record("BlendEquation", func(to GL) { to.BlendEquation(mode) }, mode)
+}
+
+// BlendEquationSeparate records a call of glBlendEquationSeparate.
+func (rec *Recorder) BlendEquationSeparate(modeRGB, modeAlpha gl.Enum) {
+
+	rec.record("BlendEquationSeparate", func(to GL) { to.BlendEquationSeparate(modeRGB, modeAlpha) }, modeRGB, modeAlpha)
+}
+
+// BlendFunc records a call of glBlendFunc.
+func (rec *Recorder) BlendFunc(sfactor, dfactor gl.Enum) {
+
+	rec.record("BlendFunc", func(to GL) { to.BlendFunc(sfactor, dfactor) }, sfactor, dfactor)
+}
+
+// BlendFuncSeparate records a call of glBlendFuncSeparate.
+func (rec *Recorder) BlendFuncSeparate(sfactorRGB, dfactorRGB, sfactorAlpha, dfactorAlpha gl.Enum) {
+
+	rec.record("BlendFuncSeparate", func(to GL) { to.BlendFuncSeparate(sfactorRGB, dfactorRGB, sfactorAlpha, dfactorAlpha) }, sfactorRGB, dfactorRGB, sfactorAlpha, dfactorAlpha)
+}
+
+// BufferData records a call of glBufferData.
+func (rec *Recorder) BufferData(target gl.Enum, src []byte, usage gl.Enum) {
+
+	src = append([]byte(nil), src...)
+	rec.record("BufferData", func(to GL) { to.BufferData(target, src, usage) }, target, src, usage)
+}
+
+// BufferSubData records a call of glBufferSubData.
+func (rec *Recorder) BufferSubData(target gl.Enum, offset int, data []byte) {
+
+	data = append([]byte(nil), data...)
+	rec.record("BufferSubData", func(to GL) { to.BufferSubData(target, offset, data) }, target, offset, data)
+}
+
+// CheckFramebufferStatus records a call of glCheckFramebufferStatus.
+func (rec *Recorder) CheckFramebufferStatus(target gl.Enum) gl.Enum {
+
+	rec.record("CheckFramebufferStatus", func(to GL) { to.CheckFramebufferStatus(target) }, target)
+	return FRAMEBUFFER_COMPLETE
+}
+
+// Clear records a call of glClear.
+func (rec *Recorder) Clear(mask gl.Enum) {
+
+	rec.record("Clear", func(to GL) { to.Clear(mask) }, mask)
+}
+
+// ClearColor records a call of glClearColor.
+func (rec *Recorder) ClearColor(red, green, blue, alpha float32) {
+
+	rec.record("ClearColor", func(to GL) { to.ClearColor(red, green, blue, alpha) }, red, green, blue, alpha)
+}
+
+// ClearStencil records a call of glClearStencil.
+func (rec *Recorder) ClearStencil(s int) {
+
+	rec.record("ClearStencil", func(to GL) { to.ClearStencil(s) }, s)
+}
+
+// CompileShader records a call of glCompileShader.
+func (rec *Recorder) CompileShader(s gl.Shader) {
+
+	rec.record("CompileShader", func(to GL) { to.CompileShader(s) }, s)
+}
+
+// CompressedTexImage2D records a call of glCompressedTexImage2D.
+func (rec *Recorder) CompressedTexImage2D(target gl.Enum, level int, iformat gl.Enum, width, height, border int, data []byte) {
+
+	rec.record("CompressedTexImage2D", func(to GL) { to.CompressedTexImage2D(target, level, iformat, width, height, border, data) }, target, level, iformat, width, height, border, data)
+}
+
+// CreateBuffer records a call of glCreateBuffer.
+func (rec *Recorder) CreateBuffer() gl.Buffer {
+
+	rec.handles++
+	rec.record("CreateBuffer", func(to GL) { to.CreateBuffer() })
+	return gl.Buffer(rec.handles)
+}
+
+// CreateFramebuffer records a call of glCreateFramebuffer.
+func (rec *Recorder) CreateFramebuffer() gl.Framebuffer {
+
+	rec.handles++
+	rec.record("CreateFramebuffer", func(to GL) { to.CreateFramebuffer() })
+	return gl.Framebuffer(rec.handles)
+}
+
+// CreateProgram records a call of glCreateProgram.
+func (rec *Recorder) CreateProgram() gl.Program {
+
+	rec.handles++
+	rec.record("CreateProgram", func(to GL) { to.CreateProgram() })
+	return gl.Program(rec.handles)
+}
+
+// CreateRenderbuffer records a call of glCreateRenderbuffer.
+func (rec *Recorder) CreateRenderbuffer() gl.Renderbuffer {
+
+	rec.handles++
+	rec.record("CreateRenderbuffer", func(to GL) { to.CreateRenderbuffer() })
+	return gl.Renderbuffer(rec.handles)
+}
+
+// CreateShader records a call of glCreateShader.
+func (rec *Recorder) CreateShader(ty gl.Enum) gl.Shader {
+
+	rec.handles++
+	rec.record("CreateShader", func(to GL) { to.CreateShader(ty) }, ty)
+	return gl.Shader(rec.handles)
+}
+
+// CreateTexture records a call of glCreateTexture.
+func (rec *Recorder) CreateTexture() gl.Texture {
+
+	rec.handles++
+	rec.record("CreateTexture", func(to GL) { to.CreateTexture() })
+	return gl.Texture(rec.handles)
+}
+
+// CreateVertexArray records a call of glCreateVertexArray.
+func (rec *Recorder) CreateVertexArray() gl.VertexArray {
+
+	rec.handles++
+	rec.record("CreateVertexArray", func(to GL) { to.CreateVertexArray() })
+	return gl.VertexArray(rec.handles)
+}
+
+// CullFace records a call of glCullFace.
+func (rec *Recorder) CullFace(mode gl.Enum) {
+
+	rec.record("CullFace", func(to GL) { to.CullFace(mode) }, mode)
+}
+
+// DeleteBuffer records a call of glDeleteBuffer.
+func (rec *Recorder) DeleteBuffer(v gl.Buffer) {
+
+	rec.record("DeleteBuffer", func(to GL) { to.DeleteBuffer(v) }, v)
+}
+
+// DeleteFramebuffer records a call of glDeleteFramebuffer.
+func (rec *Recorder) DeleteFramebuffer(v gl.Framebuffer) {
+
+	rec.record("DeleteFramebuffer", func(to GL) { to.DeleteFramebuffer(v) }, v)
+}
+
+// DeleteProgram records a call of glDeleteProgram.
+func (rec *Recorder) DeleteProgram(p gl.Program) {
+
+	rec.record("DeleteProgram", func(to GL) { to.DeleteProgram(p) }, p)
+}
+
+// DeleteRenderbuffer records a call of glDeleteRenderbuffer.
+func (rec *Recorder) DeleteRenderbuffer(v gl.Renderbuffer) {
+
+	rec.record("DeleteRenderbuffer", func(to GL) { to.DeleteRenderbuffer(v) }, v)
+}
+
+// DeleteShader records a call of glDeleteShader.
+func (rec *Recorder) DeleteShader(s gl.Shader) {
+
+	rec.record("DeleteShader", func(to GL) { to.DeleteShader(s) }, s)
+}
+
+// DeleteTexture records a call of glDeleteTexture.
+func (rec *Recorder) DeleteTexture(v gl.Texture) {
+
+	rec.record("DeleteTexture", func(to GL) { to.DeleteTexture(v) }, v)
+}
+
+// DeleteVertexArray records a call of glDeleteVertexArray.
+func (rec *Recorder) DeleteVertexArray(v gl.VertexArray) {
+
+	rec.record("DeleteVertexArray", func(to GL) { to.DeleteVertexArray(v) }, v)
+}
+
+// DepthFunc records a call of glDepthFunc.
+func (rec *Recorder) DepthFunc(fn gl.Enum) {
+
+	rec.record("DepthFunc", func(to GL) { to.DepthFunc(fn) }, fn)
+}
+
+// DepthMask records a call of glDepthMask.
+func (rec *Recorder) DepthMask(flag bool) {
+
+	rec.record("DepthMask", func(to GL) { to.DepthMask(flag) }, flag)
+}
+
+// Disable records a call of glDisable.
+func (rec *Recorder) Disable(cap gl.Enum) {
+
+	rec.record("Disable", func(to GL) { to.Disable(cap) }, cap)
+}
+
+// DrawArrays records a call of glDrawArrays.
+func (rec *Recorder) DrawArrays(mode gl.Enum, first, count int) {
+
+	rec.record("DrawArrays", func(to GL) { to.DrawArrays(mode, first, count) }, mode, first, count)
+}
+
+// DrawArraysInstanced records a call of glDrawArraysInstanced.
+func (rec *Recorder) DrawArraysInstanced(m gl.Enum, first, count, inst int) {
+
+	rec.record("DrawArraysInstanced", func(to GL) { to.DrawArraysInstanced(m, first, count, inst) }, m, first, count, inst)
+}
+
+// DrawBuffers records a call of glDrawBuffers.
+func (rec *Recorder) DrawBuffers(bufs []gl.Enum) {
+
+	rec.record("DrawBuffers", func(to GL) { to.DrawBuffers(bufs) }, bufs)
+}
+
+// DrawElements records a call of glDrawElements.
+func (rec *Recorder) DrawElements(mode gl.Enum, count int, ty gl.Enum, offset int) {
+
+	rec.record("DrawElements", func(to GL) { to.DrawElements(mode, count, ty, offset) }, mode, count, ty, offset)
+}
+
+// DrawElementsInstanced records a call of glDrawElementsInstanced.
+func (rec *Recorder) DrawElementsInstanced(m gl.Enum, count int, ty gl.Enum, off, inst int) {
+
+	rec.record("DrawElementsInstanced", func(to GL) { to.DrawElementsInstanced(m, count, ty, off, inst) }, m, count, ty, off, inst)
+}
+
+// Enable records a call of glEnable.
+func (rec *Recorder) Enable(cap gl.Enum) {
+
+	rec.record("Enable", func(to GL) { to.Enable(cap) }, cap)
+}
+
+// EnableVertexAttribArray records a call of glEnableVertexAttribArray.
+func (rec *Recorder) EnableVertexAttribArray(a gl.Attrib) {
+
+	rec.record("EnableVertexAttribArray", func(to GL) { to.EnableVertexAttribArray(a) }, a)
+}
+
+// EndTransformFeedback records a call of glEndTransformFeedback.
+func (rec *Recorder) EndTransformFeedback() {
+
+	rec.record("EndTransformFeedback", func(to GL) { to.EndTransformFeedback() })
+}
+
+// FramebufferRenderbuffer records a call of glFramebufferRenderbuffer.
+func (rec *Recorder) FramebufferRenderbuffer(target, attachment, rbTarget gl.Enum, rb gl.Renderbuffer) {
+
+	rec.record("FramebufferRenderbuffer", func(to GL) { to.FramebufferRenderbuffer(target, attachment, rbTarget, rb) }, target, attachment, rbTarget, rb)
+}
+
+// FramebufferTexture2D records a call of glFramebufferTexture2D.
+func (rec *Recorder) FramebufferTexture2D(target, attachment, texTarget gl.Enum, t gl.Texture, level int) {
+
+	rec.record("FramebufferTexture2D", func(to GL) { to.FramebufferTexture2D(target, attachment, texTarget, t, level) }, target, attachment, texTarget, t, level)
+}
+
+// FramebufferTextureLayer records a call of glFramebufferTextureLayer.
+func (rec *Recorder) FramebufferTextureLayer(target, attachment gl.Enum, t gl.Texture, level, layer int) {
+
+	rec.record("FramebufferTextureLayer", func(to GL) { to.FramebufferTextureLayer(target, attachment, t, level, layer) }, target, attachment, t, level, layer)
+}
+
+// FrontFace records a call of glFrontFace.
+func (rec *Recorder) FrontFace(mode gl.Enum) {
+
+	rec.record("FrontFace", func(to GL) { to.FrontFace(mode) }, mode)
+}
+
+// GenerateMipmap records a call of glGenerateMipmap.
+func (rec *Recorder) GenerateMipmap(target gl.Enum) {
+
+	rec.record("GenerateMipmap", func(to GL) { to.GenerateMipmap(target) }, target)
+}
+
+// GetAttribLocation records a call of glGetAttribLocation.
+func (rec *Recorder) GetAttribLocation(p gl.Program, name string) gl.Attrib {
+
+	rec.record("GetAttribLocation", func(to GL) { to.GetAttribLocation(p, name) }, p, name)
+	return gl.Attrib(rec.location(name))
+}
+
+// GetBufferSubData records a call of glGetBufferSubData.
+func (rec *Recorder) GetBufferSubData(target gl.Enum, offset int, data []byte) {
+
+	rec.record("GetBufferSubData", func(to GL) { to.GetBufferSubData(target, offset, data) }, target, offset, len(data))
+}
+
+// GetFloat records a call of glGetFloat.
+func (rec *Recorder) GetFloat(pname gl.Enum) float32 {
+
+	rec.record("GetFloat", func(to GL) { to.GetFloat(pname) }, pname)
+	return rec.floats[pname]
+}
+
+// GetInteger records a call of glGetInteger.
+func (rec *Recorder) GetInteger(pname gl.Enum) int {
+
+	rec.record("GetInteger", func(to GL) { to.GetInteger(pname) }, pname)
+	return rec.integers[pname]
+}
+
+// GetProgramInfoLog records a call of glGetProgramInfoLog.
+func (rec *Recorder) GetProgramInfoLog(p gl.Program) string {
+
+	rec.record("GetProgramInfoLog", func(to GL) { to.GetProgramInfoLog(p) }, p)
+	return ""
+}
+
+// GetProgrami records a call of glGetProgrami.
+func (rec *Recorder) GetProgrami(p gl.Program, pname gl.Enum) int {
+
+	rec.record("GetProgrami", func(to GL) { to.GetProgrami(p, pname) }, p, pname)
+	return TRUE
+}
+
+// GetShaderInfoLog records a call of glGetShaderInfoLog.
+func (rec *Recorder) GetShaderInfoLog(s gl.Shader) string {
+
+	rec.record("GetShaderInfoLog", func(to GL) { to.GetShaderInfoLog(s) }, s)
+	return ""
+}
+
+// GetShaderi records a call of glGetShaderi.
+func (rec *Recorder) GetShaderi(s gl.Shader, pname gl.Enum) int {
+
+	rec.record("GetShaderi", func(to GL) { to.GetShaderi(s, pname) }, s, pname)
+	return TRUE
+}
+
+// GetString records a call of glGetString.
+func (rec *Recorder) GetString(pname gl.Enum) string {
+
+	rec.record("GetString", func(to GL) { to.GetString(pname) }, pname)
+	return rec.strs[pname]
+}
+
+// GetUniformLocation records a call of glGetUniformLocation.
+func (rec *Recorder) GetUniformLocation(p gl.Program, name string) gl.Uniform {
+
+	rec.record("GetUniformLocation", func(to GL) { to.GetUniformLocation(p, name) }, p, name)
+	return gl.Uniform(rec.location(name))
+}
+
+// IsEnabled records a call of glIsEnabled.
+func (rec *Recorder) IsEnabled(cap gl.Enum) bool {
+
+	rec.record("IsEnabled", func(to GL) { to.IsEnabled(cap) }, cap)
+	return false
+}
+
+// LineWidth records a call of glLineWidth.
+func (rec *Recorder) LineWidth(width float32) {
+
+	rec.record("LineWidth", func(to GL) { to.LineWidth(width) }, width)
+}
+
+// LinkProgram records a call of glLinkProgram.
+func (rec *Recorder) LinkProgram(p gl.Program) {
+
+	rec.record("LinkProgram", func(to GL) { to.LinkProgram(p) }, p)
+}
+
+// MultiDrawElementsIndirect records a call of glMultiDrawElementsIndirect.
+func (rec *Recorder) MultiDrawElementsIndirect(m, ty gl.Enum, indirect, drawcount, stride int) {
+
+	rec.record("MultiDrawElementsIndirect", func(to GL) { to.MultiDrawElementsIndirect(m, ty, indirect, drawcount, stride) }, m, ty, indirect, drawcount, stride)
+}
+
+// PolygonMode records a call of glPolygonMode.
+func (rec *Recorder) PolygonMode(face, mode gl.Enum) {
+
+	rec.record("PolygonMode", func(to GL) { to.PolygonMode(face, mode) }, face, mode)
+}
+
+// PolygonOffset records a call of glPolygonOffset.
+func (rec *Recorder) PolygonOffset(factor, units float32) {
+
+	rec.record("PolygonOffset", func(to GL) { to.PolygonOffset(factor, units) }, factor, units)
+}
+
+// PrimitiveRestartIndex records a call of glPrimitiveRestartIndex.
+func (rec *Recorder) PrimitiveRestartIndex(index uint32) {
+
+	rec.record("PrimitiveRestartIndex", func(to GL) { to.PrimitiveRestartIndex(index) }, index)
+}
+
+// ReadBuffer records a call of glReadBuffer.
+func (rec *Recorder) ReadBuffer(src gl.Enum) {
+
+	rec.record("ReadBuffer", func(to GL) { to.ReadBuffer(src) }, src)
+}
+
+// RenderbufferStorage records a call of glRenderbufferStorage.
+func (rec *Recorder) RenderbufferStorage(target, internalFormat gl.Enum, width, height int) {
+
+	rec.record("RenderbufferStorage", func(to GL) { to.RenderbufferStorage(target, internalFormat, width, height) }, target, internalFormat, width, height)
+}
+
+// RenderbufferStorageMultisample records a call of glRenderbufferStorageMultisample.
+func (rec *Recorder) RenderbufferStorageMultisample(target gl.Enum, samples int, internalFormat gl.Enum, width, height int) {
+
+	rec.record("RenderbufferStorageMultisample", func(to GL) { to.RenderbufferStorageMultisample(target, samples, internalFormat, width, height) }, target, samples, internalFormat, width, height)
+}
+
+// Scissor records a call of glScissor.
+func (rec *Recorder) Scissor(x, y, width, height int32) {
+
+	rec.record("Scissor", func(to GL) { to.Scissor(x, y, width, height) }, x, y, width, height)
+}
+
+// ShaderSource records a call of glShaderSource.
+func (rec *Recorder) ShaderSource(s gl.Shader, src string) {
+
+	rec.record("ShaderSource", func(to GL) { to.ShaderSource(s, src) }, s, src)
+}
+
+// StencilFunc records a call of glStencilFunc.
+func (rec *Recorder) StencilFunc(fn gl.Enum, ref int, mask uint32) {
+
+	rec.record("StencilFunc", func(to GL) { to.StencilFunc(fn, ref, mask) }, fn, ref, mask)
+}
+
+// StencilMask records a call of glStencilMask.
+func (rec *Recorder) StencilMask(mask uint32) {
+
+	rec.record("StencilMask", func(to GL) { to.StencilMask(mask) }, mask)
+}
+
+// StencilOp records a call of glStencilOp.
+func (rec *Recorder) StencilOp(fail, zfail, zpass gl.Enum) {
+
+	rec.record("StencilOp", func(to GL) { to.StencilOp(fail, zfail, zpass) }, fail, zfail, zpass)
+}
+
+// TexImage2D records a call of glTexImage2D.
+func (rec *Recorder) TexImage2D(target gl.Enum, level, width, height int, format, ty gl.Enum, data []byte) {
+
+	data = append([]byte(nil), data...)
+	rec.record("TexImage2D", func(to GL) { to.TexImage2D(target, level, width, height, format, ty, data) }, target, level, width, height, format, ty, data)
+}
+
+// TexImage3D records a call of glTexImage3D.
+func (rec *Recorder) TexImage3D(target gl.Enum, level int, iformat gl.Enum, width, height, depth int, format, ty gl.Enum, data []byte) {
+
+	data = append([]byte(nil), data...)
+	rec.record("TexImage3D", func(to GL) { to.TexImage3D(target, level, iformat, width, height, depth, format, ty, data) }, target, level, iformat, width, height, depth, format, ty, data)
+}
+
+// TexParameterf records a call of glTexParameterf.
+func (rec *Recorder) TexParameterf(target, pname gl.Enum, param float32) {
+
+	rec.record("TexParameterf", func(to GL) { to.TexParameterf(target, pname, param) }, target, pname, param)
+}
+
+// TexParameteri records a call of glTexParameteri.
+func (rec *Recorder) TexParameteri(target, pname gl.Enum, param int) {
+
+	rec.record("TexParameteri", func(to GL) { to.TexParameteri(target, pname, param) }, target, pname, param)
+}
+
+// TransformFeedbackVaryings records a call of glTransformFeedbackVaryings.
+func (rec *Recorder) TransformFeedbackVaryings(p gl.Program, varyings []string, mode gl.Enum) {
+
+	varyings = append([]string(nil), varyings...)
+	rec.record("TransformFeedbackVaryings", func(to GL) { to.TransformFeedbackVaryings(p, varyings, mode) }, p, varyings, mode)
+}
+
+// Uniform1f records a call of glUniform1f.
+func (rec *Recorder) Uniform1f(dst gl.Uniform, v float32) {
+
+	rec.record("Uniform1f", func(to GL) { to.Uniform1f(dst, v) }, dst, v)
+}
+
+// Uniform1fv records a call of glUniform1fv.
+func (rec *Recorder) Uniform1fv(dst gl.Uniform, src []float32) {
+
+	src = append([]float32(nil), src...)
+	rec.record("Uniform1fv", func(to GL) { to.Uniform1fv(dst, src) }, dst, src)
+}
+
+// Uniform1i records a call of glUniform1i.
+func (rec *Recorder) Uniform1i(dst gl.Uniform, v int) {
+
+	rec.record("Uniform1i", func(to GL) { to.Uniform1i(dst, v) }, dst, v)
+}
+
+// Uniform2f records a call of glUniform2f.
+func (rec *Recorder) Uniform2f(dst gl.Uniform, v0, v1 float32) {
+
+	rec.record("Uniform2f", func(to GL) { to.Uniform2f(dst, v0, v1) }, dst, v0, v1)
+}
+
+// Uniform2fvP records a call of glUniform2fvP.
+func (rec *Recorder) Uniform2fvP(dst gl.Uniform, count int32, value *float32) {
+
+	data := floats(value, 2*int(count))
+	rec.record("Uniform2fvP", func(to GL) { to.Uniform2fvP(dst, count, &data[0]) }, dst, count, data)
+}
+
+// Uniform2fvUP records a call of glUniform2fvUP.
+func (rec *Recorder) Uniform2fvUP(dst gl.Uniform, count int32, value unsafe.Pointer) {
+
+	data := floats((*float32)(value), 2*int(count))
+	rec.record("Uniform2fvUP", func(to GL) { to.Uniform2fvUP(dst, count, unsafe.Pointer(&data[0])) }, dst, count, data)
+}
+
+// Uniform3f records a call of glUniform3f.
+func (rec *Recorder) Uniform3f(dst gl.Uniform, v0, v1, v2 float32) {
+
+	rec.record("Uniform3f", func(to GL) { to.Uniform3f(dst, v0, v1, v2) }, dst, v0, v1, v2)
+}
+
+// Uniform3fvP records a call of glUniform3fvP.
+func (rec *Recorder) Uniform3fvP(dst gl.Uniform, count int32, value *float32) {
+
+	data := floats(value, 3*int(count))
+	rec.record("Uniform3fvP", func(to GL) { to.Uniform3fvP(dst, count, &data[0]) }, dst, count, data)
+}
+
+// Uniform3fvUP records a call of glUniform3fvUP.
+func (rec *Recorder) Uniform3fvUP(dst gl.Uniform, count int32, value unsafe.Pointer) {
+
+	data := floats((*float32)(value), 3*int(count))
+	rec.record("Uniform3fvUP", func(to GL) { to.Uniform3fvUP(dst, count, unsafe.Pointer(&data[0])) }, dst, count, data)
+}
+
+// Uniform4f records a call of glUniform4f.
+func (rec *Recorder) Uniform4f(dst gl.Uniform, v0, v1, v2, v3 float32) {
+
+	rec.record("Uniform4f", func(to GL) { to.Uniform4f(dst, v0, v1, v2, v3) }, dst, v0, v1, v2, v3)
+}
+
+// Uniform4fv records a call of glUniform4fv.
+func (rec *Recorder) Uniform4fv(dst gl.Uniform, src []float32) {
+
+	src = append([]float32(nil), src...)
+	rec.record("Uniform4fv", func(to GL) { to.Uniform4fv(dst, src) }, dst, src)
+}
+
+// Uniform4fvUP records a call of glUniform4fvUP.
+func (rec *Recorder) Uniform4fvUP(dst gl.Uniform, count int32, value unsafe.Pointer) {
+
+	data := floats((*float32)(value), 4*int(count))
+	rec.record("Uniform4fvUP", func(to GL) { to.Uniform4fvUP(dst, count, unsafe.Pointer(&data[0])) }, dst, count, data)
+}
+
+// UniformMatrix3fvP records a call of glUniformMatrix3fvP.
+func (rec *Recorder) UniformMatrix3fvP(dst gl.Uniform, count int32, transpose bool, value *float32) {
+
+	data := floats(value, 9*int(count))
+	rec.record("UniformMatrix3fvP", func(to GL) { to.UniformMatrix3fvP(dst, count, transpose, &data[0]) }, dst, count, transpose, data)
+}
+
+// UniformMatrix4fvP records a call of glUniformMatrix4fvP.
+func (rec *Recorder) UniformMatrix4fvP(dst gl.Uniform, count int32, transpose bool, value *float32) {
+
+	data := floats(value, 16*int(count))
+	rec.record("UniformMatrix4fvP", func(to GL) { to.UniformMatrix4fvP(dst, count, transpose, &data[0]) }, dst, count, transpose, data)
+}
+
+// UseProgram records a call of glUseProgram.
+func (rec *Recorder) UseProgram(p gl.Program) {
+
+	rec.record("UseProgram", func(to GL) { to.UseProgram(p) }, p)
+}
+
+// VertexAttribDivisor records a call of glVertexAttribDivisor.
+func (rec *Recorder) VertexAttribDivisor(a gl.Attrib, d int) {
+
+	rec.record("VertexAttribDivisor", func(to GL) { to.VertexAttribDivisor(a, d) }, a, d)
+}
+
+// VertexAttribIPointer records a call of glVertexAttribIPointer.
+func (rec *Recorder) VertexAttribIPointer(a gl.Attrib, s int, t gl.Enum, st, o int) {
+
+	rec.record("VertexAttribIPointer", func(to GL) { to.VertexAttribIPointer(a, s, t, st, o) }, a, s, t, st, o)
+}
+
+// VertexAttribPointer records a call of glVertexAttribPointer.
+func (rec *Recorder) VertexAttribPointer(dst gl.Attrib, size int, ty gl.Enum, normalized bool, stride, offset int) {
+
+	rec.record("VertexAttribPointer", func(to GL) { to.VertexAttribPointer(dst, size, ty, normalized, stride, offset) }, dst, size, ty, normalized, stride, offset)
+}
+
+// Viewport records a call of glViewport.
+func (rec *Recorder) Viewport(x, y, width, height int) {
+
+	rec.record("Viewport", func(to GL) { to.Viewport(x, y, width, height) }, x, y, width, height)
+}
@@ -0,0 +1,37 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+import (
+	"testing"
+)
+
+// Test the recording of a frame and its replay into another recorder
+func TestRecorder(t *testing.T) {
+
+	rec := NewRecorder()
+	gs, err := NewWithBackend(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+	gs.BlendFunc(ONE, ONE)
+	gs.BlendFunc(ONE, ONE)
+	vao := gs.GenVertexArray()
+	gs.BindVertexArray(vao)
+	gs.DrawArrays(TRIANGLES, 0, 3)
+	expected := "BlendFunc(1, 1)\nCreateVertexArray()\nBindVertexArray(1)\nDrawArrays(4, 0, 3)\n"
+	if rec.String() != expected {
+		t.Fatalf("recorded calls:\n%s", rec)
+	}
+
+	replay := NewRecorder()
+	rgs, _ := NewWithBackend(replay)
+	replay.Reset()
+	rec.Replay(rgs)
+	if replay.String() != expected {
+		t.Errorf("replayed calls:\n%s", replay)
+	}
+}
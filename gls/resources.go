@@ -0,0 +1,104 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sort"
+)
+
+// Kinds of the tracked OpenGL resources.
+const (
+	allocBuffer = iota
+	allocFramebuffer
+	allocTexture
+	allocVertexArray
+)
+
+// allocNames are the names of the kinds of tracked OpenGL resources.
+var allocNames = [...]string{"buffer", "framebuffer", "texture", "vertex array"}
+
+// SetTrackAllocations sets whether the call stack of each allocation of a buffer,
+// framebuffer, texture or vertex array is recorded until the resource is deleted,
+// so that Dispose reports where the leaked resources were allocated.
+// It is enabled by default in debug builds, built with the debug tag.
+func (gs *GLS) SetTrackAllocations(state bool) {
+
+	if !state {
+		gs.allocs = nil
+	} else if gs.allocs == nil {
+		gs.allocs = make(map[uint64]string)
+	}
+}
+
+// TrackAllocations returns whether the allocations call stacks are recorded.
+func (gs *GLS) TrackAllocations() bool {
+
+	return gs.allocs != nil
+}
+
+// ResourceLeaks returns the statistics of the resources allocated and not yet deleted:
+// the vertex arrays, buffers, textures, texture memory and framebuffers.
+// The other statistics are zero.
+func (gs *GLS) ResourceLeaks() Stats {
+
+	return Stats{
+		Vaos:         gs.stats.Vaos,
+		Buffers:      gs.stats.Buffers,
+		Textures:     gs.stats.Textures,
+		TextureBytes: gs.stats.TextureBytes,
+		Fbos:         gs.stats.Fbos,
+	}
+}
+
+// Dispose deletes the shader programs of this OpenGL state and reports the
+// resources which were not deleted by their owners, with the call stacks of their
+// allocations if tracked. In debug builds, built with the debug tag, it panics on leaks.
+// It should be called once the scene and the renderer resources are disposed.
+// The queued objects are deleted first.
+func (gs *GLS) Dispose() {
+
+	gs.ProcessPendingDeletes()
+	for prog := range gs.programs {
+		gs.DeleteProgram(prog.Handle())
+		delete(gs.programs, prog)
+	}
+	gs.prog = nil
+
+	leaks := gs.ResourceLeaks()
+	if leaks.Vaos == 0 && leaks.Buffers == 0 && leaks.Textures == 0 && leaks.Fbos == 0 {
+		return
+	}
+	fmt.Printf("WARNING : GLS leaked %d vertex arrays, %d buffers, %d textures and %d framebuffers\n",
+		leaks.Vaos, leaks.Buffers, leaks.Textures, leaks.Fbos)
+	keys := make([]uint64, 0, len(gs.allocs))
+	for key := range gs.allocs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for _, key := range keys {
+		fmt.Printf("WARNING : Leaked %s %d allocated at:\n%s\n", allocNames[key>>32], uint32(key), gs.allocs[key])
+	}
+	if debugBuild {
+		panic("GLS.Dispose: resources leaked")
+	}
+}
+
+// track records the call stack of the allocation of the specified resource if tracked.
+func (gs *GLS) track(kind int, handle uint32) {
+
+	if gs.allocs != nil {
+		gs.allocs[uint64(kind)<<32|uint64(handle)] = string(debug.Stack())
+	}
+}
+
+// untrack removes the record of the allocation of the specified deleted resource.
+func (gs *GLS) untrack(kind int, handle uint32) {
+
+	if gs.allocs != nil {
+		delete(gs.allocs, uint64(kind)<<32|uint64(handle))
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+// stateBlock is a snapshot of the cached OpenGL state saved by PushState.
+type stateBlock struct {
+	caps                map[int]int // Cached state of the saved capabilities
+	sideView            int
+	frontFace           uint32
+	depthFunc           uint32
+	depthMask           int
+	lineWidth           float32
+	blendEquation       uint32
+	blendSrc            uint32
+	blendDst            uint32
+	blendEquationRGB    uint32
+	blendEquationAlpha  uint32
+	blendSrcRGB         uint32
+	blendSrcAlpha       uint32
+	blendDstRGB         uint32
+	blendDstAlpha       uint32
+	polygonModeFace     uint32
+	polygonModeMode     uint32
+	polygonOffsetFactor float32
+	polygonOffsetUnits  float32
+	stencilFunc         uint32
+	stencilRef          int32
+	stencilFuncMask     uint32
+	stencilOps          [3]uint32
+	stencilMask         uint32
+}
+
+// PushState saves the cached state of the specified capabilities and of the
+// cached setters (side view, front face, depth, line width, blending, polygon mode
+// and offset, stencil) so that PopState restores them. It does not query OpenGL,
+// so the state which was never set through this GLS is not saved.
+// Calls of PushState and PopState can be nested.
+func (gs *GLS) PushState(caps ...int) {
+
+	sb := stateBlock{
+		caps:                make(map[int]int, len(caps)),
+		sideView:            gs.sideView,
+		frontFace:           gs.frontFace,
+		depthFunc:           gs.depthFunc,
+		depthMask:           gs.depthMask,
+		lineWidth:           gs.lineWidth,
+		blendEquation:       gs.blendEquation,
+		blendSrc:            gs.blendSrc,
+		blendDst:            gs.blendDst,
+		blendEquationRGB:    gs.blendEquationRGB,
+		blendEquationAlpha:  gs.blendEquationAlpha,
+		blendSrcRGB:         gs.blendSrcRGB,
+		blendSrcAlpha:       gs.blendSrcAlpha,
+		blendDstRGB:         gs.blendDstRGB,
+		blendDstAlpha:       gs.blendDstAlpha,
+		polygonModeFace:     gs.polygonModeFace,
+		polygonModeMode:     gs.polygonModeMode,
+		polygonOffsetFactor: gs.polygonOffsetFactor,
+		polygonOffsetUnits:  gs.polygonOffsetUnits,
+		stencilFunc:         gs.stencilFunc,
+		stencilRef:          gs.stencilRef,
+		stencilFuncMask:     gs.stencilFuncMask,
+		stencilOps:          gs.stencilOps,
+		stencilMask:         gs.stencilMask,
+	}
+	for _, cap := range caps {
+		sb.caps[cap] = gs.capabilities[cap]
+	}
+	gs.states = append(gs.states, sb)
+}
+
+// PopState restores the state saved by the last call of PushState.
+// Only the state which changed since is set, using the cached setters.
+func (gs *GLS) PopState() {
+
+	if len(gs.states) == 0 {
+		panic("PopState: no saved state")
+	}
+	sb := &gs.states[len(gs.states)-1]
+	gs.states = gs.states[:len(gs.states)-1]
+
+	// Side view first as it enables or disables CULL_FACE
+	if sb.sideView != uintUndef {
+		gs.SetSideView(sb.sideView)
+	}
+	if sb.frontFace != 0 {
+		gs.FrontFace(sb.frontFace)
+	}
+	if sb.depthFunc != 0 {
+		gs.DepthFunc(sb.depthFunc)
+	}
+	if sb.depthMask != uintUndef {
+		gs.DepthMask(sb.depthMask == intTrue)
+	}
+	if sb.lineWidth != 0 {
+		gs.LineWidth(sb.lineWidth)
+	}
+	if sb.blendEquation != uintUndef {
+		gs.BlendEquation(sb.blendEquation)
+	}
+	if sb.blendSrc != uintUndef {
+		gs.BlendFunc(sb.blendSrc, sb.blendDst)
+	}
+	if sb.blendEquationRGB != 0 {
+		gs.BlendEquationSeparate(sb.blendEquationRGB, sb.blendEquationAlpha)
+	}
+	if sb.blendSrcRGB != uintUndef {
+		gs.BlendFuncSeparate(sb.blendSrcRGB, sb.blendDstRGB, sb.blendSrcAlpha, sb.blendDstAlpha)
+	}
+	if sb.polygonModeFace != 0 {
+		gs.PolygonMode(sb.polygonModeFace, sb.polygonModeMode)
+	}
+	if sb.polygonOffsetFactor != -1 || sb.polygonOffsetUnits != -1 {
+		gs.PolygonOffset(sb.polygonOffsetFactor, sb.polygonOffsetUnits)
+	}
+	if sb.stencilFunc != uintUndef {
+		gs.StencilFunc(sb.stencilFunc, sb.stencilRef, sb.stencilFuncMask)
+	}
+	if sb.stencilOps[0] != uintUndef {
+		gs.StencilOp(sb.stencilOps[0], sb.stencilOps[1], sb.stencilOps[2])
+	}
+	if sb.stencilMask != uintUndef {
+		gs.StencilMask(sb.stencilMask)
+	}
+
+	// Capabilities
+	for cap, state := range sb.caps {
+		switch state {
+		case capEnabled:
+			gs.Enable(cap)
+		case capDisabled:
+			gs.Disable(cap)
+		}
+	}
+}
@@ -44,6 +44,20 @@ func (u *Uniform) Location(gs *GLS) int32 {
 	return u.location
 }
 
+// OptionalLocation returns the location of this uniform for the current shader program
+// as Location, without warning if the program does not use it, such as the uniforms
+// only declared by the shaders of some materials.
+// The returned location can be -1 if not found.
+func (u *Uniform) OptionalLocation(gs *GLS) int32 {
+
+	handle := gs.prog.Handle()
+	if handle != u.handle {
+		u.location = gs.prog.uniformLocation(u.name, false)
+		u.handle = handle
+	}
+	return u.location
+}
+
 // LocationIdx returns the location of this indexed uniform for the current shader program.
 // The returned location can be -1 if not found.
 func (u *Uniform) LocationIdx(gs *GLS, idx int32) int32 {
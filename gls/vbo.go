@@ -16,7 +16,10 @@ type VBO struct {
 	handle  uint32          // OpenGL handle for this VBO
 	usage   uint32          // Expected usage pattern of the buffer
 	update  bool            // Update flag
+	version uint32          // Number of changes of the buffer data
+	size    int             // Size in bytes of the OpenGL data store
 	buffer  math32.ArrayF32 // Data buffer
+	bytes   []byte          // Raw data buffer used instead of the float buffer if not nil
 	attribs []VBOattrib     // List of attributes
 }
 
@@ -27,6 +30,9 @@ type VBOattrib struct {
 	ByteOffset  uint32     // Byte offset from the start of the VBO
 	NumElements int32      // Number of elements
 	ElementType uint32     // Type of the element (e.g. FLOAT, INT, UNSIGNED_SHORT, etc...)
+	Divisor     uint32     // Number of instances per attribute value (0 for per vertex attributes)
+	Normalized  bool       // Integer elements are normalized to [0,1] or [-1,1] when converted to float
+	Integer     bool       // Integer elements are passed to the shader as integers
 }
 
 // AttribType is the functional type of a vbo attribute.
@@ -42,6 +48,7 @@ const (
 	VertexTexcoord2
 	SkinWeight
 	SkinIndex
+	VertexDistance
 )
 
 // Map from attribute type to default attribute name.
@@ -54,18 +61,20 @@ var attribTypeNameMap = map[AttribType]string{
 	VertexTexcoord2: "VertexTexcoord2",
 	SkinWeight:      "matricesWeights",
 	SkinIndex:       "matricesIndices",
+	VertexDistance:  "VertexDistance",
 }
 
 // Map from attribute type to default attribute size.
 var attribTypeSizeMap = map[AttribType]int32{
 	VertexPosition:  3,
 	VertexNormal:    3,
-	VertexTangent:   3, // TODO
+	VertexTangent:   4,
 	VertexColor:     3,
 	VertexTexcoord:  2,
 	VertexTexcoord2: 2,
 	SkinWeight:      4,
 	SkinIndex:       4,
+	VertexDistance:  1,
 }
 
 // Map from element type to element size (in bytes).
@@ -88,6 +97,16 @@ func NewVBO(buffer math32.ArrayF32) *VBO {
 	return vbo
 }
 
+// NewVBOBytes creates and returns a pointer to a new OpenGL Vertex Buffer Object
+// with a raw data buffer, used for attributes with non-float elements.
+func NewVBOBytes(buffer []byte) *VBO {
+
+	vbo := new(VBO)
+	vbo.init()
+	vbo.SetBytes(buffer)
+	return vbo
+}
+
 // init initializes the VBO.
 func (vbo *VBO) init() {
 
@@ -151,6 +170,59 @@ func (vbo *VBO) AddCustomAttribOffset(name string, itemSize int32, byteOffset ui
 	return vbo
 }
 
+// AddAttribType adds a new attribute to the VBO with the specified type, element type
+// and normalized flag. The element type is one of BYTE, UNSIGNED_BYTE, SHORT, UNSIGNED_SHORT,
+// INT, UNSIGNED_INT or FLOAT. For example, packed colors use UNSIGNED_BYTE normalized elements.
+// The attribute's ByteOffset is computed automatically based on the existing attributes.
+func (vbo *VBO) AddAttribType(atype AttribType, elementType uint32, normalized bool) *VBO {
+
+	vbo.attribs = append(vbo.attribs, VBOattrib{
+		Type:        atype,
+		Name:        attribTypeNameMap[atype],
+		ByteOffset:  uint32(vbo.StrideSize()),
+		NumElements: attribTypeSizeMap[atype],
+		ElementType: elementType,
+		Normalized:  normalized,
+	})
+	return vbo
+}
+
+// AddCustomAttribType adds a new attribute to the VBO with the specified name, itemSize,
+// element type and normalized flag. The elements are converted to float for the shader.
+// The attribute's ByteOffset is computed automatically based on the existing attributes.
+func (vbo *VBO) AddCustomAttribType(name string, itemSize int32, elementType uint32, normalized bool) *VBO {
+
+	vbo.attribs = append(vbo.attribs, VBOattrib{
+		Type:        Undefined,
+		Name:        name,
+		ByteOffset:  uint32(vbo.StrideSize()),
+		NumElements: itemSize,
+		ElementType: elementType,
+		Normalized:  normalized,
+	})
+	return vbo
+}
+
+// AddCustomAttribInt adds a new attribute to the VBO with the specified name, itemSize and
+// integer element type, passed to the shader as integers (int, ivec or uvec inputs).
+// The attribute's ByteOffset is computed automatically based on the existing attributes.
+// The attribute is left disabled if the OpenGL 3 functions are not supported (see GLS.GL3Supported).
+func (vbo *VBO) AddCustomAttribInt(name string, itemSize int32, elementType uint32) *VBO {
+
+	if elementType == FLOAT {
+		panic("VBO.AddCustomAttribInt: invalid element type")
+	}
+	vbo.attribs = append(vbo.attribs, VBOattrib{
+		Type:        Undefined,
+		Name:        name,
+		ByteOffset:  uint32(vbo.StrideSize()),
+		NumElements: itemSize,
+		ElementType: elementType,
+		Integer:     true,
+	})
+	return vbo
+}
+
 // Attrib finds and returns a pointer to the VBO attribute with the specified type.
 // Returns nil if not found.
 func (vbo *VBO) Attrib(atype AttribType) *VBOattrib {
@@ -202,16 +274,44 @@ func (vbo *VBO) Dispose() {
 		vbo.gs.DeleteBuffers(vbo.handle)
 	}
 	vbo.gs = nil
+	vbo.size = 0
 }
 
 // SetBuffer sets the VBO buffer.
 func (vbo *VBO) SetBuffer(buffer math32.ArrayF32) *VBO {
 
 	vbo.buffer = buffer
+	vbo.bytes = nil
 	vbo.update = true
+	vbo.version++
 	return vbo
 }
 
+// SetBytes sets the VBO raw data buffer, used instead of the float buffer.
+func (vbo *VBO) SetBytes(buffer []byte) *VBO {
+
+	vbo.bytes = buffer
+	vbo.buffer = nil
+	vbo.update = true
+	vbo.version++
+	return vbo
+}
+
+// Bytes returns the VBO raw data buffer or nil if the VBO uses a float buffer.
+func (vbo *VBO) Bytes() []byte {
+
+	return vbo.bytes
+}
+
+// BufferSize returns the size in bytes of the VBO buffer.
+func (vbo *VBO) BufferSize() int {
+
+	if vbo.bytes != nil {
+		return len(vbo.bytes)
+	}
+	return vbo.buffer.Bytes()
+}
+
 // SetUsage sets the expected usage pattern of the buffer.
 // The default value is GL_STATIC_DRAW.
 func (vbo *VBO) SetUsage(usage uint32) {
@@ -226,9 +326,18 @@ func (vbo *VBO) Buffer() *math32.ArrayF32 {
 }
 
 // Update sets the update flag to force the VBO update.
+// It must be called after the buffer data is modified.
 func (vbo *VBO) Update() {
 
 	vbo.update = true
+	vbo.version++
+}
+
+// Version returns the number of changes of the buffer data, incremented
+// by SetBuffer, SetBytes and Update, to detect the changes of the data.
+func (vbo *VBO) Version() uint32 {
+
+	return vbo.version
 }
 
 // AttribOffset returns the total number of elements from
@@ -290,7 +399,8 @@ func (vbo *VBO) StrideSize() int {
 func (vbo *VBO) Transfer(gs *GLS) {
 
 	// If the VBO buffer is empty, ignore
-	if vbo.buffer.Bytes() == 0 {
+	size := vbo.BufferSize()
+	if size == 0 {
 		return
 	}
 
@@ -308,9 +418,22 @@ func (vbo *VBO) Transfer(gs *GLS) {
 				fmt.Printf("WARNING : Attribute not found: %v\n", attrib.Name)
 				continue
 			}
+			// Instanced and integer attributes need VertexAttribDivisor and VertexAttribIPointer
+			// and are left disabled without them
+			if (attrib.Divisor != 0 || attrib.Integer) && !gs.gl3 {
+				fmt.Printf("WARNING : Instanced or integer attribute requires the OpenGL 3 functions: %v\n", attrib.Name)
+				continue
+			}
 			// Enables attribute and sets its stride and offset in the buffer
 			gs.EnableVertexAttribArray(uint32(loc))
-			gs.VertexAttribPointer(uint32(loc), attrib.NumElements, attrib.ElementType, false, int32(strideSize), attrib.ByteOffset)
+			if attrib.Integer {
+				gs.VertexAttribIPointer(uint32(loc), attrib.NumElements, attrib.ElementType, int32(strideSize), attrib.ByteOffset)
+			} else {
+				gs.VertexAttribPointer(uint32(loc), attrib.NumElements, attrib.ElementType, attrib.Normalized, int32(strideSize), attrib.ByteOffset)
+			}
+			if attrib.Divisor != 0 {
+				gs.VertexAttribDivisor(uint32(loc), attrib.Divisor)
+			}
 		}
 		vbo.gs = gs // this indicates that the vbo was initialized
 	}
@@ -320,9 +443,20 @@ func (vbo *VBO) Transfer(gs *GLS) {
 		return
 	}
 
-	// Transfer the VBO data to OpenGL
+	// Transfer the VBO data to OpenGL, updating the existing data store if the size is unchanged
 	gs.BindBuffer(ARRAY_BUFFER, vbo.handle)
-	gs.BufferData(ARRAY_BUFFER, vbo.buffer.Bytes(), &vbo.buffer[0], vbo.usage)
+	var data interface{}
+	if vbo.bytes != nil {
+		data = &vbo.bytes[0]
+	} else {
+		data = &vbo.buffer[0]
+	}
+	if vbo.size == size {
+		gs.BufferSubData(ARRAY_BUFFER, 0, size, data)
+	} else {
+		gs.BufferData(ARRAY_BUFFER, size, data, vbo.usage)
+		vbo.size = size
+	}
 	vbo.update = false
 }
 
@@ -0,0 +1,76 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test uploading a VBO with an UNSIGNED_BYTE normalized color attribute
+func TestVBOBytesNormalizedColor(t *testing.T) {
+
+	rec := NewRecorder()
+	gs, err := NewWithBackend(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog := gs.NewProgram()
+	prog.AddShader(VERTEX_SHADER, `
+		in vec4 VertexColor;
+		out vec4 Color;
+		void main() {
+			Color = VertexColor;
+			gl_Position = vec4(0.0, 0.0, 0.0, 1.0);
+		}
+	`)
+	prog.AddShader(FRAGMENT_SHADER, `
+		precision mediump float;
+		in vec4 Color;
+		out vec4 FragColor;
+		void main() {
+			FragColor = Color;
+		}
+	`)
+	err = prog.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs.UseProgram(prog)
+
+	colors := []byte{255, 0, 0, 255, 0, 255, 0, 255, 0, 0, 255, 128}
+	vbo := NewVBOBytes(colors)
+	vbo.AddCustomAttribType("VertexColor", 4, UNSIGNED_BYTE, true)
+	attrib := vbo.AttribName("VertexColor")
+	if attrib.ElementType != UNSIGNED_BYTE || !attrib.Normalized || attrib.Integer {
+		t.Fatalf("invalid attribute: %+v", *attrib)
+	}
+	if vbo.StrideSize() != 4 || vbo.BufferSize() != len(colors) {
+		t.Fatalf("expected stride size 4 and buffer size %d got %d/%d", len(colors), vbo.StrideSize(), vbo.BufferSize())
+	}
+
+	rec.Reset()
+	vbo.Transfer(gs)
+	if vbo.gs != gs || vbo.update || vbo.size != len(colors) {
+		t.Fatalf("VBO not uploaded: size %d", vbo.size)
+	}
+	pointer := "VertexAttribPointer(0, 4, 5121, true, 4, 0)"
+	if !strings.Contains(rec.String(), pointer) {
+		t.Fatalf("expected %s in calls:\n%s", pointer, rec)
+	}
+
+	// Updating with the same size reuses the data store
+	colors[3] = 64
+	vbo.Update()
+	rec.Reset()
+	vbo.Transfer(gs)
+	if vbo.update || vbo.size != len(colors) {
+		t.Fatalf("VBO not updated: size %d", vbo.size)
+	}
+	names := rec.Names()
+	if names[len(names)-1] != "BufferSubData" {
+		t.Errorf("expected the data store to be reused, got calls %v", names)
+	}
+}
@@ -3,6 +3,7 @@ module github.com/thommil/tge-g3n
 go 1.12
 
 require (
+	github.com/go-gl/gl v0.0.0-20181026044259-55b76b7df9d2
 	github.com/thommil/tge v0.0.0-20190311230816-98b952a59b69
 	github.com/thommil/tge-gl v0.0.0-20190312081652-25ba7371711d
 )
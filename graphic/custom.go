@@ -0,0 +1,53 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/geometry"
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/material"
+)
+
+// DrawFunc is the type of the functions issuing the draw calls of custom graphics.
+type DrawFunc func(gs *gls.GLS, rinfo *core.RenderInfo)
+
+// Custom is a mesh which issues its own draw calls, such as instanced draws,
+// while being culled and sorted by the renderer like any other graphic.
+// The draw function is called after the material, geometry and matrices setup.
+type Custom struct {
+	Mesh // Embedded mesh
+}
+
+// NewCustom creates and returns a pointer to a new custom mesh with the specified
+// geometry, material and draw function.
+func NewCustom(igeom geometry.IGeometry, imat material.IMaterial, drawFn DrawFunc) *Custom {
+
+	if drawFn == nil {
+		panic("NewCustom: nil draw function")
+	}
+	c := new(Custom)
+	c.Mesh.Init(igeom, imat)
+	c.drawFn = drawFn
+	return c
+}
+
+// SetDrawFunc sets the function which issues the draw calls of this custom mesh.
+func (c *Custom) SetDrawFunc(drawFn DrawFunc) {
+
+	if drawFn == nil {
+		panic("Custom.SetDrawFunc: nil draw function")
+	}
+	c.drawFn = drawFn
+}
+
+// Clone clones the custom mesh and satisfies the INode interface.
+func (c *Custom) Clone() core.INode {
+
+	clone := new(Custom)
+	clone.Mesh = *c.Mesh.Clone().(*Mesh)
+	clone.SetIGraphic(&clone.Mesh)
+	return clone
+}
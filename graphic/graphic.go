@@ -19,17 +19,33 @@ import (
 type Graphic struct {
 	core.Node                      // Embedded Node
 	igeom       geometry.IGeometry // Associated IGeometry
+	drawGeom    geometry.IGeometry // Optional geometry drawn instead of igeom (CPU skinned meshes)
 	materials   []GraphicMaterial  // Materials
 	mode        uint32             // OpenGL primitive
 	renderable  bool               // Renderable flag
 	cullable    bool               // Cullable flag
 	renderOrder int                // Render order
+	sortCenter  *math32.Vector3    // Optional depth sort point in local coordinates
+	stencil     graphicStencil     // Stencil write and test state
+	drawFn      DrawFunc           // Optional custom draw function
 
 	ShaderDefines gls.ShaderDefines // Graphic-specific shader defines
 
-	mm   math32.Matrix4 // Cached Model matrix
-	mvm  math32.Matrix4 // Cached ModelView matrix
-	mvpm math32.Matrix4 // Cached ModelViewProjection matrix
+	mm       math32.Matrix4 // Cached Model matrix
+	mvm      math32.Matrix4 // Cached ModelView matrix
+	mvpm     math32.Matrix4 // Cached ModelViewProjection matrix
+	mirrored bool           // Cached negative determinant of the model matrix flag
+}
+
+// graphicStencil contains the stencil write and test state of a graphic
+type graphicStencil struct {
+	write     bool   // Whether the reference value is written
+	writeRef  int32  // Reference value written
+	writeMask uint32 // Bits written
+	test      bool   // Whether the stencil is tested
+	fn        uint32 // Test function
+	ref       int32  // Test reference value
+	mask      uint32 // Bits tested
 }
 
 // GraphicMaterial specifies the material to be used for
@@ -103,12 +119,18 @@ func (gr *Graphic) IGeometry() geometry.IGeometry {
 func (gr *Graphic) Dispose() {
 
 	gr.igeom.Dispose()
+	if gr.drawGeom != nil {
+		gr.drawGeom.Dispose()
+		gr.drawGeom = nil
+	}
 	for i := 0; i < len(gr.materials); i++ {
 		gr.materials[i].imat.Dispose()
 	}
 }
 
 // Clone clones the graphic and satisfies the INode interface.
+// The clone shares the geometry and materials of the graphic,
+// whose reference counts are incremented.
 // It should be called by Clone() implementations of IGraphic.
 // Note that the topmost implementation calling this method needs
 // to call clone.SetIGraphic(igraphic) after calling this method.
@@ -117,15 +139,20 @@ func (gr *Graphic) Clone() core.INode {
 	clone := new(Graphic)
 	clone.Node = *gr.Node.Clone().(*core.Node)
 	clone.igeom = gr.igeom
+	gr.igeom.GetGeometry().Incref()
 	clone.mode = gr.mode
 	clone.renderable = gr.renderable
 	clone.cullable = gr.cullable
 	clone.renderOrder = gr.renderOrder
+	clone.sortCenter = gr.sortCenter
+	clone.stencil = gr.stencil
+	clone.drawFn = gr.drawFn
 	clone.ShaderDefines = gr.ShaderDefines
 	clone.materials = make([]GraphicMaterial, len(gr.materials))
 
 	for i, grmat := range gr.materials {
 		clone.materials[i] = grmat
+		grmat.imat.GetMaterial().Incref()
 	}
 
 	return clone
@@ -174,6 +201,56 @@ func (gr *Graphic) RenderOrder() int {
 	return gr.renderOrder
 }
 
+// SetSortCenter sets the point in local coordinates whose depth is used to sort this
+// graphic with the others, such as the visible center of a large mesh whose origin is
+// offset. A nil center restores the default, the center of the geometry bounding box.
+func (gr *Graphic) SetSortCenter(center *math32.Vector3) {
+
+	if center == nil {
+		gr.sortCenter = nil
+		return
+	}
+	c := *center
+	gr.sortCenter = &c
+}
+
+// SortCenter returns the point in local coordinates whose depth is used to sort this graphic.
+func (gr *Graphic) SortCenter() math32.Vector3 {
+
+	if gr.sortCenter != nil {
+		return *gr.sortCenter
+	}
+	var center math32.Vector3
+	bbox := gr.igeom.GetGeometry().BoundingBox()
+	if !bbox.Empty() {
+		bbox.Center(&center)
+	}
+	return center
+}
+
+// SetStencilWrite sets the reference value written to the bits of the stencil buffer
+// selected by mask where this graphic is drawn. A mask of 0 disables the stencil write.
+// When the graphic also tests the stencil, the test reference value is written.
+// Graphics writing the stencil should render before the ones testing it (see SetRenderOrder)
+// and the framebuffer must have a stencil buffer.
+func (gr *Graphic) SetStencilWrite(ref, mask int) {
+
+	gr.stencil.write = mask != 0
+	gr.stencil.writeRef = int32(ref)
+	gr.stencil.writeMask = uint32(mask)
+}
+
+// SetStencilTest sets the stencil test of this graphic, which is only drawn where
+// the function (gls.EQUAL, gls.NOTEQUAL, ...) of the reference value and the stencil
+// buffer bits selected by mask passes. The gls.ALWAYS function disables the stencil test.
+func (gr *Graphic) SetStencilTest(fn uint32, ref, mask int) {
+
+	gr.stencil.test = fn != gls.ALWAYS
+	gr.stencil.fn = fn
+	gr.stencil.ref = int32(ref)
+	gr.stencil.mask = uint32(mask)
+}
+
 // AddMaterial adds a material for the specified subset of vertices.
 // If the material applies to all vertices, start and count must be 0.
 func (gr *Graphic) AddMaterial(igr IGraphic, imat material.IMaterial, start, count int) {
@@ -225,6 +302,24 @@ func (gr *Graphic) ClearMaterials() {
 	gr.materials = gr.materials[0:0]
 }
 
+// ReplaceMaterial replaces the specified material by the specified other material
+// in the graphic materials of this graphic, transferring the references of the
+// graphic from the old material to the new one.
+// Returns whether the graphic used the old material.
+func (gr *Graphic) ReplaceMaterial(old, imat material.IMaterial) bool {
+
+	replaced := false
+	for i := range gr.materials {
+		if gr.materials[i].imat == old {
+			imat.GetMaterial().Incref()
+			gr.materials[i].imat = imat
+			old.Dispose()
+			replaced = true
+		}
+	}
+	return replaced
+}
+
 // SetIGraphic sets the IGraphic on all this Graphic's GraphicMaterials.
 func (gr *Graphic) SetIGraphic(igr IGraphic) {
 
@@ -249,12 +344,49 @@ func (gr *Graphic) BoundingBox() math32.Box3 {
 	return bbox
 }
 
+// SceneBounds returns the world axis aligned bounding box of the visible graphics of the
+// specified node tree, which is empty if there are none. The graphics which are not cullable,
+// such as skyboxes, are ignored. The world matrices of the tree are updated.
+// See camera.Perspective.FitToScene for an example of use.
+func SceneBounds(root core.INode) math32.Box3 {
+
+	var bounds math32.Box3
+	bounds.MakeEmpty()
+	root.UpdateMatrixWorld()
+	var walk func(inode core.INode)
+	walk = func(inode core.INode) {
+		node := inode.GetNode()
+		if !node.Visible() {
+			return
+		}
+		if igr, ok := inode.(IGraphic); ok && igr.GetGraphic().Cullable() {
+			box := igr.GetGeometry().BoundingBox()
+			mw := node.MatrixWorld()
+			box.ApplyMatrix4(&mw)
+			bounds.Union(&box)
+		}
+		for _, child := range node.Children() {
+			walk(child)
+		}
+	}
+	walk(root)
+	return bounds
+}
+
 // CalculateMatrices calculates the model view and model view projection matrices.
 func (gr *Graphic) CalculateMatrices(gs *gls.GLS, rinfo *core.RenderInfo) {
 
 	gr.mm = gr.MatrixWorld()
 	gr.mvm.MultiplyMatrices(&rinfo.ViewMatrix, &gr.mm)
 	gr.mvpm.MultiplyMatrices(&rinfo.ProjMatrix, &gr.mvm)
+	gr.mirrored = gr.mm.Determinant() < 0
+}
+
+// Mirrored returns whether the last cached model matrix of this graphic
+// has a negative scale, which reverses the winding of its triangles.
+func (gr *Graphic) Mirrored() bool {
+
+	return gr.mirrored
 }
 
 // ModelViewMatrix returns the last cached model view matrix for this graphic.
@@ -287,30 +419,85 @@ func (grmat *GraphicMaterial) IGraphic() IGraphic {
 	return grmat.igraphic
 }
 
+// Range returns the index of the first element and the number of elements of the
+// geometry drawn with the GraphicMaterial. A count of 0 draws all the elements.
+func (grmat *GraphicMaterial) Range() (start, count int) {
+
+	return grmat.start, grmat.count
+}
+
 // Render is called by the renderer to render this graphic material.
 func (grmat *GraphicMaterial) Render(gs *gls.GLS, rinfo *core.RenderInfo) {
 
 	// Setup the associated material (set states and transfer material uniforms and textures)
 	grmat.imat.RenderSetup(gs)
 
-	// Setup the associated geometry (set VAO and transfer VBOS)
+	// Setup the graphic stencil write and test and resets them after drawing
+	st := &grmat.igraphic.GetGraphic().stencil
+	if !st.write && !st.test {
+		grmat.Draw(gs, rinfo)
+		return
+	}
+	gs.Enable(gls.STENCIL_TEST)
+	if st.test {
+		gs.StencilFunc(st.fn, st.ref, st.mask)
+	} else {
+		gs.StencilFunc(gls.ALWAYS, st.writeRef, 0xFF)
+	}
+	if st.write {
+		gs.StencilOp(gls.KEEP, gls.KEEP, gls.REPLACE)
+		gs.StencilMask(st.writeMask)
+	} else {
+		gs.StencilMask(0)
+	}
+	grmat.Draw(gs, rinfo)
+	gs.StencilMask(0xFF)
+	gs.StencilOp(gls.KEEP, gls.KEEP, gls.KEEP)
+	gs.Disable(gls.STENCIL_TEST)
+}
+
+// Draw sets up the geometry and the graphic of this graphic material and draws it
+// without setting up the material, for passes which use their own shader program.
+func (grmat *GraphicMaterial) Draw(gs *gls.GLS, rinfo *core.RenderInfo) {
+
+	// Mirrored graphics have clockwise front faces
 	gr := grmat.igraphic.GetGraphic()
-	gr.igeom.RenderSetup(gs)
+	if gr.mirrored {
+		gs.FrontFace(gls.CW)
+		defer gs.FrontFace(gls.CCW)
+	}
+
+	// Setup the associated geometry (set VAO and transfer VBOS)
+	igeom := gr.igeom
+	if gr.drawGeom != nil {
+		igeom = gr.drawGeom
+	}
+	igeom.RenderSetup(gs)
 
 	// Setup current graphic (transfer matrices)
 	grmat.igraphic.RenderSetup(gs, rinfo)
 
+	// Custom graphics issue their own draw calls
+	if gr.drawFn != nil {
+		gr.drawFn(gs, rinfo)
+		return
+	}
+
 	// Get the number of vertices for the current material
 	count := grmat.count
 
-	geom := gr.igeom.GetGeometry()
+	geom := igeom.GetGeometry()
 	indices := geom.Indices()
 	// Indexed geometry
 	if indices.Size() > 0 {
 		if count == 0 {
 			count = indices.Size()
 		}
-		gs.DrawElements(gr.mode, int32(count), gls.UNSIGNED_INT, 4*uint32(grmat.start))
+		if geom.PrimitiveRestart() {
+			gs.SetPrimitiveRestart(true, geom.IndexType())
+			defer gs.SetPrimitiveRestart(false, geom.IndexType())
+		}
+		gs.DrawElements(gr.mode, int32(count), geom.IndexType(), geom.IndexSize()*uint32(grmat.start))
 		// Non indexed geometry
 	} else {
 		if count == 0 {
@@ -0,0 +1,173 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/geometry"
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/material"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// Test that disposing a mesh doesn't release the geometry shared by its clone
+func TestCloneSharesGeometry(t *testing.T) {
+
+	geom := geometry.NewCube(1)
+	mesh := NewMesh(geom, material.NewStandard(&math32.Color{1, 1, 1}))
+	clone := mesh.Clone().(*Mesh)
+	mesh.Dispose()
+	if len(geom.VBOs()) == 0 {
+		t.Fatalf("geometry released while shared by the clone")
+	}
+	clone.Dispose()
+	if len(geom.VBOs()) != 0 {
+		t.Errorf("geometry not released after disposing the clone")
+	}
+}
+
+// Test dragging the x axis handle of a transform gizmo with snapping
+func TestTransformGizmoTranslate(t *testing.T) {
+
+	target := core.NewNode()
+	g := NewTransformGizmo(1)
+	g.SetTarget(target)
+	g.TranslateSnap = 0.25
+	down := math32.NewVector3(0, -1, 0)
+	if !g.PointerDown(core.NewRaycaster(math32.NewVector3(0.5, 5, 0), down)) {
+		t.Fatalf("x handle not hit")
+	}
+	g.PointerMove(core.NewRaycaster(math32.NewVector3(1.6, 5, 3), down))
+	g.PointerUp()
+	if pos := target.Position(); pos != *math32.NewVector3(1, 0, 0) {
+		t.Errorf("target position: got %v, expected (1, 0, 0)", pos)
+	}
+}
+
+// Test that the front faces of a mirrored mesh are reversed while it is drawn
+func TestMirroredFrontFace(t *testing.T) {
+
+	rec := gls.NewRecorder()
+	gs, err := gls.NewWithBackend(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog := gs.NewProgram()
+	prog.AddShader(gls.VERTEX_SHADER, "void main() {}")
+	prog.AddShader(gls.FRAGMENT_SHADER, "void main() {}")
+	if err := prog.Build(); err != nil {
+		t.Fatal(err)
+	}
+	gs.UseProgram(prog)
+
+	mesh := NewMesh(geometry.NewCube(1), material.NewStandard(&math32.Color{1, 1, 1}))
+	mesh.SetScaleX(-1)
+	mesh.UpdateMatrixWorld()
+	var rinfo core.RenderInfo
+	mesh.CalculateMatrices(gs, &rinfo)
+	if !mesh.Mirrored() {
+		t.Fatalf("mesh not mirrored")
+	}
+	rec.Reset()
+	mesh.Materials()[0].Draw(gs, &rinfo)
+	var faces []string
+	for _, c := range rec.Calls() {
+		if c.Name == "FrontFace" || c.Name == "DrawElements" {
+			faces = append(faces, c.String())
+		}
+	}
+	expected := []string{"FrontFace(2304)", "DrawElements(4, 36, 5123, 0)", "FrontFace(2305)"}
+	if fmt.Sprint(faces) != fmt.Sprint(expected) {
+		t.Errorf("got calls %v, expected %v", faces, expected)
+	}
+}
+
+// Test that a node tree with a mesh is restored by loading its saved scene
+func TestSceneRoundTrip(t *testing.T) {
+
+	root := core.NewNode()
+	root.SetName("root")
+	root.SetPosition(1, 2, 3)
+	mat := material.NewStandard(&math32.Color{1, 0, 0})
+	mat.SetShininess(10)
+	mesh := NewMesh(geometry.NewCube(1), mat)
+	mesh.SetName("cube")
+	mesh.SetRotationY(0.5)
+	mesh.SetScale(2, 2, 2)
+	root.Add(mesh)
+
+	var buf bytes.Buffer
+	if err := core.SaveScene(&buf, root); err != nil {
+		t.Fatal(err)
+	}
+	iroot, err := core.LoadScene(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := iroot.GetNode()
+	if loaded.Name() != "root" || loaded.Position() != root.Position() {
+		t.Errorf("root: got %q at %v, expected %q at %v", loaded.Name(), loaded.Position(), "root", root.Position())
+	}
+	if len(loaded.Children()) != 1 {
+		t.Fatalf("got %d children, expected 1", len(loaded.Children()))
+	}
+	lmesh, ok := loaded.Children()[0].(*Mesh)
+	if !ok {
+		t.Fatalf("got child %T, expected *Mesh", loaded.Children()[0])
+	}
+	if lmesh.Name() != "cube" || lmesh.Quaternion() != mesh.Quaternion() || lmesh.Scale() != mesh.Scale() {
+		t.Errorf("mesh transform: got %v %v, expected %v %v", lmesh.Quaternion(), lmesh.Scale(), mesh.Quaternion(), mesh.Scale())
+	}
+
+	geom, lgeom := mesh.GetGeometry(), lmesh.GetGeometry()
+	if fmt.Sprint(lgeom.Indices()) != fmt.Sprint(geom.Indices()) {
+		t.Errorf("geometry indices differ")
+	}
+	if len(lgeom.VBOs()) != len(geom.VBOs()) {
+		t.Fatalf("got %d VBOs, expected %d", len(lgeom.VBOs()), len(geom.VBOs()))
+	}
+	for i, vbo := range geom.VBOs() {
+		lvbo := lgeom.VBOs()[i]
+		if fmt.Sprint(*lvbo.Buffer()) != fmt.Sprint(*vbo.Buffer()) || fmt.Sprint(lvbo.Attributes()) != fmt.Sprint(vbo.Attributes()) {
+			t.Errorf("VBO %d differs", i)
+		}
+	}
+	if lgeom.GroupCount() != geom.GroupCount() {
+		t.Errorf("got %d groups, expected %d", lgeom.GroupCount(), geom.GroupCount())
+	}
+
+	lmat, ok := lmesh.GetMaterial(0).(*material.Standard)
+	if !ok {
+		t.Fatalf("got material %T, expected *material.Standard", lmesh.GetMaterial(0))
+	}
+	if lmat.Color() != mat.Color() || lmat.Shininess() != mat.Shininess() {
+		t.Errorf("material: got %v %v, expected %v %v", lmat.Color(), lmat.Shininess(), mat.Color(), mat.Shininess())
+	}
+}
+
+// Test that the material ranges of a mesh must be geometry groups to create its levels of detail
+func TestLODFromMeshGroups(t *testing.T) {
+
+	mesh := NewMesh(geometry.NewBox(1, 1, 1), nil)
+	mesh.AddGroupMaterial(material.NewStandard(&math32.Color{1, 0, 0}), 0)
+	lod, err := NewLODFromMesh(mesh, []float32{0.5}, []float32{10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	level, _ := lod.Level(1)
+	if grmats := level.(*Mesh).Materials(); len(grmats) != 1 || grmats[0].count == 0 {
+		t.Errorf("expected the group material on the simplified level")
+	}
+
+	mesh = NewMesh(geometry.NewBox(1, 1, 1), nil)
+	mesh.AddMaterial(material.NewStandard(&math32.Color{1, 0, 0}), 0, 3)
+	if _, err = NewLODFromMesh(mesh, []float32{0.5}, []float32{10}); err == nil {
+		t.Errorf("expected an error for a material range which is not a group")
+	}
+}
@@ -16,15 +16,26 @@ type GridHelper struct {
 	Lines
 }
 
-// NewGridHelper creates and returns a pointer to a new grid help object
-// with the specified size and step
-func NewGridHelper(size, step float32, color *math32.Color) *GridHelper {
-
+// NewGridHelper creates and returns a pointer to a new grid helper object in the
+// XZ plane centered at the origin with the specified size and number of divisions
+// along each axis. The center lines have the first color and the other lines the second color.
+// The grid is drawn with a single draw call.
+func NewGridHelper(size float32, divisions int, color1, color2 math32.Color) *GridHelper {
+
+	if divisions < 1 {
+		panic("NewGridHelper: number of divisions must be greater than 0")
+	}
 	grid := new(GridHelper)
 
 	half := size / 2
-	positions := math32.NewArrayF32(0, 0)
-	for i := -half; i <= half; i += step {
+	step := size / float32(divisions)
+	positions := math32.NewArrayF32(0, 24*(divisions+1))
+	for d := 0; d <= divisions; d++ {
+		i := -half + float32(d)*step
+		color := &color2
+		if 2*d == divisions {
+			color = &color1
+		}
 		positions.Append(
 			-half, 0, i, color.R, color.G, color.B,
 			half, 0, i, color.R, color.G, color.B,
@@ -0,0 +1,136 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/thommil/tge-g3n/camera"
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/geometry"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// LOD is a node with levels of detail as children of which only
+// the one corresponding to the distance to the camera is visible.
+// The visible level is selected when Update is called.
+type LOD struct {
+	core.Node            // Embedded node
+	levels    []lodLevel // Levels ordered by increasing distance
+}
+
+// lodLevel is a level of detail with its minimum camera distance
+type lodLevel struct {
+	inode    core.INode
+	distance float32
+}
+
+// NewLOD creates and returns a pointer to a new LOD node without levels.
+func NewLOD() *LOD {
+
+	l := new(LOD)
+	l.Node.Init()
+	return l
+}
+
+// NewLODFromMesh creates and returns a pointer to a new LOD node with the specified
+// mesh as first level and levels with simplified copies of its geometry using the
+// same materials. The ratios are the fractions of the mesh triangles kept
+// (see geometry.Simplify) and the distances are the camera distances from which
+// each simplified level is used.
+// Returns an error if a material of the mesh is attached to a range of vertices
+// which is not a geometry group, as only the groups are kept by the simplification.
+func NewLODFromMesh(mesh *Mesh, ratios, distances []float32) (*LOD, error) {
+
+	if len(ratios) != len(distances) {
+		panic("NewLODFromMesh: ratios and distances must have the same length")
+	}
+
+	// Materials of geometry groups use the group of the simplified geometry
+	geom := mesh.GetGeometry()
+	grmats := mesh.Materials()
+	groups := make([]int, len(grmats))
+	for i, grmat := range grmats {
+		groups[i] = -1
+		if grmat.count == 0 {
+			continue
+		}
+		for gi := 0; gi < geom.GroupCount(); gi++ {
+			group := geom.GroupAt(gi)
+			if group.Start == grmat.start && group.Count == grmat.count {
+				groups[i] = gi
+				break
+			}
+		}
+		if groups[i] < 0 {
+			return nil, fmt.Errorf("material range %d/%d is not a geometry group", grmat.start, grmat.count)
+		}
+	}
+
+	l := NewLOD()
+	l.AddLevel(mesh, 0)
+	for i, ratio := range ratios {
+		level := NewMesh(geometry.Simplify(geom, ratio), nil)
+		for mi, grmat := range grmats {
+			grmat.imat.GetMaterial().Incref()
+			if groups[mi] >= 0 {
+				level.AddGroupMaterial(grmat.imat, groups[mi])
+			} else {
+				level.AddMaterial(grmat.imat, 0, 0)
+			}
+		}
+		l.AddLevel(level, distances[i])
+	}
+	return l, nil
+}
+
+// AddLevel adds the specified node as a child level of detail
+// used from the specified camera distance.
+func (l *LOD) AddLevel(inode core.INode, distance float32) {
+
+	l.Add(inode)
+	l.levels = append(l.levels, lodLevel{inode, distance})
+	sort.SliceStable(l.levels, func(i, j int) bool { return l.levels[i].distance < l.levels[j].distance })
+	l.show(0)
+}
+
+// LevelCount returns the number of levels of detail.
+func (l *LOD) LevelCount() int {
+
+	return len(l.levels)
+}
+
+// Level returns the node and the camera distance of the specified level.
+func (l *LOD) Level(idx int) (core.INode, float32) {
+
+	return l.levels[idx].inode, l.levels[idx].distance
+}
+
+// Update shows the level of detail corresponding to the distance between
+// this node and the specified camera and returns its index.
+func (l *LOD) Update(icam camera.ICamera) int {
+
+	var pos, cpos math32.Vector3
+	l.WorldPosition(&pos)
+	icam.GetCamera().WorldPosition(&cpos)
+	dist := pos.DistanceTo(&cpos)
+	idx := 0
+	for i := range l.levels {
+		if dist >= l.levels[i].distance {
+			idx = i
+		}
+	}
+	l.show(idx)
+	return idx
+}
+
+// show sets the specified level visible and hides the others.
+func (l *LOD) show(idx int) {
+
+	for i := range l.levels {
+		l.levels[i].inode.GetNode().SetVisible(i == idx)
+	}
+}
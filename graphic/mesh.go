@@ -19,6 +19,7 @@ type Mesh struct {
 	uniMVm  gls.Uniform // Model view matrix uniform location cache
 	uniMVPm gls.Uniform // Model view projection matrix uniform cache
 	uniNm   gls.Uniform // Normal matrix uniform cache
+	uniCpos gls.Uniform // Camera world position uniform cache
 }
 
 // NewMesh creates and returns a pointer to a mesh with the specified geometry and material.
@@ -41,6 +42,7 @@ func (m *Mesh) Init(igeom geometry.IGeometry, imat material.IMaterial) {
 	m.uniMVm.Init("ModelViewMatrix")
 	m.uniMVPm.Init("MVP")
 	m.uniNm.Init("NormalMatrix")
+	m.uniCpos.Init("CameraPosition")
 
 	// Adds single material if not nil
 	if imat != nil {
@@ -79,6 +81,7 @@ func (m *Mesh) Clone() core.INode {
 	clone.uniMVm.Init("ModelViewMatrix")
 	clone.uniMVPm.Init("MVP")
 	clone.uniNm.Init("NormalMatrix")
+	clone.uniCpos.Init("CameraPosition")
 
 	return clone
 }
@@ -108,6 +111,13 @@ func (m *Mesh) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
 	nm.GetNormalMatrix(mvm)
 	location = m.uniNm.Location(gs)
 	gs.UniformMatrix3fv(location, 1, false, &nm[0])
+
+	// Transfer camera world position only if used by the shader (environment maps)
+	location = m.uniCpos.OptionalLocation(gs)
+	if location >= 0 {
+		cm := &rinfo.CameraMatrix
+		gs.Uniform3f(location, cm[12], cm[13], cm[14])
+	}
 }
 
 // Raycast checks intersections between this geometry and the specified raycaster
@@ -0,0 +1,404 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/geometry"
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/material"
+	"github.com/thommil/tge-g3n/math32"
+	"github.com/thommil/tge-g3n/texture"
+)
+
+// SceneGeometryLoader is used by core.LoadScene to load the mesh geometries
+// referenced by path (see geometry.Geometry.SetPath). It must be set by the
+// application before loading scenes with such references.
+var SceneGeometryLoader func(path string) (geometry.IGeometry, error)
+
+// SceneTextureLoader is used by core.LoadScene to load the material textures
+// referenced by path. The default loads the textures from image files.
+var SceneTextureLoader = texture.NewTexture2DFromImage
+
+func init() {
+	core.RegisterSceneType("Mesh", func() core.INode { return NewMesh(nil, nil) })
+	core.RegisterSceneType("Lines", func() core.INode { return NewLines(nil, nil) })
+	core.RegisterSceneType("LineStrip", func() core.INode { return NewLineStrip(nil, nil) })
+	core.RegisterSceneType("Points", func() core.INode { return NewPoints(nil, nil) })
+}
+
+// sceneGraphic describes a graphic in a scene document
+type sceneGraphic struct {
+	Geometry  sceneGeometry   `json:"geometry"`
+	Materials []sceneMaterial `json:"materials"`
+}
+
+// sceneGeometry describes a geometry referenced by path or its vertex buffers
+type sceneGeometry struct {
+	Path    string           `json:"path,omitempty"`
+	Buffers []sceneBuffer    `json:"buffers,omitempty"`
+	Indices []uint32         `json:"indices,omitempty"`
+	Groups  []geometry.Group `json:"groups,omitempty"`
+}
+
+// sceneBuffer describes a geometry VBO
+type sceneBuffer struct {
+	Attribs []gls.VBOattrib `json:"attribs"`
+	Data    []float32       `json:"data"`
+}
+
+// sceneMaterial describes a standard, phong, point or basic material
+type sceneMaterial struct {
+	Start       int            `json:"start"`
+	Count       int            `json:"count"`
+	Shader      string         `json:"shader"`
+	Ambient     math32.Color   `json:"ambient"`
+	Diffuse     math32.Color   `json:"diffuse"`
+	Specular    math32.Color   `json:"specular"`
+	Emissive    math32.Color   `json:"emissive"`
+	Shininess   float32        `json:"shininess"`
+	Opacity     float32        `json:"opacity"`
+	AlphaTest   float32        `json:"alphaTest,omitempty"`
+	Side        material.Side  `json:"side"`
+	Transparent bool           `json:"transparent"`
+	Wireframe   bool           `json:"wireframe"`
+	LineWidth   float32        `json:"lineWidth,omitempty"`
+	PointSize   float32        `json:"pointSize,omitempty"`
+	RotationZ   float32        `json:"rotationZ,omitempty"`
+	Textures    []sceneTexture `json:"textures,omitempty"`
+	NormalMap   *sceneTexture  `json:"normalMap,omitempty"`
+	HeightMap   *sceneTexture  `json:"heightMap,omitempty"`
+	HeightScale float32        `json:"heightScale,omitempty"`
+	EmissiveMap *sceneTexture  `json:"emissiveMap,omitempty"`
+}
+
+// sceneTexture describes a material texture referenced by path
+type sceneTexture struct {
+	Path   string     `json:"path"`
+	Repeat [2]float32 `json:"repeat"`
+	Offset [2]float32 `json:"offset"`
+}
+
+// SceneData satisfies the core.ISceneNode interface and returns the
+// geometry and materials of this mesh to be saved in a scene document.
+// Geometries without path are saved with their vertex buffers.
+// Only standard, phong, point and basic materials with textures loaded
+// from files are supported.
+func (m *Mesh) SceneData() (interface{}, error) {
+
+	return graphicSceneData(&m.Graphic)
+}
+
+// SetSceneData satisfies the core.ISceneNode interface and sets the
+// geometry and materials of this mesh from a scene document.
+func (m *Mesh) SetSceneData(data json.RawMessage) error {
+
+	return setGraphicSceneData(m, data)
+}
+
+// SceneData satisfies the core.ISceneNode interface and returns the
+// geometry and materials of these lines to be saved in a scene document.
+func (l *Lines) SceneData() (interface{}, error) {
+
+	return graphicSceneData(&l.Graphic)
+}
+
+// SetSceneData satisfies the core.ISceneNode interface and sets the
+// geometry and materials of these lines from a scene document.
+func (l *Lines) SetSceneData(data json.RawMessage) error {
+
+	return setGraphicSceneData(l, data)
+}
+
+// SceneData satisfies the core.ISceneNode interface and returns the
+// geometry and materials of this line strip to be saved in a scene document.
+func (l *LineStrip) SceneData() (interface{}, error) {
+
+	return graphicSceneData(&l.Graphic)
+}
+
+// SetSceneData satisfies the core.ISceneNode interface and sets the
+// geometry and materials of this line strip from a scene document.
+func (l *LineStrip) SetSceneData(data json.RawMessage) error {
+
+	return setGraphicSceneData(l, data)
+}
+
+// SceneData satisfies the core.ISceneNode interface and returns the
+// geometry and materials of these points to be saved in a scene document.
+func (p *Points) SceneData() (interface{}, error) {
+
+	return graphicSceneData(&p.Graphic)
+}
+
+// SetSceneData satisfies the core.ISceneNode interface and sets the
+// geometry and materials of these points from a scene document.
+func (p *Points) SetSceneData(data json.RawMessage) error {
+
+	return setGraphicSceneData(p, data)
+}
+
+// graphicSceneData returns the description of the geometry and materials of the specified graphic.
+func graphicSceneData(gr *Graphic) (*sceneGraphic, error) {
+
+	var sg sceneGraphic
+	geom := gr.GetGeometry()
+	sg.Geometry.Path = geom.Path()
+	if sg.Geometry.Path == "" {
+		for _, vbo := range geom.VBOs() {
+			if vbo.Bytes() != nil {
+				return nil, fmt.Errorf("scene geometry buffers must contain float data")
+			}
+			sg.Geometry.Buffers = append(sg.Geometry.Buffers, sceneBuffer{
+				Attribs: vbo.Attributes(),
+				Data:    *vbo.Buffer(),
+			})
+		}
+		sg.Geometry.Indices = geom.Indices()
+		for i := 0; i < geom.GroupCount(); i++ {
+			sg.Geometry.Groups = append(sg.Geometry.Groups, *geom.GroupAt(i))
+		}
+	}
+
+	for _, grmat := range gr.Materials() {
+		smat, err := materialSceneData(grmat.IMaterial())
+		if err != nil {
+			return nil, err
+		}
+		smat.Start = grmat.start
+		smat.Count = grmat.count
+		sg.Materials = append(sg.Materials, *smat)
+	}
+	return &sg, nil
+}
+
+// setGraphicSceneData sets the geometry and materials of the specified graphic
+// from the specified description.
+func setGraphicSceneData(igr IGraphic, data json.RawMessage) error {
+
+	var sg sceneGraphic
+	err := json.Unmarshal(data, &sg)
+	if err != nil {
+		return err
+	}
+
+	// Loads or builds the geometry
+	gr := igr.GetGraphic()
+	if sg.Geometry.Path != "" {
+		if SceneGeometryLoader == nil {
+			return fmt.Errorf("no scene geometry loader for: %s", sg.Geometry.Path)
+		}
+		gr.igeom, err = SceneGeometryLoader(sg.Geometry.Path)
+		if err != nil {
+			return err
+		}
+		gr.igeom.GetGeometry().SetPath(sg.Geometry.Path)
+	} else {
+		geom := geometry.NewGeometry()
+		for _, sb := range sg.Geometry.Buffers {
+			vbo := gls.NewVBO(math32.ArrayF32(sb.Data))
+			for _, attrib := range sb.Attribs {
+				if attrib.Type == gls.Undefined {
+					vbo.AddCustomAttribOffset(attrib.Name, attrib.NumElements, attrib.ByteOffset)
+				} else {
+					vbo.AddAttribOffset(attrib.Type, attrib.ByteOffset)
+				}
+				vbo.AttribAt(vbo.AttribCount() - 1).Divisor = attrib.Divisor
+			}
+			geom.AddVBO(vbo)
+		}
+		if len(sg.Geometry.Indices) > 0 {
+			geom.SetIndices(math32.ArrayU32(sg.Geometry.Indices))
+		}
+		geom.AddGroupList(sg.Geometry.Groups)
+		gr.igeom = geom
+	}
+
+	// Creates the materials
+	gr.ClearMaterials()
+	for i := range sg.Materials {
+		smat := &sg.Materials[i]
+		imat, err := newSceneMaterial(smat)
+		if err != nil {
+			return err
+		}
+		gr.AddMaterial(igr, imat, smat.Start, smat.Count)
+	}
+	return nil
+}
+
+// materialSceneData returns the description of the specified material.
+func materialSceneData(imat material.IMaterial) (*sceneMaterial, error) {
+
+	smat := new(sceneMaterial)
+	var ms *material.Standard
+	switch mat := imat.(type) {
+	case *material.Standard:
+		smat.Shader = "standard"
+		ms = mat
+	case *material.Phong:
+		smat.Shader = "phong"
+		ms = &mat.Standard
+	case *material.Point:
+		smat.Shader = "point"
+		smat.PointSize = mat.Size()
+		smat.RotationZ = mat.RotationZ()
+		ms = &mat.Standard
+	case *material.Basic:
+		smat.Shader = "basic"
+	default:
+		return nil, fmt.Errorf("unsupported scene material type: %T", mat)
+	}
+	mat := imat.GetMaterial()
+	smat.Side = mat.Side()
+	smat.Transparent = mat.Transparent()
+	smat.Wireframe = mat.Wireframe()
+	smat.LineWidth = mat.LineWidth()
+	if ms == nil {
+		return smat, nil
+	}
+
+	smat.Ambient = ms.AmbientColor()
+	smat.Diffuse = ms.Color()
+	smat.Specular = ms.SpecularColor()
+	smat.Emissive = ms.EmissiveColor()
+	smat.Shininess = ms.Shininess()
+	smat.Opacity = ms.Opacity()
+	smat.AlphaTest = ms.AlphaTest()
+	var err error
+	for _, tex := range ms.Textures() {
+		// The maps with their own samplers are saved separately
+		if sampler, _ := tex.GetUniformNames(); sampler != "MatTexture" {
+			continue
+		}
+		st, err := textureSceneData(tex)
+		if err != nil {
+			return nil, err
+		}
+		smat.Textures = append(smat.Textures, *st)
+	}
+	if tex := ms.NormalMap(); tex != nil {
+		if smat.NormalMap, err = textureSceneData(tex); err != nil {
+			return nil, err
+		}
+	}
+	if tex := ms.HeightMap(); tex != nil {
+		if smat.HeightMap, err = textureSceneData(tex); err != nil {
+			return nil, err
+		}
+		smat.HeightScale = ms.HeightScale()
+	}
+	if tex := ms.EmissiveMap(); tex != nil {
+		if smat.EmissiveMap, err = textureSceneData(tex); err != nil {
+			return nil, err
+		}
+	}
+	return smat, nil
+}
+
+// newSceneMaterial creates the material from the specified description.
+func newSceneMaterial(smat *sceneMaterial) (material.IMaterial, error) {
+
+	var imat material.IMaterial
+	var ms *material.Standard
+	switch smat.Shader {
+	case "standard":
+		ms = material.NewStandard(&smat.Diffuse)
+		imat = ms
+	case "phong":
+		mp := material.NewPhong(&smat.Diffuse)
+		ms = &mp.Standard
+		imat = mp
+	case "point":
+		mp := material.NewPoint(&smat.Diffuse)
+		if smat.PointSize > 0 {
+			mp.SetSize(smat.PointSize)
+		}
+		mp.SetRotationZ(smat.RotationZ)
+		ms = &mp.Standard
+		imat = mp
+	case "basic":
+		imat = material.NewBasic()
+	default:
+		return nil, fmt.Errorf("unsupported scene material shader: %q", smat.Shader)
+	}
+	mat := imat.GetMaterial()
+	mat.SetSide(smat.Side)
+	mat.SetTransparent(smat.Transparent)
+	mat.SetWireframe(smat.Wireframe)
+	if smat.LineWidth > 0 {
+		mat.SetLineWidth(smat.LineWidth)
+	}
+	if ms == nil {
+		return imat, nil
+	}
+
+	ms.SetAmbientColor(&smat.Ambient)
+	ms.SetSpecularColor(&smat.Specular)
+	if mp, ok := imat.(*material.Point); ok {
+		mp.SetEmissiveColor(&smat.Emissive)
+	} else {
+		ms.SetEmissiveColor(&smat.Emissive)
+	}
+	ms.SetShininess(smat.Shininess)
+	ms.SetOpacity(smat.Opacity)
+	ms.SetAlphaTest(smat.AlphaTest)
+	for i := range smat.Textures {
+		tex, err := loadSceneTexture(&smat.Textures[i])
+		if err != nil {
+			return nil, err
+		}
+		ms.AddTexture(tex)
+	}
+	if smat.NormalMap != nil {
+		tex, err := loadSceneTexture(smat.NormalMap)
+		if err != nil {
+			return nil, err
+		}
+		ms.SetNormalMap(tex)
+	}
+	if smat.HeightMap != nil {
+		tex, err := loadSceneTexture(smat.HeightMap)
+		if err != nil {
+			return nil, err
+		}
+		ms.SetHeightMap(tex, smat.HeightScale)
+	}
+	if smat.EmissiveMap != nil {
+		tex, err := loadSceneTexture(smat.EmissiveMap)
+		if err != nil {
+			return nil, err
+		}
+		ms.SetEmissiveMap(tex)
+	}
+	return imat, nil
+}
+
+// textureSceneData returns the description of the specified texture loaded from a file.
+func textureSceneData(tex *texture.Texture2D) (*sceneTexture, error) {
+
+	if tex.Path() == "" {
+		return nil, fmt.Errorf("scene texture not loaded from a file")
+	}
+	st := &sceneTexture{Path: tex.Path()}
+	st.Repeat[0], st.Repeat[1] = tex.Repeat()
+	st.Offset[0], st.Offset[1] = tex.Offset()
+	return st, nil
+}
+
+// loadSceneTexture loads the texture of the specified description with SceneTextureLoader.
+func loadSceneTexture(st *sceneTexture) (*texture.Texture2D, error) {
+
+	tex, err := SceneTextureLoader(st.Path)
+	if err != nil {
+		return nil, err
+	}
+	tex.SetRepeat(st.Repeat[0], st.Repeat[1])
+	tex.SetOffset(st.Offset[0], st.Offset[1])
+	return tex, nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"github.com/thommil/tge-g3n/camera"
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/geometry"
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/material"
+	"github.com/thommil/tge-g3n/math32"
+	"github.com/thommil/tge-g3n/texture"
+)
+
+// MirrorPlane is a rectangular mesh in its local XY plane, facing +Z, which
+// reflects the scene like a mirror or a flat water surface.
+// The reflection is rendered by Update from a virtual camera mirrored across
+// the plane into a render target, which is projected on the mesh by its material.
+// The virtual camera near plane is aligned with the mirror plane so objects
+// behind the mirror are clipped from the reflection.
+type MirrorPlane struct {
+	Mesh                           // Embedded mesh
+	mat      *material.Mirror      // Reflective material
+	target   *texture.RenderTarget // Reflection render target
+	camera   mirrorCamera          // Virtual reflected camera
+	clipBias float32               // Clip plane offset to avoid artifacts at the surface
+}
+
+// mirrorCamera is the virtual camera of a MirrorPlane which
+// uses the obliquely clipped projection of the main camera.
+type mirrorCamera struct {
+	*camera.Perspective                // Embedded camera used for its view matrix
+	proj                math32.Matrix4 // Oblique projection matrix
+}
+
+// ProjMatrix satisfies the ICamera interface.
+func (c *mirrorCamera) ProjMatrix(m *math32.Matrix4) {
+
+	*m = c.proj
+}
+
+// NewMirrorPlane creates and returns a pointer to a new mirror plane with the
+// specified size and reflection texture size in pixels.
+func NewMirrorPlane(width, height float32, texWidth, texHeight int) *MirrorPlane {
+
+	p := new(MirrorPlane)
+	p.mat = material.NewMirror(&math32.Color{0.5, 0.5, 0.5}, 1)
+	p.Mesh.Init(geometry.NewPlane(width, height, 1, 1), p.mat)
+	p.target = texture.NewRenderTarget(texWidth, texHeight)
+	p.mat.SetReflection(p.target.Texture().Incref())
+	p.camera.Perspective = camera.NewPerspective(60, 1, 0.1, 1000)
+	return p
+}
+
+// Material returns the reflective material of this mirror.
+func (p *MirrorPlane) Material() *material.Mirror {
+
+	return p.mat
+}
+
+// Texture returns the texture with the last rendered reflection.
+func (p *MirrorPlane) Texture() *texture.Texture2D {
+
+	return p.target.Texture()
+}
+
+// SetClipBias sets the offset of the clip plane along the mirror normal,
+// used to avoid artifacts with objects touching the surface. Default is 0.
+func (p *MirrorPlane) SetClipBias(bias float32) {
+
+	p.clipBias = bias
+}
+
+// ClipBias returns the offset of the clip plane.
+func (p *MirrorPlane) ClipBias() float32 {
+
+	return p.clipBias
+}
+
+// Update renders the reflection of the specified scene as seen by the specified
+// camera. It should be called before each frame rendered with this camera.
+// Nothing is rendered if the camera is behind the mirror.
+func (p *MirrorPlane) Update(r SceneRenderer, scene core.INode, icam camera.ICamera) error {
+
+	// Mirror plane in world coordinates
+	scene.UpdateMatrixWorld()
+	mw := p.MatrixWorld()
+	var rot math32.Matrix4
+	rot.ExtractRotation(&mw)
+	var pos, normal math32.Vector3
+	p.WorldPosition(&pos)
+	normal.Set(0, 0, 1).ApplyMatrix4(&rot).Normalize()
+	var plane math32.Plane
+	plane.SetFromNormalAndCoplanarPoint(&normal, &pos)
+
+	// Nothing to reflect if the camera is behind the mirror
+	cam := icam.GetCamera()
+	cmw := cam.MatrixWorld()
+	var camPos math32.Vector3
+	cam.WorldPosition(&camPos)
+	if plane.DistanceToPoint(&camPos) <= 0 {
+		return nil
+	}
+
+	// Reflects the camera position, target and up vector across the plane
+	var refl math32.Matrix4
+	refl.MakeReflection(&plane)
+	rot.ExtractRotation(&cmw)
+	var target, up math32.Vector3
+	target.Set(0, 0, -1).ApplyMatrix4(&rot).Add(&camPos).ApplyMatrix4(&refl)
+	up.Set(0, 1, 0).ApplyMatrix4(&rot).Reflect(&normal)
+	camPos.ApplyMatrix4(&refl)
+
+	vcam := p.camera.Perspective
+	vcam.SetPositionVec(&camPos)
+	vcam.SetUp(&up)
+	vcam.LookAt(&target)
+	var view math32.Matrix4
+	vcam.ViewMatrix(&view)
+
+	// Uses the main camera projection with the mirror plane as near plane
+	icam.ProjMatrix(&p.camera.proj)
+	p.clipProjection(&view, &normal, &pos)
+
+	// Maps the mirror model coordinates to the reflection texture coordinates
+	var texMatrix math32.Matrix4
+	texMatrix.Set(
+		0.5, 0, 0, 0.5,
+		0, 0.5, 0, 0.5,
+		0, 0, 0.5, 0.5,
+		0, 0, 0, 1,
+	)
+	texMatrix.Multiply(&p.camera.proj)
+	texMatrix.Multiply(&view)
+	texMatrix.Multiply(&mw)
+	p.mat.SetTextureMatrix(&texMatrix)
+
+	// Renders the scene without the mirror itself
+	gs := r.GLS()
+	visible := p.Visible()
+	p.SetVisible(false)
+	err := p.target.Bind(gs)
+	if err == nil {
+		gs.Clear(gls.DEPTH_BUFFER_BIT | gls.COLOR_BUFFER_BIT)
+		_, err = r.RenderScene(scene, &p.camera)
+		p.target.Unbind()
+	}
+	p.SetVisible(visible)
+	return err
+}
+
+// Dispose releases the OpenGL resources of this mirror.
+func (p *MirrorPlane) Dispose() {
+
+	p.target.Dispose()
+	p.Mesh.Dispose()
+}
+
+// clipProjection modifies the virtual camera projection matrix so its near plane
+// is the mirror plane, specified by its world normal and a point, using the
+// oblique near-plane clipping technique by Eric Lengyel.
+func (p *MirrorPlane) clipProjection(view *math32.Matrix4, normal, point *math32.Vector3) {
+
+	// Mirror plane in camera coordinates
+	var rot math32.Matrix4
+	rot.ExtractRotation(view)
+	var n, v math32.Vector3
+	n.Copy(normal).ApplyMatrix4(&rot)
+	v.Copy(point).ApplyMatrix4(view)
+	clip := math32.Vector4{X: n.X, Y: n.Y, Z: n.Z, W: -n.Dot(&v) - p.clipBias}
+
+	// Corner point of the frustum opposite to the clip plane
+	m := &p.camera.proj
+	var q math32.Vector4
+	q.X = (math32.Sign(clip.X) + m[8]) / m[0]
+	q.Y = (math32.Sign(clip.Y) + m[9]) / m[5]
+	q.Z = -1
+	q.W = (1 + m[10]) / m[14]
+
+	// Replaces the third row of the projection matrix
+	clip.MultiplyScalar(2 / clip.Dot(&q))
+	m[2] = clip.X
+	m[6] = clip.Y
+	m[10] = clip.Z + 1
+	m[14] = clip.W
+}
@@ -0,0 +1,127 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"github.com/thommil/tge-g3n/camera"
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/material"
+	"github.com/thommil/tge-g3n/math32"
+	"github.com/thommil/tge-g3n/texture"
+)
+
+// SceneRenderer is the interface for objects which can render a scene
+// into the currently bound framebuffer, such as renderer.Renderer.
+type SceneRenderer interface {
+	GLS() *gls.GLS
+	RenderScene(scene core.INode, icam camera.ICamera) (bool, error)
+}
+
+// Cube map faces view directions and up vectors in the OpenGL faces order
+var probeFaces = [6][2]math32.Vector3{
+	{{1, 0, 0}, {0, -1, 0}},
+	{{-1, 0, 0}, {0, -1, 0}},
+	{{0, 1, 0}, {0, 0, 1}},
+	{{0, -1, 0}, {0, 0, -1}},
+	{{0, 0, 1}, {0, -1, 0}},
+	{{0, 0, -1}, {0, -1, 0}},
+}
+
+// ReflectionProbe is a node which captures the scene around its world position
+// into a cube map which can be used by materials as local reflections.
+// The capture is only done when requested by the application by calling Capture.
+// Objects using the probe cube map should be hidden during the capture.
+type ReflectionProbe struct {
+	core.Node                       // Embedded node
+	target    *texture.RenderTarget // Cube map render target
+	camera    *camera.Perspective   // Camera used to render the faces
+	box       math32.Box3           // Box projection bounds in world coordinates
+	hasBox    bool                  // Box projection is enabled
+}
+
+// NewReflectionProbe creates and returns a pointer to a new reflection probe
+// with the specified cube map faces size and camera near and far planes.
+func NewReflectionProbe(size int, near, far float32) *ReflectionProbe {
+
+	p := new(ReflectionProbe)
+	p.Node.Init()
+	p.target = texture.NewCubeRenderTarget(size)
+	p.camera = camera.NewPerspective(90, 1, near, far)
+	return p
+}
+
+// Texture returns the cube map with the last captured environment.
+func (p *ReflectionProbe) Texture() *texture.TextureCube {
+
+	return p.target.CubeTexture()
+}
+
+// SetBox sets the world space bounds used for the box projection correction
+// of the reflections, normally the room or area around the probe.
+func (p *ReflectionProbe) SetBox(box *math32.Box3) {
+
+	if box == nil {
+		p.hasBox = false
+		return
+	}
+	p.box = *box
+	p.hasBox = true
+}
+
+// Box returns the box projection bounds and whether it is enabled.
+func (p *ReflectionProbe) Box() (math32.Box3, bool) {
+
+	return p.box, p.hasBox
+}
+
+// Apply sets the probe cube map and box projection as the environment
+// map of the specified material with the specified reflectivity.
+func (p *ReflectionProbe) Apply(mat *material.Standard, reflectivity float32) {
+
+	mat.SetEnvMap(p.Texture(), reflectivity)
+	if p.hasBox {
+		var pos math32.Vector3
+		p.WorldPosition(&pos)
+		mat.SetEnvMapBox(&p.box.Min, &p.box.Max, &pos)
+	} else {
+		mat.SetEnvMapBox(nil, nil, nil)
+	}
+}
+
+// Capture renders the specified scene from the probe world position
+// into the six faces of the probe cube map.
+func (p *ReflectionProbe) Capture(r SceneRenderer, scene core.INode) error {
+
+	var pos, target math32.Vector3
+	p.WorldPosition(&pos)
+	p.camera.SetPositionVec(&pos)
+	for face := 0; face < 6; face++ {
+		target.AddVectors(&pos, &probeFaces[face][0])
+		p.camera.SetUp(&probeFaces[face][1])
+		p.camera.LookAt(&target)
+
+		p.target.SetCubeFace(face)
+		gs := r.GLS()
+		err := p.target.Bind(gs)
+		if err != nil {
+			return err
+		}
+		gs.Clear(gls.DEPTH_BUFFER_BIT | gls.COLOR_BUFFER_BIT)
+		_, err = r.RenderScene(scene, p.camera)
+		p.target.Unbind()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Dispose releases the OpenGL resources of this probe.
+func (p *ReflectionProbe) Dispose() {
+
+	p.target.Dispose()
+	p.Node.Dispose()
+}
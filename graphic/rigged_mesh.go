@@ -9,6 +9,7 @@ import (
 	"strconv"
 
 	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/geometry"
 	"github.com/thommil/tge-g3n/gls"
 	"github.com/thommil/tge-g3n/math32"
 )
@@ -16,11 +17,22 @@ import (
 // MaxBoneInfluencers is the maximum number of bone influencers per vertex.
 const MaxBoneInfluencers = 4
 
+// Number of vertex uniform vectors reserved for the non bone uniforms
+// when checking if the bone matrices fit in the vertex shader.
+const skinningReservedVectors = 64
+
 // RiggedMesh is a Mesh associated with a skeleton.
+// The vertices are skinned in the vertex shader unless the bone matrices
+// exceed the vertex uniform vectors limit of the OpenGL context, in which case
+// they are transformed on the CPU into buffers of the mesh, which are streamed
+// each frame without changing the geometry shared with other meshes.
 type RiggedMesh struct {
-	*Mesh    // Embedded mesh
-	skeleton *Skeleton
-	mBones   gls.Uniform
+	*Mesh          // Embedded mesh
+	skeleton       *Skeleton
+	mBones         gls.Uniform
+	cpuSkinning    bool               // Vertices are skinned on the CPU
+	skinningChosen bool               // Skinning method was selected
+	skinGeom       *geometry.Geometry // Geometry drawn with the vertices skinned on the CPU
 }
 
 // NewRiggedMesh returns a new rigged mesh.
@@ -43,12 +55,53 @@ func (rm *RiggedMesh) SetSkeleton(sk *Skeleton) {
 	rm.ShaderDefines.Set("TOTAL_BONES", strconv.Itoa(len(rm.skeleton.Bones())))
 }
 
-// SetSkeleton returns the skeleton used by the rigged mesh.
+// Skeleton returns the skeleton used by the rigged mesh.
 func (rm *RiggedMesh) Skeleton() *Skeleton {
 
 	return rm.skeleton
 }
 
+// SetCPUSkinning forces the vertices to be skinned on the CPU (true) or
+// in the vertex shader (false). By default the method is selected by SelectSkinning.
+func (rm *RiggedMesh) SetCPUSkinning(state bool) {
+
+	rm.cpuSkinning = state
+	rm.skinningChosen = true
+	if state {
+		rm.ShaderDefines.Unset("BONE_INFLUENCERS")
+		if rm.skinGeom == nil {
+			rm.skinGeom = newSkinGeometry(rm.GetGeometry())
+			rm.drawGeom = rm.skinGeom
+		}
+	} else {
+		rm.ShaderDefines.Set("BONE_INFLUENCERS", strconv.Itoa(MaxBoneInfluencers))
+		if rm.skinGeom != nil {
+			rm.skinGeom.Dispose()
+			rm.skinGeom = nil
+			rm.drawGeom = nil
+		}
+	}
+}
+
+// CPUSkinning returns whether the vertices are skinned on the CPU.
+func (rm *RiggedMesh) CPUSkinning() bool {
+
+	return rm.cpuSkinning
+}
+
+// SelectSkinning selects the skinning method from the MaxVertexUniformVectors limit
+// of the capabilities of the specified OpenGL state, unless it was already selected
+// or set by SetCPUSkinning. It is called by the renderer before the shader program
+// of the mesh is selected.
+func (rm *RiggedMesh) SelectSkinning(gs *gls.GLS) {
+
+	if rm.skinningChosen || rm.skeleton == nil {
+		return
+	}
+	maxVectors := gs.Capabilities().MaxVertexUniformVectors
+	rm.SetCPUSkinning(4*len(rm.skeleton.Bones())+skinningReservedVectors > maxVectors)
+}
+
 // RenderSetup is called by the renderer before drawing the geometry.
 func (rm *RiggedMesh) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
 
@@ -64,8 +117,100 @@ func (rm *RiggedMesh) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
 		fmt.Println("ERROR : Skeleton.BoneMatrices: inverting matrix failed!")
 	}
 
-	// Transfer bone matrices
 	boneMatrices := rm.skeleton.BoneMatrices(&invMat)
+	if rm.cpuSkinning {
+		rm.skinVertices(gs, boneMatrices)
+		return
+	}
+
+	// Transfer bone matrices
 	location := rm.mBones.Location(gs)
 	gs.UniformMatrix4fv(location, int32(len(boneMatrices)), false, &boneMatrices[0][0])
 }
+
+// skinVertices transforms the bind pose vertex positions and normals of the mesh
+// geometry by the specified bone matrices into the VBOs of the skinned geometry.
+func (rm *RiggedMesh) skinVertices(gs *gls.GLS, boneMatrices []math32.Matrix4) {
+
+	geom := rm.GetGeometry()
+	bindPos := geom.VBO(gls.VertexPosition)
+	vboIndices := geom.VBO(gls.SkinIndex)
+	vboWeights := geom.VBO(gls.SkinWeight)
+	if bindPos == nil || vboIndices == nil || vboWeights == nil {
+		return
+	}
+	bindNormal := geom.VBO(gls.VertexNormal)
+	vboPos := rm.skinGeom.VBO(gls.VertexPosition)
+	vboNormal := rm.skinGeom.VBO(gls.VertexNormal)
+
+	var skinMat math32.Matrix4
+	var indices, weights math32.Vector4
+	var vec math32.Vector3
+	items := geom.Items()
+	for i := 0; i < items; i++ {
+		// Blends the bone matrices of this vertex influencers
+		vboIndices.Buffer().GetVector4(i*vboIndices.Stride()+vboIndices.AttribOffset(gls.SkinIndex), &indices)
+		vboWeights.Buffer().GetVector4(i*vboWeights.Stride()+vboWeights.AttribOffset(gls.SkinWeight), &weights)
+		skinMat = math32.Matrix4{}
+		for j := 0; j < MaxBoneInfluencers; j++ {
+			w := weights.Component(j)
+			if w == 0 {
+				continue
+			}
+			bone := &boneMatrices[int(indices.Component(j))]
+			for k := range skinMat {
+				skinMat[k] += bone[k] * w
+			}
+		}
+
+		offset := i*bindPos.Stride() + bindPos.AttribOffset(gls.VertexPosition)
+		bindPos.Buffer().GetVector3(offset, &vec)
+		vec.ApplyMatrix4(&skinMat)
+		vboPos.Buffer().SetVector3(offset, &vec)
+		if bindNormal != nil {
+			// Normals are transformed without the translation
+			skinMat[12], skinMat[13], skinMat[14] = 0, 0, 0
+			offset = i*bindNormal.Stride() + bindNormal.AttribOffset(gls.VertexNormal)
+			bindNormal.Buffer().GetVector3(offset, &vec)
+			vec.ApplyMatrix4(&skinMat).Normalize()
+			vboNormal.Buffer().SetVector3(offset, &vec)
+		}
+	}
+
+	// The skinned geometry VBOs were already transferred for this draw
+	vboPos.Update()
+	vboPos.Transfer(gs)
+	if vboNormal != nil && vboNormal != vboPos {
+		vboNormal.Update()
+		vboNormal.Transfer(gs)
+	}
+}
+
+// newSkinGeometry returns a geometry drawing the vertices of the specified geometry
+// with its own VBOs. The VBOs with the vertex positions or normals have copies of
+// the data, which are overwritten by the skinned vertices, and the other VBOs share
+// the data of the specified geometry.
+func newSkinGeometry(src *geometry.Geometry) *geometry.Geometry {
+
+	geom := geometry.NewGeometry()
+	for _, vbo := range src.VBOs() {
+		var nvbo *gls.VBO
+		switch {
+		case vbo.Bytes() != nil:
+			nvbo = gls.NewVBOBytes(vbo.Bytes())
+		case vbo.Attrib(gls.VertexPosition) != nil || vbo.Attrib(gls.VertexNormal) != nil:
+			nvbo = gls.NewVBO(append(math32.ArrayF32(nil), *vbo.Buffer()...))
+			nvbo.SetUsage(gls.STREAM_DRAW)
+		default:
+			nvbo = gls.NewVBO(*vbo.Buffer())
+		}
+		for i, attrib := range vbo.Attributes() {
+			nvbo.AddCustomAttribOffset(attrib.Name, attrib.NumElements, attrib.ByteOffset)
+			*nvbo.AttribAt(i) = attrib
+		}
+		geom.AddVBO(nvbo)
+	}
+	geom.SetIndices(src.Indices())
+	geom.SetPrimitiveRestart(src.PrimitiveRestart())
+	return geom
+}
@@ -0,0 +1,84 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"math"
+
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/geometry"
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/material"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// SkyDome is the Graphic of a sky background with a vertical color gradient,
+// a lightweight alternative to a Skybox which does not require textures.
+// It is centered on the camera and rendered first, behind all other objects.
+type SkyDome struct {
+	Graphic                       // Embedded graphic object
+	mat     *material.SkyGradient // Gradient material
+	uniMVPm gls.Uniform           // Model view projection matrix uniform location cache
+}
+
+// NewSkyDome creates and returns a pointer to a new SkyDome
+// with the specified top, horizon and bottom colors.
+func NewSkyDome(top, horizon, bottom *math32.Color) *SkyDome {
+
+	sd := new(SkyDome)
+	geom := geometry.NewSphere(1, 32, 16, 0, 2*math.Pi, 0, math.Pi)
+	sd.Graphic.Init(geom, gls.TRIANGLES)
+	sd.Graphic.SetCullable(false)
+
+	// The inside of the sphere is visible and the depth buffer is not written,
+	// so every other object is drawn over the dome.
+	sd.mat = material.NewSkyGradient(top, horizon, bottom)
+	sd.mat.SetSide(material.SideBack)
+	sd.mat.SetDepthMask(false)
+	sd.AddMaterial(sd, sd.mat, 0, 0)
+
+	sd.uniMVPm.Init("MVP")
+
+	// The sky dome should always be rendered first
+	sd.SetRenderOrder(-100)
+	return sd
+}
+
+// CoversBackground satisfies the core.ISky interface and returns whether
+// the material of this sky dome is opaque, so it covers the whole background.
+func (sd *SkyDome) CoversBackground() bool {
+
+	return opaqueMaterials(&sd.Graphic)
+}
+
+// Material returns the gradient material of this sky dome.
+func (sd *SkyDome) Material() *material.SkyGradient {
+
+	return sd.mat
+}
+
+// SetColors sets the top, horizon and bottom colors of the gradient.
+func (sd *SkyDome) SetColors(top, horizon, bottom *math32.Color) {
+
+	sd.mat.SetColors(top, horizon, bottom)
+}
+
+// RenderSetup is called by the engine before drawing the sky dome geometry
+// It is responsible to updating the current shader uniforms with
+// the model matrices.
+func (sd *SkyDome) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
+
+	// Clear translation so the dome is centered on the camera
+	mvm := *sd.ModelViewMatrix()
+	mvm[12] = 0
+	mvm[13] = 0
+	mvm[14] = 0
+
+	// Calculates model view projection matrix and updates uniform
+	var mvpm math32.Matrix4
+	mvpm.MultiplyMatrices(&rinfo.ProjMatrix, &mvm)
+	location := sd.uniMVPm.Location(gs)
+	gs.UniformMatrix4fv(location, 1, false, &mvpm[0])
+}
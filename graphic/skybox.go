@@ -42,8 +42,8 @@ func NewSkybox(data SkyboxData) (*Skybox, error) {
 		if err != nil {
 			return nil, err
 		}
-		matFace := material.NewStandard(math32.NewColor("white"))
-		matFace.AddTexture(tex)
+		matFace := material.NewStandard(&math32.Color{0, 0, 0})
+		matFace.SetEmissiveMap(tex)
 		matFace.SetSide(material.SideBack)
 		matFace.SetUseLights(material.UseLightNone)
 		matFace.SetEmissiveColor(&math32.Color{1, 1, 1})
@@ -67,6 +67,26 @@ func NewSkybox(data SkyboxData) (*Skybox, error) {
 	return skybox, nil
 }
 
+// CoversBackground satisfies the core.ISky interface and returns whether
+// the materials of this skybox are opaque, so it covers the whole background.
+func (skybox *Skybox) CoversBackground() bool {
+
+	return opaqueMaterials(&skybox.Graphic)
+}
+
+// opaqueMaterials returns whether the materials of the specified graphic
+// are neither transparent nor rendered as wireframes.
+func opaqueMaterials(gr *Graphic) bool {
+
+	for _, grmat := range gr.Materials() {
+		mat := grmat.IMaterial().GetMaterial()
+		if mat.Transparent() || mat.Wireframe() {
+			return false
+		}
+	}
+	return true
+}
+
 // RenderSetup is called by the engine before drawing the skybox geometry
 // It is responsible to updating the current shader uniforms with
 // the model matrices.
@@ -0,0 +1,84 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/geometry"
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/material"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// ThickLines is a Graphic which is rendered as a collection of independent lines
+// with a constant width in pixels, which is not limited by the drivers as LineWidth.
+// Each line is drawn as a quad facing the camera expanded by the vertex shader.
+type ThickLines struct {
+	Graphic                     // Embedded graphic object
+	mat     *material.ThickLine // Thick line material
+	uniMVPm gls.Uniform         // Model view projection matrix uniform location cache
+}
+
+// Vertices of the quad of each line: end point (0 for the start, 1 for the end)
+// and side of the vertex relative to the direction from the vertex to the other end.
+// The start and end of the line are the first two vertices, as used by raycasting.
+var thickLineQuad = [6]struct {
+	end  int
+	side float32
+}{{0, 1}, {1, -1}, {0, -1}, {0, -1}, {1, -1}, {1, 1}}
+
+// NewThickLines creates and returns a pointer to a new ThickLines object
+// with the specified pairs of start and end points of the lines,
+// drawn white with the specified width in pixels.
+func NewThickLines(points []math32.Vector3, width float32) *ThickLines {
+
+	if len(points)%2 != 0 {
+		panic("NewThickLines: odd number of points")
+	}
+	count := len(points) / 2 * len(thickLineQuad)
+	positions := math32.NewArrayF32(0, 3*count)
+	others := math32.NewArrayF32(0, 3*count)
+	sides := math32.NewArrayF32(0, count)
+	for i := 0; i < len(points); i += 2 {
+		for _, v := range thickLineQuad {
+			positions.AppendVector3(&points[i+v.end])
+			others.AppendVector3(&points[i+1-v.end])
+			sides.Append(v.side)
+		}
+	}
+	geom := geometry.NewGeometry()
+	geom.AddVBO(gls.NewVBO(positions).AddAttrib(gls.VertexPosition))
+	geom.AddVBO(gls.NewVBO(others).AddCustomAttrib("LineOther", 3))
+	geom.AddVBO(gls.NewVBO(sides).AddCustomAttrib("LineSide", 1))
+
+	l := new(ThickLines)
+	l.Graphic.Init(geom, gls.TRIANGLES)
+	l.mat = material.NewThickLine(&math32.Color{1, 1, 1}, width)
+	l.AddMaterial(l, l.mat, 0, 0)
+	l.uniMVPm.Init("MVP")
+	return l
+}
+
+// Material returns the thick line material of these lines.
+func (l *ThickLines) Material() *material.ThickLine {
+
+	return l.mat
+}
+
+// RenderSetup is called by the engine before drawing this geometry.
+func (l *ThickLines) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
+
+	// Transfer model view projection matrix uniform
+	mvpm := l.ModelViewProjectionMatrix()
+	location := l.uniMVPm.Location(gs)
+	gs.UniformMatrix4fv(location, 1, false, &mvpm[0])
+}
+
+// Raycast satisfies the INode interface and checks the intersections
+// of the center lines of these lines with the specified raycaster.
+func (l *ThickLines) Raycast(rc *core.Raycaster, intersects *[]core.Intersect) {
+
+	lineRaycast(l, rc, intersects, len(thickLineQuad))
+}
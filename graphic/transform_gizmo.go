@@ -0,0 +1,331 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// GizmoMode is the transformation mode of a TransformGizmo.
+type GizmoMode int
+
+// The transformation modes of a TransformGizmo
+const (
+	GizmoTranslate = GizmoMode(iota) // Translates the target along an axis
+	GizmoRotate                      // Rotates the target around an axis
+	GizmoScale                       // Scales the target along an axis
+)
+
+// Colors of the gizmo axes handles and of the dragged handle
+var gizmoColors = [3]math32.Color{{1, 0.2, 0.2}, {0.2, 1, 0.2}, {0.2, 0.4, 1}}
+var gizmoDragColor = math32.Color{1, 1, 0}
+
+// TransformGizmo is an interactive handle which translates, rotates or scales
+// a target node along or around the world axes while its handles are dragged.
+// It must be added to the root of the scene and is drawn over the other objects.
+//
+// The application forwards the pointer events with a raycaster set from the camera
+// at the pointer position, such as with camera.SetRaycaster: PointerDown hit tests
+// the handles of the current mode with the raycaster, whose LinePrecision is the
+// hit distance in world units, and the following calls of PointerMove drag the
+// target until PointerUp. Update must be called once per frame.
+type TransformGizmo struct {
+	core.Node                         // Embedded node
+	EnableTranslate bool              // Translate mode enabled state
+	EnableRotate    bool              // Rotate mode enabled state
+	EnableScale     bool              // Scale mode enabled state
+	TranslateSnap   float32           // Translation increment in world units or 0 for none
+	RotateSnap      float32           // Rotation increment in radians or 0 for none
+	ScaleSnap       float32           // Scale factor increment or 0 for none
+	target          core.INode        // Transformed node
+	mode            GizmoMode         // Current mode
+	size            float32           // Length of the handles in world units
+	handles         [3][3]*ThickLines // Handles by mode and axis
+	axis            int               // Dragged axis or -1
+	start           float32           // Position along the axis or angle at the start of the drag
+	origin          math32.Vector3    // World position of the target and of the gizmo at the start of the drag
+	startQuat       math32.Quaternion // Local quaternion of the target at the start of the drag
+	startScale      math32.Vector3    // Local scale of the target at the start of the drag
+}
+
+// NewTransformGizmo creates and returns a pointer to a new TransformGizmo
+// in translate mode with handles of the specified length in world units.
+func NewTransformGizmo(size float32) *TransformGizmo {
+
+	g := new(TransformGizmo)
+	g.Node.Init()
+	g.EnableTranslate = true
+	g.EnableRotate = true
+	g.EnableScale = true
+	g.size = size
+	g.axis = -1
+	for mode := range g.handles {
+		for axis := range g.handles[mode] {
+			h := NewThickLines(gizmoHandle(GizmoMode(mode), axis, size), 3)
+			h.Material().SetColor(&gizmoColors[axis])
+			h.Material().SetDepthTest(false)
+			h.SetRenderOrder(100)
+			h.SetCullable(false)
+			g.handles[mode][axis] = h
+			g.Add(h)
+		}
+	}
+	g.Update()
+	return g
+}
+
+// gizmoHandle returns the lines of the handle of the specified mode and axis.
+func gizmoHandle(mode GizmoMode, axis int, size float32) []math32.Vector3 {
+
+	var u, v, w math32.Vector3
+	u.SetComponent(axis, size)
+	v.SetComponent((axis+1)%3, size)
+	w.SetComponent((axis+2)%3, size)
+	at := func(a, b, c float32) math32.Vector3 {
+		var p, t math32.Vector3
+		p.Copy(&u).MultiplyScalar(a)
+		p.Add(t.Copy(&v).MultiplyScalar(b))
+		p.Add(t.Copy(&w).MultiplyScalar(c))
+		return p
+	}
+	var points []math32.Vector3
+	switch mode {
+	case GizmoTranslate:
+		// Axis with an arrow head
+		points = append(points, at(0, 0, 0), at(1, 0, 0))
+		for _, d := range [][2]float32{{0.06, 0}, {-0.06, 0}, {0, 0.06}, {0, -0.06}} {
+			points = append(points, at(1, 0, 0), at(0.85, d[0], d[1]))
+		}
+	case GizmoRotate:
+		// Circle around the axis
+		const segments = 48
+		for i := 0; i < segments; i++ {
+			a0 := 2 * math32.Pi * float32(i) / segments
+			a1 := 2 * math32.Pi * float32(i+1) / segments
+			points = append(points, at(0, math32.Cos(a0), math32.Sin(a0)), at(0, math32.Cos(a1), math32.Sin(a1)))
+		}
+	case GizmoScale:
+		// Axis with a square at the end
+		points = append(points, at(0, 0, 0), at(1, 0, 0))
+		corners := [4][2]float32{{0.05, 0.05}, {-0.05, 0.05}, {-0.05, -0.05}, {0.05, -0.05}}
+		for i, c := range corners {
+			n := corners[(i+1)%4]
+			points = append(points, at(1, c[0], c[1]), at(1, n[0], n[1]))
+		}
+	}
+	return points
+}
+
+// SetTarget sets the node transformed by this gizmo or nil to hide the gizmo.
+func (g *TransformGizmo) SetTarget(target core.INode) {
+
+	g.target = target
+	g.axis = -1
+	g.Update()
+}
+
+// Target returns the node transformed by this gizmo.
+func (g *TransformGizmo) Target() core.INode {
+
+	return g.target
+}
+
+// SetMode sets the transformation mode of this gizmo.
+func (g *TransformGizmo) SetMode(mode GizmoMode) {
+
+	if mode < GizmoTranslate || mode > GizmoScale {
+		panic("TransformGizmo.SetMode: invalid mode")
+	}
+	g.mode = mode
+	g.axis = -1
+	g.Update()
+}
+
+// Mode returns the transformation mode of this gizmo.
+func (g *TransformGizmo) Mode() GizmoMode {
+
+	return g.mode
+}
+
+// Dragging returns whether a handle of this gizmo is being dragged.
+func (g *TransformGizmo) Dragging() bool {
+
+	return g.axis >= 0
+}
+
+// enabled returns whether the specified mode is enabled.
+func (g *TransformGizmo) enabled(mode GizmoMode) bool {
+
+	switch mode {
+	case GizmoTranslate:
+		return g.EnableTranslate
+	case GizmoRotate:
+		return g.EnableRotate
+	default:
+		return g.EnableScale
+	}
+}
+
+// Update moves this gizmo to the world position of its target and shows the
+// handles of the current mode if enabled. It should be called once per frame.
+func (g *TransformGizmo) Update() {
+
+	visible := g.target != nil && g.enabled(g.mode)
+	if g.target != nil {
+		var pos math32.Vector3
+		g.target.GetNode().WorldPosition(&pos)
+		g.SetPositionVec(&pos)
+	}
+	for mode := range g.handles {
+		for axis, h := range g.handles[mode] {
+			h.SetVisible(visible && GizmoMode(mode) == g.mode)
+			if axis == g.axis {
+				h.Material().SetColor(&gizmoDragColor)
+			} else {
+				h.Material().SetColor(&gizmoColors[axis])
+			}
+		}
+	}
+}
+
+// PointerDown hit tests the handles of the current mode with the specified
+// raycaster and starts dragging the closest one. It returns whether a handle
+// was hit, in which case the pointer event should not be used by other controls.
+func (g *TransformGizmo) PointerDown(rc *core.Raycaster) bool {
+
+	g.axis = -1
+	if g.target == nil || !g.enabled(g.mode) {
+		return false
+	}
+	g.Update()
+	g.UpdateMatrixWorld()
+	var inodes []core.INode
+	for _, h := range g.handles[g.mode] {
+		inodes = append(inodes, h)
+	}
+	intersects := rc.IntersectObjects(inodes, false)
+	if len(intersects) == 0 {
+		return false
+	}
+	g.origin = g.Position()
+	for axis, h := range g.handles[g.mode] {
+		if intersects[0].Object == core.INode(h) {
+			start, ok := g.dragParam(&rc.Ray, axis)
+			if !ok {
+				return false
+			}
+			g.axis = axis
+			g.start = start
+		}
+	}
+	node := g.target.GetNode()
+	g.startQuat = node.Quaternion()
+	g.startScale = node.Scale()
+	g.Update()
+	return g.axis >= 0
+}
+
+// PointerMove transforms the target according to the dragged handle
+// and the specified raycaster. It does nothing if no handle is dragged.
+func (g *TransformGizmo) PointerMove(rc *core.Raycaster) {
+
+	if g.axis < 0 || g.target == nil {
+		return
+	}
+	param, ok := g.dragParam(&rc.Ray, g.axis)
+	if !ok {
+		return
+	}
+	node := g.target.GetNode()
+	switch g.mode {
+	case GizmoTranslate:
+		delta := snap(param-g.start, g.TranslateSnap)
+		var pos math32.Vector3
+		pos.SetComponent(g.axis, delta)
+		pos.Add(&g.origin)
+		if parent := node.Parent(); parent != nil {
+			var inverse math32.Matrix4
+			pmw := parent.GetNode().MatrixWorld()
+			inverse.GetInverse(&pmw)
+			pos.ApplyMatrix4(&inverse)
+		}
+		node.SetPositionVec(&pos)
+	case GizmoRotate:
+		angle := snap(param-g.start, g.RotateSnap)
+		var axis math32.Vector3
+		axis.SetComponent(g.axis, 1)
+		if parent := node.Parent(); parent != nil {
+			var pq math32.Quaternion
+			parent.GetNode().WorldQuaternion(&pq)
+			axis.ApplyQuaternion(pq.Inverse())
+		}
+		var q math32.Quaternion
+		q.SetFromAxisAngle(&axis, angle)
+		q.Multiply(&g.startQuat)
+		node.SetQuaternionQuat(&q)
+	case GizmoScale:
+		factor := snap(1+(param-g.start)/g.size, g.ScaleSnap)
+		if factor < 1e-3 {
+			factor = 1e-3
+		}
+		scale := g.startScale
+		scale.SetComponent(g.axis, scale.Component(g.axis)*factor)
+		node.SetScaleVec(&scale)
+	}
+	g.Update()
+}
+
+// PointerUp stops dragging the handle.
+func (g *TransformGizmo) PointerUp() {
+
+	g.axis = -1
+	g.Update()
+}
+
+// dragParam returns the position along the specified axis from the drag origin
+// closest to the specified ray, or the angle around the axis of the intersection
+// of the ray and the plane of the rotation handle, and whether it is defined.
+func (g *TransformGizmo) dragParam(ray *math32.Ray, axis int) (float32, bool) {
+
+	origin := g.origin
+	var dir math32.Vector3
+	dir.SetComponent(axis, 1)
+	rorigin := ray.Origin()
+	rdir := ray.Direction()
+	var w math32.Vector3
+	w.SubVectors(&origin, &rorigin)
+
+	if g.mode == GizmoRotate {
+		// Intersects the ray with the plane of the handle
+		denom := rdir.Dot(&dir)
+		if math32.Abs(denom) < 1e-6 {
+			return 0, false
+		}
+		var p math32.Vector3
+		ray.At(w.Dot(&dir)/denom, &p)
+		p.Sub(&origin)
+		u := p.Component((axis + 1) % 3)
+		v := p.Component((axis + 2) % 3)
+		return math32.Atan2(v, u), true
+	}
+
+	// Closest points of the ray and of the axis line
+	b := dir.Dot(&rdir)
+	denom := 1 - b*b
+	if denom < 1e-6 {
+		return 0, false
+	}
+	return (b*rdir.Dot(&w) - dir.Dot(&w)) / denom, true
+}
+
+// snap returns the specified value rounded to the specified increment if not zero.
+func snap(value, increment float32) float32 {
+
+	if increment <= 0 {
+		return value
+	}
+	return math32.Round(value/increment) * increment
+}
@@ -0,0 +1,192 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ibl implements the precomputation of the image based lighting textures
+// of physically based materials: the diffuse irradiance and the prefiltered specular
+// cube maps of an environment and the split sum BRDF lookup table.
+// They are rendered offscreen once, normally at startup, and can be cached by the application.
+package ibl
+
+import (
+	"github.com/thommil/tge-g3n/camera"
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/geometry"
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/graphic"
+	"github.com/thommil/tge-g3n/material"
+	"github.com/thommil/tge-g3n/texture"
+)
+
+// Faces size of the irradiance cube maps
+const irradianceSize = 32
+
+// Number of importance samples of the specular prefiltering and of the BRDF integration
+const (
+	prefilterSamples = 512
+	brdfSamples      = 512
+)
+
+// GenerateIrradiance renders and returns the diffuse irradiance cube map of the specified
+// environment cube map, the cosine weighted average of the environment over the hemisphere
+// of each direction, using the specified renderer.
+func GenerateIrradiance(r graphic.SceneRenderer, env *texture.TextureCube) (*texture.TextureCube, error) {
+
+	b := newBaker(r, "ibl_irradiance", env)
+	defer b.dispose()
+	target := texture.NewCubeRenderTarget(irradianceSize)
+	defer target.Dispose()
+	for face := 0; face < 6; face++ {
+		b.mat.info[0] = float32(face)
+		target.SetCubeFace(face)
+		err := b.render(target)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return target.CubeTexture().Incref(), nil
+}
+
+// PrefilterSpecular renders and returns the prefiltered specular cube map of the specified
+// environment cube map, with the size of the environment and the specified number of mip levels
+// whose roughness increases linearly from 0 at the first level to 1 at the last one.
+// The environment should have mipmaps, which are sampled to reduce the aliasing.
+func PrefilterSpecular(r graphic.SceneRenderer, env *texture.TextureCube, mipLevels int) (*texture.TextureCube, error) {
+
+	size := env.Size()
+	if mipLevels < 1 || size>>uint(mipLevels-1) == 0 {
+		panic("PrefilterSpecular: invalid number of mip levels")
+	}
+	b := newBaker(r, "ibl_prefilter", env)
+	defer b.dispose()
+	target := texture.NewCubeRenderTarget(size)
+	defer target.Dispose()
+	target.SetCubeLevels(mipLevels)
+	b.mat.info[2] = prefilterSamples
+	b.mat.info[3] = float32(size)
+	for level := 0; level < mipLevels; level++ {
+		if mipLevels > 1 {
+			b.mat.info[1] = float32(level) / float32(mipLevels-1)
+		}
+		target.SetCubeLevel(level)
+		for face := 0; face < 6; face++ {
+			b.mat.info[0] = float32(face)
+			target.SetCubeFace(face)
+			err := b.render(target)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return target.CubeTexture().Incref(), nil
+}
+
+// GenerateBRDFLUT renders and returns the split sum BRDF lookup table of the specified size,
+// indexed by the cosine of the view angle (s) and the roughness (t), whose red and green
+// channels are the scale and the bias of the specular color. It stores half float values.
+func GenerateBRDFLUT(r graphic.SceneRenderer, size int) (*texture.Texture2D, error) {
+
+	if size <= 0 {
+		panic("GenerateBRDFLUT: invalid size")
+	}
+	b := newBaker(r, "ibl_brdf", nil)
+	defer b.dispose()
+	target := texture.NewRenderTarget(size, size)
+	defer target.Dispose()
+	target.SetHDR(true)
+	b.mat.info[2] = brdfSamples
+	err := b.render(target)
+	if err != nil {
+		return nil, err
+	}
+	lut := target.Texture().Incref()
+	lut.SetWrapS(gls.CLAMP_TO_EDGE)
+	lut.SetWrapT(gls.CLAMP_TO_EDGE)
+	return lut, nil
+}
+
+// baker renders a full screen quad with a precomputation shader into render targets.
+type baker struct {
+	r     graphic.SceneRenderer // Renderer of the quad
+	scene *core.Node            // Scene with the quad
+	quad  *bakeQuad             // Full screen quad
+	mat   *bakeMaterial         // Material of the quad with the precomputation shader
+	cam   *camera.Perspective   // Unused camera required to render the scene
+}
+
+// bakeQuad is the full screen graphic rendered by the baker.
+// Its vertices are already in clip space, so it has no matrices to transfer.
+type bakeQuad struct {
+	graphic.Graphic
+}
+
+// bakeMaterial is the material of the baker quad, which transfers the environment and the parameters.
+type bakeMaterial struct {
+	material.Material                      // Embedded material with the precomputation shader
+	env               *texture.TextureCube // Environment cube map or nil
+	info              [4]float32           // Cube face, roughness, number of samples and environment size
+	uniEnv            gls.Uniform          // Environment sampler uniform location cache
+	uniInfo           gls.Uniform          // Parameters uniform location cache
+}
+
+// newBaker creates and returns a pointer to a new baker with the specified shader program and environment.
+func newBaker(r graphic.SceneRenderer, shader string, env *texture.TextureCube) *baker {
+
+	b := new(baker)
+	b.r = r
+	b.mat = new(bakeMaterial)
+	b.mat.Material.Init()
+	b.mat.SetShader(shader)
+	b.mat.SetShaderUnique(true)
+	b.mat.SetUseLights(material.UseLightNone)
+	b.mat.SetSide(material.SideDouble)
+	b.mat.SetDepthTest(false)
+	b.mat.SetDepthMask(false)
+	b.mat.SetBlending(material.BlendingNone)
+	b.mat.env = env
+	b.mat.uniEnv.Init("IBLEnvMap")
+	b.mat.uniInfo.Init("IBLInfo")
+
+	b.quad = new(bakeQuad)
+	b.quad.Graphic.Init(geometry.NewPlane(2, 2, 1, 1), gls.TRIANGLES)
+	b.quad.AddMaterial(b.quad, b.mat, 0, 0)
+	b.quad.SetCullable(false)
+	b.scene = core.NewNode()
+	b.scene.Add(b.quad)
+	b.cam = camera.NewPerspective(90, 1, 0.1, 10)
+	return b
+}
+
+// render renders the quad into the current face and level of the specified render target.
+func (b *baker) render(target *texture.RenderTarget) error {
+
+	gs := b.r.GLS()
+	err := target.Bind(gs)
+	if err != nil {
+		return err
+	}
+	_, err = b.r.RenderScene(b.scene, b.cam)
+	target.Unbind()
+	return err
+}
+
+// dispose releases the quad geometry and material.
+func (b *baker) dispose() {
+
+	b.quad.Dispose()
+}
+
+// RenderSetup satisfies the IGraphic interface and does nothing.
+func (q *bakeQuad) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
+}
+
+// RenderSetup is called by the renderer before drawing the quad.
+func (m *bakeMaterial) RenderSetup(gs *gls.GLS) {
+
+	m.Material.RenderSetup(gs)
+	if m.env != nil {
+		m.env.RenderSetup(gs, 0)
+		gs.Uniform1i(m.uniEnv.Location(gs), 0)
+	}
+	gs.Uniform4fv(m.uniInfo.Location(gs), 1, m.info[:])
+}
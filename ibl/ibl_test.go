@@ -0,0 +1,65 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ibl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/renderer"
+	"github.com/thommil/tge-g3n/texture"
+)
+
+// Test that the prefiltered specular cube map renders each face of each mip level
+func TestPrefilterSpecular(t *testing.T) {
+
+	rec := gls.NewRecorder()
+	gs, err := gls.NewWithBackend(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := renderer.NewRenderer(gs)
+	err = r.AddDefaultShaders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := texture.NewTextureCube(64)
+	cube, err := PrefilterSpecular(r, env, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cube.Size() != 64 || cube.Levels() != 4 {
+		t.Errorf("expected size 64 and 4 levels got %d and %d", cube.Size(), cube.Levels())
+	}
+	attached := 0
+	draws := 0
+	lastLevel := 0
+	for _, c := range rec.Calls() {
+		switch c.Name {
+		case "FramebufferTexture2D":
+			attached++
+		case "DrawElements":
+			draws++
+		}
+		if c.Name == "TexImage2D" && strings.Contains(c.String(), ", 3, 8, 8, ") {
+			lastLevel++
+		}
+	}
+	if lastLevel != 6 {
+		t.Errorf("expected the last level of size 8 of the 6 faces allocated got %d", lastLevel)
+	}
+	if attached != 6*4 || draws != 6*4 {
+		t.Errorf("expected %d faces rendered got %d attached and %d drawn", 6*4, attached, draws)
+	}
+
+	lut, err := GenerateBRDFLUT(r, 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lut.Width() != 128 || lut.TexName() == 0 {
+		t.Errorf("expected an allocated lookup table of size 128 got %d", lut.Width())
+	}
+}
@@ -12,13 +12,31 @@ import (
 	"github.com/thommil/tge-g3n/math32"
 )
 
+// ShadowFilter is the filtering of the shadow map samples of a light.
+type ShadowFilter int
+
+// The shadow filters
+const (
+	ShadowFilterNone        = ShadowFilter(iota) // Hard shadows from a single sample
+	ShadowFilterPCF                              // Percentage closer filtering of a square kernel of samples
+	ShadowFilterExponential                      // Exponential falloff with the depth behind the occluders
+	ShadowFilterVariance                         // Variance shadow map of the blurred depth moments
+)
+
 // Directional represents a directional, positionless light
 type Directional struct {
-	core.Node              // Embedded node
-	color     math32.Color // Light color
-	intensity float32      // Light intensity
-	uni       gls.Uniform  // Uniform location cache
-	udata     struct {     // Combined uniform data in 2 vec3:
+	core.Node                  // Embedded node
+	color         math32.Color // Light color
+	intensity     float32      // Light intensity
+	uni           gls.Uniform  // Uniform location cache
+	castShadow    bool         // Whether the light casts shadows
+	shadowFilter  ShadowFilter // Filtering of the shadow map samples
+	shadowSamples int          // Width of the PCF kernel or variance blur in texels
+	shadowBias    float32      // Depth bias of the shadow map samples
+	shadowMapSize int          // Width and height of the shadow map in texels
+	shadowSize    float32      // Width and height of the area covered by the shadow map
+	shadowDepth   float32      // Depth of the area covered by the shadow map
+	udata         struct {     // Combined uniform data in 2 vec3:
 		color    math32.Color   // Light color
 		position math32.Vector3 // Light position
 	}
@@ -35,6 +53,12 @@ func NewDirectional(color *math32.Color, intensity float32) *Directional {
 	ld.intensity = intensity
 	ld.uni.Init("DirLight")
 	ld.SetColor(color)
+	ld.shadowFilter = ShadowFilterPCF
+	ld.shadowSamples = 3
+	ld.shadowBias = 0.005
+	ld.shadowMapSize = 1024
+	ld.shadowSize = 20
+	ld.shadowDepth = 100
 	return ld
 }
 
@@ -66,6 +90,106 @@ func (ld *Directional) Intensity() float32 {
 	return ld.intensity
 }
 
+// SetCastShadow sets whether this light casts shadows.
+// The renderer draws the shadows of the first directional light which casts
+// shadows on the materials using the Phong model (standard, phong and terrain).
+func (ld *Directional) SetCastShadow(state bool) {
+
+	ld.castShadow = state
+}
+
+// CastShadow returns whether this light casts shadows.
+func (ld *Directional) CastShadow() bool {
+
+	return ld.castShadow
+}
+
+// SetShadowFilter sets the filtering of the shadow map samples and the width in texels,
+// rounded up to an odd number, of the square kernel of samples of the ShadowFilterPCF mode
+// or of the blur of the ShadowFilterVariance mode. The default is a PCF kernel of 3x3 samples.
+// Variance shadows are soft and filtered by the hardware but let some light bleed through
+// overlapping occluders, and require rendering to half float textures.
+func (ld *Directional) SetShadowFilter(mode ShadowFilter, samples int) {
+
+	if samples < 1 {
+		panic("SetShadowFilter: samples must be at least 1")
+	}
+	ld.shadowFilter = mode
+	ld.shadowSamples = samples | 1
+}
+
+// ShadowFilter returns the filtering of the shadow map samples and the width of its kernel.
+func (ld *Directional) ShadowFilter() (ShadowFilter, int) {
+
+	return ld.shadowFilter, ld.shadowSamples
+}
+
+// SetShadowBias sets the depth bias subtracted from the depth of the shaded
+// fragments before comparing it with the shadow map, to avoid shadow acne.
+func (ld *Directional) SetShadowBias(bias float32) {
+
+	ld.shadowBias = bias
+}
+
+// ShadowBias returns the depth bias of the shadow map samples.
+func (ld *Directional) ShadowBias() float32 {
+
+	return ld.shadowBias
+}
+
+// SetShadowMapSize sets the width and height in texels of the shadow map.
+func (ld *Directional) SetShadowMapSize(size int) {
+
+	if size < 1 {
+		panic("SetShadowMapSize: invalid size")
+	}
+	ld.shadowMapSize = size
+}
+
+// ShadowMapSize returns the width and height in texels of the shadow map.
+func (ld *Directional) ShadowMapSize() int {
+
+	return ld.shadowMapSize
+}
+
+// SetShadowArea sets the width and height of the square area around the camera
+// covered by the shadow map and its depth along the light direction.
+func (ld *Directional) SetShadowArea(size, depth float32) {
+
+	ld.shadowSize = size
+	ld.shadowDepth = depth
+}
+
+// ShadowArea returns the size and depth of the area covered by the shadow map.
+func (ld *Directional) ShadowArea() (size, depth float32) {
+
+	return ld.shadowSize, ld.shadowDepth
+}
+
+// ShadowCamera sets the specified view and projection matrices of the orthographic
+// camera of the shadow map, centered on the specified world position.
+func (ld *Directional) ShadowCamera(center *math32.Vector3, view, proj *math32.Matrix4) {
+
+	// The light shines from its position towards the origin
+	var dir math32.Vector3
+	ld.WorldPosition(&dir)
+	dir.Normalize()
+	up := math32.Vector3{0, 1, 0}
+	if math32.Abs(dir.Y) > 0.99 {
+		up = math32.Vector3{0, 0, 1}
+	}
+	eye := dir
+	eye.MultiplyScalar(ld.shadowDepth / 2).Add(center)
+
+	var world math32.Matrix4
+	world.Identity()
+	world.LookAt(&eye, center, &up)
+	world.SetPosition(&eye)
+	view.GetInverse(&world)
+	half := ld.shadowSize / 2
+	proj.MakeOrthographic(-half, half, half, -half, 0, ld.shadowDepth)
+}
+
 // RenderSetup is called by the engine before rendering the scene
 func (ld *Directional) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo, idx int) {
 
@@ -0,0 +1,148 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"encoding/json"
+
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+func init() {
+	white := &math32.Color{1, 1, 1}
+	core.RegisterSceneType("AmbientLight", func() core.INode { return NewAmbient(white, 1) })
+	core.RegisterSceneType("DirectionalLight", func() core.INode { return NewDirectional(white, 1) })
+	core.RegisterSceneType("PointLight", func() core.INode { return NewPoint(white, 1) })
+	core.RegisterSceneType("SpotLight", func() core.INode { return NewSpot(white, 1) })
+}
+
+// sceneLight describes the color of a light in a scene document
+type sceneLight struct {
+	Color     math32.Color `json:"color"`
+	Intensity float32      `json:"intensity"`
+}
+
+// sceneDirectional describes a directional light and its shadows
+type sceneDirectional struct {
+	sceneLight
+	CastShadow    bool         `json:"castShadow"`
+	ShadowFilter  ShadowFilter `json:"shadowFilter"`
+	ShadowSamples int          `json:"shadowSamples"`
+	ShadowBias    float32      `json:"shadowBias"`
+	ShadowMapSize int          `json:"shadowMapSize"`
+	ShadowSize    float32      `json:"shadowSize"`
+	ShadowDepth   float32      `json:"shadowDepth"`
+}
+
+// scenePoint describes a point light
+type scenePoint struct {
+	sceneLight
+	LinearDecay    float32 `json:"linearDecay"`
+	QuadraticDecay float32 `json:"quadraticDecay"`
+}
+
+// sceneSpot describes a spot light
+type sceneSpot struct {
+	scenePoint
+	CutoffAngle  float32 `json:"cutoffAngle"`
+	AngularDecay float32 `json:"angularDecay"`
+}
+
+// SceneData satisfies the core.ISceneNode interface and returns the color of this light.
+func (la *Ambient) SceneData() (interface{}, error) {
+
+	return &sceneLight{la.color, la.intensity}, nil
+}
+
+// SetSceneData satisfies the core.ISceneNode interface and sets the color of this light.
+func (la *Ambient) SetSceneData(data json.RawMessage) error {
+
+	var sl sceneLight
+	err := json.Unmarshal(data, &sl)
+	if err != nil {
+		return err
+	}
+	la.SetIntensity(sl.Intensity)
+	la.SetColor(&sl.Color)
+	return nil
+}
+
+// SceneData satisfies the core.ISceneNode interface and returns the color and shadows of this light.
+func (ld *Directional) SceneData() (interface{}, error) {
+
+	sd := &sceneDirectional{sceneLight: sceneLight{ld.color, ld.intensity}}
+	sd.CastShadow = ld.castShadow
+	sd.ShadowFilter, sd.ShadowSamples = ld.ShadowFilter()
+	sd.ShadowBias = ld.shadowBias
+	sd.ShadowMapSize = ld.shadowMapSize
+	sd.ShadowSize, sd.ShadowDepth = ld.ShadowArea()
+	return sd, nil
+}
+
+// SetSceneData satisfies the core.ISceneNode interface and sets the color and shadows of this light.
+func (ld *Directional) SetSceneData(data json.RawMessage) error {
+
+	var sd sceneDirectional
+	err := json.Unmarshal(data, &sd)
+	if err != nil {
+		return err
+	}
+	ld.SetIntensity(sd.Intensity)
+	ld.SetColor(&sd.Color)
+	ld.SetCastShadow(sd.CastShadow)
+	ld.SetShadowFilter(sd.ShadowFilter, sd.ShadowSamples)
+	ld.SetShadowBias(sd.ShadowBias)
+	ld.SetShadowMapSize(sd.ShadowMapSize)
+	ld.SetShadowArea(sd.ShadowSize, sd.ShadowDepth)
+	return nil
+}
+
+// SceneData satisfies the core.ISceneNode interface and returns the color and decay of this light.
+func (lp *Point) SceneData() (interface{}, error) {
+
+	return &scenePoint{sceneLight{lp.color, lp.intensity}, lp.LinearDecay(), lp.QuadraticDecay()}, nil
+}
+
+// SetSceneData satisfies the core.ISceneNode interface and sets the color and decay of this light.
+func (lp *Point) SetSceneData(data json.RawMessage) error {
+
+	var sp scenePoint
+	err := json.Unmarshal(data, &sp)
+	if err != nil {
+		return err
+	}
+	lp.SetIntensity(sp.Intensity)
+	lp.SetColor(&sp.Color)
+	lp.SetLinearDecay(sp.LinearDecay)
+	lp.SetQuadraticDecay(sp.QuadraticDecay)
+	return nil
+}
+
+// SceneData satisfies the core.ISceneNode interface and returns the color, decay and cone of this light.
+func (l *Spot) SceneData() (interface{}, error) {
+
+	ss := &sceneSpot{scenePoint: scenePoint{sceneLight{l.color, l.intensity}, l.LinearDecay(), l.QuadraticDecay()}}
+	ss.CutoffAngle = l.CutoffAngle()
+	ss.AngularDecay = l.AngularDecay()
+	return ss, nil
+}
+
+// SetSceneData satisfies the core.ISceneNode interface and sets the color, decay and cone of this light.
+func (l *Spot) SetSceneData(data json.RawMessage) error {
+
+	var ss sceneSpot
+	err := json.Unmarshal(data, &ss)
+	if err != nil {
+		return err
+	}
+	l.SetIntensity(ss.Intensity)
+	l.SetColor(&ss.Color)
+	l.SetLinearDecay(ss.LinearDecay)
+	l.SetQuadraticDecay(ss.QuadraticDecay)
+	l.SetCutoffAngle(ss.CutoffAngle)
+	l.SetAngularDecay(ss.AngularDecay)
+	return nil
+}
@@ -441,11 +441,11 @@ func (g *GLTF) LoadMesh(meshIdx int) (core.INode, error) {
 		return nil, fmt.Errorf("invalid mesh index")
 	}
 	meshData := g.Meshes[meshIdx]
-	// Return cached if available
+	// Return an instance sharing the geometries and materials of the cached mesh if available
 	if meshData.cache != nil {
-		// TODO CLONE/REINSTANCE INSTEAD
-		//log.Debug("Instancing Mesh %d (from cached)", meshIdx)
-		//return meshData.cache, nil
+		if inst := instanceMesh(meshData.cache, len(meshData.Primitives)); inst != nil {
+			return inst, nil
+		}
 	}
 	// log.Debug("Loading Mesh %d", meshIdx)
 
@@ -541,6 +541,29 @@ func (g *GLTF) LoadMesh(meshIdx int) (core.INode, error) {
 	return meshNode, nil
 }
 
+// instanceMesh returns a new container node with clones of the primitives of the specified
+// cached mesh node, which share their geometries and materials, or nil if the primitives
+// can't be cloned. The primitives are the first children of the cached node.
+func instanceMesh(cache core.INode, count int) core.INode {
+
+	children := cache.GetNode().Children()
+	if len(children) < count {
+		return nil
+	}
+	for _, child := range children[:count] {
+		if _, ok := child.(*graphic.Mesh); !ok {
+			return nil
+		}
+	}
+	inst := core.NewNode()
+	for _, child := range children[:count] {
+		clone := child.Clone()
+		clone.GetNode().SetName(child.GetNode().Name())
+		inst.Add(clone)
+	}
+	return inst
+}
+
 // loadAttributes loads the provided list of vertex attributes as VBO(s) into the specified geometry.
 func (g *GLTF) loadAttributes(geom *geometry.Geometry, attributes map[string]int, indices math32.ArrayU32) error {
 
@@ -699,6 +722,7 @@ func (g *GLTF) LoadMaterial(matIdx int) (material.IMaterial, error) {
 	// Return cached if available
 	if matData.cache != nil {
 		// log.Debug("Fetching Material %d (cached)", matIdx)
+		matData.cache.GetMaterial().Incref()
 		return matData.cache, nil
 	}
 	// log.Debug("Loading Material %d", matIdx)
@@ -768,19 +792,13 @@ func (g *GLTF) applySampler(samplerIdx int, tex *texture.Texture2D) error {
 	}
 	sampler := g.Samplers[samplerIdx]
 
-	// Magnification filter
-	magFilter := gls.LINEAR
+	// Filters, the texture keeps the default filtering if not specified
 	if sampler.MagFilter != nil {
-		magFilter = *sampler.MagFilter
+		tex.SetMagFilter(uint32(*sampler.MagFilter))
 	}
-	tex.SetMagFilter(uint32(magFilter))
-
-	// Minification filter
-	minFilter := gls.LINEAR_MIPMAP_LINEAR
 	if sampler.MinFilter != nil {
-		minFilter = *sampler.MinFilter
+		tex.SetMinFilter(uint32(*sampler.MinFilter))
 	}
-	tex.SetMinFilter(uint32(minFilter))
 
 	// S coordinate wrapping mode
 	wrapS := gls.REPEAT
@@ -0,0 +1,88 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package loader
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/loader/gltf"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// UpAxis is the vertical axis of the coordinate system of an asset.
+type UpAxis int
+
+// The up axes
+const (
+	UpY UpAxis = iota // The Y axis points up, as in glTF and in the engine
+	UpZ               // The Z axis points up, as in most CAD and some modeling tools
+)
+
+// Handedness is the orientation of the coordinate system of an asset.
+type Handedness int
+
+// The handedness
+const (
+	RightHanded Handedness = iota // Right-handed coordinates, as in glTF and in the engine
+	LeftHanded                    // Left-handed coordinates, as in DirectX based tools
+)
+
+// ImportOptions describes the coordinate system of an asset, which is
+// converted at import time to the Y-up right-handed coordinates of the engine.
+// The zero value is the glTF coordinate system, which requires no conversion.
+type ImportOptions struct {
+	UpAxis     UpAxis     // Up axis of the asset
+	Handedness Handedness // Handedness of the asset
+}
+
+// apply sets the transform of the specified root node of an imported asset
+// which converts its coordinates to the engine coordinates.
+func (opts *ImportOptions) apply(root *core.Node) {
+
+	// Mirrors the forward axis of left-handed assets. The graphics reverse their
+	// front faces, as for any transform with a negative scale, so they are not inside out.
+	if opts.Handedness == LeftHanded {
+		if opts.UpAxis == UpZ {
+			root.SetScale(1, -1, 1)
+		} else {
+			root.SetScale(1, 1, -1)
+		}
+	}
+	if opts.UpAxis == UpZ {
+		root.SetRotationX(-math32.Pi / 2)
+	}
+}
+
+// DecodeGLTF decodes the default scene, or the first scene, of the specified
+// glTF file and converts it from the coordinate system of the specified options,
+// which may be nil for the glTF Y-up right-handed coordinate system.
+// Files with the .glb extension are decoded as binary glTF.
+func DecodeGLTF(path string, opts *ImportOptions) (core.INode, error) {
+
+	var g *gltf.GLTF
+	var err error
+	if strings.ToLower(filepath.Ext(path)) == ".glb" {
+		g, err = gltf.ParseBin(path)
+	} else {
+		g, err = gltf.ParseJSON(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sceneIdx := 0
+	if g.Scene != nil {
+		sceneIdx = *g.Scene
+	}
+	root, err := g.LoadScene(sceneIdx)
+	if err != nil {
+		return nil, err
+	}
+	if opts != nil {
+		opts.apply(root.GetNode())
+	}
+	return root, nil
+}
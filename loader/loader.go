@@ -0,0 +1,249 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package loader loads assets asynchronously without blocking the render loop.
+//
+// The work is split between two threads:
+//
+// The load functions, which read and decode the files and build the nodes, geometries,
+// materials and textures, run on background goroutines. They must not call OpenGL,
+// which is the case of the obj and gltf loaders and of the engine objects constructors,
+// as the objects transfer their data to OpenGL at their first render setup.
+//
+// The transfers of the geometries and textures data to OpenGL are queued and
+// performed on the render thread by ProcessUploads, which the application calls
+// once per frame with a time budget, so that large assets do not transfer all
+// their data at their first draw. The future of an asset is done once all its data
+// is transferred, so it can be added to the scene without stalling the next frame.
+//
+// The assets using other coordinate systems than the Y-up right-handed
+// coordinates of the engine are converted according to their ImportOptions.
+package loader
+
+import (
+	"sync"
+	"time"
+
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/geometry"
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/graphic"
+	"github.com/thommil/tge-g3n/loader/obj"
+	"github.com/thommil/tge-g3n/texture"
+)
+
+// AsyncLoader loads assets on background goroutines and
+// queues their OpenGL uploads for the render thread.
+type AsyncLoader struct {
+	gs      *gls.GLS            // OpenGL state of the render thread
+	mutex   sync.Mutex          // Protects the uploads queue
+	uploads []func(gs *gls.GLS) // Queued uploads
+}
+
+// Future is the node of an asset being loaded.
+type Future struct {
+	done chan struct{} // Closed when the asset is loaded
+	node core.INode    // Loaded node
+	err  error         // Load error
+}
+
+// TextureFuture is a texture being loaded.
+type TextureFuture struct {
+	done chan struct{}      // Closed when the texture is loaded
+	tex  *texture.Texture2D // Loaded texture
+	err  error              // Load error
+}
+
+// NewAsyncLoader creates and returns a pointer to a new AsyncLoader
+// uploading the loaded assets to the specified OpenGL state.
+func NewAsyncLoader(gs *gls.GLS) *AsyncLoader {
+
+	l := new(AsyncLoader)
+	l.gs = gs
+	return l
+}
+
+// Load runs the specified function on a background goroutine and queues the
+// uploads of the geometries and textures of the returned node and its descendants.
+// The function must not call OpenGL.
+func (l *AsyncLoader) Load(load func() (core.INode, error)) *Future {
+
+	f := &Future{done: make(chan struct{})}
+	go func() {
+		f.node, f.err = load()
+		if f.err != nil {
+			close(f.done)
+			return
+		}
+		l.queue(nodeUploads(f.node), func() { close(f.done) })
+	}()
+	return f
+}
+
+// LoadOBJ loads the specified obj file and its optional mtl file asynchronously.
+// See obj.Decode.
+func (l *AsyncLoader) LoadOBJ(objpath, mtlpath string) *Future {
+
+	return l.Load(func() (core.INode, error) {
+		dec, err := obj.Decode(objpath, mtlpath)
+		if err != nil {
+			return nil, err
+		}
+		return dec.NewGroup()
+	})
+}
+
+// LoadGLTF loads the default scene, or the first scene, of the specified
+// glTF file asynchronously. See DecodeGLTF.
+func (l *AsyncLoader) LoadGLTF(path string, opts *ImportOptions) *Future {
+
+	return l.Load(func() (core.INode, error) {
+		return DecodeGLTF(path, opts)
+	})
+}
+
+// LoadTexture loads the specified image file as a texture asynchronously.
+// See texture.NewTexture2DFromImage.
+func (l *AsyncLoader) LoadTexture(imgfile string) *TextureFuture {
+
+	f := &TextureFuture{done: make(chan struct{})}
+	go func() {
+		f.tex, f.err = texture.NewTexture2DFromImage(imgfile)
+		if f.err != nil {
+			close(f.done)
+			return
+		}
+		l.queue([]func(gs *gls.GLS){f.tex.Upload}, func() { close(f.done) })
+	}()
+	return f
+}
+
+// ProcessUploads performs the queued uploads on the calling thread, which must be
+// the render thread, until the specified time budget is elapsed. At least one upload
+// is performed if any is queued, so that loading progresses with a zero budget.
+// It should be called once per frame and returns the number of uploads performed.
+func (l *AsyncLoader) ProcessUploads(budget time.Duration) int {
+
+	start := time.Now()
+	count := 0
+	for {
+		l.mutex.Lock()
+		if len(l.uploads) == 0 {
+			l.mutex.Unlock()
+			break
+		}
+		upload := l.uploads[0]
+		l.uploads[0] = nil
+		l.uploads = l.uploads[1:]
+		l.mutex.Unlock()
+		upload(l.gs)
+		count++
+		if time.Since(start) >= budget {
+			break
+		}
+	}
+	return count
+}
+
+// Pending returns the number of queued uploads.
+func (l *AsyncLoader) Pending() int {
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return len(l.uploads)
+}
+
+// queue queues the specified uploads followed by the specified function
+// called on the render thread once they are performed.
+func (l *AsyncLoader) queue(uploads []func(gs *gls.GLS), done func()) {
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.uploads = append(l.uploads, uploads...)
+	l.uploads = append(l.uploads, func(*gls.GLS) { done() })
+}
+
+// nodeUploads returns the uploads of the geometries and of the
+// materials textures of the graphics of the specified node tree.
+func nodeUploads(root core.INode) []func(gs *gls.GLS) {
+
+	var uploads []func(gs *gls.GLS)
+	geoms := make(map[geometry.IGeometry]bool)
+	texs := make(map[*texture.Texture2D]bool)
+	var walk func(inode core.INode)
+	walk = func(inode core.INode) {
+		if igr, ok := inode.(graphic.IGraphic); ok {
+			gr := igr.GetGraphic()
+			if igeom := gr.IGeometry(); igeom != nil && !geoms[igeom] {
+				geoms[igeom] = true
+				uploads = append(uploads, igeom.RenderSetup)
+			}
+			for _, grmat := range gr.Materials() {
+				for _, tex := range grmat.IMaterial().GetMaterial().Textures() {
+					if !texs[tex] {
+						texs[tex] = true
+						uploads = append(uploads, tex.Upload)
+					}
+				}
+			}
+		}
+		for _, child := range inode.GetNode().Children() {
+			walk(child)
+		}
+	}
+	walk(root)
+	return uploads
+}
+
+// Done returns a channel closed when the asset is loaded or failed to load.
+func (f *Future) Done() <-chan struct{} {
+
+	return f.done
+}
+
+// Ready returns whether the asset is loaded or failed to load.
+func (f *Future) Ready() bool {
+
+	select {
+	case <-f.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Result waits for the asset to be loaded and returns its node or the load error.
+// As the uploads are performed by ProcessUploads, it must not be called
+// on the render thread before the future is ready.
+func (f *Future) Result() (core.INode, error) {
+
+	<-f.done
+	return f.node, f.err
+}
+
+// Done returns a channel closed when the texture is loaded or failed to load.
+func (f *TextureFuture) Done() <-chan struct{} {
+
+	return f.done
+}
+
+// Ready returns whether the texture is loaded or failed to load.
+func (f *TextureFuture) Ready() bool {
+
+	select {
+	case <-f.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Result waits for the texture to be loaded and returns it or the load error.
+// As the upload is performed by ProcessUploads, it must not be called
+// on the render thread before the future is ready.
+func (f *TextureFuture) Result() (*texture.Texture2D, error) {
+
+	<-f.done
+	return f.tex, f.err
+}
@@ -0,0 +1,117 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package loader
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/geometry"
+	"github.com/thommil/tge-g3n/graphic"
+	"github.com/thommil/tge-g3n/material"
+	"github.com/thommil/tge-g3n/math32"
+	"github.com/thommil/tge-g3n/texture"
+)
+
+// Test the uploads collected from a node tree sharing a geometry and a texture
+func TestNodeUploads(t *testing.T) {
+
+	geom := geometry.NewCube(1)
+	tex := texture.NewTexture2DFromData(1, 1, 0, 0, 0, []byte{0, 0, 0, 0})
+	root := core.NewNode()
+	for i := 0; i < 3; i++ {
+		mat := material.NewStandard(&math32.Color{1, 1, 1})
+		mat.AddTexture(tex)
+		root.Add(graphic.NewMesh(geom, mat))
+	}
+	if n := len(nodeUploads(root)); n != 2 {
+		t.Errorf("uploads count: got %d, expected 2", n)
+	}
+}
+
+// Test that failed loads are ready without uploads
+func TestLoadError(t *testing.T) {
+
+	l := NewAsyncLoader(nil)
+	f := l.Load(func() (core.INode, error) { return nil, errors.New("failed") })
+	if _, err := f.Result(); err == nil {
+		t.Errorf("expected load error")
+	}
+	if !f.Ready() || l.Pending() != 0 {
+		t.Errorf("unexpected state: ready %v, pending %d", f.Ready(), l.Pending())
+	}
+}
+
+// Test the conversion of the coordinate systems of imported assets
+func TestImportOptions(t *testing.T) {
+
+	for _, opts := range []ImportOptions{
+		{},
+		{UpAxis: UpZ},
+		{Handedness: LeftHanded},
+		{UpAxis: UpZ, Handedness: LeftHanded},
+	} {
+		root := core.NewNode()
+		opts.apply(root)
+		root.UpdateMatrixWorld()
+		m := root.MatrixWorld()
+
+		// The up axis of the asset points up and its forward axis
+		// points away from the viewer as the -Z axis of the engine
+		up := math32.Vector3{0, 1, 0}
+		forward := math32.Vector3{0, 0, -1}
+		if opts.UpAxis == UpZ {
+			up = math32.Vector3{0, 0, 1}
+			forward = math32.Vector3{0, 1, 0}
+		}
+		if opts.Handedness == LeftHanded {
+			forward.Negate()
+		}
+		up.ApplyMatrix4(&m)
+		forward.ApplyMatrix4(&m)
+		if up.DistanceTo(&math32.Vector3{0, 1, 0}) > 1e-6 || forward.DistanceTo(&math32.Vector3{0, 0, -1}) > 1e-6 {
+			t.Errorf("%+v: got up %v and forward %v", opts, up, forward)
+		}
+		if mirrored := m.Determinant() < 0; mirrored != (opts.Handedness == LeftHanded) {
+			t.Errorf("%+v: unexpected mirrored %v", opts, mirrored)
+		}
+	}
+}
+
+// Test the sharing of identical textures and materials and the welding of meshes
+func TestOptimize(t *testing.T) {
+
+	root := core.NewNode()
+	for i := 0; i < 3; i++ {
+		mat := material.NewStandard(&math32.Color{1, 1, 1})
+		mat.AddTexture(texture.NewTexture2DFromData(1, 1, 0, 0, 0, []byte{0, 0, 0, 0}))
+		mesh := graphic.NewMesh(geometry.NewCube(1), mat)
+		mesh.SetPosition(float32(i), 0, 0)
+		root.Add(mesh)
+	}
+	root.Add(graphic.NewMesh(geometry.NewCube(1), material.NewStandard(&math32.Color{1, 0, 0})))
+
+	report := Optimize(root, nil)
+	expected := OptimizeReport{
+		DrawCallsBefore: 4, DrawCallsAfter: 2,
+		MaterialsBefore: 4, MaterialsAfter: 2,
+		TexturesBefore: 3, TexturesAfter: 1,
+	}
+	if report != expected {
+		t.Errorf("report: got %+v, expected %+v", report, expected)
+	}
+	if n := len(root.Children()); n != 2 {
+		t.Fatalf("children count: got %d, expected 2", n)
+	}
+	welded := root.Children()[1].(*graphic.Mesh).GetGeometry()
+	if welded.Items() != 3*24 {
+		t.Errorf("welded vertices: got %d, expected %d", welded.Items(), 3*24)
+	}
+	bbox := welded.BoundingBox()
+	if bbox.Max.X != 2.5 {
+		t.Errorf("welded bounding box: got %v", bbox)
+	}
+}
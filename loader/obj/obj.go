@@ -29,18 +29,19 @@ import (
 
 // Decoder contains all decoded data from the obj and mtl files
 type Decoder struct {
-	Objects       []Object             // decoded objects
-	Matlib        string               // name of the material lib
-	Materials     map[string]*Material // maps material name to object
-	Vertices      math32.ArrayF32      // vertices positions array
-	Normals       math32.ArrayF32      // vertices normals
-	Uvs           math32.ArrayF32      // vertices texture coordinates
-	Warnings      []string             // warning messages
-	line          uint                 // current line number
-	objCurrent    *Object              // current object
-	matCurrent    *Material            // current material
-	smoothCurrent bool                 // current smooth state
-	mtlDir        string               // Directory of material file
+	Objects       []Object                      // decoded objects
+	Matlib        string                        // name of the material lib
+	Materials     map[string]*Material          // maps material name to object
+	Vertices      math32.ArrayF32               // vertices positions array
+	Normals       math32.ArrayF32               // vertices normals
+	Uvs           math32.ArrayF32               // vertices texture coordinates
+	Warnings      []string                      // warning messages
+	line          uint                          // current line number
+	objCurrent    *Object                       // current object
+	matCurrent    *Material                     // current material
+	smoothCurrent bool                          // current smooth state
+	mtlDir        string                        // Directory of material file
+	textures      map[string]*texture.Texture2D // Loaded textures by path, shared by the materials
 }
 
 // Object contains all information about one decoded object
@@ -275,6 +276,10 @@ func (dec *Decoder) NewGeometry(obj *Object) (*geometry.Geometry, error) {
 	geom.AddVBO(gls.NewVBO(normals).AddAttrib(gls.VertexNormal))
 	geom.AddVBO(gls.NewVBO(uvs).AddAttrib(gls.VertexTexcoord))
 
+	// Makes the faces winding consistent if mixed
+	if count := geom.FixWinding(); count > 0 {
+		dec.Warnings = append(dec.Warnings, fmt.Sprintf("%s: fixed winding of %d triangles of object '%s'", objType, count, obj.Name))
+	}
 	return geom, nil
 }
 
@@ -297,11 +302,21 @@ func (dec *Decoder) loadTex(mat *material.Material, desc *Material) error {
 		texPath = filepath.Join(dec.mtlDir, desc.MapKd)
 	}
 
+	// Shares the texture already loaded from the same file
+	if tex, ok := dec.textures[texPath]; ok {
+		mat.AddTexture(tex.Incref())
+		return nil
+	}
+
 	// Try to load texture from image file
 	tex, err := texture.NewTexture2DFromImage(texPath)
 	if err != nil {
 		return err
 	}
+	if dec.textures == nil {
+		dec.textures = make(map[string]*texture.Texture2D)
+	}
+	dec.textures[texPath] = tex
 	mat.AddTexture(tex)
 	return nil
 }
@@ -0,0 +1,223 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package loader
+
+import (
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/geometry"
+	"github.com/thommil/tge-g3n/graphic"
+	"github.com/thommil/tge-g3n/material"
+	"github.com/thommil/tge-g3n/math32"
+	"github.com/thommil/tge-g3n/texture"
+)
+
+// OptimizeOptions selects the optimizations of Optimize.
+// The zero value enables all of them.
+type OptimizeOptions struct {
+	KeepTextures  bool // Do not share the identical textures
+	KeepMaterials bool // Do not share the identical materials
+	KeepMeshes    bool // Do not weld the meshes
+}
+
+// OptimizeReport reports the number of draw calls, materials and
+// textures of a node tree before and after Optimize.
+type OptimizeReport struct {
+	DrawCallsBefore int // Number of graphic materials before
+	DrawCallsAfter  int // Number of graphic materials after
+	MaterialsBefore int // Number of distinct materials before
+	MaterialsAfter  int // Number of distinct materials after
+	TexturesBefore  int // Number of distinct textures before
+	TexturesAfter   int // Number of distinct textures after
+}
+
+// Optimize reduces the draw calls and the memory of the specified loaded node tree,
+// which must not be rendered yet. The identical textures and materials, such as the ones
+// decoded several times from the same asset description, are replaced by a single one
+// shared with reference counting. The meshes without children of the same parent which
+// use the same material with the same render settings are then welded into a single mesh,
+// named as the first one, whose geometry contains their vertices transformed by their
+// local transforms. Options may be nil to apply all the optimizations.
+func Optimize(root core.INode, opts *OptimizeOptions) OptimizeReport {
+
+	if opts == nil {
+		opts = &OptimizeOptions{}
+	}
+	var report OptimizeReport
+	graphics, mats, texs := optimizeStats(root)
+	report.DrawCallsBefore = drawCalls(graphics)
+	report.MaterialsBefore = len(mats)
+	report.TexturesBefore = len(texs)
+
+	if !opts.KeepTextures {
+		var uniques []*texture.Texture2D
+		for _, tex := range texs {
+			unique := tex
+			for _, u := range uniques {
+				if texture.Equal(u, tex) {
+					unique = u
+					break
+				}
+			}
+			if unique == tex {
+				uniques = append(uniques, tex)
+				continue
+			}
+			for _, imat := range mats {
+				material.ReplaceTexture(imat, tex, unique)
+			}
+		}
+	}
+	if !opts.KeepMaterials {
+		var uniques []material.IMaterial
+		for _, imat := range mats {
+			unique := imat
+			for _, u := range uniques {
+				if material.Equal(u, imat) {
+					unique = u
+					break
+				}
+			}
+			if unique == imat {
+				uniques = append(uniques, imat)
+				continue
+			}
+			for _, gr := range graphics {
+				gr.ReplaceMaterial(imat, unique)
+			}
+		}
+	}
+	if !opts.KeepMeshes {
+		weldMeshes(root)
+	}
+
+	graphics, mats, texs = optimizeStats(root)
+	report.DrawCallsAfter = drawCalls(graphics)
+	report.MaterialsAfter = len(mats)
+	report.TexturesAfter = len(texs)
+	return report
+}
+
+// optimizeStats returns the graphics, the distinct materials and the
+// distinct textures of the specified node tree in traversal order.
+func optimizeStats(root core.INode) ([]*graphic.Graphic, []material.IMaterial, []*texture.Texture2D) {
+
+	var graphics []*graphic.Graphic
+	var mats []material.IMaterial
+	var texs []*texture.Texture2D
+	matSet := make(map[material.IMaterial]bool)
+	texSet := make(map[*texture.Texture2D]bool)
+	var walk func(inode core.INode)
+	walk = func(inode core.INode) {
+		if igr, ok := inode.(graphic.IGraphic); ok {
+			gr := igr.GetGraphic()
+			graphics = append(graphics, gr)
+			for _, grmat := range gr.Materials() {
+				imat := grmat.IMaterial()
+				if matSet[imat] {
+					continue
+				}
+				matSet[imat] = true
+				mats = append(mats, imat)
+				for _, tex := range imat.GetMaterial().Textures() {
+					if !texSet[tex] {
+						texSet[tex] = true
+						texs = append(texs, tex)
+					}
+				}
+			}
+		}
+		for _, child := range inode.GetNode().Children() {
+			walk(child)
+		}
+	}
+	walk(root)
+	return graphics, mats, texs
+}
+
+// drawCalls returns the number of graphic materials of the specified graphics.
+func drawCalls(graphics []*graphic.Graphic) int {
+
+	count := 0
+	for _, gr := range graphics {
+		count += len(gr.Materials())
+	}
+	return count
+}
+
+// weldGroup is a group of meshes of the same parent which can be welded.
+type weldGroup struct {
+	imat   material.IMaterial // Material of the meshes
+	meshes []*graphic.Mesh    // Meshes to weld
+}
+
+// weldMeshes welds the meshes of each node of the specified node tree.
+func weldMeshes(inode core.INode) {
+
+	node := inode.GetNode()
+	var groups []*weldGroup
+	for _, child := range node.Children() {
+		weldMeshes(child)
+		m, ok := child.(*graphic.Mesh)
+		if !ok || !weldable(m) {
+			continue
+		}
+		var group *weldGroup
+		for _, g := range groups {
+			first := g.meshes[0]
+			if g.imat == m.Materials()[0].IMaterial() && first.Visible() == m.Visible() &&
+				first.Renderable() == m.Renderable() && first.Cullable() == m.Cullable() &&
+				first.RenderOrder() == m.RenderOrder() && geometry.Mergeable(first.GetGeometry(), m.GetGeometry()) {
+				group = g
+				break
+			}
+		}
+		if group == nil {
+			group = &weldGroup{imat: m.Materials()[0].IMaterial()}
+			groups = append(groups, group)
+		}
+		group.meshes = append(group.meshes, m)
+	}
+
+	for _, g := range groups {
+		if len(g.meshes) < 2 {
+			continue
+		}
+		geoms := make([]*geometry.Geometry, len(g.meshes))
+		matrices := make([]*math32.Matrix4, len(g.meshes))
+		for i, m := range g.meshes {
+			m.UpdateMatrix()
+			matrix := m.Matrix()
+			geoms[i] = m.GetGeometry()
+			matrices[i] = &matrix
+		}
+		first := g.meshes[0]
+		g.imat.GetMaterial().Incref()
+		welded := graphic.NewMesh(geometry.Merge(geoms, matrices), g.imat)
+		welded.SetName(first.Name())
+		welded.SetVisible(first.Visible())
+		welded.SetRenderable(first.Renderable())
+		welded.SetCullable(first.Cullable())
+		welded.SetRenderOrder(first.RenderOrder())
+		for _, m := range g.meshes {
+			node.Remove(m)
+			m.Dispose()
+		}
+		node.Add(welded)
+	}
+}
+
+// weldable returns whether the specified mesh can be welded, which requires
+// a single material drawing all its static geometry and no children.
+func weldable(m *graphic.Mesh) bool {
+
+	if len(m.Children()) != 0 || len(m.Materials()) != 1 || m.GetGeometry() == nil {
+		return false
+	}
+	if _, morph := m.IGeometry().(*geometry.MorphGeometry); morph {
+		return false
+	}
+	_, count := m.Materials()[0].Range()
+	return count == 0
+}
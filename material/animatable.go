@@ -0,0 +1,144 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package material
+
+import (
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// IAnimatable is the interface of the materials exposing properties, such as colors
+// and uniform values, which can be animated by name as vectors of float32 values.
+// All the materials implement it through the embedded Material.
+type IAnimatable interface {
+	Property(name string) []float32
+	SetProperty(name string, values []float32) bool
+}
+
+// The names of the animatable properties of the materials with their number of values
+const (
+	PropColor         = "color"         // Diffuse or base color (3)
+	PropOpacity       = "opacity"       // Opacity (1)
+	PropEmissive      = "emissive"      // Emissive color, whose intensity is its scale (3)
+	PropShininess     = "shininess"     // Specular shininess factor of the standard materials (1)
+	PropMetallic      = "metallic"      // Metallic factor of the physical materials (1)
+	PropRoughness     = "roughness"     // Roughness factor of the physical materials (1)
+	PropTextureOffset = "textureOffset" // Offset of the textures or of the texture transform of the standard materials (2)
+	PropTextureRotate = "textureRotate" // Rotation of the texture transform of the standard materials (1)
+)
+
+// Property returns the values of the specified animatable property
+// of this material or nil if the material does not have it.
+// The base material has the textures offset, which is the one of its first texture.
+func (mat *Material) Property(name string) []float32 {
+
+	if name == PropTextureOffset && len(mat.textures) > 0 {
+		x, y := mat.textures[0].Offset()
+		return []float32{x, y}
+	}
+	return nil
+}
+
+// SetProperty sets the values of the specified animatable property of this material.
+// Returns false if the material does not have the property or if the number of values differs.
+func (mat *Material) SetProperty(name string, values []float32) bool {
+
+	if name == PropTextureOffset && len(mat.textures) > 0 && len(values) == 2 {
+		for _, tex := range mat.textures {
+			tex.SetOffset(values[0], values[1])
+		}
+		return true
+	}
+	return false
+}
+
+// Property returns the values of the specified animatable property
+// of this material or nil if the material does not have it.
+func (ms *Standard) Property(name string) []float32 {
+
+	switch name {
+	case PropColor:
+		c := ms.udata.diffuse
+		return []float32{c.R, c.G, c.B}
+	case PropOpacity:
+		return []float32{ms.udata.opacity}
+	case PropEmissive:
+		c := ms.udata.emissive
+		return []float32{c.R, c.G, c.B}
+	case PropShininess:
+		return []float32{ms.udata.shininess}
+	case PropTextureOffset:
+		return []float32{ms.texOffset.X, ms.texOffset.Y}
+	case PropTextureRotate:
+		return []float32{ms.texRotate}
+	}
+	return ms.Material.Property(name)
+}
+
+// SetProperty sets the values of the specified animatable property of this material.
+// Returns false if the material does not have the property or if the number of values differs.
+func (ms *Standard) SetProperty(name string, values []float32) bool {
+
+	switch {
+	case name == PropColor && len(values) == 3:
+		ms.SetColor(&math32.Color{values[0], values[1], values[2]})
+	case name == PropOpacity && len(values) == 1:
+		ms.SetOpacity(values[0])
+	case name == PropEmissive && len(values) == 3:
+		ms.SetEmissiveColor(&math32.Color{values[0], values[1], values[2]})
+	case name == PropShininess && len(values) == 1:
+		ms.SetShininess(values[0])
+	case name == PropTextureOffset && len(values) == 2:
+		ms.SetTextureTransform(math32.Vector2{values[0], values[1]}, ms.texRepeat, ms.texRotate)
+	case name == PropTextureRotate && len(values) == 1:
+		ms.SetTextureTransform(ms.texOffset, ms.texRepeat, values[0])
+	default:
+		return ms.Material.SetProperty(name, values)
+	}
+	return true
+}
+
+// Property returns the values of the specified animatable property
+// of this material or nil if the material does not have it.
+func (m *Physical) Property(name string) []float32 {
+
+	switch name {
+	case PropColor:
+		c := m.udata.baseColorFactor
+		return []float32{c.R, c.G, c.B}
+	case PropOpacity:
+		return []float32{m.udata.baseColorFactor.A}
+	case PropEmissive:
+		c := m.udata.emissiveFactor
+		return []float32{c.R, c.G, c.B}
+	case PropMetallic:
+		return []float32{m.udata.metallicFactor}
+	case PropRoughness:
+		return []float32{m.udata.roughnessFactor}
+	}
+	return m.Material.Property(name)
+}
+
+// SetProperty sets the values of the specified animatable property of this material.
+// Returns false if the material does not have the property or if the number of values differs.
+func (m *Physical) SetProperty(name string, values []float32) bool {
+
+	switch {
+	case name == PropColor && len(values) == 3:
+		m.udata.baseColorFactor.R = values[0]
+		m.udata.baseColorFactor.G = values[1]
+		m.udata.baseColorFactor.B = values[2]
+	case name == PropOpacity && len(values) == 1:
+		m.udata.baseColorFactor.A = values[0]
+	case name == PropEmissive && len(values) == 3:
+		m.SetEmissiveFactor(&math32.Color{values[0], values[1], values[2]})
+	case name == PropMetallic && len(values) == 1:
+		m.SetMetallicFactor(values[0])
+	case name == PropRoughness && len(values) == 1:
+		m.SetRoughnessFactor(values[0])
+	default:
+		return m.Material.SetProperty(name, values)
+	}
+	return true
+}
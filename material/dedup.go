@@ -0,0 +1,77 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package material
+
+import (
+	"reflect"
+	"unsafe"
+
+	"github.com/thommil/tge-g3n/texture"
+)
+
+var (
+	textureType      = reflect.TypeOf((*texture.Texture2D)(nil))
+	textureSliceType = reflect.TypeOf([]*texture.Texture2D(nil))
+)
+
+// Equal returns whether the specified materials have the same type, parameters and
+// textures, such as the materials decoded twice from the same asset description.
+// The references count of the materials and their uniform location caches are
+// not compared, so it is intended for materials which were not rendered yet.
+func Equal(a, b IMaterial) bool {
+
+	if a == b {
+		return true
+	}
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Type() != vb.Type() || va.Kind() != reflect.Ptr {
+		return false
+	}
+	ca, cb := reflect.New(va.Type().Elem()), reflect.New(vb.Type().Elem())
+	ca.Elem().Set(va.Elem())
+	cb.Elem().Set(vb.Elem())
+	for _, c := range []*Material{ca.Interface().(IMaterial).GetMaterial(), cb.Interface().(IMaterial).GetMaterial()} {
+		c.refcount = 0
+		c.alphaWarned = false
+		c.unitsWarned = false
+	}
+	return reflect.DeepEqual(ca.Interface(), cb.Interface())
+}
+
+// ReplaceTexture replaces the specified texture by the specified other texture in the
+// specified material, including its specific maps such as the normal map, transferring
+// the references of the material from the old texture to the new one.
+// Returns whether the material used the old texture.
+func ReplaceTexture(imat IMaterial, old, tex *texture.Texture2D) bool {
+
+	replaced := false
+	var replace func(v reflect.Value)
+	replace = func(v reflect.Value) {
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Field(i)
+			switch {
+			case f.Type() == textureType:
+				p := (**texture.Texture2D)(unsafe.Pointer(f.UnsafeAddr()))
+				if *p == old {
+					*p = tex
+				}
+			case f.Type() == textureSliceType:
+				// The textures list holds the references of the material
+				list := *(*[]*texture.Texture2D)(unsafe.Pointer(f.UnsafeAddr()))
+				for j := range list {
+					if list[j] == old {
+						list[j] = tex.Incref()
+						old.Dispose()
+						replaced = true
+					}
+				}
+			case f.Kind() == reflect.Struct:
+				replace(f)
+			}
+		}
+	}
+	replace(reflect.ValueOf(imat).Elem())
+	return replaced
+}
@@ -0,0 +1,68 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package material
+
+import (
+	"github.com/thommil/tge-g3n/gls"
+)
+
+// LineDashed is an unlit material for lines drawn with dashes separated by gaps,
+// colored by the vertex colors. The dash pattern is computed from the distance along
+// the lines of each vertex, which is set by geometry.ComputeLineDistances.
+type LineDashed struct {
+	Material             // Embedded material
+	uni      gls.Uniform // Dash uniform location cache
+	udata    struct {    // Combined uniform data in 1 vec2:
+		dashSize float32 // Length of the dashes
+		gapSize  float32 // Length of the gaps
+	}
+}
+
+// NewLineDashed creates and returns a pointer to a new dashed lines material
+// with the specified dash and gap lengths.
+func NewLineDashed(dashSize, gapSize float32) *LineDashed {
+
+	m := new(LineDashed)
+	m.Material.Init()
+	m.SetShader("dashed")
+	m.SetUseLights(UseLightNone)
+	m.uni.Init("Dash")
+	m.udata.dashSize = dashSize
+	m.udata.gapSize = gapSize
+	return m
+}
+
+// SetDashSize sets the length of the dashes.
+func (m *LineDashed) SetDashSize(size float32) {
+
+	m.udata.dashSize = size
+}
+
+// DashSize returns the length of the dashes.
+func (m *LineDashed) DashSize() float32 {
+
+	return m.udata.dashSize
+}
+
+// SetGapSize sets the length of the gaps between the dashes.
+func (m *LineDashed) SetGapSize(size float32) {
+
+	m.udata.gapSize = size
+}
+
+// GapSize returns the length of the gaps between the dashes.
+func (m *LineDashed) GapSize() float32 {
+
+	return m.udata.gapSize
+}
+
+// RenderSetup is called by the engine before drawing the object
+// which uses this material
+func (m *LineDashed) RenderSetup(gs *gls.GLS) {
+
+	m.Material.RenderSetup(gs)
+	location := m.uni.Location(gs)
+	gs.Uniform2f(location, m.udata.dashSize, m.udata.gapSize)
+}
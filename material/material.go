@@ -7,6 +7,8 @@
 package material
 
 import (
+	"fmt"
+
 	"github.com/thommil/tge-g3n/gls"
 	"github.com/thommil/tge-g3n/texture"
 )
@@ -32,6 +34,24 @@ const (
 	BlendingSubtractive Blending = 3
 	BlendingMultiply    Blending = 4
 	BlendingCustom      Blending = 5
+	// BlendingPremultiplied blends colors premultiplied by their alpha, such as the
+	// textures with premultiplied alpha (see texture.Texture2D.SetPremultipliedAlpha),
+	// using BlendFunc(ONE, ONE_MINUS_SRC_ALPHA) instead of the BlendingNormal
+	// BlendFunc(SRC_ALPHA, ONE_MINUS_SRC_ALPHA) default state, which causes dark fringes
+	// around transparent edges. The material opacity is not applied to the colors
+	// and should be left at 1.
+	BlendingPremultiplied Blending = 6
+)
+
+// The render queues of the materials, the graphics are rendered by increasing queue.
+// The queues up to QueueGeometryLast are sorted front to back and rendered first,
+// the following queues are sorted back to front.
+const (
+	QueueBackground   = 1000 // Rendered first, such as backgrounds
+	QueueOpaque       = 2000 // Default queue of the opaque materials
+	QueueGeometryLast = 2500 // Last queue sorted front to back
+	QueueTransparent  = 3000 // Default queue of the transparent materials
+	QueueOverlay      = 4000 // Rendered last, such as helpers over the scene
 )
 
 // UseLights flags
@@ -66,7 +86,13 @@ type Material struct {
 	sidevis     Side                 // Face side(s) visibility
 	blending    Blending             // Blending mode
 	transparent bool                 // Whether at all transparent
+	renderQueue int                  // Render queue or 0 for the default queue of the transparency
 	wireframe   bool                 // Whether to render only the wireframe
+	alphaCover  bool                 // Whether alpha to coverage is enabled
+	alphaWarned bool                 // Whether the missing multisample warning was printed
+	unitsWarned bool                 // Whether the texture units limit warning was printed
+	screenRefl  float32              // Reflectivity of the screen space reflections
+	softFade    float32              // Fade distance of the soft particles or 0
 	lineWidth   float32              // Line width for lines and mesh wireframe
 	textures    []*texture.Texture2D // List of textures
 
@@ -211,6 +237,79 @@ func (mat *Material) Transparent() bool {
 	return mat.transparent
 }
 
+// SetRenderQueue sets the render queue of this material, such as QueueOverlay, overriding
+// the QueueOpaque or QueueTransparent default queue given by its transparency, or 0 to
+// restore it. It doesn't change the blending and depth states of the material.
+func (mat *Material) SetRenderQueue(queue int) {
+
+	if queue < 0 {
+		panic("Material.SetRenderQueue: invalid queue")
+	}
+	mat.renderQueue = queue
+}
+
+// RenderQueue returns the render queue of this material.
+func (mat *Material) RenderQueue() int {
+
+	if mat.renderQueue != 0 {
+		return mat.renderQueue
+	}
+	if mat.transparent {
+		return QueueTransparent
+	}
+	return QueueOpaque
+}
+
+// SetAlphaToCoverage sets whether the fragments alpha is converted to a multisample
+// coverage mask, which antialiases the edges of alpha tested cutouts such as foliage.
+// It requires a multisample framebuffer and is ignored with a warning otherwise.
+func (mat *Material) SetAlphaToCoverage(state bool) {
+
+	mat.alphaCover = state
+}
+
+// AlphaToCoverage returns whether alpha to coverage is enabled.
+func (mat *Material) AlphaToCoverage() bool {
+
+	return mat.alphaCover
+}
+
+// SetScreenReflections sets the reflectivity (0 to 1) of the screen space reflections
+// of this material when they are enabled in the renderer.
+// The default value 0 excludes the material from the screen space reflections.
+func (mat *Material) SetScreenReflections(reflectivity float32) {
+
+	mat.screenRefl = reflectivity
+}
+
+// ScreenReflections returns the reflectivity of the screen space reflections of this material.
+func (mat *Material) ScreenReflections() float32 {
+
+	return mat.screenRefl
+}
+
+// SetSoftParticles sets the distance from the opaque objects behind the fragments of
+// this material along which they fade out, such as to soften the intersections of smoke
+// particles with the floor. The material is made transparent, as the renderer samples the
+// depth of the opaque objects rendered before the transparent ones. The point, sprite and
+// standard shaders support soft particles. The default value 0 disables the fading.
+func (mat *Material) SetSoftParticles(fadeDistance float32) {
+
+	mat.softFade = fadeDistance
+	if fadeDistance > 0 {
+		mat.transparent = true
+		mat.ShaderDefines.Set("SOFT_PARTICLES", "")
+	} else {
+		mat.ShaderDefines.Unset("SOFT_PARTICLES")
+	}
+}
+
+// SoftParticles returns the fade distance of the soft particles of this material.
+func (mat *Material) SoftParticles() float32 {
+
+	return mat.softFade
+}
+
 // SetWireframe sets whether only the wireframe is rendered.
 func (mat *Material) SetWireframe(state bool) {
 
@@ -243,26 +342,50 @@ func (mat *Material) SetLineWidth(width float32) {
 	mat.lineWidth = width
 }
 
+// LineWidth returns the line width for lines and mesh wireframe
+func (mat *Material) LineWidth() float32 {
+
+	return mat.lineWidth
+}
+
+// SetPolygonOffset sets the polygon offset factor and units added to the depth
+// of the fragments, such as for decals drawn over other surfaces.
+// The polygon offset is disabled when both are zero, which is the default.
 func (mat *Material) SetPolygonOffset(factor, units float32) {
 
 	mat.polyOffsetFactor = factor
 	mat.polyOffsetUnits = units
 }
 
+// PolygonOffset returns the polygon offset factor and units.
+func (mat *Material) PolygonOffset() (float32, float32) {
+
+	return mat.polyOffsetFactor, mat.polyOffsetUnits
+}
+
 // RenderSetup is called by the renderer before drawing objects with this material.
 func (mat *Material) RenderSetup(gs *gls.GLS) {
 
-	// Sets triangle side view mode
+	// Sets triangle side view mode.
+	// All materials set it, so double sided materials don't affect the others.
 	switch mat.sidevis {
 	case SideFront:
-		gs.Enable(gls.CULL_FACE)
-		gs.FrontFace(gls.CCW)
+		gs.SetSideView(gls.FrontSide)
 	case SideBack:
-		gs.Enable(gls.CULL_FACE)
-		gs.FrontFace(gls.CW)
+		gs.SetSideView(gls.BackSide)
 	case SideDouble:
-		gs.Disable(gls.CULL_FACE)
-		gs.FrontFace(gls.CCW)
+		gs.SetSideView(gls.DoubleSide)
+	}
+
+	// Sets alpha to coverage if supported by the framebuffer
+	if mat.alphaCover && gs.Multisample() {
+		gs.Enable(gls.SAMPLE_ALPHA_TO_COVERAGE)
+	} else {
+		if mat.alphaCover && !mat.alphaWarned {
+			fmt.Printf("WARNING : Material alpha to coverage ignored without multisample\n")
+			mat.alphaWarned = true
+		}
+		gs.Disable(gls.SAMPLE_ALPHA_TO_COVERAGE)
 	}
 
 	if mat.depthTest {
@@ -279,8 +402,17 @@ func (mat *Material) RenderSetup(gs *gls.GLS) {
 		gs.PolygonMode(gls.FRONT_AND_BACK, gls.FILL)
 	}
 
-	// Set polygon offset if requested
-	gs.PolygonOffset(mat.polyOffsetFactor, mat.polyOffsetUnits)
+	// Set polygon offset if requested, otherwise the depth of the fragments is not offset
+	if mat.polyOffsetFactor != 0 || mat.polyOffsetUnits != 0 {
+		gs.Enable(gls.POLYGON_OFFSET_FILL)
+		gs.Enable(gls.POLYGON_OFFSET_LINE)
+		gs.Enable(gls.POLYGON_OFFSET_POINT)
+		gs.PolygonOffset(mat.polyOffsetFactor, mat.polyOffsetUnits)
+	} else {
+		gs.Disable(gls.POLYGON_OFFSET_FILL)
+		gs.Disable(gls.POLYGON_OFFSET_LINE)
+		gs.Disable(gls.POLYGON_OFFSET_POINT)
+	}
 
 	// Sets line width
 	gs.LineWidth(mat.lineWidth)
@@ -290,23 +422,15 @@ func (mat *Material) RenderSetup(gs *gls.GLS) {
 	case BlendingNone:
 		gs.Disable(gls.BLEND)
 	case BlendingNormal:
-		gs.Enable(gls.BLEND)
-		gs.BlendEquation(gls.FUNC_ADD)
-		gs.BlendFunc(gls.SRC_ALPHA, gls.ONE_MINUS_SRC_ALPHA)
+		gs.BlendAlpha()
 	case BlendingAdditive:
-		gs.Enable(gls.BLEND)
-		gs.BlendEquation(gls.FUNC_ADD)
-		gs.BlendFunc(gls.SRC_ALPHA, gls.ONE)
+		gs.BlendAdditive()
 	case BlendingSubtractive:
-		gs.Enable(gls.BLEND)
-		gs.BlendEquation(gls.FUNC_ADD)
-		gs.BlendFunc(gls.ZERO, gls.ONE_MINUS_SRC_COLOR)
-		break
+		gs.BlendSubtractive()
 	case BlendingMultiply:
-		gs.Enable(gls.BLEND)
-		gs.BlendEquation(gls.FUNC_ADD)
-		gs.BlendFunc(gls.ZERO, gls.SRC_COLOR)
-		break
+		gs.BlendMultiply()
+	case BlendingPremultiplied:
+		gs.BlendPremultiplied()
 	case BlendingCustom:
 		gs.BlendEquationSeparate(mat.blendRGB, mat.blendAlpha)
 		gs.BlendFuncSeparate(mat.blendSrcRGB, mat.blendDstRGB, mat.blendSrcAlpha, mat.blendDstAlpha)
@@ -317,8 +441,13 @@ func (mat *Material) RenderSetup(gs *gls.GLS) {
 
 	// Render textures
 	// Keep track of counts of unique sampler names to correctly index sampler arrays
+	gs.ResetTextureUnits()
 	samplerCounts := make(map[string]int)
-	for slotIdx, tex := range mat.textures {
+	for _, tex := range mat.textures {
+		slotIdx := mat.textureUnit(gs)
+		if slotIdx < 0 {
+			break
+		}
 		samplerName, _ := tex.GetUniformNames()
 		uniIdx, _ := samplerCounts[samplerName]
 		tex.RenderSetup(gs, slotIdx, uniIdx)
@@ -326,6 +455,19 @@ func (mat *Material) RenderSetup(gs *gls.GLS) {
 	}
 }
 
+// textureUnit allocates and returns the next texture unit for a sampler of this material
+// or -1 if the material exceeds the texture units limit, warning once in this case.
+// The texture units reserved for the renderer are not available to the materials.
+func (mat *Material) textureUnit(gs *gls.GLS) int {
+
+	unit := gs.AllocTextureUnit()
+	if unit < 0 && !mat.unitsWarned {
+		fmt.Printf("WARNING : Material textures exceed the %d texture units limit\n", gs.MaxAllocTextureUnits())
+		mat.unitsWarned = true
+	}
+	return unit
+}
+
 // AddTexture adds the specified Texture2d to the material
 func (mat *Material) AddTexture(tex *texture.Texture2D) {
 
@@ -357,8 +499,27 @@ func (mat *Material) HasTexture(tex *texture.Texture2D) bool {
 	return false
 }
 
+// Textures returns the list of textures of this material
+func (mat *Material) Textures() []*texture.Texture2D {
+
+	return mat.textures
+}
+
 // TextureCount returns the current number of textures
 func (mat *Material) TextureCount() int {
 
 	return len(mat.textures)
 }
+
+// MatTextureCount returns the number of textures sampled by the MatTexture array of the
+// shaders, excluding the maps with their own samplers such as the normal map.
+func (mat *Material) MatTextureCount() int {
+
+	count := 0
+	for _, tex := range mat.textures {
+		if sampler, _ := tex.GetUniformNames(); sampler == "MatTexture" {
+			count++
+		}
+	}
+	return count
+}
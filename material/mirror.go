@@ -0,0 +1,104 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package material
+
+import (
+	"unsafe"
+
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/math32"
+	"github.com/thommil/tge-g3n/texture"
+)
+
+// Mirror is a reflective material which projects a planar reflection
+// texture on the object, normally rendered by a graphic.MirrorPlane.
+// The final color is the surface color mixed with the reflection by the reflectivity.
+type Mirror struct {
+	Material                     // Embedded material
+	reflTex   *texture.Texture2D // Reflection texture
+	texMatrix math32.Matrix4     // Model to reflection texture coordinates matrix
+	uni       gls.Uniform        // Material uniform location cache
+	uniTexMat gls.Uniform        // Texture matrix uniform location cache
+	udata     struct {           // Combined uniform data in 1 vec4:
+		color        math32.Color // Surface color
+		reflectivity float32      // Reflection mix factor
+	}
+}
+
+// NewMirror creates and returns a pointer to a new mirror material
+// with the specified surface color and reflectivity.
+func NewMirror(color *math32.Color, reflectivity float32) *Mirror {
+
+	m := new(Mirror)
+	m.Material.Init()
+	m.SetShader("mirror")
+	m.SetUseLights(UseLightNone)
+	m.uni.Init("Material")
+	m.uniTexMat.Init("MirrorTextureMatrix")
+	m.texMatrix.Identity()
+	m.SetColor(color)
+	m.SetReflectivity(reflectivity)
+	return m
+}
+
+// SetColor sets the surface color mixed with the reflection.
+func (m *Mirror) SetColor(color *math32.Color) {
+
+	m.udata.color = *color
+}
+
+// Color returns the surface color.
+func (m *Mirror) Color() math32.Color {
+
+	return m.udata.color
+}
+
+// SetReflectivity sets the reflection mix factor from 0 (surface color only)
+// to 1 (reflection only).
+func (m *Mirror) SetReflectivity(reflectivity float32) {
+
+	m.udata.reflectivity = reflectivity
+}
+
+// Reflectivity returns the reflection mix factor.
+func (m *Mirror) Reflectivity() float32 {
+
+	return m.udata.reflectivity
+}
+
+// SetReflection sets the texture with the rendered reflection.
+func (m *Mirror) SetReflection(tex *texture.Texture2D) {
+
+	if m.reflTex != nil {
+		m.RemoveTexture(m.reflTex)
+	}
+	m.reflTex = tex
+	if m.reflTex != nil {
+		m.reflTex.SetUniformNames("MirrorMap", "MirrorMapInfo")
+		m.AddTexture(m.reflTex)
+	}
+}
+
+// Reflection returns the reflection texture or nil.
+func (m *Mirror) Reflection() *texture.Texture2D {
+
+	return m.reflTex
+}
+
+// SetTextureMatrix sets the matrix which transforms the model coordinates
+// to the projective coordinates of the reflection texture.
+func (m *Mirror) SetTextureMatrix(mat *math32.Matrix4) {
+
+	m.texMatrix = *mat
+}
+
+// RenderSetup is called by the engine before drawing the object
+// which uses this material
+func (m *Mirror) RenderSetup(gs *gls.GLS) {
+
+	m.Material.RenderSetup(gs)
+	gs.Uniform4fvUP(m.uni.Location(gs), 1, unsafe.Pointer(&m.udata))
+	gs.UniformMatrix4fv(m.uniTexMat.Location(gs), 1, false, &m.texMatrix[0])
+}
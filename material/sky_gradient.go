@@ -0,0 +1,62 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package material
+
+import (
+	"unsafe"
+
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// SkyGradient is an unlit material which colors the object with a vertical gradient
+// from the bottom color to the horizon color and to the top color, according to the
+// direction from the object origin. It is normally used by a graphic.SkyDome.
+type SkyGradient struct {
+	Material             // Embedded material
+	uni      gls.Uniform // Sky colors uniform location cache
+	udata    struct {    // Combined uniform data in 3 vec3:
+		top     math32.Color // Color above the object origin
+		horizon math32.Color // Color at the horizontal plane of the object origin
+		bottom  math32.Color // Color below the object origin
+	}
+}
+
+// NewSkyGradient creates and returns a pointer to a new sky gradient material
+// with the specified top, horizon and bottom colors.
+func NewSkyGradient(top, horizon, bottom *math32.Color) *SkyGradient {
+
+	m := new(SkyGradient)
+	m.Material.Init()
+	m.SetShader("skydome")
+	m.SetShaderUnique(true)
+	m.SetUseLights(UseLightNone)
+	m.uni.Init("Sky")
+	m.SetColors(top, horizon, bottom)
+	return m
+}
+
+// SetColors sets the top, horizon and bottom colors of the gradient.
+func (m *SkyGradient) SetColors(top, horizon, bottom *math32.Color) {
+
+	m.udata.top = *top
+	m.udata.horizon = *horizon
+	m.udata.bottom = *bottom
+}
+
+// Colors returns the top, horizon and bottom colors of the gradient.
+func (m *SkyGradient) Colors() (top, horizon, bottom math32.Color) {
+
+	return m.udata.top, m.udata.horizon, m.udata.bottom
+}
+
+// RenderSetup is called by the engine before drawing the object
+// which uses this material
+func (m *SkyGradient) RenderSetup(gs *gls.GLS) {
+
+	m.Material.RenderSetup(gs)
+	location := m.uni.Location(gs)
+	gs.Uniform3fvUP(location, 3, unsafe.Pointer(&m.udata))
+}
@@ -7,29 +7,50 @@ package material
 import (
 	"github.com/thommil/tge-g3n/gls"
 	"github.com/thommil/tge-g3n/math32"
+	"github.com/thommil/tge-g3n/texture"
 	"unsafe"
 )
 
 // Standard material supports the classic lighting model with
 // ambient, diffuse, specular and emissive lights.
+// The emissive color is added after the lighting.
 // The lighting calculation is implemented in the vertex shader.
+// If a normal, height or environment map is set the lighting calculation
+// is moved to the fragment shader.
 type Standard struct {
-	Material             // Embedded material
-	uni      gls.Uniform // Uniform location cache
-	udata    struct {    // Combined uniform data in 6 vec3:
-		ambient    math32.Color // Ambient color reflectivity
-		diffuse    math32.Color // Diffuse color reflectivity
-		specular   math32.Color // Specular color reflectivity
-		emissive   math32.Color // Emissive color
-		shininess  float32      // Specular shininess factor
-		opacity    float32      // Opacity
-		psize      float32      // Point size
-		protationZ float32      // Point rotation around Z axis
+	Material                       // Embedded material
+	normalTex *texture.Texture2D   // Optional normal map texture
+	emissTex  *texture.Texture2D   // Optional emissive map texture
+	heightTex *texture.Texture2D   // Optional height map texture
+	envTex    *texture.TextureCube // Optional environment cube map
+	envBox    [3]math32.Vector3    // Environment box projection min, max and capture position
+	envBoxOn  bool                 // Environment box projection enabled
+	uniEnvBox gls.Uniform          // Environment box uniform location cache
+	texOffset math32.Vector2       // Texture coordinates transform offset
+	texRepeat math32.Vector2       // Texture coordinates transform repeat
+	texRotate float32              // Texture coordinates transform rotation in radians
+	texMatrix math32.Matrix3       // Texture coordinates transform matrix
+	uniTexMat gls.Uniform          // Texture coordinates transform uniform location cache
+	vertexLit bool                 // Shader switched from "standard" to "phong" by the maps
+	uni       gls.Uniform          // Uniform location cache
+	udata     struct {             // Combined uniform data in 7 vec3:
+		ambient       math32.Color // Ambient color reflectivity
+		diffuse       math32.Color // Diffuse color reflectivity
+		specular      math32.Color // Specular color reflectivity
+		emissive      math32.Color // Emissive color
+		shininess     float32      // Specular shininess factor
+		opacity       float32      // Opacity
+		psize         float32      // Point size
+		protationZ    float32      // Point rotation around Z axis
+		parallaxScale float32      // Height map parallax scale
+		reflectivity  float32      // Environment map reflectivity
+		alphaTest     float32      // Alpha test threshold
+		_             [2]float32   // Padding
 	}
 }
 
 // Number of glsl shader vec3 elements used by uniform data
-const standardVec3Count = 6
+const standardVec3Count = 7
 
 // NewStandard creates and returns a pointer to a new standard material
 func NewStandard(color *math32.Color) *Standard {
@@ -48,6 +69,9 @@ func (ms *Standard) Init(shader string, color *math32.Color) {
 
 	// Creates uniforms and set initial values
 	ms.uni.Init("Material")
+	ms.uniEnvBox.Init("EnvMapBox")
+	ms.uniTexMat.Init("MatTexTransform")
+	ms.SetTextureTransform(math32.Vector2{0, 0}, math32.Vector2{1, 1}, 0)
 	ms.SetColor(color)
 	ms.SetSpecularColor(&math32.Color{0.5, 0.5, 0.5})
 	ms.SetEmissiveColor(&math32.Color{0, 0, 0})
@@ -76,11 +100,21 @@ func (ms *Standard) SetColor(color *math32.Color) {
 	ms.udata.ambient = *color
 }
 
-// SetEmissiveColor sets the material emissive color
+// Color returns the material diffuse color
+func (ms *Standard) Color() math32.Color {
+
+	return ms.udata.diffuse
+}
+
+// SetEmissiveColor sets the material emissive color, which is added to the lit color
+// and multiplied by the emissive map if set. Components above 1 are kept when the scene
+// is rendered with high dynamic range colors (see Renderer.SetExposure), so bright
+// emissive surfaces exceed the tone mapping white point.
 // The default is {0,0,0}
 func (ms *Standard) SetEmissiveColor(color *math32.Color) {
 
 	ms.udata.emissive = *color
+	ms.updateEmissive()
 }
 
 // EmissiveColor returns the material current emissive color
@@ -89,6 +123,42 @@ func (ms *Standard) EmissiveColor() math32.Color {
 	return ms.udata.emissive
 }
 
+// SetEmissiveMap sets this material optional emissive map, whose colors are
+// multiplied by the emissive color. Returns pointer to this updated material.
+func (ms *Standard) SetEmissiveMap(tex *texture.Texture2D) *Standard {
+
+	if ms.emissTex != nil {
+		ms.RemoveTexture(ms.emissTex)
+	}
+	ms.emissTex = tex
+	if ms.emissTex != nil {
+		ms.emissTex.SetUniformNames("MatEmissiveMap", "MatEmissiveMapInfo")
+		ms.ShaderDefines.Set("EMISSIVE_MAP", "")
+		ms.AddTexture(ms.emissTex)
+	} else {
+		ms.ShaderDefines.Unset("EMISSIVE_MAP")
+	}
+	ms.updateEmissive()
+	return ms
+}
+
+// EmissiveMap returns this material optional emissive map or nil.
+func (ms *Standard) EmissiveMap() *texture.Texture2D {
+
+	return ms.emissTex
+}
+
+// updateEmissive sets the EMISSIVE shader define if the material has an emissive color.
+func (ms *Standard) updateEmissive() {
+
+	e := ms.udata.emissive
+	if e.R > 0 || e.G > 0 || e.B > 0 {
+		ms.ShaderDefines.Set("EMISSIVE", "")
+	} else {
+		ms.ShaderDefines.Unset("EMISSIVE")
+	}
+}
+
 // SetSpecularColor sets the material specular color reflectivity.
 // The default is {0.5, 0.5, 0.5}
 func (ms *Standard) SetSpecularColor(color *math32.Color) {
@@ -96,18 +166,211 @@ func (ms *Standard) SetSpecularColor(color *math32.Color) {
 	ms.udata.specular = *color
 }
 
+// SpecularColor returns the material specular color reflectivity
+func (ms *Standard) SpecularColor() math32.Color {
+
+	return ms.udata.specular
+}
+
 // SetShininess sets the specular highlight factor. Default is 30.
 func (ms *Standard) SetShininess(shininess float32) {
 
 	ms.udata.shininess = shininess
 }
 
+// Shininess returns the specular highlight factor
+func (ms *Standard) Shininess() float32 {
+
+	return ms.udata.shininess
+}
+
 // SetOpacity sets the material opacity (alpha). Default is 1.0.
 func (ms *Standard) SetOpacity(opacity float32) {
 
 	ms.udata.opacity = opacity
 }
 
+// Opacity returns the material opacity (alpha)
+func (ms *Standard) Opacity() float32 {
+
+	return ms.udata.opacity
+}
+
+// SetAlphaTest sets the alpha threshold below which the fragments are discarded
+// and makes this material opaque, so it is rendered in the opaque pass writing
+// the depth buffer without back to front sorting. It is used for cutouts such as
+// foliage and fences. A threshold of 0 disables the alpha test.
+// Returns pointer to this updated material.
+func (ms *Standard) SetAlphaTest(threshold float32) *Standard {
+
+	ms.udata.alphaTest = threshold
+	if threshold > 0 {
+		ms.ShaderDefines.Set("ALPHA_TEST", "")
+		ms.SetTransparent(false)
+	} else {
+		ms.ShaderDefines.Unset("ALPHA_TEST")
+	}
+	return ms
+}
+
+// AlphaTest returns the alpha test threshold or 0 if disabled.
+func (ms *Standard) AlphaTest() float32 {
+
+	return ms.udata.alphaTest
+}
+
+// SetNormalMap sets this material optional tangent space normal map.
+// The geometry must contain tangents (see geometry.ComputeTangents), otherwise
+// they are approximated from the screen space derivatives in the shader.
+// Returns pointer to this updated material.
+func (ms *Standard) SetNormalMap(tex *texture.Texture2D) *Standard {
+
+	if ms.normalTex != nil {
+		ms.RemoveTexture(ms.normalTex)
+	}
+	ms.normalTex = tex
+	if ms.normalTex != nil {
+		ms.normalTex.SetUniformNames("MatNormalMap", "MatNormalMapInfo")
+		ms.ShaderDefines.Set("NORMAL_MAP", "")
+		ms.AddTexture(ms.normalTex)
+	} else {
+		ms.ShaderDefines.Unset("NORMAL_MAP")
+	}
+	ms.updateLighting()
+	return ms
+}
+
+// NormalMap returns this material optional normal map or nil.
+func (ms *Standard) NormalMap() *texture.Texture2D {
+
+	return ms.normalTex
+}
+
+// SetHeightMap sets this material optional height map used for parallax occlusion
+// mapping with the specified scale. Texels with higher values in the red channel are
+// closer to the surface. Typical scale values are in the [0.01, 0.1] range.
+// Returns pointer to this updated material.
+func (ms *Standard) SetHeightMap(tex *texture.Texture2D, scale float32) *Standard {
+
+	if ms.heightTex != nil {
+		ms.RemoveTexture(ms.heightTex)
+	}
+	ms.heightTex = tex
+	ms.udata.parallaxScale = scale
+	if ms.heightTex != nil {
+		ms.heightTex.SetUniformNames("MatHeightMap", "MatHeightMapInfo")
+		ms.ShaderDefines.Set("PARALLAX", "")
+		ms.AddTexture(ms.heightTex)
+	} else {
+		ms.ShaderDefines.Unset("PARALLAX")
+	}
+	ms.updateLighting()
+	return ms
+}
+
+// HeightMap returns this material optional height map or nil.
+func (ms *Standard) HeightMap() *texture.Texture2D {
+
+	return ms.heightTex
+}
+
+// HeightScale returns the parallax scale of this material height map.
+func (ms *Standard) HeightScale() float32 {
+
+	return ms.udata.parallaxScale
+}
+
+// SetEnvMap sets this material optional environment cube map, such as the texture
+// captured by a reflection probe, mixed with the lit color by the specified reflectivity
+// in the [0, 1] range. Returns pointer to this updated material.
+func (ms *Standard) SetEnvMap(tex *texture.TextureCube, reflectivity float32) *Standard {
+
+	ms.envTex = tex
+	ms.udata.reflectivity = reflectivity
+	if ms.envTex != nil {
+		ms.envTex.SetUniformName("MatEnvMap")
+		ms.ShaderDefines.Set("ENV_MAP", "")
+	} else {
+		ms.ShaderDefines.Unset("ENV_MAP")
+	}
+	ms.updateLighting()
+	return ms
+}
+
+// EnvMap returns this material optional environment cube map or nil.
+func (ms *Standard) EnvMap() *texture.TextureCube {
+
+	return ms.envTex
+}
+
+// SetEnvMapBox enables the box projection correction of the environment map
+// for the specified world space box and the position the map was captured from.
+// A nil min or max disables the correction.
+// Returns pointer to this updated material.
+func (ms *Standard) SetEnvMapBox(min, max, position *math32.Vector3) *Standard {
+
+	ms.envBoxOn = min != nil && max != nil
+	if !ms.envBoxOn {
+		ms.ShaderDefines.Unset("ENV_MAP_BOX")
+		return ms
+	}
+	ms.envBox[0] = *min
+	ms.envBox[1] = *max
+	ms.envBox[2] = *position
+	ms.ShaderDefines.Set("ENV_MAP_BOX", "")
+	return ms
+}
+
+// SetTextureTransform sets the transform of the texture coordinates of this material,
+// which are scaled by the repeat factors, rotated counterclockwise by the rotation angle
+// in radians around the center of the scaled texture and translated by the offset,
+// before the offset and repeat of each texture. Animating the offset, for example with
+// a material track of the material.PropTextureOffset property, scrolls the textures,
+// whose wrapping modes should be REPEAT. The default is the identity transform,
+// with no offset, a repeat of {1,1} and no rotation.
+func (ms *Standard) SetTextureTransform(offset, repeat math32.Vector2, rotation float32) {
+
+	ms.texOffset = offset
+	ms.texRepeat = repeat
+	ms.texRotate = rotation
+	if offset.X == 0 && offset.Y == 0 && repeat.X == 1 && repeat.Y == 1 && rotation == 0 {
+		ms.texMatrix.Identity()
+		ms.ShaderDefines.Unset("TEX_TRANSFORM")
+		return
+	}
+	c, s := math32.Cos(rotation), math32.Sin(rotation)
+	cx, cy := repeat.X/2, repeat.Y/2
+	ms.texMatrix.Set(
+		c*repeat.X, -s*repeat.Y, -c*cx+s*cy+cx+offset.X,
+		s*repeat.X, c*repeat.Y, -s*cx-c*cy+cy+offset.Y,
+		0, 0, 1,
+	)
+	ms.ShaderDefines.Set("TEX_TRANSFORM", "")
+}
+
+// TextureTransform returns the offset, repeat and rotation of the
+// transform of the texture coordinates of this material.
+func (ms *Standard) TextureTransform() (offset, repeat math32.Vector2, rotation float32) {
+
+	return ms.texOffset, ms.texRepeat, ms.texRotate
+}
+
+// updateLighting selects the per fragment lighting shader if a normal, height or environment
+// map is used by a material with the per vertex lighting "standard" shader, and restores it
+// when all maps are removed.
+func (ms *Standard) updateLighting() {
+
+	if ms.normalTex != nil || ms.heightTex != nil || ms.envTex != nil {
+		if ms.Shader() == "standard" {
+			ms.SetShader("phong")
+			ms.vertexLit = true
+		}
+	} else if ms.vertexLit {
+		ms.SetShader("standard")
+		ms.vertexLit = false
+	}
+}
+
 // RenderSetup is called by the engine before drawing the object
 // which uses this material
 func (ms *Standard) RenderSetup(gs *gls.GLS) {
@@ -115,4 +378,17 @@ func (ms *Standard) RenderSetup(gs *gls.GLS) {
 	ms.Material.RenderSetup(gs)
 	location := ms.uni.Location(gs)
 	gs.Uniform3fvUP(location, standardVec3Count, unsafe.Pointer(&ms.udata))
+	if _, ok := ms.ShaderDefines["TEX_TRANSFORM"]; ok {
+		gs.UniformMatrix3fv(ms.uniTexMat.Location(gs), 1, false, &ms.texMatrix[0])
+	}
+
+	// The environment map uses the first texture unit after the material textures
+	if ms.envTex != nil {
+		if unit := ms.textureUnit(gs); unit >= 0 {
+			ms.envTex.RenderSetup(gs, unit)
+		}
+		if ms.envBoxOn {
+			gs.Uniform3fv(ms.uniEnvBox.Location(gs), 3, &ms.envBox[0].X)
+		}
+	}
 }
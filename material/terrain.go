@@ -0,0 +1,128 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package material
+
+import (
+	"strconv"
+
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/math32"
+	"github.com/thommil/tge-g3n/texture"
+)
+
+// TerrainMaxLayers is the maximum number of texture array layers blended by the terrain material.
+const TerrainMaxLayers = 4
+
+// Terrain material blends up to 4 layers of a texture array (splatting)
+// using per vertex weights from the geometry VertexColor attribute (layers 0 to 2)
+// or per pixel weights from the RGBA channels of an optional weight map.
+// The lighting calculation is implemented in the fragment shader.
+type Terrain struct {
+	Standard                          // Embedded standard material
+	layers    *texture.Texture2DArray // Texture array with the terrain layers
+	weightTex *texture.Texture2D      // Optional weight map texture
+	uniRepeat gls.Uniform             // Layers repeat uniform location cache
+	repeat    math32.Vector2          // Layers repeat factors
+}
+
+// NewTerrain creates and returns a pointer to a new terrain material
+// blending the layers of the specified texture array, which may be nil.
+func NewTerrain(layers *texture.Texture2DArray) *Terrain {
+
+	m := new(Terrain)
+	m.Standard.Init("terrain", &math32.Color{1, 1, 1})
+	m.uniRepeat.Init("TerrainRepeat")
+	m.repeat.Set(1, 1)
+	m.SetLayers(layers)
+	return m
+}
+
+// SetLayers sets the texture array with the terrain layers.
+// Only the first TerrainMaxLayers layers are used.
+// A nil texture array clears the layers and the material colors are used alone.
+// Returns pointer to this updated material.
+func (m *Terrain) SetLayers(layers *texture.Texture2DArray) *Terrain {
+
+	m.layers = layers
+	count := 0
+	if m.layers != nil {
+		m.layers.SetUniformName("TerrainLayers")
+		count = layers.Layers()
+	}
+	if count > TerrainMaxLayers {
+		count = TerrainMaxLayers
+	}
+	m.ShaderDefines.Set("SPLAT_LAYERS", strconv.Itoa(count))
+	return m
+}
+
+// Layers returns the texture array with the terrain layers or nil.
+func (m *Terrain) Layers() *texture.Texture2DArray {
+
+	return m.layers
+}
+
+// SetWeightMap sets the optional weight map texture. The RGBA channels of the weight map
+// are the weights of the layers 0 to 3. If not set, the weights are read from the
+// VertexColor attribute of the geometry.
+// Returns pointer to this updated material.
+func (m *Terrain) SetWeightMap(tex *texture.Texture2D) *Terrain {
+
+	if m.weightTex != nil {
+		m.RemoveTexture(m.weightTex)
+	}
+	m.weightTex = tex
+	if m.weightTex != nil {
+		m.weightTex.SetUniformNames("TerrainWeights", "TerrainWeightsInfo")
+		m.ShaderDefines.Set("SPLAT_WEIGHTMAP", "")
+		m.AddTexture(m.weightTex)
+	} else {
+		m.ShaderDefines.Unset("SPLAT_WEIGHTMAP")
+	}
+	return m
+}
+
+// WeightMap returns the optional weight map texture or nil.
+func (m *Terrain) WeightMap() *texture.Texture2D {
+
+	return m.weightTex
+}
+
+// SetLayersRepeat sets the number of times the layers are repeated over the
+// geometry texture coordinates. The weight map is not repeated. Default is {1, 1}.
+func (m *Terrain) SetLayersRepeat(x, y float32) {
+
+	m.repeat.Set(x, y)
+}
+
+// LayersRepeat returns the layers repeat factors.
+func (m *Terrain) LayersRepeat() (float32, float32) {
+
+	return m.repeat.X, m.repeat.Y
+}
+
+// Dispose decrements this material reference count and
+// releases its textures when no longer referenced.
+func (m *Terrain) Dispose() {
+
+	if m.refcount == 1 && m.layers != nil {
+		m.layers.Dispose()
+	}
+	m.Standard.Dispose()
+}
+
+// RenderSetup is called by the engine before drawing the object
+// which uses this material
+func (m *Terrain) RenderSetup(gs *gls.GLS) {
+
+	m.Standard.RenderSetup(gs)
+	// The texture array uses the first texture unit after the standard material textures
+	if m.layers != nil {
+		if unit := m.textureUnit(gs); unit >= 0 {
+			m.layers.RenderSetup(gs, unit)
+		}
+	}
+	gs.Uniform2f(m.uniRepeat.Location(gs), m.repeat.X, m.repeat.Y)
+}
@@ -0,0 +1,76 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package material
+
+import (
+	"unsafe"
+
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// ThickLine is an unlit material for lines drawn as quads facing the camera with
+// a width in pixels, independent of the line width of OpenGL, which many drivers
+// and WebGL limit to 1. It is used by graphic.ThickLines which builds the quads.
+type ThickLine struct {
+	Material             // Embedded material
+	uni      gls.Uniform // Line uniform location cache
+	udata    struct {    // Combined uniform data in 2 vec4:
+		color    math32.Color // Lines color
+		width    float32      // Lines width in pixels
+		viewport [4]float32   // Viewport width and height in pixels
+	}
+}
+
+// NewThickLine creates and returns a pointer to a new thick line material
+// with the specified color and width in pixels.
+func NewThickLine(color *math32.Color, width float32) *ThickLine {
+
+	m := new(ThickLine)
+	m.Material.Init()
+	m.SetShader("thickline")
+	m.SetUseLights(UseLightNone)
+	m.SetSide(SideDouble)
+	m.uni.Init("ThickLine")
+	m.udata.color = *color
+	m.udata.width = width
+	return m
+}
+
+// SetColor sets the color of the lines.
+func (m *ThickLine) SetColor(color *math32.Color) {
+
+	m.udata.color = *color
+}
+
+// Color returns the color of the lines.
+func (m *ThickLine) Color() math32.Color {
+
+	return m.udata.color
+}
+
+// SetWidth sets the width of the lines in pixels.
+func (m *ThickLine) SetWidth(width float32) {
+
+	m.udata.width = width
+}
+
+// Width returns the width of the lines in pixels.
+func (m *ThickLine) Width() float32 {
+
+	return m.udata.width
+}
+
+// RenderSetup is called by the engine before drawing the object
+// which uses this material
+func (m *ThickLine) RenderSetup(gs *gls.GLS) {
+
+	m.Material.RenderSetup(gs)
+	_, _, width, height := gs.GetViewport()
+	m.udata.viewport[0] = float32(width)
+	m.udata.viewport[1] = float32(height)
+	location := m.uni.Location(gs)
+	gs.Uniform4fvUP(location, 2, unsafe.Pointer(&m.udata))
+}
@@ -178,6 +178,12 @@ func (f *Frustum) ContainsPoint(point *Vector3) bool {
 	return true
 }
 
+// Plane returns a pointer to the plane of this frustum at the specified index from 0 to 5.
+func (f *Frustum) Plane(idx int) *Plane {
+
+	return &f.planes[idx]
+}
+
 // Clone returns a pointer to a new Frustum object with the same planes as the original
 func (f *Frustum) Clone() *Frustum {
 
@@ -98,6 +98,16 @@ func IsNaN(v float32) bool {
 	return math.IsNaN(float64(v))
 }
 
+func Sign(v float32) float32 {
+	if v > 0 {
+		return 1
+	}
+	if v < 0 {
+		return -1
+	}
+	return 0
+}
+
 func Sin(v float32) float32 {
 	return float32(math.Sin(float64(v)))
 }
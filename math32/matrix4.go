@@ -622,6 +622,22 @@ func (m *Matrix4) MakeScale(x, y, z float32) *Matrix4 {
 	return m
 }
 
+// MakeReflection sets this matrix to a reflection transformation across the specified plane.
+// The plane normal is assumed to be normalized.
+// Returns pointer to this updated matrix.
+func (m *Matrix4) MakeReflection(plane *Plane) *Matrix4 {
+
+	n := &plane.normal
+	d := plane.constant
+	m.Set(
+		1-2*n.X*n.X, -2*n.X*n.Y, -2*n.X*n.Z, -2*n.X*d,
+		-2*n.Y*n.X, 1-2*n.Y*n.Y, -2*n.Y*n.Z, -2*n.Y*d,
+		-2*n.Z*n.X, -2*n.Z*n.Y, 1-2*n.Z*n.Z, -2*n.Z*d,
+		0, 0, 0, 1,
+	)
+	return m
+}
+
 // Compose sets this matrix to a transformation matrix for the specified position,
 // rotation specified by the quaternion and scale.
 // Returns pointer to this updated matrix.
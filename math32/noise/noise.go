@@ -0,0 +1,222 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package noise implements seeded Perlin, simplex and value noise functions
+// of float32 coordinates for procedural textures, terrains and gameplay.
+// The noise of a seed is deterministic.
+package noise
+
+import (
+	"math/rand"
+
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// Noise is a noise generator whose output only depends on its seed.
+type Noise struct {
+	perm [512]uint8 // Permutation table of the lattice hashes, repeated twice
+}
+
+// Generator of the package functions
+var defaultNoise = New(0)
+
+// Noise2D returns the 2D Perlin noise of the specified point with the seed 0, approximately in the range [-1, 1].
+func Noise2D(x, y float32) float32 {
+
+	return defaultNoise.Perlin2D(x, y)
+}
+
+// Noise3D returns the 3D Perlin noise of the specified point with the seed 0, approximately in the range [-1, 1].
+func Noise3D(x, y, z float32) float32 {
+
+	return defaultNoise.Perlin3D(x, y, z)
+}
+
+// New creates and returns a pointer to a new noise generator with the specified seed.
+func New(seed int64) *Noise {
+
+	n := new(Noise)
+	perm := rand.New(rand.NewSource(seed)).Perm(256)
+	for i := range n.perm {
+		n.perm[i] = uint8(perm[i&255])
+	}
+	return n
+}
+
+// Perlin2D returns the 2D Perlin gradient noise of the specified point, approximately in the range [-1, 1].
+// It is 0 at the integer coordinates.
+func (n *Noise) Perlin2D(x, y float32) float32 {
+
+	fx, fy := math32.Floor(x), math32.Floor(y)
+	xi, yi := int(fx)&255, int(fy)&255
+	x, y = x-fx, y-fy
+	u, v := fade(x), fade(y)
+
+	p := &n.perm
+	a, b := int(p[xi])+yi, int(p[xi+1])+yi
+	return lerp(v,
+		lerp(u, grad2(p[a], x, y), grad2(p[b], x-1, y)),
+		lerp(u, grad2(p[a+1], x, y-1), grad2(p[b+1], x-1, y-1)))
+}
+
+// Perlin3D returns the 3D Perlin gradient noise of the specified point, approximately in the range [-1, 1].
+// It is 0 at the integer coordinates.
+func (n *Noise) Perlin3D(x, y, z float32) float32 {
+
+	fx, fy, fz := math32.Floor(x), math32.Floor(y), math32.Floor(z)
+	xi, yi, zi := int(fx)&255, int(fy)&255, int(fz)&255
+	x, y, z = x-fx, y-fy, z-fz
+	u, v, w := fade(x), fade(y), fade(z)
+
+	p := &n.perm
+	a := int(p[xi]) + yi
+	aa, ab := int(p[a])+zi, int(p[a+1])+zi
+	b := int(p[xi+1]) + yi
+	ba, bb := int(p[b])+zi, int(p[b+1])+zi
+	return lerp(w,
+		lerp(v,
+			lerp(u, grad3(p[aa], x, y, z), grad3(p[ba], x-1, y, z)),
+			lerp(u, grad3(p[ab], x, y-1, z), grad3(p[bb], x-1, y-1, z))),
+		lerp(v,
+			lerp(u, grad3(p[aa+1], x, y, z-1), grad3(p[ba+1], x-1, y, z-1)),
+			lerp(u, grad3(p[ab+1], x, y-1, z-1), grad3(p[bb+1], x-1, y-1, z-1))))
+}
+
+// Skewing factors of the 2D simplex grid
+const (
+	simplexF2 = 0.36602540378 // (sqrt(3) - 1) / 2
+	simplexG2 = 0.21132486540 // (3 - sqrt(3)) / 6
+)
+
+// Simplex2D returns the 2D simplex noise of the specified point, approximately in the range [-1, 1].
+// It has fewer directional artifacts than the Perlin noise.
+func (n *Noise) Simplex2D(x, y float32) float32 {
+
+	// Cell of the skewed grid and first corner of the triangle
+	s := (x + y) * simplexF2
+	fi, fj := math32.Floor(x+s), math32.Floor(y+s)
+	t := (fi + fj) * simplexG2
+	x0, y0 := x-(fi-t), y-(fj-t)
+
+	// Second corner of the triangle
+	var i1, j1 int
+	if x0 > y0 {
+		i1 = 1
+	} else {
+		j1 = 1
+	}
+	x1, y1 := x0-float32(i1)+simplexG2, y0-float32(j1)+simplexG2
+	x2, y2 := x0-1+2*simplexG2, y0-1+2*simplexG2
+
+	p := &n.perm
+	i, j := int(fi)&255, int(fj)&255
+	corner := func(h uint8, x, y float32) float32 {
+		t := 0.5 - x*x - y*y
+		if t < 0 {
+			return 0
+		}
+		t *= t
+		return t * t * grad2(h, x, y)
+	}
+	sum := corner(p[i+int(p[j])], x0, y0) +
+		corner(p[i+i1+int(p[j+j1])], x1, y1) +
+		corner(p[i+1+int(p[j+1])], x2, y2)
+	return math32.Clamp(70*sum, -1, 1)
+}
+
+// Value2D returns the 2D value noise of the specified point in the range [-1, 1],
+// which smoothly interpolates random values at the integer coordinates.
+func (n *Noise) Value2D(x, y float32) float32 {
+
+	fx, fy := math32.Floor(x), math32.Floor(y)
+	xi, yi := int(fx)&255, int(fy)&255
+	u, v := fade(x-fx), fade(y-fy)
+
+	p := &n.perm
+	value := func(i, j int) float32 {
+		return float32(p[int(p[i])+j])/127.5 - 1
+	}
+	return lerp(v,
+		lerp(u, value(xi, yi), value(xi+1, yi)),
+		lerp(u, value(xi, yi+1), value(xi+1, yi+1)))
+}
+
+// Fractal2D returns the sum of the specified number of octaves of the specified 2D noise
+// function, each with its frequency multiplied by lacunarity and its amplitude multiplied
+// by persistence, divided by the sum of the amplitudes so it stays in the range [-1, 1].
+func Fractal2D(noise func(x, y float32) float32, x, y float32, octaves int, lacunarity, persistence float32) float32 {
+
+	var sum, total float32
+	amplitude := float32(1)
+	for i := 0; i < octaves; i++ {
+		sum += amplitude * noise(x, y)
+		total += amplitude
+		x *= lacunarity
+		y *= lacunarity
+		amplitude *= persistence
+	}
+	if total == 0 {
+		return 0
+	}
+	return sum / total
+}
+
+// fade returns the quintic smoothstep of t in [0, 1].
+func fade(t float32) float32 {
+
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+// lerp returns the linear interpolation between a and b.
+func lerp(t, a, b float32) float32 {
+
+	return a + t*(b-a)
+}
+
+// grad2 returns the dot product of the specified offset and one of 8 gradients chosen by the hash.
+func grad2(h uint8, x, y float32) float32 {
+
+	switch h & 7 {
+	case 0:
+		return x + y
+	case 1:
+		return -x + y
+	case 2:
+		return x - y
+	case 3:
+		return -x - y
+	case 4:
+		return x
+	case 5:
+		return -x
+	case 6:
+		return y
+	default:
+		return -y
+	}
+}
+
+// grad3 returns the dot product of the specified offset and one of the 12 edge
+// gradients of the improved Perlin noise chosen by the hash.
+func grad3(h uint8, x, y, z float32) float32 {
+
+	h &= 15
+	u := y
+	if h < 8 {
+		u = x
+	}
+	v := z
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	}
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	return u + v
+}
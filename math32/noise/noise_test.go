@@ -0,0 +1,57 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package noise
+
+import (
+	"testing"
+)
+
+// Test that the noise of a seed is deterministic, differs between seeds and stays in range
+func TestNoiseSeed(t *testing.T) {
+
+	a, b, c := New(42), New(42), New(7)
+	funcs := []struct {
+		name string
+		fa   func(x, y float32) float32
+		fb   func(x, y float32) float32
+		fc   func(x, y float32) float32
+	}{
+		{"Perlin2D", a.Perlin2D, b.Perlin2D, c.Perlin2D},
+		{"Simplex2D", a.Simplex2D, b.Simplex2D, c.Simplex2D},
+		{"Value2D", a.Value2D, b.Value2D, c.Value2D},
+	}
+	for _, f := range funcs {
+		differ := false
+		for i := 0; i < 1000; i++ {
+			x, y := float32(i)*0.137-20, float32(i%37)*0.291-5
+			va := f.fa(x, y)
+			if vb := f.fb(x, y); va != vb {
+				t.Fatalf("%s: expected the same noise for the same seed at (%v,%v) got %v and %v", f.name, x, y, va, vb)
+			}
+			if va < -1 || va > 1 {
+				t.Fatalf("%s: expected noise in [-1, 1] at (%v,%v) got %v", f.name, x, y, va)
+			}
+			differ = differ || va != f.fc(x, y)
+		}
+		if !differ {
+			t.Errorf("%s: expected a different noise for a different seed", f.name)
+		}
+	}
+}
+
+// Test that the Perlin noise is 0 at the integer coordinates
+func TestPerlinLattice(t *testing.T) {
+
+	for x := -3; x <= 3; x++ {
+		for y := -3; y <= 3; y++ {
+			if v := Noise2D(float32(x), float32(y)); v != 0 {
+				t.Errorf("expected 0 at (%d,%d) got %v", x, y, v)
+			}
+			if v := Noise3D(float32(x), float32(y), 1); v != 0 {
+				t.Errorf("expected 0 at (%d,%d,1) got %v", x, y, v)
+			}
+		}
+	}
+}
@@ -91,6 +91,18 @@ func (p *Plane) Negate() *Plane {
 	return p
 }
 
+// Normal returns the normal of this plane.
+func (p *Plane) Normal() Vector3 {
+
+	return p.normal
+}
+
+// Constant returns the constant of this plane.
+func (p *Plane) Constant() float32 {
+
+	return p.constant
+}
+
 // DistanceToPoint returns the distance of this plane from point.
 func (p *Plane) DistanceToPoint(point *Vector3) float32 {
 
@@ -0,0 +1,193 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package physics implements lightweight rigid body collisions between
+// axis aligned boxes and spheres attached to nodes, with gravity,
+// penetration resolution and collision events.
+// For a more complete engine see the experimental physics package.
+package physics
+
+import (
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// ColliderType is the type of the collision shape of a body.
+type ColliderType int
+
+// The collider types.
+const (
+	BoxCollider    = ColliderType(iota) // Axis aligned box
+	SphereCollider                      // Sphere
+)
+
+// Body is a rigid body moving a node, with an axis aligned box or sphere collider.
+// The body sets the position of its node, so the node should be a child of
+// a node without rotation or scale, such as the scene. The node rotation is ignored.
+// A body with zero mass is static.
+type Body struct {
+	node        core.INode     // Node moved by the body
+	collider    ColliderType   // Collider type
+	halfSize    math32.Vector3 // Half extents of the box collider
+	radius      float32        // Radius of the sphere collider
+	offset      math32.Vector3 // Collider center relative to the node position
+	mass        float32        // Mass or 0 for static bodies
+	invMass     float32        // Inverse of the mass or 0 for static bodies
+	velocity    math32.Vector3 // Linear velocity
+	restitution float32        // Fraction of the velocity kept when bouncing
+	trigger     bool           // Whether collisions are reported without being resolved
+}
+
+// NewBoxBody creates and returns a pointer to a new body for the specified node with an
+// axis aligned box collider with the specified half extents, centered on the node position.
+func NewBoxBody(node core.INode, halfSize math32.Vector3, mass float32) *Body {
+
+	b := newBody(node, mass)
+	b.collider = BoxCollider
+	b.halfSize = halfSize
+	return b
+}
+
+// NewSphereBody creates and returns a pointer to a new body for the specified node
+// with a sphere collider with the specified radius, centered on the node position.
+func NewSphereBody(node core.INode, radius, mass float32) *Body {
+
+	b := newBody(node, mass)
+	b.collider = SphereCollider
+	b.radius = radius
+	b.halfSize.Set(radius, radius, radius)
+	return b
+}
+
+// newBody creates and returns a pointer to a new body without collider.
+func newBody(node core.INode, mass float32) *Body {
+
+	b := new(Body)
+	b.node = node
+	b.SetMass(mass)
+	return b
+}
+
+// Node returns the node moved by this body.
+func (b *Body) Node() core.INode {
+
+	return b.node
+}
+
+// Collider returns the type of the collider of this body.
+func (b *Body) Collider() ColliderType {
+
+	return b.collider
+}
+
+// SetMass sets the mass of this body. A zero mass makes the body static.
+func (b *Body) SetMass(mass float32) {
+
+	if mass < 0 {
+		panic("Body.SetMass: mass must not be negative")
+	}
+	b.mass = mass
+	b.invMass = 0
+	if mass > 0 {
+		b.invMass = 1 / mass
+	} else {
+		b.velocity.Zero()
+	}
+}
+
+// Mass returns the mass of this body.
+func (b *Body) Mass() float32 {
+
+	return b.mass
+}
+
+// Static returns whether this body is static.
+func (b *Body) Static() bool {
+
+	return b.invMass == 0
+}
+
+// SetOffset sets the position of the collider center relative to the node position.
+func (b *Body) SetOffset(offset math32.Vector3) {
+
+	b.offset = offset
+}
+
+// Offset returns the position of the collider center relative to the node position.
+func (b *Body) Offset() math32.Vector3 {
+
+	return b.offset
+}
+
+// SetVelocity sets the linear velocity of this body.
+func (b *Body) SetVelocity(velocity math32.Vector3) {
+
+	if !b.Static() {
+		b.velocity = velocity
+	}
+}
+
+// Velocity returns the linear velocity of this body.
+func (b *Body) Velocity() math32.Vector3 {
+
+	return b.velocity
+}
+
+// ApplyImpulse changes the velocity of this body by the specified impulse divided by its mass.
+func (b *Body) ApplyImpulse(impulse math32.Vector3) {
+
+	b.velocity.Add(impulse.MultiplyScalar(b.invMass))
+}
+
+// SetRestitution sets the fraction of the velocity kept when this body bounces,
+// from 0 for no bounce to 1 for a perfect bounce.
+// The restitution of a collision is the maximum of the restitutions of the two bodies.
+func (b *Body) SetRestitution(restitution float32) {
+
+	b.restitution = restitution
+}
+
+// Restitution returns the fraction of the velocity kept when this body bounces.
+func (b *Body) Restitution() float32 {
+
+	return b.restitution
+}
+
+// SetTrigger sets whether the collisions of this body are reported without being resolved.
+func (b *Body) SetTrigger(trigger bool) {
+
+	b.trigger = trigger
+}
+
+// Trigger returns whether the collisions of this body are reported without being resolved.
+func (b *Body) Trigger() bool {
+
+	return b.trigger
+}
+
+// Center returns the position of the collider center.
+func (b *Body) Center() math32.Vector3 {
+
+	center := b.node.GetNode().Position()
+	center.Add(&b.offset)
+	return center
+}
+
+// BoundingBox returns the axis aligned bounding box of the collider.
+func (b *Body) BoundingBox() math32.Box3 {
+
+	center := b.Center()
+	var box math32.Box3
+	box.Min.SubVectors(&center, &b.halfSize)
+	box.Max.AddVectors(&center, &b.halfSize)
+	return box
+}
+
+// translate moves the node of this body by the specified offset.
+func (b *Body) translate(offset *math32.Vector3) {
+
+	node := b.node.GetNode()
+	pos := node.Position()
+	node.SetPositionVec(pos.Add(offset))
+}
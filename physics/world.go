@@ -0,0 +1,367 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package physics
+
+import (
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// OnCollision is the event dispatched to the nodes of two colliding bodies
+// at each step they collide. The event data is a pointer to a Collision.
+const OnCollision = "physics.OnCollision"
+
+// contactSlop is the distance under which touching bodies are considered in contact.
+const contactSlop = 1e-4
+
+// Collision describes the contact between the body of the node receiving
+// the OnCollision event and another body.
+type Collision struct {
+	Body   *Body          // Body of the node receiving the event
+	Other  *Body          // Other colliding body
+	Normal math32.Vector3 // Contact normal pointing from Body toward Other
+	Depth  float32        // Penetration depth before resolution
+}
+
+// World is a set of bodies moved by gravity and by their velocities,
+// whose collisions are detected and resolved at each step.
+// Dynamic bodies are swept against static bodies as boxes, so that
+// fast bodies do not tunnel through thin static bodies.
+type World struct {
+	bodies   []*Body            // Bodies of the world
+	gravity  math32.Vector3     // Gravity acceleration
+	cellSize float32            // Size of the cells of the broad phase grid
+	grid     map[[3]int32][]int // Bodies indices of each cell of the broad phase grid
+	pairs    []uint64           // Pairs of bodies found by the broad phase in order
+	seen     map[uint64]bool    // Pairs of bodies already found by the broad phase
+	contacts []Collision        // Contacts found by the last step
+}
+
+// NewWorld creates and returns a pointer to a new World with the earth gravity along -Y.
+func NewWorld() *World {
+
+	w := new(World)
+	w.gravity.Set(0, -9.81, 0)
+	w.cellSize = 4
+	w.grid = make(map[[3]int32][]int)
+	w.seen = make(map[uint64]bool)
+	return w
+}
+
+// AddBody adds the specified body to this world.
+func (w *World) AddBody(b *Body) {
+
+	w.bodies = append(w.bodies, b)
+}
+
+// RemoveBody removes the specified body from this world
+// and returns whether it was found.
+func (w *World) RemoveBody(b *Body) bool {
+
+	for i, body := range w.bodies {
+		if body == b {
+			copy(w.bodies[i:], w.bodies[i+1:])
+			w.bodies[len(w.bodies)-1] = nil
+			w.bodies = w.bodies[:len(w.bodies)-1]
+			return true
+		}
+	}
+	return false
+}
+
+// Bodies returns the bodies of this world.
+func (w *World) Bodies() []*Body {
+
+	return w.bodies
+}
+
+// SetGravity sets the gravity acceleration.
+func (w *World) SetGravity(gravity math32.Vector3) {
+
+	w.gravity = gravity
+}
+
+// Gravity returns the gravity acceleration.
+func (w *World) Gravity() math32.Vector3 {
+
+	return w.gravity
+}
+
+// SetCellSize sets the size of the cells of the uniform grid used to find the
+// bodies which may collide, which should be about the size of the common bodies.
+// The default value is 4.
+func (w *World) SetCellSize(size float32) {
+
+	if size <= 0 {
+		panic("World.SetCellSize: size must be greater than 0")
+	}
+	w.cellSize = size
+}
+
+// Contacts returns the contacts found by the last step, once per pair of colliding bodies.
+func (w *World) Contacts() []Collision {
+
+	return w.contacts
+}
+
+// Step advances the world by the specified time in seconds: the dynamic bodies are
+// accelerated by the gravity and moved by their velocities, then the penetrations
+// of the colliding bodies are resolved and the OnCollision events are dispatched.
+func (w *World) Step(dt float32) {
+
+	// Moves the dynamic bodies, stopping them at the static bodies on their way
+	w.buildGrid()
+	for _, b := range w.bodies {
+		if b.Static() {
+			continue
+		}
+		gravity := w.gravity
+		b.velocity.Add(gravity.MultiplyScalar(dt))
+		move := b.velocity
+		move.MultiplyScalar(dt)
+		if !b.trigger {
+			if toi, normal, ok := w.sweep(b, &move); ok {
+				move.MultiplyScalar(toi)
+				if vn := b.velocity.Dot(&normal); vn < 0 {
+					b.velocity.Sub(normal.MultiplyScalar((1 + b.restitution) * vn))
+				}
+			}
+		}
+		b.translate(&move)
+	}
+
+	// Finds and resolves the contacts between the bodies which may collide
+	w.buildGrid()
+	w.contacts = w.contacts[:0]
+	for _, pair := range w.pairs {
+		a, b := w.bodies[pair>>32], w.bodies[pair&0xFFFFFFFF]
+		c, ok := contact(a, b)
+		if !ok {
+			continue
+		}
+		if !a.trigger && !b.trigger {
+			resolve(&c)
+		}
+		w.contacts = append(w.contacts, c)
+	}
+
+	// Dispatches the collision events
+	for i := range w.contacts {
+		c := w.contacts[i]
+		c.Body.node.Dispatch(OnCollision, &c)
+		other := Collision{Body: c.Other, Other: c.Body, Normal: *c.Normal.Clone().Negate(), Depth: c.Depth}
+		other.Body.node.Dispatch(OnCollision, &other)
+	}
+}
+
+// buildGrid inserts the bodies in the cells of the broad phase grid
+// overlapped by their bounding boxes and collects the pairs of bodies sharing a cell.
+func (w *World) buildGrid() {
+
+	for key := range w.grid {
+		delete(w.grid, key)
+	}
+	for key := range w.seen {
+		delete(w.seen, key)
+	}
+	w.pairs = w.pairs[:0]
+	for i, b := range w.bodies {
+		box := b.BoundingBox()
+		min, max := w.cell(&box.Min), w.cell(&box.Max)
+		for x := min[0]; x <= max[0]; x++ {
+			for y := min[1]; y <= max[1]; y++ {
+				for z := min[2]; z <= max[2]; z++ {
+					key := [3]int32{x, y, z}
+					for _, j := range w.grid[key] {
+						pair := uint64(j)<<32 | uint64(i)
+						if (!b.Static() || !w.bodies[j].Static()) && !w.seen[pair] {
+							w.seen[pair] = true
+							w.pairs = append(w.pairs, pair)
+						}
+					}
+					w.grid[key] = append(w.grid[key], i)
+				}
+			}
+		}
+	}
+}
+
+// cell returns the coordinates of the grid cell containing the specified point.
+func (w *World) cell(p *math32.Vector3) [3]int32 {
+
+	return [3]int32{
+		int32(math32.Floor(p.X / w.cellSize)),
+		int32(math32.Floor(p.Y / w.cellSize)),
+		int32(math32.Floor(p.Z / w.cellSize)),
+	}
+}
+
+// sweep returns the earliest fraction of the specified move at which the bounding box
+// of the body hits the bounding box of a static body, with the normal of the hit face.
+func (w *World) sweep(b *Body, move *math32.Vector3) (float32, math32.Vector3, bool) {
+
+	box := b.BoundingBox()
+	swept := box
+	var end math32.Box3
+	end.Min.AddVectors(&box.Min, move)
+	end.Max.AddVectors(&box.Max, move)
+	swept.Union(&end)
+	center := b.Center()
+	min, max := w.cell(&swept.Min), w.cell(&swept.Max)
+
+	toi := float32(1)
+	var normal math32.Vector3
+	hit := false
+	for x := min[0]; x <= max[0]; x++ {
+		for y := min[1]; y <= max[1]; y++ {
+			for z := min[2]; z <= max[2]; z++ {
+				for _, i := range w.grid[[3]int32{x, y, z}] {
+					other := w.bodies[i]
+					if !other.Static() || other.trigger {
+						continue
+					}
+					// Sweeps the center against the static box expanded by the body half extents
+					target := other.BoundingBox()
+					target.Min.Sub(&b.halfSize)
+					target.Max.Add(&b.halfSize)
+					if t, n, ok := sweepPoint(&center, move, &target); ok && t < toi {
+						toi, normal, hit = t, n, true
+					}
+				}
+			}
+		}
+	}
+	return toi, normal, hit
+}
+
+// sweepPoint returns the fraction of the specified move at which the point enters
+// the box and the normal of the entered face. Points starting inside the box are ignored.
+func sweepPoint(p, move *math32.Vector3, box *math32.Box3) (float32, math32.Vector3, bool) {
+
+	var normal math32.Vector3
+	tEnter, tExit := float32(-math32.Infinity), float32(math32.Infinity)
+	axis := -1
+	for i := 0; i < 3; i++ {
+		pi, mi := p.Component(i), move.Component(i)
+		lo, hi := box.Min.Component(i), box.Max.Component(i)
+		if mi == 0 {
+			if pi <= lo || pi >= hi {
+				return 0, normal, false
+			}
+			continue
+		}
+		t1, t2 := (lo-pi)/mi, (hi-pi)/mi
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		if t1 > tEnter {
+			tEnter = t1
+			axis = i
+		}
+		tExit = math32.Min(tExit, t2)
+	}
+	if axis < 0 || tEnter < 0 || tEnter > 1 || tEnter >= tExit {
+		return 0, normal, false
+	}
+	if move.Component(axis) > 0 {
+		normal.SetComponent(axis, -1)
+	} else {
+		normal.SetComponent(axis, 1)
+	}
+	return tEnter, normal, true
+}
+
+// contact returns the contact between the two specified bodies if they collide.
+func contact(a, b *Body) (Collision, bool) {
+
+	if a.collider == SphereCollider && b.collider == BoxCollider {
+		c, ok := contact(b, a)
+		c.Body, c.Other = a, b
+		c.Normal.Negate()
+		return c, ok
+	}
+	c := Collision{Body: a, Other: b}
+	ca, cb := a.Center(), b.Center()
+	var d math32.Vector3
+	d.SubVectors(&cb, &ca)
+
+	switch {
+	case a.collider == SphereCollider && b.collider == SphereCollider:
+		dist := d.Length()
+		if dist >= a.radius+b.radius+contactSlop {
+			return c, false
+		}
+		c.Depth = math32.Max(a.radius+b.radius-dist, 0)
+		if dist > 0 {
+			c.Normal = *d.MultiplyScalar(1 / dist)
+		} else {
+			c.Normal.Set(0, 1, 0)
+		}
+		return c, true
+
+	case a.collider == BoxCollider && b.collider == SphereCollider:
+		box := a.BoundingBox()
+		var closest math32.Vector3
+		box.ClampPoint(&cb, &closest)
+		var diff math32.Vector3
+		diff.SubVectors(&cb, &closest)
+		dist := diff.Length()
+		if dist >= b.radius+contactSlop {
+			return c, false
+		}
+		if dist > 0 {
+			c.Depth = math32.Max(b.radius-dist, 0)
+			c.Normal = *diff.MultiplyScalar(1 / dist)
+			return c, true
+		}
+	}
+
+	// Boxes, or sphere center inside the box, separated along the axis of least penetration
+	c.Depth = math32.Infinity
+	for i := 0; i < 3; i++ {
+		di := d.Component(i)
+		overlap := a.halfSize.Component(i) + b.halfSize.Component(i) - math32.Abs(di)
+		if overlap <= -contactSlop {
+			return c, false
+		}
+		if overlap < c.Depth {
+			c.Depth = math32.Max(overlap, 0)
+			c.Normal.Zero()
+			if di < 0 {
+				c.Normal.SetComponent(i, -1)
+			} else {
+				c.Normal.SetComponent(i, 1)
+			}
+		}
+	}
+	return c, true
+}
+
+// resolve separates the colliding bodies in proportion to their inverse masses
+// and applies the impulse cancelling their approaching velocity.
+func resolve(c *Collision) {
+
+	a, b := c.Body, c.Other
+	invSum := a.invMass + b.invMass
+	if invSum == 0 {
+		return
+	}
+	correction := c.Normal
+	correction.MultiplyScalar(c.Depth / invSum)
+	a.translate(correction.Clone().MultiplyScalar(-a.invMass))
+	b.translate(correction.Clone().MultiplyScalar(b.invMass))
+
+	var rel math32.Vector3
+	rel.SubVectors(&b.velocity, &a.velocity)
+	vn := rel.Dot(&c.Normal)
+	if vn >= 0 {
+		return
+	}
+	e := math32.Max(a.restitution, b.restitution)
+	j := -(1 + e) * vn / invSum
+	impulse := c.Normal
+	impulse.MultiplyScalar(j)
+	a.velocity.Sub(impulse.Clone().MultiplyScalar(a.invMass))
+	b.velocity.Add(impulse.Clone().MultiplyScalar(b.invMass))
+}
@@ -0,0 +1,66 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package physics
+
+import (
+	"testing"
+
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// Test bodies falling onto a static ground and the collision events
+func TestWorldStep(t *testing.T) {
+
+	w := NewWorld()
+	ground := NewBoxBody(core.NewNode(), math32.Vector3{X: 10, Y: 0.05, Z: 10}, 0)
+	w.AddBody(ground)
+
+	sphereNode := core.NewNode()
+	sphereNode.SetPosition(0, 3, 0)
+	sphere := NewSphereBody(sphereNode, 0.5, 1)
+	w.AddBody(sphere)
+
+	// A fast box must not tunnel through the thin ground
+	boxNode := core.NewNode()
+	boxNode.SetPosition(3, 2, 0)
+	box := NewBoxBody(boxNode, math32.Vector3{X: 0.5, Y: 0.5, Z: 0.5}, 2)
+	box.SetVelocity(math32.Vector3{Y: -200})
+	w.AddBody(box)
+
+	hits := 0
+	sphereNode.Subscribe(OnCollision, func(evname string, ev interface{}) {
+		c := ev.(*Collision)
+		if c.Other != ground {
+			return
+		}
+		if c.Body != sphere || c.Normal.Y != -1 {
+			t.Errorf("unexpected collision %+v", *c)
+		}
+		hits++
+	})
+	for i := 0; i < 120; i++ {
+		w.Step(1.0 / 60)
+	}
+	if hits == 0 {
+		t.Error("expected collision events")
+	}
+	if y := sphereNode.Position().Y; math32.Abs(y-0.55) > 1e-3 {
+		t.Errorf("expected sphere resting at 0.55 got %v", y)
+	}
+	if y := boxNode.Position().Y; math32.Abs(y-0.55) > 1e-3 {
+		t.Errorf("expected box resting at 0.55 got %v", y)
+	}
+
+	// Dynamic bodies push each other apart
+	other := NewSphereBody(core.NewNode(), 0.5, 1)
+	other.Node().GetNode().SetPosition(0.2, 0.55, 0)
+	w.AddBody(other)
+	w.Step(1.0 / 60)
+	a, b := sphere.Center(), other.Center()
+	if d := a.DistanceTo(&b); d < 1-1e-3 {
+		t.Errorf("expected separated spheres got distance %v", d)
+	}
+}
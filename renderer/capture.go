@@ -0,0 +1,82 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"github.com/thommil/tge-g3n/camera"
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/graphic"
+)
+
+// FrameReport describes the rendering of a frame captured by CaptureFrame.
+// It can be serialized to JSON to be shared in bug reports.
+type FrameReport struct {
+	Programs  []string     `json:"programs"`  // Names of the programs in activation order, once per program change
+	Draws     []DrawReport `json:"draws"`     // Rendered graphic materials in submission order
+	Calls     []string     `json:"calls"`     // OpenGL state changes and draw calls in submission order
+	Rendered  int          `json:"rendered"`  // Number of graphics which passed the frustum culling
+	Culled    int          `json:"culled"`    // Number of graphics rejected by the frustum culling
+	Stats     Stats        `json:"stats"`     // Renderer statistics of the frame
+	Drawcalls uint64       `json:"drawcalls"` // Number of draw calls of the frame
+	Triangles uint64       `json:"triangles"` // Number of triangles drawn, except by indirect draw calls
+	Unisets   uint64       `json:"unisets"`   // Number of uniform sets of the frame
+}
+
+// DrawReport describes the rendering of a graphic material in a FrameReport.
+type DrawReport struct {
+	Graphic   string `json:"graphic"`   // Name of the graphic node
+	Program   string `json:"program"`   // Name of the program
+	Pass      string `json:"pass"`      // Render pass: "opaque", "oit" or "transparent"
+	Drawcalls uint64 `json:"drawcalls"` // Number of draw calls
+	Triangles uint64 `json:"triangles"` // Number of triangles drawn, except by indirect draw calls
+}
+
+// CaptureFrame renders the previously set Scene using the specified camera as Render
+// does and returns the report of this frame, which lists the programs used, the draw
+// calls of each graphic material with their triangle counts, the OpenGL state changes
+// and the culling statistics. It is a debugging aid to understand why a frame is slow
+// or why an object is not drawn without an external OpenGL debugger. The capture slows
+// the frame down and should not be left enabled.
+func (r *Renderer) CaptureFrame(icam camera.ICamera) (FrameReport, error) {
+
+	var report FrameReport
+	var before, after gls.Stats
+	r.capture = &report
+	r.shaman.onUse = func(name string) {
+		report.Programs = append(report.Programs, name)
+	}
+	r.gs.Stats(&before)
+	r.gs.BeginCapture()
+	_, err := r.Render(icam)
+	calls := r.gs.EndCapture()
+	r.gs.Stats(&after)
+	r.shaman.onUse = nil
+	r.capture = nil
+
+	report.Calls = make([]string, len(calls))
+	for i, call := range calls {
+		report.Calls[i] = call.String()
+	}
+	report.Stats = r.stats
+	report.Drawcalls = after.Drawcalls - before.Drawcalls
+	report.Triangles = after.Triangles - before.Triangles
+	report.Unisets = after.Unisets - before.Unisets
+	return report, err
+}
+
+// addDraw appends the report of the specified graphic material rendered with the
+// specified program in the specified pass, whose GLS statistics before rendering are before.
+func (fr *FrameReport) addDraw(gs *gls.GLS, grmat *graphic.GraphicMaterial, prog, pass string, before *gls.Stats) {
+
+	var after gls.Stats
+	gs.Stats(&after)
+	fr.Draws = append(fr.Draws, DrawReport{
+		Graphic:   grmat.IGraphic().GetNode().Name(),
+		Program:   prog,
+		Pass:      pass,
+		Drawcalls: after.Drawcalls - before.Drawcalls,
+		Triangles: after.Triangles - before.Triangles,
+	})
+}
@@ -0,0 +1,571 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"math"
+	"time"
+
+	"github.com/thommil/tge-g3n/camera"
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/geometry"
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/graphic"
+	"github.com/thommil/tge-g3n/material"
+	"github.com/thommil/tge-g3n/texture"
+)
+
+// PostEffect is a full screen post processing pass applied by the renderer to the
+// rendered scene. Its fragment shader includes the "post" chunk which declares the
+// previous pass color (PostColor), the scene depth (PostDepth), the texel size and
+// depth linearization factors (PostInfo) and the effect parameters (PostEffect) uniforms.
+// The vertex shader of the program must be "post_vertex".
+type PostEffect struct {
+	material.Material                // Embedded material with the effect shader
+	Params            [8]float32     // Effect parameters transferred to the PostEffect uniform
+	enabled           bool           // Whether the effect is applied
+	setup             func(*gls.GLS) // Optional setup of additional uniforms and textures
+	uniParams         gls.Uniform    // Parameters uniform location cache
+	quad              *postQuad      // Full screen graphic rendered with this effect
+}
+
+// postQuad is the full screen graphic rendered by the post processing effects.
+// Its vertices are already in clip space, so it has no matrices to transfer.
+type postQuad struct {
+	graphic.Graphic
+}
+
+// Built-in post processing effects in the order they are applied
+const (
+	postScreenReflections = iota
+	postDepthOfField
+	postToneMapping
+	postChromaticAberration
+	postVignette
+	postColorGrading
+	postEffectCount
+)
+
+// composer applies the post processing effects to the rendered scene.
+// The scene is rendered to an offscreen target with a depth texture and each
+// effect renders the previous pass color to the next target, except the last one
+// which renders to the framebuffer which was bound when rendering started.
+type composer struct {
+	effects  [postEffectCount]*PostEffect // Built-in effects
+	custom   []*PostEffect                // Application effects applied after the built-in ones
+	passes   []*PostEffect                // Enabled effects of the current render
+	scene    *texture.RenderTarget        // Scene color and depth target
+	targets  [2]*texture.RenderTarget     // Intermediate passes targets
+	specs    ShaderSpecs                  // Effects shader specs
+	uniColor gls.Uniform                  // Previous pass color sampler uniform location cache
+	uniDepth gls.Uniform                  // Scene depth sampler uniform location cache
+	uniInfo  gls.Uniform                  // Texel size and depth factors uniform location cache
+	lut      *texture.Texture3D           // Color grading lookup table
+	normals  *texture.RenderTarget        // View normals and reflectivity target for reflections
+	nspecs   ShaderSpecs                  // Normals pass shader specs
+	uniNorm  gls.Uniform                  // Normals sampler uniform location cache
+	uniSSR   gls.Uniform                  // Normals pass texel size and reflectivity uniform location cache
+	uniLum   gls.Uniform                  // Adapted luminance sampler uniform location cache
+	uniPrev  gls.Uniform                  // Previous adapted luminance sampler uniform location cache
+	lum      *texture.RenderTarget        // Log luminance target with mipmaps for the auto exposure
+	adapted  [2]*texture.RenderTarget     // Current and previous adapted luminance targets
+	adaptIdx int                          // Index of the current adapted luminance target
+	lumPass  *PostEffect                  // Log luminance pass
+	adapt    *PostEffect                  // Luminance adaptation pass
+	speed    float32                      // Auto exposure adaptation speed
+	lastTime time.Time                    // Time of the last luminance adaptation
+	samples  int                          // Number of samples of the multisampled scene target or 0
+}
+
+// NewPostEffect creates and returns a pointer to a new enabled post processing
+// effect using the specified shader program.
+func NewPostEffect(shader string) *PostEffect {
+
+	e := new(PostEffect)
+	e.Material.Init()
+	e.SetShader(shader)
+	e.SetShaderUnique(true)
+	e.SetUseLights(material.UseLightNone)
+	e.SetSide(material.SideDouble)
+	e.SetDepthTest(false)
+	e.SetDepthMask(false)
+	e.SetBlending(material.BlendingNone)
+	e.uniParams.Init("PostEffect")
+	e.enabled = true
+
+	e.quad = new(postQuad)
+	e.quad.Graphic.Init(geometry.NewPlane(2, 2, 1, 1), gls.TRIANGLES)
+	e.quad.AddMaterial(e.quad, e, 0, 0)
+	return e
+}
+
+// SetEnabled sets whether this effect is applied.
+func (e *PostEffect) SetEnabled(state bool) {
+
+	e.enabled = state
+}
+
+// Enabled returns whether this effect is applied.
+func (e *PostEffect) Enabled() bool {
+
+	return e.enabled
+}
+
+// RenderSetup is called by the renderer before drawing the effect.
+func (e *PostEffect) RenderSetup(gs *gls.GLS) {
+
+	e.Material.RenderSetup(gs)
+	if e.setup != nil {
+		e.setup(gs)
+	}
+	gs.Uniform4fv(e.uniParams.Location(gs), 2, e.Params[:])
+}
+
+// RenderSetup satisfies the IGraphic interface and does nothing.
+func (q *postQuad) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
+}
+
+// AddPostEffect adds the specified post processing effect, applied after the
+// built-in effects and the previously added ones. The texture units 0 and 1 are
+// used by the PostColor and PostDepth samplers.
+func (r *Renderer) AddPostEffect(e *PostEffect) {
+
+	r.composer.custom = append(r.composer.custom, e)
+}
+
+// RemovePostEffect removes the specified post processing effect
+// and returns true if it was found.
+func (r *Renderer) RemovePostEffect(e *PostEffect) bool {
+
+	for i, ce := range r.composer.custom {
+		if ce == e {
+			copy(r.composer.custom[i:], r.composer.custom[i+1:])
+			r.composer.custom[len(r.composer.custom)-1] = nil
+			r.composer.custom = r.composer.custom[:len(r.composer.custom)-1]
+			return true
+		}
+	}
+	return false
+}
+
+// SetSSR sets the screen space reflections post processing effect, which marches
+// reflected rays through the depth buffer for the opaque materials with screen
+// reflections (see material.Material.SetScreenReflections). The rays advance up to
+// maxDistance in the specified number of steps (at most 256) and hit the scene when
+// they go behind it by less than thickness. Where a ray leaves the screen without
+// a hit, the material keeps its own environment map reflection.
+// A number of steps of 0 disables the effect. It requires a perspective camera.
+func (r *Renderer) SetSSR(maxDistance, thickness float32, steps int) {
+
+	if steps > 256 {
+		steps = 256
+	}
+	c := &r.composer
+	e := c.effect(postScreenReflections, "post_ssr")
+	e.Params[0] = maxDistance
+	e.Params[1] = thickness
+	e.Params[2] = float32(steps)
+	e.setup = func(gs *gls.GLS) {
+		// Projection scale factors to reconstruct the view positions
+		e.Params[4] = r.rinfo.ProjMatrix[0]
+		e.Params[5] = r.rinfo.ProjMatrix[5]
+		gs.ActiveTexture(gls.TEXTURE2)
+		gs.BindTexture(gls.TEXTURE_2D, c.normals.Texture().TexName())
+		gs.Uniform1i(c.uniNorm.Location(gs), 2)
+	}
+	e.SetEnabled(steps > 0)
+}
+
+// SetExposure sets the exposure of the scene colors in the tone mapping post processing
+// effect, which maps the high dynamic range colors of the scene to the screen with the
+// ACES filmic curve. Tone mapping renders the scene into half float targets, which
+// require the EXT_color_buffer_float extension with OpenGL ES.
+// An exposure of 0 disables the tone mapping and the auto exposure.
+func (r *Renderer) SetExposure(exposure float32) {
+
+	e := r.composer.effect(postToneMapping, "post_tonemap")
+	e.Params[0] = exposure
+	e.SetEnabled(exposure > 0)
+}
+
+// SetAutoExposure sets the auto exposure of the tone mapping, which scales the exposure
+// so the average scene luminance, clamped between minLum and maxLum, maps to middle gray.
+// The luminance adapts to the scene with the specified speed, where a speed of 1 covers
+// about 63% of a change in one second. An adaptSpeed of 0 disables the auto exposure.
+// Enabling it also enables the tone mapping with an exposure of 1 if it was disabled.
+func (r *Renderer) SetAutoExposure(minLum, maxLum, adaptSpeed float32) {
+
+	c := &r.composer
+	e := c.effect(postToneMapping, "post_tonemap")
+	if !e.enabled && adaptSpeed > 0 {
+		r.SetExposure(1)
+	}
+	c.speed = adaptSpeed
+	c.lastTime = time.Time{}
+	if adaptSpeed > 0 {
+		e.Params[1] = 1
+	} else {
+		e.Params[1] = 0
+	}
+	e.Params[2] = minLum
+	e.Params[3] = maxLum
+	e.setup = func(gs *gls.GLS) {
+		if c.speed > 0 {
+			gs.ActiveTexture(gls.TEXTURE2)
+			gs.BindTexture(gls.TEXTURE_2D, c.adapted[c.adaptIdx].Texture().TexName())
+			gs.Uniform1i(c.uniLum.Location(gs), 2)
+		}
+	}
+}
+
+// SetDepthOfField sets the depth of field post processing effect, which blurs the
+// scene according to the distance to the camera. The scene within focusRange/2 of the
+// focusDistance is sharp and the blur radius increases up to bokehScale pixels over
+// another focusRange. A bokehScale of 0 disables the effect.
+// It requires a perspective camera.
+func (r *Renderer) SetDepthOfField(focusDistance, focusRange, bokehScale float32) {
+
+	e := r.composer.effect(postDepthOfField, "post_dof")
+	e.Params[0] = focusDistance
+	e.Params[1] = focusRange
+	e.Params[2] = bokehScale
+	e.SetEnabled(bokehScale > 0)
+}
+
+// SetMSAASamples sets the number of samples of the multisample anti-aliasing of the scene
+// rendered offscreen for the post processing effects, clamped to GLS.MaxSamples, which is 0
+// without GLS.GL3Supported, or 0 to disable it. The multisampled scene is resolved before the effects are applied.
+// The anti-aliasing of the default framebuffer is chosen when the context is created
+// and its actual number of samples is returned by GLS.GetInteger(gls.SAMPLES).
+func (r *Renderer) SetMSAASamples(samples int) {
+
+	if samples < 0 {
+		panic("SetMSAASamples: invalid number of samples")
+	}
+	if max := r.gs.MaxSamples(); samples > max {
+		samples = max
+	}
+	r.composer.samples = samples
+}
+
+// MSAASamples returns the number of samples of the multisample anti-aliasing of the offscreen scene.
+func (r *Renderer) MSAASamples() int {
+
+	return r.composer.samples
+}
+
+// SetVignette sets the vignette post processing effect, which darkens the screen
+// edges by up to intensity (0 to 1) at the corners. The smoothness (0 to 1) is the
+// fraction of the distance from the corners to the center over which the darkening
+// fades out. An intensity of 0 disables the effect.
+func (r *Renderer) SetVignette(intensity, smoothness float32) {
+
+	e := r.composer.effect(postVignette, "post_vignette")
+	e.Params[0] = intensity
+	e.Params[1] = smoothness
+	e.SetEnabled(intensity > 0)
+}
+
+// SetChromaticAberration sets the chromatic aberration post processing effect, which
+// offsets the red and blue channels radially by up to strength pixels at the screen
+// edges. A strength of 0 disables the effect.
+func (r *Renderer) SetChromaticAberration(strength float32) {
+
+	e := r.composer.effect(postChromaticAberration, "post_chroma")
+	e.Params[0] = strength
+	e.SetEnabled(strength > 0)
+}
+
+// SetColorGrading sets the 3D lookup table used by the color grading post processing
+// effect to remap the final colors, or disables the effect if nil.
+// The sampler uniform name of the texture is set to "PostLUT".
+func (r *Renderer) SetColorGrading(lut *texture.Texture3D) {
+
+	c := &r.composer
+	if c.lut != nil {
+		c.lut.Dispose()
+	}
+	c.lut = lut
+	if lut == nil {
+		if c.effects[postColorGrading] != nil {
+			c.effects[postColorGrading].SetEnabled(false)
+		}
+		return
+	}
+	lut.Incref()
+	lut.SetUniformName("PostLUT")
+	e := c.effect(postColorGrading, "post_lut")
+	e.Params[0] = float32(lut.Width())
+	e.setup = func(gs *gls.GLS) { c.lut.RenderSetup(gs, 2) }
+	e.SetEnabled(true)
+}
+
+// init initializes the composer uniforms.
+func (c *composer) init() {
+
+	c.uniColor.Init("PostColor")
+	c.uniDepth.Init("PostDepth")
+	c.uniInfo.Init("PostInfo")
+	c.uniNorm.Init("PostNormal")
+	c.uniSSR.Init("SSRInfo")
+	c.uniLum.Init("PostLuminance")
+	c.uniPrev.Init("PostAdapted")
+	c.nspecs.Name = "ssr_normal"
+	c.nspecs.ShaderUnique = true
+}
+
+// effect returns the specified built-in effect, creating it with the specified shader if necessary.
+func (c *composer) effect(idx int, shader string) *PostEffect {
+
+	if c.effects[idx] == nil {
+		c.effects[idx] = NewPostEffect(shader)
+	}
+	return c.effects[idx]
+}
+
+// active updates the list of enabled effects and returns if there are any.
+func (c *composer) active() bool {
+
+	c.passes = c.passes[0:0]
+	for _, e := range c.effects {
+		if e != nil && e.enabled {
+			c.passes = append(c.passes, e)
+		}
+	}
+	for _, e := range c.custom {
+		if e.enabled {
+			c.passes = append(c.passes, e)
+		}
+	}
+	return len(c.passes) > 0
+}
+
+// hdr returns whether the scene is rendered with high dynamic range colors.
+func (c *composer) hdr() bool {
+
+	e := c.effects[postToneMapping]
+	return e != nil && e.enabled
+}
+
+// resize creates the render targets if they don't exist or their size, dynamic
+// range or number of samples are different from the specified ones.
+func (c *composer) resize(width, height int, hdr bool) {
+
+	if c.scene != nil && c.scene.Width() == width && c.scene.Height() == height && c.scene.HDR() == hdr &&
+		c.scene.Samples() == c.samples {
+		return
+	}
+	c.dispose()
+	c.scene = texture.NewRenderTarget(width, height)
+	c.scene.SetDepthTexture(true)
+	c.scene.SetHDR(hdr)
+	c.scene.SetSamples(c.samples)
+	for i := range c.targets {
+		c.targets[i] = texture.NewRenderTarget(width, height)
+		c.targets[i].SetHDR(hdr)
+	}
+}
+
+// dispose releases the render targets.
+func (c *composer) dispose() {
+
+	if c.scene != nil {
+		c.scene.Dispose()
+		c.scene = nil
+	}
+	for i, rt := range c.targets {
+		if rt != nil {
+			rt.Dispose()
+			c.targets[i] = nil
+		}
+	}
+	if c.normals != nil {
+		c.normals.Dispose()
+		c.normals = nil
+	}
+}
+
+// render renders the specified scene using the specified camera
+// and applies the enabled effects.
+func (c *composer) render(r *Renderer, iscene core.INode, icam camera.ICamera) error {
+
+	gs := r.gs
+	_, _, width, height := gs.GetViewport()
+	c.resize(int(width), int(height), c.hdr())
+
+	// Renders the scene into the scene target
+	err := c.scene.Bind(gs)
+	if err != nil {
+		return err
+	}
+	gs.Clear(gls.DEPTH_BUFFER_BIT | gls.STENCIL_BUFFER_BIT | gls.COLOR_BUFFER_BIT)
+	err = r.renderScene(iscene, icam)
+	c.scene.Unbind()
+	if err != nil {
+		return err
+	}
+	if c.effects[postScreenReflections] != nil && c.effects[postScreenReflections].enabled {
+		err = c.renderNormals(r, float32(width), float32(height))
+		if err != nil {
+			return err
+		}
+	}
+	if c.hdr() && c.speed > 0 {
+		err = c.renderLuminance(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Applies the effects, the last one to the current framebuffer
+	proj := &r.rinfo.ProjMatrix
+	input := c.scene.Texture()
+	for i, e := range c.passes {
+		var target *texture.RenderTarget
+		if i < len(c.passes)-1 {
+			target = c.targets[i%2]
+			err = target.Bind(gs)
+			if err != nil {
+				return err
+			}
+		}
+		err = c.renderPass(r, e, input, float32(width), float32(height), proj[10], proj[14])
+		if target != nil {
+			target.Unbind()
+			input = target.Texture()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	r.rendered = true
+	return nil
+}
+
+// renderNormals renders the view normals and the reflectivity of the opaque
+// graphic materials with screen reflections of the last rendered scene.
+func (c *composer) renderNormals(r *Renderer, width, height float32) error {
+
+	gs := r.gs
+	if c.normals == nil {
+		c.normals = texture.NewRenderTarget(int(width), int(height))
+	}
+	err := c.normals.Bind(gs)
+	if err != nil {
+		return err
+	}
+	defer c.normals.Unbind()
+
+	// Surfaces without reflections have a zero reflectivity
+	cr, cg, cb, ca := gs.GetClearColor()
+	gs.ClearColor(0, 0, 0, 0)
+	gs.Clear(gls.DEPTH_BUFFER_BIT | gls.COLOR_BUFFER_BIT)
+	gs.ClearColor(cr, cg, cb, ca)
+
+	_, err = r.shaman.SetProgram(&c.nspecs)
+	if err != nil {
+		return err
+	}
+	gs.Disable(gls.BLEND)
+	gs.Enable(gls.DEPTH_TEST)
+	gs.DepthFunc(gls.LEQUAL)
+	gs.DepthMask(true)
+	gs.SetSideView(gls.DoubleSide)
+	gs.ActiveTexture(gls.TEXTURE1)
+	gs.BindTexture(gls.TEXTURE_2D, c.scene.DepthTexture().TexName())
+	gs.Uniform1i(c.uniDepth.Location(gs), 1)
+	for _, grmat := range r.grmatsOpaque {
+		refl := grmat.IMaterial().GetMaterial().ScreenReflections()
+		if refl <= 0 {
+			continue
+		}
+		gs.Uniform4f(c.uniSSR.Location(gs), 1/width, 1/height, refl, 0)
+		grmat.Draw(gs, &r.rinfo)
+	}
+	return nil
+}
+
+// renderLuminance renders the log luminance of the scene, reduces it to its
+// average with mipmaps and adapts the auto exposure luminance to it.
+func (c *composer) renderLuminance(r *Renderer) error {
+
+	gs := r.gs
+	if c.lum == nil {
+		const size = 256
+		c.lum = texture.NewRenderTarget(size, size)
+		c.lum.SetHDR(true)
+		c.lum.Texture().SetMinFilter(gls.LINEAR_MIPMAP_LINEAR)
+		for i := range c.adapted {
+			c.adapted[i] = texture.NewRenderTarget(1, 1)
+			c.adapted[i].SetHDR(true)
+		}
+		c.lumPass = NewPostEffect("post_luminance")
+		c.adapt = NewPostEffect("post_adapt")
+		c.adapt.setup = func(gs *gls.GLS) {
+			gs.ActiveTexture(gls.TEXTURE2)
+			gs.BindTexture(gls.TEXTURE_2D, c.adapted[1-c.adaptIdx].Texture().TexName())
+			gs.Uniform1i(c.uniPrev.Location(gs), 2)
+		}
+	}
+
+	// Renders the log luminance and generates its mipmaps
+	err := c.lum.Bind(gs)
+	if err != nil {
+		return err
+	}
+	err = c.renderPass(r, c.lumPass, c.scene.Texture(), 1, 1, 0, 0)
+	c.lum.Unbind()
+	if err != nil {
+		return err
+	}
+	gs.ActiveTexture(gls.TEXTURE0)
+	gs.BindTexture(gls.TEXTURE_2D, c.lum.Texture().TexName())
+	gs.GenerateMipmap(gls.TEXTURE_2D)
+
+	// Adapts the luminance for the time elapsed since the last frame
+	now := time.Now()
+	rate := float32(1)
+	if !c.lastTime.IsZero() {
+		dt := now.Sub(c.lastTime).Seconds()
+		rate = 1 - float32(math.Exp(-dt*float64(c.speed)))
+	}
+	c.lastTime = now
+	c.adapt.Params[0] = rate
+	c.adaptIdx = 1 - c.adaptIdx
+	target := c.adapted[c.adaptIdx]
+	err = target.Bind(gs)
+	if err != nil {
+		return err
+	}
+	err = c.renderPass(r, c.adapt, c.lum.Texture(), 1, 1, 0, 0)
+	target.Unbind()
+	return err
+}
+
+// renderPass renders the specified effect with the specified previous pass color,
+// viewport size and depth linearization factors.
+func (c *composer) renderPass(r *Renderer, e *PostEffect, input *texture.Texture2D, width, height, p10, p14 float32) error {
+
+	gs := r.gs
+	c.specs.Name = e.Shader()
+	c.specs.ShaderUnique = true
+	_, err := r.shaman.SetProgram(&c.specs)
+	if err != nil {
+		return err
+	}
+
+	// Transfers the inputs uniforms
+	gs.ActiveTexture(gls.TEXTURE0)
+	gs.BindTexture(gls.TEXTURE_2D, input.TexName())
+	gs.Uniform1i(c.uniColor.Location(gs), 0)
+	gs.ActiveTexture(gls.TEXTURE1)
+	gs.BindTexture(gls.TEXTURE_2D, c.scene.DepthTexture().TexName())
+	gs.Uniform1i(c.uniDepth.Location(gs), 1)
+	gs.Uniform4f(c.uniInfo.Location(gs), 1/width, 1/height, p10, p14)
+
+	grmats := e.quad.Materials()
+	grmats[0].Render(gs, &r.rinfo)
+	return nil
+}
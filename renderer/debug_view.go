@@ -0,0 +1,126 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/graphic"
+	"github.com/thommil/tge-g3n/material"
+)
+
+// DebugView is a visualization of the graphics attributes which
+// replaces the materials of all the graphics of the scene.
+type DebugView int
+
+// The debug views
+const (
+	ViewNone      DebugView = iota // Graphics are rendered with their materials
+	ViewNormals                    // World normals as RGB colors
+	ViewUVs                        // Texture coordinates as RG colors, repeated in the [0,1] range
+	ViewOverdraw                   // Number of fragments drawn per pixel, with additive blending and without depth test
+	ViewDepth                      // Linear camera depth from the near plane (black) to the far plane (white)
+	ViewWireframe                  // Triangles edges in white
+)
+
+// debugView renders the graphics with the debug program of the selected view.
+type debugView struct {
+	view    DebugView   // Selected view
+	specs   ShaderSpecs // Debug program shader specs
+	uniInfo gls.Uniform // Camera near and far uniform location cache
+	info    [4]float32  // Camera near and far planes distances
+}
+
+// init initializes the debug view uniforms.
+func (dv *debugView) init() {
+
+	dv.specs.Name = "debug"
+	dv.uniInfo.Init("DebugInfo")
+}
+
+// set selects the specified view.
+func (dv *debugView) set(view DebugView) {
+
+	var define string
+	switch view {
+	case ViewNone, ViewWireframe:
+	case ViewNormals:
+		define = "DEBUG_NORMALS"
+	case ViewUVs:
+		define = "DEBUG_UVS"
+	case ViewOverdraw:
+		define = "DEBUG_OVERDRAW"
+	case ViewDepth:
+		define = "DEBUG_DEPTH"
+	default:
+		panic("Renderer.SetDebugView: invalid view")
+	}
+	dv.view = view
+	dv.specs.Defines = gls.ShaderDefines{}
+	if define != "" {
+		dv.specs.Defines.Set(define, "")
+	}
+}
+
+// draw draws the specified graphic material with the debug program.
+func (dv *debugView) draw(r *Renderer, grmat *graphic.GraphicMaterial) error {
+
+	gs := r.gs
+	_, err := r.shaman.SetProgram(&dv.specs)
+	if err != nil {
+		return err
+	}
+
+	// Near and far planes of the perspective or orthographic camera projection
+	proj := &r.rinfo.ProjMatrix
+	if proj[11] != 0 {
+		dv.info[0] = proj[14] / (proj[10] - 1)
+		dv.info[1] = proj[14] / (proj[10] + 1)
+	} else {
+		dv.info[0] = (proj[14] + 1) / proj[10]
+		dv.info[1] = (proj[14] - 1) / proj[10]
+	}
+	gs.Uniform4fv(dv.uniInfo.Location(gs), 1, dv.info[:])
+
+	if dv.view == ViewOverdraw {
+		gs.Disable(gls.DEPTH_TEST)
+		gs.DepthMask(false)
+		gs.BlendAdditive()
+	} else {
+		gs.Disable(gls.BLEND)
+		gs.Enable(gls.DEPTH_TEST)
+		gs.DepthFunc(gls.LEQUAL)
+		gs.DepthMask(true)
+	}
+	switch grmat.IMaterial().GetMaterial().Side() {
+	case material.SideFront:
+		gs.SetSideView(gls.FrontSide)
+	case material.SideBack:
+		gs.SetSideView(gls.BackSide)
+	case material.SideDouble:
+		gs.SetSideView(gls.DoubleSide)
+	}
+	if dv.view == ViewWireframe {
+		gs.PolygonMode(gls.FRONT_AND_BACK, gls.LINE)
+		grmat.Draw(gs, &r.rinfo)
+		gs.PolygonMode(gls.FRONT_AND_BACK, gls.FILL)
+		return nil
+	}
+	grmat.Draw(gs, &r.rinfo)
+	return nil
+}
+
+// SetDebugView sets the debug view replacing the materials of all the graphics
+// to visualize their normals, texture coordinates, overdraw, depth or wireframe.
+// ViewNone, the default, renders the graphics with their materials.
+func (r *Renderer) SetDebugView(view DebugView) {
+
+	r.debug.set(view)
+}
+
+// DebugView returns the current debug view.
+func (r *Renderer) DebugView() DebugView {
+
+	return r.debug.view
+}
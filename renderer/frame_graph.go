@@ -0,0 +1,337 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"fmt"
+
+	"github.com/thommil/tge-g3n/camera"
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/texture"
+)
+
+// FrameScreen is the name of the output of the frame graph passes
+// which render into the current framebuffer.
+const FrameScreen = "screen"
+
+// FrameGraph describes the passes of a frame with the render targets each one reads
+// and writes. The renderer executes the passes in an order which satisfies their
+// dependencies, skips the passes whose outputs are not used, and allocates the
+// transient render targets, sharing one render target between the transient
+// targets of the same specification which are not used at the same time.
+type FrameGraph struct {
+	passes   []*framePass            // Passes in declaration order
+	targets  map[string]*frameTarget // Targets by name
+	order    []*framePass            // Passes in execution order
+	pool     []*framePooled          // Render targets allocated for the transient targets
+	compiled bool                    // Whether the execution order and the allocations are up to date
+}
+
+// FramePassFunc is the function of a frame graph pass. Its first output
+// render target is bound when it is called unless it is FrameScreen.
+type FramePassFunc func(fc *FrameContext) error
+
+// FrameContext is the context of the execution of a frame graph pass.
+type FrameContext struct {
+	Renderer *Renderer      // Renderer executing the frame graph
+	Scene    core.INode     // Scene set in the renderer or nil
+	Camera   camera.ICamera // Camera of the render
+	graph    *FrameGraph
+}
+
+// framePass is a pass of a frame graph.
+type framePass struct {
+	name    string        // Pass name
+	inputs  []string      // Names of the read targets
+	outputs []string      // Names of the written targets
+	exec    FramePassFunc // Pass function
+	writers []*framePass  // Passes writing the inputs of this pass
+}
+
+// frameTarget is a render target of a frame graph.
+type frameTarget struct {
+	spec     texture.RenderTargetSpec // Specification of a transient target
+	rt       *texture.RenderTarget    // Imported or allocated render target
+	imported bool                     // Whether the target is imported
+	writer   *framePass               // Pass writing the target
+	first    int                      // Execution index of the first pass using the target
+	last     int                      // Execution index of the last pass using the target
+}
+
+// framePooled is a render target allocated for transient targets.
+type framePooled struct {
+	spec  texture.RenderTargetSpec // Specification of the render target
+	rt    *texture.RenderTarget    // Render target
+	until int                      // Execution index of the last pass using the render target
+}
+
+// NewFrameGraph creates and returns a pointer to a new empty frame graph.
+func NewFrameGraph() *FrameGraph {
+
+	fg := new(FrameGraph)
+	fg.targets = make(map[string]*frameTarget)
+	return fg
+}
+
+// AddTarget adds a transient render target with the specified name and specification,
+// which is allocated by the frame graph and only valid during its execution.
+func (fg *FrameGraph) AddTarget(name string, spec texture.RenderTargetSpec) {
+
+	fg.addTarget(name, &frameTarget{spec: spec})
+}
+
+// ImportTarget adds the specified render target, which keeps its contents between
+// frames and can be read by the passes without being written in the frame graph.
+// The passes writing an imported target are always executed.
+func (fg *FrameGraph) ImportTarget(name string, rt *texture.RenderTarget) {
+
+	fg.addTarget(name, &frameTarget{rt: rt, imported: true})
+}
+
+// addTarget adds the specified target with the specified name.
+func (fg *FrameGraph) addTarget(name string, t *frameTarget) {
+
+	if _, ok := fg.targets[name]; ok || name == FrameScreen {
+		panic(fmt.Sprintf("FrameGraph.AddTarget: invalid target name %q", name))
+	}
+	fg.targets[name] = t
+	fg.compiled = false
+}
+
+// AddPass adds a pass with the specified name, which reads the specified input
+// targets and writes the specified output targets, including FrameScreen.
+func (fg *FrameGraph) AddPass(name string, inputs, outputs []string, exec FramePassFunc) {
+
+	if len(outputs) == 0 {
+		panic(fmt.Sprintf("FrameGraph.AddPass: pass %q without output", name))
+	}
+	fg.passes = append(fg.passes, &framePass{name: name, inputs: inputs, outputs: outputs, exec: exec})
+	fg.compiled = false
+}
+
+// Compile computes the execution order of the passes and allocates the transient
+// render targets. It is called by the renderer when the frame graph changed and
+// returns an error if a target is not declared, is written by several passes,
+// is read without being written or if the passes depend on each other.
+func (fg *FrameGraph) Compile() error {
+
+	// Links the targets to their writers and the passes to the writers of their inputs
+	for _, t := range fg.targets {
+		t.writer = nil
+	}
+	for _, p := range fg.passes {
+		for _, out := range p.outputs {
+			if out == FrameScreen {
+				continue
+			}
+			t, ok := fg.targets[out]
+			if !ok {
+				return fmt.Errorf("frame graph pass %q writes undeclared target %q", p.name, out)
+			}
+			if t.writer != nil {
+				return fmt.Errorf("frame graph target %q written by passes %q and %q", out, t.writer.name, p.name)
+			}
+			t.writer = p
+		}
+	}
+	for _, p := range fg.passes {
+		p.writers = p.writers[:0]
+		for _, in := range p.inputs {
+			t, ok := fg.targets[in]
+			if !ok {
+				return fmt.Errorf("frame graph pass %q reads undeclared target %q", p.name, in)
+			}
+			if t.writer != nil {
+				p.writers = append(p.writers, t.writer)
+			} else if !t.imported {
+				return fmt.Errorf("frame graph pass %q reads target %q which no pass writes", p.name, in)
+			}
+		}
+	}
+
+	// Orders the passes contributing to the screen or to an imported target
+	// after the passes they depend on, in declaration order otherwise
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[*framePass]int)
+	fg.order = fg.order[:0]
+	var visit func(p *framePass) error
+	visit = func(p *framePass) error {
+		switch state[p] {
+		case visiting:
+			return fmt.Errorf("frame graph pass %q depends on itself", p.name)
+		case visited:
+			return nil
+		}
+		state[p] = visiting
+		for _, w := range p.writers {
+			if err := visit(w); err != nil {
+				return err
+			}
+		}
+		state[p] = visited
+		fg.order = append(fg.order, p)
+		return nil
+	}
+	for _, p := range fg.passes {
+		for _, out := range p.outputs {
+			if out == FrameScreen || fg.targets[out].imported {
+				if err := visit(p); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+
+	// Computes the lifetimes of the transient targets
+	for _, t := range fg.targets {
+		t.first, t.last = -1, -1
+	}
+	for i, p := range fg.order {
+		for _, names := range [][]string{p.outputs, p.inputs} {
+			for _, name := range names {
+				if t := fg.targets[name]; t != nil && !t.imported {
+					if t.first < 0 {
+						t.first = i
+					}
+					t.last = i
+				}
+			}
+		}
+	}
+
+	// Allocates the transient targets in order of first use, sharing the
+	// render targets of the same specification not used anymore
+	fg.dispose()
+	for i, p := range fg.order {
+		for _, out := range p.outputs {
+			t := fg.targets[out]
+			if t == nil || t.imported || t.first != i {
+				continue
+			}
+			var pooled *framePooled
+			for _, fp := range fg.pool {
+				if fp.until < t.first && specEquals(&fp.spec, &t.spec) {
+					pooled = fp
+					break
+				}
+			}
+			if pooled == nil {
+				pooled = &framePooled{spec: t.spec, rt: texture.NewRenderTargetSpec(t.spec)}
+				fg.pool = append(fg.pool, pooled)
+			}
+			pooled.until = t.last
+			t.rt = pooled.rt
+		}
+	}
+	fg.compiled = true
+	return nil
+}
+
+// Order returns the names of the executed passes in execution order.
+// The frame graph must be compiled.
+func (fg *FrameGraph) Order() []string {
+
+	names := make([]string, len(fg.order))
+	for i, p := range fg.order {
+		names[i] = p.name
+	}
+	return names
+}
+
+// Dispose releases the render targets allocated by this frame graph.
+func (fg *FrameGraph) Dispose() {
+
+	fg.dispose()
+	fg.compiled = false
+}
+
+// dispose releases the allocated render targets.
+func (fg *FrameGraph) dispose() {
+
+	for _, fp := range fg.pool {
+		fp.rt.Dispose()
+	}
+	fg.pool = fg.pool[:0]
+	for _, t := range fg.targets {
+		if !t.imported {
+			t.rt = nil
+		}
+	}
+}
+
+// execute executes the passes of this frame graph with the specified renderer and camera.
+func (fg *FrameGraph) execute(r *Renderer, icam camera.ICamera) error {
+
+	if !fg.compiled {
+		err := fg.Compile()
+		if err != nil {
+			return err
+		}
+	}
+	fc := FrameContext{Renderer: r, Scene: r.scene, Camera: icam, graph: fg}
+	for _, p := range fg.order {
+		t := fg.targets[p.outputs[0]]
+		if t == nil {
+			if err := p.exec(&fc); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := t.rt.Bind(r.gs); err != nil {
+			return err
+		}
+		err := p.exec(&fc)
+		t.rt.Unbind()
+		if err != nil {
+			return err
+		}
+	}
+	if len(fg.order) > 0 {
+		r.rendered = true
+	}
+	return nil
+}
+
+// Target returns the render target with the specified name, which is
+// nil for an unknown name or for a transient target of a skipped pass.
+func (fc *FrameContext) Target(name string) *texture.RenderTarget {
+
+	if t := fc.graph.targets[name]; t != nil {
+		return t.rt
+	}
+	return nil
+}
+
+// specEquals returns whether the specified render target specifications are equal.
+func specEquals(a, b *texture.RenderTargetSpec) bool {
+
+	if a.Width != b.Width || a.Height != b.Height || a.DepthFormat != b.DepthFormat ||
+		a.DepthTexture != b.DepthTexture || a.Samples != b.Samples || len(a.ColorFormats) != len(b.ColorFormats) {
+		return false
+	}
+	for i := range a.ColorFormats {
+		if a.ColorFormats[i] != b.ColorFormats[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// SetFrameGraph sets the frame graph whose passes Render executes instead of
+// rendering the scene through the post processing effects, or nil to restore them.
+func (r *Renderer) SetFrameGraph(fg *FrameGraph) {
+
+	r.graph = fg
+}
+
+// FrameGraph returns the current frame graph or nil.
+func (r *Renderer) FrameGraph() *FrameGraph {
+
+	return r.graph
+}
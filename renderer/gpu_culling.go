@@ -0,0 +1,189 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"fmt"
+
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/math32"
+	gl "github.com/thommil/tge-gl"
+)
+
+// Vertex shader testing each bounding box transformed by its world matrix against
+// the frustum planes, with the same conservative test as math32.Frustum.IntersectsBox.
+const gpuCullVertex = `
+in vec3 BoxMin;
+in vec3 BoxMax;
+in vec4 BoxMatrix0;
+in vec4 BoxMatrix1;
+in vec4 BoxMatrix2;
+in vec4 BoxMatrix3;
+
+uniform vec4 Planes[6];
+
+out float Visible;
+
+void main() {
+
+    mat4 m = mat4(BoxMatrix0, BoxMatrix1, BoxMatrix2, BoxMatrix3);
+    vec3 center = (m * vec4((BoxMin + BoxMax) * 0.5, 1.0)).xyz;
+    vec3 extent = mat3(abs(m[0].xyz), abs(m[1].xyz), abs(m[2].xyz)) * ((BoxMax - BoxMin) * 0.5);
+    Visible = 1.0;
+    for (int i = 0; i < 6; i++) {
+        if (dot(Planes[i].xyz, center) + dot(abs(Planes[i].xyz), extent) + Planes[i].w < 0.0) {
+            Visible = 0.0;
+        }
+    }
+    gl_Position = vec4(0.0);
+}
+`
+
+// Fragment shader required to link the program, the rasterization is discarded.
+const gpuCullFragment = `
+precision mediump float;
+out vec4 FragColor;
+
+void main() {
+
+    FragColor = vec4(1.0);
+}
+`
+
+// Number of floats of the data of each tested graphic: box min, box max and world matrix
+const gpuCullStride = 22
+
+// gpuCuller tests the bounding boxes of the graphics against the camera frustum
+// with a transform feedback pass whose results are read back by the CPU.
+type gpuCuller struct {
+	prog      *gls.Program    // Culling program or nil if not built
+	failed    bool            // Indicates if the program could not be built
+	vao       uint32          // Vertex array of the boxes attributes
+	boxes     uint32          // Buffer of the boxes and world matrices
+	results   uint32          // Transform feedback buffer of the visibility results
+	size      int             // Number of graphics the buffers can hold
+	data      math32.ArrayF32 // Boxes and world matrices data
+	readback  []byte          // Visibility results data
+	uniPlanes gls.Uniform     // Frustum planes uniform location cache
+	planes    [24]float32     // Frustum planes
+}
+
+// cull sets the frustum culling results of the specified graphics and returns true,
+// or returns false if transform feedback is not supported and they must be culled by the CPU.
+func (gc *gpuCuller) cull(r *Renderer, cgrs []cullGraphic, frustum *math32.Frustum) bool {
+
+	gs := r.gs
+	if gc.failed || !gs.TransformFeedbackSupported() {
+		return false
+	}
+	if gc.prog == nil && !gc.build(gs) {
+		return false
+	}
+	if len(cgrs) == 0 {
+		return true
+	}
+
+	// Transfers the boxes and world matrices of the graphics
+	gc.data = gc.data[:0]
+	for i := range cgrs {
+		cgr := &cgrs[i]
+		mw := cgr.gr.MatrixWorld()
+		gc.data.Append(cgr.box.Min.X, cgr.box.Min.Y, cgr.box.Min.Z, cgr.box.Max.X, cgr.box.Max.Y, cgr.box.Max.Z)
+		gc.data.Append(mw[:]...)
+	}
+	gs.BindVertexArray(gc.vao)
+	if len(cgrs) > gc.size {
+		gc.size = len(cgrs)
+		gc.readback = make([]byte, 4*gc.size)
+		gs.BindBuffer(gls.ARRAY_BUFFER, gc.boxes)
+		gs.BufferData(gls.ARRAY_BUFFER, gc.data.Bytes(), gc.data, gls.STREAM_DRAW)
+		gs.BindBuffer(gls.TRANSFORM_FEEDBACK_BUFFER, gc.results)
+		gs.BufferData(gls.TRANSFORM_FEEDBACK_BUFFER, len(gc.readback), &gc.readback[0], gls.STREAM_READ)
+	} else {
+		gs.BindBuffer(gls.ARRAY_BUFFER, gc.boxes)
+		gs.BufferSubData(gls.ARRAY_BUFFER, 0, gc.data.Bytes(), &gc.data[0])
+	}
+
+	// Tests the boxes against the frustum planes without rasterization
+	for i := 0; i < 6; i++ {
+		p := frustum.Plane(i)
+		n := p.Normal()
+		gc.planes[4*i], gc.planes[4*i+1], gc.planes[4*i+2], gc.planes[4*i+3] = n.X, n.Y, n.Z, p.Constant()
+	}
+	gs.UseProgram(gc.prog)
+	r.shaman.resetProgram()
+	gs.Uniform4fv(gc.uniPlanes.Location(gs), 6, gc.planes[:])
+	gs.BindBufferBase(gls.TRANSFORM_FEEDBACK_BUFFER, 0, gc.results)
+	gs.Enable(gls.RASTERIZER_DISCARD)
+	gs.BeginTransformFeedback(gls.POINTS)
+	gs.DrawArrays(gls.POINTS, 0, int32(len(cgrs)))
+	gs.EndTransformFeedback()
+	gs.Disable(gls.RASTERIZER_DISCARD)
+	gs.BindBufferBase(gls.TRANSFORM_FEEDBACK_BUFFER, 0, 0)
+
+	// Reads back the results, waiting for the pass to complete
+	results := gc.readback[:4*len(cgrs)]
+	gs.BindBuffer(gls.TRANSFORM_FEEDBACK_BUFFER, gc.results)
+	gs.GetBufferSubData(gls.TRANSFORM_FEEDBACK_BUFFER, 0, results)
+	gs.BindBuffer(gls.TRANSFORM_FEEDBACK_BUFFER, 0)
+	for i := range cgrs {
+		cgr := &cgrs[i]
+		visible := !cgr.cullable || results[4*i]|results[4*i+1]|results[4*i+2]|results[4*i+3] != 0
+		cgr.result = cullResult{cgr.gr.MatrixWorld(), cgr.box, visible}
+	}
+	return true
+}
+
+// build builds the culling program and creates its buffers.
+// It returns false if the program could not be built.
+func (gc *gpuCuller) build(gs *gls.GLS) bool {
+
+	version := fmt.Sprintf("#version %s\n", gl.GetGLSLVersion())
+	prog := gs.NewProgram()
+	prog.AddShader(gls.VERTEX_SHADER, version+gpuCullVertex)
+	prog.AddShader(gls.FRAGMENT_SHADER, version+gpuCullFragment)
+	prog.SetFeedbackVaryings("Visible")
+	err := prog.Build()
+	if err != nil {
+		fmt.Printf("WARNING : GPU culling disabled: %v\n", err)
+		gc.failed = true
+		return false
+	}
+	gc.prog = prog
+	gc.uniPlanes.Init("Planes")
+
+	// Sets the attributes of the boxes buffer
+	gc.vao = gs.GenVertexArray()
+	gc.boxes = gs.GenBuffer()
+	gc.results = gs.GenBuffer()
+	gs.BindVertexArray(gc.vao)
+	gs.BindBuffer(gls.ARRAY_BUFFER, gc.boxes)
+	attribs := []struct {
+		name   string
+		size   int32
+		offset uint32
+	}{{"BoxMin", 3, 0}, {"BoxMax", 3, 3}, {"BoxMatrix0", 4, 6}, {"BoxMatrix1", 4, 10}, {"BoxMatrix2", 4, 14}, {"BoxMatrix3", 4, 18}}
+	for _, a := range attribs {
+		loc := prog.GetAttribLocation(a.name)
+		if loc < 0 {
+			continue
+		}
+		gs.EnableVertexAttribArray(uint32(loc))
+		gs.VertexAttribPointer(uint32(loc), a.size, gls.FLOAT, false, 4*gpuCullStride, 4*a.offset)
+	}
+	return true
+}
+
+// dispose releases the culling program and buffers.
+func (gc *gpuCuller) dispose(gs *gls.GLS) {
+
+	if gc.prog == nil {
+		return
+	}
+	gs.DeleteBuffers(gc.boxes, gc.results)
+	gs.DeleteVertexArrays(gc.vao)
+	gs.DeleteProgram(gc.prog.Handle())
+	*gc = gpuCuller{}
+}
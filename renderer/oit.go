@@ -0,0 +1,167 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/graphic"
+	"github.com/thommil/tge-g3n/material"
+	"github.com/thommil/tge-g3n/texture"
+)
+
+// TransparencyMode is the rendering mode of the transparent objects
+type TransparencyMode int
+
+// The transparency modes
+const (
+	TransparencySorted          = TransparencyMode(iota) // Sorted back to front and blended in order
+	TransparencyWeightedBlended                          // Weighted blended order independent transparency
+)
+
+// oitShaders are the shaders of the materials supporting the order independent transparency
+var oitShaders = map[string]bool{"standard": true, "phong": true, "physical": true}
+
+// weightedBlended renders the transparent graphics with the weighted blended order
+// independent transparency. Their colors weighted by their alpha and depth are accumulated
+// without sorting into half float targets, which are composited over the opaque graphics.
+type weightedBlended struct {
+	target     *texture.RenderTarget // Accumulated colors and revealage (0) and sum of the weights (1)
+	composite  *PostEffect           // Compositing pass
+	specs      ShaderSpecs           // Compositing pass shader specs
+	dspecs     ShaderSpecs           // Opaque depth pass shader specs
+	uniAccum   gls.Uniform           // Accumulated colors sampler uniform location cache
+	uniWeights gls.Uniform           // Sum of the weights sampler uniform location cache
+}
+
+// SetTransparencyMode sets the rendering mode of the transparent objects. TransparencySorted,
+// the default, sorts them back to front (see SetTransparencySort), which is exact for separate
+// objects but fails for intersecting or interleaved ones. TransparencyWeightedBlended renders
+// them in any order with an approximation of their blending, which has no popping and suits
+// overlapping glass and foliage. It requires half float render targets (see texture.RenderTarget.SetHDR)
+// and renders the opaque objects depth again. Its multiple render targets need GLS.GL3Supported,
+// without which the objects are sorted. Only the "standard", "phong" and "physical" shaders
+// support it, the other transparent materials are sorted and rendered after the composition.
+// The graphics stencil is not applied by this mode.
+func (r *Renderer) SetTransparencyMode(mode TransparencyMode) {
+
+	r.transpMode = mode
+	if mode == TransparencySorted {
+		r.oit.dispose()
+	}
+}
+
+// TransparencyMode returns the rendering mode of the transparent objects.
+func (r *Renderer) TransparencyMode() TransparencyMode {
+
+	return r.transpMode
+}
+
+// init initializes the compositing pass and the uniforms.
+func (wb *weightedBlended) init() {
+
+	wb.uniAccum.Init("PostColor")
+	wb.uniWeights.Init("OITWeights")
+	wb.specs.Name = "oit_composite"
+	wb.specs.ShaderUnique = true
+	wb.dspecs.Name = "depth"
+	wb.dspecs.ShaderUnique = true
+	wb.composite = NewPostEffect("oit_composite")
+	wb.composite.SetBlending(material.BlendingNormal)
+	wb.composite.setup = func(gs *gls.GLS) {
+		gs.ActiveTexture(gls.TEXTURE0)
+		gs.BindTexture(gls.TEXTURE_2D, wb.target.ColorTexture(0).TexName())
+		gs.Uniform1i(wb.uniAccum.Location(gs), 0)
+		gs.ActiveTexture(gls.TEXTURE1)
+		gs.BindTexture(gls.TEXTURE_2D, wb.target.ColorTexture(1).TexName())
+		gs.Uniform1i(wb.uniWeights.Location(gs), 1)
+	}
+}
+
+// split moves the commands of the transparent materials of the queues up to QueueTransparent
+// supporting the order independent transparency from the specified transparent commands
+// to the specified OIT commands, adding their OIT define, and returns the remaining
+// transparent commands and the OIT commands.
+func (wb *weightedBlended) split(transp, oit []renderCommand) ([]renderCommand, []renderCommand) {
+
+	oit = oit[0:0]
+	count := 0
+	for _, cmd := range transp {
+		mat := cmd.grmat.IMaterial().GetMaterial()
+		if oitShaders[cmd.specs.Name] && mat.Transparent() && mat.RenderQueue() <= material.QueueTransparent {
+			cmd.specs.Defines.Set("OIT", "")
+			cmd.oit = true
+			oit = append(oit, cmd)
+		} else {
+			transp[count] = cmd
+			count++
+		}
+	}
+	return transp[:count], oit
+}
+
+// begin binds the accumulation target, sized as the current viewport, and renders the
+// depth of the opaque graphics of the last classified scene to test the transparent ones.
+func (wb *weightedBlended) begin(r *Renderer) error {
+
+	gs := r.gs
+	_, _, width, height := gs.GetViewport()
+	if wb.target != nil && (wb.target.Width() != int(width) || wb.target.Height() != int(height)) {
+		wb.dispose()
+	}
+	if wb.target == nil {
+		wb.target = texture.NewRenderTarget(int(width), int(height))
+		wb.target.SetHDR(true)
+		wb.target.SetColorTextures(2)
+	}
+	err := wb.target.Bind(gs)
+	if err != nil {
+		return err
+	}
+	gs.Clear(gls.DEPTH_BUFFER_BIT)
+	err = r.renderOpaqueDepth(&wb.dspecs)
+	if err != nil {
+		wb.target.Unbind()
+		return err
+	}
+
+	// The revealage in the alpha of the accumulated colors starts at 1
+	cr, cg, cb, ca := gs.GetClearColor()
+	gs.ClearColor(0, 0, 0, 1)
+	gs.Clear(gls.COLOR_BUFFER_BIT)
+	gs.ClearColor(cr, cg, cb, ca)
+	return nil
+}
+
+// draw renders the specified graphic material accumulating its colors instead of blending them.
+func (wb *weightedBlended) draw(gs *gls.GLS, grmat *graphic.GraphicMaterial, rinfo *core.RenderInfo) {
+
+	grmat.IMaterial().RenderSetup(gs)
+	gs.BlendAccumulate()
+	gs.DepthMask(false)
+	grmat.Draw(gs, rinfo)
+}
+
+// end unbinds the accumulation target and composites it over the bound framebuffer.
+func (wb *weightedBlended) end(r *Renderer) error {
+
+	wb.target.Unbind()
+	_, err := r.shaman.SetProgram(&wb.specs)
+	if err != nil {
+		return err
+	}
+	grmats := wb.composite.quad.Materials()
+	grmats[0].Render(r.gs, &r.rinfo)
+	return nil
+}
+
+// dispose releases the accumulation target.
+func (wb *weightedBlended) dispose() {
+
+	if wb.target != nil {
+		wb.target.Dispose()
+		wb.target = nil
+	}
+}
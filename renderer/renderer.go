@@ -6,37 +6,112 @@ package renderer
 
 import (
 	"sort"
+	"sync"
 
 	"github.com/thommil/tge-g3n/camera"
 	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/geometry"
 	"github.com/thommil/tge-g3n/gls"
 	"github.com/thommil/tge-g3n/graphic"
 	"github.com/thommil/tge-g3n/light"
+	"github.com/thommil/tge-g3n/material"
 	"github.com/thommil/tge-g3n/math32"
 )
 
 // Renderer renders a 3D scene and/or a 2D GUI on the current window.
 type Renderer struct {
 	gs           *gls.GLS
-	shaman       Shaman                     // Internal shader manager
-	stats        Stats                      // Renderer statistics
-	prevStats    Stats                      // Renderer statistics for previous frame
-	scene        core.INode                 // Node containing 3D scene to render
-	ambLights    []*light.Ambient           // Array of ambient lights for last scene
-	dirLights    []*light.Directional       // Array of directional lights for last scene
-	pointLights  []*light.Point             // Array of point
-	spotLights   []*light.Spot              // Array of spot lights for the scene
-	others       []core.INode               // Other nodes (audio, players, etc)
-	rgraphics    []*graphic.Graphic         // Array of rendered graphics
-	cgraphics    []*graphic.Graphic         // Array of rendered graphics
-	grmatsOpaque []*graphic.GraphicMaterial // Array of rendered opaque graphic materials for scene
-	grmatsTransp []*graphic.GraphicMaterial // Array of rendered transparent graphic materials for scene
-	rinfo        core.RenderInfo            // Preallocated Render info
-	specs        ShaderSpecs                // Preallocated Shader specs
-	sortObjects  bool                       // Flag indicating whether objects should be sorted before rendering
-	rendered     bool                       // Flag indicating if anything was rendered
-	frameBuffers int                        // Number of frame buffers
-	frameCount   int                        // Current number of frame buffers to write
+	shaman       Shaman                          // Internal shader manager
+	stats        Stats                           // Renderer statistics
+	prevStats    Stats                           // Renderer statistics for previous frame
+	scene        core.INode                      // Node containing 3D scene to render
+	ambLights    []*light.Ambient                // Array of ambient lights for last scene
+	dirLights    []*light.Directional            // Array of directional lights for last scene
+	pointLights  []*light.Point                  // Array of point
+	spotLights   []*light.Spot                   // Array of spot lights for the scene
+	others       []core.INode                    // Other nodes (audio, players, etc)
+	rgraphics    []*graphic.Graphic              // Array of rendered graphics
+	cgraphics    []*graphic.Graphic              // Array of rendered graphics
+	grmatsOpaque []*graphic.GraphicMaterial      // Array of rendered opaque graphic materials for scene
+	grmatsTransp []*graphic.GraphicMaterial      // Array of rendered transparent graphic materials for scene
+	rinfo        core.RenderInfo                 // Preallocated Render info
+	projView     math32.Matrix4                  // Projection and view matrix of the last scene render
+	viewport     [4]int32                        // Viewport of the last scene render
+	specs        ShaderSpecs                     // Preallocated Shader specs
+	sortObjects  bool                            // Flag indicating whether objects should be sorted before rendering
+	transpSort   TransparencySort                // Depth measure of the transparent objects sort
+	transpMode   TransparencyMode                // Rendering mode of the transparent objects
+	oit          weightedBlended                 // Order independent transparency pass
+	rendered     bool                            // Flag indicating if anything was rendered
+	frameBuffers int                             // Number of frame buffers
+	frameCount   int                             // Current number of frame buffers to write
+	lightsSetup  map[*gls.Program]bool           // Programs which received the lights uniforms in the current scene render
+	cullCaching  bool                            // Flag indicating whether frustum culling results are reused
+	cullProj     math32.Matrix4                  // Camera projection and view matrix of the cached culling results
+	cullCache    map[*graphic.Graphic]cullResult // Culling results of the last scene render
+	cullNext     map[*graphic.Graphic]cullResult // Culling results of the current scene render
+	cullWorkers  int                             // Number of goroutines used for frustum culling
+	cmdWorkers   int                             // Number of goroutines used to prepare the render commands
+	cmdsOpaque   []renderCommand                 // Render commands of the opaque graphic materials
+	cmdsTransp   []renderCommand                 // Render commands of the transparent graphic materials
+	cmdsOIT      []renderCommand                 // Render commands of the order independent transparent graphic materials
+	vgraphics    []cullGraphic                   // Graphics of the scene to be culled
+	gpuCulling   bool                            // Flag indicating whether frustum culling is done by the GPU if supported
+	gpuCuller    gpuCuller                       // GPU frustum culling pass
+	showBounds   bool                            // Flag indicating whether graphics bounding boxes are drawn
+	bounds       *graphic.Lines                  // Lines of the graphics bounding boxes
+	composer     composer                        // Post processing effects composer
+	soft         softParticles                   // Depth pass of the soft particles
+	shadows      shadowMap                       // Shadow map of the first directional light casting shadows
+	noClear      bool                            // Flag indicating that scene renders must not clear the framebuffer
+	stereoCull   *math32.Matrix4                 // Projection and view matrix culling both eyes of a stereo render or nil
+	debug        debugView                       // Debug view replacing the materials of the graphics
+	graph        *FrameGraph                     // Frame graph executed by Render or nil
+	env          *core.Environment               // Environment of the scene being rendered or nil
+	envAmbient   *light.Ambient                  // Ambient light of the scene environment
+	uniFog       gls.Uniform                     // Fog uniform location cache
+	capture      *FrameReport                    // Report of the frame being captured or nil
+}
+
+// SceneView is a scene rendered by RenderScenes with its camera,
+// the viewport it is rendered to and the buffers cleared before rendering it.
+type SceneView struct {
+	Scene    core.INode     // Scene to render
+	Camera   camera.ICamera // Camera of the scene
+	Viewport [4]int32       // Viewport x, y, width and height or the current viewport if the width is 0
+	Clear    uint           // Buffers cleared inside the viewport (gls.COLOR_BUFFER_BIT | gls.DEPTH_BUFFER_BIT ...)
+}
+
+// TransparencySort is the depth measure used to sort the transparent objects back to front
+type TransparencySort int
+
+// The transparency sort depth measures
+const (
+	SortByZ        = TransparencySort(iota) // Sorts by the view Z of the sort centers
+	SortByDistance                          // Sorts by the distance from the camera to the sort centers
+)
+
+// cullGraphic is a graphic of the scene with its culling result
+type cullGraphic struct {
+	gr       *graphic.Graphic
+	cullable bool
+	box      math32.Box3 // Geometry bounding box
+	result   cullResult
+}
+
+// cullResult is the frustum culling result of a graphic
+// with the transform and bounding box used to compute it.
+type cullResult struct {
+	matrixWorld math32.Matrix4
+	box         math32.Box3
+	visible     bool
+}
+
+// renderCommand is a graphic material to render with its prepared shader specs
+type renderCommand struct {
+	grmat *graphic.GraphicMaterial
+	specs ShaderSpecs
+	oit   bool // Rendered by the order independent transparency pass
 }
 
 // Stats describes how many object types were rendered.
@@ -64,8 +139,18 @@ func NewRenderer(gs *gls.GLS) *Renderer {
 	r.cgraphics = make([]*graphic.Graphic, 0)
 	r.grmatsOpaque = make([]*graphic.GraphicMaterial, 0)
 	r.grmatsTransp = make([]*graphic.GraphicMaterial, 0)
+	r.lightsSetup = make(map[*gls.Program]bool)
 	r.frameBuffers = 2
 	r.sortObjects = true
+	r.cullWorkers = 1
+	r.cmdWorkers = 1
+	r.composer.init()
+	r.soft.init()
+	r.shadows.init()
+	r.oit.init()
+	r.debug.init()
+	r.envAmbient = light.NewAmbient(&math32.Color{1, 1, 1}, 0)
+	r.uniFog.Init("Fog")
 	return r
 }
 
@@ -95,6 +180,20 @@ func (r *Renderer) AddProgram(name, vertex, frag string, others ...string) {
 	r.shaman.AddProgram(name, vertex, frag, others...)
 }
 
+// PreviewShaderSource returns the source of the shader with the specified name as
+// it is compiled with the specified defines, with its include chunks inlined.
+// See Shaman.ResolveSource.
+func (r *Renderer) PreviewShaderSource(name string, defines *gls.ShaderDefines) (string, error) {
+
+	return r.shaman.ResolveSource(name, defines)
+}
+
+// GLS returns the OpenGL state used by this renderer.
+func (r *Renderer) GLS() *gls.GLS {
+
+	return r.gs
+}
+
 // SetScene sets the 3D scene to be rendered.
 // If set to nil, no 3D scene will be rendered.
 func (r *Renderer) SetScene(scene core.INode) {
@@ -109,6 +208,60 @@ func (r *Renderer) Stats() Stats {
 	return r.stats
 }
 
+// LastRenderInfo returns a copy of the camera matrices used by the last scene render,
+// such as for overlay draws with the same camera.
+func (r *Renderer) LastRenderInfo() core.RenderInfo {
+
+	return r.rinfo
+}
+
+// LastProjView returns the product of the projection and view matrices used by the
+// last scene render for frustum culling, which maps world points to clip coordinates.
+func (r *Renderer) LastProjView() math32.Matrix4 {
+
+	return r.projView
+}
+
+// WorldToScreen returns the pixel coordinates of the specified world point relative to the
+// top left corner of the viewport of the last scene render, as seen by its camera,
+// such as to anchor GUI elements to objects, and whether the point is inside the camera frustum.
+func (r *Renderer) WorldToScreen(p math32.Vector3) (x, y float32, visible bool) {
+
+	clip := math32.Vector4{X: p.X, Y: p.Y, Z: p.Z, W: 1}
+	clip.ApplyMatrix4(&r.projView)
+	if clip.W == 0 {
+		return 0, 0, false
+	}
+	nx, ny, nz := clip.X/clip.W, clip.Y/clip.W, clip.Z/clip.W
+	x = (nx + 1) / 2 * float32(r.viewport[2])
+	y = (1 - ny) / 2 * float32(r.viewport[3])
+	visible = clip.W > 0 && nx >= -1 && nx <= 1 && ny >= -1 && ny <= 1 && nz >= -1 && nz <= 1
+	return x, y, visible
+}
+
+// ScreenToWorld returns the world point at the specified pixel coordinates relative to the
+// top left corner of the viewport of the last scene render and at the specified depth,
+// from 0 on the camera near plane to 1 on its far plane, such as to cast rays from the mouse position.
+func (r *Renderer) ScreenToWorld(x, y, depth float32) math32.Vector3 {
+
+	var inv math32.Matrix4
+	var p math32.Vector3
+	if r.viewport[2] == 0 || r.viewport[3] == 0 || inv.GetInverse(&r.projView) != nil {
+		return p
+	}
+	clip := math32.Vector4{
+		X: 2*x/float32(r.viewport[2]) - 1,
+		Y: 1 - 2*y/float32(r.viewport[3]),
+		Z: 2*depth - 1,
+		W: 1,
+	}
+	clip.ApplyMatrix4(&inv)
+	if clip.W != 0 {
+		p.Set(clip.X/clip.W, clip.Y/clip.W, clip.Z/clip.W)
+	}
+	return p
+}
+
 // SetObjectSorting sets whether objects will be sorted before rendering.
 func (r *Renderer) SetObjectSorting(sort bool) {
 
@@ -121,16 +274,140 @@ func (r *Renderer) ObjectSorting() bool {
 	return r.sortObjects
 }
 
-// Render renders the previously set Scene and Gui using the specified camera.
+// SetTransparencySort sets the depth measure used to sort the transparent objects back to front.
+// SortByZ, the default, orders the objects the way the depth buffer does and suits orthographic
+// cameras and objects spread in depth such as layered planes. SortByDistance orders correctly
+// the objects far from the view axis of wide field of view cameras, such as particles around
+// the viewer. The opaque objects are always sorted by their view Z.
+func (r *Renderer) SetTransparencySort(mode TransparencySort) {
+
+	r.transpSort = mode
+}
+
+// TransparencySort returns the depth measure used to sort the transparent objects.
+func (r *Renderer) TransparencySort() TransparencySort {
+
+	return r.transpSort
+}
+
+// SetCullCaching sets whether the frustum culling result of each graphic is reused
+// while the camera and the graphic world transform and bounding box are unchanged.
+// It saves time in scenes where the camera is often still. Default is false.
+func (r *Renderer) SetCullCaching(state bool) {
+
+	r.cullCaching = state
+	if state {
+		r.cullCache = make(map[*graphic.Graphic]cullResult)
+		r.cullNext = make(map[*graphic.Graphic]cullResult)
+	} else {
+		r.cullCache = nil
+		r.cullNext = nil
+	}
+}
+
+// CullCaching returns whether frustum culling results are reused.
+func (r *Renderer) CullCaching() bool {
+
+	return r.cullCaching
+}
+
+// SetParallelCulling sets the number of goroutines used to test the scene graphics
+// against the camera frustum. Only the culling math is parallelized, the OpenGL calls
+// stay on the render thread and the render order is the same as with a single worker.
+// It is worth it only for scenes with many thousands of graphics. Default is 1.
+func (r *Renderer) SetParallelCulling(workers int) {
+
+	if workers < 1 {
+		panic("SetParallelCulling: workers must be at least 1")
+	}
+	r.cullWorkers = workers
+}
+
+// ParallelCulling returns the number of goroutines used for frustum culling.
+func (r *Renderer) ParallelCulling() int {
+
+	return r.cullWorkers
+}
+
+// SetGPUCulling sets whether the graphics are tested against the camera frustum by
+// the GPU in a transform feedback pass, which requires desktop OpenGL 3.0.
+// Without transform feedback support (see GLS.TransformFeedbackSupported), the graphics
+// are culled by the CPU.
+//
+// The results are the same as with CPU culling but they are read back before drawing,
+// which waits for the GPU to complete the pass and all the previous commands, so it is
+// only worth it for scenes with many thousands of graphics whose CPU culling is the bottleneck.
+// The world matrices are transferred at each render, the cached culling results are not reused.
+func (r *Renderer) SetGPUCulling(state bool) {
+
+	if !state && r.gpuCulling {
+		r.gpuCuller.dispose(r.gs)
+	}
+	r.gpuCulling = state
+}
+
+// GPUCulling returns whether the frustum culling is done by the GPU if supported.
+func (r *Renderer) GPUCulling() bool {
+
+	return r.gpuCulling
+}
+
+// SetCommandWorkers sets the number of goroutines used to prepare the render commands
+// of the scene graphics: the calculation of their matrices and the assembly of the shader
+// specs of their materials. The commands are then submitted to OpenGL on the render thread
+// in the same order as with a single worker. It is worth it only for scenes with many
+// graphic materials. Default is 1.
+func (r *Renderer) SetCommandWorkers(workers int) {
+
+	if workers < 1 {
+		panic("SetCommandWorkers: workers must be at least 1")
+	}
+	r.cmdWorkers = workers
+}
+
+// CommandWorkers returns the number of goroutines used to prepare the render commands.
+func (r *Renderer) CommandWorkers() int {
+
+	return r.cmdWorkers
+}
+
+// SetShowBounds sets whether the world space bounding boxes of the graphics
+// are drawn as lines, in green for the rendered graphics and in red for the
+// culled ones. It is a debugging aid for culling and transform problems.
+func (r *Renderer) SetShowBounds(state bool) {
+
+	r.showBounds = state
+}
+
+// ShowBounds returns whether the graphics bounding boxes are drawn.
+func (r *Renderer) ShowBounds() bool {
+
+	return r.showBounds
+}
+
+// Render renders the previously set Scene and Gui using the specified camera,
+// after deleting the OpenGL objects queued for deletion since the last frame.
 // Returns an indication if anything was rendered and an error.
 func (r *Renderer) Render(icam camera.ICamera) (bool, error) {
 
+	r.gs.ProcessPendingDeletes()
 	r.rendered = false
 	r.stats = Stats{}
 
-	// Renders the 3D scene
-	if r.scene != nil {
-		err := r.renderScene(r.scene, icam)
+	// Executes the frame graph passes if any, otherwise
+	// renders the 3D scene through the post processing effects if any
+	if r.graph != nil {
+		err := r.graph.execute(r, icam)
+		if err != nil {
+			return r.rendered, err
+		}
+	} else if r.scene != nil {
+		var err error
+		if r.composer.active() {
+			err = r.composer.render(r, r.scene, icam)
+		} else {
+			err = r.renderScene(r.scene, icam)
+		}
 		if err != nil {
 			return r.rendered, err
 		}
@@ -140,100 +417,109 @@ func (r *Renderer) Render(icam camera.ICamera) (bool, error) {
 	return r.rendered, nil
 }
 
+// RenderScenes renders the specified scene views in sequence into the current
+// framebuffer, each one drawn over the previous ones after clearing the buffers
+// it specifies, such as a 3D world and then a 3D HUD scene which only clears the depth.
+// The post processing effects are only applied to the first view.
+// The scene set by SetScene is not rendered. Like Render, it first deletes the OpenGL
+// objects queued for deletion since the last frame.
+func (r *Renderer) RenderScenes(views []SceneView) (bool, error) {
+
+	r.gs.ProcessPendingDeletes()
+	r.rendered = false
+	r.stats = Stats{}
+	r.noClear = true
+	defer func() { r.noClear = false }()
+
+	x, y, width, height := r.gs.GetViewport()
+	defer r.gs.Viewport(x, y, width, height)
+	for i := range views {
+		v := &views[i]
+		if v.Viewport[2] > 0 {
+			r.gs.Viewport(v.Viewport[0], v.Viewport[1], v.Viewport[2], v.Viewport[3])
+		} else {
+			r.gs.Viewport(x, y, width, height)
+		}
+
+		// Clears the buffers inside the viewport only
+		if v.Clear != 0 {
+			vx, vy, vw, vh := r.gs.GetViewport()
+			r.gs.Enable(gls.SCISSOR_TEST)
+			r.gs.Scissor(vx, vy, uint32(vw), uint32(vh))
+			r.gs.Clear(v.Clear)
+			r.gs.Disable(gls.SCISSOR_TEST)
+			r.rendered = true
+		}
+
+		var err error
+		if i == 0 && r.composer.active() {
+			err = r.composer.render(r, v.Scene, v.Camera)
+		} else {
+			err = r.renderScene(v.Scene, v.Camera)
+		}
+		if err != nil {
+			return r.rendered, err
+		}
+	}
+
+	r.prevStats = r.stats
+	return r.rendered, nil
+}
+
+// RenderScene renders the specified scene using the specified camera into the
+// currently bound framebuffer, such as an offscreen texture.RenderTarget.
+// Returns an indication if anything was rendered and an error.
+func (r *Renderer) RenderScene(scene core.INode, icam camera.ICamera) (bool, error) {
+
+	r.rendered = false
+	err := r.renderScene(scene, icam)
+	return r.rendered, err
+}
+
 // renderScene renders the 3D scene using the specified camera.
 func (r *Renderer) renderScene(iscene core.INode, icam camera.ICamera) error {
 
+	// Lights uniforms must be transferred again to all programs.
+	// Clearing the set also forgets the programs deleted since the last render.
+	for prog := range r.lightsSetup {
+		delete(r.lightsSetup, prog)
+	}
+
 	// Updates world matrices of all scene nodes
 	iscene.UpdateMatrixWorld()
 	scene := iscene.GetNode()
 
+	// Environment of the scene if any
+	r.env = nil
+	if is, ok := iscene.(core.IScene); ok {
+		r.env = is.Environment()
+	}
+
 	// Builds RenderInfo calls RenderSetup for all visible nodes
 	icam.ViewMatrix(&r.rinfo.ViewMatrix)
 	icam.ProjMatrix(&r.rinfo.ProjMatrix)
+	r.rinfo.CameraMatrix.GetInverse(&r.rinfo.ViewMatrix)
 
-	// Clear scene arrays
-	r.ambLights = r.ambLights[0:0]
-	r.dirLights = r.dirLights[0:0]
-	r.pointLights = r.pointLights[0:0]
-	r.spotLights = r.spotLights[0:0]
-	r.others = r.others[0:0]
-	r.rgraphics = r.rgraphics[0:0]
-	r.cgraphics = r.cgraphics[0:0]
+	// Classifies the scene nodes and culls the graphics outside the camera frustum
+	r.projView.MultiplyMatrices(&r.rinfo.ProjMatrix, &r.rinfo.ViewMatrix)
+	r.viewport[0], r.viewport[1], r.viewport[2], r.viewport[3] = r.gs.GetViewport()
+	if r.stereoCull != nil {
+		r.classifyScene(scene, r.stereoCull)
+	} else {
+		r.classifyScene(scene, &r.projView)
+	}
+	if r.capture != nil {
+		r.capture.Rendered += len(r.rgraphics)
+		r.capture.Culled += len(r.cgraphics)
+	}
+	if r.env != nil && r.env.AmbientIntensity > 0 {
+		r.envAmbient.SetColor(&r.env.AmbientColor)
+		r.envAmbient.SetIntensity(r.env.AmbientIntensity)
+		r.ambLights = append(r.ambLights, r.envAmbient)
+	}
 	r.grmatsOpaque = r.grmatsOpaque[0:0]
 	r.grmatsTransp = r.grmatsTransp[0:0]
 
-	// Prepare for frustum culling
-	var proj math32.Matrix4
-	proj.MultiplyMatrices(&r.rinfo.ProjMatrix, &r.rinfo.ViewMatrix)
-	frustum := math32.NewFrustumFromMatrix(&proj)
-
-	// Internal function to classify a node and its children
-	var classifyNode func(inode core.INode)
-	classifyNode = func(inode core.INode) {
-
-		// If node not visible, ignore
-		node := inode.GetNode()
-		if !node.Visible() {
-			return
-		}
-
-		// Checks if node is a Graphic
-		igr, ok := inode.(graphic.IGraphic)
-		if ok {
-			if igr.Renderable() {
-
-				gr := igr.GetGraphic()
-
-				// Frustum culling
-				if igr.Cullable() {
-					mw := gr.MatrixWorld()
-					geom := igr.GetGeometry()
-					bb := geom.BoundingBox()
-					bb.ApplyMatrix4(&mw)
-					if frustum.IntersectsBox(&bb) {
-						// Append graphic to list of graphics to be rendered
-						r.rgraphics = append(r.rgraphics, gr)
-					} else {
-						// Append graphic to list of culled graphics
-						r.cgraphics = append(r.cgraphics, gr)
-					}
-				} else {
-					// Append graphic to list of graphics to be rendered
-					r.rgraphics = append(r.rgraphics, gr)
-				}
-			}
-			// Node is not a Graphic
-		} else {
-			// Checks if node is a Light
-			il, ok := inode.(light.ILight)
-			if ok {
-				switch l := il.(type) {
-				case *light.Ambient:
-					r.ambLights = append(r.ambLights, l)
-				case *light.Directional:
-					r.dirLights = append(r.dirLights, l)
-				case *light.Point:
-					r.pointLights = append(r.pointLights, l)
-				case *light.Spot:
-					r.spotLights = append(r.spotLights, l)
-				default:
-					panic("Invalid light type")
-				}
-				// Other nodes
-			} else {
-				r.others = append(r.others, inode)
-			}
-		}
-
-		// Classify node children
-		for _, ichild := range node.Children() {
-			classifyNode(ichild)
-		}
-	}
-
-	// Classify all scene nodes
-	classifyNode(scene)
-
 	//log.Debug("Rendered/Culled: %v/%v", len(r.grmats), len(r.cgrmats))
 
 	// Sets lights count in shader specs
@@ -242,30 +528,59 @@ func (r *Renderer) renderScene(iscene core.INode, icam camera.ICamera) error {
 	r.specs.PointLightsMax = len(r.pointLights)
 	r.specs.SpotLightsMax = len(r.spotLights)
 
-	// Pre-calculate MV and MVP matrices and compile lists of opaque and transparent graphic materials
+	// Selects the skinning method of the rigged meshes before their shader programs
 	for _, gr := range r.rgraphics {
-		// Calculate MV and MVP matrices for all graphics to be rendered
-		gr.CalculateMatrices(r.gs, &r.rinfo)
+		if materials := gr.Materials(); len(materials) > 0 {
+			if rm, ok := materials[0].IGraphic().(*graphic.RiggedMesh); ok {
+				rm.SelectSkinning(r.gs)
+			}
+		}
+	}
 
+	// Renders the shadow map before the matrices of the graphics are calculated for the camera
+	if r.shadows.find(r.dirLights) {
+		if err := r.shadows.render(r); err != nil {
+			return err
+		}
+	}
+
+	// Pre-calculate MV and MVP matrices and compile lists of opaque and transparent graphic materials
+	r.runCommandWorkers(len(r.rgraphics), func(start, end int) {
+		// Calculate MV and MVP matrices for all graphics to be rendered
+		for _, gr := range r.rgraphics[start:end] {
+			gr.CalculateMatrices(r.gs, &r.rinfo)
+		}
+	})
+	queued := false // Whether a material overrides its default render queue
+	for _, gr := range r.rgraphics {
 		// Append all graphic materials of this graphic to list of graphic materials to be rendered
 		materials := gr.Materials()
 		for i := 0; i < len(materials); i++ {
-			if materials[i].IMaterial().GetMaterial().Transparent() {
+			mat := materials[i].IMaterial().GetMaterial()
+			queue := mat.RenderQueue()
+			if queue > material.QueueGeometryLast {
 				r.grmatsTransp = append(r.grmatsTransp, &materials[i])
+				queued = queued || queue != material.QueueTransparent
 			} else {
 				r.grmatsOpaque = append(r.grmatsOpaque, &materials[i])
+				queued = queued || queue != material.QueueOpaque
 			}
 		}
 	}
 
-	// TODO: If both GraphicMaterials belong to same Graphic we might want to keep their relative order...
-
-	// Z-sort graphic materials (opaque front-to-back and transparent back-to-front)
+	// Z-sort graphic materials (opaque front-to-back and transparent back-to-front).
+	// The sort is stable so that the graphic materials with the same render order and depth,
+	// such as coplanar transparent surfaces, keep the scene order and don't flicker.
 	if r.sortObjects {
 		// Internal function to render a list of graphic materials
 		var zSortGraphicMaterials func(grmats []*graphic.GraphicMaterial, backToFront bool)
 		zSortGraphicMaterials = func(grmats []*graphic.GraphicMaterial, backToFront bool) {
-			sort.Slice(grmats, func(i, j int) bool {
+			sort.SliceStable(grmats, func(i, j int) bool {
+				q1 := grmats[i].IMaterial().GetMaterial().RenderQueue()
+				q2 := grmats[j].IMaterial().GetMaterial().RenderQueue()
+				if q1 != q2 {
+					return q1 < q2
+				}
 				gr1 := grmats[i].IGraphic().GetGraphic()
 				gr2 := grmats[j].IGraphic().GetGraphic()
 
@@ -276,14 +591,17 @@ func (r *Renderer) renderScene(iscene core.INode, icam camera.ICamera) error {
 					return rO1 < rO2
 				}
 
-				mvm1 := gr1.ModelViewMatrix()
-				mvm2 := gr2.ModelViewMatrix()
-				g1pos := gr1.Position()
-				g2pos := gr2.Position()
-				g1pos.ApplyMatrix4(mvm1)
-				g2pos.ApplyMatrix4(mvm2)
+				// Compares the view depths of the graphics sort centers
+				g1pos := gr1.SortCenter()
+				g2pos := gr2.SortCenter()
+				g1pos.ApplyMatrix4(gr1.ModelViewMatrix())
+				g2pos.ApplyMatrix4(gr2.ModelViewMatrix())
 
 				if backToFront {
+					// The camera is at the origin of the view space
+					if r.transpSort == SortByDistance {
+						return g1pos.LengthSq() > g2pos.LengthSq()
+					}
 					return g1pos.Z < g2pos.Z
 				}
 
@@ -293,6 +611,23 @@ func (r *Renderer) renderScene(iscene core.INode, icam camera.ICamera) error {
 
 		zSortGraphicMaterials(r.grmatsOpaque, false) // Sort opaque graphics front to back
 		zSortGraphicMaterials(r.grmatsTransp, true)  // Sort transparent graphics back to front
+	} else if queued {
+		sortRenderQueues(r.grmatsOpaque)
+		sortRenderQueues(r.grmatsTransp)
+	}
+
+	// Append the bounding boxes lines after the opaque graphics
+	if r.showBounds && r.updateBounds() {
+		r.bounds.CalculateMatrices(r.gs, &r.rinfo)
+		r.grmatsOpaque = append(r.grmatsOpaque, &r.bounds.Materials()[0])
+	}
+
+	// Prepares the render commands of the graphic materials
+	r.cmdsOpaque = r.prepareCommands(r.cmdsOpaque, r.grmatsOpaque)
+	r.cmdsTransp = r.prepareCommands(r.cmdsTransp, r.grmatsTransp)
+	r.cmdsOIT = r.cmdsOIT[0:0]
+	if r.transpMode == TransparencyWeightedBlended && r.gs.GL3Supported() {
+		r.cmdsTransp, r.cmdsOIT = r.oit.split(r.cmdsTransp, r.cmdsOIT)
 	}
 
 	// Render other nodes (audio players, etc)
@@ -308,69 +643,483 @@ func (r *Renderer) renderScene(iscene core.INode, icam camera.ICamera) error {
 	// If there is graphic material to render or there was in the previous frame
 	// it is necessary to clear the screen.
 	if len(r.grmatsOpaque) > 0 || len(r.grmatsTransp) > 0 || r.prevStats.Graphics > 0 {
-		// Clears the area inside the current scissor
-		r.gs.Clear(gls.DEPTH_BUFFER_BIT | gls.STENCIL_BUFFER_BIT | gls.COLOR_BUFFER_BIT)
+		// Clears the area inside the current scissor unless rendering scene views
+		if !r.noClear {
+			mask := uint(gls.DEPTH_BUFFER_BIT | gls.STENCIL_BUFFER_BIT | gls.COLOR_BUFFER_BIT)
+			if r.env != nil {
+				if r.env.ClearColor && !r.skyCovers(iscene) {
+					bg := &r.env.Background
+					if cr, cg, cb, ca := r.gs.GetClearColor(); cr != bg.R || cg != bg.G || cb != bg.B || ca != bg.A {
+						r.gs.ClearColor(bg.R, bg.G, bg.B, bg.A)
+					}
+				} else {
+					mask &^= gls.COLOR_BUFFER_BIT
+				}
+			}
+			r.gs.Clear(mask)
+		}
 		r.rendered = true
 	}
 
 	err := error(nil)
+	var lightsProg *gls.Program // Program which last received the lights uniforms
+	softDepth := false          // Whether the soft particles depth is rendered
 
-	// Internal function to render a list of graphic materials
-	var renderGraphicMaterials func(grmats []*graphic.GraphicMaterial)
-	renderGraphicMaterials = func(grmats []*graphic.GraphicMaterial) {
-		// For each *GraphicMaterial
-		for _, grmat := range grmats {
+	// Internal function to submit a list of render commands
+	var renderGraphicMaterials func(cmds []renderCommand, pass string)
+	renderGraphicMaterials = func(cmds []renderCommand, pass string) {
+		// For each prepared *GraphicMaterial
+		for i := range cmds {
+			grmat := cmds[i].grmat
 			mat := grmat.IMaterial().GetMaterial()
-			geom := grmat.IGraphic().GetGeometry()
-			gr := grmat.IGraphic().GetGraphic()
-
-			// Add defines from material and geometry
-			r.specs.Defines = *gls.NewShaderDefines()
-			r.specs.Defines.Add(&mat.ShaderDefines)
-			r.specs.Defines.Add(&geom.ShaderDefines)
-			r.specs.Defines.Add(&gr.ShaderDefines)
 
-			// Sets the shader specs for this material and sets shader program
-			r.specs.Name = mat.Shader()
-			r.specs.ShaderUnique = mat.ShaderUnique()
-			r.specs.UseLights = mat.UseLights()
-			r.specs.MatTexturesMax = mat.TextureCount()
+			// Debug views replace the materials
+			if r.debug.view != ViewNone {
+				var before gls.Stats
+				if r.capture != nil {
+					r.gs.Stats(&before)
+				}
+				err = r.debug.draw(r, grmat)
+				if err != nil {
+					return
+				}
+				if r.capture != nil {
+					r.capture.addDraw(r.gs, grmat, r.debug.specs.Name, pass, &before)
+				}
+				r.stats.Graphics++
+				continue
+			}
 
 			// Set active program and apply shader specs
-			_, err = r.shaman.SetProgram(&r.specs)
+			_, err = r.shaman.SetProgram(&cmds[i].specs)
 			if err != nil {
 				return
 			}
 
-			// Setup lights (transfer lights' uniforms)
-			for idx, l := range r.ambLights {
-				l.RenderSetup(r.gs, &r.rinfo, idx)
-				r.stats.Lights++
-			}
-			for idx, l := range r.dirLights {
-				l.RenderSetup(r.gs, &r.rinfo, idx)
-				r.stats.Lights++
-			}
-			for idx, l := range r.pointLights {
-				l.RenderSetup(r.gs, &r.rinfo, idx)
-				r.stats.Lights++
+			// Setup lights (transfer lights' uniforms) only when the program changes and
+			// once per program for this scene render, as programs keep their uniforms values.
+			if prog := r.gs.Prog(); prog != lightsProg {
+				lightsProg = prog
+				if !r.lightsSetup[prog] {
+					r.lightsSetup[prog] = true
+					r.setupLights()
+					r.setupFog()
+				}
+				// The texture bindings are not kept by the programs, so the shadow map is
+				// bound again at each program change in case its unit was used since.
+				if r.shadows.light != nil {
+					r.shadows.setup(r.gs)
+				}
 			}
-			for idx, l := range r.spotLights {
-				l.RenderSetup(r.gs, &r.rinfo, idx)
-				r.stats.Lights++
+
+			// Transfers the opaque objects depth to the soft particles
+			if fade := mat.SoftParticles(); fade > 0 && softDepth {
+				r.soft.setup(r.gs, fade)
 			}
 
 			// Render this graphic material
-			grmat.Render(r.gs, &r.rinfo)
+			var before gls.Stats
+			if r.capture != nil {
+				r.gs.Stats(&before)
+			}
+			if cmds[i].oit {
+				r.oit.draw(r.gs, grmat, &r.rinfo)
+			} else {
+				grmat.Render(r.gs, &r.rinfo)
+			}
+			if r.capture != nil {
+				r.capture.addDraw(r.gs, grmat, cmds[i].specs.Name, pass, &before)
+			}
 			r.stats.Graphics++
 		}
 	}
 
-	renderGraphicMaterials(r.grmatsOpaque) // Render opaque objects (front to back)
+	renderGraphicMaterials(r.cmdsOpaque, "opaque") // Render opaque objects (front to back)
 	if err != nil {
 		return err
 	}
-	renderGraphicMaterials(r.grmatsTransp) // Render transparent objects (back to front)
+	if r.soft.used(r.grmatsTransp) {
+		err = r.soft.renderDepth(r)
+		if err != nil {
+			return err
+		}
+		softDepth = true
+	}
+	if r.debug.view != ViewNone {
+		renderGraphicMaterials(r.cmdsOIT, "transparent") // Debug views draw all objects into the framebuffer
+		if err != nil {
+			return err
+		}
+	} else if len(r.cmdsOIT) > 0 {
+		err = r.oit.begin(r)
+		if err != nil {
+			return err
+		}
+		renderGraphicMaterials(r.cmdsOIT, "oit") // Accumulate order independent transparent objects
+		if err != nil {
+			r.oit.target.Unbind()
+			return err
+		}
+		err = r.oit.end(r)
+		if err != nil {
+			return err
+		}
+	}
+	renderGraphicMaterials(r.cmdsTransp, "transparent") // Render transparent objects (back to front)
 
 	return err
 }
+
+// skyCovers returns whether the specified scene has a visible sky covering
+// the whole background, unless the clear is forced by its environment.
+func (r *Renderer) skyCovers(iscene core.INode) bool {
+
+	s, ok := iscene.(*core.Scene)
+	if !ok || r.env.ForceClear {
+		return false
+	}
+	sky, ok := s.Sky().(core.ISky)
+	return ok && sky.GetNode().Visible() && sky.CoversBackground()
+}
+
+// sortRenderQueues sorts the specified graphic materials by render queue, keeping
+// the order of the graphic materials of the same queue.
+func sortRenderQueues(grmats []*graphic.GraphicMaterial) {
+
+	sort.SliceStable(grmats, func(i, j int) bool {
+		return grmats[i].IMaterial().GetMaterial().RenderQueue() < grmats[j].IMaterial().GetMaterial().RenderQueue()
+	})
+}
+
+// renderOpaqueDepth renders the depth of the opaque graphics of the last classified
+// scene to the bound framebuffer with the program of the specified specs.
+func (r *Renderer) renderOpaqueDepth(specs *ShaderSpecs) error {
+
+	_, err := r.shaman.SetProgram(specs)
+	if err != nil {
+		return err
+	}
+	gs := r.gs
+	gs.Disable(gls.BLEND)
+	gs.Enable(gls.DEPTH_TEST)
+	gs.DepthFunc(gls.LEQUAL)
+	gs.DepthMask(true)
+	gs.SetSideView(gls.DoubleSide)
+	for _, grmat := range r.grmatsOpaque {
+		grmat.Draw(gs, &r.rinfo)
+	}
+	return nil
+}
+
+// setupFog transfers the fog of the scene environment to the current program.
+func (r *Renderer) setupFog() {
+
+	if r.env == nil || r.env.Fog.Mode == core.FogNone {
+		return
+	}
+	fog := &r.env.Fog
+	data := [8]float32{fog.Color.R, fog.Color.G, fog.Color.B, float32(fog.Mode), fog.Near, fog.Far, fog.Density, 0}
+	r.gs.Uniform4fv(r.uniFog.Location(r.gs), 2, data[:])
+}
+
+// prepareCommands returns the specified commands slice filled with the render commands
+// of the specified graphic materials, prepared by the command workers.
+func (r *Renderer) prepareCommands(cmds []renderCommand, grmats []*graphic.GraphicMaterial) []renderCommand {
+
+	cmds = cmds[0:0]
+	for _, grmat := range grmats {
+		cmds = append(cmds, renderCommand{grmat: grmat})
+	}
+	r.runCommandWorkers(len(cmds), func(start, end int) {
+		for i := start; i < end; i++ {
+			r.prepareCommand(&cmds[i])
+		}
+	})
+	return cmds
+}
+
+// prepareCommand sets the shader specs of the specified render command
+// from the lights of the scene and the defines of its graphic material.
+func (r *Renderer) prepareCommand(cmd *renderCommand) {
+
+	mat := cmd.grmat.IMaterial().GetMaterial()
+	geom := cmd.grmat.IGraphic().GetGeometry()
+	gr := cmd.grmat.IGraphic().GetGraphic()
+
+	// Add defines from material and geometry
+	cmd.specs = r.specs
+	cmd.specs.Defines = *gls.NewShaderDefines()
+	cmd.specs.Defines.Add(&mat.ShaderDefines)
+	cmd.specs.Defines.Add(&geom.ShaderDefines)
+	cmd.specs.Defines.Add(&gr.ShaderDefines)
+
+	// Add the fog of the scene environment
+	if r.env != nil && r.env.Fog.Mode != core.FogNone {
+		cmd.specs.Defines.Set("FOG", "")
+	}
+
+	// Add the shadows of the first directional light casting shadows
+	if r.shadows.light != nil && mat.UseLights()&material.UseLightDirectional != 0 {
+		r.shadows.defines(&cmd.specs.Defines)
+	}
+
+	// Sets the shader specs for this material
+	cmd.specs.Name = mat.Shader()
+	cmd.specs.ShaderUnique = mat.ShaderUnique()
+	cmd.specs.UseLights = mat.UseLights()
+	cmd.specs.MatTexturesMax = mat.MatTextureCount()
+}
+
+// runCommandWorkers calls the specified function for ranges of the specified count
+// of items, split among the command workers if enabled and there are enough items.
+func (r *Renderer) runCommandWorkers(count int, fn func(start, end int)) {
+
+	workers := r.cmdWorkers
+	if workers <= 1 || count < 2*workers {
+		fn(0, count)
+		return
+	}
+	var wg sync.WaitGroup
+	chunk := (count + workers - 1) / workers
+	for start := 0; start < count; start += chunk {
+		end := start + chunk
+		if end > count {
+			end = count
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			fn(start, end)
+		}(start, end)
+	}
+	wg.Wait()
+}
+
+// setupLights transfers the uniforms of all the scene lights to the current program.
+func (r *Renderer) setupLights() {
+
+	for idx, l := range r.ambLights {
+		l.RenderSetup(r.gs, &r.rinfo, idx)
+		r.stats.Lights++
+	}
+	for idx, l := range r.dirLights {
+		l.RenderSetup(r.gs, &r.rinfo, idx)
+		r.stats.Lights++
+	}
+	for idx, l := range r.pointLights {
+		l.RenderSetup(r.gs, &r.rinfo, idx)
+		r.stats.Lights++
+	}
+	for idx, l := range r.spotLights {
+		l.RenderSetup(r.gs, &r.rinfo, idx)
+		r.stats.Lights++
+	}
+}
+
+// Colors of the bounding boxes of rendered and culled graphics
+var (
+	boundsColor       = math32.Color{0, 1, 0}
+	boundsCulledColor = math32.Color{1, 0, 0}
+)
+
+// updateBounds updates the lines of the world space bounding boxes
+// of the rendered and culled graphics and returns if there are any.
+func (r *Renderer) updateBounds() bool {
+
+	if r.bounds == nil {
+		geom := geometry.NewGeometry()
+		vpos := gls.NewVBO(math32.NewArrayF32(0, 0)).AddAttrib(gls.VertexPosition)
+		vpos.SetUsage(gls.DYNAMIC_DRAW)
+		vcol := gls.NewVBO(math32.NewArrayF32(0, 0)).AddAttrib(gls.VertexColor)
+		vcol.SetUsage(gls.DYNAMIC_DRAW)
+		geom.AddVBO(vpos)
+		geom.AddVBO(vcol)
+		r.bounds = graphic.NewLines(geom, material.NewBasic())
+	}
+
+	geom := r.bounds.GetGeometry()
+	vpos := geom.VBO(gls.VertexPosition)
+	vcol := geom.VBO(gls.VertexColor)
+	positions := vpos.Buffer()
+	colors := vcol.Buffer()
+	*positions = (*positions)[:0]
+	*colors = (*colors)[:0]
+	appendBounds := func(grs []*graphic.Graphic, color *math32.Color) {
+		for _, gr := range grs {
+			box := gr.GetGeometry().BoundingBox()
+			mw := gr.MatrixWorld()
+			box.ApplyMatrix4(&mw)
+			appendBoxLines(positions, colors, &box, color)
+		}
+	}
+	appendBounds(r.rgraphics, &boundsColor)
+	appendBounds(r.cgraphics, &boundsCulledColor)
+	vpos.Update()
+	vcol.Update()
+	return len(*positions) > 0
+}
+
+// appendBoxLines appends to the specified arrays the positions and colors
+// of the vertices of the 12 lines of the edges of the specified box.
+func appendBoxLines(positions, colors *math32.ArrayF32, box *math32.Box3, color *math32.Color) {
+
+	min, max := &box.Min, &box.Max
+	corners := [8]math32.Vector3{
+		{min.X, min.Y, min.Z}, {max.X, min.Y, min.Z}, {max.X, max.Y, min.Z}, {min.X, max.Y, min.Z},
+		{min.X, min.Y, max.Z}, {max.X, min.Y, max.Z}, {max.X, max.Y, max.Z}, {min.X, max.Y, max.Z},
+	}
+	edges := [24]int{0, 1, 1, 2, 2, 3, 3, 0, 4, 5, 5, 6, 6, 7, 7, 4, 0, 4, 1, 5, 2, 6, 3, 7}
+	for _, i := range edges {
+		positions.AppendVector3(&corners[i])
+		colors.AppendColor(color)
+	}
+}
+
+// classifyScene sorts the visible nodes of the specified scene into the lights,
+// others, rendered and culled graphics lists, using the specified camera
+// projection and view matrix for frustum culling.
+func (r *Renderer) classifyScene(scene *core.Node, proj *math32.Matrix4) {
+
+	// Clear scene arrays
+	r.ambLights = r.ambLights[0:0]
+	r.dirLights = r.dirLights[0:0]
+	r.pointLights = r.pointLights[0:0]
+	r.spotLights = r.spotLights[0:0]
+	r.others = r.others[0:0]
+	r.rgraphics = r.rgraphics[0:0]
+	r.cgraphics = r.cgraphics[0:0]
+	r.vgraphics = r.vgraphics[0:0]
+
+	// Cached culling results are invalid if the camera changed
+	if r.cullCaching && *proj != r.cullProj {
+		r.cullProj = *proj
+		for gr := range r.cullCache {
+			delete(r.cullCache, gr)
+		}
+	}
+
+	// Internal function to classify a node and its children
+	var classifyNode func(inode core.INode)
+	classifyNode = func(inode core.INode) {
+
+		// If node not visible, ignore
+		node := inode.GetNode()
+		if !node.Visible() {
+			return
+		}
+
+		// Checks if node is a Graphic
+		igr, ok := inode.(graphic.IGraphic)
+		if ok {
+			if igr.Renderable() {
+				// Append graphic to list of graphics to be culled.
+				// The bounding box is lazily calculated so it must not be done concurrently.
+				cgr := cullGraphic{gr: igr.GetGraphic(), cullable: igr.Cullable()}
+				if cgr.cullable {
+					cgr.box = igr.GetGeometry().BoundingBox()
+				}
+				r.vgraphics = append(r.vgraphics, cgr)
+			}
+			// Node is not a Graphic
+		} else {
+			// Checks if node is a Light
+			il, ok := inode.(light.ILight)
+			if ok {
+				switch l := il.(type) {
+				case *light.Ambient:
+					r.ambLights = append(r.ambLights, l)
+				case *light.Directional:
+					r.dirLights = append(r.dirLights, l)
+				case *light.Point:
+					r.pointLights = append(r.pointLights, l)
+				case *light.Spot:
+					r.spotLights = append(r.spotLights, l)
+				default:
+					panic("Invalid light type")
+				}
+				// Other nodes
+			} else {
+				r.others = append(r.others, inode)
+			}
+		}
+
+		// Classify node children
+		for _, ichild := range node.Children() {
+			classifyNode(ichild)
+		}
+	}
+
+	// Classify all scene nodes
+	classifyNode(scene)
+
+	// Frustum culling, split among the culling workers if enabled
+	frustum := math32.NewFrustumFromMatrix(proj)
+	count := len(r.vgraphics)
+	workers := r.cullWorkers
+	switch {
+	case r.gpuCulling && r.gpuCuller.cull(r, r.vgraphics, frustum):
+		// Culled by the GPU
+	case workers > 1 && count >= 2*workers:
+		var wg sync.WaitGroup
+		chunk := (count + workers - 1) / workers
+		for start := 0; start < count; start += chunk {
+			end := start + chunk
+			if end > count {
+				end = count
+			}
+			wg.Add(1)
+			go func(cgrs []cullGraphic) {
+				defer wg.Done()
+				r.cullGraphics(cgrs, frustum)
+			}(r.vgraphics[start:end])
+		}
+		wg.Wait()
+	default:
+		r.cullGraphics(r.vgraphics, frustum)
+	}
+
+	// Append graphics to the lists of rendered and culled graphics in the scene order
+	for i := range r.vgraphics {
+		cgr := &r.vgraphics[i]
+		if cgr.result.visible {
+			r.rgraphics = append(r.rgraphics, cgr.gr)
+		} else {
+			r.cgraphics = append(r.cgraphics, cgr.gr)
+		}
+		if r.cullCaching && cgr.cullable {
+			r.cullNext[cgr.gr] = cgr.result
+		}
+	}
+
+	// Keeps only the culling results of the graphics of this scene render
+	if r.cullCaching {
+		for gr := range r.cullCache {
+			delete(r.cullCache, gr)
+		}
+		r.cullCache, r.cullNext = r.cullNext, r.cullCache
+	}
+}
+
+// cullGraphics sets the frustum culling result of the specified graphics.
+// If culling caching is enabled, the last result is reused if the graphic did not change.
+// It only reads the renderer state so it can be called concurrently.
+func (r *Renderer) cullGraphics(cgrs []cullGraphic, frustum *math32.Frustum) {
+
+	for i := range cgrs {
+		cgr := &cgrs[i]
+		if !cgr.cullable {
+			cgr.result.visible = true
+			continue
+		}
+		mw := cgr.gr.MatrixWorld()
+		if r.cullCaching {
+			if res, ok := r.cullCache[cgr.gr]; ok && res.matrixWorld == mw && res.box == cgr.box {
+				cgr.result = res
+				continue
+			}
+		}
+		wbb := cgr.box
+		wbb.ApplyMatrix4(&mw)
+		cgr.result = cullResult{mw, cgr.box, frustum.IntersectsBox(&wbb)}
+	}
+}
@@ -0,0 +1,1131 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/thommil/tge-g3n/camera"
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/geometry"
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/graphic"
+	"github.com/thommil/tge-g3n/light"
+	"github.com/thommil/tge-g3n/material"
+	"github.com/thommil/tge-g3n/math32"
+	"github.com/thommil/tge-g3n/texture"
+)
+
+// newTestRenderer returns a renderer with the default shaders on the specified backend.
+func newTestRenderer(tb testing.TB, backend gls.GL) *Renderer {
+
+	tb.Helper()
+	gs, err := gls.NewWithBackend(backend)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	r := NewRenderer(gs)
+	err = r.AddDefaultShaders()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return r
+}
+
+// newTestBox returns a unit box mesh with the specified material
+// or with a white standard material if nil.
+func newTestBox(mat material.IMaterial) *graphic.Mesh {
+
+	if mat == nil {
+		mat = material.NewStandard(&math32.Color{1, 1, 1})
+	}
+	return graphic.NewMesh(geometry.NewBox(1, 1, 1), mat)
+}
+
+// renderTestScene sets the scene of the specified renderer and renders
+// it once with a default perspective camera.
+func renderTestScene(tb testing.TB, r *Renderer, scene core.INode) {
+
+	tb.Helper()
+	r.SetScene(scene)
+	if _, err := r.Render(camera.NewPerspective(60, 1, 0.1, 100)); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// Benchmark rendering a scene with many materials and lights, recording the OpenGL calls.
+func BenchmarkRenderMaterialsLights(b *testing.B) {
+
+	rec := gls.NewRecorder()
+	r := newTestRenderer(b, rec)
+
+	scene := core.NewNode()
+	scene.Add(light.NewAmbient(&math32.Color{1, 1, 1}, 0.2))
+	for i := 0; i < 4; i++ {
+		l := light.NewPoint(&math32.Color{1, 1, 1}, 1)
+		l.SetPosition(float32(i)*4-6, 5, 0)
+		scene.Add(l)
+	}
+	for i := 0; i < 2; i++ {
+		l := light.NewDirectional(&math32.Color{1, 1, 1}, 0.5)
+		l.SetPosition(float32(i), 1, 1)
+		scene.Add(l)
+	}
+	geom := geometry.NewBox(1, 1, 1)
+	for i := 0; i < 256; i++ {
+		var mat material.IMaterial
+		color := &math32.Color{float32(i%16) / 16, float32(i/16) / 16, 0.5}
+		if i%2 == 0 {
+			mat = material.NewStandard(color)
+		} else {
+			mat = material.NewPhong(color)
+		}
+		mesh := graphic.NewMesh(geom.Incref(), mat)
+		mesh.SetPosition(float32(i%16)-8, float32(i/16)-8, -20)
+		scene.Add(mesh)
+	}
+	cam := camera.NewPerspective(60, 1, 0.1, 100)
+	r.SetScene(scene)
+
+	var before, after gls.Stats
+	r.gs.Stats(&before)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rec.Reset()
+		if _, err := r.Render(cam); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	r.gs.Stats(&after)
+	b.ReportMetric(float64(after.Unisets-before.Unisets)/float64(b.N), "unisets/op")
+	b.ReportMetric(float64(r.Stats().Lights), "lights/op")
+}
+
+// Test that the lights are set up once per program and not once per material.
+func TestRenderLightsSetup(t *testing.T) {
+
+	rec := gls.NewRecorder()
+	r := newTestRenderer(t, rec)
+
+	const nlights = 3
+	const nmeshes = 20
+	scene := core.NewNode()
+	for i := 0; i < nlights; i++ {
+		scene.Add(light.NewPoint(&math32.Color{1, 1, 1}, 1))
+	}
+	geom := geometry.NewBox(1, 1, 1)
+	for i := 0; i < nmeshes; i++ {
+		var mat material.IMaterial = material.NewStandard(&math32.Color{1, 0, 0})
+		if i%2 == 1 {
+			mat = material.NewPhong(&math32.Color{0, 1, 0})
+		}
+		mesh := graphic.NewMesh(geom.Incref(), mat)
+		mesh.SetPosition((float32(i)-nmeshes/2)/2, 0, -20)
+		scene.Add(mesh)
+	}
+	cam := camera.NewPerspective(60, 1, 0.1, 100)
+	r.SetScene(scene)
+
+	// Two programs (standard and phong) receive the lights at each frame
+	for frame := 0; frame < 2; frame++ {
+		rec.Reset()
+		if _, err := r.Render(cam); err != nil {
+			t.Fatal(err)
+		}
+		stats := r.Stats()
+		if stats.Graphics != nmeshes {
+			t.Errorf("frame %d: expected %d graphics got %d", frame, nmeshes, stats.Graphics)
+		}
+		if stats.Lights != 2*nlights {
+			t.Errorf("frame %d: expected %d lights setups got %d", frame, 2*nlights, stats.Lights)
+		}
+		// Each point light transfers its 3 vectors (color, position and attenuation) once per program
+		uploads := 0
+		for _, c := range rec.Calls() {
+			if c.Name == "Uniform3fvUP" && fmt.Sprint(c.Args[1]) == "3" {
+				uploads++
+			}
+		}
+		if uploads != 2*nlights {
+			t.Errorf("frame %d: expected %d point light uniforms transfers got %d", frame, 2*nlights, uploads)
+		}
+	}
+
+	// The programs not used anymore are forgotten
+	renderTestScene(t, r, core.NewNode())
+	if len(r.lightsSetup) != 0 {
+		t.Errorf("expected no programs with lights setup got %d", len(r.lightsSetup))
+	}
+}
+
+// newCullingScene returns a scene with the specified number of meshes spread
+// around the origin and the projection and view matrix of a camera looking at it.
+func newCullingScene(count int) (*core.Node, *math32.Matrix4) {
+
+	scene := core.NewNode()
+	geom := geometry.NewBox(1, 1, 1)
+	mat := material.NewStandard(&math32.Color{1, 1, 1})
+	for i := 0; i < count; i++ {
+		group := core.NewNode()
+		group.SetPosition(float32(i%100)-50, float32(i/100%100)-50, -10-float32(i/10000)*10)
+		group.Add(graphic.NewMesh(geom, mat))
+		scene.Add(group)
+	}
+	scene.UpdateMatrixWorld()
+	cam := camera.NewPerspective(60, 1, 0.1, 100)
+	var view, proj, pv math32.Matrix4
+	cam.ViewMatrix(&view)
+	cam.ProjMatrix(&proj)
+	pv.MultiplyMatrices(&proj, &view)
+	return scene, &pv
+}
+
+// Test that parallel culling gives the same graphics in the same order.
+func TestParallelCulling(t *testing.T) {
+
+	scene, pv := newCullingScene(5000)
+	r := NewRenderer(nil)
+	r.classifyScene(scene, pv)
+	expected := append([]*graphic.Graphic(nil), r.rgraphics...)
+	culled := len(r.cgraphics)
+	if len(expected) == 0 || culled == 0 {
+		t.Fatalf("expected rendered and culled graphics got %d/%d", len(expected), culled)
+	}
+	for _, workers := range []int{2, 3, 8} {
+		r.SetParallelCulling(workers)
+		r.classifyScene(scene, pv)
+		if len(r.rgraphics) != len(expected) || len(r.cgraphics) != culled {
+			t.Fatalf("workers %d: expected %d/%d got %d/%d", workers, len(expected), culled, len(r.rgraphics), len(r.cgraphics))
+		}
+		for i := range expected {
+			if r.rgraphics[i] != expected[i] {
+				t.Fatalf("workers %d: different order at %d", workers, i)
+			}
+		}
+	}
+}
+
+// Test that the culling results are reused while the camera and the graphics are unchanged
+// and that the results of the graphics removed from the scene are dropped.
+func TestCullCaching(t *testing.T) {
+
+	scene := core.NewNode()
+	geom := geometry.NewBox(1, 1, 1)
+	mesh := graphic.NewMesh(geom, material.NewStandard(&math32.Color{1, 1, 1}))
+	mesh.SetPosition(0, 0, -10)
+	scene.Add(mesh)
+	scene.UpdateMatrixWorld()
+	cam := camera.NewPerspective(60, 1, 0.1, 100)
+	projView := func() *math32.Matrix4 {
+		cam.UpdateMatrixWorld()
+		var view, proj, pv math32.Matrix4
+		cam.ViewMatrix(&view)
+		cam.ProjMatrix(&proj)
+		pv.MultiplyMatrices(&proj, &view)
+		return &pv
+	}
+	r := NewRenderer(nil)
+	r.SetCullCaching(true)
+	r.classifyScene(scene, projView())
+	if len(r.rgraphics) != 1 || len(r.cullCache) != 1 {
+		t.Fatalf("expected 1 rendered and cached graphic got %d/%d", len(r.rgraphics), len(r.cullCache))
+	}
+
+	// A cached result is detected by marking it culled while the graphic is visible
+	gr := mesh.GetGraphic()
+	reused := func() bool {
+		res := r.cullCache[gr]
+		res.visible = false
+		r.cullCache[gr] = res
+		r.classifyScene(scene, projView())
+		return len(r.rgraphics) == 0
+	}
+	if !reused() {
+		t.Errorf("expected the culling result reused without changes")
+	}
+	cam.SetPosition(0, 0.5, 0)
+	if reused() {
+		t.Errorf("expected the culling result invalidated by the camera move")
+	}
+	mesh.SetPosition(0.5, 0, -10)
+	scene.UpdateMatrixWorld()
+	if reused() {
+		t.Errorf("expected the culling result invalidated by the node move")
+	}
+	geom.OperateOnVertices(func(vertex *math32.Vector3) bool {
+		vertex.MultiplyScalar(2)
+		return false
+	})
+	if reused() {
+		t.Errorf("expected the culling result invalidated by the bounding box change")
+	}
+	scene.Remove(mesh)
+	r.classifyScene(scene, projView())
+	if len(r.cullCache) != 0 {
+		t.Errorf("expected the culling result of the removed graphic dropped got %d results", len(r.cullCache))
+	}
+}
+
+// Benchmark the classification and culling of a scene with 100k nodes
+func BenchmarkClassifyScene(b *testing.B) {
+
+	scene, pv := newCullingScene(50000)
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			r := NewRenderer(nil)
+			r.SetParallelCulling(workers)
+			for i := 0; i < b.N; i++ {
+				r.classifyScene(scene, pv)
+			}
+		})
+	}
+}
+
+// Test the round trip between world and screen coordinates.
+func TestWorldToScreen(t *testing.T) {
+
+	cam := camera.NewPerspective(60, 2, 0.1, 100)
+	cam.SetPosition(1, 2, 10)
+	cam.UpdateMatrixWorld()
+	var view, proj math32.Matrix4
+	cam.ViewMatrix(&view)
+	cam.ProjMatrix(&proj)
+	r := new(Renderer)
+	r.projView.MultiplyMatrices(&proj, &view)
+	r.viewport = [4]int32{0, 0, 800, 400}
+
+	// The point in front of the camera is at the center of the viewport
+	x, y, visible := r.WorldToScreen(math32.Vector3{X: 1, Y: 2, Z: 0})
+	if !visible || math32.Abs(x-400) > 1e-2 || math32.Abs(y-200) > 1e-2 {
+		t.Errorf("expected visible point at 400,200 got %v,%v %v", x, y, visible)
+	}
+	if _, _, visible = r.WorldToScreen(math32.Vector3{X: 1, Y: 2, Z: 20}); visible {
+		t.Error("expected point behind the camera not visible")
+	}
+
+	p := math32.Vector3{X: 2, Y: 3, Z: -5}
+	x, y, visible = r.WorldToScreen(p)
+	if !visible || x <= 400 || y >= 200 {
+		t.Errorf("expected visible point at the top right got %v,%v %v", x, y, visible)
+	}
+	// Depth of the point in the [0,1] range
+	clip := math32.Vector4{X: p.X, Y: p.Y, Z: p.Z, W: 1}
+	clip.ApplyMatrix4(&r.projView)
+	depth := (clip.Z/clip.W + 1) / 2
+	back := r.ScreenToWorld(x, y, depth)
+	if back.DistanceTo(&p) > 1e-2 {
+		t.Errorf("expected %v got %v", p, back)
+	}
+}
+
+// newMaterialsScene returns a renderer on the specified backend with a scene
+// with the specified number of meshes, each with its own material.
+func newMaterialsScene(tb testing.TB, backend gls.GL, count int) (*Renderer, camera.ICamera) {
+
+	r := newTestRenderer(tb, backend)
+	scene := core.NewNode()
+	scene.Add(light.NewAmbient(&math32.Color{1, 1, 1}, 0.2))
+	scene.Add(light.NewPoint(&math32.Color{1, 1, 1}, 1))
+	geom := geometry.NewBox(1, 1, 1)
+	for i := 0; i < count; i++ {
+		color := &math32.Color{float32(i%16) / 16, float32(i/16%16) / 16, 0.5}
+		mat := material.NewStandard(color)
+		if i%3 == 0 {
+			mat.SetTransparent(true)
+			mat.SetOpacity(0.5)
+		}
+		mesh := graphic.NewMesh(geom.Incref(), mat)
+		mesh.SetPosition(float32(i%32)-16, float32(i/32%32)-16, -30)
+		scene.Add(mesh)
+	}
+	r.SetScene(scene)
+	return r, camera.NewPerspective(60, 1, 0.1, 100)
+}
+
+// Test that the command workers submit the same OpenGL calls as a single worker.
+func TestCommandWorkers(t *testing.T) {
+
+	var expected []string
+	for _, workers := range []int{1, 2, 4, 8} {
+		rec := gls.NewRecorder()
+		r, cam := newMaterialsScene(t, rec, 200)
+		r.SetCommandWorkers(workers)
+		if _, err := r.Render(cam); err != nil {
+			t.Fatal(err)
+		}
+		if workers == 1 {
+			expected = rec.Names()
+			continue
+		}
+		names := rec.Names()
+		if len(names) != len(expected) {
+			t.Fatalf("workers %d: expected %d calls got %d", workers, len(expected), len(names))
+		}
+		for i := range expected {
+			if names[i] != expected[i] {
+				t.Fatalf("workers %d: expected %s at %d got %s", workers, expected[i], i, names[i])
+			}
+		}
+	}
+}
+
+// Benchmark rendering a scene with many materials with serial and parallel
+// preparation of the render commands, recording the OpenGL calls.
+func BenchmarkCommandWorkers(b *testing.B) {
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			rec := gls.NewRecorder()
+			r, cam := newMaterialsScene(b, rec, 4096)
+			r.SetCommandWorkers(workers)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				rec.Reset()
+				if _, err := r.Render(cam); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// Test that the environment of a scene sets the clear color, the ambient light and the fog.
+func TestSceneEnvironment(t *testing.T) {
+
+	rec := gls.NewRecorder()
+	r := newTestRenderer(t, rec)
+	scene := core.NewScene()
+	env := scene.Environment()
+	env.Background = math32.Color4{0.5, 0.25, 0, 1}
+	env.AmbientIntensity = 0.5
+	env.Fog.Mode = core.FogLinear
+	scene.Add(newTestBox(nil))
+	renderTestScene(t, r, scene)
+	found := map[string]bool{}
+	for _, c := range rec.Calls() {
+		found[c.String()] = true
+	}
+	for _, call := range []string{"ClearColor(0.5, 0.25, 0, 1)", "GetUniformLocation(1, Fog)"} {
+		if !found[call] {
+			t.Errorf("expected call %s", call)
+		}
+	}
+	if r.Stats().Lights != 1 {
+		t.Errorf("expected the environment ambient light got %d lights", r.Stats().Lights)
+	}
+}
+
+// Test that GPU culling falls back to CPU culling without transform
+// feedback support and otherwise culls the graphics in a feedback pass.
+func TestGPUCulling(t *testing.T) {
+
+	scene, pv := newCullingScene(1000)
+	rec := gls.NewRecorder()
+	gs, err := gls.NewWithBackend(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRenderer(gs)
+	r.classifyScene(scene, pv)
+	rendered := len(r.rgraphics)
+
+	// Transform feedback is not supported without OpenGL 3
+	r.SetGPUCulling(true)
+	rec.Reset()
+	r.classifyScene(scene, pv)
+	if len(r.rgraphics) != rendered || len(rec.Calls()) != 2 {
+		t.Fatalf("expected CPU culling got %d graphics and calls %v", len(r.rgraphics), rec)
+	}
+
+	// The recorder reads back zero results, which cull all the graphics
+	gs, err = gls.NewWithBackend(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.SetInteger(gls.MAJOR_VERSION, 3)
+	r = NewRenderer(gs)
+	r.SetGPUCulling(true)
+	rec.Reset()
+	r.classifyScene(scene, pv)
+	if len(r.rgraphics) != 0 || len(r.cgraphics) != 1000 {
+		t.Fatalf("expected GPU culling got %d/%d", len(r.rgraphics), len(r.cgraphics))
+	}
+	found := map[string]bool{}
+	for _, c := range rec.Calls() {
+		found[c.Name] = true
+	}
+	for _, name := range []string{"TransformFeedbackVaryings", "BeginTransformFeedback", "DrawArrays", "EndTransformFeedback", "GetBufferSubData"} {
+		if !found[name] {
+			t.Errorf("expected call %s", name)
+		}
+	}
+}
+
+// Test that transparent graphic materials at the same depth keep the scene order.
+func TestStableSort(t *testing.T) {
+
+	r := newTestRenderer(t, gls.NewRecorder())
+	scene := core.NewNode()
+	geom := geometry.NewPlane(1, 1, 1, 1)
+	var far, near []*graphic.Mesh
+	for i := 0; i < 100; i++ {
+		mat := material.NewStandard(&math32.Color{1, 1, 1})
+		mat.SetTransparent(true)
+		mesh := graphic.NewMesh(geom.Incref(), mat)
+		if i%3 == 0 {
+			mesh.SetPosition(0, 0, -6)
+			far = append(far, mesh)
+		} else {
+			mesh.SetPosition(0, 0, -5)
+			near = append(near, mesh)
+		}
+		scene.Add(mesh)
+	}
+	meshes := append(far, near...)
+	r.SetScene(scene)
+	cam := camera.NewPerspective(60, 1, 0.1, 100)
+	for frame := 0; frame < 3; frame++ {
+		if _, err := r.Render(cam); err != nil {
+			t.Fatal(err)
+		}
+		for i, grmat := range r.grmatsTransp {
+			if grmat.IGraphic().GetGraphic() != meshes[i].GetGraphic() {
+				t.Fatalf("frame %d: different order at %d", frame, i)
+			}
+		}
+	}
+}
+
+func TestShadows(t *testing.T) {
+
+	filters := []struct {
+		filter  light.ShadowFilter
+		define  string
+		targets int
+	}{
+		{light.ShadowFilterNone, "#define SHADOWS", 1},
+		{light.ShadowFilterPCF, "#define SHADOW_PCF", 1},
+		{light.ShadowFilterExponential, "#define SHADOW_EXP", 1},
+		{light.ShadowFilterVariance, "#define SHADOW_VSM", 2},
+	}
+	for _, f := range filters {
+		rec := gls.NewRecorder()
+		r := newTestRenderer(t, rec)
+		scene := core.NewNode()
+		scene.Add(light.NewDirectional(&math32.Color{1, 1, 1}, 0.5))
+		sun := light.NewDirectional(&math32.Color{1, 1, 1}, 1)
+		sun.SetPosition(1, 2, 1)
+		sun.SetCastShadow(true)
+		sun.SetShadowFilter(f.filter, 3)
+		scene.Add(sun)
+		scene.Add(newTestBox(material.NewPhong(&math32.Color{1, 1, 1})))
+		renderTestScene(t, r, scene)
+		if r.dirLights[0] != sun {
+			t.Errorf("filter %d: expected the shadow light to be the first directional light", f.filter)
+		}
+		var define, matrix bool
+		targets := 0
+		for _, c := range rec.Calls() {
+			s := c.String()
+			define = define || strings.Contains(s, f.define)
+			matrix = matrix || strings.Contains(s, "ShadowMatrix)")
+			if c.Name == "CreateFramebuffer" {
+				targets++
+			}
+		}
+		if !define || !matrix {
+			t.Errorf("filter %d: expected the shadows program and uniforms", f.filter)
+		}
+		if targets != f.targets {
+			t.Errorf("filter %d: expected %d shadow targets got %d", f.filter, f.targets, targets)
+		}
+	}
+}
+
+// Test that the shadow map is bound again when a program which already received
+// the lights uniforms is used again after another program.
+func TestShadowMapRebind(t *testing.T) {
+
+	rec := gls.NewRecorder()
+	r := newTestRenderer(t, rec)
+	scene := core.NewNode()
+	sun := light.NewDirectional(&math32.Color{1, 1, 1}, 1)
+	sun.SetPosition(1, 2, 1)
+	sun.SetCastShadow(true)
+	scene.Add(sun)
+	scene.Add(newTestBox(material.NewPhong(&math32.Color{1, 1, 1})))
+	scene.Add(newTestBox(material.NewStandard(&math32.Color{1, 1, 1})))
+	transparent := material.NewPhong(&math32.Color{1, 1, 1})
+	transparent.SetTransparent(true)
+	scene.Add(newTestBox(transparent))
+	renderTestScene(t, r, scene)
+	rec.Reset()
+	renderTestScene(t, r, scene)
+	bind := fmt.Sprintf("BindTexture(%d, %d)", gls.TEXTURE_2D, r.shadows.target.DepthTexture().TexName())
+	binds := 0
+	for _, c := range rec.Calls() {
+		if c.String() == bind {
+			binds++
+		}
+	}
+	if binds != 3 {
+		t.Errorf("expected the shadow map bound for each of the 3 program changes got %d binds", binds)
+	}
+}
+
+func TestMSAASamples(t *testing.T) {
+
+	rec := gls.NewRecorder()
+	rec.SetInteger(gls.MAX_SAMPLES, 4)
+	r := newTestRenderer(t, rec)
+	r.SetMSAASamples(8)
+	if r.MSAASamples() != 4 {
+		t.Errorf("expected the samples clamped to 4 got %d", r.MSAASamples())
+	}
+	r.SetVignette(0.5, 0.5)
+	scene := core.NewNode()
+	scene.Add(newTestBox(nil))
+	renderTestScene(t, r, scene)
+	var storage, blit bool
+	for _, c := range rec.Calls() {
+		s := c.String()
+		storage = storage || strings.HasPrefix(s, "RenderbufferStorageMultisample(36161, 4,")
+		blit = blit || c.Name == "BlitFramebuffer"
+	}
+	if !storage || !blit {
+		t.Errorf("expected the multisampled scene to be rendered and resolved")
+	}
+}
+
+// Test that the emissive color and map of the standard material are added by the shaders.
+func TestEmissive(t *testing.T) {
+
+	rec := gls.NewRecorder()
+	r := newTestRenderer(t, rec)
+	mat := material.NewStandard(&math32.Color{0, 0, 0})
+	mat.SetEmissiveColor(&math32.Color{4, 2, 1})
+	mat.SetEmissiveMap(texture.NewTexture2DFromData(1, 1, gls.RGBA, gls.UNSIGNED_BYTE, gls.RGBA8, []uint8{255, 255, 255, 255}))
+	scene := core.NewNode()
+	scene.Add(newTestBox(mat))
+	renderTestScene(t, r, scene)
+	found := false
+	for _, c := range rec.Calls() {
+		if c.Name == "GetUniformLocation" && strings.HasSuffix(c.String(), "MatEmissiveMap)") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the emissive map uniform")
+	}
+	if max := r.cmdsOpaque[0].specs.MatTexturesMax; max != 0 {
+		t.Errorf("expected no MatTexture sampler for the emissive map got %d", max)
+	}
+	mat.SetEmissiveColor(&math32.Color{0, 0, 0})
+	if _, ok := mat.ShaderDefines["EMISSIVE"]; ok {
+		t.Errorf("expected the EMISSIVE define unset without emissive color")
+	}
+}
+
+// Test that the emissive map of a standard material without other textures, such as
+// the skybox faces, doesn't use the MatTexture uniforms which are not declared.
+func TestEmissiveMapWithoutTextures(t *testing.T) {
+
+	r := newTestRenderer(t, gls.NewRecorder())
+	defines := gls.NewShaderDefines()
+	defines.Set("EMISSIVE", "")
+	defines.Set("EMISSIVE_MAP", "")
+	defines.Set("MAT_TEXTURES", "0")
+	source, err := r.PreviewShaderSource("standard_fragment", defines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conds []string
+	for i, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "#if"):
+			conds = append(conds, strings.Replace(line, " ", "", -1))
+		case strings.HasPrefix(line, "#elif"), strings.HasPrefix(line, "#else"):
+			conds[len(conds)-1] = line
+		case strings.HasPrefix(line, "#endif"):
+			conds = conds[:len(conds)-1]
+		case strings.Contains(line, "MatTexFlipY(") || strings.Contains(line, "MatTexinfo"):
+			guarded := false
+			for _, cond := range conds {
+				guarded = guarded || cond == "#ifMAT_TEXTURES>0"
+			}
+			if !guarded {
+				t.Errorf("line %d uses the MatTexture uniforms without MAT_TEXTURES > 0: %s", i+1, line)
+			}
+		}
+	}
+}
+
+// Test that the transparent graphic materials are sorted by the depth of their sort centers.
+func TestSortCenter(t *testing.T) {
+
+	r := newTestRenderer(t, gls.NewRecorder())
+	scene := core.NewNode()
+	var meshes [2]*graphic.Mesh
+	for i := range meshes {
+		mat := material.NewStandard(&math32.Color{1, 1, 1})
+		mat.SetTransparent(true)
+		meshes[i] = graphic.NewMesh(geometry.NewPlane(1, 1, 1, 1), mat)
+		meshes[i].SetPosition(0, 0, -5-float32(i))
+		scene.Add(meshes[i])
+	}
+	r.SetScene(scene)
+	cam := camera.NewPerspective(60, 1, 0.1, 100)
+	for _, first := range []int{1, 0} {
+		if _, err := r.Render(cam); err != nil {
+			t.Fatal(err)
+		}
+		if r.grmatsTransp[0].IGraphic().GetGraphic() != meshes[first].GetGraphic() {
+			t.Errorf("expected mesh %d rendered first", first)
+		}
+		// The pivot of the near mesh is offset from its visible center
+		meshes[0].GetGeometry().ApplyMatrix(math32.NewMatrix4().MakeTranslation(0, 0, -10))
+	}
+	meshes[0].SetSortCenter(&math32.Vector3{0, 0, 5})
+	if _, err := r.Render(cam); err != nil {
+		t.Fatal(err)
+	}
+	if r.grmatsTransp[0].IGraphic().GetGraphic() != meshes[1].GetGraphic() {
+		t.Errorf("expected the sort center to override the bounding box center")
+	}
+}
+
+// Test that the transparent graphic materials are sorted by view Z or by distance to the camera.
+func TestTransparencySort(t *testing.T) {
+
+	r := newTestRenderer(t, gls.NewRecorder())
+	scene := core.NewNode()
+	var meshes [2]*graphic.Mesh
+	for i := range meshes {
+		mat := material.NewStandard(&math32.Color{1, 1, 1})
+		mat.SetTransparent(true)
+		meshes[i] = graphic.NewMesh(geometry.NewPlane(1, 1, 1, 1), mat)
+		scene.Add(meshes[i])
+	}
+	meshes[0].SetPosition(0, 0, -5)
+	meshes[1].SetPosition(-8, 0, -4.5)
+	r.SetScene(scene)
+	cam := camera.NewPerspective(120, 1, 0.1, 100)
+	for first, mode := range []TransparencySort{SortByZ, SortByDistance} {
+		r.SetTransparencySort(mode)
+		if _, err := r.Render(cam); err != nil {
+			t.Fatal(err)
+		}
+		if r.grmatsTransp[0].IGraphic().GetGraphic() != meshes[first].GetGraphic() {
+			t.Errorf("sort %d: expected mesh %d rendered first", mode, first)
+		}
+	}
+}
+
+// Test that the transparent materials supporting it are accumulated by the
+// order independent transparency pass and the other ones are sorted.
+func TestWeightedBlended(t *testing.T) {
+
+	rec := gls.NewRecorder()
+	r := newTestRenderer(t, rec)
+	r.SetTransparencyMode(TransparencyWeightedBlended)
+	scene := core.NewNode()
+	for i := 0; i < 3; i++ {
+		mat := material.NewStandard(&math32.Color{1, 1, 1})
+		mat.SetTransparent(true)
+		mat.SetOpacity(0.5)
+		mesh := graphic.NewMesh(geometry.NewPlane(1, 1, 1, 1), mat)
+		mesh.SetPosition(0, 0, -5+float32(i))
+		scene.Add(mesh)
+	}
+	basic := material.NewBasic()
+	basic.SetTransparent(true)
+	sorted := graphic.NewMesh(geometry.NewPlane(1, 1, 1, 1), basic)
+	sorted.SetPosition(0, 0, -6)
+	scene.Add(sorted)
+	opaque := newTestBox(nil)
+	opaque.SetPosition(0, 0, -8)
+	scene.Add(opaque)
+	renderTestScene(t, r, scene)
+	if len(r.cmdsOIT) != 3 || len(r.cmdsTransp) != 1 {
+		t.Fatalf("expected 3 accumulated and 1 sorted commands got %d and %d", len(r.cmdsOIT), len(r.cmdsTransp))
+	}
+	found := map[string]bool{}
+	for _, c := range rec.Calls() {
+		found[c.String()] = true
+	}
+	calls := []string{
+		fmt.Sprintf("DrawBuffers([%d %d])", gls.COLOR_ATTACHMENT0, gls.COLOR_ATTACHMENT1),
+		fmt.Sprintf("BlendFuncSeparate(%d, %d, %d, %d)", gls.ONE, gls.ONE, gls.ZERO, gls.ONE_MINUS_SRC_ALPHA),
+		"ClearColor(0, 0, 0, 1)",
+	}
+	for _, call := range calls {
+		if !found[call] {
+			t.Errorf("expected call %s", call)
+		}
+	}
+}
+
+func TestCaptureFrame(t *testing.T) {
+
+	r := newTestRenderer(t, gls.NewRecorder())
+	scene := core.NewNode()
+	box := newTestBox(nil)
+	box.SetName("box")
+	box.SetPosition(0, 0, -5)
+	scene.Add(box)
+	hidden := newTestBox(nil)
+	hidden.SetPosition(0, 0, 5)
+	scene.Add(hidden)
+	r.SetScene(scene)
+	report, err := r.CaptureFrame(camera.NewPerspective(60, 1, 0.1, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Rendered != 1 || report.Culled != 1 {
+		t.Errorf("expected 1 rendered and 1 culled graphics got %d and %d", report.Rendered, report.Culled)
+	}
+	if len(report.Draws) != 1 || report.Draws[0].Graphic != "box" || report.Draws[0].Program != "standard" ||
+		report.Draws[0].Pass != "opaque" || report.Draws[0].Triangles != 12 {
+		t.Errorf("unexpected draws %+v", report.Draws)
+	}
+	if report.Triangles != 12 || report.Drawcalls != 1 {
+		t.Errorf("expected 12 triangles in 1 draw call got %d in %d", report.Triangles, report.Drawcalls)
+	}
+	if len(report.Programs) != 1 || report.Programs[0] != "standard" {
+		t.Errorf("unexpected programs %v", report.Programs)
+	}
+	draws := 0
+	for _, call := range report.Calls {
+		if strings.HasPrefix(call, "DrawElements(") {
+			draws++
+		}
+	}
+	if draws != 1 {
+		t.Errorf("expected 1 captured draw call got %d in %v", draws, report.Calls)
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"graphic":"box"`) {
+		t.Errorf("unexpected JSON %s", data)
+	}
+
+	// The capture is only active for one frame
+	_, err = r.Render(camera.NewPerspective(60, 1, 0.1, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.capture != nil || r.gs.EndCapture() != nil {
+		t.Error("expected the capture to be stopped")
+	}
+}
+
+func TestRenderQueue(t *testing.T) {
+
+	r := newTestRenderer(t, gls.NewRecorder())
+	scene := core.NewNode()
+	queues := []int{0, material.QueueBackground, material.QueueOverlay, 0}
+	var meshes [4]*graphic.Mesh
+	for i, queue := range queues {
+		mat := material.NewStandard(&math32.Color{1, 1, 1})
+		mat.SetRenderQueue(queue)
+		mat.SetTransparent(i == 3)
+		meshes[i] = graphic.NewMesh(geometry.NewPlane(1, 1, 1, 1), mat)
+		meshes[i].SetPosition(0, 0, -2-float32(i))
+		scene.Add(meshes[i])
+	}
+	r.SetScene(scene)
+	for _, sorting := range []bool{true, false} {
+		r.SetObjectSorting(sorting)
+		if _, err := r.Render(camera.NewPerspective(60, 1, 0.1, 100)); err != nil {
+			t.Fatal(err)
+		}
+		// The background is rendered before the nearer opaque mesh and the overlay after the transparent one
+		expected := [][]int{{1, 0}, {3, 2}}
+		for l, grmats := range [][]*graphic.GraphicMaterial{r.grmatsOpaque, r.grmatsTransp} {
+			if len(grmats) != len(expected[l]) {
+				t.Fatalf("sorting %v: expected %d graphic materials got %d", sorting, len(expected[l]), len(grmats))
+			}
+			for i, m := range expected[l] {
+				if grmats[i].IGraphic().GetGraphic() != meshes[m].GetGraphic() {
+					t.Errorf("sorting %v: expected mesh %d at position %d", sorting, m, i)
+				}
+			}
+		}
+	}
+}
+
+func TestSkyClear(t *testing.T) {
+
+	rec := gls.NewRecorder()
+	r := newTestRenderer(t, rec)
+	scene := core.NewScene()
+	sky := graphic.NewSkyDome(&math32.Color{0, 0, 1}, &math32.Color{1, 1, 1}, &math32.Color{0, 1, 0})
+	scene.SetSky(sky)
+	r.SetScene(scene)
+	for _, c := range []struct {
+		force   bool
+		visible bool
+		mask    uint
+	}{
+		{false, true, gls.DEPTH_BUFFER_BIT | gls.STENCIL_BUFFER_BIT},
+		{true, true, gls.DEPTH_BUFFER_BIT | gls.STENCIL_BUFFER_BIT | gls.COLOR_BUFFER_BIT},
+		{false, false, gls.DEPTH_BUFFER_BIT | gls.STENCIL_BUFFER_BIT | gls.COLOR_BUFFER_BIT},
+	} {
+		scene.Environment().ForceClear = c.force
+		sky.SetVisible(c.visible)
+		rec.Reset()
+		if _, err := r.Render(camera.NewPerspective(60, 1, 0.1, 100)); err != nil {
+			t.Fatal(err)
+		}
+		expected := fmt.Sprintf("Clear(%d)", c.mask)
+		found := false
+		for _, call := range rec.Calls() {
+			found = found || call.String() == expected
+		}
+		if !found {
+			t.Errorf("force %v visible %v: expected %s", c.force, c.visible, expected)
+		}
+	}
+}
+
+// Test that each render method deletes the OpenGL objects queued for deletion.
+func TestRenderPendingDeletes(t *testing.T) {
+
+	r := newTestRenderer(t, gls.NewRecorder())
+	scene := core.NewNode()
+	scene.Add(newTestBox(nil))
+	r.SetScene(scene)
+	cam := camera.NewPerspective(60, 1, 0.1, 100)
+	for _, c := range []struct {
+		name   string
+		render func() (bool, error)
+	}{
+		{"Render", func() (bool, error) { return r.Render(cam) }},
+		{"RenderScenes", func() (bool, error) { return r.RenderScenes([]SceneView{{Scene: scene, Camera: cam}}) }},
+		{"RenderStereo", func() (bool, error) { return r.RenderStereo(cam, 0.1) }},
+	} {
+		r.gs.QueueDeleteTextures(r.gs.GenTexture())
+		if _, err := c.render(); err != nil {
+			t.Fatal(err)
+		}
+		if n := r.gs.PendingDeletes(); n != 0 {
+			t.Errorf("%s: expected no pending deletes got %d", c.name, n)
+		}
+	}
+}
+
+// Test that a stereo render draws both eyes side by side and culls with both eyes frustums.
+func TestRenderStereo(t *testing.T) {
+
+	rec := gls.NewRecorder()
+	r := newTestRenderer(t, rec)
+	r.gs.Viewport(0, 0, 800, 400)
+	scene := core.NewNode()
+	mesh := graphic.NewMesh(geometry.NewBox(0.2, 0.2, 0.2), material.NewStandard(&math32.Color{1, 1, 1}))
+	scene.Add(mesh)
+	r.SetScene(scene)
+	cam := camera.NewPerspective(60, 2, 0.1, 100)
+
+	// Each eye sees from -5.77 to 5.77 around its position at 10 units
+	for _, c := range []struct {
+		x        float32
+		graphics int
+	}{
+		{0, 2},
+		{6.3, 2}, // Only visible to the right eye
+		{7.5, 0},
+	} {
+		mesh.SetPosition(c.x, 0, -10)
+		rec.Reset()
+		if _, err := r.RenderStereo(cam, 2); err != nil {
+			t.Fatal(err)
+		}
+		if r.Stats().Graphics != c.graphics {
+			t.Errorf("x %v: expected %d graphics got %d", c.x, c.graphics, r.Stats().Graphics)
+		}
+		var viewports []string
+		for _, call := range rec.Calls() {
+			if call.Name == "Viewport" {
+				viewports = append(viewports, call.String())
+			}
+		}
+		expected := []string{"Viewport(0, 0, 400, 400)", "Viewport(400, 0, 400, 400)", "Viewport(0, 0, 800, 400)"}
+		if fmt.Sprint(viewports) != fmt.Sprint(expected) {
+			t.Errorf("expected viewports %v got %v", expected, viewports)
+		}
+	}
+}
+
+// Test that the debug views replace the materials with the debug program.
+func TestDebugView(t *testing.T) {
+
+	rec := gls.NewRecorder()
+	r, cam := newMaterialsScene(t, rec, 6)
+	for _, view := range []DebugView{ViewNormals, ViewUVs, ViewOverdraw, ViewDepth, ViewWireframe, ViewNone} {
+		r.SetDebugView(view)
+		report, err := r.CaptureFrame(cam)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Draws) != 6 {
+			t.Fatalf("view %d: expected 6 draws got %d", view, len(report.Draws))
+		}
+		for _, draw := range report.Draws {
+			if (draw.Program == "debug") != (view != ViewNone) {
+				t.Errorf("view %d: unexpected program %s", view, draw.Program)
+			}
+		}
+		wireframe := false
+		for _, call := range report.Calls {
+			wireframe = wireframe || call == fmt.Sprintf("PolygonMode(%d, %d)", gls.FRONT_AND_BACK, gls.LINE)
+		}
+		if wireframe != (view == ViewWireframe) {
+			t.Errorf("view %d: unexpected wireframe %v", view, wireframe)
+		}
+	}
+}
+
+// Test the execution order, the culling and the targets sharing of a frame graph.
+func TestFrameGraph(t *testing.T) {
+
+	rec := gls.NewRecorder()
+	r, cam := newMaterialsScene(t, rec, 2)
+	var executed []string
+	var targets []*texture.RenderTarget
+	pass := func(name string) FramePassFunc {
+		return func(fc *FrameContext) error {
+			executed = append(executed, name)
+			if name == "main" {
+				targets = append(targets, fc.Target("gbuffer"), fc.Target("lit"), fc.Target("bloom"))
+				_, err := fc.Renderer.RenderScene(fc.Scene, fc.Camera)
+				return err
+			}
+			return nil
+		}
+	}
+	spec := texture.RenderTargetSpec{Width: 8, Height: 8, ColorFormats: []uint32{gls.RGBA16F}}
+	fg := NewFrameGraph()
+	fg.AddTarget("gbuffer", spec)
+	fg.AddTarget("lit", spec)
+	fg.AddTarget("bloom", spec)
+	fg.AddTarget("unused", spec)
+	fg.AddPass("post", []string{"lit", "bloom"}, []string{FrameScreen}, pass("post"))
+	fg.AddPass("bloom", []string{"lit"}, []string{"bloom"}, pass("bloom"))
+	fg.AddPass("debug", nil, []string{"unused"}, pass("debug"))
+	fg.AddPass("main", []string{"gbuffer"}, []string{"lit"}, pass("main"))
+	fg.AddPass("gbuffer", nil, []string{"gbuffer"}, pass("gbuffer"))
+	r.SetFrameGraph(fg)
+	rendered, err := r.Render(cam)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"gbuffer", "main", "bloom", "post"}
+	if !rendered || fmt.Sprint(executed) != fmt.Sprint(expected) || fmt.Sprint(fg.Order()) != fmt.Sprint(expected) {
+		t.Errorf("expected passes %v got %v", expected, executed)
+	}
+	if r.Stats().Graphics != 2 {
+		t.Errorf("expected 2 graphics got %d", r.Stats().Graphics)
+	}
+	// The gbuffer is not used anymore when the bloom is written
+	if targets[0] != targets[2] || targets[0] == targets[1] {
+		t.Errorf("unexpected targets sharing %v", targets)
+	}
+
+	fg.AddPass("feedback", []string{"post"}, []string{FrameScreen}, pass("feedback"))
+	if err = fg.Compile(); err == nil {
+		t.Error("expected an error for an undeclared target")
+	}
+	fg = NewFrameGraph()
+	fg.AddTarget("a", spec)
+	fg.AddTarget("b", spec)
+	fg.AddPass("a", []string{"b"}, []string{"a"}, pass("a"))
+	fg.AddPass("b", []string{"a"}, []string{"b", FrameScreen}, pass("b"))
+	if err = fg.Compile(); err == nil {
+		t.Error("expected an error for a cycle")
+	}
+}
+
+// Test that the texture transform of a standard material is uploaded only when set.
+func TestTextureTransform(t *testing.T) {
+
+	rec := gls.NewRecorder()
+	r := newTestRenderer(t, rec)
+	mat := material.NewStandard(&math32.Color{1, 1, 1})
+	scene := core.NewNode()
+	scene.Add(newTestBox(mat))
+	r.SetScene(scene)
+	cam := camera.NewPerspective(60, 1, 0.1, 100)
+	count := func() int {
+		rec.Reset()
+		if _, err := r.Render(cam); err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for _, c := range rec.Calls() {
+			if c.Name == "UniformMatrix3fvP" {
+				n++
+			}
+		}
+		return n
+	}
+	base := count()
+	mat.SetTextureTransform(math32.Vector2{0.5, 0}, math32.Vector2{2, 2}, 0)
+	if _, ok := mat.ShaderDefines["TEX_TRANSFORM"]; !ok {
+		t.Fatalf("expected the TEX_TRANSFORM define")
+	}
+	if n := count(); n != base+1 {
+		t.Errorf("matrix uniforms: got %d, expected %d", n, base+1)
+	}
+	mat.SetProperty(material.PropTextureOffset, []float32{0, 0})
+	mat.SetProperty(material.PropTextureRotate, []float32{0})
+	offset, repeat, _ := mat.TextureTransform()
+	if offset != (math32.Vector2{0, 0}) || repeat != (math32.Vector2{2, 2}) {
+		t.Errorf("unexpected transform %v %v", offset, repeat)
+	}
+	mat.SetTextureTransform(math32.Vector2{0, 0}, math32.Vector2{1, 1}, 0)
+	if n := count(); n != base {
+		t.Errorf("matrix uniforms: got %d, expected %d", n, base)
+	}
+}
+
+// Test the preview of a custom shader source with its chunks and defines
+func TestPreviewShaderSource(t *testing.T) {
+
+	gs, err := gls.NewWithBackend(gls.NewRecorder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRenderer(gs)
+	r.AddChunk("custom_chunk", "float customValue;\n")
+	r.AddShader("custom_vertex", "#include <custom_chunk>\nvoid main() {}\n")
+	r.AddShader("broken_vertex", "#include <missing_chunk>\n")
+	defines := gls.NewShaderDefines()
+	defines.Set("B_DEFINE", "2")
+	defines.Set("A_DEFINE", "1")
+	source, err := r.PreviewShaderSource("custom_vertex", defines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(source, "\n")
+	if len(lines) < 5 || !strings.HasPrefix(lines[0], "#version") || lines[1] != "#define A_DEFINE 1" ||
+		lines[2] != "#define B_DEFINE 2" || !strings.Contains(source, "float customValue;") {
+		t.Errorf("unexpected source:\n%s", source)
+	}
+	if _, err := r.PreviewShaderSource("broken_vertex", nil); err == nil {
+		t.Errorf("expected an error for the missing chunk")
+	}
+	if _, err := r.PreviewShaderSource("missing_vertex", nil); err == nil {
+		t.Errorf("expected an error for the missing shader")
+	}
+}
@@ -0,0 +1,25 @@
+precision mediump float;
+//
+// Fragment shader for dashed lines
+//
+
+// Dash uniform
+uniform vec2 Dash;
+#define DashSize    Dash[0]
+#define GapSize     Dash[1]
+
+// Inputs from vertex shader
+in vec3 Color;
+in float Distance;
+
+// Output
+out vec4 FragColor;
+
+void main() {
+
+    if (mod(Distance, DashSize + GapSize) > DashSize) {
+        discard;
+    }
+    FragColor = vec4(Color, 1.0);
+}
+
@@ -0,0 +1,19 @@
+//
+// Vertex shader for dashed lines
+//
+#include <attributes>
+
+// Model uniforms
+uniform mat4 MVP;
+
+// Outputs for fragment shader
+out vec3 Color;
+out float Distance;
+
+void main() {
+
+    Color = VertexColor;
+    Distance = VertexDistance;
+    gl_Position = MVP * vec4(VertexPosition, 1.0);
+}
+
@@ -0,0 +1,33 @@
+precision highp float;
+//
+// Fragment shader of the debug views
+//
+
+// Camera near (x) and far (y) planes distances
+uniform vec4 DebugInfo;
+
+// Inputs from vertex shader
+in vec3 Normal;
+in vec2 Texcoord;
+in float Depth;
+
+// Output
+out vec4 FragColor;
+
+void main() {
+
+#ifdef DEBUG_NORMALS
+    FragColor = vec4(normalize(Normal) * 0.5 + 0.5, 1.0);
+#elif defined(DEBUG_UVS)
+    FragColor = vec4(fract(Texcoord), 0.0, 1.0);
+#elif defined(DEBUG_OVERDRAW)
+    // Each fragment adds to the blended color
+    FragColor = vec4(0.1, 0.05, 0.02, 1.0);
+#elif defined(DEBUG_DEPTH)
+    float depth = clamp((Depth - DebugInfo.x) / (DebugInfo.y - DebugInfo.x), 0.0, 1.0);
+    FragColor = vec4(vec3(depth), 1.0);
+#else
+    FragColor = vec4(1.0);
+#endif
+}
+
@@ -0,0 +1,23 @@
+//
+// Vertex shader of the debug views
+//
+#include <attributes>
+
+// Model uniforms
+uniform mat4 ModelMatrix;
+uniform mat4 ModelViewMatrix;
+uniform mat4 MVP;
+
+// Outputs for fragment shader
+out vec3 Normal;
+out vec2 Texcoord;
+out float Depth;
+
+void main() {
+
+    Normal = transpose(inverse(mat3(ModelMatrix))) * VertexNormal;
+    Texcoord = VertexTexcoord;
+    Depth = -(ModelViewMatrix * vec4(VertexPosition, 1.0)).z;
+    gl_Position = MVP * vec4(VertexPosition, 1.0);
+}
+
@@ -0,0 +1,13 @@
+precision mediump float;
+//
+// Fragment shader for depth only passes
+//
+
+// Output
+out vec4 FragColor;
+
+void main() {
+
+    FragColor = vec4(1.0);
+}
+
@@ -0,0 +1,13 @@
+//
+// Vertex shader for depth only passes
+//
+#include <attributes>
+
+// Model uniforms
+uniform mat4 MVP;
+
+void main() {
+
+    gl_Position = MVP * vec4(VertexPosition, 1.0);
+}
+
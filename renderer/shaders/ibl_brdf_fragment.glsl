@@ -0,0 +1,43 @@
+precision highp float;
+precision highp int;
+//
+// Fragment shader of the split sum BRDF lookup table, indexed by the cosine
+// of the view angle (x) and the roughness (y), with the scale (r) and the bias (g)
+// of the specular color.
+//
+#include <ibl>
+
+// Returns the Schlick-GGX geometry term of the specified cosine with the image based lighting remapping.
+float geometrySchlickGGX(float NdotX, float roughness) {
+
+    float k = roughness * roughness * 0.5;
+    return NdotX / (NdotX * (1.0 - k) + k);
+}
+
+void main() {
+
+    float NdotV = max(FragTexcoord.x, 0.0001);
+    float roughness = FragTexcoord.y;
+    vec3 N = vec3(0.0, 0.0, 1.0);
+    vec3 V = vec3(sqrt(1.0 - NdotV * NdotV), 0.0, NdotV);
+    int count = int(IBLInfo.z);
+
+    float scale = 0.0;
+    float bias = 0.0;
+    for (int i = 0; i < count; i++) {
+        vec3 H = importanceSampleGGX(hammersley(i, count), N, roughness);
+        vec3 L = normalize(2.0 * dot(V, H) * H - V);
+        float NdotL = max(L.z, 0.0);
+        if (NdotL > 0.0) {
+            float NdotH = max(H.z, 0.0);
+            float VdotH = max(dot(V, H), 0.0);
+            float G = geometrySchlickGGX(NdotV, roughness) * geometrySchlickGGX(NdotL, roughness);
+            float visibility = G * VdotH / (NdotH * NdotV);
+            float fresnel = pow(1.0 - VdotH, 5.0);
+            scale += (1.0 - fresnel) * visibility;
+            bias += fresnel * visibility;
+        }
+    }
+    FragColor = vec4(scale / float(count), bias / float(count), 0.0, 1.0);
+}
+
@@ -0,0 +1,30 @@
+precision highp float;
+precision highp int;
+//
+// Fragment shader of the diffuse irradiance cube map of an environment
+//
+#include <ibl>
+
+void main() {
+
+    // Tangent space of the face direction
+    vec3 N = iblDirection(FragTexcoord);
+    vec3 up = abs(N.y) < 0.999 ? vec3(0.0, 1.0, 0.0) : vec3(0.0, 0.0, 1.0);
+    vec3 right = normalize(cross(up, N));
+    up = cross(N, right);
+
+    // Cosine weighted sum of the environment over the hemisphere
+    vec3 irradiance = vec3(0.0);
+    float count = 0.0;
+    const float delta = 0.05;
+    for (float phi = 0.0; phi < 2.0 * IBL_PI; phi += delta) {
+        for (float theta = 0.0; theta < 0.5 * IBL_PI; theta += delta) {
+            vec3 t = vec3(sin(theta) * cos(phi), sin(theta) * sin(phi), cos(theta));
+            vec3 dir = t.x * right + t.y * up + t.z * N;
+            irradiance += texture(IBLEnvMap, dir).rgb * cos(theta) * sin(theta);
+            count += 1.0;
+        }
+    }
+    FragColor = vec4(IBL_PI * irradiance / count, 1.0);
+}
+
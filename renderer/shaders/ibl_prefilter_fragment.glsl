@@ -0,0 +1,36 @@
+precision highp float;
+precision highp int;
+//
+// Fragment shader of a roughness level of the prefiltered specular cube map of an environment
+//
+#include <ibl>
+
+void main() {
+
+    // The view direction is assumed to be the normal direction
+    vec3 N = iblDirection(FragTexcoord);
+    float roughness = IBLInfo.y;
+    int count = int(IBLInfo.z);
+    float a2 = roughness * roughness * roughness * roughness;
+    float saTexel = 4.0 * IBL_PI / (6.0 * IBLInfo.w * IBLInfo.w);
+
+    vec3 color = vec3(0.0);
+    float weight = 0.0;
+    for (int i = 0; i < count; i++) {
+        vec3 H = importanceSampleGGX(hammersley(i, count), N, roughness);
+        vec3 L = normalize(2.0 * dot(N, H) * H - N);
+        float NdotL = dot(N, L);
+        if (NdotL > 0.0) {
+            // Samples a lower environment level for the less probable directions to reduce the aliasing
+            float NdotH = max(dot(N, H), 0.0);
+            float d = NdotH * NdotH * (a2 - 1.0) + 1.0;
+            float pdf = a2 / (IBL_PI * d * d) * 0.25 + 0.0001;
+            float saSample = 1.0 / (float(count) * pdf + 0.0001);
+            float lod = roughness == 0.0 ? 0.0 : max(0.5 * log2(saSample / saTexel), 0.0);
+            color += textureLod(IBLEnvMap, L, lod).rgb * NdotL;
+            weight += NdotL;
+        }
+    }
+    FragColor = vec4(color / max(weight, 0.0001), 1.0);
+}
+
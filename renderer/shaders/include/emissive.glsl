@@ -0,0 +1,35 @@
+//
+// Emissive color added after the lighting
+// Requires the FragTexcoord fragment shader input.
+//
+#ifdef EMISSIVE
+
+#ifdef EMISSIVE_MAP
+    uniform sampler2D MatEmissiveMap;
+    uniform vec2 MatEmissiveMapInfo[3];
+#endif
+
+// Returns the emissive color of the fragment, which is not clamped
+// so colors above 1 are kept by the HDR rendering and drive the bloom.
+vec3 emissiveColor() {
+
+#ifdef EMISSIVE_MAP
+    vec2 uv = FragTexcoord;
+#if MAT_TEXTURES > 0
+    // The texture coordinates are already flipped by the first material texture
+    if (bool(MatEmissiveMapInfo[2].x) != MatTexFlipY(0)) {
+        uv.y = 1.0 - uv.y;
+    }
+#else
+    if (bool(MatEmissiveMapInfo[2].x)) {
+        uv.y = 1.0 - uv.y;
+    }
+#endif
+    uv = uv * MatEmissiveMapInfo[1] + MatEmissiveMapInfo[0];
+    return MatEmissiveColor * texture(MatEmissiveMap, uv).rgb;
+#else
+    return MatEmissiveColor;
+#endif
+}
+
+#endif
@@ -0,0 +1,40 @@
+//
+// Environment cube map reflections with optional box projection
+// Requires the WorldPosition and WorldNormal fragment shader inputs.
+//
+#ifdef ENV_MAP
+
+in vec3 WorldPosition;  // Fragment position in world coordinates
+in vec3 WorldNormal;    // Fragment normal in world coordinates
+
+uniform samplerCube MatEnvMap;
+uniform vec3 CameraPosition;
+#ifdef ENV_MAP_BOX
+    // Box projection parameters in world coordinates
+    uniform vec3 EnvMapBox[3];
+    #define EnvMapBoxMin        EnvMapBox[0]
+    #define EnvMapBoxMax        EnvMapBox[1]
+    #define EnvMapPosition      EnvMapBox[2]
+#endif
+
+// Returns the environment color reflected by the fragment.
+vec3 envMapColor() {
+
+    vec3 normal = normalize(WorldNormal);
+    if (!gl_FrontFacing) {
+        normal = -normal;
+    }
+    vec3 dir = reflect(normalize(WorldPosition - CameraPosition), normal);
+#ifdef ENV_MAP_BOX
+    // Intersects the reflected ray with the box and samples the
+    // direction from the capture position to the intersection
+    vec3 first = (EnvMapBoxMax - WorldPosition) / dir;
+    vec3 second = (EnvMapBoxMin - WorldPosition) / dir;
+    vec3 furthest = max(first, second);
+    float dist = min(min(furthest.x, furthest.y), furthest.z);
+    dir = WorldPosition + dir * dist - EnvMapPosition;
+#endif
+    return texture(MatEnvMap, dir).rgb;
+}
+
+#endif
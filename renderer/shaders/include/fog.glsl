@@ -0,0 +1,23 @@
+#ifdef FOG
+//
+// Fog blending the fragments with the fog color with their distance from the camera
+//
+
+// Fog color (rgb) and mode (a: 1 linear, 2 exponential squared) in the first vec4
+// and near and far distances of the linear fog (xy) and density (z) in the second vec4
+uniform vec4 Fog[2];
+
+// Returns the specified color blended with the fog color.
+// The distance from the camera plane is derived from the perspective depth.
+vec3 applyFog(vec3 color) {
+
+    float dist = 1.0 / gl_FragCoord.w;
+    float f;
+    if (Fog[0].a < 1.5) {
+        f = clamp((dist - Fog[1].x) / (Fog[1].y - Fog[1].x), 0.0, 1.0);
+    } else {
+        f = 1.0 - clamp(exp(-Fog[1].z * Fog[1].z * dist * dist), 0.0, 1.0);
+    }
+    return mix(color, Fog[0].rgb, f);
+}
+#endif
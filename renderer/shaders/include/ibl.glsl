@@ -0,0 +1,65 @@
+//
+// Image based lighting precomputation inputs and functions
+//
+
+// Environment cube map
+uniform samplerCube IBLEnvMap;
+
+// Cube face (x), roughness (y), number of samples (z) and environment faces size (w)
+uniform vec4 IBLInfo;
+
+// Input from vertex shader
+in vec2 FragTexcoord;
+
+// Output
+out vec4 FragColor;
+
+const float IBL_PI = 3.14159265359;
+
+// Returns the direction of the texel of the current cube face at the specified texture coordinates.
+vec3 iblDirection(vec2 uv) {
+
+    vec2 st = uv * 2.0 - 1.0;
+    int face = int(IBLInfo.x + 0.5);
+    vec3 dir;
+    if (face == 0) {
+        dir = vec3(1.0, -st.y, -st.x);
+    } else if (face == 1) {
+        dir = vec3(-1.0, -st.y, st.x);
+    } else if (face == 2) {
+        dir = vec3(st.x, 1.0, st.y);
+    } else if (face == 3) {
+        dir = vec3(st.x, -1.0, -st.y);
+    } else if (face == 4) {
+        dir = vec3(st.x, -st.y, 1.0);
+    } else {
+        dir = vec3(-st.x, -st.y, -1.0);
+    }
+    return normalize(dir);
+}
+
+// Returns the point of the specified index of the Hammersley sequence of n points.
+vec2 hammersley(int i, int n) {
+
+    uint bits = uint(i);
+    bits = (bits << 16u) | (bits >> 16u);
+    bits = ((bits & 0x55555555u) << 1u) | ((bits & 0xAAAAAAAAu) >> 1u);
+    bits = ((bits & 0x33333333u) << 2u) | ((bits & 0xCCCCCCCCu) >> 2u);
+    bits = ((bits & 0x0F0F0F0Fu) << 4u) | ((bits & 0xF0F0F0F0u) >> 4u);
+    bits = ((bits & 0x00FF00FFu) << 8u) | ((bits & 0xFF00FF00u) >> 8u);
+    return vec2(float(i) / float(n), float(bits) * 2.3283064365386963e-10);
+}
+
+// Returns the GGX importance sampled half vector around the normal N for the specified roughness.
+vec3 importanceSampleGGX(vec2 xi, vec3 N, float roughness) {
+
+    float a = roughness * roughness;
+    float phi = 2.0 * IBL_PI * xi.x;
+    float cosTheta = sqrt((1.0 - xi.y) / (1.0 + (a * a - 1.0) * xi.y));
+    float sinTheta = sqrt(1.0 - cosTheta * cosTheta);
+    vec3 H = vec3(cos(phi) * sinTheta, sin(phi) * sinTheta, cosTheta);
+    vec3 up = abs(N.z) < 0.999 ? vec3(0.0, 0.0, 1.0) : vec3(1.0, 0.0, 0.0);
+    vec3 tangent = normalize(cross(up, N));
+    vec3 bitangent = cross(N, tangent);
+    return normalize(tangent * H.x + bitangent * H.y + N * H.z);
+}
@@ -3,7 +3,7 @@
 //
 
 // Material parameters uniform array
-uniform vec3 Material[6];
+uniform mediump vec3 Material[7];
 // Macros to access elements inside the Material array
 #define MatAmbientColor		Material[0]
 #define MatDiffuseColor     Material[1]
@@ -13,12 +13,15 @@ uniform vec3 Material[6];
 #define MatOpacity          Material[4].y
 #define MatPointSize        Material[4].z
 #define MatPointRotationZ   Material[5].x
+#define MatParallaxScale    Material[5].y
+#define MatReflectivity     Material[5].z
+#define MatAlphaTest        Material[6].x
 
 #if MAT_TEXTURES > 0
     // Texture unit sampler array
     uniform sampler2D MatTexture[MAT_TEXTURES];
     // Texture parameters (3*vec2 per texture)
-    uniform vec2 MatTexinfo[3*MAT_TEXTURES];
+    uniform mediump vec2 MatTexinfo[3*MAT_TEXTURES];
     // Macros to access elements inside the MatTexinfo array
     #define MatTexOffset(a)		MatTexinfo[(3*a)]
     #define MatTexRepeat(a)		MatTexinfo[(3*a)+1]
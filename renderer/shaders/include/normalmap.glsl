@@ -0,0 +1,86 @@
+//
+// Normal and height (parallax occlusion) mapping
+// Requires the Normal, Tangent, Position and FragTexcoord fragment shader inputs.
+//
+#if defined(NORMAL_MAP) || defined(PARALLAX)
+
+in vec4 Tangent;        // Vertex tangent in camera coordinates (w: handedness)
+
+#ifdef NORMAL_MAP
+    uniform sampler2D MatNormalMap;
+    uniform vec2 MatNormalMapInfo[3];
+#endif
+#ifdef PARALLAX
+    uniform sampler2D MatHeightMap;
+    uniform vec2 MatHeightMapInfo[3];
+#endif
+
+// Returns the tangent space matrix for the specified normal.
+// If the geometry has no tangents they are calculated from the screen space derivatives.
+mat3 tangentSpace(vec3 normal) {
+
+    vec3 t = Tangent.xyz;
+    float handedness = Tangent.w;
+    if (dot(t, t) < 1e-6) {
+        vec3 pos_dx = dFdx(Position.xyz);
+        vec3 pos_dy = dFdy(Position.xyz);
+        vec2 tex_dx = dFdx(FragTexcoord);
+        vec2 tex_dy = dFdy(FragTexcoord);
+        t = tex_dy.t * pos_dx - tex_dx.t * pos_dy;
+        handedness = sign(tex_dx.s * tex_dy.t - tex_dy.s * tex_dx.t);
+    }
+    t = normalize(t - normal * dot(normal, t));
+    vec3 b = cross(normal, t) * handedness;
+    return mat3(t, b, normal);
+}
+
+#ifdef NORMAL_MAP
+// Returns the normal in camera coordinates perturbed by the normal map.
+vec3 normalMap(mat3 tbn, vec2 texcoord) {
+
+    vec2 uv = texcoord * MatNormalMapInfo[1] + MatNormalMapInfo[0];
+    vec3 n = texture(MatNormalMap, uv).rgb * 2.0 - 1.0;
+    return normalize(tbn * n);
+}
+#endif
+
+#ifdef PARALLAX
+// Returns the texture coordinates displaced by parallax occlusion mapping
+// for the specified view direction in tangent space.
+vec2 parallaxMap(vec2 texcoord, vec3 viewDir) {
+
+    const float minLayers = 8.0;
+    const float maxLayers = 32.0;
+    float numLayers = mix(maxLayers, minLayers, abs(viewDir.z));
+    float layerDepth = 1.0 / numLayers;
+    vec2 delta = viewDir.xy / max(viewDir.z, 0.05) * MatParallaxScale / numLayers;
+
+    // Gradients are calculated outside the loop to keep them well defined
+    vec2 scale = MatHeightMapInfo[1];
+    vec2 offset = MatHeightMapInfo[0];
+    vec2 dx = dFdx(texcoord * scale);
+    vec2 dy = dFdy(texcoord * scale);
+
+    // Steps along the view direction until the height field is crossed
+    vec2 uv = texcoord;
+    float depth = 0.0;
+    float mapDepth = 1.0 - textureGrad(MatHeightMap, uv * scale + offset, dx, dy).r;
+    for (int i = 0; i < int(maxLayers); i++) {
+        if (depth >= mapDepth) {
+            break;
+        }
+        uv -= delta;
+        mapDepth = 1.0 - textureGrad(MatHeightMap, uv * scale + offset, dx, dy).r;
+        depth += layerDepth;
+    }
+
+    // Interpolates between the last two layers
+    vec2 prevUV = uv + delta;
+    float after = mapDepth - depth;
+    float before = 1.0 - textureGrad(MatHeightMap, prevUV * scale + offset, dx, dy).r - depth + layerDepth;
+    float weight = after / (after - before);
+    return mix(uv, prevUV, weight);
+}
+#endif
+
+#endif
@@ -0,0 +1,25 @@
+//
+// Fragment shader output of the materials supporting the weighted blended
+// order independent transparency, which replaces the FragColor output by the
+// accumulated color and weight outputs when OIT is defined.
+//
+#ifdef OIT
+
+layout(location = 0) out vec4 OITAccum;
+layout(location = 1) out vec4 OITWeight;
+vec4 FragColor;
+
+// Writes FragColor weighted by its alpha and depth to the accumulation outputs.
+// The weight function is from "Weighted Blended Order-Independent Transparency"
+// (McGuire and Bavoil, 2013), which favors the near and opaque fragments.
+void oitWrite() {
+
+    float a = FragColor.a;
+    float w = clamp(a * max(1e-2, 3e3 * pow(1.0 - gl_FragCoord.z, 3.0)), 1e-2, 3e3);
+    OITAccum = vec4(FragColor.rgb * a * w, a);
+    OITWeight = vec4(a * w, 0.0, 0.0, a);
+}
+
+#else
+out vec4 FragColor;
+#endif
@@ -40,12 +40,18 @@ void phongModel(vec4 position, vec3 normal, vec3 camDir, vec3 matAmbient, vec3 m
         vec3 lightDirection = normalize(DirLightPosition(i));
         // Calculates the dot product between the light direction and this vertex normal.
         float dotNormal = max(dot(lightDirection, normal), 0.0);
-        diffuseTotal += DirLightColor(i) * matDiffuse * dotNormal;
+#ifdef SHADOWS
+        // The first directional light casts the shadows
+        float shadow = i == 0 ? shadowFactor(position) : 1.0;
+#else
+        float shadow = 1.0;
+#endif
+        diffuseTotal += DirLightColor(i) * matDiffuse * dotNormal * shadow;
         // Specular reflection
         // Calculates the light reflection vector
         vec3 ref = reflect(-lightDirection, normal);
         if (dotNormal > 0.0) {
-            specularTotal += DirLightColor(i) * MatSpecularColor * pow(max(dot(ref, camDir), 0.0), MatShininess);
+            specularTotal += DirLightColor(i) * MatSpecularColor * pow(max(dot(ref, camDir), 0.0), MatShininess) * shadow;
         }
     }
 #endif
@@ -109,6 +115,6 @@ void phongModel(vec4 position, vec3 normal, vec3 camDir, vec3 matAmbient, vec3 m
 #endif
 
     // Sets output colors
-    ambdiff = ambientTotal + MatEmissiveColor + diffuseTotal;
+    ambdiff = ambientTotal + diffuseTotal;
     spec = specularTotal;
 }
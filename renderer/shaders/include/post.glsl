@@ -0,0 +1,26 @@
+//
+// Post processing effects inputs
+//
+
+// Color of the previous pass and depth of the scene
+uniform sampler2D PostColor;
+uniform sampler2D PostDepth;
+
+// Texel size (xy) and projection matrix elements [10] and [14] (zw)
+uniform vec4 PostInfo;
+
+// Effect parameters
+uniform vec4 PostEffect[2];
+
+// Input from vertex shader
+in vec2 FragTexcoord;
+
+// Output
+out vec4 FragColor;
+
+// Returns the distance from the camera plane of the scene point at the specified coordinates.
+float postViewDistance(vec2 uv) {
+
+    float z = texture(PostDepth, uv).r * 2.0 - 1.0;
+    return PostInfo.w / (z + PostInfo.z);
+}
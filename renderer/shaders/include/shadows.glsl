@@ -0,0 +1,55 @@
+#ifdef SHADOWS
+//
+// Shadow of the first directional light sampled from its shadow map
+//
+
+// Depth of the shadow casters seen from the light
+uniform sampler2D ShadowMap;
+// Transforms the camera coordinates to the shadow map coordinates and depth
+uniform mat4 ShadowMatrix;
+// Texel size (x), depth bias (y), PCF kernel radius (z) and exponential factor
+// or variance light bleeding reduction (w)
+uniform vec4 ShadowInfo;
+
+// Returns the fraction of the light received at the specified position in camera coordinates,
+// from 0.0 if it is in the shadow to 1.0 if it is lit.
+float shadowFactor(vec4 position) {
+
+    vec4 coord = ShadowMatrix * position;
+    vec3 p = coord.xyz / coord.w;
+    if (p.x < 0.0 || p.x > 1.0 || p.y < 0.0 || p.y > 1.0 || p.z > 1.0) {
+        return 1.0;
+    }
+    float depth = p.z - ShadowInfo.y;
+#if defined(SHADOW_PCF)
+    // Percentage of the kernel texels closer to the light than the position
+    int radius = int(ShadowInfo.z);
+    float lit = 0.0;
+    for (int x = -radius; x <= radius; x++) {
+        for (int y = -radius; y <= radius; y++) {
+            vec2 offset = vec2(float(x), float(y)) * ShadowInfo.x;
+            lit += depth <= texture(ShadowMap, p.xy + offset).r ? 1.0 : 0.0;
+        }
+    }
+    float size = float(2 * radius + 1);
+    return lit / (size * size);
+#elif defined(SHADOW_EXP)
+    // Exponential falloff with the distance behind the occluder
+    float occluder = texture(ShadowMap, p.xy).r;
+    return clamp(exp(ShadowInfo.w * (occluder - depth)), 0.0, 1.0);
+#elif defined(SHADOW_VSM)
+    // Upper bound of the lit fraction from the depth mean and variance (Chebyshev's inequality)
+    vec2 moments = texture(ShadowMap, p.xy).rg;
+    if (depth <= moments.x) {
+        return 1.0;
+    }
+    float variance = max(moments.y - moments.x * moments.x, 0.00002);
+    float d = depth - moments.x;
+    float pmax = variance / (variance + d * d);
+    // Cuts off the low fractions to reduce the light bleeding
+    return clamp((pmax - ShadowInfo.w) / (1.0 - ShadowInfo.w), 0.0, 1.0);
+#else
+    return depth <= texture(ShadowMap, p.xy).r ? 1.0 : 0.0;
+#endif
+}
+#endif
@@ -0,0 +1,27 @@
+#ifdef SOFT_PARTICLES
+//
+// Soft particles fading near the opaque objects behind them
+//
+
+// Depth of the opaque objects of the scene
+uniform sampler2D SoftDepth;
+
+// Texel size (xy) and projection matrix elements [10] and [14] (zw) in the first vec4
+// and viewport position (xy) and fade distance (z) in the second vec4
+uniform vec4 SoftInfo[2];
+
+// Returns the distance from the camera plane of the specified depth buffer value.
+float softViewDistance(float depth) {
+
+    return SoftInfo[0].w / (depth * 2.0 - 1.0 + SoftInfo[0].z);
+}
+
+// Returns the opacity factor of the fragment, which fades
+// as it approaches the opaque objects behind it.
+float softParticlesFade() {
+
+    vec2 uv = (gl_FragCoord.xy - SoftInfo[1].xy) * SoftInfo[0].xy;
+    float scene = softViewDistance(texture(SoftDepth, uv).r);
+    return clamp((scene - softViewDistance(gl_FragCoord.z)) / SoftInfo[1].z, 0.0, 1.0);
+}
+#endif
@@ -0,0 +1,25 @@
+precision mediump float;
+//
+// Fragment shader for planar reflections
+//
+
+// Reflection texture uniforms
+uniform sampler2D MirrorMap;
+
+// Material uniform: rgb is the surface color, a is the reflectivity
+uniform vec4 Material[1];
+#define MatColor            Material[0].rgb
+#define MatReflectivity     Material[0].a
+
+// Inputs from vertex shader
+in vec4 MirrorCoord;
+
+// Output
+out vec4 FragColor;
+
+void main() {
+
+    vec3 reflected = textureProj(MirrorMap, MirrorCoord).rgb;
+    FragColor = vec4(mix(MatColor, reflected, MatReflectivity), 1.0);
+}
+
@@ -0,0 +1,20 @@
+//
+// Vertex shader for planar reflections
+//
+#include <attributes>
+
+// Model uniforms
+uniform mat4 MVP;
+
+// Projects the model coordinates to the reflection texture
+uniform mat4 MirrorTextureMatrix;
+
+// Output for fragment shader
+out vec4 MirrorCoord;
+
+void main() {
+
+    MirrorCoord = MirrorTextureMatrix * vec4(VertexPosition, 1.0);
+    gl_Position = MVP * vec4(VertexPosition, 1.0);
+}
+
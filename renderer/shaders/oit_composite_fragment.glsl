@@ -0,0 +1,21 @@
+precision mediump float;
+//
+// Fragment shader compositing the weighted blended order independent transparency
+//
+#include <post>
+
+// Sum of the weights of the transparent fragments
+uniform sampler2D OITWeights;
+
+void main() {
+
+    // The accumulated alpha is the product of the transparent fragments transmittances
+    vec4 accum = texture(PostColor, FragTexcoord);
+    float revealage = accum.a;
+    if (revealage >= 1.0) {
+        discard;
+    }
+    float weights = texture(OITWeights, FragTexcoord).r;
+    FragColor = vec4(accum.rgb / max(weights, 1e-5), 1.0 - revealage);
+}
+
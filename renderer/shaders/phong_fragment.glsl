@@ -12,13 +12,35 @@ in vec2 FragTexcoord;
 
 #include <lights>
 #include <material>
+#include <fog>
+#include <shadows>
 #include <phong_model>
+#include <normalmap>
+#include <envmap>
+#include <emissive>
 
 // Final fragment color
-out vec4 FragColor;
+#include <oit>
 
 void main() {
 
+    // Inverts the fragment normal if not FrontFacing
+    vec3 fragNormal = normalize(Normal);
+    if (!gl_FrontFacing) {
+        fragNormal = -fragNormal;
+    }
+
+#if defined(NORMAL_MAP) || defined(PARALLAX)
+    mat3 tbn = tangentSpace(fragNormal);
+#endif
+#ifdef PARALLAX
+    // Shadows the FragTexcoord input with the displaced texture coordinates
+    vec2 FragTexcoord = parallaxMap(FragTexcoord, normalize(CamDir * tbn));
+#endif
+#ifdef NORMAL_MAP
+    fragNormal = normalMap(tbn, FragTexcoord);
+#endif
+
     // Mix material color with textures colors
     vec4 texMixed = vec4(1);
     vec4 texColor;
@@ -37,17 +59,37 @@ void main() {
     vec4 matDiffuse = vec4(MatDiffuseColor, MatOpacity) * texMixed;
     vec4 matAmbient = vec4(MatAmbientColor, MatOpacity) * texMixed;
 
-    // Inverts the fragment normal if not FrontFacing
-    vec3 fragNormal = Normal;
-    if (!gl_FrontFacing) {
-        fragNormal = -fragNormal;
-    }
-
     // Calculates the Ambient+Diffuse and Specular colors for this fragment using the Phong model.
     vec3 Ambdiff, Spec;
     phongModel(Position, fragNormal, CamDir, vec3(matAmbient), vec3(matDiffuse), Ambdiff, Spec);
 
     // Final fragment color
     FragColor = min(vec4(Ambdiff + Spec, matDiffuse.a), vec4(1.0));
-}
 
+#ifdef ALPHA_TEST
+    // Discards the fragments below the alpha test threshold
+    if (FragColor.a < MatAlphaTest) {
+        discard;
+    }
+#endif
+
+#ifdef ENV_MAP
+    // Mix with the reflected environment color
+    FragColor.rgb = mix(FragColor.rgb, envMapColor(), MatReflectivity);
+#endif
+
+#ifdef EMISSIVE
+    // Adds the emissive color after the clamped lighting
+    FragColor.rgb += emissiveColor();
+#endif
+
+#ifdef FOG
+    // Blends the fragment with the fog color
+    FragColor.rgb = applyFog(FragColor.rgb);
+#endif
+
+#ifdef OIT
+    // Accumulates the color for the order independent transparency
+    oitWrite();
+#endif
+}
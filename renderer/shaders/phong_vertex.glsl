@@ -7,6 +7,9 @@
 uniform mat4 ModelViewMatrix;
 uniform mat3 NormalMatrix;
 uniform mat4 MVP;
+#ifdef TEX_TRANSFORM
+uniform mat3 MatTexTransform;
+#endif
 
 #include <material>
 #include <morphtarget_vertex_declaration>
@@ -17,6 +20,14 @@ out vec4 Position;
 out vec3 Normal;
 out vec3 CamDir;
 out vec2 FragTexcoord;
+#if defined(NORMAL_MAP) || defined(PARALLAX)
+out vec4 Tangent;
+#endif
+#ifdef ENV_MAP
+uniform mat4 ModelMatrix;
+out vec3 WorldPosition;
+out vec3 WorldNormal;
+#endif
 
 void main() {
 
@@ -26,6 +37,17 @@ void main() {
     // Transform this vertex normal to camera coordinates.
     Normal = normalize(NormalMatrix * VertexNormal);
 
+#if defined(NORMAL_MAP) || defined(PARALLAX)
+    // Transform this vertex tangent to camera coordinates keeping its handedness.
+    Tangent = vec4(NormalMatrix * VertexTangent.xyz, VertexTangent.w);
+#endif
+
+#ifdef ENV_MAP
+    // Transform this vertex position and normal to world coordinates.
+    WorldPosition = vec3(ModelMatrix * vec4(VertexPosition, 1.0));
+    WorldNormal = mat3(ModelMatrix) * VertexNormal;
+#endif
+
     // Calculate the direction vector from the vertex to the camera
     // The camera is at 0,0,0
     CamDir = normalize(-Position.xyz);
@@ -34,8 +56,11 @@ void main() {
     vec2 texcoord = VertexTexcoord;
 #if MAT_TEXTURES>0
     if (MatTexFlipY(0)) {
-        texcoord.y = 1 - texcoord.y;
+        texcoord.y = 1.0 - texcoord.y;
     }
+#endif
+#ifdef TEX_TRANSFORM
+    texcoord = (MatTexTransform * vec3(texcoord, 1.0)).xy;
 #endif
     FragTexcoord = texcoord;
     vec3 vPosition = VertexPosition;
@@ -53,6 +53,7 @@ uniform vec4 Material[3];
 #define uRoughnessFactor    Material[2].y
 
 #include <lights>
+#include <fog>
 
 // Inputs from vertex shader
 in vec3 Position;       // Vertex position in camera coordinates.
@@ -61,7 +62,7 @@ in vec3 CamDir;         // Direction from vertex to camera
 in vec2 FragTexcoord;
 
 // Final fragment color
-out vec4 FragColor;
+#include <oit>
 
 // Encapsulate the various inputs used by the various functions in the shading equation
 // We store values in this struct to simplify the integration of alternative implementations
@@ -410,6 +411,14 @@ void main() {
 
     // Final fragment color
     FragColor = vec4(pow(color,vec3(1.0/2.2)), baseColor.a);
-}
 
+#ifdef FOG
+    // Blends the fragment with the fog color
+    FragColor.rgb = applyFog(FragColor.rgb);
+#endif
 
+#ifdef OIT
+    // Accumulates the color for the order independent transparency
+    oitWrite();
+#endif
+}
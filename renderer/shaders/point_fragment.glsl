@@ -1,6 +1,8 @@
 precision mediump float;
 
 #include <material>
+#include <fog>
+#include <soft_particles>
 
 // GLSL 3.30 does not allow indexing texture sampler with non constant values.
 // This macro is used to mix the texture with the specified index with the material color.
@@ -40,5 +42,21 @@ void main() {
 
     // Generates final color
     FragColor = min(vec4(Color, MatOpacity) * texMixed, vec4(1));
-}
 
+#ifdef SOFT_PARTICLES
+    // Fades the fragment near the opaque objects behind it
+    FragColor.a *= softParticlesFade();
+#endif
+
+#ifdef ALPHA_TEST
+    // Discards the fragments below the alpha test threshold
+    if (FragColor.a < MatAlphaTest) {
+        discard;
+    }
+#endif
+
+#ifdef FOG
+    // Blends the fragment with the fog color
+    FragColor.rgb = applyFog(FragColor.rgb);
+#endif
+}
@@ -0,0 +1,20 @@
+precision highp float;
+//
+// Fragment shader adapting the auto exposure luminance to the scene luminance
+//
+#include <post>
+
+// Previously adapted luminance
+uniform sampler2D PostAdapted;
+
+// Effect parameters
+#define Rate                PostEffect[0].x
+
+void main() {
+
+    // The smallest mipmap of the log luminance texture has its average
+    float lum = exp(textureLod(PostColor, vec2(0.5), 16.0).r);
+    float prev = texture(PostAdapted, vec2(0.5)).r;
+    FragColor = vec4(mix(prev, lum, Rate), 0.0, 0.0, 1.0);
+}
+
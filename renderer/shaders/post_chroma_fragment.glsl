@@ -0,0 +1,19 @@
+precision mediump float;
+//
+// Fragment shader for the chromatic aberration post processing effect
+//
+#include <post>
+
+// Effect parameters
+#define Strength            PostEffect[0].x
+
+void main() {
+
+    // Offsets the red and blue channels radially, up to Strength pixels at the screen edges
+    vec2 offset = (FragTexcoord - 0.5) * 2.0 * Strength * PostInfo.xy;
+    vec4 color = texture(PostColor, FragTexcoord);
+    color.r = texture(PostColor, FragTexcoord + offset).r;
+    color.b = texture(PostColor, FragTexcoord - offset).b;
+    FragColor = color;
+}
+
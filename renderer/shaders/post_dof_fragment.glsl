@@ -0,0 +1,41 @@
+precision highp float;
+//
+// Fragment shader for the depth of field post processing effect
+//
+#include <post>
+
+// Effect parameters
+#define FocusDistance       PostEffect[0].x
+#define FocusRange          PostEffect[0].y
+#define BokehScale          PostEffect[0].z
+
+// Number of samples of the blur disc
+const int SAMPLES = 32;
+const float GOLDEN_ANGLE = 2.39996323;
+
+// Returns the circle of confusion radius in pixels of the specified point.
+float circleOfConfusion(vec2 uv) {
+
+    float dist = postViewDistance(uv);
+    float coc = (abs(dist - FocusDistance) - 0.5 * FocusRange) / max(FocusRange, 0.0001);
+    return clamp(coc, 0.0, 1.0) * BokehScale;
+}
+
+void main() {
+
+    float radius = circleOfConfusion(FragTexcoord);
+    vec3 color = texture(PostColor, FragTexcoord).rgb;
+    float weight = 1.0;
+
+    // Gathers the samples of a disc which spread over this pixel
+    for (int i = 1; i < SAMPLES; i++) {
+        float r = radius * sqrt(float(i) / float(SAMPLES));
+        float a = float(i) * GOLDEN_ANGLE;
+        vec2 uv = FragTexcoord + vec2(cos(a), sin(a)) * r * PostInfo.xy;
+        float w = clamp(circleOfConfusion(uv) - r + 1.0, 0.0, 1.0);
+        color += texture(PostColor, uv).rgb * w;
+        weight += w;
+    }
+    FragColor = vec4(color / weight, 1.0);
+}
+
@@ -0,0 +1,13 @@
+precision highp float;
+//
+// Fragment shader computing the log luminance of the scene for the auto exposure
+//
+#include <post>
+
+void main() {
+
+    vec3 color = texture(PostColor, FragTexcoord).rgb;
+    float lum = dot(color, vec3(0.2126, 0.7152, 0.0722));
+    FragColor = vec4(log(max(lum, 0.0001)), 0.0, 0.0, 1.0);
+}
+
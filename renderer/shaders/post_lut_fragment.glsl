@@ -0,0 +1,20 @@
+precision mediump float;
+//
+// Fragment shader for the color grading post processing effect
+//
+#include <post>
+
+// Color grading lookup table
+uniform mediump sampler3D PostLUT;
+
+// Effect parameters
+#define LUTSize             PostEffect[0].x
+
+void main() {
+
+    vec4 color = texture(PostColor, FragTexcoord);
+    // Maps the color range to the centers of the first and last texels
+    vec3 uvw = clamp(color.rgb, 0.0, 1.0) * ((LUTSize - 1.0) / LUTSize) + 0.5 / LUTSize;
+    FragColor = vec4(texture(PostLUT, uvw).rgb, color.a);
+}
+
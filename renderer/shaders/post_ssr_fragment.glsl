@@ -0,0 +1,72 @@
+precision highp float;
+//
+// Fragment shader for the screen space reflections post processing effect
+//
+#include <post>
+
+// View normals (rgb) and reflectivity (a) of the reflective surfaces
+uniform sampler2D PostNormal;
+
+// Effect parameters
+#define MaxDistance         PostEffect[0].x
+#define Thickness           PostEffect[0].y
+#define Steps               PostEffect[0].z
+#define ProjScale           PostEffect[1].xy
+
+// Maximum number of ray marching steps
+const int MAX_STEPS = 256;
+
+// Returns the view position of the scene point at the specified coordinates.
+vec3 viewPosition(vec2 uv) {
+
+    float dist = postViewDistance(uv);
+    return vec3((uv * 2.0 - 1.0) * dist / ProjScale, -dist);
+}
+
+// Returns the screen coordinates of the specified view position.
+vec2 screenPosition(vec3 pos) {
+
+    return (pos.xy * ProjScale / -pos.z) * 0.5 + 0.5;
+}
+
+void main() {
+
+    vec4 color = texture(PostColor, FragTexcoord);
+    vec4 surface = texture(PostNormal, FragTexcoord);
+    if (surface.a == 0.0) {
+        FragColor = color;
+        return;
+    }
+
+    // Marches the reflected ray until it goes behind the depth buffer
+    vec3 pos = viewPosition(FragTexcoord);
+    vec3 normal = normalize(surface.rgb * 2.0 - 1.0);
+    vec3 dir = reflect(normalize(pos), normal);
+    vec3 delta = dir * (MaxDistance / Steps);
+    vec3 ray = pos;
+    for (int i = 1; i <= MAX_STEPS; i++) {
+        if (float(i) > Steps) {
+            break;
+        }
+        ray += delta;
+        if (ray.z >= 0.0) {
+            break;
+        }
+        vec2 uv = screenPosition(ray);
+        if (any(lessThan(uv, vec2(0.0))) || any(greaterThan(uv, vec2(1.0)))) {
+            break;
+        }
+        float diff = -ray.z - postViewDistance(uv);
+        if (diff > 0.0 && diff < Thickness) {
+            // Fades the reflection near the screen edges and the maximum distance
+            vec2 edge = smoothstep(0.0, 0.1, uv) * (1.0 - smoothstep(0.9, 1.0, uv));
+            float fade = edge.x * edge.y * (1.0 - float(i) / Steps);
+            FragColor = vec4(mix(color.rgb, texture(PostColor, uv).rgb, surface.a * fade), color.a);
+            return;
+        }
+    }
+
+    // The ray left the screen without a hit: keeps the material environment reflections
+    FragColor = color;
+}
+
@@ -0,0 +1,33 @@
+precision highp float;
+//
+// Fragment shader for the exposure and tone mapping post processing effect
+//
+#include <post>
+
+// Adapted scene luminance of the auto exposure
+uniform sampler2D PostLuminance;
+
+// Effect parameters
+#define Exposure            PostEffect[0].x
+#define AutoExposure        PostEffect[0].y
+#define MinLuminance        PostEffect[0].z
+#define MaxLuminance        PostEffect[0].w
+
+// Returns the ACES filmic tone mapping of the specified color.
+vec3 toneMap(vec3 x) {
+
+    return clamp((x * (2.51 * x + 0.03)) / (x * (2.43 * x + 0.59) + 0.14), 0.0, 1.0);
+}
+
+void main() {
+
+    vec4 color = texture(PostColor, FragTexcoord);
+    float exposure = Exposure;
+    if (AutoExposure > 0.0) {
+        // Maps the scene average luminance to middle gray
+        float lum = clamp(texture(PostLuminance, vec2(0.5)).r, MinLuminance, MaxLuminance);
+        exposure *= 0.18 / lum;
+    }
+    FragColor = vec4(toneMap(color.rgb * exposure), color.a);
+}
+
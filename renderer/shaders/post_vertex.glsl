@@ -0,0 +1,14 @@
+//
+// Vertex shader for full screen post processing effects
+//
+#include <attributes>
+
+// Output for fragment shader
+out vec2 FragTexcoord;
+
+void main() {
+
+    FragTexcoord = VertexTexcoord;
+    gl_Position = vec4(VertexPosition.xy, 0.0, 1.0);
+}
+
@@ -0,0 +1,19 @@
+precision mediump float;
+//
+// Fragment shader for the vignette post processing effect
+//
+#include <post>
+
+// Effect parameters
+#define Intensity           PostEffect[0].x
+#define Smoothness          PostEffect[0].y
+
+void main() {
+
+    // Distance from the screen center, 1 at the corners
+    float dist = length(FragTexcoord - 0.5) * 1.41421356;
+    float dark = Intensity * smoothstep(1.0 - max(Smoothness, 0.001), 1.0, dist);
+    vec4 color = texture(PostColor, FragTexcoord);
+    FragColor = vec4(color.rgb * (1.0 - clamp(dark, 0.0, 1.0)), color.a);
+}
+
@@ -0,0 +1,25 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shaders
+
+// Adds the programs which share the vertex shader of another program
+// and cannot be named from their shader files by g3nshaders
+func init() {
+
+	AddProgram("ibl_brdf", "post_vertex", "ibl_brdf_fragment")
+	AddProgram("ibl_irradiance", "post_vertex", "ibl_irradiance_fragment")
+	AddProgram("ibl_prefilter", "post_vertex", "ibl_prefilter_fragment")
+	AddProgram("oit_composite", "post_vertex", "oit_composite_fragment")
+	AddProgram("post_adapt", "post_vertex", "post_adapt_fragment")
+	AddProgram("post_chroma", "post_vertex", "post_chroma_fragment")
+	AddProgram("post_dof", "post_vertex", "post_dof_fragment")
+	AddProgram("post_luminance", "post_vertex", "post_luminance_fragment")
+	AddProgram("post_lut", "post_vertex", "post_lut_fragment")
+	AddProgram("post_ssr", "post_vertex", "post_ssr_fragment")
+	AddProgram("post_tonemap", "post_vertex", "post_tonemap_fragment")
+	AddProgram("post_vignette", "post_vertex", "post_vignette_fragment")
+	AddProgram("shadow_blur", "post_vertex", "shadow_blur_fragment")
+	AddProgram("shadow_variance", "depth_vertex", "shadow_variance_fragment")
+}
@@ -6,7 +6,9 @@
 package shaders
 
 // Generates shaders sources from this directory and include directory *.glsl files
-//go:generate g3nshaders -in=. -out=sources.go -pkg=shaders -v
+// for the desktop OpenGL and for the OpenGL ES and WebGL builds
+//go:generate g3nshaders -in=. -out=sources.go -pkg=shaders "-build=darwin freebsd linux windows" -build=!android -build=!ios -build=!js -v
+//go:generate g3nshaders -in=. -out=sources-gles.go -pkg=shaders "-build=android ios js" -v
 
 // ProgramInfo contains information for a registered shader program
 type ProgramInfo struct {
@@ -0,0 +1,17 @@
+precision mediump float;
+//
+// Fragment shader of the separable box blur of the variance shadow maps
+//
+#include <post>
+
+void main() {
+
+    // PostEffect[0]: texel offset along the blur direction (xy) and kernel radius (z)
+    int radius = int(PostEffect[0].z);
+    vec4 sum = vec4(0.0);
+    for (int i = -radius; i <= radius; i++) {
+        sum += texture(PostColor, FragTexcoord + float(i) * PostEffect[0].xy);
+    }
+    FragColor = sum / float(2 * radius + 1);
+}
+
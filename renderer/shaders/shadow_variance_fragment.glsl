@@ -0,0 +1,17 @@
+precision mediump float;
+//
+// Fragment shader of the variance shadow maps depth moments pass
+//
+
+// Output
+out vec4 FragColor;
+
+void main() {
+
+    // Depth and squared depth, biased by the depth slope to reduce the shadow acne
+    float depth = gl_FragCoord.z;
+    float dx = dFdx(depth);
+    float dy = dFdy(depth);
+    FragColor = vec4(depth, depth * depth + 0.25 * (dx * dx + dy * dy), 0.0, 1.0);
+}
+
@@ -0,0 +1,29 @@
+precision mediump float;
+//
+// Fragment shader for gradient sky domes
+//
+
+// Sky colors uniform
+uniform vec3 Sky[3];
+#define SkyTopColor         Sky[0]
+#define SkyHorizonColor     Sky[1]
+#define SkyBottomColor      Sky[2]
+
+// Inputs from vertex shader
+in vec3 Direction;
+
+// Output
+out vec4 FragColor;
+
+void main() {
+
+    float h = normalize(Direction).y;
+    vec3 color;
+    if (h >= 0.0) {
+        color = mix(SkyHorizonColor, SkyTopColor, h);
+    } else {
+        color = mix(SkyHorizonColor, SkyBottomColor, -h);
+    }
+    FragColor = vec4(color, 1.0);
+}
+
@@ -0,0 +1,18 @@
+//
+// Vertex shader for gradient sky domes
+//
+#include <attributes>
+
+// Model uniforms
+uniform mat4 MVP;
+
+// Output for fragment shader
+out vec3 Direction;
+
+void main() {
+
+    Direction = VertexPosition;
+    // Places the dome on the far plane, behind everything else
+    gl_Position = (MVP * vec4(VertexPosition, 1.0)).xyww;
+}
+
@@ -1,8 +1,9 @@
+//go:build android || ios || js
 // +build android ios js
 
-// Based on File generated by G3NSHADERS :
-//  - add precision qualifer
-//  - int to float when needed
+// Code generated by G3NSHADERS. DO NOT EDIT.
+// To regenerate this file install 'g3nshaders' and execute:
+// 'go generate' in this folder.
 
 package shaders
 
@@ -15,6 +16,7 @@ layout(location = 2) in  vec3  VertexColor;
 layout(location = 3) in  vec2  VertexTexcoord;
 layout(location = 4) in  float VertexDistance;
 layout(location = 5) in  vec4  VertexTexoffsets;
+layout(location = 6) in  vec4  VertexTangent;
 
 
 `
@@ -64,6 +66,177 @@ const include_bones_vertex_declaration_source = `#ifdef BONE_INFLUENCERS
 #endif
 `
 
+const include_emissive_source = `//
+// Emissive color added after the lighting
+// Requires the FragTexcoord fragment shader input.
+//
+#ifdef EMISSIVE
+
+#ifdef EMISSIVE_MAP
+    uniform sampler2D MatEmissiveMap;
+    uniform vec2 MatEmissiveMapInfo[3];
+#endif
+
+// Returns the emissive color of the fragment, which is not clamped
+// so colors above 1 are kept by the HDR rendering and drive the bloom.
+vec3 emissiveColor() {
+
+#ifdef EMISSIVE_MAP
+    vec2 uv = FragTexcoord;
+#if MAT_TEXTURES > 0
+    // The texture coordinates are already flipped by the first material texture
+    if (bool(MatEmissiveMapInfo[2].x) != MatTexFlipY(0)) {
+        uv.y = 1.0 - uv.y;
+    }
+#else
+    if (bool(MatEmissiveMapInfo[2].x)) {
+        uv.y = 1.0 - uv.y;
+    }
+#endif
+    uv = uv * MatEmissiveMapInfo[1] + MatEmissiveMapInfo[0];
+    return MatEmissiveColor * texture(MatEmissiveMap, uv).rgb;
+#else
+    return MatEmissiveColor;
+#endif
+}
+
+#endif
+`
+
+const include_envmap_source = `//
+// Environment cube map reflections with optional box projection
+// Requires the WorldPosition and WorldNormal fragment shader inputs.
+//
+#ifdef ENV_MAP
+
+in vec3 WorldPosition;  // Fragment position in world coordinates
+in vec3 WorldNormal;    // Fragment normal in world coordinates
+
+uniform samplerCube MatEnvMap;
+uniform vec3 CameraPosition;
+#ifdef ENV_MAP_BOX
+    // Box projection parameters in world coordinates
+    uniform vec3 EnvMapBox[3];
+    #define EnvMapBoxMin        EnvMapBox[0]
+    #define EnvMapBoxMax        EnvMapBox[1]
+    #define EnvMapPosition      EnvMapBox[2]
+#endif
+
+// Returns the environment color reflected by the fragment.
+vec3 envMapColor() {
+
+    vec3 normal = normalize(WorldNormal);
+    if (!gl_FrontFacing) {
+        normal = -normal;
+    }
+    vec3 dir = reflect(normalize(WorldPosition - CameraPosition), normal);
+#ifdef ENV_MAP_BOX
+    // Intersects the reflected ray with the box and samples the
+    // direction from the capture position to the intersection
+    vec3 first = (EnvMapBoxMax - WorldPosition) / dir;
+    vec3 second = (EnvMapBoxMin - WorldPosition) / dir;
+    vec3 furthest = max(first, second);
+    float dist = min(min(furthest.x, furthest.y), furthest.z);
+    dir = WorldPosition + dir * dist - EnvMapPosition;
+#endif
+    return texture(MatEnvMap, dir).rgb;
+}
+
+#endif
+`
+
+const include_fog_source = `#ifdef FOG
+//
+// Fog blending the fragments with the fog color with their distance from the camera
+//
+
+// Fog color (rgb) and mode (a: 1 linear, 2 exponential squared) in the first vec4
+// and near and far distances of the linear fog (xy) and density (z) in the second vec4
+uniform vec4 Fog[2];
+
+// Returns the specified color blended with the fog color.
+// The distance from the camera plane is derived from the perspective depth.
+vec3 applyFog(vec3 color) {
+
+    float dist = 1.0 / gl_FragCoord.w;
+    float f;
+    if (Fog[0].a < 1.5) {
+        f = clamp((dist - Fog[1].x) / (Fog[1].y - Fog[1].x), 0.0, 1.0);
+    } else {
+        f = 1.0 - clamp(exp(-Fog[1].z * Fog[1].z * dist * dist), 0.0, 1.0);
+    }
+    return mix(color, Fog[0].rgb, f);
+}
+#endif
+`
+
+const include_ibl_source = `//
+// Image based lighting precomputation inputs and functions
+//
+
+// Environment cube map
+uniform samplerCube IBLEnvMap;
+
+// Cube face (x), roughness (y), number of samples (z) and environment faces size (w)
+uniform vec4 IBLInfo;
+
+// Input from vertex shader
+in vec2 FragTexcoord;
+
+// Output
+out vec4 FragColor;
+
+const float IBL_PI = 3.14159265359;
+
+// Returns the direction of the texel of the current cube face at the specified texture coordinates.
+vec3 iblDirection(vec2 uv) {
+
+    vec2 st = uv * 2.0 - 1.0;
+    int face = int(IBLInfo.x + 0.5);
+    vec3 dir;
+    if (face == 0) {
+        dir = vec3(1.0, -st.y, -st.x);
+    } else if (face == 1) {
+        dir = vec3(-1.0, -st.y, st.x);
+    } else if (face == 2) {
+        dir = vec3(st.x, 1.0, st.y);
+    } else if (face == 3) {
+        dir = vec3(st.x, -1.0, -st.y);
+    } else if (face == 4) {
+        dir = vec3(st.x, -st.y, 1.0);
+    } else {
+        dir = vec3(-st.x, -st.y, -1.0);
+    }
+    return normalize(dir);
+}
+
+// Returns the point of the specified index of the Hammersley sequence of n points.
+vec2 hammersley(int i, int n) {
+
+    uint bits = uint(i);
+    bits = (bits << 16u) | (bits >> 16u);
+    bits = ((bits & 0x55555555u) << 1u) | ((bits & 0xAAAAAAAAu) >> 1u);
+    bits = ((bits & 0x33333333u) << 2u) | ((bits & 0xCCCCCCCCu) >> 2u);
+    bits = ((bits & 0x0F0F0F0Fu) << 4u) | ((bits & 0xF0F0F0F0u) >> 4u);
+    bits = ((bits & 0x00FF00FFu) << 8u) | ((bits & 0xFF00FF00u) >> 8u);
+    return vec2(float(i) / float(n), float(bits) * 2.3283064365386963e-10);
+}
+
+// Returns the GGX importance sampled half vector around the normal N for the specified roughness.
+vec3 importanceSampleGGX(vec2 xi, vec3 N, float roughness) {
+
+    float a = roughness * roughness;
+    float phi = 2.0 * IBL_PI * xi.x;
+    float cosTheta = sqrt((1.0 - xi.y) / (1.0 + (a * a - 1.0) * xi.y));
+    float sinTheta = sqrt(1.0 - cosTheta * cosTheta);
+    vec3 H = vec3(cos(phi) * sinTheta, sin(phi) * sinTheta, cosTheta);
+    vec3 up = abs(N.z) < 0.999 ? vec3(0.0, 0.0, 1.0) : vec3(1.0, 0.0, 0.0);
+    vec3 tangent = normalize(cross(up, N));
+    vec3 bitangent = cross(N, tangent);
+    return normalize(tangent * H.x + bitangent * H.y + N * H.z);
+}
+`
+
 const include_lights_source = `//
 // Lights uniforms
 //
@@ -111,7 +284,7 @@ const include_material_source = `//
 //
 
 // Material parameters uniform array
-uniform mediump vec3 Material[6];
+uniform mediump vec3 Material[7];
 // Macros to access elements inside the Material array
 #define MatAmbientColor		Material[0]
 #define MatDiffuseColor     Material[1]
@@ -121,6 +294,9 @@ uniform mediump vec3 Material[6];
 #define MatOpacity          Material[4].y
 #define MatPointSize        Material[4].z
 #define MatPointRotationZ   Material[5].x
+#define MatParallaxScale    Material[5].y
+#define MatReflectivity     Material[5].z
+#define MatAlphaTest        Material[6].x
 
 #if MAT_TEXTURES > 0
     // Texture unit sampler array
@@ -150,11 +326,11 @@ uniform mediump vec3 Material[6];
     }
 
 // TODO for alpha blending dont use mix use implementation below (similar to one in panel shader)
-            //vec4 prevTexPre = texMixed;                                                      
-            //prevTexPre.rgb *= prevTexPre.a;                                                  
-            //vec4 currTexPre = texColor;                                                      
-            //currTexPre.rgb *= currTexPre.a;                                                  
-            //texMixed = currTexPre + prevTexPre * (1 - currTexPre.a);                         
+            //vec4 prevTexPre = texMixed;                                                      \
+            //prevTexPre.rgb *= prevTexPre.a;                                                  \
+            //vec4 currTexPre = texColor;                                                      \
+            //currTexPre.rgb *= currTexPre.a;                                                  \
+            //texMixed = currTexPre + prevTexPre * (1 - currTexPre.a);                         \
             //texMixed.rgb /= texMixed.a;
 `
 
@@ -182,6 +358,121 @@ const include_morphtarget_vertex_declaration2_source = `	in vec3 MorphPosition{i
   #endif
 `
 
+const include_normalmap_source = `//
+// Normal and height (parallax occlusion) mapping
+// Requires the Normal, Tangent, Position and FragTexcoord fragment shader inputs.
+//
+#if defined(NORMAL_MAP) || defined(PARALLAX)
+
+in vec4 Tangent;        // Vertex tangent in camera coordinates (w: handedness)
+
+#ifdef NORMAL_MAP
+    uniform sampler2D MatNormalMap;
+    uniform vec2 MatNormalMapInfo[3];
+#endif
+#ifdef PARALLAX
+    uniform sampler2D MatHeightMap;
+    uniform vec2 MatHeightMapInfo[3];
+#endif
+
+// Returns the tangent space matrix for the specified normal.
+// If the geometry has no tangents they are calculated from the screen space derivatives.
+mat3 tangentSpace(vec3 normal) {
+
+    vec3 t = Tangent.xyz;
+    float handedness = Tangent.w;
+    if (dot(t, t) < 1e-6) {
+        vec3 pos_dx = dFdx(Position.xyz);
+        vec3 pos_dy = dFdy(Position.xyz);
+        vec2 tex_dx = dFdx(FragTexcoord);
+        vec2 tex_dy = dFdy(FragTexcoord);
+        t = tex_dy.t * pos_dx - tex_dx.t * pos_dy;
+        handedness = sign(tex_dx.s * tex_dy.t - tex_dy.s * tex_dx.t);
+    }
+    t = normalize(t - normal * dot(normal, t));
+    vec3 b = cross(normal, t) * handedness;
+    return mat3(t, b, normal);
+}
+
+#ifdef NORMAL_MAP
+// Returns the normal in camera coordinates perturbed by the normal map.
+vec3 normalMap(mat3 tbn, vec2 texcoord) {
+
+    vec2 uv = texcoord * MatNormalMapInfo[1] + MatNormalMapInfo[0];
+    vec3 n = texture(MatNormalMap, uv).rgb * 2.0 - 1.0;
+    return normalize(tbn * n);
+}
+#endif
+
+#ifdef PARALLAX
+// Returns the texture coordinates displaced by parallax occlusion mapping
+// for the specified view direction in tangent space.
+vec2 parallaxMap(vec2 texcoord, vec3 viewDir) {
+
+    const float minLayers = 8.0;
+    const float maxLayers = 32.0;
+    float numLayers = mix(maxLayers, minLayers, abs(viewDir.z));
+    float layerDepth = 1.0 / numLayers;
+    vec2 delta = viewDir.xy / max(viewDir.z, 0.05) * MatParallaxScale / numLayers;
+
+    // Gradients are calculated outside the loop to keep them well defined
+    vec2 scale = MatHeightMapInfo[1];
+    vec2 offset = MatHeightMapInfo[0];
+    vec2 dx = dFdx(texcoord * scale);
+    vec2 dy = dFdy(texcoord * scale);
+
+    // Steps along the view direction until the height field is crossed
+    vec2 uv = texcoord;
+    float depth = 0.0;
+    float mapDepth = 1.0 - textureGrad(MatHeightMap, uv * scale + offset, dx, dy).r;
+    for (int i = 0; i < int(maxLayers); i++) {
+        if (depth >= mapDepth) {
+            break;
+        }
+        uv -= delta;
+        mapDepth = 1.0 - textureGrad(MatHeightMap, uv * scale + offset, dx, dy).r;
+        depth += layerDepth;
+    }
+
+    // Interpolates between the last two layers
+    vec2 prevUV = uv + delta;
+    float after = mapDepth - depth;
+    float before = 1.0 - textureGrad(MatHeightMap, prevUV * scale + offset, dx, dy).r - depth + layerDepth;
+    float weight = after / (after - before);
+    return mix(uv, prevUV, weight);
+}
+#endif
+
+#endif
+`
+
+const include_oit_source = `//
+// Fragment shader output of the materials supporting the weighted blended
+// order independent transparency, which replaces the FragColor output by the
+// accumulated color and weight outputs when OIT is defined.
+//
+#ifdef OIT
+
+layout(location = 0) out vec4 OITAccum;
+layout(location = 1) out vec4 OITWeight;
+vec4 FragColor;
+
+// Writes FragColor weighted by its alpha and depth to the accumulation outputs.
+// The weight function is from "Weighted Blended Order-Independent Transparency"
+// (McGuire and Bavoil, 2013), which favors the near and opaque fragments.
+void oitWrite() {
+
+    float a = FragColor.a;
+    float w = clamp(a * max(1e-2, 3e3 * pow(1.0 - gl_FragCoord.z, 3.0)), 1e-2, 3e3);
+    OITAccum = vec4(FragColor.rgb * a * w, a);
+    OITWeight = vec4(a * w, 0.0, 0.0, a);
+}
+
+#else
+out vec4 FragColor;
+#endif
+`
+
 const include_phong_model_source = `/***
  phong lighting model
  Parameters:
@@ -224,12 +515,18 @@ void phongModel(vec4 position, vec3 normal, vec3 camDir, vec3 matAmbient, vec3 m
         vec3 lightDirection = normalize(DirLightPosition(i));
         // Calculates the dot product between the light direction and this vertex normal.
         float dotNormal = max(dot(lightDirection, normal), 0.0);
-        diffuseTotal += DirLightColor(i) * matDiffuse * dotNormal;
+#ifdef SHADOWS
+        // The first directional light casts the shadows
+        float shadow = i == 0 ? shadowFactor(position) : 1.0;
+#else
+        float shadow = 1.0;
+#endif
+        diffuseTotal += DirLightColor(i) * matDiffuse * dotNormal * shadow;
         // Specular reflection
         // Calculates the light reflection vector
         vec3 ref = reflect(-lightDirection, normal);
         if (dotNormal > 0.0) {
-            specularTotal += DirLightColor(i) * MatSpecularColor * pow(max(dot(ref, camDir), 0.0), MatShininess);
+            specularTotal += DirLightColor(i) * MatSpecularColor * pow(max(dot(ref, camDir), 0.0), MatShininess) * shadow;
         }
     }
 #endif
@@ -293,12 +590,127 @@ void phongModel(vec4 position, vec3 normal, vec3 camDir, vec3 matAmbient, vec3 m
 #endif
 
     // Sets output colors
-    ambdiff = ambientTotal + MatEmissiveColor + diffuseTotal;
+    ambdiff = ambientTotal + diffuseTotal;
     spec = specularTotal;
 }
 `
 
+const include_post_source = `//
+// Post processing effects inputs
+//
+
+// Color of the previous pass and depth of the scene
+uniform sampler2D PostColor;
+uniform sampler2D PostDepth;
+
+// Texel size (xy) and projection matrix elements [10] and [14] (zw)
+uniform vec4 PostInfo;
+
+// Effect parameters
+uniform vec4 PostEffect[2];
+
+// Input from vertex shader
+in vec2 FragTexcoord;
+
+// Output
+out vec4 FragColor;
+
+// Returns the distance from the camera plane of the scene point at the specified coordinates.
+float postViewDistance(vec2 uv) {
+
+    float z = texture(PostDepth, uv).r * 2.0 - 1.0;
+    return PostInfo.w / (z + PostInfo.z);
+}
+`
+
+const include_shadows_source = `#ifdef SHADOWS
+//
+// Shadow of the first directional light sampled from its shadow map
+//
+
+// Depth of the shadow casters seen from the light
+uniform sampler2D ShadowMap;
+// Transforms the camera coordinates to the shadow map coordinates and depth
+uniform mat4 ShadowMatrix;
+// Texel size (x), depth bias (y), PCF kernel radius (z) and exponential factor
+// or variance light bleeding reduction (w)
+uniform vec4 ShadowInfo;
+
+// Returns the fraction of the light received at the specified position in camera coordinates,
+// from 0.0 if it is in the shadow to 1.0 if it is lit.
+float shadowFactor(vec4 position) {
+
+    vec4 coord = ShadowMatrix * position;
+    vec3 p = coord.xyz / coord.w;
+    if (p.x < 0.0 || p.x > 1.0 || p.y < 0.0 || p.y > 1.0 || p.z > 1.0) {
+        return 1.0;
+    }
+    float depth = p.z - ShadowInfo.y;
+#if defined(SHADOW_PCF)
+    // Percentage of the kernel texels closer to the light than the position
+    int radius = int(ShadowInfo.z);
+    float lit = 0.0;
+    for (int x = -radius; x <= radius; x++) {
+        for (int y = -radius; y <= radius; y++) {
+            vec2 offset = vec2(float(x), float(y)) * ShadowInfo.x;
+            lit += depth <= texture(ShadowMap, p.xy + offset).r ? 1.0 : 0.0;
+        }
+    }
+    float size = float(2 * radius + 1);
+    return lit / (size * size);
+#elif defined(SHADOW_EXP)
+    // Exponential falloff with the distance behind the occluder
+    float occluder = texture(ShadowMap, p.xy).r;
+    return clamp(exp(ShadowInfo.w * (occluder - depth)), 0.0, 1.0);
+#elif defined(SHADOW_VSM)
+    // Upper bound of the lit fraction from the depth mean and variance (Chebyshev's inequality)
+    vec2 moments = texture(ShadowMap, p.xy).rg;
+    if (depth <= moments.x) {
+        return 1.0;
+    }
+    float variance = max(moments.y - moments.x * moments.x, 0.00002);
+    float d = depth - moments.x;
+    float pmax = variance / (variance + d * d);
+    // Cuts off the low fractions to reduce the light bleeding
+    return clamp((pmax - ShadowInfo.w) / (1.0 - ShadowInfo.w), 0.0, 1.0);
+#else
+    return depth <= texture(ShadowMap, p.xy).r ? 1.0 : 0.0;
+#endif
+}
+#endif
+`
+
+const include_soft_particles_source = `#ifdef SOFT_PARTICLES
+//
+// Soft particles fading near the opaque objects behind them
+//
+
+// Depth of the opaque objects of the scene
+uniform sampler2D SoftDepth;
+
+// Texel size (xy) and projection matrix elements [10] and [14] (zw) in the first vec4
+// and viewport position (xy) and fade distance (z) in the second vec4
+uniform vec4 SoftInfo[2];
+
+// Returns the distance from the camera plane of the specified depth buffer value.
+float softViewDistance(float depth) {
+
+    return SoftInfo[0].w / (depth * 2.0 - 1.0 + SoftInfo[0].z);
+}
+
+// Returns the opacity factor of the fragment, which fades
+// as it approaches the opaque objects behind it.
+float softParticlesFade() {
+
+    vec2 uv = (gl_FragCoord.xy - SoftInfo[1].xy) * SoftInfo[0].xy;
+    float scene = softViewDistance(texture(SoftDepth, uv).r);
+    return clamp((scene - softViewDistance(gl_FragCoord.z)) / SoftInfo[1].z, 0.0, 1.0);
+}
+#endif
+`
+
 const basic_fragment_source = `precision mediump float;
+
 //
 // Fragment Shader template
 //
@@ -333,127 +745,453 @@ void main() {
 
 `
 
-const panel_fragment_source = `precision mediump float;
+const dashed_fragment_source = `precision mediump float;
 //
-// Fragment Shader template
+// Fragment shader for dashed lines
 //
 
-// Texture uniforms
-uniform sampler2D	MatTexture;
-uniform vec2		MatTexinfo[3];
-
-// Macros to access elements inside the MatTexinfo array
-#define MatTexOffset		MatTexinfo[0]
-#define MatTexRepeat		MatTexinfo[1]
-#define MatTexFlipY	    	bool(MatTexinfo[2].x) // not used
-#define MatTexVisible	    bool(MatTexinfo[2].y) // not used
+// Dash uniform
+uniform vec2 Dash;
+#define DashSize    Dash[0]
+#define GapSize     Dash[1]
 
 // Inputs from vertex shader
-in vec2 FragTexcoord;
-
-// Input uniform
-uniform vec4 Panel[8];
-#define Bounds			Panel[0]		  // panel bounds in texture coordinates
-#define Border			Panel[1]		  // panel border in texture coordinates
-#define Padding			Panel[2]		  // panel padding in texture coordinates
-#define Content			Panel[3]		  // panel content area in texture coordinates
-#define BorderColor		Panel[4]		  // panel border color
-#define PaddingColor	Panel[5]		  // panel padding color
-#define ContentColor	Panel[6]		  // panel content color
-#define TextureValid	bool(Panel[7].x)  // texture valid flag
+in vec3 Color;
+in float Distance;
 
 // Output
 out vec4 FragColor;
 
+void main() {
 
-/***
-* Checks if current fragment texture coordinate is inside the
-* supplied rectangle in texture coordinates:
-* rect[0] - position x [0,1]
-* rect[1] - position y [0,1]
-* rect[2] - width [0,1]
-* rect[3] - height [0,1]
-*/
-bool checkRect(vec4 rect) {
-
-    if (FragTexcoord.x < rect[0]) {
-        return false;
-    }
-    if (FragTexcoord.x > rect[0] + rect[2]) {
-        return false;
-    }
-    if (FragTexcoord.y < rect[1]) {
-        return false;
-    }
-    if (FragTexcoord.y > rect[1] + rect[3]) {
-        return false;
+    if (mod(Distance, DashSize + GapSize) > DashSize) {
+        discard;
     }
-    return true;
+    FragColor = vec4(Color, 1.0);
 }
 
+`
 
-void main() {
+const dashed_vertex_source = `//
+// Vertex shader for dashed lines
+//
+#include <attributes>
 
-    // Discard fragment outside of received bounds
-    // Bounds[0] - xmin
-    // Bounds[1] - ymin
-    // Bounds[2] - xmax
-    // Bounds[3] - ymax
-    if (FragTexcoord.x <= Bounds[0] || FragTexcoord.x >= Bounds[2]) {
-        discard;
-    }
-    if (FragTexcoord.y <= Bounds[1] || FragTexcoord.y >= Bounds[3]) {
-        discard;
-    }
+// Model uniforms
+uniform mat4 MVP;
 
-    // Check if fragment is inside content area
-    if (checkRect(Content)) {
+// Outputs for fragment shader
+out vec3 Color;
+out float Distance;
 
-        // If no texture, the color will be the material color.
-        vec4 color = ContentColor;
+void main() {
 
-		if (TextureValid) {
-            // Adjust texture coordinates to fit texture inside the content area
-            vec2 offset = vec2(-Content[0], -Content[1]);
-            vec2 factor = vec2(1/Content[2], 1/Content[3]);
-            vec2 texcoord = (FragTexcoord + offset) * factor;
-            vec4 texColor = texture(MatTexture, texcoord * MatTexRepeat + MatTexOffset);
+    Color = VertexColor;
+    Distance = VertexDistance;
+    gl_Position = MVP * vec4(VertexPosition, 1.0);
+}
 
-            // Mix content color with texture color.
-            // Note that doing a simple linear interpolation (e.g. using mix()) is not correct!
-            // The right formula can be found here: https://en.wikipedia.org/wiki/Alpha_compositing#Alpha_blending
-            // For a more in-depth discussion: http://apoorvaj.io/alpha-compositing-opengl-blending-and-premultiplied-alpha.html#toc4
+`
 
-            // Pre-multiply the content color
-            vec4 contentPre = ContentColor;
-            contentPre.rgb *= contentPre.a;
+const debug_fragment_source = `precision highp float;
+//
+// Fragment shader of the debug views
+//
 
-            // Pre-multiply the texture color
-            vec4 texPre = texColor;
-            texPre.rgb *= texPre.a;
+// Camera near (x) and far (y) planes distances
+uniform vec4 DebugInfo;
 
-            // Combine colors the premultiplied final color
-            color = texPre + contentPre * (1 - texPre.a);
+// Inputs from vertex shader
+in vec3 Normal;
+in vec2 Texcoord;
+in float Depth;
 
-            // Un-pre-multiply (pre-divide? :P)
-            color.rgb /= color.a;
-		}
+// Output
+out vec4 FragColor;
 
-        FragColor = color;
-        return;
-    }
+void main() {
 
-    // Checks if fragment is inside paddings area
-    if (checkRect(Padding)) {
-        FragColor = PaddingColor;
-        return;
-    }
+#ifdef DEBUG_NORMALS
+    FragColor = vec4(normalize(Normal) * 0.5 + 0.5, 1.0);
+#elif defined(DEBUG_UVS)
+    FragColor = vec4(fract(Texcoord), 0.0, 1.0);
+#elif defined(DEBUG_OVERDRAW)
+    // Each fragment adds to the blended color
+    FragColor = vec4(0.1, 0.05, 0.02, 1.0);
+#elif defined(DEBUG_DEPTH)
+    float depth = clamp((Depth - DebugInfo.x) / (DebugInfo.y - DebugInfo.x), 0.0, 1.0);
+    FragColor = vec4(vec3(depth), 1.0);
+#else
+    FragColor = vec4(1.0);
+#endif
+}
 
-    // Checks if fragment is inside borders area
-    if (checkRect(Border)) {
-        FragColor = BorderColor;
-        return;
-    }
+`
+
+const debug_vertex_source = `//
+// Vertex shader of the debug views
+//
+#include <attributes>
+
+// Model uniforms
+uniform mat4 ModelMatrix;
+uniform mat4 ModelViewMatrix;
+uniform mat4 MVP;
+
+// Outputs for fragment shader
+out vec3 Normal;
+out vec2 Texcoord;
+out float Depth;
+
+void main() {
+
+    Normal = transpose(inverse(mat3(ModelMatrix))) * VertexNormal;
+    Texcoord = VertexTexcoord;
+    Depth = -(ModelViewMatrix * vec4(VertexPosition, 1.0)).z;
+    gl_Position = MVP * vec4(VertexPosition, 1.0);
+}
+
+`
+
+const depth_fragment_source = `precision mediump float;
+//
+// Fragment shader for depth only passes
+//
+
+// Output
+out vec4 FragColor;
+
+void main() {
+
+    FragColor = vec4(1.0);
+}
+
+`
+
+const depth_vertex_source = `//
+// Vertex shader for depth only passes
+//
+#include <attributes>
+
+// Model uniforms
+uniform mat4 MVP;
+
+void main() {
+
+    gl_Position = MVP * vec4(VertexPosition, 1.0);
+}
+
+`
+
+const ibl_brdf_fragment_source = `precision highp float;
+precision highp int;
+//
+// Fragment shader of the split sum BRDF lookup table, indexed by the cosine
+// of the view angle (x) and the roughness (y), with the scale (r) and the bias (g)
+// of the specular color.
+//
+#include <ibl>
+
+// Returns the Schlick-GGX geometry term of the specified cosine with the image based lighting remapping.
+float geometrySchlickGGX(float NdotX, float roughness) {
+
+    float k = roughness * roughness * 0.5;
+    return NdotX / (NdotX * (1.0 - k) + k);
+}
+
+void main() {
+
+    float NdotV = max(FragTexcoord.x, 0.0001);
+    float roughness = FragTexcoord.y;
+    vec3 N = vec3(0.0, 0.0, 1.0);
+    vec3 V = vec3(sqrt(1.0 - NdotV * NdotV), 0.0, NdotV);
+    int count = int(IBLInfo.z);
+
+    float scale = 0.0;
+    float bias = 0.0;
+    for (int i = 0; i < count; i++) {
+        vec3 H = importanceSampleGGX(hammersley(i, count), N, roughness);
+        vec3 L = normalize(2.0 * dot(V, H) * H - V);
+        float NdotL = max(L.z, 0.0);
+        if (NdotL > 0.0) {
+            float NdotH = max(H.z, 0.0);
+            float VdotH = max(dot(V, H), 0.0);
+            float G = geometrySchlickGGX(NdotV, roughness) * geometrySchlickGGX(NdotL, roughness);
+            float visibility = G * VdotH / (NdotH * NdotV);
+            float fresnel = pow(1.0 - VdotH, 5.0);
+            scale += (1.0 - fresnel) * visibility;
+            bias += fresnel * visibility;
+        }
+    }
+    FragColor = vec4(scale / float(count), bias / float(count), 0.0, 1.0);
+}
+
+`
+
+const ibl_irradiance_fragment_source = `precision highp float;
+precision highp int;
+//
+// Fragment shader of the diffuse irradiance cube map of an environment
+//
+#include <ibl>
+
+void main() {
+
+    // Tangent space of the face direction
+    vec3 N = iblDirection(FragTexcoord);
+    vec3 up = abs(N.y) < 0.999 ? vec3(0.0, 1.0, 0.0) : vec3(0.0, 0.0, 1.0);
+    vec3 right = normalize(cross(up, N));
+    up = cross(N, right);
+
+    // Cosine weighted sum of the environment over the hemisphere
+    vec3 irradiance = vec3(0.0);
+    float count = 0.0;
+    const float delta = 0.05;
+    for (float phi = 0.0; phi < 2.0 * IBL_PI; phi += delta) {
+        for (float theta = 0.0; theta < 0.5 * IBL_PI; theta += delta) {
+            vec3 t = vec3(sin(theta) * cos(phi), sin(theta) * sin(phi), cos(theta));
+            vec3 dir = t.x * right + t.y * up + t.z * N;
+            irradiance += texture(IBLEnvMap, dir).rgb * cos(theta) * sin(theta);
+            count += 1.0;
+        }
+    }
+    FragColor = vec4(IBL_PI * irradiance / count, 1.0);
+}
+
+`
+
+const ibl_prefilter_fragment_source = `precision highp float;
+precision highp int;
+//
+// Fragment shader of a roughness level of the prefiltered specular cube map of an environment
+//
+#include <ibl>
+
+void main() {
+
+    // The view direction is assumed to be the normal direction
+    vec3 N = iblDirection(FragTexcoord);
+    float roughness = IBLInfo.y;
+    int count = int(IBLInfo.z);
+    float a2 = roughness * roughness * roughness * roughness;
+    float saTexel = 4.0 * IBL_PI / (6.0 * IBLInfo.w * IBLInfo.w);
+
+    vec3 color = vec3(0.0);
+    float weight = 0.0;
+    for (int i = 0; i < count; i++) {
+        vec3 H = importanceSampleGGX(hammersley(i, count), N, roughness);
+        vec3 L = normalize(2.0 * dot(N, H) * H - N);
+        float NdotL = dot(N, L);
+        if (NdotL > 0.0) {
+            // Samples a lower environment level for the less probable directions to reduce the aliasing
+            float NdotH = max(dot(N, H), 0.0);
+            float d = NdotH * NdotH * (a2 - 1.0) + 1.0;
+            float pdf = a2 / (IBL_PI * d * d) * 0.25 + 0.0001;
+            float saSample = 1.0 / (float(count) * pdf + 0.0001);
+            float lod = roughness == 0.0 ? 0.0 : max(0.5 * log2(saSample / saTexel), 0.0);
+            color += textureLod(IBLEnvMap, L, lod).rgb * NdotL;
+            weight += NdotL;
+        }
+    }
+    FragColor = vec4(color / max(weight, 0.0001), 1.0);
+}
+
+`
+
+const mirror_fragment_source = `precision mediump float;
+//
+// Fragment shader for planar reflections
+//
+
+// Reflection texture uniforms
+uniform sampler2D MirrorMap;
+
+// Material uniform: rgb is the surface color, a is the reflectivity
+uniform vec4 Material[1];
+#define MatColor            Material[0].rgb
+#define MatReflectivity     Material[0].a
+
+// Inputs from vertex shader
+in vec4 MirrorCoord;
+
+// Output
+out vec4 FragColor;
+
+void main() {
+
+    vec3 reflected = textureProj(MirrorMap, MirrorCoord).rgb;
+    FragColor = vec4(mix(MatColor, reflected, MatReflectivity), 1.0);
+}
+
+`
+
+const mirror_vertex_source = `//
+// Vertex shader for planar reflections
+//
+#include <attributes>
+
+// Model uniforms
+uniform mat4 MVP;
+
+// Projects the model coordinates to the reflection texture
+uniform mat4 MirrorTextureMatrix;
+
+// Output for fragment shader
+out vec4 MirrorCoord;
+
+void main() {
+
+    MirrorCoord = MirrorTextureMatrix * vec4(VertexPosition, 1.0);
+    gl_Position = MVP * vec4(VertexPosition, 1.0);
+}
+
+`
+
+const oit_composite_fragment_source = `precision mediump float;
+//
+// Fragment shader compositing the weighted blended order independent transparency
+//
+#include <post>
+
+// Sum of the weights of the transparent fragments
+uniform sampler2D OITWeights;
+
+void main() {
+
+    // The accumulated alpha is the product of the transparent fragments transmittances
+    vec4 accum = texture(PostColor, FragTexcoord);
+    float revealage = accum.a;
+    if (revealage >= 1.0) {
+        discard;
+    }
+    float weights = texture(OITWeights, FragTexcoord).r;
+    FragColor = vec4(accum.rgb / max(weights, 1e-5), 1.0 - revealage);
+}
+
+`
+
+const panel_fragment_source = `precision mediump float;
+
+//
+// Fragment Shader template
+//
+
+// Texture uniforms
+uniform sampler2D	MatTexture;
+uniform vec2		MatTexinfo[3];
+
+// Macros to access elements inside the MatTexinfo array
+#define MatTexOffset		MatTexinfo[0]
+#define MatTexRepeat		MatTexinfo[1]
+#define MatTexFlipY	    	bool(MatTexinfo[2].x) // not used
+#define MatTexVisible	    bool(MatTexinfo[2].y) // not used
+
+// Inputs from vertex shader
+in vec2 FragTexcoord;
+
+// Input uniform
+uniform vec4 Panel[8];
+#define Bounds			Panel[0]		  // panel bounds in texture coordinates
+#define Border			Panel[1]		  // panel border in texture coordinates
+#define Padding			Panel[2]		  // panel padding in texture coordinates
+#define Content			Panel[3]		  // panel content area in texture coordinates
+#define BorderColor		Panel[4]		  // panel border color
+#define PaddingColor	Panel[5]		  // panel padding color
+#define ContentColor	Panel[6]		  // panel content color
+#define TextureValid	bool(Panel[7].x)  // texture valid flag
+
+// Output
+out vec4 FragColor;
+
+
+/***
+* Checks if current fragment texture coordinate is inside the
+* supplied rectangle in texture coordinates:
+* rect[0] - position x [0,1]
+* rect[1] - position y [0,1]
+* rect[2] - width [0,1]
+* rect[3] - height [0,1]
+*/
+bool checkRect(vec4 rect) {
+
+    if (FragTexcoord.x < rect[0]) {
+        return false;
+    }
+    if (FragTexcoord.x > rect[0] + rect[2]) {
+        return false;
+    }
+    if (FragTexcoord.y < rect[1]) {
+        return false;
+    }
+    if (FragTexcoord.y > rect[1] + rect[3]) {
+        return false;
+    }
+    return true;
+}
+
+
+void main() {
+
+    // Discard fragment outside of received bounds
+    // Bounds[0] - xmin
+    // Bounds[1] - ymin
+    // Bounds[2] - xmax
+    // Bounds[3] - ymax
+    if (FragTexcoord.x <= Bounds[0] || FragTexcoord.x >= Bounds[2]) {
+        discard;
+    }
+    if (FragTexcoord.y <= Bounds[1] || FragTexcoord.y >= Bounds[3]) {
+        discard;
+    }
+
+    // Check if fragment is inside content area
+    if (checkRect(Content)) {
+
+        // If no texture, the color will be the material color.
+        vec4 color = ContentColor;
+
+		if (TextureValid) {
+            // Adjust texture coordinates to fit texture inside the content area
+            vec2 offset = vec2(-Content[0], -Content[1]);
+            vec2 factor = vec2(1/Content[2], 1/Content[3]);
+            vec2 texcoord = (FragTexcoord + offset) * factor;
+            vec4 texColor = texture(MatTexture, texcoord * MatTexRepeat + MatTexOffset);
+
+            // Mix content color with texture color.
+            // Note that doing a simple linear interpolation (e.g. using mix()) is not correct!
+            // The right formula can be found here: https://en.wikipedia.org/wiki/Alpha_compositing#Alpha_blending
+            // For a more in-depth discussion: http://apoorvaj.io/alpha-compositing-opengl-blending-and-premultiplied-alpha.html#toc4
+
+            // Pre-multiply the content color
+            vec4 contentPre = ContentColor;
+            contentPre.rgb *= contentPre.a;
+
+            // Pre-multiply the texture color
+            vec4 texPre = texColor;
+            texPre.rgb *= texPre.a;
+
+            // Combine colors the premultiplied final color
+            color = texPre + contentPre * (1 - texPre.a);
+
+            // Un-pre-multiply (pre-divide? :P)
+            color.rgb /= color.a;
+		}
+
+        FragColor = color;
+        return;
+    }
+
+    // Checks if fragment is inside paddings area
+    if (checkRect(Padding)) {
+        FragColor = PaddingColor;
+        return;
+    }
+
+    // Checks if fragment is inside borders area
+    if (checkRect(Border)) {
+        FragColor = BorderColor;
+        return;
+    }
 
     // Fragment is in margins area (always transparent)
     FragColor = vec4(1,1,1,0);
@@ -488,6 +1226,7 @@ void main() {
 `
 
 const phong_fragment_source = `precision mediump float;
+
 //
 // Fragment Shader template
 //
@@ -500,13 +1239,35 @@ in vec2 FragTexcoord;
 
 #include <lights>
 #include <material>
+#include <fog>
+#include <shadows>
 #include <phong_model>
+#include <normalmap>
+#include <envmap>
+#include <emissive>
 
 // Final fragment color
-out vec4 FragColor;
+#include <oit>
 
 void main() {
 
+    // Inverts the fragment normal if not FrontFacing
+    vec3 fragNormal = normalize(Normal);
+    if (!gl_FrontFacing) {
+        fragNormal = -fragNormal;
+    }
+
+#if defined(NORMAL_MAP) || defined(PARALLAX)
+    mat3 tbn = tangentSpace(fragNormal);
+#endif
+#ifdef PARALLAX
+    // Shadows the FragTexcoord input with the displaced texture coordinates
+    vec2 FragTexcoord = parallaxMap(FragTexcoord, normalize(CamDir * tbn));
+#endif
+#ifdef NORMAL_MAP
+    fragNormal = normalMap(tbn, FragTexcoord);
+#endif
+
     // Mix material color with textures colors
     vec4 texMixed = vec4(1);
     vec4 texColor;
@@ -525,20 +1286,40 @@ void main() {
     vec4 matDiffuse = vec4(MatDiffuseColor, MatOpacity) * texMixed;
     vec4 matAmbient = vec4(MatAmbientColor, MatOpacity) * texMixed;
 
-    // Inverts the fragment normal if not FrontFacing
-    vec3 fragNormal = Normal;
-    if (!gl_FrontFacing) {
-        fragNormal = -fragNormal;
+    // Calculates the Ambient+Diffuse and Specular colors for this fragment using the Phong model.
+    vec3 Ambdiff, Spec;
+    phongModel(Position, fragNormal, CamDir, vec3(matAmbient), vec3(matDiffuse), Ambdiff, Spec);
+
+    // Final fragment color
+    FragColor = min(vec4(Ambdiff + Spec, matDiffuse.a), vec4(1.0));
+
+#ifdef ALPHA_TEST
+    // Discards the fragments below the alpha test threshold
+    if (FragColor.a < MatAlphaTest) {
+        discard;
     }
+#endif
 
-    // Calculates the Ambient+Diffuse and Specular colors for this fragment using the Phong model.
-    vec3 Ambdiff, Spec;
-    phongModel(Position, fragNormal, CamDir, vec3(matAmbient), vec3(matDiffuse), Ambdiff, Spec);
+#ifdef ENV_MAP
+    // Mix with the reflected environment color
+    FragColor.rgb = mix(FragColor.rgb, envMapColor(), MatReflectivity);
+#endif
 
-    // Final fragment color
-    FragColor = min(vec4(Ambdiff + Spec, matDiffuse.a), vec4(1.0));
-}
+#ifdef EMISSIVE
+    // Adds the emissive color after the clamped lighting
+    FragColor.rgb += emissiveColor();
+#endif
 
+#ifdef FOG
+    // Blends the fragment with the fog color
+    FragColor.rgb = applyFog(FragColor.rgb);
+#endif
+
+#ifdef OIT
+    // Accumulates the color for the order independent transparency
+    oitWrite();
+#endif
+}
 `
 
 const phong_vertex_source = `//
@@ -550,6 +1331,9 @@ const phong_vertex_source = `//
 uniform mat4 ModelViewMatrix;
 uniform mat3 NormalMatrix;
 uniform mat4 MVP;
+#ifdef TEX_TRANSFORM
+uniform mat3 MatTexTransform;
+#endif
 
 #include <material>
 #include <morphtarget_vertex_declaration>
@@ -560,6 +1344,14 @@ out vec4 Position;
 out vec3 Normal;
 out vec3 CamDir;
 out vec2 FragTexcoord;
+#if defined(NORMAL_MAP) || defined(PARALLAX)
+out vec4 Tangent;
+#endif
+#ifdef ENV_MAP
+uniform mat4 ModelMatrix;
+out vec3 WorldPosition;
+out vec3 WorldNormal;
+#endif
 
 void main() {
 
@@ -569,6 +1361,17 @@ void main() {
     // Transform this vertex normal to camera coordinates.
     Normal = normalize(NormalMatrix * VertexNormal);
 
+#if defined(NORMAL_MAP) || defined(PARALLAX)
+    // Transform this vertex tangent to camera coordinates keeping its handedness.
+    Tangent = vec4(NormalMatrix * VertexTangent.xyz, VertexTangent.w);
+#endif
+
+#ifdef ENV_MAP
+    // Transform this vertex position and normal to world coordinates.
+    WorldPosition = vec3(ModelMatrix * vec4(VertexPosition, 1.0));
+    WorldNormal = mat3(ModelMatrix) * VertexNormal;
+#endif
+
     // Calculate the direction vector from the vertex to the camera
     // The camera is at 0,0,0
     CamDir = normalize(-Position.xyz);
@@ -579,6 +1382,9 @@ void main() {
     if (MatTexFlipY(0)) {
         texcoord.y = 1.0 - texcoord.y;
     }
+#endif
+#ifdef TEX_TRANSFORM
+    texcoord = (MatTexTransform * vec3(texcoord, 1.0)).xy;
 #endif
     FragTexcoord = texcoord;
     vec3 vPosition = VertexPosition;
@@ -591,7 +1397,8 @@ void main() {
 
 `
 
-const physical_fragment_source = `precision mediump float;
+const physical_fragment_source = `precision highp float;
+
 //
 // Physically Based Shading of a microfacet surface material - Fragment Shader
 // Modified from reference implementation at https://github.com/KhronosGroup/glTF-WebGL-PBR
@@ -645,6 +1452,7 @@ uniform vec4 Material[3];
 #define uRoughnessFactor    Material[2].y
 
 #include <lights>
+#include <fog>
 
 // Inputs from vertex shader
 in vec3 Position;       // Vertex position in camera coordinates.
@@ -653,7 +1461,7 @@ in vec3 CamDir;         // Direction from vertex to camera
 in vec2 FragTexcoord;
 
 // Final fragment color
-out vec4 FragColor;
+#include <oit>
 
 // Encapsulate the various inputs used by the various functions in the shading equation
 // We store values in this struct to simplify the integration of alternative implementations
@@ -1002,9 +1810,17 @@ void main() {
 
     // Final fragment color
     FragColor = vec4(pow(color,vec3(1.0/2.2)), baseColor.a);
-}
 
+#ifdef FOG
+    // Blends the fragment with the fog color
+    FragColor.rgb = applyFog(FragColor.rgb);
+#endif
 
+#ifdef OIT
+    // Accumulates the color for the order independent transparency
+    oitWrite();
+#endif
+}
 `
 
 const physical_vertex_source = `//
@@ -1048,101 +1864,481 @@ void main() {
     // #endif
     FragTexcoord = texcoord;
 
-    vec3 vPosition = VertexPosition;
-    mat4 finalWorld = mat4(1.0);
-    #include <morphtarget_vertex>
-    #include <bones_vertex>
+    vec3 vPosition = VertexPosition;
+    mat4 finalWorld = mat4(1.0);
+    #include <morphtarget_vertex>
+    #include <bones_vertex>
+
+    gl_Position = MVP * finalWorld * vec4(vPosition, 1.0);
+
+}
+
+
+`
+
+const point_fragment_source = `precision mediump float;
+
+#include <material>
+#include <fog>
+#include <soft_particles>
+
+// GLSL 3.30 does not allow indexing texture sampler with non constant values.
+// This macro is used to mix the texture with the specified index with the material color.
+// It should be called for each texture index.
+#define MIX_POINT_TEXTURE(i)                                                                                     \
+    if (MatTexVisible(i)) {                                                                                      \
+        vec2 pt = gl_PointCoord - vec2(0.5);                                                                     \
+        vec4 texColor = texture(MatTexture[i], (Rotation * pt + vec2(0.5)) * MatTexRepeat(i) + MatTexOffset(i)); \
+        if (i == 0) {                                                                                            \
+            texMixed = texColor;                                                                                 \
+        } else {                                                                                                 \
+            texMixed = mix(texMixed, texColor, texColor.a);                                                      \
+        }                                                                                                        \
+    }
+
+// Inputs from vertex shader
+in vec3 Color;
+flat in mat2 Rotation;
+
+// Output
+out vec4 FragColor;
+
+void main() {
+
+    // Mix material color with textures colors
+    vec4 texMixed = vec4(1);
+    #if MAT_TEXTURES==1
+        MIX_POINT_TEXTURE(0)
+    #elif MAT_TEXTURES==2
+        MIX_POINT_TEXTURE(0)
+        MIX_POINT_TEXTURE(1)
+    #elif MAT_TEXTURES==3
+        MIX_POINT_TEXTURE(0)
+        MIX_POINT_TEXTURE(1)
+        MIX_POINT_TEXTURE(2)
+    #endif
+
+    // Generates final color
+    FragColor = min(vec4(Color, MatOpacity) * texMixed, vec4(1));
+
+#ifdef SOFT_PARTICLES
+    // Fades the fragment near the opaque objects behind it
+    FragColor.a *= softParticlesFade();
+#endif
+
+#ifdef ALPHA_TEST
+    // Discards the fragments below the alpha test threshold
+    if (FragColor.a < MatAlphaTest) {
+        discard;
+    }
+#endif
+
+#ifdef FOG
+    // Blends the fragment with the fog color
+    FragColor.rgb = applyFog(FragColor.rgb);
+#endif
+}
+`
+
+const point_vertex_source = `#include <attributes>
+
+// Model uniforms
+uniform mat4 MVP;
+
+// Material uniforms
+#include <material>
+
+// Outputs for fragment shader
+out vec3 Color;
+flat out mat2 Rotation;
+
+void main() {
+
+    // Rotation matrix for fragment shader
+    float rotSin = sin(MatPointRotationZ);
+    float rotCos = cos(MatPointRotationZ);
+    Rotation = mat2(rotCos, rotSin, - rotSin, rotCos);
+
+    // Sets the vertex position
+    vec4 pos = MVP * vec4(VertexPosition, 1.0);
+    gl_Position = pos;
+
+    // Sets the size of the rasterized point decreasing with distance
+    gl_PointSize = (1.0 - pos.z / pos.w) * MatPointSize;
+
+    // Outputs color
+    Color = MatEmissiveColor;
+}
+
+`
+
+const post_adapt_fragment_source = `precision highp float;
+//
+// Fragment shader adapting the auto exposure luminance to the scene luminance
+//
+#include <post>
+
+// Previously adapted luminance
+uniform sampler2D PostAdapted;
+
+// Effect parameters
+#define Rate                PostEffect[0].x
+
+void main() {
+
+    // The smallest mipmap of the log luminance texture has its average
+    float lum = exp(textureLod(PostColor, vec2(0.5), 16.0).r);
+    float prev = texture(PostAdapted, vec2(0.5)).r;
+    FragColor = vec4(mix(prev, lum, Rate), 0.0, 0.0, 1.0);
+}
+
+`
+
+const post_chroma_fragment_source = `precision mediump float;
+//
+// Fragment shader for the chromatic aberration post processing effect
+//
+#include <post>
+
+// Effect parameters
+#define Strength            PostEffect[0].x
+
+void main() {
+
+    // Offsets the red and blue channels radially, up to Strength pixels at the screen edges
+    vec2 offset = (FragTexcoord - 0.5) * 2.0 * Strength * PostInfo.xy;
+    vec4 color = texture(PostColor, FragTexcoord);
+    color.r = texture(PostColor, FragTexcoord + offset).r;
+    color.b = texture(PostColor, FragTexcoord - offset).b;
+    FragColor = color;
+}
+
+`
+
+const post_dof_fragment_source = `precision highp float;
+//
+// Fragment shader for the depth of field post processing effect
+//
+#include <post>
+
+// Effect parameters
+#define FocusDistance       PostEffect[0].x
+#define FocusRange          PostEffect[0].y
+#define BokehScale          PostEffect[0].z
+
+// Number of samples of the blur disc
+const int SAMPLES = 32;
+const float GOLDEN_ANGLE = 2.39996323;
+
+// Returns the circle of confusion radius in pixels of the specified point.
+float circleOfConfusion(vec2 uv) {
+
+    float dist = postViewDistance(uv);
+    float coc = (abs(dist - FocusDistance) - 0.5 * FocusRange) / max(FocusRange, 0.0001);
+    return clamp(coc, 0.0, 1.0) * BokehScale;
+}
+
+void main() {
+
+    float radius = circleOfConfusion(FragTexcoord);
+    vec3 color = texture(PostColor, FragTexcoord).rgb;
+    float weight = 1.0;
+
+    // Gathers the samples of a disc which spread over this pixel
+    for (int i = 1; i < SAMPLES; i++) {
+        float r = radius * sqrt(float(i) / float(SAMPLES));
+        float a = float(i) * GOLDEN_ANGLE;
+        vec2 uv = FragTexcoord + vec2(cos(a), sin(a)) * r * PostInfo.xy;
+        float w = clamp(circleOfConfusion(uv) - r + 1.0, 0.0, 1.0);
+        color += texture(PostColor, uv).rgb * w;
+        weight += w;
+    }
+    FragColor = vec4(color / weight, 1.0);
+}
+
+`
+
+const post_luminance_fragment_source = `precision highp float;
+//
+// Fragment shader computing the log luminance of the scene for the auto exposure
+//
+#include <post>
+
+void main() {
+
+    vec3 color = texture(PostColor, FragTexcoord).rgb;
+    float lum = dot(color, vec3(0.2126, 0.7152, 0.0722));
+    FragColor = vec4(log(max(lum, 0.0001)), 0.0, 0.0, 1.0);
+}
+
+`
+
+const post_lut_fragment_source = `precision mediump float;
+//
+// Fragment shader for the color grading post processing effect
+//
+#include <post>
+
+// Color grading lookup table
+uniform mediump sampler3D PostLUT;
+
+// Effect parameters
+#define LUTSize             PostEffect[0].x
+
+void main() {
+
+    vec4 color = texture(PostColor, FragTexcoord);
+    // Maps the color range to the centers of the first and last texels
+    vec3 uvw = clamp(color.rgb, 0.0, 1.0) * ((LUTSize - 1.0) / LUTSize) + 0.5 / LUTSize;
+    FragColor = vec4(texture(PostLUT, uvw).rgb, color.a);
+}
+
+`
+
+const post_ssr_fragment_source = `precision highp float;
+//
+// Fragment shader for the screen space reflections post processing effect
+//
+#include <post>
+
+// View normals (rgb) and reflectivity (a) of the reflective surfaces
+uniform sampler2D PostNormal;
+
+// Effect parameters
+#define MaxDistance         PostEffect[0].x
+#define Thickness           PostEffect[0].y
+#define Steps               PostEffect[0].z
+#define ProjScale           PostEffect[1].xy
+
+// Maximum number of ray marching steps
+const int MAX_STEPS = 256;
+
+// Returns the view position of the scene point at the specified coordinates.
+vec3 viewPosition(vec2 uv) {
+
+    float dist = postViewDistance(uv);
+    return vec3((uv * 2.0 - 1.0) * dist / ProjScale, -dist);
+}
+
+// Returns the screen coordinates of the specified view position.
+vec2 screenPosition(vec3 pos) {
+
+    return (pos.xy * ProjScale / -pos.z) * 0.5 + 0.5;
+}
+
+void main() {
+
+    vec4 color = texture(PostColor, FragTexcoord);
+    vec4 surface = texture(PostNormal, FragTexcoord);
+    if (surface.a == 0.0) {
+        FragColor = color;
+        return;
+    }
+
+    // Marches the reflected ray until it goes behind the depth buffer
+    vec3 pos = viewPosition(FragTexcoord);
+    vec3 normal = normalize(surface.rgb * 2.0 - 1.0);
+    vec3 dir = reflect(normalize(pos), normal);
+    vec3 delta = dir * (MaxDistance / Steps);
+    vec3 ray = pos;
+    for (int i = 1; i <= MAX_STEPS; i++) {
+        if (float(i) > Steps) {
+            break;
+        }
+        ray += delta;
+        if (ray.z >= 0.0) {
+            break;
+        }
+        vec2 uv = screenPosition(ray);
+        if (any(lessThan(uv, vec2(0.0))) || any(greaterThan(uv, vec2(1.0)))) {
+            break;
+        }
+        float diff = -ray.z - postViewDistance(uv);
+        if (diff > 0.0 && diff < Thickness) {
+            // Fades the reflection near the screen edges and the maximum distance
+            vec2 edge = smoothstep(0.0, 0.1, uv) * (1.0 - smoothstep(0.9, 1.0, uv));
+            float fade = edge.x * edge.y * (1.0 - float(i) / Steps);
+            FragColor = vec4(mix(color.rgb, texture(PostColor, uv).rgb, surface.a * fade), color.a);
+            return;
+        }
+    }
+
+    // The ray left the screen without a hit: keeps the material environment reflections
+    FragColor = color;
+}
+
+`
+
+const post_tonemap_fragment_source = `precision highp float;
+//
+// Fragment shader for the exposure and tone mapping post processing effect
+//
+#include <post>
+
+// Adapted scene luminance of the auto exposure
+uniform sampler2D PostLuminance;
+
+// Effect parameters
+#define Exposure            PostEffect[0].x
+#define AutoExposure        PostEffect[0].y
+#define MinLuminance        PostEffect[0].z
+#define MaxLuminance        PostEffect[0].w
+
+// Returns the ACES filmic tone mapping of the specified color.
+vec3 toneMap(vec3 x) {
+
+    return clamp((x * (2.51 * x + 0.03)) / (x * (2.43 * x + 0.59) + 0.14), 0.0, 1.0);
+}
+
+void main() {
+
+    vec4 color = texture(PostColor, FragTexcoord);
+    float exposure = Exposure;
+    if (AutoExposure > 0.0) {
+        // Maps the scene average luminance to middle gray
+        float lum = clamp(texture(PostLuminance, vec2(0.5)).r, MinLuminance, MaxLuminance);
+        exposure *= 0.18 / lum;
+    }
+    FragColor = vec4(toneMap(color.rgb * exposure), color.a);
+}
+
+`
+
+const post_vertex_source = `//
+// Vertex shader for full screen post processing effects
+//
+#include <attributes>
+
+// Output for fragment shader
+out vec2 FragTexcoord;
+
+void main() {
+
+    FragTexcoord = VertexTexcoord;
+    gl_Position = vec4(VertexPosition.xy, 0.0, 1.0);
+}
+
+`
+
+const post_vignette_fragment_source = `precision mediump float;
+//
+// Fragment shader for the vignette post processing effect
+//
+#include <post>
+
+// Effect parameters
+#define Intensity           PostEffect[0].x
+#define Smoothness          PostEffect[0].y
+
+void main() {
+
+    // Distance from the screen center, 1 at the corners
+    float dist = length(FragTexcoord - 0.5) * 1.41421356;
+    float dark = Intensity * smoothstep(1.0 - max(Smoothness, 0.001), 1.0, dist);
+    vec4 color = texture(PostColor, FragTexcoord);
+    FragColor = vec4(color.rgb * (1.0 - clamp(dark, 0.0, 1.0)), color.a);
+}
+
+`
+
+const shadow_blur_fragment_source = `precision mediump float;
+//
+// Fragment shader of the separable box blur of the variance shadow maps
+//
+#include <post>
+
+void main() {
+
+    // PostEffect[0]: texel offset along the blur direction (xy) and kernel radius (z)
+    int radius = int(PostEffect[0].z);
+    vec4 sum = vec4(0.0);
+    for (int i = -radius; i <= radius; i++) {
+        sum += texture(PostColor, FragTexcoord + float(i) * PostEffect[0].xy);
+    }
+    FragColor = sum / float(2 * radius + 1);
+}
+
+`
+
+const shadow_variance_fragment_source = `precision mediump float;
+//
+// Fragment shader of the variance shadow maps depth moments pass
+//
+
+// Output
+out vec4 FragColor;
 
-    gl_Position = MVP * finalWorld * vec4(vPosition, 1.0);
+void main() {
 
+    // Depth and squared depth, biased by the depth slope to reduce the shadow acne
+    float depth = gl_FragCoord.z;
+    float dx = dFdx(depth);
+    float dy = dFdy(depth);
+    FragColor = vec4(depth, depth * depth + 0.25 * (dx * dx + dy * dy), 0.0, 1.0);
 }
 
-
 `
 
-const point_fragment_source = `precision mediump float;
-#include <material>
+const skydome_fragment_source = `precision mediump float;
+//
+// Fragment shader for gradient sky domes
+//
 
-// GLSL 3.30 does not allow indexing texture sampler with non constant values.
-// This macro is used to mix the texture with the specified index with the material color.
-// It should be called for each texture index.
-#define MIX_POINT_TEXTURE(i)                                                                                     \
-    if (MatTexVisible(i)) {                                                                                      \
-        vec2 pt = gl_PointCoord - vec2(0.5);                                                                     \
-        vec4 texColor = texture(MatTexture[i], (Rotation * pt + vec2(0.5)) * MatTexRepeat(i) + MatTexOffset(i)); \
-        if (i == 0) {                                                                                            \
-            texMixed = texColor;                                                                                 \
-        } else {                                                                                                 \
-            texMixed = mix(texMixed, texColor, texColor.a);                                                      \
-        }                                                                                                        \
-    }
+// Sky colors uniform
+uniform vec3 Sky[3];
+#define SkyTopColor         Sky[0]
+#define SkyHorizonColor     Sky[1]
+#define SkyBottomColor      Sky[2]
 
 // Inputs from vertex shader
-in vec3 Color;
-flat in mat2 Rotation;
+in vec3 Direction;
 
 // Output
 out vec4 FragColor;
 
 void main() {
 
-    // Mix material color with textures colors
-    vec4 texMixed = vec4(1);
-    #if MAT_TEXTURES==1
-        MIX_POINT_TEXTURE(0)
-    #elif MAT_TEXTURES==2
-        MIX_POINT_TEXTURE(0)
-        MIX_POINT_TEXTURE(1)
-    #elif MAT_TEXTURES==3
-        MIX_POINT_TEXTURE(0)
-        MIX_POINT_TEXTURE(1)
-        MIX_POINT_TEXTURE(2)
-    #endif
-
-    // Generates final color
-    FragColor = min(vec4(Color, MatOpacity) * texMixed, vec4(1));
+    float h = normalize(Direction).y;
+    vec3 color;
+    if (h >= 0.0) {
+        color = mix(SkyHorizonColor, SkyTopColor, h);
+    } else {
+        color = mix(SkyHorizonColor, SkyBottomColor, -h);
+    }
+    FragColor = vec4(color, 1.0);
 }
 
 `
 
-const point_vertex_source = `#include <attributes>
+const skydome_vertex_source = `//
+// Vertex shader for gradient sky domes
+//
+#include <attributes>
 
 // Model uniforms
 uniform mat4 MVP;
 
-// Material uniforms
-#include <material>
-
-// Outputs for fragment shader
-out vec3 Color;
-flat out mat2 Rotation;
+// Output for fragment shader
+out vec3 Direction;
 
 void main() {
 
-    // Rotation matrix for fragment shader
-    float rotSin = sin(MatPointRotationZ);
-    float rotCos = cos(MatPointRotationZ);
-    Rotation = mat2(rotCos, rotSin, - rotSin, rotCos);
-
-    // Sets the vertex position
-    vec4 pos = MVP * vec4(VertexPosition, 1.0);
-    gl_Position = pos;
-
-    // Sets the size of the rasterized point decreasing with distance
-    gl_PointSize = (1.0 - pos.z / pos.w) * MatPointSize;
-
-    // Outputs color
-    Color = MatEmissiveColor;
+    Direction = VertexPosition;
+    // Places the dome on the far plane, behind everything else
+    gl_Position = (MVP * vec4(VertexPosition, 1.0)).xyww;
 }
 
 `
 
 const sprite_fragment_source = `precision mediump float;
+
 //
 // Fragment shader for sprite
 //
 
 #include <material>
+#include <fog>
+#include <soft_particles>
 
 // Inputs from vertex shader
 in vec3 Color;
@@ -1168,8 +2364,24 @@ void main() {
 
     // Combine material color with texture
     FragColor = min(vec4(Color, MatOpacity) * texCombined, vec4(1));
-}
 
+#ifdef SOFT_PARTICLES
+    // Fades the fragment near the opaque objects behind it
+    FragColor.a *= softParticlesFade();
+#endif
+
+#ifdef ALPHA_TEST
+    // Discards the fragments below the alpha test threshold
+    if (FragColor.a < MatAlphaTest) {
+        discard;
+    }
+#endif
+
+#ifdef FOG
+    // Blends the fragment with the fog color
+    FragColor.rgb = applyFog(FragColor.rgb);
+#endif
+}
 `
 
 const sprite_vertex_source = `//
@@ -1207,11 +2419,63 @@ void main() {
 
 `
 
+const ssr_normal_fragment_source = `precision highp float;
+//
+// Fragment shader of the screen space reflections normal pass
+//
+
+// Scene depth
+uniform sampler2D PostDepth;
+
+// Texel size (xy) and material reflectivity (z)
+uniform vec4 SSRInfo;
+
+// Input from vertex shader
+in vec3 Normal;
+
+// Output
+out vec4 FragColor;
+
+void main() {
+
+    // Discards the fragments hidden by other objects of the scene
+    float depth = texture(PostDepth, gl_FragCoord.xy * SSRInfo.xy).r;
+    if (gl_FragCoord.z > depth + 0.00001) {
+        discard;
+    }
+    FragColor = vec4(normalize(Normal) * 0.5 + 0.5, SSRInfo.z);
+}
+
+`
+
+const ssr_normal_vertex_source = `//
+// Vertex shader of the screen space reflections normal pass
+//
+#include <attributes>
+
+// Model uniforms
+uniform mat3 NormalMatrix;
+uniform mat4 MVP;
+
+// Output for fragment shader
+out vec3 Normal;
+
+void main() {
+
+    Normal = NormalMatrix * VertexNormal;
+    gl_Position = MVP * vec4(VertexPosition, 1.0);
+}
+
+`
+
 const standard_fragment_source = `precision mediump float;
+
 //
 // Fragment Shader template
 //
 #include <material>
+#include <fog>
+#include <soft_particles>
 
 // Inputs from Vertex shader
 in vec3 ColorFrontAmbdiff;
@@ -1220,8 +2484,10 @@ in vec3 ColorBackAmbdiff;
 in vec3 ColorBackSpec;
 in vec2 FragTexcoord;
 
+#include <emissive>
+
 // Output
-out vec4 FragColor;
+#include <oit>
 
 
 void main() {
@@ -1250,8 +2516,34 @@ void main() {
         colorSpec = vec4(ColorBackSpec, 0);
     }
     FragColor = min(colorAmbDiff * texMixed + colorSpec, vec4(1));
-}
 
+#ifdef SOFT_PARTICLES
+    // Fades the fragment near the opaque objects behind it
+    FragColor.a *= softParticlesFade();
+#endif
+
+#ifdef ALPHA_TEST
+    // Discards the fragments below the alpha test threshold
+    if (FragColor.a < MatAlphaTest) {
+        discard;
+    }
+#endif
+
+#ifdef EMISSIVE
+    // Adds the emissive color after the clamped lighting
+    FragColor.rgb += emissiveColor();
+#endif
+
+#ifdef FOG
+    // Blends the fragment with the fog color
+    FragColor.rgb = applyFog(FragColor.rgb);
+#endif
+
+#ifdef OIT
+    // Accumulates the color for the order independent transparency
+    oitWrite();
+#endif
+}
 `
 
 const standard_vertex_source = `//
@@ -1263,9 +2555,13 @@ const standard_vertex_source = `//
 uniform mat4 ModelViewMatrix;
 uniform mat3 NormalMatrix;
 uniform mat4 MVP;
+#ifdef TEX_TRANSFORM
+uniform mat3 MatTexTransform;
+#endif
 
 #include <lights>
 #include <material>
+#include <shadows>
 #include <phong_model>
 #include <morphtarget_vertex_declaration>
 #include <bones_vertex_declaration>
@@ -1300,6 +2596,9 @@ void main() {
     if (MatTexFlipY(0)) {
         texcoord.y = 1 - texcoord.y;
     }
+#endif
+#ifdef TEX_TRANSFORM
+    texcoord = (MatTexTransform * vec3(texcoord, 1.0)).xy;
 #endif
     FragTexcoord = texcoord;
     vec3 vPosition = VertexPosition;
@@ -1312,48 +2611,292 @@ void main() {
 
 `
 
+const terrain_fragment_source = `precision mediump float;
+precision mediump sampler2DArray;
+//
+// Terrain splatting fragment shader
+//
+
+// Inputs from vertex shader
+in vec4 Position;       // Vertex position in camera coordinates.
+in vec3 Normal;         // Vertex normal in camera coordinates.
+in vec3 CamDir;         // Direction from vertex to camera
+in vec2 FragTexcoord;
+in vec3 SplatWeights;   // Per vertex layers weights
+
+#include <lights>
+#include <material>
+#include <fog>
+#include <shadows>
+#include <phong_model>
+#include <emissive>
+
+// Terrain layers texture array and repeat factors
+uniform sampler2DArray TerrainLayers;
+uniform vec2 TerrainRepeat;
+
+#ifdef SPLAT_WEIGHTMAP
+    // Per pixel layers weights (RGBA)
+    uniform sampler2D TerrainWeights;
+    uniform vec2 TerrainWeightsInfo[3];
+#endif
+
+// Final fragment color
+out vec4 FragColor;
+
+void main() {
+
+    // Gets and normalizes the layers weights
+#ifdef SPLAT_WEIGHTMAP
+    vec4 weights = texture(TerrainWeights, FragTexcoord);
+#else
+    vec4 weights = vec4(SplatWeights, 0.0);
+#endif
+    weights /= max(dot(weights, vec4(1.0)), 1e-4);
+
+    // Blends the layers colors
+#if SPLAT_LAYERS > 0
+    vec2 uv = FragTexcoord * TerrainRepeat;
+    vec4 texMixed = vec4(0.0);
+    for (int i = 0; i < SPLAT_LAYERS; i++) {
+        texMixed += texture(TerrainLayers, vec3(uv, float(i))) * weights[i];
+    }
+#else
+    // Without layers only the material colors are used
+    vec4 texMixed = vec4(1.0);
+#endif
+
+    // Combine material with layers colors
+    vec4 matDiffuse = vec4(MatDiffuseColor, MatOpacity) * texMixed;
+    vec4 matAmbient = vec4(MatAmbientColor, MatOpacity) * texMixed;
+
+    // Inverts the fragment normal if not FrontFacing
+    vec3 fragNormal = Normal;
+    if (!gl_FrontFacing) {
+        fragNormal = -fragNormal;
+    }
+
+    // Calculates the Ambient+Diffuse and Specular colors for this fragment using the Phong model.
+    vec3 Ambdiff, Spec;
+    phongModel(Position, fragNormal, CamDir, vec3(matAmbient), vec3(matDiffuse), Ambdiff, Spec);
+
+    // Final fragment color
+    FragColor = min(vec4(Ambdiff + Spec, matDiffuse.a), vec4(1.0));
+
+#ifdef ALPHA_TEST
+    // Discards the fragments below the alpha test threshold
+    if (FragColor.a < MatAlphaTest) {
+        discard;
+    }
+#endif
+
+#ifdef EMISSIVE
+    // Adds the emissive color after the clamped lighting
+    FragColor.rgb += emissiveColor();
+#endif
+
+#ifdef FOG
+    // Blends the fragment with the fog color
+    FragColor.rgb = applyFog(FragColor.rgb);
+#endif
+}
+`
+
+const terrain_vertex_source = `//
+// Terrain splatting vertex shader
+//
+#include <attributes>
+
+// Model uniforms
+uniform mat4 ModelViewMatrix;
+uniform mat3 NormalMatrix;
+uniform mat4 MVP;
+#ifdef TEX_TRANSFORM
+uniform mat3 MatTexTransform;
+#endif
+
+#include <material>
+
+// Output variables for Fragment shader
+out vec4 Position;
+out vec3 Normal;
+out vec3 CamDir;
+out vec2 FragTexcoord;
+out vec3 SplatWeights;
+
+void main() {
+
+    // Transform this vertex position to camera coordinates.
+    Position = ModelViewMatrix * vec4(VertexPosition, 1.0);
+
+    // Transform this vertex normal to camera coordinates.
+    Normal = normalize(NormalMatrix * VertexNormal);
+
+    // Calculate the direction vector from the vertex to the camera
+    // The camera is at 0,0,0
+    CamDir = normalize(-Position.xyz);
+
+    // Flips texture coordinate Y if requested.
+    vec2 texcoord = VertexTexcoord;
+#if MAT_TEXTURES>0
+    if (MatTexFlipY(0)) {
+        texcoord.y = 1.0 - texcoord.y;
+    }
+#endif
+#ifdef TEX_TRANSFORM
+    texcoord = (MatTexTransform * vec3(texcoord, 1.0)).xy;
+#endif
+    FragTexcoord = texcoord;
+
+    // Layers weights are stored in the vertex color
+    SplatWeights = VertexColor;
+
+    gl_Position = MVP * vec4(VertexPosition, 1.0);
+}
+
+`
+
+const thickline_fragment_source = `precision mediump float;
+//
+// Fragment shader for screen space thick lines
+//
+
+// Line uniform
+uniform vec4 ThickLine[2];
+#define LineColor       ThickLine[0].rgb
+
+// Output
+out vec4 FragColor;
+
+void main() {
+
+    FragColor = vec4(LineColor, 1.0);
+}
+
+`
+
+const thickline_vertex_source = `//
+// Vertex shader for screen space thick lines
+//
+#include <attributes>
+
+// Model uniforms
+uniform mat4 MVP;
+
+// Line uniform
+uniform vec4 ThickLine[2];
+#define LineColor       ThickLine[0].rgb
+#define LineWidth       ThickLine[0].w
+#define ViewportSize    ThickLine[1].xy
+
+// Other end of the segment and side of the vertex
+in vec3 LineOther;
+in float LineSide;
+
+void main() {
+
+    vec4 clip = MVP * vec4(VertexPosition, 1.0);
+    vec4 other = MVP * vec4(LineOther, 1.0);
+
+    // Offsets the vertex perpendicularly to the segment on the screen by half the width
+    vec2 dir = normalize(other.xy / other.w * ViewportSize - clip.xy / clip.w * ViewportSize);
+    vec2 normal = vec2(-dir.y, dir.x);
+    clip.xy += normal * LineSide * LineWidth / ViewportSize * clip.w;
+    gl_Position = clip;
+}
+
+`
+
 // Maps include name with its source code
 var includeMap = map[string]string{
 
 	"attributes":                      include_attributes_source,
 	"bones_vertex":                    include_bones_vertex_source,
 	"bones_vertex_declaration":        include_bones_vertex_declaration_source,
+	"emissive":                        include_emissive_source,
+	"envmap":                          include_envmap_source,
+	"fog":                             include_fog_source,
+	"ibl":                             include_ibl_source,
 	"lights":                          include_lights_source,
 	"material":                        include_material_source,
 	"morphtarget_vertex":              include_morphtarget_vertex_source,
 	"morphtarget_vertex2":             include_morphtarget_vertex2_source,
 	"morphtarget_vertex_declaration":  include_morphtarget_vertex_declaration_source,
 	"morphtarget_vertex_declaration2": include_morphtarget_vertex_declaration2_source,
+	"normalmap":                       include_normalmap_source,
+	"oit":                             include_oit_source,
 	"phong_model":                     include_phong_model_source,
+	"post":                            include_post_source,
+	"shadows":                         include_shadows_source,
+	"soft_particles":                  include_soft_particles_source,
 }
 
 // Maps shader name with its source code
 var shaderMap = map[string]string{
 
-	"basic_fragment":    basic_fragment_source,
-	"basic_vertex":      basic_vertex_source,
-	"panel_fragment":    panel_fragment_source,
-	"panel_vertex":      panel_vertex_source,
-	"phong_fragment":    phong_fragment_source,
-	"phong_vertex":      phong_vertex_source,
-	"physical_fragment": physical_fragment_source,
-	"physical_vertex":   physical_vertex_source,
-	"point_fragment":    point_fragment_source,
-	"point_vertex":      point_vertex_source,
-	"sprite_fragment":   sprite_fragment_source,
-	"sprite_vertex":     sprite_vertex_source,
-	"standard_fragment": standard_fragment_source,
-	"standard_vertex":   standard_vertex_source,
+	"basic_fragment":           basic_fragment_source,
+	"basic_vertex":             basic_vertex_source,
+	"dashed_fragment":          dashed_fragment_source,
+	"dashed_vertex":            dashed_vertex_source,
+	"debug_fragment":           debug_fragment_source,
+	"debug_vertex":             debug_vertex_source,
+	"depth_fragment":           depth_fragment_source,
+	"depth_vertex":             depth_vertex_source,
+	"ibl_brdf_fragment":        ibl_brdf_fragment_source,
+	"ibl_irradiance_fragment":  ibl_irradiance_fragment_source,
+	"ibl_prefilter_fragment":   ibl_prefilter_fragment_source,
+	"mirror_fragment":          mirror_fragment_source,
+	"mirror_vertex":            mirror_vertex_source,
+	"oit_composite_fragment":   oit_composite_fragment_source,
+	"panel_fragment":           panel_fragment_source,
+	"panel_vertex":             panel_vertex_source,
+	"phong_fragment":           phong_fragment_source,
+	"phong_vertex":             phong_vertex_source,
+	"physical_fragment":        physical_fragment_source,
+	"physical_vertex":          physical_vertex_source,
+	"point_fragment":           point_fragment_source,
+	"point_vertex":             point_vertex_source,
+	"post_adapt_fragment":      post_adapt_fragment_source,
+	"post_chroma_fragment":     post_chroma_fragment_source,
+	"post_dof_fragment":        post_dof_fragment_source,
+	"post_luminance_fragment":  post_luminance_fragment_source,
+	"post_lut_fragment":        post_lut_fragment_source,
+	"post_ssr_fragment":        post_ssr_fragment_source,
+	"post_tonemap_fragment":    post_tonemap_fragment_source,
+	"post_vertex":              post_vertex_source,
+	"post_vignette_fragment":   post_vignette_fragment_source,
+	"shadow_blur_fragment":     shadow_blur_fragment_source,
+	"shadow_variance_fragment": shadow_variance_fragment_source,
+	"skydome_fragment":         skydome_fragment_source,
+	"skydome_vertex":           skydome_vertex_source,
+	"sprite_fragment":          sprite_fragment_source,
+	"sprite_vertex":            sprite_vertex_source,
+	"ssr_normal_fragment":      ssr_normal_fragment_source,
+	"ssr_normal_vertex":        ssr_normal_vertex_source,
+	"standard_fragment":        standard_fragment_source,
+	"standard_vertex":          standard_vertex_source,
+	"terrain_fragment":         terrain_fragment_source,
+	"terrain_vertex":           terrain_vertex_source,
+	"thickline_fragment":       thickline_fragment_source,
+	"thickline_vertex":         thickline_vertex_source,
 }
 
 // Maps program name with Proginfo struct with shaders names
 var programMap = map[string]ProgramInfo{
 
-	"basic":    {"basic_vertex", "basic_fragment", ""},
-	"panel":    {"panel_vertex", "panel_fragment", ""},
-	"phong":    {"phong_vertex", "phong_fragment", ""},
-	"physical": {"physical_vertex", "physical_fragment", ""},
-	"point":    {"point_vertex", "point_fragment", ""},
-	"sprite":   {"sprite_vertex", "sprite_fragment", ""},
-	"standard": {"standard_vertex", "standard_fragment", ""},
+	"basic":      {"basic_vertex", "basic_fragment", ""},
+	"dashed":     {"dashed_vertex", "dashed_fragment", ""},
+	"debug":      {"debug_vertex", "debug_fragment", ""},
+	"depth":      {"depth_vertex", "depth_fragment", ""},
+	"mirror":     {"mirror_vertex", "mirror_fragment", ""},
+	"panel":      {"panel_vertex", "panel_fragment", ""},
+	"phong":      {"phong_vertex", "phong_fragment", ""},
+	"physical":   {"physical_vertex", "physical_fragment", ""},
+	"point":      {"point_vertex", "point_fragment", ""},
+	"skydome":    {"skydome_vertex", "skydome_fragment", ""},
+	"sprite":     {"sprite_vertex", "sprite_fragment", ""},
+	"ssr_normal": {"ssr_normal_vertex", "ssr_normal_fragment", ""},
+	"standard":   {"standard_vertex", "standard_fragment", ""},
+	"terrain":    {"terrain_vertex", "terrain_fragment", ""},
+	"thickline":  {"thickline_vertex", "thickline_fragment", ""},
 }
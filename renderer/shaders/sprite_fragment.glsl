@@ -5,6 +5,8 @@ precision mediump float;
 //
 
 #include <material>
+#include <fog>
+#include <soft_particles>
 
 // Inputs from vertex shader
 in vec3 Color;
@@ -30,5 +32,21 @@ void main() {
 
     // Combine material color with texture
     FragColor = min(vec4(Color, MatOpacity) * texCombined, vec4(1));
-}
 
+#ifdef SOFT_PARTICLES
+    // Fades the fragment near the opaque objects behind it
+    FragColor.a *= softParticlesFade();
+#endif
+
+#ifdef ALPHA_TEST
+    // Discards the fragments below the alpha test threshold
+    if (FragColor.a < MatAlphaTest) {
+        discard;
+    }
+#endif
+
+#ifdef FOG
+    // Blends the fragment with the fog color
+    FragColor.rgb = applyFog(FragColor.rgb);
+#endif
+}
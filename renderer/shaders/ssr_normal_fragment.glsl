@@ -0,0 +1,27 @@
+precision highp float;
+//
+// Fragment shader of the screen space reflections normal pass
+//
+
+// Scene depth
+uniform sampler2D PostDepth;
+
+// Texel size (xy) and material reflectivity (z)
+uniform vec4 SSRInfo;
+
+// Input from vertex shader
+in vec3 Normal;
+
+// Output
+out vec4 FragColor;
+
+void main() {
+
+    // Discards the fragments hidden by other objects of the scene
+    float depth = texture(PostDepth, gl_FragCoord.xy * SSRInfo.xy).r;
+    if (gl_FragCoord.z > depth + 0.00001) {
+        discard;
+    }
+    FragColor = vec4(normalize(Normal) * 0.5 + 0.5, SSRInfo.z);
+}
+
@@ -0,0 +1,18 @@
+//
+// Vertex shader of the screen space reflections normal pass
+//
+#include <attributes>
+
+// Model uniforms
+uniform mat3 NormalMatrix;
+uniform mat4 MVP;
+
+// Output for fragment shader
+out vec3 Normal;
+
+void main() {
+
+    Normal = NormalMatrix * VertexNormal;
+    gl_Position = MVP * vec4(VertexPosition, 1.0);
+}
+
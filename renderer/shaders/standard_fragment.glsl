@@ -4,6 +4,8 @@ precision mediump float;
 // Fragment Shader template
 //
 #include <material>
+#include <fog>
+#include <soft_particles>
 
 // Inputs from Vertex shader
 in vec3 ColorFrontAmbdiff;
@@ -12,8 +14,10 @@ in vec3 ColorBackAmbdiff;
 in vec3 ColorBackSpec;
 in vec2 FragTexcoord;
 
+#include <emissive>
+
 // Output
-out vec4 FragColor;
+#include <oit>
 
 
 void main() {
@@ -42,5 +46,31 @@ void main() {
         colorSpec = vec4(ColorBackSpec, 0);
     }
     FragColor = min(colorAmbDiff * texMixed + colorSpec, vec4(1));
-}
 
+#ifdef SOFT_PARTICLES
+    // Fades the fragment near the opaque objects behind it
+    FragColor.a *= softParticlesFade();
+#endif
+
+#ifdef ALPHA_TEST
+    // Discards the fragments below the alpha test threshold
+    if (FragColor.a < MatAlphaTest) {
+        discard;
+    }
+#endif
+
+#ifdef EMISSIVE
+    // Adds the emissive color after the clamped lighting
+    FragColor.rgb += emissiveColor();
+#endif
+
+#ifdef FOG
+    // Blends the fragment with the fog color
+    FragColor.rgb = applyFog(FragColor.rgb);
+#endif
+
+#ifdef OIT
+    // Accumulates the color for the order independent transparency
+    oitWrite();
+#endif
+}
@@ -7,9 +7,13 @@
 uniform mat4 ModelViewMatrix;
 uniform mat3 NormalMatrix;
 uniform mat4 MVP;
+#ifdef TEX_TRANSFORM
+uniform mat3 MatTexTransform;
+#endif
 
 #include <lights>
 #include <material>
+#include <shadows>
 #include <phong_model>
 #include <morphtarget_vertex_declaration>
 #include <bones_vertex_declaration>
@@ -44,6 +48,9 @@ void main() {
     if (MatTexFlipY(0)) {
         texcoord.y = 1 - texcoord.y;
     }
+#endif
+#ifdef TEX_TRANSFORM
+    texcoord = (MatTexTransform * vec3(texcoord, 1.0)).xy;
 #endif
     FragTexcoord = texcoord;
     vec3 vPosition = VertexPosition;
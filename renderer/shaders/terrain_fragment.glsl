@@ -0,0 +1,89 @@
+precision mediump float;
+precision mediump sampler2DArray;
+//
+// Terrain splatting fragment shader
+//
+
+// Inputs from vertex shader
+in vec4 Position;       // Vertex position in camera coordinates.
+in vec3 Normal;         // Vertex normal in camera coordinates.
+in vec3 CamDir;         // Direction from vertex to camera
+in vec2 FragTexcoord;
+in vec3 SplatWeights;   // Per vertex layers weights
+
+#include <lights>
+#include <material>
+#include <fog>
+#include <shadows>
+#include <phong_model>
+#include <emissive>
+
+// Terrain layers texture array and repeat factors
+uniform sampler2DArray TerrainLayers;
+uniform vec2 TerrainRepeat;
+
+#ifdef SPLAT_WEIGHTMAP
+    // Per pixel layers weights (RGBA)
+    uniform sampler2D TerrainWeights;
+    uniform vec2 TerrainWeightsInfo[3];
+#endif
+
+// Final fragment color
+out vec4 FragColor;
+
+void main() {
+
+    // Gets and normalizes the layers weights
+#ifdef SPLAT_WEIGHTMAP
+    vec4 weights = texture(TerrainWeights, FragTexcoord);
+#else
+    vec4 weights = vec4(SplatWeights, 0.0);
+#endif
+    weights /= max(dot(weights, vec4(1.0)), 1e-4);
+
+    // Blends the layers colors
+#if SPLAT_LAYERS > 0
+    vec2 uv = FragTexcoord * TerrainRepeat;
+    vec4 texMixed = vec4(0.0);
+    for (int i = 0; i < SPLAT_LAYERS; i++) {
+        texMixed += texture(TerrainLayers, vec3(uv, float(i))) * weights[i];
+    }
+#else
+    // Without layers only the material colors are used
+    vec4 texMixed = vec4(1.0);
+#endif
+
+    // Combine material with layers colors
+    vec4 matDiffuse = vec4(MatDiffuseColor, MatOpacity) * texMixed;
+    vec4 matAmbient = vec4(MatAmbientColor, MatOpacity) * texMixed;
+
+    // Inverts the fragment normal if not FrontFacing
+    vec3 fragNormal = Normal;
+    if (!gl_FrontFacing) {
+        fragNormal = -fragNormal;
+    }
+
+    // Calculates the Ambient+Diffuse and Specular colors for this fragment using the Phong model.
+    vec3 Ambdiff, Spec;
+    phongModel(Position, fragNormal, CamDir, vec3(matAmbient), vec3(matDiffuse), Ambdiff, Spec);
+
+    // Final fragment color
+    FragColor = min(vec4(Ambdiff + Spec, matDiffuse.a), vec4(1.0));
+
+#ifdef ALPHA_TEST
+    // Discards the fragments below the alpha test threshold
+    if (FragColor.a < MatAlphaTest) {
+        discard;
+    }
+#endif
+
+#ifdef EMISSIVE
+    // Adds the emissive color after the clamped lighting
+    FragColor.rgb += emissiveColor();
+#endif
+
+#ifdef FOG
+    // Blends the fragment with the fog color
+    FragColor.rgb = applyFog(FragColor.rgb);
+#endif
+}
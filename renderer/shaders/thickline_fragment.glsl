@@ -0,0 +1,17 @@
+precision mediump float;
+//
+// Fragment shader for screen space thick lines
+//
+
+// Line uniform
+uniform vec4 ThickLine[2];
+#define LineColor       ThickLine[0].rgb
+
+// Output
+out vec4 FragColor;
+
+void main() {
+
+    FragColor = vec4(LineColor, 1.0);
+}
+
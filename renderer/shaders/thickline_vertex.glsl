@@ -0,0 +1,30 @@
+//
+// Vertex shader for screen space thick lines
+//
+#include <attributes>
+
+// Model uniforms
+uniform mat4 MVP;
+
+// Line uniform
+uniform vec4 ThickLine[2];
+#define LineColor       ThickLine[0].rgb
+#define LineWidth       ThickLine[0].w
+#define ViewportSize    ThickLine[1].xy
+
+// Other end of the segment and side of the vertex
+in vec3 LineOther;
+in float LineSide;
+
+void main() {
+
+    vec4 clip = MVP * vec4(VertexPosition, 1.0);
+    vec4 other = MVP * vec4(LineOther, 1.0);
+
+    // Offsets the vertex perpendicularly to the segment on the screen by half the width
+    vec2 dir = normalize(other.xy / other.w * ViewportSize - clip.xy / clip.w * ViewportSize);
+    vec2 normal = vec2(-dir.y, dir.x);
+    clip.xy += normal * LineSide * LineWidth / ViewportSize * clip.w;
+    gl_Position = clip;
+}
+
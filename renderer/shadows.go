@@ -0,0 +1,262 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"github.com/thommil/tge-g3n/core"
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/graphic"
+	"github.com/thommil/tge-g3n/light"
+	"github.com/thommil/tge-g3n/math32"
+	"github.com/thommil/tge-g3n/texture"
+)
+
+// Exponential factor of the ShadowFilterExponential filter
+const shadowExpFactor = 80
+
+// Light bleeding reduction of the ShadowFilterVariance filter
+const shadowBleedReduction = 0.2
+
+// Slot of the texture unit reserved for the shadow map (see gls.ReservedTextureUnit)
+const shadowTextureSlot = 0
+
+// shadowMap renders the depth, or the depth moments of variance shadows, of the opaque graphics of the
+// scene seen from the first directional light casting shadows into a texture sampled by the Phong model materials.
+type shadowMap struct {
+	target    *texture.RenderTarget // Depth or depth moments of the shadow casters
+	blurred   *texture.RenderTarget // Horizontally blurred depth moments
+	variance  bool                  // Whether the targets store the depth moments of variance shadows
+	specs     ShaderSpecs           // Depth pass shader specs
+	blur      *PostEffect           // Depth moments blur pass
+	bspecs    ShaderSpecs           // Blur pass shader specs
+	light     *light.Directional    // Light casting the shadows of the current scene render or nil
+	uniMap    gls.Uniform           // Shadow map sampler uniform location cache
+	uniMatrix gls.Uniform           // Shadow matrix uniform location cache
+	uniInfo   gls.Uniform           // Shadow info uniform location cache
+	uniColor  gls.Uniform           // Blur input sampler uniform location cache
+	matrix    math32.Matrix4        // Camera coordinates to shadow map coordinates matrix
+	info      [4]float32            // Texel size, depth bias, PCF kernel radius and exponential factor or bleeding reduction
+}
+
+// init initializes the shadow map uniforms.
+func (sm *shadowMap) init() {
+
+	sm.uniMap.Init("ShadowMap")
+	sm.uniMatrix.Init("ShadowMatrix")
+	sm.uniInfo.Init("ShadowInfo")
+	sm.uniColor.Init("PostColor")
+	sm.specs.ShaderUnique = true
+	sm.bspecs.Name = "shadow_blur"
+	sm.bspecs.ShaderUnique = true
+}
+
+// find selects the first of the specified directional lights which casts shadows and moves it
+// to the first position, whose light is the one shadowed by the shaders. It returns whether one was found.
+func (sm *shadowMap) find(lights []*light.Directional) bool {
+
+	sm.light = nil
+	for i, l := range lights {
+		if l.CastShadow() {
+			lights[0], lights[i] = lights[i], lights[0]
+			sm.light = l
+			return true
+		}
+	}
+	return false
+}
+
+// render renders the shadow map of the opaque rendered and culled graphics of the last classified scene
+// seen from the shadow light, and computes the shadow matrix of the camera of the renderer render info.
+// The matrices of the graphics must be calculated again for the camera afterwards.
+func (sm *shadowMap) render(r *Renderer) error {
+
+	size := sm.light.ShadowMapSize()
+	filter, samples := sm.light.ShadowFilter()
+	variance := filter == light.ShadowFilterVariance
+	if sm.target != nil && (sm.target.Width() != size || sm.variance != variance) {
+		sm.dispose()
+	}
+	if sm.target == nil {
+		sm.variance = variance
+		if variance {
+			sm.target = texture.NewRenderTarget(size, size)
+			sm.target.SetHDR(true)
+			sm.blurred = texture.NewRenderTarget(size, size)
+			sm.blurred.SetHDR(true)
+		} else {
+			sm.target = texture.NewRenderTargetSpec(texture.RenderTargetSpec{Width: size, Height: size, DepthTexture: true})
+		}
+	}
+
+	// Orthographic camera of the light centered on the camera
+	var center math32.Vector3
+	center.SetFromMatrixPosition(&r.rinfo.CameraMatrix)
+	rinfo := r.rinfo
+	sm.light.ShadowCamera(&center, &rinfo.ViewMatrix, &rinfo.ProjMatrix)
+	rinfo.CameraMatrix.GetInverse(&rinfo.ViewMatrix)
+	err := sm.renderDepth(r, &rinfo)
+	if err != nil {
+		return err
+	}
+
+	// Transforms the camera coordinates to the light clip coordinates then to [0,1]
+	var bias math32.Matrix4
+	bias.Set(
+		0.5, 0, 0, 0.5,
+		0, 0.5, 0, 0.5,
+		0, 0, 0.5, 0.5,
+		0, 0, 0, 1,
+	)
+	sm.matrix.MultiplyMatrices(&rinfo.ProjMatrix, &rinfo.ViewMatrix)
+	sm.matrix.MultiplyMatrices(&bias, &sm.matrix)
+	sm.matrix.Multiply(&r.rinfo.CameraMatrix)
+
+	sm.info = [4]float32{1 / float32(size), sm.light.ShadowBias(), 0, shadowExpFactor}
+	switch filter {
+	case light.ShadowFilterPCF:
+		sm.info[2] = float32(samples / 2)
+	case light.ShadowFilterVariance:
+		sm.info[3] = shadowBleedReduction
+		if samples > 1 {
+			return sm.renderBlur(r, samples/2)
+		}
+	}
+	return nil
+}
+
+// renderDepth renders the depth, or the depth moments, of the shadow casters seen
+// from the light camera of the specified render info into the shadow map.
+func (sm *shadowMap) renderDepth(r *Renderer, rinfo *core.RenderInfo) error {
+
+	gs := r.gs
+	err := sm.target.Bind(gs)
+	if err != nil {
+		return err
+	}
+	defer sm.target.Unbind()
+	if sm.variance {
+		// The moments of the areas without casters are at the far plane
+		cr, cg, cb, ca := gs.GetClearColor()
+		gs.ClearColor(1, 1, 0, 0)
+		gs.Clear(gls.DEPTH_BUFFER_BIT | gls.COLOR_BUFFER_BIT)
+		gs.ClearColor(cr, cg, cb, ca)
+		sm.specs.Name = "shadow_variance"
+	} else {
+		gs.Clear(gls.DEPTH_BUFFER_BIT | gls.COLOR_BUFFER_BIT)
+		sm.specs.Name = "depth"
+	}
+
+	_, err = r.shaman.SetProgram(&sm.specs)
+	if err != nil {
+		return err
+	}
+	gs.Disable(gls.BLEND)
+	gs.Enable(gls.DEPTH_TEST)
+	gs.DepthFunc(gls.LEQUAL)
+	gs.DepthMask(true)
+	gs.SetSideView(gls.DoubleSide)
+	draw := func(grs []*graphic.Graphic) {
+		for _, gr := range grs {
+			gr.CalculateMatrices(gs, rinfo)
+			materials := gr.Materials()
+			for i := range materials {
+				if !materials[i].IMaterial().GetMaterial().Transparent() {
+					materials[i].Draw(gs, rinfo)
+				}
+			}
+		}
+	}
+	draw(r.rgraphics)
+	draw(r.cgraphics)
+	return nil
+}
+
+// renderBlur blurs the depth moments of the variance shadow map with a separable
+// box filter of the specified radius, horizontally then vertically.
+func (sm *shadowMap) renderBlur(r *Renderer, radius int) error {
+
+	if sm.blur == nil {
+		sm.blur = NewPostEffect(sm.bspecs.Name)
+	}
+	texel := 1 / float32(sm.target.Width())
+	passes := []struct {
+		input  *texture.Texture2D
+		output *texture.RenderTarget
+		dx, dy float32
+	}{{sm.target.Texture(), sm.blurred, texel, 0}, {sm.blurred.Texture(), sm.target, 0, texel}}
+	for _, pass := range passes {
+		err := pass.output.Bind(r.gs)
+		if err != nil {
+			return err
+		}
+		err = sm.blurPass(r, pass.input, pass.dx, pass.dy, radius)
+		pass.output.Unbind()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blurPass renders the specified depth moments texture blurred along the specified texel offset.
+func (sm *shadowMap) blurPass(r *Renderer, input *texture.Texture2D, dx, dy float32, radius int) error {
+
+	gs := r.gs
+	_, err := r.shaman.SetProgram(&sm.bspecs)
+	if err != nil {
+		return err
+	}
+	gs.ActiveTexture(gls.TEXTURE0)
+	gs.BindTexture(gls.TEXTURE_2D, input.TexName())
+	gs.Uniform1i(sm.uniColor.Location(gs), 0)
+	sm.blur.Params[0], sm.blur.Params[1], sm.blur.Params[2] = dx, dy, float32(radius)
+	grmats := sm.blur.quad.Materials()
+	grmats[0].Render(gs, &r.rinfo)
+	return nil
+}
+
+// defines adds the shadows defines of the filter of the shadow light to the specified defines.
+func (sm *shadowMap) defines(defines *gls.ShaderDefines) {
+
+	defines.Set("SHADOWS", "")
+	filter, _ := sm.light.ShadowFilter()
+	switch filter {
+	case light.ShadowFilterPCF:
+		defines.Set("SHADOW_PCF", "")
+	case light.ShadowFilterExponential:
+		defines.Set("SHADOW_EXP", "")
+	case light.ShadowFilterVariance:
+		defines.Set("SHADOW_VSM", "")
+	}
+}
+
+// setup binds the shadow map to its reserved texture unit, which is not allocated to the
+// materials, and transfers the shadow uniforms to the current program.
+func (sm *shadowMap) setup(gs *gls.GLS) {
+
+	unit := gs.ReservedTextureUnit(shadowTextureSlot)
+	gs.ActiveTexture(gls.TEXTURE0 + uint32(unit))
+	if sm.variance {
+		gs.BindTexture(gls.TEXTURE_2D, sm.target.Texture().TexName())
+	} else {
+		gs.BindTexture(gls.TEXTURE_2D, sm.target.DepthTexture().TexName())
+	}
+	gs.Uniform1i(sm.uniMap.Location(gs), int32(unit))
+	gs.UniformMatrix4fv(sm.uniMatrix.Location(gs), 1, false, &sm.matrix[0])
+	gs.Uniform4fv(sm.uniInfo.Location(gs), 1, sm.info[:])
+}
+
+// dispose releases the shadow map render targets.
+func (sm *shadowMap) dispose() {
+
+	if sm.target != nil {
+		sm.target.Dispose()
+		sm.target = nil
+	}
+	if sm.blurred != nil {
+		sm.blurred.Dispose()
+		sm.blurred = nil
+	}
+}
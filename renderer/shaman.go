@@ -7,6 +7,7 @@ package renderer
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"strconv"
@@ -56,6 +57,7 @@ type Shaman struct {
 	proginfo map[string]shaders.ProgramInfo // maps name of the program to ProgramInfo
 	programs []ProgSpecs                    // list of compiled programs with specs
 	specs    ShaderSpecs                    // Current shader specs
+	onUse    func(name string)              // Called with the name of each activated program if not nil
 }
 
 // NewShaman creates and returns a pointer to a new shader manager
@@ -153,6 +155,9 @@ func (sm *Shaman) SetProgram(s *ShaderSpecs) (bool, error) {
 		if pinfo.specs.equals(&specs) {
 			sm.gs.UseProgram(pinfo.program)
 			sm.specs = specs
+			if sm.onUse != nil {
+				sm.onUse(specs.Name)
+			}
 			return true, nil
 		}
 	}
@@ -168,9 +173,19 @@ func (sm *Shaman) SetProgram(s *ShaderSpecs) (bool, error) {
 	sm.specs = specs
 	sm.programs = append(sm.programs, ProgSpecs{prog, specs})
 	sm.gs.UseProgram(prog)
+	if sm.onUse != nil {
+		sm.onUse(specs.Name)
+	}
 	return true, nil
 }
 
+// resetProgram forgets the current shader specs after a program
+// was activated without the shader manager.
+func (sm *Shaman) resetProgram() {
+
+	sm.specs = ShaderSpecs{}
+}
+
 // GenProgram generates shader program from the specified specs
 func (sm *Shaman) GenProgram(specs *ShaderSpecs) (*gls.Program, error) {
 
@@ -193,40 +208,20 @@ func (sm *Shaman) GenProgram(specs *ShaderSpecs) (*gls.Program, error) {
 		defines[name] = value
 	}
 
-	// Get vertex shader source
-	vertexSource, ok := sm.shadersm[progInfo.Vertex]
-	if !ok {
-		return nil, fmt.Errorf("Vertex shader:%s not found", progInfo.Vertex)
-	}
-	// Pre-process vertex shader source
-	vertexSource, err := sm.preprocess(vertexSource, defines)
+	// Get and pre-process the vertex and fragment shaders sources
+	vertexSource, err := sm.resolveSource("Vertex shader", progInfo.Vertex, defines)
 	if err != nil {
 		return nil, err
 	}
-	//fmt.Printf("vertexSource:%s\n", vertexSource)
-
-	// Get fragment shader source
-	fragSource, ok := sm.shadersm[progInfo.Fragment]
-	if err != nil {
-		return nil, fmt.Errorf("Fragment shader:%s not found", progInfo.Fragment)
-	}
-	// Pre-process fragment shader source
-	fragSource, err = sm.preprocess(fragSource, defines)
+	fragSource, err := sm.resolveSource("Fragment shader", progInfo.Fragment, defines)
 	if err != nil {
 		return nil, err
 	}
-	//fmt.Printf("fragSource:%s\n", fragSource)
 
 	// Checks for optional geometry shader compiled template
 	var geomSource = ""
 	if progInfo.Geometry != "" {
-		// Get geometry shader source
-		geomSource, ok = sm.shadersm[progInfo.Geometry]
-		if !ok {
-			return nil, fmt.Errorf("Geometry shader:%s not found", progInfo.Geometry)
-		}
-		// Pre-process geometry shader source
-		geomSource, err = sm.preprocess(geomSource, defines)
+		geomSource, err = sm.resolveSource("Geometry shader", progInfo.Geometry, defines)
 		if err != nil {
 			return nil, err
 		}
@@ -247,15 +242,47 @@ func (sm *Shaman) GenProgram(specs *ShaderSpecs) (*gls.Program, error) {
 	return prog, nil
 }
 
+// ResolveSource returns the source of the shader with the specified name as it is
+// compiled: prefixed with the GLSL version directive and the "#define" directives of
+// the specified defines, which may be nil, in name order, and with its include chunks
+// inlined recursively. Returns an error if the shader or one of its chunks is not found.
+// The defines of the lights and textures counts set by GenProgram must be included
+// in the specified defines to resolve the chunks repeated by them.
+func (sm *Shaman) ResolveSource(name string, defines *gls.ShaderDefines) (string, error) {
+
+	d := map[string]string{}
+	if defines != nil {
+		for n, v := range *defines {
+			d[n] = v
+		}
+	}
+	return sm.resolveSource("Shader", name, d)
+}
+
+// resolveSource returns the pre-processed source of the shader of the specified kind and name.
+func (sm *Shaman) resolveSource(kind, name string, defines map[string]string) (string, error) {
+
+	source, ok := sm.shadersm[name]
+	if !ok {
+		return "", fmt.Errorf("%s:%s not found", kind, name)
+	}
+	return sm.preprocess(source, defines)
+}
+
 func (sm *Shaman) preprocess(source string, defines map[string]string) (string, error) {
 
 	// If defines map supplied, generate prefix with glsl version directive first,
-	// followed by "#define" directives
+	// followed by "#define" directives in name order
 	var prefix = ""
 	if defines != nil { // This is only true for the outer call
 		prefix = fmt.Sprintf("#version %s\n", gl.GetGLSLVersion())
-		for name, value := range defines {
-			prefix = prefix + fmt.Sprintf("#define %s %s\n", name, value)
+		names := make([]string, 0, len(defines))
+		for name := range defines {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prefix = prefix + fmt.Sprintf("#define %s %s\n", name, defines[name])
 		}
 	}
 
@@ -0,0 +1,94 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/graphic"
+	"github.com/thommil/tge-g3n/texture"
+)
+
+// Slot of the texture unit reserved for the soft particles depth (see gls.ReservedTextureUnit)
+const softParticlesTextureSlot = 1
+
+// softParticles renders the depth of the opaque graphics of the scene into
+// a texture sampled by the transparent materials with soft particles.
+type softParticles struct {
+	target   *texture.RenderTarget // Depth of the opaque graphics
+	specs    ShaderSpecs           // Depth pass shader specs
+	uniDepth gls.Uniform           // Depth sampler uniform location cache
+	uniInfo  gls.Uniform           // Depth info uniform location cache
+	info     [8]float32            // Texel size, projection, viewport position and fade distance
+}
+
+// init initializes the soft particles uniforms.
+func (sp *softParticles) init() {
+
+	sp.uniDepth.Init("SoftDepth")
+	sp.uniInfo.Init("SoftInfo")
+	sp.specs.Name = "depth"
+	sp.specs.ShaderUnique = true
+}
+
+// used returns whether a material of the specified graphic materials has soft particles.
+func (sp *softParticles) used(grmats []*graphic.GraphicMaterial) bool {
+
+	for _, grmat := range grmats {
+		if grmat.IMaterial().GetMaterial().SoftParticles() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// renderDepth renders the depth of the opaque graphics of the last classified scene.
+func (sp *softParticles) renderDepth(r *Renderer) error {
+
+	gs := r.gs
+	x, y, width, height := gs.GetViewport()
+	if sp.target != nil && (sp.target.Width() != int(width) || sp.target.Height() != int(height)) {
+		sp.dispose()
+	}
+	if sp.target == nil {
+		sp.target = texture.NewRenderTarget(int(width), int(height))
+		sp.target.SetDepthTexture(true)
+	}
+	err := sp.target.Bind(gs)
+	if err != nil {
+		return err
+	}
+	defer sp.target.Unbind()
+	gs.Clear(gls.DEPTH_BUFFER_BIT | gls.COLOR_BUFFER_BIT)
+
+	err = r.renderOpaqueDepth(&sp.specs)
+	if err != nil {
+		return err
+	}
+
+	proj := &r.rinfo.ProjMatrix
+	sp.info = [8]float32{1 / float32(width), 1 / float32(height), proj[10], proj[14], float32(x), float32(y), 0, 0}
+	return nil
+}
+
+// setup binds the depth texture to its reserved texture unit, which is not allocated
+// to the materials, and transfers the uniforms of the specified fade distance.
+func (sp *softParticles) setup(gs *gls.GLS, fade float32) {
+
+	unit := gs.ReservedTextureUnit(softParticlesTextureSlot)
+	gs.ActiveTexture(gls.TEXTURE0 + uint32(unit))
+	gs.BindTexture(gls.TEXTURE_2D, sp.target.DepthTexture().TexName())
+	gs.Uniform1i(sp.uniDepth.Location(gs), int32(unit))
+	sp.info[6] = fade
+	gs.Uniform4fv(sp.uniInfo.Location(gs), 2, sp.info[:])
+}
+
+// dispose releases the depth render target.
+func (sp *softParticles) dispose() {
+
+	if sp.target != nil {
+		sp.target.Dispose()
+		sp.target = nil
+	}
+}
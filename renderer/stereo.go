@@ -0,0 +1,97 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"github.com/thommil/tge-g3n/camera"
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/math32"
+)
+
+// eyeCamera is the camera of one eye of a stereo render, which replaces
+// the view and projection matrices of the camera it wraps.
+type eyeCamera struct {
+	camera.ICamera                // Camera of the stereo render
+	view           math32.Matrix4 // View matrix of the eye
+	proj           math32.Matrix4 // Projection matrix of the eye
+}
+
+// ViewMatrix returns the view matrix of the eye.
+func (e *eyeCamera) ViewMatrix(m *math32.Matrix4) {
+
+	*m = e.view
+}
+
+// ProjMatrix returns the projection matrix of the eye.
+func (e *eyeCamera) ProjMatrix(m *math32.Matrix4) {
+
+	*m = e.proj
+}
+
+// RenderStereo renders the previously set Scene twice side by side, for the left eye
+// into the left half of the current viewport and for the right eye into the right half.
+// The eyes are offset from the specified camera along its horizontal axis by half the
+// specified interpupillary distance, in world units, and keep its vertical field of view.
+// To render each eye into its own render target, use RenderScene with each target bound.
+// The graphics are culled once with a frustum enclosing both eyes frustums, so that an
+// object is not culled for one eye while visible to the other.
+// The post processing effects are not applied. Like Render, it first deletes the OpenGL
+// objects queued for deletion since the last frame.
+// Returns an indication if anything was rendered and an error.
+func (r *Renderer) RenderStereo(icam camera.ICamera, ipd float32) (bool, error) {
+
+	if ipd < 0 {
+		panic("Renderer.RenderStereo: invalid interpupillary distance")
+	}
+	r.gs.ProcessPendingDeletes()
+	r.rendered = false
+	r.stats = Stats{}
+	if r.scene == nil {
+		return false, nil
+	}
+
+	// Each eye compresses the horizontal field of view of the camera into half the viewport
+	var view, proj math32.Matrix4
+	icam.ViewMatrix(&view)
+	icam.ProjMatrix(&proj)
+	proj[0] *= 2
+	proj[4] *= 2
+	proj[8] *= 2
+	proj[12] *= 2
+
+	// Moving the w clip coordinate by the eye offset pushes the left plane of the
+	// camera frustum to the left plane of the left eye and the right plane to the
+	// one of the right eye, enlarging the other planes slightly.
+	var cull math32.Matrix4
+	cullProj := proj
+	cullProj[15] += proj[0] * ipd / 2
+	cull.MultiplyMatrices(&cullProj, &view)
+	r.stereoCull = &cull
+	defer func() { r.stereoCull = nil }()
+
+	x, y, width, height := r.gs.GetViewport()
+	defer r.gs.Viewport(x, y, width, height)
+	half := width / 2
+	for i := int32(0); i < 2; i++ {
+		var offset math32.Matrix4
+		eye := eyeCamera{ICamera: icam, proj: proj}
+		offset.MakeTranslation(float32(1-2*i)*ipd/2, 0, 0)
+		eye.view.MultiplyMatrices(&offset, &view)
+
+		// Clears and draws inside the half of the eye only
+		ex := x + i*half
+		r.gs.Viewport(ex, y, half, height)
+		r.gs.Enable(gls.SCISSOR_TEST)
+		r.gs.Scissor(ex, y, uint32(half), uint32(height))
+		err := r.renderScene(r.scene, &eye)
+		r.gs.Disable(gls.SCISSOR_TEST)
+		if err != nil {
+			return r.rendered, err
+		}
+	}
+
+	r.prevStats = r.stats
+	return r.rendered, nil
+}
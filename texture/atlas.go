@@ -0,0 +1,155 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"image"
+	"image/draw"
+	"sort"
+)
+
+// AtlasRect is the location of an image packed in an atlas texture.
+type AtlasRect struct {
+	X, Y, Width, Height int     // Pixels rectangle of the image in the atlas from its top-left corner
+	U0, V0, U1, V1      float32 // Texture coordinates of the bottom-left and top-right corners of the image
+}
+
+// AtlasBuilder packs many small images into a single atlas texture, so that the
+// sprites, GUI elements or particles using them can share one texture and be
+// drawn without texture binds between them.
+type AtlasBuilder struct {
+	padding int           // Pixels around each image
+	names   []string      // Images names in insertion order
+	images  []image.Image // Images in insertion order
+}
+
+// NewAtlasBuilder creates and returns a pointer to a new empty atlas builder which
+// surrounds each packed image with the specified number of pixels, filled with the
+// edge pixels of the image, so that bilinear filtering and mipmaps do not blend
+// the images with their neighbours.
+func NewAtlasBuilder(padding int) *AtlasBuilder {
+
+	if padding < 0 {
+		panic("NewAtlasBuilder: invalid padding")
+	}
+	return &AtlasBuilder{padding: padding}
+}
+
+// Add adds the specified image with the specified name, which must be unique.
+func (ab *AtlasBuilder) Add(name string, img image.Image) {
+
+	for _, n := range ab.names {
+		if n == name {
+			panic("AtlasBuilder.Add: duplicate name " + name)
+		}
+	}
+	ab.names = append(ab.names, name)
+	ab.images = append(ab.images, img)
+}
+
+// Build packs the added images into a new atlas texture, whose sizes are the smallest
+// powers of two found to hold them, and returns it with the rectangles of the images by name.
+func (ab *AtlasBuilder) Build() (*Texture2D, map[string]AtlasRect) {
+
+	rgba, rects := ab.build()
+	return NewTexture2DFromRGBA(rgba), rects
+}
+
+// build packs the added images and returns the atlas image and the images rectangles.
+func (ab *AtlasBuilder) build() (*image.RGBA, map[string]AtlasRect) {
+
+	// Packs the tallest images first on shelves of decreasing heights
+	order := make([]int, len(ab.images))
+	area := 0
+	for i, img := range ab.images {
+		order[i] = i
+		size := img.Bounds().Size()
+		area += (size.X + 2*ab.padding) * (size.Y + 2*ab.padding)
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return ab.images[order[i]].Bounds().Dy() > ab.images[order[j]].Bounds().Dy()
+	})
+
+	// Doubles the smallest size of the atlas until all the images fit
+	width, height := 1, 1
+	for width*height < area {
+		if width <= height {
+			width *= 2
+		} else {
+			height *= 2
+		}
+	}
+	cells, ok := ab.pack(order, width, height)
+	for !ok {
+		if width <= height {
+			width *= 2
+		} else {
+			height *= 2
+		}
+		cells, ok = ab.pack(order, width, height)
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+	rects := make(map[string]AtlasRect, len(ab.images))
+	for i, img := range ab.images {
+		cell := cells[i]
+		inner := cell.Inset(ab.padding)
+		draw.Draw(rgba, inner, img, img.Bounds().Min, draw.Src)
+
+		// Extrudes the edges of the image into its padding
+		for y := cell.Min.Y; y < cell.Max.Y; y++ {
+			for x := cell.Min.X; x < cell.Max.X; x++ {
+				if (image.Point{x, y}).In(inner) {
+					continue
+				}
+				rgba.SetRGBA(x, y, rgba.RGBAAt(clamp(x, inner.Min.X, inner.Max.X-1), clamp(y, inner.Min.Y, inner.Max.Y-1)))
+			}
+		}
+
+		w, h := float32(width), float32(height)
+		rects[ab.names[i]] = AtlasRect{
+			X: inner.Min.X, Y: inner.Min.Y, Width: inner.Dx(), Height: inner.Dy(),
+			U0: float32(inner.Min.X) / w, V0: 1 - float32(inner.Max.Y)/h,
+			U1: float32(inner.Max.X) / w, V1: 1 - float32(inner.Min.Y)/h,
+		}
+	}
+	return rgba, rects
+}
+
+// pack places the images in the specified order on shelves in an atlas of the specified
+// sizes and returns the rectangles of the images with their padding, by image index.
+// Returns false if the images do not fit.
+func (ab *AtlasBuilder) pack(order []int, width, height int) ([]image.Rectangle, bool) {
+
+	cells := make([]image.Rectangle, len(ab.images))
+	x, y, shelf := 0, 0, 0
+	for _, i := range order {
+		size := ab.images[i].Bounds().Size().Add(image.Point{2 * ab.padding, 2 * ab.padding})
+		if x+size.X > width {
+			x, y, shelf = 0, y+shelf, 0
+		}
+		if size.X > width || y+size.Y > height {
+			return nil, false
+		}
+		cells[i] = image.Rectangle{image.Point{x, y}, image.Point{x, y}.Add(size)}
+		x += size.X
+		if size.Y > shelf {
+			shelf = size.Y
+		}
+	}
+	return cells, true
+}
+
+// clamp returns the specified value limited to the specified range.
+func clamp(v, min, max int) int {
+
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
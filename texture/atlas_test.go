@@ -0,0 +1,55 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+// Test the packing of images without overlaps and the padding of their edges
+func TestAtlasBuilder(t *testing.T) {
+
+	ab := NewAtlasBuilder(1)
+	colors := map[string]color.RGBA{
+		"red":   {255, 0, 0, 255},
+		"green": {0, 255, 0, 255},
+		"blue":  {0, 0, 255, 255},
+	}
+	sizes := map[string]image.Point{"red": {8, 4}, "green": {3, 7}, "blue": {5, 5}}
+	for _, name := range []string{"red", "green", "blue"} {
+		img := image.NewRGBA(image.Rectangle{Max: sizes[name]})
+		draw.Draw(img, img.Bounds(), image.NewUniform(colors[name]), image.Point{}, draw.Src)
+		ab.Add(name, img)
+	}
+
+	rgba, rects := ab.build()
+	size := rgba.Bounds().Size()
+	if size.X&(size.X-1) != 0 || size.Y&(size.Y-1) != 0 {
+		t.Errorf("atlas size not a power of two: %v", size)
+	}
+	var cells []image.Rectangle
+	for name, r := range rects {
+		if r.Width != sizes[name].X || r.Height != sizes[name].Y {
+			t.Errorf("%s: got size %dx%d", name, r.Width, r.Height)
+		}
+		cell := image.Rect(r.X, r.Y, r.X+r.Width, r.Y+r.Height).Inset(-1)
+		for _, other := range cells {
+			if cell.Overlaps(other) {
+				t.Errorf("%s: overlapping cells %v and %v", name, cell, other)
+			}
+		}
+		cells = append(cells, cell)
+		if rgba.RGBAAt(r.X, r.Y) != colors[name] || rgba.RGBAAt(r.X-1, r.Y-1) != colors[name] ||
+			rgba.RGBAAt(r.X+r.Width, r.Y+r.Height) != colors[name] {
+			t.Errorf("%s: unexpected image or padding colors", name)
+		}
+		if r.U1-r.U0 != float32(r.Width)/float32(size.X) || r.V1 != 1-float32(r.Y)/float32(size.Y) {
+			t.Errorf("%s: unexpected texture coordinates %+v", name, r)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/thommil/tge-g3n/gls"
+)
+
+// CompressedFormat is the compressed internal format of a 2D texture
+type CompressedFormat uint32
+
+// The compressed formats
+const (
+	CompressionDXT1 = CompressedFormat(gls.COMPRESSED_RGB_S3TC_DXT1_EXT)  // BC1 with 4 bits per texel and no alpha
+	CompressionDXT5 = CompressedFormat(gls.COMPRESSED_RGBA_S3TC_DXT5_EXT) // BC3 with 8 bits per texel and interpolated alpha
+)
+
+// NewTexture2DFromImageCompressed creates and returns a pointer to a new Texture2D from the specified
+// image, which is compressed on the CPU to the specified format with its mip levels when uploaded,
+// reducing the texture memory by 4 (DXT5) or 8 (DXT1) times. If the S3TC formats are not supported
+// (see GLS.S3TCSupported) the texture is uploaded uncompressed.
+func NewTexture2DFromImageCompressed(img image.Image, format CompressedFormat) *Texture2D {
+
+	if format != CompressionDXT1 && format != CompressionDXT5 {
+		panic("NewTexture2DFromImageCompressed: invalid compressed format")
+	}
+	rgba, ok := img.(*image.RGBA)
+	if !ok || rgba.Stride != rgba.Rect.Size().X*4 {
+		rgba = image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	}
+	t := NewTexture2DFromRGBA(rgba)
+	t.compressed = uint32(format)
+	return t
+}
+
+// Compression returns the compressed format of this texture or 0 if it is not compressed.
+func (t *Texture2D) Compression() CompressedFormat {
+
+	return CompressedFormat(t.compressed)
+}
+
+// uploadCompressed compresses the texture data with its mip levels if requested
+// and uploads them to the bound OpenGL texture.
+func (t *Texture2D) uploadCompressed(gs *gls.GLS) {
+
+	levels := [][]uint8{t.uploadData().([]uint8)}
+	if t.genMipmap {
+		levels = mipLevels(levels[0], int(t.width), int(t.height))
+	}
+	alpha := t.compressed == uint32(CompressionDXT5)
+	for level, pix := range levels {
+		width, height := mipSize(t.width, level), mipSize(t.height, level)
+		data := compressDXT(pix, int(width), int(height), alpha)
+		gs.CompressedTexImage2D(gls.TEXTURE_2D, int32(level), t.compressed, width, height, data)
+		t.bytes += len(data)
+	}
+	gs.AddTextureBytes(t.bytes)
+}
+
+// compressDXT compresses the specified RGBA image data into DXT1 blocks,
+// or into DXT5 blocks if alpha is set. The edge blocks repeat the last texels.
+func compressDXT(pix []uint8, width, height int, alpha bool) []byte {
+
+	blockSize := 8
+	if alpha {
+		blockSize = 16
+	}
+	bw, bh := (width+3)/4, (height+3)/4
+	data := make([]byte, 0, bw*bh*blockSize)
+	var block [16][4]uint8
+	for by := 0; by < bh; by++ {
+		for bx := 0; bx < bw; bx++ {
+			for i := range block {
+				x, y := bx*4+i%4, by*4+i/4
+				if x >= width {
+					x = width - 1
+				}
+				if y >= height {
+					y = height - 1
+				}
+				copy(block[i][:], pix[(y*width+x)*4:])
+			}
+			if alpha {
+				data = compressAlphaBlock(data, &block)
+			}
+			data = compressColorBlock(data, &block)
+		}
+	}
+	return data
+}
+
+// compressColorBlock appends to data the DXT1 color block of the specified texels, whose
+// endpoints are the corners of the diagonal of their color bounding box which follows
+// the colors the most, inset to reduce the error.
+func compressColorBlock(data []byte, block *[16][4]uint8) []byte {
+
+	var min, max, mean [3]int
+	ref := 0
+	for c := 0; c < 3; c++ {
+		min[c], max[c] = 255, 0
+		for i := range block {
+			v := int(block[i][c])
+			mean[c] += v
+			if v < min[c] {
+				min[c] = v
+			}
+			if v > max[c] {
+				max[c] = v
+			}
+		}
+		mean[c] /= 16
+		if max[c]-min[c] > max[ref]-min[ref] {
+			ref = c
+		}
+	}
+	for c := 0; c < 3; c++ {
+		// Flips the diagonal of the channels decreasing with the channel of largest range
+		cov := 0
+		for i := range block {
+			cov += (int(block[i][c]) - mean[c]) * (int(block[i][ref]) - mean[ref])
+		}
+		if cov < 0 {
+			min[c], max[c] = max[c], min[c]
+		}
+		inset := (max[c] - min[c]) / 16
+		min[c] += inset
+		max[c] -= inset
+	}
+	c0, c1 := rgb565(max), rgb565(min)
+	if c0 < c1 {
+		c0, c1 = c1, c0
+	}
+
+	// Palette of the 4 colors mode, which requires c0 > c1
+	var palette [4][3]int
+	palette[0], palette[1] = rgb888(c0), rgb888(c1)
+	for c := 0; c < 3; c++ {
+		palette[2][c] = (2*palette[0][c] + palette[1][c]) / 3
+		palette[3][c] = (palette[0][c] + 2*palette[1][c]) / 3
+	}
+	var indices uint32
+	if c0 != c1 {
+		for i := range block {
+			best, bestDist := 0, -1
+			for p := range palette {
+				dist := 0
+				for c := 0; c < 3; c++ {
+					d := int(block[i][c]) - palette[p][c]
+					dist += d * d
+				}
+				if bestDist < 0 || dist < bestDist {
+					best, bestDist = p, dist
+				}
+			}
+			indices |= uint32(best) << uint(2*i)
+		}
+	}
+	return append(data, uint8(c0), uint8(c0>>8), uint8(c1), uint8(c1>>8),
+		uint8(indices), uint8(indices>>8), uint8(indices>>16), uint8(indices>>24))
+}
+
+// compressAlphaBlock appends to data the DXT5 alpha block of the specified texels,
+// whose endpoints are their minimum and maximum alpha.
+func compressAlphaBlock(data []byte, block *[16][4]uint8) []byte {
+
+	a0, a1 := 0, 255
+	for i := range block {
+		a := int(block[i][3])
+		if a > a0 {
+			a0 = a
+		}
+		if a < a1 {
+			a1 = a
+		}
+	}
+
+	// Palette of the 8 alphas mode, which requires a0 > a1
+	var indices uint64
+	if a0 != a1 {
+		var palette [8]int
+		palette[0], palette[1] = a0, a1
+		for p := 2; p < 8; p++ {
+			palette[p] = ((8-p)*a0 + (p-1)*a1) / 7
+		}
+		for i := range block {
+			best, bestDist := 0, -1
+			for p, a := range palette {
+				dist := int(block[i][3]) - a
+				if dist < 0 {
+					dist = -dist
+				}
+				if bestDist < 0 || dist < bestDist {
+					best, bestDist = p, dist
+				}
+			}
+			indices |= uint64(best) << uint(3*i)
+		}
+	}
+	return append(data, uint8(a0), uint8(a1), uint8(indices), uint8(indices>>8),
+		uint8(indices>>16), uint8(indices>>24), uint8(indices>>32), uint8(indices>>40))
+}
+
+// rgb565 returns the 16 bits 5:6:5 color of the specified 8 bits color components.
+func rgb565(c [3]int) uint16 {
+
+	return uint16((c[0]*31+127)/255)<<11 | uint16((c[1]*63+127)/255)<<5 | uint16((c[2]*31+127)/255)
+}
+
+// rgb888 returns the 8 bits color components of the specified 16 bits 5:6:5 color.
+func rgb888(c uint16) [3]int {
+
+	r, g, b := int(c>>11), int(c>>5&63), int(c&31)
+	return [3]int{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2}
+}
@@ -0,0 +1,82 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/thommil/tge-g3n/gls"
+)
+
+// Test that a DXT5 block decodes to the compressed colors and alphas
+func TestCompressDXT(t *testing.T) {
+
+	// 4x4 texels with a red to blue gradient and alternating alphas
+	pix := make([]uint8, 16*4)
+	for i := 0; i < 16; i++ {
+		pix[i*4] = uint8(255 - i*17)
+		pix[i*4+2] = uint8(i * 17)
+		pix[i*4+3] = uint8(255 * (i % 2))
+	}
+	data := compressDXT(pix, 4, 4, true)
+	if len(data) != 16 {
+		t.Fatalf("expected one 16 bytes block got %d bytes", len(data))
+	}
+	alphas := []int{int(data[0]), int(data[1])}
+	bits := uint64(data[2]) | uint64(data[3])<<8 | uint64(data[4])<<16 | uint64(data[5])<<24 | uint64(data[6])<<32 | uint64(data[7])<<40
+	c0 := uint16(data[8]) | uint16(data[9])<<8
+	c1 := uint16(data[10]) | uint16(data[11])<<8
+	indices := uint32(data[12]) | uint32(data[13])<<8 | uint32(data[14])<<16 | uint32(data[15])<<24
+	if c0 <= c1 {
+		t.Fatalf("expected the 4 colors mode got endpoints %d and %d", c0, c1)
+	}
+	palette := [4][3]int{rgb888(c0), rgb888(c1)}
+	for c := 0; c < 3; c++ {
+		palette[2][c] = (2*palette[0][c] + palette[1][c]) / 3
+		palette[3][c] = (palette[0][c] + 2*palette[1][c]) / 3
+	}
+	for i := 0; i < 16; i++ {
+		a := alphas[bits>>uint(3*i)&7]
+		if a != int(pix[i*4+3]) {
+			t.Errorf("texel %d: expected alpha %d got %d", i, pix[i*4+3], a)
+		}
+		rgb := palette[indices>>uint(2*i)&3]
+		for c := 0; c < 3; c++ {
+			if d := rgb[c] - int(pix[i*4+c]); d < -40 || d > 40 {
+				t.Errorf("texel %d: expected color %v got %v", i, pix[i*4:i*4+3], rgb)
+				break
+			}
+		}
+	}
+}
+
+// Test that a compressed texture uploads its compressed mip levels and counts their size
+func TestNewTexture2DFromImageCompressed(t *testing.T) {
+
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for i := 0; i < 64; i++ {
+		img.Set(i%8, i/8, color.NRGBA{uint8(i * 4), 128, 0, 255})
+	}
+	tex := NewTexture2DFromImageCompressed(img, CompressionDXT1)
+	gs, err := gls.NewWithBackend(gls.NewRecorder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tex.Upload(gs)
+	var stats gls.Stats
+	gs.Stats(&stats)
+
+	// 8x8, 4x4, 2x2 and 1x1 levels of 4, 1, 1 and 1 blocks of 8 bytes
+	if stats.TextureBytes != 7*8 {
+		t.Errorf("expected %d texture bytes got %d", 7*8, stats.TextureBytes)
+	}
+	tex.Dispose()
+	gs.Stats(&stats)
+	if stats.TextureBytes != 0 {
+		t.Errorf("expected no texture bytes after dispose got %d", stats.TextureBytes)
+	}
+}
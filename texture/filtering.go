@@ -0,0 +1,36 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"github.com/thommil/tge-g3n/gls"
+)
+
+var (
+	defaultMinFilter  uint32 = gls.LINEAR_MIPMAP_LINEAR // Minification filter of the new 2D textures
+	defaultMagFilter  uint32 = gls.LINEAR               // Magnification filter of the new 2D textures
+	defaultAnisotropy        = float32(1)               // Anisotropy of the new 2D textures
+)
+
+// SetDefaultFiltering sets the minification and magnification filters and the anisotropy
+// of the 2D textures created afterwards, including the ones created by the loaders.
+// The default is trilinear filtering (gls.LINEAR_MIPMAP_LINEAR and gls.LINEAR) without
+// anisotropy (1). Each texture can still change its filtering. The anisotropy is clamped
+// to GLS.MaxAnisotropy and ignored if anisotropic filtering is not supported.
+func SetDefaultFiltering(minFilter, magFilter uint32, anisotropy float32) {
+
+	if anisotropy < 1 {
+		panic("SetDefaultFiltering: anisotropy must be at least 1")
+	}
+	defaultMinFilter = minFilter
+	defaultMagFilter = magFilter
+	defaultAnisotropy = anisotropy
+}
+
+// DefaultFiltering returns the minification and magnification filters and the anisotropy of the new 2D textures.
+func DefaultFiltering() (minFilter, magFilter uint32, anisotropy float32) {
+
+	return defaultMinFilter, defaultMagFilter, defaultAnisotropy
+}
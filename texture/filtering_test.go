@@ -0,0 +1,44 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"testing"
+
+	"github.com/thommil/tge-g3n/gls"
+)
+
+// Test that the new textures use the default filtering with the anisotropy clamped to the maximum
+func TestDefaultFiltering(t *testing.T) {
+
+	minFilter, magFilter, anisotropy := DefaultFiltering()
+	defer SetDefaultFiltering(minFilter, magFilter, anisotropy)
+	SetDefaultFiltering(gls.LINEAR_MIPMAP_NEAREST, gls.NEAREST, 16)
+
+	rec := gls.NewRecorder()
+	rec.SetFloat(gls.MAX_TEXTURE_MAX_ANISOTROPY_EXT, 8)
+	gs, err := gls.NewWithBackend(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tex := NewTexture2DFromData(4, 4, gls.RGBA, gls.UNSIGNED_BYTE, gls.RGBA8, make([]byte, 4*4*4))
+	if tex.Anisotropy() != 16 {
+		t.Errorf("expected the default anisotropy 16 got %v", tex.Anisotropy())
+	}
+	tex.Upload(gs)
+	found := map[string]bool{}
+	for _, c := range rec.Calls() {
+		found[c.String()] = true
+	}
+	for _, call := range []string{
+		"TexParameteri(3553, 10241, 9985)", // LINEAR_MIPMAP_NEAREST min filter
+		"TexParameteri(3553, 10240, 9728)", // NEAREST mag filter
+		"TexParameterf(3553, 34046, 8)",    // Anisotropy clamped to the maximum
+	} {
+		if !found[call] {
+			t.Errorf("expected call %s", call)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// LoadCubeLUT reads a 3D color lookup table in the .CUBE format from the
+// specified reader and returns a pointer to a new Texture3D with its colors.
+// The red component varies fastest, as in the texture width.
+func LoadCubeLUT(r io.Reader) (*Texture3D, error) {
+
+	size := 0
+	dmin := [3]float32{0, 0, 0}
+	dmax := [3]float32{1, 1, 1}
+	var data []byte
+	count := 0
+	line := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(text)
+		switch fields[0] {
+		case "TITLE":
+			continue
+		case "LUT_1D_SIZE":
+			return nil, fmt.Errorf("line %d: 1D lookup tables are not supported", line)
+		case "LUT_3D_SIZE":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %d: invalid LUT_3D_SIZE", line)
+			}
+			v, err := strconv.Atoi(fields[1])
+			if err != nil || v < 2 || v > 256 {
+				return nil, fmt.Errorf("line %d: invalid LUT_3D_SIZE", line)
+			}
+			size = v
+			data = make([]byte, size*size*size*4)
+			continue
+		case "DOMAIN_MIN", "DOMAIN_MAX":
+			v, err := parseCubeTriplet(fields)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s", line, fields[0])
+			}
+			if fields[0] == "DOMAIN_MIN" {
+				dmin = v
+			} else {
+				dmax = v
+			}
+			continue
+		}
+
+		// Color entry
+		if data == nil {
+			return nil, fmt.Errorf("line %d: color before LUT_3D_SIZE", line)
+		}
+		if count == size*size*size {
+			return nil, fmt.Errorf("line %d: too many colors", line)
+		}
+		v, err := parseCubeTriplet(append([]string{""}, fields...))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid color", line)
+		}
+		for i := 0; i < 3; i++ {
+			data[count*4+i] = cubeByte((v[i] - dmin[i]) / (dmax[i] - dmin[i]))
+		}
+		data[count*4+3] = 255
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("missing LUT_3D_SIZE")
+	}
+	if count != size*size*size {
+		return nil, fmt.Errorf("expected %d colors got %d", size*size*size, count)
+	}
+	return NewTexture3D(size, size, size, data), nil
+}
+
+// NewLUTFromImage creates and returns a pointer to a new Texture3D with the colors
+// of a lookup table stored as a horizontal strip of square slices, such as the ones
+// exported by image editors. The red component increases along each slice width,
+// the green component along the height and the blue component from slice to slice.
+func NewLUTFromImage(img image.Image) (*Texture3D, error) {
+
+	bounds := img.Bounds()
+	size := bounds.Dy()
+	if size < 2 || bounds.Dx() != size*size {
+		return nil, fmt.Errorf("lookup table image must be %d pixels wide", size*size)
+	}
+	data := make([]byte, size*size*size*4)
+	pos := 0
+	for b := 0; b < size; b++ {
+		for g := 0; g < size; g++ {
+			for r := 0; r < size; r++ {
+				c := color.NRGBAModel.Convert(img.At(bounds.Min.X+b*size+r, bounds.Min.Y+g)).(color.NRGBA)
+				data[pos] = c.R
+				data[pos+1] = c.G
+				data[pos+2] = c.B
+				data[pos+3] = 255
+				pos += 4
+			}
+		}
+	}
+	return NewTexture3D(size, size, size, data), nil
+}
+
+// parseCubeTriplet parses the three float values following the first of the specified fields.
+func parseCubeTriplet(fields []string) ([3]float32, error) {
+
+	var v [3]float32
+	if len(fields) != 4 {
+		return v, fmt.Errorf("expected 3 values")
+	}
+	for i := 0; i < 3; i++ {
+		f, err := strconv.ParseFloat(fields[i+1], 32)
+		if err != nil {
+			return v, err
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
+
+// cubeByte converts the specified normalized value to a rounded byte.
+func cubeByte(v float32) byte {
+
+	if v <= 0 {
+		return 0
+	}
+	if v >= 1 {
+		return 255
+	}
+	return byte(v*255 + 0.5)
+}
@@ -0,0 +1,49 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test parsing of a .CUBE lookup table with a domain and comments
+func TestLoadCubeLUT(t *testing.T) {
+
+	const cube = `# Test table
+TITLE "test"
+LUT_3D_SIZE 2
+DOMAIN_MIN 0 0 0
+DOMAIN_MAX 2 2 2
+
+0 0 0
+2 0 0
+0 2 0
+2 2 0
+0 0 2
+2 0 2
+0 2 2
+1 1 1
+`
+	lut, err := LoadCubeLUT(strings.NewReader(cube))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lut.Width() != 2 || lut.Height() != 2 || lut.Depth() != 2 {
+		t.Fatalf("expected size 2 got %dx%dx%d", lut.Width(), lut.Height(), lut.Depth())
+	}
+	data := lut.Data()
+	if data[4] != 255 || data[5] != 0 || data[6] != 0 || data[7] != 255 {
+		t.Errorf("expected red at second texel got %v", data[4:8])
+	}
+	if data[28] != 128 || data[29] != 128 || data[30] != 128 {
+		t.Errorf("expected gray at last texel got %v", data[28:32])
+	}
+
+	_, err = LoadCubeLUT(strings.NewReader("LUT_3D_SIZE 2\n0 0 0\n"))
+	if err == nil {
+		t.Errorf("expected error for missing colors")
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/math32"
+	"github.com/thommil/tge-g3n/math32/noise"
+)
+
+// NoiseType is the type of the noise of a noise texture
+type NoiseType int
+
+// The noise types
+const (
+	NoisePerlin  = NoiseType(iota) // Perlin gradient noise
+	NoiseSimplex                   // Simplex noise
+	NoiseValue                     // Value noise
+)
+
+// NoiseOptions describes the noise of a noise texture.
+// The zero values of the fields are replaced by their default values.
+type NoiseOptions struct {
+	Type        NoiseType // Type of the noise
+	Seed        int64     // Seed of the noise, the same seed always generates the same texture
+	Octaves     int       // Number of summed octaves (default 1)
+	Frequency   float32   // Number of noise cells across the texture width of the first octave (default 4)
+	Lacunarity  float32   // Frequency multiplier of each octave (default 2)
+	Persistence float32   // Amplitude multiplier of each octave (default 0.5)
+}
+
+// NewNoise2D creates and returns a pointer to a new grayscale 2D texture of the specified size
+// filled with the specified noise, whose range [-1, 1] is mapped to the black to white colors.
+func NewNoise2D(width, height int, opts NoiseOptions) *Texture2D {
+
+	if width <= 0 || height <= 0 {
+		panic("NewNoise2D: invalid texture size")
+	}
+	if opts.Octaves == 0 {
+		opts.Octaves = 1
+	}
+	if opts.Frequency == 0 {
+		opts.Frequency = 4
+	}
+	if opts.Lacunarity == 0 {
+		opts.Lacunarity = 2
+	}
+	if opts.Persistence == 0 {
+		opts.Persistence = 0.5
+	}
+
+	n := noise.New(opts.Seed)
+	var fn func(x, y float32) float32
+	switch opts.Type {
+	case NoiseSimplex:
+		fn = n.Simplex2D
+	case NoiseValue:
+		fn = n.Value2D
+	default:
+		fn = n.Perlin2D
+	}
+
+	// Generates the texture data with square noise cells
+	data := make([]byte, width*height*4)
+	scale := opts.Frequency / float32(width)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := noise.Fractal2D(fn, float32(x)*scale, float32(y)*scale, opts.Octaves, opts.Lacunarity, opts.Persistence)
+			c := byte(math32.Clamp((v+1)*127.5, 0, 255))
+			pos := (x + y*width) * 4
+			data[pos] = c
+			data[pos+1] = c
+			data[pos+2] = c
+			data[pos+3] = 255
+		}
+	}
+	return NewTexture2DFromData(width, height, gls.RGBA, gls.UNSIGNED_BYTE, gls.RGBA8, data)
+}
@@ -0,0 +1,30 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Test that the noise textures of a seed are deterministic
+func TestNewNoise2D(t *testing.T) {
+
+	for _, typ := range []NoiseType{NoisePerlin, NoiseSimplex, NoiseValue} {
+		opts := NoiseOptions{Type: typ, Seed: 3, Octaves: 4}
+		a := NewNoise2D(32, 16, opts).data.([]byte)
+		b := NewNoise2D(32, 16, opts).data.([]byte)
+		if len(a) != 32*16*4 {
+			t.Fatalf("expected %d bytes got %d", 32*16*4, len(a))
+		}
+		if !bytes.Equal(a, b) {
+			t.Errorf("type %d: expected the same texture for the same seed", typ)
+		}
+		opts.Seed = 4
+		if bytes.Equal(a, NewNoise2D(32, 16, opts).data.([]byte)) {
+			t.Errorf("type %d: expected a different texture for a different seed", typ)
+		}
+	}
+}
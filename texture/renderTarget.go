@@ -0,0 +1,539 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"fmt"
+
+	"github.com/thommil/tge-g3n/gls"
+)
+
+// RenderTarget is an offscreen framebuffer with a depth buffer and a color
+// texture, which is either a Texture2D or one face of a TextureCube.
+// The scene rendered between Bind and Unbind is written to the color texture.
+// The depth buffer of 2D targets can also be a texture (see SetDepthTexture),
+// 2D targets can have several color textures (see SetColorTextures), none,
+// formats other than RGBA8 (see NewRenderTargetSpec) or be multisampled (see SetSamples).
+type RenderTarget struct {
+	gs           *gls.GLS     // Pointer to OpenGL state. Valid after first Bind
+	fbo          uint32       // Framebuffer handle
+	depthRbo     uint32       // Depth renderbuffer handle
+	depth        *Texture2D   // Optional depth texture used instead of the renderbuffer
+	depthFormat  uint32       // Internal format of the depth buffer
+	width        int32        // Width in pixels
+	height       int32        // Height in pixels
+	color        *Texture2D   // Color texture (2D targets)
+	colors       []*Texture2D // Additional color textures (2D targets)
+	cube         *TextureCube // Color texture (cube targets)
+	face         int          // Cube face to render to
+	level        int          // Cube mip level to render to
+	attachedFace int          // Cube face currently attached
+	attachedLvl  int          // Cube mip level currently attached
+	prevFbo      uint32       // Framebuffer bound before Bind
+	prevViewport [4]int32     // Viewport set before Bind
+	samples      int32        // Number of samples of the multisampled buffers or 0
+	msFbo        uint32       // Multisampled framebuffer handle
+	msColorRbo   uint32       // Multisampled color renderbuffer handle
+	msDepthRbo   uint32       // Multisampled depth renderbuffer handle
+}
+
+// RenderTargetSpec describes the buffers of a 2D render target created by NewRenderTargetSpec.
+// The supported color formats are gls.RGBA8, gls.RGBA16F and gls.R11F_G11F_B10F for high dynamic
+// range colors, gls.R8 and gls.RG16F for auxiliary buffers and gls.R32UI for identifiers, and
+// the supported depth formats gls.DEPTH_COMPONENT24, gls.DEPTH_COMPONENT32F and gls.DEPTH24_STENCIL8.
+type RenderTargetSpec struct {
+	Width        int      // Width in pixels
+	Height       int      // Height in pixels
+	ColorFormats []uint32 // Internal formats of the color textures, none for a depth only target
+	DepthFormat  uint32   // Internal format of the depth buffer (default gls.DEPTH_COMPONENT24)
+	DepthTexture bool     // Indicates if the depth buffer is a texture (see SetDepthTexture)
+	Samples      int      // Number of samples of the multisample anti-aliasing (see SetSamples)
+}
+
+// colorFormats maps the supported color internal formats to their format and type
+var colorFormats = map[uint32][2]uint32{
+	gls.RGBA8:          {gls.RGBA, gls.UNSIGNED_BYTE},
+	gls.RGBA16F:        {gls.RGBA, gls.HALF_FLOAT},
+	gls.R11F_G11F_B10F: {gls.RGB, gls.HALF_FLOAT},
+	gls.R8:             {gls.RED, gls.UNSIGNED_BYTE},
+	gls.RG16F:          {gls.RG, gls.HALF_FLOAT},
+	gls.R32UI:          {gls.RED_INTEGER, gls.UNSIGNED_INT},
+}
+
+// depthFormats maps the supported depth internal formats to their format and type
+var depthFormats = map[uint32][2]uint32{
+	gls.DEPTH_COMPONENT24:  {gls.DEPTH_COMPONENT, gls.UNSIGNED_INT},
+	gls.DEPTH_COMPONENT32F: {gls.DEPTH_COMPONENT, gls.FLOAT},
+	gls.DEPTH24_STENCIL8:   {gls.DEPTH_STENCIL, gls.UNSIGNED_INT_24_8},
+}
+
+// NewRenderTarget creates and returns a pointer to a new RenderTarget
+// with a color texture of the specified size.
+func NewRenderTarget(width, height int) *RenderTarget {
+
+	return NewRenderTargetSpec(RenderTargetSpec{Width: width, Height: height, ColorFormats: []uint32{gls.RGBA8}})
+}
+
+// NewRenderTargetSpec creates and returns a pointer to a new 2D RenderTarget with the
+// specified size and buffers formats, such as a depth only target for shadow maps. It panics
+// if a format is not supported, and Bind returns an error if the OpenGL implementation
+// can't render to the combination of formats, which is checked by the framebuffer completeness.
+// Multisampled targets have a single color texture.
+func NewRenderTargetSpec(spec RenderTargetSpec) *RenderTarget {
+
+	if spec.Width < 0 || spec.Height < 0 {
+		panic("NewRenderTargetSpec: invalid size")
+	}
+	rt := new(RenderTarget)
+	rt.width = int32(spec.Width)
+	rt.height = int32(spec.Height)
+	for i, format := range spec.ColorFormats {
+		t := newColorTexture(spec.Width, spec.Height, format)
+		if i == 0 {
+			rt.color = t
+		} else {
+			rt.colors = append(rt.colors, t)
+		}
+	}
+	rt.depthFormat = spec.DepthFormat
+	if rt.depthFormat == 0 {
+		rt.depthFormat = gls.DEPTH_COMPONENT24
+	}
+	if _, ok := depthFormats[rt.depthFormat]; !ok {
+		panic("NewRenderTargetSpec: unsupported depth format")
+	}
+	if spec.DepthTexture {
+		rt.SetDepthTexture(true)
+	}
+	if spec.Samples > 0 {
+		if rt.color == nil {
+			panic("NewRenderTargetSpec: multisampled targets must have a color texture")
+		}
+		rt.SetSamples(spec.Samples)
+	}
+	return rt
+}
+
+// newColorTexture creates and returns a pointer to a new color attachment texture
+// with the specified internal format.
+func newColorTexture(width, height int, iformat uint32) *Texture2D {
+
+	format, ok := colorFormats[iformat]
+	if !ok {
+		panic("RenderTarget: unsupported color format")
+	}
+	t := NewTexture2DFromData(width, height, int(format[0]), int(format[1]), int(iformat), nil)
+	if format[0] == gls.RED_INTEGER {
+		// Integer textures can't be filtered
+		t.SetMagFilter(gls.NEAREST)
+		t.SetMinFilter(gls.NEAREST)
+	} else {
+		t.SetMinFilter(gls.LINEAR)
+	}
+	t.SetFlipY(false)
+	t.genMipmap = false
+	return t
+}
+
+// NewCubeRenderTarget creates and returns a pointer to a new RenderTarget
+// with a cube map color texture of the specified faces size.
+func NewCubeRenderTarget(size int) *RenderTarget {
+
+	rt := new(RenderTarget)
+	rt.width = int32(size)
+	rt.height = int32(size)
+	rt.cube = NewTextureCube(size)
+	rt.attachedFace = -1
+	rt.depthFormat = gls.DEPTH_COMPONENT24
+	return rt
+}
+
+// Texture returns the first color texture of a 2D render target or nil.
+func (rt *RenderTarget) Texture() *Texture2D {
+
+	return rt.color
+}
+
+// SetColorTextures sets the number of color textures of this 2D render target, which are
+// written by the fragment shader outputs of the same locations (multiple render targets).
+// The additional textures have the format of the first one. It must be set before the
+// first Bind and multisampled targets have a single color texture. Bind fails with multiple
+// color textures if GLS.GL3Supported is false.
+func (rt *RenderTarget) SetColorTextures(count int) {
+
+	if rt.gs != nil || rt.cube != nil {
+		panic("RenderTarget.SetColorTextures: must be set before the first Bind of a 2D target")
+	}
+	if count < 1 || count > 1 && rt.samples > 0 || rt.color == nil {
+		panic("RenderTarget.SetColorTextures: invalid number of color textures")
+	}
+	rt.colors = rt.colors[:0]
+	for i := 1; i < count; i++ {
+		rt.colors = append(rt.colors, newColorTexture(int(rt.width), int(rt.height), uint32(rt.color.iformat)))
+	}
+}
+
+// ColorTexture returns the color texture of the specified index of a 2D render target.
+func (rt *RenderTarget) ColorTexture(index int) *Texture2D {
+
+	if index == 0 {
+		return rt.color
+	}
+	return rt.colors[index-1]
+}
+
+// SetDepthTexture sets whether the depth buffer of this 2D render target is a texture
+// which can be sampled by shaders, such as post processing effects, instead of a
+// renderbuffer. It must be set before the first Bind.
+func (rt *RenderTarget) SetDepthTexture(state bool) {
+
+	if rt.gs != nil || rt.cube != nil {
+		panic("RenderTarget.SetDepthTexture: must be set before the first Bind of a 2D target")
+	}
+	if !state {
+		rt.depth = nil
+		return
+	}
+	format := depthFormats[rt.depthFormat]
+	rt.depth = NewTexture2DFromData(int(rt.width), int(rt.height), int(format[0]), int(format[1]), int(rt.depthFormat), nil)
+	rt.depth.SetMagFilter(gls.NEAREST)
+	rt.depth.SetMinFilter(gls.NEAREST)
+	rt.depth.SetFlipY(false)
+	rt.depth.genMipmap = false
+}
+
+// SetHDR sets whether the color textures of this 2D render target store half float
+// RGBA values for high dynamic range rendering instead of RGBA bytes, replacing their
+// format. It must be set before the first Bind. OpenGL ES requires the EXT_color_buffer_float extension.
+func (rt *RenderTarget) SetHDR(state bool) {
+
+	if rt.gs != nil || rt.cube != nil {
+		panic("RenderTarget.SetHDR: must be set before the first Bind of a 2D target")
+	}
+	if rt.color == nil {
+		return
+	}
+	for _, t := range append([]*Texture2D{rt.color}, rt.colors...) {
+		t.format = gls.RGBA
+		if state {
+			t.iformat = gls.RGBA16F
+			t.formatType = gls.HALF_FLOAT
+		} else {
+			t.iformat = gls.RGBA8
+			t.formatType = gls.UNSIGNED_BYTE
+		}
+	}
+}
+
+// HDR returns whether the color texture of this render target stores half float values.
+func (rt *RenderTarget) HDR() bool {
+
+	return rt.color != nil && rt.color.formatType == gls.HALF_FLOAT
+}
+
+// SetSamples sets the number of samples of the multisample anti-aliasing of this 2D
+// render target, or 0 to disable it. The scene is rendered to multisampled renderbuffers
+// which are resolved to the color and depth textures by Unbind. The number of samples
+// is clamped to GLS.MaxSamples at the first Bind, before which it must be set.
+func (rt *RenderTarget) SetSamples(samples int) {
+
+	if rt.gs != nil || rt.cube != nil {
+		panic("RenderTarget.SetSamples: must be set before the first Bind of a 2D target")
+	}
+	if samples < 0 || samples > 0 && len(rt.colors) > 0 {
+		panic("RenderTarget.SetSamples: invalid number of samples")
+	}
+	rt.samples = int32(samples)
+}
+
+// Samples returns the number of samples of the multisampled buffers of this render target or 0.
+func (rt *RenderTarget) Samples() int {
+
+	return int(rt.samples)
+}
+
+// DepthFormat returns the internal format of the depth buffer of this render target.
+func (rt *RenderTarget) DepthFormat() uint32 {
+
+	return rt.depthFormat
+}
+
+// DepthTexture returns the depth texture of this render target or nil.
+func (rt *RenderTarget) DepthTexture() *Texture2D {
+
+	return rt.depth
+}
+
+// CubeTexture returns the color texture of a cube render target or nil.
+func (rt *RenderTarget) CubeTexture() *TextureCube {
+
+	return rt.cube
+}
+
+// Width returns the render target width in pixels
+func (rt *RenderTarget) Width() int {
+
+	return int(rt.width)
+}
+
+// Height returns the render target height in pixels
+func (rt *RenderTarget) Height() int {
+
+	return int(rt.height)
+}
+
+// SetCubeFace sets the face of the cube map texture which will be rendered
+// to at the next Bind, from 0 (+X) to 5 (-Z).
+func (rt *RenderTarget) SetCubeFace(face int) {
+
+	rt.face = face
+}
+
+// SetCubeLevels sets the number of mip levels of the cube map texture, which are
+// rendered to (see SetCubeLevel) instead of being generated, such as the roughness
+// levels of a prefiltered environment map. It must be set before the first Bind.
+func (rt *RenderTarget) SetCubeLevels(levels int) {
+
+	if rt.gs != nil || rt.cube == nil {
+		panic("RenderTarget.SetCubeLevels: must be set before the first Bind of a cube target")
+	}
+	if levels < 1 || rt.width>>uint(levels-1) == 0 {
+		panic("RenderTarget.SetCubeLevels: invalid number of levels")
+	}
+	rt.cube.levels = int32(levels)
+	if levels > 1 {
+		rt.cube.minFilter = gls.LINEAR_MIPMAP_LINEAR
+	}
+}
+
+// SetCubeLevel sets the mip level of the cube map texture which will be rendered
+// to at the next Bind, whose viewport is the size of the level.
+func (rt *RenderTarget) SetCubeLevel(level int) {
+
+	if rt.cube == nil || level < 0 || level >= int(rt.cube.levels) {
+		panic("RenderTarget.SetCubeLevel: invalid level")
+	}
+	rt.level = level
+}
+
+// Bind binds this render target framebuffer and sets the viewport to its size,
+// saving the previous framebuffer and viewport to be restored by Unbind.
+// The OpenGL objects are created at the first call.
+func (rt *RenderTarget) Bind(gs *gls.GLS) error {
+
+	rt.prevFbo = gs.Framebuffer()
+	rt.prevViewport[0], rt.prevViewport[1], rt.prevViewport[2], rt.prevViewport[3] = gs.GetViewport()
+
+	// One time initialization
+	if rt.gs == nil {
+		err := rt.init(gs)
+		if err != nil {
+			return err
+		}
+	}
+	if rt.samples > 0 {
+		gs.BindFramebuffer(rt.msFbo)
+	} else {
+		gs.BindFramebuffer(rt.fbo)
+	}
+
+	// Attaches the requested cube face and level
+	if rt.cube != nil {
+		if rt.face != rt.attachedFace || rt.level != rt.attachedLvl {
+			gs.FramebufferTexture2D(gls.COLOR_ATTACHMENT0, uint32(gls.TEXTURE_CUBE_MAP_POSITIVE_X+rt.face), rt.cube.texname, int32(rt.level))
+			rt.attachedFace = rt.face
+			rt.attachedLvl = rt.level
+		}
+		size := rt.width >> uint(rt.level)
+		gs.Viewport(0, 0, size, size)
+		return nil
+	}
+	gs.Viewport(0, 0, rt.width, rt.height)
+	return nil
+}
+
+// Unbind resolves the multisampled buffers and restores the framebuffer and viewport saved by Bind.
+func (rt *RenderTarget) Unbind() {
+
+	if rt.gs == nil {
+		return
+	}
+	if rt.samples > 0 {
+		mask := uint(gls.COLOR_BUFFER_BIT)
+		if rt.depth != nil {
+			mask |= gls.DEPTH_BUFFER_BIT
+			if rt.depthFormat == gls.DEPTH24_STENCIL8 {
+				mask |= gls.STENCIL_BUFFER_BIT
+			}
+		}
+		rt.gs.BlitFramebuffer(rt.msFbo, rt.fbo, 0, 0, rt.width, rt.height, 0, 0, rt.width, rt.height, mask, gls.NEAREST)
+	}
+	rt.gs.BindFramebuffer(rt.prevFbo)
+	rt.gs.Viewport(rt.prevViewport[0], rt.prevViewport[1], rt.prevViewport[2], rt.prevViewport[3])
+}
+
+// Dispose releases the OpenGL resources associated with this render target.
+func (rt *RenderTarget) Dispose() {
+
+	if rt.gs != nil {
+		rt.gs.DeleteFramebuffers(rt.fbo)
+		if rt.depthRbo != 0 {
+			rt.gs.DeleteRenderbuffers(rt.depthRbo)
+		}
+		if rt.msFbo != 0 {
+			rt.gs.DeleteFramebuffers(rt.msFbo)
+			rt.gs.DeleteRenderbuffers(rt.msColorRbo, rt.msDepthRbo)
+		}
+		rt.gs = nil
+	}
+	if rt.color != nil {
+		rt.color.Dispose()
+	}
+	for _, t := range rt.colors {
+		t.Dispose()
+	}
+	if rt.depth != nil {
+		rt.depth.Dispose()
+	}
+	if rt.cube != nil {
+		rt.cube.Dispose()
+	}
+}
+
+// init creates the framebuffer, allocates the color texture and the depth
+// renderbuffer and checks the framebuffer completeness.
+func (rt *RenderTarget) init(gs *gls.GLS) error {
+
+	fbo := gs.GenFramebuffer()
+	gs.BindFramebuffer(fbo)
+
+	// Allocates and attaches the color texture
+	if rt.color != nil {
+		rt.allocTexture(gs, rt.color)
+		gs.FramebufferTexture2D(gls.COLOR_ATTACHMENT0, gls.TEXTURE_2D, rt.color.texname, 0)
+		if len(rt.colors) > 0 {
+			if !gs.GL3Supported() {
+				gs.BindFramebuffer(rt.prevFbo)
+				gs.DeleteFramebuffers(fbo)
+				return fmt.Errorf("multiple color textures require DrawBuffers, see GLS.GL3Supported")
+			}
+			bufs := []uint32{gls.COLOR_ATTACHMENT0}
+			for i, t := range rt.colors {
+				rt.allocTexture(gs, t)
+				attachment := uint32(gls.COLOR_ATTACHMENT1 + i)
+				gs.FramebufferTexture2D(attachment, gls.TEXTURE_2D, t.texname, 0)
+				bufs = append(bufs, attachment)
+			}
+			gs.DrawBuffers(bufs...)
+		}
+	} else if rt.cube != nil {
+		rt.cube.bind(gs)
+	} else {
+		// Depth only target, which OpenGL ES and WebGL don't require to disable the color buffers
+		if gs.GL3Supported() {
+			gs.DrawBuffers(gls.NONE)
+			gs.ReadBuffer(gls.NONE)
+		}
+	}
+
+	// Creates and attaches the depth buffer
+	var rbo uint32
+	if rt.depth != nil {
+		rt.allocTexture(gs, rt.depth)
+		gs.FramebufferTexture2D(rt.depthAttachment(), gls.TEXTURE_2D, rt.depth.texname, 0)
+	} else {
+		rbo = gs.GenRenderbuffer()
+		gs.BindRenderbuffer(rbo)
+		gs.RenderbufferStorage(rt.depthFormat, rt.width, rt.height)
+		gs.FramebufferRenderbuffer(rt.depthAttachment(), rbo)
+	}
+
+	// Cube targets attach their face at each Bind
+	if rt.cube == nil {
+		status := gs.CheckFramebufferStatus()
+		if status != gls.FRAMEBUFFER_COMPLETE {
+			gs.BindFramebuffer(rt.prevFbo)
+			gs.DeleteFramebuffers(fbo)
+			if rbo != 0 {
+				gs.DeleteRenderbuffers(rbo)
+			}
+			return fmt.Errorf("incomplete framebuffer: 0x%X with the color formats %#x and the depth format %#x", status, rt.colorFormats(), rt.depthFormat)
+		}
+	}
+	rt.fbo = fbo
+	rt.depthRbo = rbo
+	rt.gs = gs
+	if max := gs.MaxSamples(); int(rt.samples) > max {
+		rt.samples = int32(max)
+	}
+	if rt.samples > 0 {
+		return rt.initMultisample()
+	}
+	return nil
+}
+
+// initMultisample creates the multisampled framebuffer with its color
+// and depth renderbuffers and checks the framebuffer completeness.
+func (rt *RenderTarget) initMultisample() error {
+
+	gs := rt.gs
+	rt.msFbo = gs.GenFramebuffer()
+	gs.BindFramebuffer(rt.msFbo)
+	rt.msColorRbo = gs.GenRenderbuffer()
+	gs.BindRenderbuffer(rt.msColorRbo)
+	gs.RenderbufferStorageMultisample(rt.samples, uint32(rt.color.iformat), rt.width, rt.height)
+	gs.FramebufferRenderbuffer(gls.COLOR_ATTACHMENT0, rt.msColorRbo)
+	rt.msDepthRbo = gs.GenRenderbuffer()
+	gs.BindRenderbuffer(rt.msDepthRbo)
+	gs.RenderbufferStorageMultisample(rt.samples, rt.depthFormat, rt.width, rt.height)
+	gs.FramebufferRenderbuffer(rt.depthAttachment(), rt.msDepthRbo)
+	status := gs.CheckFramebufferStatus()
+	if status != gls.FRAMEBUFFER_COMPLETE {
+		gs.BindFramebuffer(rt.prevFbo)
+		gs.DeleteFramebuffers(rt.msFbo)
+		gs.DeleteRenderbuffers(rt.msColorRbo, rt.msDepthRbo)
+		rt.msFbo = 0
+		rt.samples = 0
+		return fmt.Errorf("incomplete multisample framebuffer: 0x%X", status)
+	}
+	return nil
+}
+
+// depthAttachment returns the framebuffer attachment of the depth buffer.
+func (rt *RenderTarget) depthAttachment() uint32 {
+
+	if rt.depthFormat == gls.DEPTH24_STENCIL8 {
+		return gls.DEPTH_STENCIL_ATTACHMENT
+	}
+	return gls.DEPTH_ATTACHMENT
+}
+
+// colorFormats returns the internal formats of the color textures of a 2D render target.
+func (rt *RenderTarget) colorFormats() []uint32 {
+
+	var formats []uint32
+	if rt.color != nil {
+		for _, t := range append([]*Texture2D{rt.color}, rt.colors...) {
+			formats = append(formats, uint32(t.iformat))
+		}
+	}
+	return formats
+}
+
+// allocTexture allocates the storage of the specified attachment texture
+// and sets its parameters, so it can be sampled without a RenderSetup.
+func (rt *RenderTarget) allocTexture(gs *gls.GLS, t *Texture2D) {
+
+	t.texname = gs.GenTexture()
+	t.gs = gs
+	gs.BindTexture(gls.TEXTURE_2D, t.texname)
+	gs.TexImage2D(gls.TEXTURE_2D, 0, t.iformat, t.width, t.height, 0, t.format, t.formatType, nil)
+	t.updateData = false
+	gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_MAG_FILTER, int32(t.magFilter))
+	gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_MIN_FILTER, int32(t.minFilter))
+	gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_WRAP_S, int32(t.wrapS))
+	gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_WRAP_T, int32(t.wrapT))
+	t.updateParams = false
+}
@@ -0,0 +1,67 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/thommil/tge-g3n/gls"
+)
+
+// Test the allocation of the render targets buffers with the specified formats
+func TestNewRenderTargetSpec(t *testing.T) {
+
+	rec := gls.NewRecorder()
+	gs, err := gls.NewWithBackend(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		spec  RenderTargetSpec
+		calls []string
+	}{
+		{RenderTargetSpec{Width: 4, Height: 4, DepthTexture: true}, []string{
+			fmt.Sprintf("DrawBuffers([%d])", gls.NONE),
+			fmt.Sprintf("ReadBuffer(%d)", gls.NONE),
+			fmt.Sprintf("TexImage2D(%d, 0, 4, 4, %d, %d, [0 bytes])", gls.TEXTURE_2D, gls.DEPTH_COMPONENT, gls.UNSIGNED_INT),
+		}},
+		{RenderTargetSpec{Width: 4, Height: 4, ColorFormats: []uint32{gls.R11F_G11F_B10F, gls.R32UI}, DepthFormat: gls.DEPTH24_STENCIL8}, []string{
+			fmt.Sprintf("TexImage2D(%d, 0, 4, 4, %d, %d, [0 bytes])", gls.TEXTURE_2D, gls.RGB, gls.HALF_FLOAT),
+			fmt.Sprintf("TexImage2D(%d, 0, 4, 4, %d, %d, [0 bytes])", gls.TEXTURE_2D, gls.RED_INTEGER, gls.UNSIGNED_INT),
+			fmt.Sprintf("RenderbufferStorage(%d, %d, 4, 4)", gls.RENDERBUFFER, gls.DEPTH24_STENCIL8),
+			fmt.Sprintf("FramebufferRenderbuffer(%d, %d, %d, 6)", gls.FRAMEBUFFER, gls.DEPTH_STENCIL_ATTACHMENT, gls.RENDERBUFFER),
+		}},
+	} {
+		rec.Reset()
+		rt := NewRenderTargetSpec(c.spec)
+		err := rt.Bind(gs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rt.Unbind()
+		for i, format := range c.spec.ColorFormats {
+			if iformat := uint32(rt.ColorTexture(i).iformat); iformat != format {
+				t.Errorf("expected color format %#x got %#x", format, iformat)
+			}
+		}
+		found := map[string]bool{}
+		for _, call := range rec.Calls() {
+			found[call.String()] = true
+		}
+		for _, call := range c.calls {
+			if !found[call] {
+				t.Errorf("expected call %s in %v", call, rec.Calls())
+			}
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for an unsupported color format")
+		}
+	}()
+	NewRenderTargetSpec(RenderTargetSpec{Width: 4, Height: 4, ColorFormats: []uint32{gls.RGB8}})
+}
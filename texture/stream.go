@@ -0,0 +1,266 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"github.com/thommil/tge-g3n/gls"
+)
+
+// streamMinSize is the maximum size in texels of the mip levels
+// uploaded at once when a streamed texture is first used.
+const streamMinSize = 64
+
+var (
+	streamBudget int          // Memory budget of the streamed textures in bytes or 0 if disabled
+	streamBytes  int          // Memory used by the streamed textures in bytes
+	streamed     []*Texture2D // Streamed textures uploaded to OpenGL
+)
+
+// SetMemoryBudget sets the maximum memory in bytes used by the mip levels of the
+// streamed 2D textures, which are the RGBA unsigned byte textures with mipmaps
+// uploaded after the budget is set. The small mip levels of a streamed texture
+// are uploaded when the texture is first used and the larger levels are then uploaded
+// one at a time each time it is used, evicting the largest levels of the least
+// recently used textures when over budget. The smallest levels are always kept,
+// so the budget may be exceeded. A budget of 0, the default, disables streaming.
+func SetMemoryBudget(bytes int) {
+
+	if bytes < 0 {
+		panic("SetMemoryBudget: bytes must not be negative")
+	}
+	streamBudget = bytes
+}
+
+// MemoryBudget returns the maximum memory in bytes used by the streamed textures
+// or 0 if streaming is disabled.
+func MemoryBudget() int {
+
+	return streamBudget
+}
+
+// streamable returns whether the texture data can be streamed.
+func (t *Texture2D) streamable() bool {
+
+	pix, ok := t.data.([]uint8)
+	return ok && streamBudget > 0 && t.genMipmap && t.format == gls.RGBA && t.formatType == gls.UNSIGNED_BYTE &&
+		len(pix) >= int(t.width)*int(t.height)*4
+}
+
+// uploadStreamed builds the mip levels of the texture data and uploads the
+// levels not larger than streamMinSize to the bound OpenGL texture.
+func (t *Texture2D) uploadStreamed(gs *gls.GLS) {
+
+	t.mips = mipLevels(t.uploadData().([]uint8), int(t.width), int(t.height))
+	t.baseLevel = len(t.mips) - 1
+	for t.baseLevel > 0 && mipSize(t.width, t.baseLevel-1) <= streamMinSize && mipSize(t.height, t.baseLevel-1) <= streamMinSize {
+		t.baseLevel--
+	}
+	t.lastDraw = drawcalls(gs)
+	size := 0
+	for level := t.baseLevel; level < len(t.mips); level++ {
+		size += len(t.mips[level])
+	}
+	if evictLevels(gs, size, t.lastDraw) {
+		gs.BindTexture(gls.TEXTURE_2D, t.texname)
+	}
+	t.uploadLevels(gs)
+	streamed = append(streamed, t)
+}
+
+// streamLevel uploads the next larger mip level of the streamed texture to the
+// bound OpenGL texture if it fits in the budget, evicting levels of other textures if needed.
+func (t *Texture2D) streamLevel(gs *gls.GLS) {
+
+	t.lastDraw = drawcalls(gs)
+	if t.baseLevel == 0 {
+		return
+	}
+	level := t.baseLevel - 1
+	if evictLevels(gs, len(t.mips[level]), t.lastDraw) {
+		gs.BindTexture(gls.TEXTURE_2D, t.texname)
+	}
+	if streamBudget > 0 && streamBytes+len(t.mips[level]) > streamBudget {
+		return
+	}
+	t.baseLevel = level
+	t.uploadLevel(gs, level)
+	t.setLevels(gs)
+}
+
+// uploadLevels uploads the mip levels of the streamed texture from its base level
+// to the bound OpenGL texture.
+func (t *Texture2D) uploadLevels(gs *gls.GLS) {
+
+	for level := len(t.mips) - 1; level >= t.baseLevel; level-- {
+		t.uploadLevel(gs, level)
+	}
+	t.setLevels(gs)
+}
+
+// uploadLevel uploads the specified mip level of the streamed texture to the bound OpenGL texture.
+func (t *Texture2D) uploadLevel(gs *gls.GLS, level int) {
+
+	gs.TexImage2D(gls.TEXTURE_2D, int32(level), t.iformat, mipSize(t.width, level), mipSize(t.height, level),
+		0, t.format, t.formatType, t.mips[level])
+	t.bytes += len(t.mips[level])
+	streamBytes += len(t.mips[level])
+	gs.AddTextureBytes(len(t.mips[level]))
+}
+
+// setLevels restricts the sampling of the bound OpenGL texture to the uploaded mip levels.
+func (t *Texture2D) setLevels(gs *gls.GLS) {
+
+	gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_BASE_LEVEL, int32(t.baseLevel))
+	gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_MAX_LEVEL, int32(len(t.mips)-1))
+}
+
+// evictLevel releases the largest uploaded mip level of the streamed texture.
+// As OpenGL does not release the memory of single levels, the texture is recreated
+// with its remaining levels, leaving it bound to the active texture unit.
+func (t *Texture2D) evictLevel() {
+
+	gs := t.gs
+	t.freeBytes()
+	gs.DeleteTextures(t.texname)
+	t.texname = gs.GenTexture()
+	gs.BindTexture(gls.TEXTURE_2D, t.texname)
+	t.baseLevel++
+	t.uploadLevels(gs)
+	t.updateParams = true
+}
+
+// release releases the texture memory accounted for the uploaded data
+// and stops streaming the texture.
+func (t *Texture2D) release() {
+
+	t.freeBytes()
+	if t.mips == nil {
+		return
+	}
+	t.mips = nil
+	for i, tex := range streamed {
+		if tex == t {
+			copy(streamed[i:], streamed[i+1:])
+			streamed[len(streamed)-1] = nil
+			streamed = streamed[:len(streamed)-1]
+			break
+		}
+	}
+}
+
+// freeBytes removes the memory of the uploaded data from the texture memory usage.
+func (t *Texture2D) freeBytes() {
+
+	if t.mips != nil {
+		streamBytes -= t.bytes
+	}
+	t.gs.AddTextureBytes(-t.bytes)
+	t.bytes = 0
+}
+
+// evictLevels evicts the largest mip levels of the least recently used streamed textures,
+// not used since the specified number of draw calls, until the specified number of bytes
+// fits in the budget or no level can be evicted. Returns whether levels were evicted,
+// which changes the texture bound to the active texture unit.
+func evictLevels(gs *gls.GLS, bytes int, draw uint64) bool {
+
+	evicted := false
+	for streamBudget > 0 && streamBytes+bytes > streamBudget {
+		var lru *Texture2D
+		for _, tex := range streamed {
+			// Textures set up for the current draw may be bound to other units
+			if tex.lastDraw >= draw || mipSize(tex.width, tex.baseLevel) <= streamMinSize && mipSize(tex.height, tex.baseLevel) <= streamMinSize {
+				continue
+			}
+			if lru == nil || tex.lastDraw < lru.lastDraw {
+				lru = tex
+			}
+		}
+		if lru == nil {
+			break
+		}
+		lru.evictLevel()
+		evicted = true
+	}
+	return evicted
+}
+
+// drawcalls returns the cumulative number of draw calls of the specified OpenGL state,
+// used as the time at which the streamed textures are used.
+func drawcalls(gs *gls.GLS) uint64 {
+
+	var stats gls.Stats
+	gs.Stats(&stats)
+	return stats.Drawcalls
+}
+
+// mipSize returns the size of the specified mip level of a texture of the specified size.
+func mipSize(size int32, level int) int32 {
+
+	if size>>uint(level) < 1 {
+		return 1
+	}
+	return size >> uint(level)
+}
+
+// mipLevels returns the mip levels of the specified RGBA image data, from the image
+// itself to the 1x1 level, each level averaging the 2x2 texels of the previous one.
+func mipLevels(pix []uint8, width, height int) [][]uint8 {
+
+	levels := [][]uint8{pix}
+	for width > 1 || height > 1 {
+		w, h := width/2, height/2
+		if w < 1 {
+			w = 1
+		}
+		if h < 1 {
+			h = 1
+		}
+		next := make([]uint8, w*h*4)
+		for y := 0; y < h; y++ {
+			y0 := 2 * y
+			y1 := y0 + 1
+			if y1 >= height {
+				y1 = y0
+			}
+			for x := 0; x < w; x++ {
+				x0 := 2 * x
+				x1 := x0 + 1
+				if x1 >= width {
+					x1 = x0
+				}
+				for c := 0; c < 4; c++ {
+					sum := uint32(pix[(y0*width+x0)*4+c]) + uint32(pix[(y0*width+x1)*4+c]) +
+						uint32(pix[(y1*width+x0)*4+c]) + uint32(pix[(y1*width+x1)*4+c])
+					next[(y*w+x)*4+c] = uint8((sum + 2) / 4)
+				}
+			}
+		}
+		levels = append(levels, next)
+		pix, width, height = next, w, h
+	}
+	return levels
+}
+
+// pixelSize returns the size in bytes of a texel with the specified format and type.
+func pixelSize(format, formatType uint32) int {
+
+	components := 4
+	switch format {
+	case gls.RED, gls.DEPTH_COMPONENT:
+		components = 1
+	case gls.RG:
+		components = 2
+	case gls.RGB:
+		components = 3
+	}
+	switch formatType {
+	case gls.HALF_FLOAT, gls.UNSIGNED_SHORT:
+		return components * 2
+	case gls.FLOAT, gls.UNSIGNED_INT:
+		return components * 4
+	}
+	return components
+}
@@ -0,0 +1,55 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"testing"
+
+	"github.com/thommil/tge-g3n/gls"
+)
+
+// Test the mip levels built for streaming a non square texture
+func TestMipLevels(t *testing.T) {
+
+	// 4x2 texels with red values 0, 40, 80, 120 on the first row and 160, 200, 240, 0 on the second
+	reds := []uint8{0, 40, 80, 120, 160, 200, 240, 0}
+	pix := make([]uint8, len(reds)*4)
+	for i, r := range reds {
+		pix[i*4] = r
+		pix[i*4+3] = 255
+	}
+	levels := mipLevels(pix, 4, 2)
+	if len(levels) != 3 {
+		t.Fatalf("levels count: got %d, expected 3", len(levels))
+	}
+	if len(levels[1]) != 2*1*4 || len(levels[2]) != 1*1*4 {
+		t.Fatalf("levels sizes: got %d and %d bytes", len(levels[1]), len(levels[2]))
+	}
+	if levels[1][0] != 100 || levels[1][4] != 110 || levels[1][3] != 255 {
+		t.Errorf("level 1: got %v", levels[1])
+	}
+	if levels[2][0] != 105 {
+		t.Errorf("level 2: got %v", levels[2])
+	}
+	if mipSize(4, 2) != 1 || mipSize(2, 2) != 1 || mipSize(1024, 3) != 128 {
+		t.Errorf("unexpected mip sizes")
+	}
+}
+
+// Test that a texture larger than the maximum texture size is downscaled to a mip level which fits
+func TestFitMaxSize(t *testing.T) {
+
+	rec := gls.NewRecorder()
+	rec.SetInteger(gls.MAX_TEXTURE_SIZE, 16)
+	gs, err := gls.NewWithBackend(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tex := NewTexture2DFromData(64, 8, gls.RGBA, gls.UNSIGNED_BYTE, gls.RGBA8, make([]uint8, 64*8*4))
+	tex.Upload(gs)
+	if tex.Width() != 16 || tex.Height() != 2 || len(tex.data.([]uint8)) != 16*2*4 {
+		t.Errorf("expected the 16x2 mip level got %dx%d", tex.Width(), tex.Height())
+	}
+}
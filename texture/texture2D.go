@@ -12,10 +12,12 @@ import (
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
+	"reflect"
 	"unsafe"
 
 	plugin "github.com/thommil/tge-g3n"
 	"github.com/thommil/tge-g3n/gls"
+	"github.com/thommil/tge-g3n/math32"
 )
 
 // Texture2D represents a texture
@@ -27,6 +29,7 @@ type Texture2D struct {
 	minFilter    uint32      // minification filter
 	wrapS        uint32      // wrap mode for s coordinate
 	wrapT        uint32      // wrap mode for t coordinate
+	anisotropy   float32     // maximum anisotropy of the filtering
 	iformat      int32       // internal format
 	width        int32       // texture width in pixels
 	height       int32       // texture height in pixels
@@ -35,7 +38,14 @@ type Texture2D struct {
 	updateData   bool        // texture data needs to be sent
 	updateParams bool        // texture parameters needs to be sent
 	genMipmap    bool        // generate mipmaps flag
+	premultAlpha bool        // premultiply colors by alpha on upload flag
 	data         interface{} // array with texture data
+	path         string      // image file path if loaded from a file
+	bytes        int         // estimated memory of the uploaded data in bytes
+	compressed   uint32      // compressed internal format of the uploaded data or 0
+	mips         [][]byte    // mip levels data from the largest if streamed
+	baseLevel    int         // largest mip level uploaded if streamed
+	lastDraw     uint64      // number of draw calls when last set up if streamed
 	uniUnit      gls.Uniform // Texture unit uniform location cache
 	uniInfo      gls.Uniform // Texture info uniform location cache
 	udata        struct {    // Combined uniform data in 3 vec2:
@@ -54,8 +64,9 @@ func newTexture2D() *Texture2D {
 	t.gs = nil
 	t.refcount = 1
 	t.texname = 0
-	t.magFilter = gls.LINEAR
-	t.minFilter = gls.LINEAR_MIPMAP_LINEAR
+	t.magFilter = defaultMagFilter
+	t.minFilter = defaultMinFilter
+	t.anisotropy = defaultAnisotropy
 	t.wrapS = gls.CLAMP_TO_EDGE
 	t.wrapT = gls.CLAMP_TO_EDGE
 	t.updateData = false
@@ -85,6 +96,7 @@ func NewTexture2DFromImage(imgfile string) (*Texture2D, error) {
 
 	t := newTexture2D()
 	t.SetFromRGBA(rgba)
+	t.path = imgfile
 	return t, nil
 }
 
@@ -104,6 +116,31 @@ func NewTexture2DFromData(width, height int, format int, formatType, iformat int
 	return t
 }
 
+// Equal returns whether the specified textures have the same data, parameters
+// and uniform names, such as the textures decoded twice from the same image.
+// The OpenGL state and the references count of the textures are not compared.
+func Equal(a, b *Texture2D) bool {
+
+	if a == b {
+		return true
+	}
+	ca, cb := *a, *b
+	for _, c := range []*Texture2D{&ca, &cb} {
+		c.gs = nil
+		c.refcount = 0
+		c.texname = 0
+		c.updateData = false
+		c.updateParams = false
+		c.path = ""
+		c.lastDraw = 0
+		unit, info := c.uniUnit.Name(), c.uniInfo.Name()
+		c.uniUnit, c.uniInfo = gls.Uniform{}, gls.Uniform{}
+		c.uniUnit.Init(unit)
+		c.uniInfo.Init(info)
+	}
+	return reflect.DeepEqual(&ca, &cb)
+}
+
 // Incref increments the reference count for this texture
 // and returns a pointer to the geometry.
 // It should be used when this texture is shared by another
@@ -124,6 +161,7 @@ func (t *Texture2D) Dispose() {
 		return
 	}
 	if t.gs != nil {
+		t.release()
 		t.gs.DeleteTextures(t.texname)
 		t.gs = nil
 	}
@@ -151,6 +189,7 @@ func (t *Texture2D) SetImage(imgfile string) error {
 		return err
 	}
 	t.SetFromRGBA(rgba)
+	t.path = imgfile
 	return nil
 }
 
@@ -176,9 +215,64 @@ func (t *Texture2D) SetData(width, height int, format int, formatType, iformat i
 	t.formatType = uint32(formatType)
 	t.iformat = int32(iformat)
 	t.data = data
+	t.path = ""
+	t.compressed = 0
 	t.updateData = true
 }
 
+// SetPremultipliedAlpha sets whether the texture colors are multiplied by their alpha
+// when the data is uploaded to OpenGL, which avoids the dark fringes around transparent
+// edges when blending and filtering. The original data is not modified. It must be used
+// with material.BlendingPremultiplied and applies only to RGBA data with UNSIGNED_BYTE type.
+func (t *Texture2D) SetPremultipliedAlpha(state bool) {
+
+	if t.premultAlpha != state {
+		t.premultAlpha = state
+		t.updateData = t.data != nil
+	}
+}
+
+// PremultipliedAlpha returns whether the texture colors are premultiplied by alpha on upload.
+func (t *Texture2D) PremultipliedAlpha() bool {
+
+	return t.premultAlpha
+}
+
+// uploadData returns the texture data to upload, premultiplied by alpha if requested.
+func (t *Texture2D) uploadData() interface{} {
+
+	if !t.premultAlpha {
+		return t.data
+	}
+	pix, ok := t.data.([]uint8)
+	if !ok || t.format != gls.RGBA || t.formatType != gls.UNSIGNED_BYTE {
+		fmt.Printf("WARNING : Texture2D premultiplied alpha requires RGBA unsigned byte data\n")
+		return t.data
+	}
+	premult := make([]uint8, len(pix))
+	for i := 0; i+3 < len(pix); i += 4 {
+		a := uint32(pix[i+3])
+		premult[i] = uint8((uint32(pix[i])*a + 127) / 255)
+		premult[i+1] = uint8((uint32(pix[i+1])*a + 127) / 255)
+		premult[i+2] = uint8((uint32(pix[i+2])*a + 127) / 255)
+		premult[i+3] = pix[i+3]
+	}
+	return premult
+}
+
+// TexName returns the OpenGL texture handle or 0 if not yet allocated.
+func (t *Texture2D) TexName() uint32 {
+
+	return t.texname
+}
+
+// Path returns the path of the image file this texture was loaded from
+// or an empty string if its data was not set from a file.
+func (t *Texture2D) Path() string {
+
+	return t.path
+}
+
 // SetVisible sets the visibility state of the texture
 func (t *Texture2D) SetVisible(state bool) {
 
@@ -200,7 +294,7 @@ func (t *Texture2D) Visible() bool {
 }
 
 // SetMagFilter sets the filter to be applied when the texture element
-// covers more than on pixel. The default value is gls.Linear.
+// covers more than on pixel. The default value is set by SetDefaultFiltering.
 func (t *Texture2D) SetMagFilter(magFilter uint32) {
 
 	t.magFilter = magFilter
@@ -208,13 +302,31 @@ func (t *Texture2D) SetMagFilter(magFilter uint32) {
 }
 
 // SetMinFilter sets the filter to be applied when the texture element
-// covers less than on pixel. The default value is gls.Linear.
+// covers less than on pixel. The default value is set by SetDefaultFiltering.
 func (t *Texture2D) SetMinFilter(minFilter uint32) {
 
 	t.minFilter = minFilter
 	t.updateParams = true
 }
 
+// SetAnisotropy sets the maximum anisotropy of the filtering, which improves the sharpness
+// of the texture seen at grazing angles, from 1 (disabled) to GLS.MaxAnisotropy.
+// The default value is set by SetDefaultFiltering.
+func (t *Texture2D) SetAnisotropy(anisotropy float32) {
+
+	if anisotropy < 1 {
+		panic("Texture2D.SetAnisotropy: anisotropy must be at least 1")
+	}
+	t.anisotropy = anisotropy
+	t.updateParams = true
+}
+
+// Anisotropy returns the maximum anisotropy of the filtering.
+func (t *Texture2D) Anisotropy() float32 {
+
+	return t.anisotropy
+}
+
 // SetWrapS set the wrapping mode for texture S coordinate
 // The default value is GL_CLAMP_TO_EDGE;
 func (t *Texture2D) SetWrapS(wrapS uint32) {
@@ -312,38 +424,88 @@ func DecodeImage(imgfile string) (*image.RGBA, error) {
 	return rgba, nil
 }
 
-// RenderSetup is called by the material render setup
-func (t *Texture2D) RenderSetup(gs *gls.GLS, slotIdx, uniIdx int) { // Could have as input - TEXTURE0 (slot) and uni location
+// upload transfers the texture data to the bound OpenGL texture,
+// streaming its mip levels if a texture memory budget is set.
+func (t *Texture2D) upload(gs *gls.GLS) {
+
+	streamed := t.mips != nil
+	t.release()
+	t.fitMaxSize(gs)
+	if t.compressed != 0 && gs.S3TCSupported() {
+		t.uploadCompressed(gs)
+		return
+	}
+	if t.streamable() {
+		t.uploadStreamed(gs)
+		return
+	}
+	// Restores all the mip levels of a previously streamed texture
+	if streamed {
+		gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_BASE_LEVEL, 0)
+		gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_MAX_LEVEL, 1000)
+	}
+	gs.TexImage2D(
+		gls.TEXTURE_2D, // texture type
+		0,              // level of detail
+		t.iformat,      // internal format
+		t.width,        // width in texels
+		t.height,       // height in texels
+		0,              // border must be 0
+		t.format,       // format of supplied texture data
+		t.formatType,   // type of external format color component
+		t.uploadData(), // image data
+	)
+	t.bytes = int(t.width) * int(t.height) * pixelSize(t.format, t.formatType)
+	// Generates mipmaps if requested
+	if t.genMipmap {
+		gs.GenerateMipmap(gls.TEXTURE_2D)
+		t.bytes = t.bytes * 4 / 3
+	}
+	gs.AddTextureBytes(t.bytes)
+}
+
+// fitMaxSize replaces the RGBA unsigned byte data of the texture larger than the maximum
+// texture size of the OpenGL implementation by its largest mip level which fits.
+func (t *Texture2D) fitMaxSize(gs *gls.GLS) {
+
+	max := int32(gs.Capabilities().MaxTextureSize)
+	if t.width <= max && t.height <= max {
+		return
+	}
+	pix, ok := t.data.([]uint8)
+	if !ok || t.format != gls.RGBA || t.formatType != gls.UNSIGNED_BYTE || len(pix) < int(t.width)*int(t.height)*4 {
+		fmt.Printf("WARNING : Texture2D %dx%d exceeds the %d maximum texture size\n", t.width, t.height, max)
+		return
+	}
+	level := 0
+	for mipSize(t.width, level) > max || mipSize(t.height, level) > max {
+		level++
+	}
+	width, height := mipSize(t.width, level), mipSize(t.height, level)
+	fmt.Printf("WARNING : Texture2D %dx%d exceeds the %d maximum texture size and is downscaled to %dx%d\n", t.width, t.height, max, width, height)
+	t.data = mipLevels(pix, int(t.width), int(t.height))[level]
+	t.width, t.height = width, height
+}
+
+// Upload binds the texture to the active texture unit, creating the OpenGL texture
+// if needed, and transfers the texture data and parameters not yet transferred.
+// It is called by RenderSetup and can be called beforehand on the render thread,
+// so that the data is not transferred at the first draw using the texture.
+func (t *Texture2D) Upload(gs *gls.GLS) {
 
 	// One time initialization
 	if t.gs == nil {
 		t.texname = gs.GenTexture()
 		t.gs = gs
 	}
-
-	// Sets the texture unit for this texture
-	gs.ActiveTexture(uint32(gls.TEXTURE0 + slotIdx))
 	gs.BindTexture(gls.TEXTURE_2D, t.texname)
 
 	// Transfer texture data to OpenGL if necessary
 	if t.updateData {
-		gs.TexImage2D(
-			gls.TEXTURE_2D, // texture type
-			0,              // level of detail
-			t.iformat,      // internal format
-			t.width,        // width in texels
-			t.height,       // height in texels
-			0,              // border must be 0
-			t.format,       // format of supplied texture data
-			t.formatType,   // type of external format color component
-			t.data,         // image data
-		)
-		// Generates mipmaps if requested
-		if t.genMipmap {
-			gs.GenerateMipmap(gls.TEXTURE_2D)
-		}
-		// No data to send
+		t.upload(gs)
 		t.updateData = false
+	} else if t.mips != nil {
+		t.streamLevel(gs)
 	}
 
 	// Sets texture parameters if needed
@@ -352,8 +514,19 @@ func (t *Texture2D) RenderSetup(gs *gls.GLS, slotIdx, uniIdx int) { // Could hav
 		gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_MIN_FILTER, int32(t.minFilter))
 		gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_WRAP_S, int32(t.wrapS))
 		gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_WRAP_T, int32(t.wrapT))
+		if max := gs.MaxAnisotropy(); max > 0 {
+			gs.TexParameterf(gls.TEXTURE_2D, gls.TEXTURE_MAX_ANISOTROPY_EXT, math32.Min(t.anisotropy, max))
+		}
 		t.updateParams = false
 	}
+}
+
+// RenderSetup is called by the material render setup
+func (t *Texture2D) RenderSetup(gs *gls.GLS, slotIdx, uniIdx int) { // Could have as input - TEXTURE0 (slot) and uni location
+
+	// Sets the texture unit for this texture
+	gs.ActiveTexture(uint32(gls.TEXTURE0 + slotIdx))
+	t.Upload(gs)
 
 	// Transfer texture unit uniform
 	var location int32
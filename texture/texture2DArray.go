@@ -0,0 +1,217 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+
+	"github.com/thommil/tge-g3n/gls"
+)
+
+// Texture2DArray represents an array of two-dimensional textures of the same size.
+// Each layer of the array is sampled in the shader with a sampler2DArray
+// using the layer index as the third texture coordinate.
+// Its data is only transferred if GLS.GL3Supported.
+type Texture2DArray struct {
+	gs           *gls.GLS    // Pointer to OpenGL state
+	refcount     int         // Current number of references
+	texname      uint32      // Texture handle
+	magFilter    uint32      // magnification filter
+	minFilter    uint32      // minification filter
+	wrapS        uint32      // wrap mode for s coordinate
+	wrapT        uint32      // wrap mode for t coordinate
+	width        int32       // layer width in pixels
+	height       int32       // layer height in pixels
+	layers       int32       // number of layers
+	updateData   bool        // texture data needs to be sent
+	updateParams bool        // texture parameters needs to be sent
+	genMipmap    bool        // generate mipmaps flag
+	data         []byte      // RGBA8 data of all layers
+	uniUnit      gls.Uniform // Texture unit uniform location cache
+}
+
+// NewTexture2DArray creates and returns a pointer to a new Texture2DArray
+// with one layer for each of the specified images.
+// All the images must be non nil and have the same size.
+func NewTexture2DArray(imgs []image.Image) (*Texture2DArray, error) {
+
+	if len(imgs) == 0 {
+		return nil, fmt.Errorf("texture array without images")
+	}
+	for i, img := range imgs {
+		if img == nil {
+			return nil, fmt.Errorf("texture array image %d is nil", i)
+		}
+	}
+	size := imgs[0].Bounds().Size()
+	layerBytes := size.X * size.Y * 4
+	data := make([]byte, layerBytes*len(imgs))
+	for i, img := range imgs {
+		if img.Bounds().Size() != size {
+			return nil, fmt.Errorf("texture array image %d size differs from first image", i)
+		}
+		// Converts image to RGBA format directly into the layer data
+		rgba := &image.RGBA{
+			Pix:    data[i*layerBytes : (i+1)*layerBytes],
+			Stride: size.X * 4,
+			Rect:   image.Rect(0, 0, size.X, size.Y),
+		}
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	}
+
+	t := new(Texture2DArray)
+	t.refcount = 1
+	t.magFilter = gls.LINEAR
+	t.minFilter = gls.LINEAR_MIPMAP_LINEAR
+	t.wrapS = gls.REPEAT
+	t.wrapT = gls.REPEAT
+	t.width = int32(size.X)
+	t.height = int32(size.Y)
+	t.layers = int32(len(imgs))
+	t.data = data
+	t.updateData = true
+	t.updateParams = true
+	t.genMipmap = true
+	t.uniUnit.Init("MatTextureArray")
+	return t, nil
+}
+
+// Incref increments the reference count for this texture
+// and returns a pointer to the texture.
+func (t *Texture2DArray) Incref() *Texture2DArray {
+
+	t.refcount++
+	return t
+}
+
+// Dispose decrements this texture reference count and
+// if necessary releases OpenGL resources associated with this texture.
+func (t *Texture2DArray) Dispose() {
+
+	if t.refcount > 1 {
+		t.refcount--
+		return
+	}
+	if t.gs != nil {
+		t.gs.DeleteTextures(t.texname)
+		t.gs = nil
+	}
+}
+
+// SetUniformName sets the name of the sampler uniform in the shader.
+func (t *Texture2DArray) SetUniformName(sampler string) {
+
+	t.uniUnit.Init(sampler)
+}
+
+// UniformName returns the name of the sampler uniform in the shader.
+func (t *Texture2DArray) UniformName() string {
+
+	return t.uniUnit.Name()
+}
+
+// SetMagFilter sets the filter to be applied when the texture element
+// covers more than on pixel. The default value is gls.Linear.
+func (t *Texture2DArray) SetMagFilter(magFilter uint32) {
+
+	t.magFilter = magFilter
+	t.updateParams = true
+}
+
+// SetMinFilter sets the filter to be applied when the texture element
+// covers less than on pixel. The default value is gls.LINEAR_MIPMAP_LINEAR.
+func (t *Texture2DArray) SetMinFilter(minFilter uint32) {
+
+	t.minFilter = minFilter
+	t.updateParams = true
+}
+
+// SetWrapS set the wrapping mode for texture S coordinate
+// The default value is GL_REPEAT;
+func (t *Texture2DArray) SetWrapS(wrapS uint32) {
+
+	t.wrapS = wrapS
+	t.updateParams = true
+}
+
+// SetWrapT set the wrapping mode for texture T coordinate
+// The default value is GL_REPEAT;
+func (t *Texture2DArray) SetWrapT(wrapT uint32) {
+
+	t.wrapT = wrapT
+	t.updateParams = true
+}
+
+// Width returns the width of the layers in pixels
+func (t *Texture2DArray) Width() int {
+
+	return int(t.width)
+}
+
+// Height returns the height of the layers in pixels
+func (t *Texture2DArray) Height() int {
+
+	return int(t.height)
+}
+
+// Layers returns the number of layers of this texture array
+func (t *Texture2DArray) Layers() int {
+
+	return int(t.layers)
+}
+
+// RenderSetup is called by the material render setup
+func (t *Texture2DArray) RenderSetup(gs *gls.GLS, slotIdx int) {
+
+	// One time initialization
+	if t.gs == nil {
+		t.texname = gs.GenTexture()
+		t.gs = gs
+	}
+
+	// Sets the texture unit for this texture
+	gs.ActiveTexture(uint32(gls.TEXTURE0 + slotIdx))
+	gs.BindTexture(gls.TEXTURE_2D_ARRAY, t.texname)
+
+	// Transfer texture data to OpenGL if necessary
+	if t.updateData {
+		if !gs.GL3Supported() {
+			// TexImage3D is not provided by tge-gl on OpenGL ES and WebGL
+			fmt.Printf("WARNING : Texture2DArray requires the OpenGL 3 functions (see GLS.GL3Supported)\n")
+		} else {
+			gs.TexImage3D(
+				gls.TEXTURE_2D_ARRAY, // texture type
+				0,                    // level of detail
+				gls.RGBA8,            // internal format
+				t.width,              // width in texels
+				t.height,             // height in texels
+				t.layers,             // number of layers
+				0,                    // border must be 0
+				gls.RGBA,             // format of supplied texture data
+				gls.UNSIGNED_BYTE,    // type of external format color component
+				t.data,               // image data
+			)
+			// Generates mipmaps if requested
+			if t.genMipmap {
+				gs.GenerateMipmap(gls.TEXTURE_2D_ARRAY)
+			}
+		}
+		t.updateData = false
+	}
+
+	// Sets texture parameters if needed
+	if t.updateParams {
+		gs.TexParameteri(gls.TEXTURE_2D_ARRAY, gls.TEXTURE_MAG_FILTER, int32(t.magFilter))
+		gs.TexParameteri(gls.TEXTURE_2D_ARRAY, gls.TEXTURE_MIN_FILTER, int32(t.minFilter))
+		gs.TexParameteri(gls.TEXTURE_2D_ARRAY, gls.TEXTURE_WRAP_S, int32(t.wrapS))
+		gs.TexParameteri(gls.TEXTURE_2D_ARRAY, gls.TEXTURE_WRAP_T, int32(t.wrapT))
+		t.updateParams = false
+	}
+
+	// Transfer texture unit uniform
+	gs.Uniform1i(t.uniUnit.Location(gs), int32(slotIdx))
+}
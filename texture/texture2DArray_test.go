@@ -0,0 +1,35 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"image"
+	"testing"
+)
+
+// Test the creation of texture arrays from valid and invalid images
+func TestNewTexture2DArray(t *testing.T) {
+
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	tex, err := NewTexture2DArray([]image.Image{img, img})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tex.Width() != 2 || tex.Height() != 2 || tex.Layers() != 2 {
+		t.Errorf("expected 2x2x2 got %dx%dx%d", tex.Width(), tex.Height(), tex.Layers())
+	}
+
+	invalid := [][]image.Image{
+		nil,
+		{nil},
+		{img, nil},
+		{img, image.NewRGBA(image.Rect(0, 0, 4, 4))},
+	}
+	for i, imgs := range invalid {
+		if _, err := NewTexture2DArray(imgs); err == nil {
+			t.Errorf("expected error for images %d", i)
+		}
+	}
+}
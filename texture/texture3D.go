@@ -0,0 +1,190 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"fmt"
+
+	"github.com/thommil/tge-g3n/gls"
+)
+
+// Texture3D represents a three-dimensional texture sampled
+// in the shader with a sampler3D, such as a color lookup table.
+// Its data is only transferred if GLS.GL3Supported.
+type Texture3D struct {
+	gs           *gls.GLS    // Pointer to OpenGL state
+	refcount     int         // Current number of references
+	texname      uint32      // Texture handle
+	magFilter    uint32      // magnification filter
+	minFilter    uint32      // minification filter
+	wrapS        uint32      // wrap mode for s coordinate
+	wrapT        uint32      // wrap mode for t coordinate
+	wrapR        uint32      // wrap mode for r coordinate
+	width        int32       // width in pixels
+	height       int32       // height in pixels
+	depth        int32       // depth in pixels
+	updateData   bool        // texture data needs to be sent
+	updateParams bool        // texture parameters needs to be sent
+	data         []byte      // RGBA8 data of all the slices
+	uniUnit      gls.Uniform // Texture unit uniform location cache
+}
+
+// NewTexture3D creates and returns a pointer to a new Texture3D with the specified
+// size and RGBA8 data ordered by slice, row and column.
+// The texture is linearly filtered without mipmaps and clamped to its edges.
+func NewTexture3D(width, height, depth int, data []byte) *Texture3D {
+
+	if len(data) != width*height*depth*4 {
+		panic("NewTexture3D: data length does not match the texture size")
+	}
+	t := new(Texture3D)
+	t.refcount = 1
+	t.magFilter = gls.LINEAR
+	t.minFilter = gls.LINEAR
+	t.wrapS = gls.CLAMP_TO_EDGE
+	t.wrapT = gls.CLAMP_TO_EDGE
+	t.wrapR = gls.CLAMP_TO_EDGE
+	t.width = int32(width)
+	t.height = int32(height)
+	t.depth = int32(depth)
+	t.data = data
+	t.updateData = true
+	t.updateParams = true
+	t.uniUnit.Init("MatTexture3D")
+	return t
+}
+
+// Incref increments the reference count for this texture
+// and returns a pointer to the texture.
+func (t *Texture3D) Incref() *Texture3D {
+
+	t.refcount++
+	return t
+}
+
+// Dispose decrements this texture reference count and
+// if necessary releases OpenGL resources associated with this texture.
+func (t *Texture3D) Dispose() {
+
+	if t.refcount > 1 {
+		t.refcount--
+		return
+	}
+	if t.gs != nil {
+		t.gs.DeleteTextures(t.texname)
+		t.gs = nil
+	}
+}
+
+// SetUniformName sets the name of the sampler uniform in the shader.
+func (t *Texture3D) SetUniformName(sampler string) {
+
+	t.uniUnit.Init(sampler)
+}
+
+// UniformName returns the name of the sampler uniform in the shader.
+func (t *Texture3D) UniformName() string {
+
+	return t.uniUnit.Name()
+}
+
+// SetMagFilter sets the filter to be applied when the texture element
+// covers more than on pixel. The default value is gls.Linear.
+func (t *Texture3D) SetMagFilter(magFilter uint32) {
+
+	t.magFilter = magFilter
+	t.updateParams = true
+}
+
+// SetMinFilter sets the filter to be applied when the texture element
+// covers less than on pixel. The default value is gls.Linear.
+func (t *Texture3D) SetMinFilter(minFilter uint32) {
+
+	t.minFilter = minFilter
+	t.updateParams = true
+}
+
+// SetWrap sets the wrapping mode of the three texture coordinates.
+// The default value is gls.CLAMP_TO_EDGE.
+func (t *Texture3D) SetWrap(wrap uint32) {
+
+	t.wrapS = wrap
+	t.wrapT = wrap
+	t.wrapR = wrap
+	t.updateParams = true
+}
+
+// Width returns the width of the texture in pixels
+func (t *Texture3D) Width() int {
+
+	return int(t.width)
+}
+
+// Height returns the height of the texture in pixels
+func (t *Texture3D) Height() int {
+
+	return int(t.height)
+}
+
+// Depth returns the depth of the texture in pixels
+func (t *Texture3D) Depth() int {
+
+	return int(t.depth)
+}
+
+// Data returns the RGBA8 data of the texture.
+func (t *Texture3D) Data() []byte {
+
+	return t.data
+}
+
+// RenderSetup is called by the material render setup
+func (t *Texture3D) RenderSetup(gs *gls.GLS, slotIdx int) {
+
+	// One time initialization
+	if t.gs == nil {
+		t.texname = gs.GenTexture()
+		t.gs = gs
+	}
+
+	// Sets the texture unit for this texture
+	gs.ActiveTexture(uint32(gls.TEXTURE0 + slotIdx))
+	gs.BindTexture(gls.TEXTURE_3D, t.texname)
+
+	// Transfer texture data to OpenGL if necessary
+	if t.updateData {
+		if !gs.GL3Supported() {
+			// TexImage3D is not provided by tge-gl on OpenGL ES and WebGL
+			fmt.Printf("WARNING : Texture3D requires the OpenGL 3 functions (see GLS.GL3Supported)\n")
+		} else {
+			gs.TexImage3D(
+				gls.TEXTURE_3D,    // texture type
+				0,                 // level of detail
+				gls.RGBA8,         // internal format
+				t.width,           // width in texels
+				t.height,          // height in texels
+				t.depth,           // depth in texels
+				0,                 // border must be 0
+				gls.RGBA,          // format of supplied texture data
+				gls.UNSIGNED_BYTE, // type of external format color component
+				t.data,            // image data
+			)
+		}
+		t.updateData = false
+	}
+
+	// Sets texture parameters if needed
+	if t.updateParams {
+		gs.TexParameteri(gls.TEXTURE_3D, gls.TEXTURE_MAG_FILTER, int32(t.magFilter))
+		gs.TexParameteri(gls.TEXTURE_3D, gls.TEXTURE_MIN_FILTER, int32(t.minFilter))
+		gs.TexParameteri(gls.TEXTURE_3D, gls.TEXTURE_WRAP_S, int32(t.wrapS))
+		gs.TexParameteri(gls.TEXTURE_3D, gls.TEXTURE_WRAP_T, int32(t.wrapT))
+		gs.TexParameteri(gls.TEXTURE_3D, gls.TEXTURE_WRAP_R, int32(t.wrapR))
+		t.updateParams = false
+	}
+
+	// Transfer texture unit uniform
+	gs.Uniform1i(t.uniUnit.Location(gs), int32(slotIdx))
+}
@@ -0,0 +1,185 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/thommil/tge-g3n/gls"
+)
+
+// TextureCube represents a cube map texture with 6 square faces in the
+// order +X, -X, +Y, -Y, +Z, -Z. It is sampled in the shader with a samplerCube.
+type TextureCube struct {
+	gs           *gls.GLS    // Pointer to OpenGL state
+	refcount     int         // Current number of references
+	texname      uint32      // Texture handle
+	magFilter    uint32      // magnification filter
+	minFilter    uint32      // minification filter
+	size         int32       // faces width and height in pixels
+	levels       int32       // number of allocated mip levels of render targets
+	updateData   bool        // texture data needs to be sent
+	updateParams bool        // texture parameters needs to be sent
+	genMipmap    bool        // generate mipmaps flag
+	faces        [6][]byte   // RGBA8 data of each face (nil for render targets)
+	uniUnit      gls.Uniform // Texture unit uniform location cache
+}
+
+// NewTextureCube creates and returns a pointer to a new empty TextureCube
+// with the specified faces size, normally used as a render target.
+func NewTextureCube(size int) *TextureCube {
+
+	t := new(TextureCube)
+	t.refcount = 1
+	t.magFilter = gls.LINEAR
+	t.minFilter = gls.LINEAR
+	t.size = int32(size)
+	t.levels = 1
+	t.updateData = true
+	t.updateParams = true
+	t.uniUnit.Init("MatEnvMap")
+	return t
+}
+
+// NewTextureCubeFromRGBA creates and returns a pointer to a new TextureCube
+// from the specified square face images in the order +X, -X, +Y, -Y, +Z, -Z.
+func NewTextureCubeFromRGBA(faces [6]*image.RGBA) (*TextureCube, error) {
+
+	size := faces[0].Rect.Size()
+	if size.X != size.Y {
+		return nil, fmt.Errorf("cube map faces must be square")
+	}
+	t := NewTextureCube(size.X)
+	for i, face := range faces {
+		if face.Rect.Size() != size || face.Stride != size.X*4 {
+			return nil, fmt.Errorf("cube map face %d size differs from first face", i)
+		}
+		t.faces[i] = face.Pix
+	}
+	t.minFilter = gls.LINEAR_MIPMAP_LINEAR
+	t.genMipmap = true
+	return t, nil
+}
+
+// Incref increments the reference count for this texture
+// and returns a pointer to the texture.
+func (t *TextureCube) Incref() *TextureCube {
+
+	t.refcount++
+	return t
+}
+
+// Dispose decrements this texture reference count and
+// if necessary releases OpenGL resources associated with this texture.
+func (t *TextureCube) Dispose() {
+
+	if t.refcount > 1 {
+		t.refcount--
+		return
+	}
+	if t.gs != nil {
+		t.gs.DeleteTextures(t.texname)
+		t.gs = nil
+	}
+}
+
+// SetUniformName sets the name of the sampler uniform in the shader.
+func (t *TextureCube) SetUniformName(sampler string) {
+
+	t.uniUnit.Init(sampler)
+}
+
+// UniformName returns the name of the sampler uniform in the shader.
+func (t *TextureCube) UniformName() string {
+
+	return t.uniUnit.Name()
+}
+
+// SetMagFilter sets the filter to be applied when the texture element
+// covers more than on pixel. The default value is gls.Linear.
+func (t *TextureCube) SetMagFilter(magFilter uint32) {
+
+	t.magFilter = magFilter
+	t.updateParams = true
+}
+
+// SetMinFilter sets the filter to be applied when the texture element
+// covers less than on pixel. The default value is gls.Linear.
+func (t *TextureCube) SetMinFilter(minFilter uint32) {
+
+	t.minFilter = minFilter
+	t.updateParams = true
+}
+
+// Size returns the width and height of the faces in pixels
+func (t *TextureCube) Size() int {
+
+	return int(t.size)
+}
+
+// Levels returns the number of mip levels allocated for a render target cube map,
+// whose levels are rendered to instead of being generated. It is 1 otherwise.
+func (t *TextureCube) Levels() int {
+
+	return int(t.levels)
+}
+
+// TexName returns the OpenGL texture handle or 0 if not yet allocated.
+func (t *TextureCube) TexName() uint32 {
+
+	return t.texname
+}
+
+// bind creates the texture if necessary, binds it to the current
+// texture unit and transfers its data and parameters if needed.
+func (t *TextureCube) bind(gs *gls.GLS) {
+
+	// One time initialization
+	if t.gs == nil {
+		t.texname = gs.GenTexture()
+		t.gs = gs
+	}
+	gs.BindTexture(gls.TEXTURE_CUBE_MAP, t.texname)
+
+	// Transfer faces data to OpenGL if necessary
+	if t.updateData {
+		for i := 0; i < 6; i++ {
+			gs.TexImage2D(uint32(gls.TEXTURE_CUBE_MAP_POSITIVE_X+i), 0, gls.RGBA8, t.size, t.size, 0, gls.RGBA, gls.UNSIGNED_BYTE, t.faces[i])
+			for l := int32(1); l < t.levels; l++ {
+				size := t.size >> uint(l)
+				gs.TexImage2D(uint32(gls.TEXTURE_CUBE_MAP_POSITIVE_X+i), l, gls.RGBA8, size, size, 0, gls.RGBA, gls.UNSIGNED_BYTE, nil)
+			}
+		}
+		if t.levels > 1 {
+			gs.TexParameteri(gls.TEXTURE_CUBE_MAP, gls.TEXTURE_MAX_LEVEL, t.levels-1)
+		}
+		if t.genMipmap {
+			gs.GenerateMipmap(gls.TEXTURE_CUBE_MAP)
+		}
+		t.updateData = false
+	}
+
+	// Sets texture parameters if needed
+	if t.updateParams {
+		gs.TexParameteri(gls.TEXTURE_CUBE_MAP, gls.TEXTURE_MAG_FILTER, int32(t.magFilter))
+		gs.TexParameteri(gls.TEXTURE_CUBE_MAP, gls.TEXTURE_MIN_FILTER, int32(t.minFilter))
+		gs.TexParameteri(gls.TEXTURE_CUBE_MAP, gls.TEXTURE_WRAP_S, gls.CLAMP_TO_EDGE)
+		gs.TexParameteri(gls.TEXTURE_CUBE_MAP, gls.TEXTURE_WRAP_T, gls.CLAMP_TO_EDGE)
+		gs.TexParameteri(gls.TEXTURE_CUBE_MAP, gls.TEXTURE_WRAP_R, gls.CLAMP_TO_EDGE)
+		t.updateParams = false
+	}
+}
+
+// RenderSetup is called by the material render setup
+func (t *TextureCube) RenderSetup(gs *gls.GLS, slotIdx int) {
+
+	// Sets the texture unit for this texture
+	gs.ActiveTexture(uint32(gls.TEXTURE0 + slotIdx))
+	t.bind(gs)
+
+	// Transfer texture unit uniform
+	gs.Uniform1i(t.uniUnit.Location(gs), int32(slotIdx))
+}
@@ -7,7 +7,7 @@
 // Also it builds maps associating include and shader names to its respective
 // source strings.
 // Usage:
-// 		g3nshaders -in=<input_dir> -out<output_gofile> -v
+// 		g3nshaders -in=<input_dir> -out<output_gofile> [-build=<constraint> ...] -v
 // It is normally invoked by "go generate" inside the "shaders" directory
 package main
 
@@ -20,6 +20,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 )
@@ -40,7 +41,9 @@ const (
 // TEMPLATE is a Go template to generate the output file with the shaders' sources and
 // maps describing the include and shader names and programs shaders.
 //
-const TEMPLATE = `// Code generated by G3NSHADERS. DO NOT EDIT.
+const TEMPLATE = `{{range .Build}}// +build {{.}}
+{{end}}{{if .Build}}
+{{end}}// Code generated by G3NSHADERS. DO NOT EDIT.
 // To regenerate this file install 'g3nshaders' and execute:
 // 'go generate' in this folder.
 
@@ -80,8 +83,30 @@ var (
 	oOut     = flag.String("out", "sources.go", "Go output file")
 	oPackage = flag.String("pkg", "shaders", "Package name")
 	oVerbose = flag.Bool("v", false, "Show files being processed")
+	oBuild   buildFlags
 )
 
+func init() {
+
+	flag.Var(&oBuild, "build", "Build constraint of the Go output file (can be repeated)")
+}
+
+// buildFlags is the list of the values of the repeated build option
+type buildFlags []string
+
+// String returns the build constraints separated by commas
+func (b *buildFlags) String() string {
+
+	return strings.Join(*b, ",")
+}
+
+// Set appends a build constraint
+func (b *buildFlags) Set(value string) error {
+
+	*b = append(*b, value)
+	return nil
+}
+
 // Valid shader types
 var shaderTypes = map[string]bool{
 	TYPE_VERTEX:   true,
@@ -108,6 +133,7 @@ type progInfo struct {
 type templInfo struct {
 	Count    int                 // number of shader files processed
 	Pkg      string              // name of the package for the generated output file
+	Build    []string            // build constraints of the generated output file
 	Includes []fileInfo          // list of include files found
 	Shaders  []fileInfo          // list of shader files found
 	Programs map[string]progInfo // map of shader programs found
@@ -133,6 +159,7 @@ func main() {
 
 	// Initialize template data
 	templData.Pkg = *oPackage
+	templData.Build = oBuild
 	templData.Programs = make(map[string]progInfo)
 
 	// Process the current directory and its subdirectories recursively
@@ -143,6 +170,17 @@ func main() {
 		return
 	}
 
+	// Programs without vertex or fragment shader are not registered, their
+	// shaders can be used by programs added with AddProgram
+	for name, pinfo := range templData.Programs {
+		if pinfo.Vertex == "" || pinfo.Fragment == "" {
+			if *oVerbose {
+				logger.Printf("Incomplete program not registered: %s", name)
+			}
+			delete(templData.Programs, name)
+		}
+	}
+
 	// Generates output file from TEMPLATE
 	generate(*oOut)
 }
@@ -157,11 +195,12 @@ func processDir(dir string, include bool) {
 	}
 	defer f.Close()
 
-	// Read all file entries from the directory
+	// Read all file entries from the directory in name order
 	finfos, err := f.Readdir(0)
 	if err != nil {
 		panic(err)
 	}
+	sort.Slice(finfos, func(i, j int) bool { return finfos[i].Name() < finfos[j].Name() })
 
 	// Process all directory entries.
 	for _, fi := range finfos {
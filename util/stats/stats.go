@@ -13,6 +13,8 @@ type Stats struct {
 	UnilocMiss   int       // Uniform location cache misses per frame
 	Unisets      int       // Uniform sets per frame
 	Drawcalls    int       // Draw calls per frame
+	Triangles    int       // Triangles drawn per frame
+	Vertices     int       // Vertices drawn per frame
 	Cgocalls     int       // Cgo calls per frame
 	prevGls      gls.Stats // previous gls statistics
 	prevCgocalls int64     // previous number of cgo calls
@@ -61,6 +63,12 @@ func (s *Stats) Update(d time.Duration) bool {
 	drawcalls := s.Glstats.Drawcalls - s.prevGls.Drawcalls
 	s.Drawcalls = int(float64(drawcalls) / float64(s.frames))
 
+	// Calculates triangles and vertices drawn per frame
+	triangles := s.Glstats.Triangles - s.prevGls.Triangles
+	s.Triangles = int(float64(triangles) / float64(s.frames))
+	vertices := s.Glstats.Vertices - s.prevGls.Vertices
+	s.Vertices = int(float64(vertices) / float64(s.frames))
+
 	// Calculates number of cgo calls per frame
 	current := runtime.NumCgoCall()
 	cgocalls := current - s.prevCgocalls
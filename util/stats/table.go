@@ -38,6 +38,8 @@ func NewStatsTable(width, height float32, gs *gls.GLS) *StatsTable {
 	st.addRow("textures", "Textures:")
 	st.addRow("unisets", "Uniforms/frame:")
 	st.addRow("drawcalls", "Draw calls/frame:")
+	st.addRow("triangles", "Triangles/frame:")
+	st.addRow("vertices", "Vertices/frame:")
 	st.addRow("cgocalls", "CGO calls/frame:")
 	return st
 }
@@ -60,6 +62,10 @@ func (st *StatsTable) Update(s *Stats) {
 			st.Table.SetCell(f.row, "v", s.Unisets)
 		case "drawcalls":
 			st.Table.SetCell(f.row, "v", s.Drawcalls)
+		case "triangles":
+			st.Table.SetCell(f.row, "v", s.Triangles)
+		case "vertices":
+			st.Table.SetCell(f.row, "v", s.Vertices)
 		case "cgocalls":
 			st.Table.SetCell(f.row, "v", s.Cgocalls)
 		}